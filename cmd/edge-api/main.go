@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/TeaOSLab/EdgeAPI/internal/apps"
+	"github.com/TeaOSLab/EdgeAPI/internal/configs"
 	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
 	"github.com/TeaOSLab/EdgeAPI/internal/nodes"
 	"github.com/TeaOSLab/EdgeAPI/internal/setup"
@@ -13,6 +14,7 @@ import (
 	"github.com/iwind/TeaGo/maps"
 	"log"
 	"os"
+	"strconv"
 )
 
 func main() {
@@ -22,7 +24,7 @@ func main() {
 	app := apps.NewAppCmd()
 	app.Version(teaconst.Version)
 	app.Product(teaconst.ProductName)
-	app.Usage(teaconst.ProcessName + " [start|stop|restart|setup|upgrade|service|daemon]")
+	app.Usage(teaconst.ProcessName + " [start|stop|restart|reload|setup|upgrade|service|daemon|secret-key|rotate-secrets]")
 	app.On("setup", func() {
 		setupCmd := setup.NewSetupFromCmd()
 		err := setupCmd.Run()
@@ -57,6 +59,28 @@ func main() {
 		}
 		fmt.Println("finished!")
 	})
+	app.On("secret-key", func() {
+		key, err := configs.GenerateSecretKey()
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+			return
+		}
+		fmt.Println(key)
+	})
+	app.On("rotate-secrets", func() {
+		fmt.Println("start ...")
+		rotator, err := setup.NewSecretRotatorFromCmd()
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+			return
+		}
+		count, err := rotator.Run()
+		if err != nil {
+			fmt.Println("ERROR: " + err.Error())
+			return
+		}
+		fmt.Println("finished, " + strconv.FormatInt(count, 10) + " rows updated")
+	})
 	app.On("daemon", func() {
 		nodes.NewAPINode().Daemon()
 	})
@@ -0,0 +1,64 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"errors"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+)
+
+// 日志格式
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatLogfmt   = "logfmt"
+	FormatCombined = "combined" // Apache combined格式
+	FormatTemplate = "template" // 自定义模板
+)
+
+// FormatConfig 日志格式配置
+type FormatConfig struct {
+	Type     string   `yaml:"type" json:"type"`         // 格式类型
+	Fields   []string `yaml:"fields" json:"fields"`     // 字段列表，用于CSV和logfmt
+	Template string   `yaml:"template" json:"template"` // 自定义模板，比如 ${remoteAddr} ${requestPath}
+}
+
+// EncoderInterface 日志编码器接口
+type EncoderInterface interface {
+	// Encode 对单条日志进行编码，结果中不包含换行符
+	Encode(accessLog *pb.HTTPAccessLog) ([]byte, error)
+}
+
+// FindAllFormats 所有支持的格式
+func FindAllFormats() []string {
+	return []string{FormatJSON, FormatCSV, FormatLogfmt, FormatCombined, FormatTemplate}
+}
+
+// NewEncoder 根据配置获取编码器
+func NewEncoder(config *FormatConfig) (EncoderInterface, error) {
+	if config == nil {
+		return NewJSONEncoder(), nil
+	}
+
+	var fields = config.Fields
+	if len(fields) == 0 {
+		fields = DefaultAccessLogFields
+	}
+
+	switch config.Type {
+	case "", FormatJSON:
+		return NewJSONEncoder(), nil
+	case FormatCSV:
+		return NewCSVEncoder(fields), nil
+	case FormatLogfmt:
+		return NewLogfmtEncoder(fields), nil
+	case FormatCombined:
+		return NewCombinedEncoder(), nil
+	case FormatTemplate:
+		if len(config.Template) == 0 {
+			return nil, errors.New("'template' should not be empty")
+		}
+		return NewTemplateEncoder(config.Template), nil
+	}
+	return nil, errors.New("invalid format '" + config.Type + "'")
+}
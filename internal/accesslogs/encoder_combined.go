@@ -0,0 +1,65 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CombinedEncoder Apache combined格式编码器
+// %h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i"
+type CombinedEncoder struct {
+}
+
+func NewCombinedEncoder() *CombinedEncoder {
+	return &CombinedEncoder{}
+}
+
+func (this *CombinedEncoder) Encode(accessLog *pb.HTTPAccessLog) ([]byte, error) {
+	var timeLocal = accessLog.TimeLocal
+	if len(timeLocal) == 0 {
+		var t = time.Now()
+		if accessLog.Timestamp > 0 {
+			t = time.Unix(accessLog.Timestamp, 0)
+		}
+		timeLocal = t.Format("2/Jan/2006:15:04:05 -0700")
+	}
+
+	var bodyBytesSent = "-"
+	if accessLog.BodyBytesSent > 0 {
+		bodyBytesSent = strconv.FormatInt(int64(accessLog.BodyBytesSent), 10)
+	}
+
+	var builder = &strings.Builder{}
+	builder.WriteString(this.dash(accessLog.RemoteAddr))
+	builder.WriteString(" - ")
+	builder.WriteString(this.dash(accessLog.RemoteUser))
+	builder.WriteString(" [")
+	builder.WriteString(timeLocal)
+	builder.WriteString("] \"")
+	builder.WriteString(this.escape(accessLog.RequestMethod + " " + accessLog.RequestURI + " " + accessLog.Proto))
+	builder.WriteString("\" ")
+	builder.WriteString(strconv.FormatInt(int64(accessLog.Status), 10))
+	builder.WriteString(" ")
+	builder.WriteString(bodyBytesSent)
+	builder.WriteString(" \"")
+	builder.WriteString(this.escape(this.dash(accessLog.Referer)))
+	builder.WriteString("\" \"")
+	builder.WriteString(this.escape(this.dash(accessLog.UserAgent)))
+	builder.WriteString("\"")
+	return []byte(builder.String()), nil
+}
+
+func (this *CombinedEncoder) dash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
+
+func (this *CombinedEncoder) escape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(s)
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+)
+
+// CSVEncoder CSV编码器
+type CSVEncoder struct {
+	fields []string
+}
+
+func NewCSVEncoder(fields []string) *CSVEncoder {
+	return &CSVEncoder{fields: fields}
+}
+
+func (this *CSVEncoder) Encode(accessLog *pb.HTTPAccessLog) ([]byte, error) {
+	var record = make([]string, 0, len(this.fields))
+	for _, field := range this.fields {
+		value, _ := AccessLogFieldValue(accessLog, field)
+		record = append(record, value)
+	}
+
+	var buf = &bytes.Buffer{}
+	var writer = csv.NewWriter(buf)
+	err := writer.Write(record)
+	if err != nil {
+		return nil, err
+	}
+	writer.Flush()
+	err = writer.Error()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/types"
+	"strconv"
+	"strings"
+)
+
+// DefaultAccessLogFields 默认输出的字段
+var DefaultAccessLogFields = []string{
+	"timeISO8601",
+	"requestId",
+	"serverId",
+	"remoteAddr",
+	"requestMethod",
+	"host",
+	"requestURI",
+	"proto",
+	"status",
+	"bytesSent",
+	"requestTime",
+	"referer",
+	"userAgent",
+}
+
+// AccessLogFieldValue 读取日志中某个字段的值
+// 支持 header.NAME、sentHeader.NAME、cookie.NAME 形式读取单个头部信息和Cookie
+func AccessLogFieldValue(accessLog *pb.HTTPAccessLog, field string) (value string, ok bool) {
+	if strings.HasPrefix(field, "header.") {
+		return headerValue(accessLog.Header, field[len("header."):]), true
+	}
+	if strings.HasPrefix(field, "sentHeader.") {
+		return headerValue(accessLog.SentHeader, field[len("sentHeader."):]), true
+	}
+	if strings.HasPrefix(field, "cookie.") {
+		return accessLog.Cookie[field[len("cookie."):]], true
+	}
+
+	switch field {
+	case "requestId":
+		return accessLog.RequestId, true
+	case "serverId":
+		return types.String(accessLog.ServerId), true
+	case "nodeId":
+		return types.String(accessLog.NodeId), true
+	case "locationId":
+		return types.String(accessLog.LocationId), true
+	case "originId":
+		return types.String(accessLog.OriginId), true
+	case "remoteAddr":
+		return accessLog.RemoteAddr, true
+	case "rawRemoteAddr":
+		return accessLog.RawRemoteAddr, true
+	case "remotePort":
+		return types.String(accessLog.RemotePort), true
+	case "remoteUser":
+		return accessLog.RemoteUser, true
+	case "requestURI":
+		return accessLog.RequestURI, true
+	case "requestPath":
+		return accessLog.RequestPath, true
+	case "requestLength":
+		return types.String(accessLog.RequestLength), true
+	case "requestTime":
+		return strconv.FormatFloat(accessLog.RequestTime, 'f', -1, 64), true
+	case "requestMethod":
+		return accessLog.RequestMethod, true
+	case "requestFilename":
+		return accessLog.RequestFilename, true
+	case "scheme":
+		return accessLog.Scheme, true
+	case "proto":
+		return accessLog.Proto, true
+	case "bytesSent":
+		return types.String(accessLog.BytesSent), true
+	case "bodyBytesSent":
+		return types.String(accessLog.BodyBytesSent), true
+	case "status":
+		return types.String(accessLog.Status), true
+	case "statusMessage":
+		return accessLog.StatusMessage, true
+	case "timeISO8601":
+		return accessLog.TimeISO8601, true
+	case "timeLocal":
+		return accessLog.TimeLocal, true
+	case "msec":
+		return strconv.FormatFloat(accessLog.Msec, 'f', 3, 64), true
+	case "timestamp":
+		return types.String(accessLog.Timestamp), true
+	case "host":
+		return accessLog.Host, true
+	case "referer":
+		return accessLog.Referer, true
+	case "userAgent":
+		return accessLog.UserAgent, true
+	case "request":
+		return accessLog.Request, true
+	case "contentType":
+		return accessLog.ContentType, true
+	case "args":
+		return accessLog.Args, true
+	case "queryString":
+		return accessLog.QueryString, true
+	case "serverName":
+		return accessLog.ServerName, true
+	case "serverPort":
+		return types.String(accessLog.ServerPort), true
+	case "serverProtocol":
+		return accessLog.ServerProtocol, true
+	case "hostname":
+		return accessLog.Hostname, true
+	case "originAddress":
+		return accessLog.OriginAddress, true
+	case "errors":
+		return strings.Join(accessLog.Errors, "; "), true
+	case "firewallPolicyId":
+		return types.String(accessLog.FirewallPolicyId), true
+	case "firewallRuleGroupId":
+		return types.String(accessLog.FirewallRuleGroupId), true
+	case "firewallRuleSetId":
+		return types.String(accessLog.FirewallRuleSetId), true
+	case "firewallRuleId":
+		return types.String(accessLog.FirewallRuleId), true
+	}
+	return "", false
+}
+
+func headerValue(header map[string]*pb.Strings, name string) string {
+	for k, v := range header {
+		if v != nil && strings.EqualFold(k, name) {
+			return strings.Join(v.Values, ", ")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"bytes"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"strconv"
+	"strings"
+)
+
+// LogfmtEncoder logfmt编码器，格式为 key1=value1 key2="value 2"
+type LogfmtEncoder struct {
+	fields []string
+}
+
+func NewLogfmtEncoder(fields []string) *LogfmtEncoder {
+	return &LogfmtEncoder{fields: fields}
+}
+
+func (this *LogfmtEncoder) Encode(accessLog *pb.HTTPAccessLog) ([]byte, error) {
+	var buf = &bytes.Buffer{}
+	for index, field := range this.fields {
+		value, _ := AccessLogFieldValue(accessLog, field)
+		if index > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(field)
+		buf.WriteByte('=')
+		if len(value) == 0 || strings.ContainsAny(value, " =\"\t\r\n") {
+			buf.WriteString(strconv.Quote(value))
+		} else {
+			buf.WriteString(value)
+		}
+	}
+	return buf.Bytes(), nil
+}
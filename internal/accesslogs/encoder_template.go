@@ -0,0 +1,28 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/configutils"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+)
+
+// TemplateEncoder 自定义模板编码器
+// 模板中可以使用 ${field} 变量，比如 ${remoteAddr} ${requestMethod} ${header.User-Agent}
+type TemplateEncoder struct {
+	template string
+}
+
+func NewTemplateEncoder(template string) *TemplateEncoder {
+	return &TemplateEncoder{template: template}
+}
+
+func (this *TemplateEncoder) Encode(accessLog *pb.HTTPAccessLog) ([]byte, error) {
+	return []byte(configutils.ParseVariables(this.template, func(varName string) (value string) {
+		value, ok := AccessLogFieldValue(accessLog, varName)
+		if !ok {
+			return "${" + varName + "}"
+		}
+		return value
+	})), nil
+}
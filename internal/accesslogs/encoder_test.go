@@ -0,0 +1,97 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"testing"
+)
+
+func testEncoderAccessLog() *pb.HTTPAccessLog {
+	return &pb.HTTPAccessLog{
+		RequestId:     "abc",
+		ServerId:      1,
+		RemoteAddr:    "127.0.0.1",
+		RequestMethod: "GET",
+		RequestURI:    "/hello?name=world",
+		RequestPath:   "/hello",
+		Proto:         "HTTP/1.1",
+		Status:        200,
+		BodyBytesSent: 1024,
+		TimeLocal:     "23/Jul/2018:22:23:35 +0800",
+		Referer:       "https://example.com/",
+		UserAgent:     "Mozilla/5.0 \"Test\"",
+		Header: map[string]*pb.Strings{
+			"Content-Type": {Values: []string{"text/html"}},
+		},
+		Cookie: map[string]string{
+			"sid": "123",
+		},
+	}
+}
+
+func TestNewEncoder(t *testing.T) {
+	for _, format := range FindAllFormats() {
+		encoder, err := NewEncoder(&FormatConfig{
+			Type:     format,
+			Template: "${remoteAddr}",
+		})
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		data, err := encoder.Encode(testEncoderAccessLog())
+		if err != nil {
+			t.Fatal(format, err)
+		}
+		t.Log(format+":", string(data))
+	}
+
+	_, err := NewEncoder(&FormatConfig{Type: "xml"})
+	if err == nil {
+		t.Fatal("invalid format should be rejected")
+	}
+}
+
+func TestCombinedEncoder_Encode(t *testing.T) {
+	data, err := NewCombinedEncoder().Encode(testEncoderAccessLog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = `127.0.0.1 - - [23/Jul/2018:22:23:35 +0800] "GET /hello?name=world HTTP/1.1" 200 1024 "https://example.com/" "Mozilla/5.0 \"Test\""`
+	if string(data) != expected {
+		t.Fatal("expect:", expected, "got:", string(data))
+	}
+}
+
+func TestCSVEncoder_Encode(t *testing.T) {
+	data, err := NewCSVEncoder([]string{"remoteAddr", "status", "userAgent"}).Encode(testEncoderAccessLog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = `127.0.0.1,200,"Mozilla/5.0 ""Test"""`
+	if string(data) != expected {
+		t.Fatal("expect:", expected, "got:", string(data))
+	}
+}
+
+func TestLogfmtEncoder_Encode(t *testing.T) {
+	data, err := NewLogfmtEncoder([]string{"remoteAddr", "status", "referer", "remoteUser"}).Encode(testEncoderAccessLog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = `remoteAddr=127.0.0.1 status=200 referer=https://example.com/ remoteUser=""`
+	if string(data) != expected {
+		t.Fatal("expect:", expected, "got:", string(data))
+	}
+}
+
+func TestTemplateEncoder_Encode(t *testing.T) {
+	data, err := NewTemplateEncoder("${remoteAddr} ${requestMethod} ${header.content-type} ${cookie.sid} ${unknown}").Encode(testEncoderAccessLog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = "127.0.0.1 GET text/html 123 ${unknown}"
+	if string(data) != expected {
+		t.Fatal("expect:", expected, "got:", string(data))
+	}
+}
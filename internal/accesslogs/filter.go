@@ -0,0 +1,319 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"errors"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/cespare/xxhash/v2"
+	"google.golang.org/protobuf/proto"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 脱敏目标
+const (
+	RedactTargetHeader = "header"
+	RedactTargetCookie = "cookie"
+	RedactTargetQuery  = "query"
+)
+
+const defaultRedactReplacement = "***"
+
+// FilterConfig 日志过滤配置，在写入存储之前执行
+type FilterConfig struct {
+	IncludeFields []string           `yaml:"includeFields" json:"includeFields"` // 只保留的字段，为空表示保留所有字段
+	ExcludeFields []string           `yaml:"excludeFields" json:"excludeFields"` // 需要去除的字段
+	Redactions    []*RedactionConfig `yaml:"redactions" json:"redactions"`       // 脱敏规则
+	SampleRatio   float64            `yaml:"sampleRatio" json:"sampleRatio"`     // 采样比例，0或1表示不采样
+	StatusRatios  map[string]float64 `yaml:"statusRatios" json:"statusRatios"`   // 按状态码类别采样，比如 {"2xx": 0.1, "5xx": 1}，优先于sampleRatio
+}
+
+// RedactionConfig 脱敏规则
+type RedactionConfig struct {
+	Target      string `yaml:"target" json:"target"`           // 目标：header、cookie、query
+	Name        string `yaml:"name" json:"name"`               // 名称正则表达式，为空表示所有名称
+	Pattern     string `yaml:"pattern" json:"pattern"`         // 值正则表达式，为空表示整个值
+	Replacement string `yaml:"replacement" json:"replacement"` // 替换为，默认为 ***
+}
+
+type redaction struct {
+	target      string
+	nameReg     *regexp.Regexp
+	valueReg    *regexp.Regexp
+	replacement string
+}
+
+// Filter 编译后的日志过滤器
+type Filter struct {
+	includeFields map[string]bool
+	excludeFields map[string]bool
+	redactions    []*redaction
+	sampleRatio   float64
+	statusRatios  map[int32]float64 // 状态码类别（1-5） => 比例
+}
+
+// NewFilter 根据配置获取过滤器，如果没有任何规则，则返回nil
+func NewFilter(config *FilterConfig) (*Filter, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	var filter = &Filter{
+		sampleRatio:  1,
+		statusRatios: map[int32]float64{},
+	}
+	var hasRules = false
+
+	if len(config.IncludeFields) > 0 {
+		filter.includeFields = map[string]bool{}
+		for _, field := range config.IncludeFields {
+			filter.includeFields[strings.ToLower(field)] = true
+		}
+		hasRules = true
+	}
+	if len(config.ExcludeFields) > 0 {
+		filter.excludeFields = map[string]bool{}
+		for _, field := range config.ExcludeFields {
+			filter.excludeFields[strings.ToLower(field)] = true
+		}
+		hasRules = true
+	}
+
+	for _, redactionConfig := range config.Redactions {
+		switch redactionConfig.Target {
+		case RedactTargetHeader, RedactTargetCookie, RedactTargetQuery:
+		default:
+			return nil, errors.New("invalid redaction target '" + redactionConfig.Target + "'")
+		}
+		var r = &redaction{
+			target:      redactionConfig.Target,
+			replacement: redactionConfig.Replacement,
+		}
+		if len(r.replacement) == 0 {
+			r.replacement = defaultRedactReplacement
+		}
+		if len(redactionConfig.Name) > 0 {
+			reg, err := regexp.Compile(redactionConfig.Name)
+			if err != nil {
+				return nil, errors.New("invalid redaction name '" + redactionConfig.Name + "': " + err.Error())
+			}
+			r.nameReg = reg
+		}
+		if len(redactionConfig.Pattern) > 0 {
+			reg, err := regexp.Compile(redactionConfig.Pattern)
+			if err != nil {
+				return nil, errors.New("invalid redaction pattern '" + redactionConfig.Pattern + "': " + err.Error())
+			}
+			r.valueReg = reg
+		}
+		filter.redactions = append(filter.redactions, r)
+		hasRules = true
+	}
+
+	if config.SampleRatio > 0 && config.SampleRatio < 1 {
+		filter.sampleRatio = config.SampleRatio
+		hasRules = true
+	}
+	for class, ratio := range config.StatusRatios {
+		class = strings.ToLower(class)
+		if len(class) != 3 || class[1:] != "xx" || class[0] < '1' || class[0] > '5' {
+			return nil, errors.New("invalid status class '" + class + "'")
+		}
+		if ratio < 0 {
+			ratio = 0
+		}
+		filter.statusRatios[int32(class[0]-'0')] = ratio
+		hasRules = true
+	}
+
+	if !hasRules {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// Filter 过滤一组日志，不会修改原有日志
+func (this *Filter) Filter(accessLogs []*pb.HTTPAccessLog) []*pb.HTTPAccessLog {
+	var result = make([]*pb.HTTPAccessLog, 0, len(accessLogs))
+	for _, accessLog := range accessLogs {
+		if !this.sample(accessLog) {
+			continue
+		}
+
+		if len(this.includeFields) == 0 && len(this.excludeFields) == 0 && len(this.redactions) == 0 {
+			result = append(result, accessLog)
+			continue
+		}
+
+		var newAccessLog = proto.Clone(accessLog).(*pb.HTTPAccessLog)
+		this.redact(newAccessLog)
+		this.filterFields(newAccessLog)
+		result = append(result, newAccessLog)
+	}
+	return result
+}
+
+// 判断是否采样，对同一个请求ID结果总是相同
+func (this *Filter) sample(accessLog *pb.HTTPAccessLog) bool {
+	var ratio = this.sampleRatio
+	statusRatio, ok := this.statusRatios[accessLog.Status/100]
+	if ok {
+		ratio = statusRatio
+	}
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+
+	var key = accessLog.RequestId
+	if len(key) == 0 {
+		key = accessLog.RemoteAddr + "@" + strconv.FormatInt(int64(accessLog.Timestamp), 10) + "@" + accessLog.RequestURI
+	}
+	return float64(xxhash.Sum64String(key)) < ratio*math.MaxUint64
+}
+
+// 脱敏
+func (this *Filter) redact(accessLog *pb.HTTPAccessLog) {
+	for _, r := range this.redactions {
+		switch r.target {
+		case RedactTargetHeader:
+			for name, values := range accessLog.Header {
+				if values == nil || !r.matchName(name) {
+					continue
+				}
+				for index, value := range values.Values {
+					values.Values[index] = r.replace(value)
+				}
+			}
+		case RedactTargetCookie:
+			for name, value := range accessLog.Cookie {
+				if r.matchName(name) {
+					accessLog.Cookie[name] = r.replace(value)
+				}
+			}
+			for name, values := range accessLog.Header {
+				if values == nil || !strings.EqualFold(name, "Cookie") {
+					continue
+				}
+				for index, value := range values.Values {
+					values.Values[index] = r.replaceCookieHeader(value)
+				}
+			}
+		case RedactTargetQuery:
+			accessLog.QueryString = r.replaceQuery(accessLog.QueryString)
+			accessLog.Args = r.replaceQuery(accessLog.Args)
+			accessLog.RequestURI = r.replaceURLQuery(accessLog.RequestURI)
+			accessLog.Request = r.replaceURLQuery(accessLog.Request)
+			accessLog.Referer = r.replaceURLQuery(accessLog.Referer)
+			for name, values := range accessLog.Header {
+				if values == nil || !strings.EqualFold(name, "Referer") {
+					continue
+				}
+				for index, value := range values.Values {
+					values.Values[index] = r.replaceURLQuery(value)
+				}
+			}
+		}
+	}
+}
+
+// 去除字段
+func (this *Filter) filterFields(accessLog *pb.HTTPAccessLog) {
+	var value = reflect.ValueOf(accessLog).Elem()
+	var valueType = value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		var field = valueType.Field(i)
+		if len(field.PkgPath) > 0 { // 未导出的字段
+			continue
+		}
+
+		var name = strings.ToLower(field.Name)
+		var jsonName = strings.ToLower(strings.Split(field.Tag.Get("json"), ",")[0])
+		var keep = true
+		if len(this.includeFields) > 0 && !this.includeFields[name] && !this.includeFields[jsonName] {
+			keep = false
+		}
+		if this.excludeFields[name] || this.excludeFields[jsonName] {
+			keep = false
+		}
+		if !keep {
+			value.Field(i).Set(reflect.Zero(field.Type))
+		}
+	}
+}
+
+func (this *redaction) matchName(name string) bool {
+	return this.nameReg == nil || this.nameReg.MatchString(name)
+}
+
+func (this *redaction) replace(value string) string {
+	if this.valueReg == nil {
+		return this.replacement
+	}
+	return this.valueReg.ReplaceAllString(value, this.replacement)
+}
+
+// 替换Cookie头部中的值：a=b; c=d
+func (this *redaction) replaceCookieHeader(header string) string {
+	var pieces = strings.Split(header, ";")
+	for index, piece := range pieces {
+		var equalIndex = strings.Index(piece, "=")
+		if equalIndex < 0 {
+			continue
+		}
+		if this.matchName(strings.TrimSpace(piece[:equalIndex])) {
+			pieces[index] = piece[:equalIndex+1] + this.replace(piece[equalIndex+1:])
+		}
+	}
+	return strings.Join(pieces, ";")
+}
+
+// 替换URL中查询参数的值，比如 /hello?a=b、GET /hello?a=b HTTP/1.1、https://example.com/?a=b#c
+func (this *redaction) replaceURLQuery(s string) string {
+	var index = strings.Index(s, "?")
+	if index < 0 {
+		return s
+	}
+	var query = s[index+1:]
+	var suffix = ""
+	var endIndex = strings.IndexAny(query, " #")
+	if endIndex >= 0 {
+		suffix = query[endIndex:]
+		query = query[:endIndex]
+	}
+	return s[:index+1] + this.replaceQuery(query) + suffix
+}
+
+// 替换查询参数中的值，保持参数顺序不变
+func (this *redaction) replaceQuery(query string) string {
+	if len(query) == 0 {
+		return query
+	}
+	var pieces = strings.Split(query, "&")
+	for index, piece := range pieces {
+		var equalIndex = strings.Index(piece, "=")
+		if equalIndex < 0 {
+			continue
+		}
+		name, err := url.QueryUnescape(piece[:equalIndex])
+		if err != nil {
+			name = piece[:equalIndex]
+		}
+		if !this.matchName(name) {
+			continue
+		}
+		value, err := url.QueryUnescape(piece[equalIndex+1:])
+		if err != nil {
+			value = piece[equalIndex+1:]
+		}
+		pieces[index] = piece[:equalIndex+1] + url.QueryEscape(this.replace(value))
+	}
+	return strings.Join(pieces, "&")
+}
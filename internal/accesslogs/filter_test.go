@@ -0,0 +1,152 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"strconv"
+	"testing"
+)
+
+func TestFilter_Fields(t *testing.T) {
+	filter, err := NewFilter(&FilterConfig{
+		ExcludeFields: []string{"cookie", "header"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accessLog = &pb.HTTPAccessLog{
+		RequestPath: "/hello",
+		Header:      map[string]*pb.Strings{"Authorization": {Values: []string{"Basic abc"}}},
+		Cookie:      map[string]string{"sid": "123"},
+	}
+	var result = filter.Filter([]*pb.HTTPAccessLog{accessLog})
+	if len(result) != 1 {
+		t.Fatal("expect 1 log")
+	}
+	if result[0].Header != nil || result[0].Cookie != nil || result[0].RequestPath != "/hello" {
+		t.Fatal("fields should be excluded:", result[0])
+	}
+	if accessLog.Header == nil {
+		t.Fatal("original log should not be changed")
+	}
+
+	filter, err = NewFilter(&FilterConfig{
+		IncludeFields: []string{"requestPath", "status"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = filter.Filter([]*pb.HTTPAccessLog{{RequestPath: "/hello", Status: 200, RemoteAddr: "127.0.0.1"}})
+	if result[0].RemoteAddr != "" || result[0].RequestPath != "/hello" || result[0].Status != 200 {
+		t.Fatal("only included fields should be kept:", result[0])
+	}
+}
+
+func TestFilter_Redact(t *testing.T) {
+	filter, err := NewFilter(&FilterConfig{
+		Redactions: []*RedactionConfig{
+			{Target: RedactTargetHeader, Name: "(?i)^authorization$"},
+			{Target: RedactTargetCookie, Name: "^sid$"},
+			{Target: RedactTargetQuery, Name: "^token$", Pattern: `.{4}$`, Replacement: "****"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result = filter.Filter([]*pb.HTTPAccessLog{
+		{
+			RequestURI:  "/login?user=me&token=abcdefgh",
+			QueryString: "user=me&token=abcdefgh",
+			Args:        "user=me&token=abcdefgh",
+			Request:     "GET /login?user=me&token=abcdefgh HTTP/1.1",
+			Referer:     "https://example.com/?token=abcdefgh#top",
+			Header: map[string]*pb.Strings{
+				"Referer":       {Values: []string{"https://example.com/?token=abcdefgh#top"}},
+				"Authorization": {Values: []string{"Basic abc"}},
+				"Cookie":        {Values: []string{"sid=123; lang=zh"}},
+				"Accept":        {Values: []string{"*/*"}},
+			},
+			Cookie: map[string]string{"sid": "123", "lang": "zh"},
+		},
+	})
+	var accessLog = result[0]
+	if accessLog.Header["Authorization"].Values[0] != "***" {
+		t.Fatal("authorization should be redacted")
+	}
+	if accessLog.Header["Accept"].Values[0] != "*/*" {
+		t.Fatal("accept should not be redacted")
+	}
+	if accessLog.Header["Cookie"].Values[0] != "sid=***; lang=zh" {
+		t.Fatal("cookie header should be redacted:", accessLog.Header["Cookie"].Values[0])
+	}
+	if accessLog.Cookie["sid"] != "***" || accessLog.Cookie["lang"] != "zh" {
+		t.Fatal("cookie should be redacted")
+	}
+	if accessLog.QueryString != "user=me&token=abcd%2A%2A%2A%2A" {
+		t.Fatal("query should be redacted:", accessLog.QueryString)
+	}
+	if accessLog.RequestURI != "/login?user=me&token=abcd%2A%2A%2A%2A" {
+		t.Fatal("request uri should be redacted:", accessLog.RequestURI)
+	}
+	if accessLog.Args != "user=me&token=abcd%2A%2A%2A%2A" {
+		t.Fatal("args should be redacted:", accessLog.Args)
+	}
+	if accessLog.Request != "GET /login?user=me&token=abcd%2A%2A%2A%2A HTTP/1.1" {
+		t.Fatal("request line should be redacted:", accessLog.Request)
+	}
+	if accessLog.Referer != "https://example.com/?token=abcd%2A%2A%2A%2A#top" {
+		t.Fatal("referer should be redacted:", accessLog.Referer)
+	}
+	if accessLog.Header["Referer"].Values[0] != "https://example.com/?token=abcd%2A%2A%2A%2A#top" {
+		t.Fatal("referer header should be redacted:", accessLog.Header["Referer"].Values[0])
+	}
+}
+
+func TestFilter_Sample(t *testing.T) {
+	filter, err := NewFilter(&FilterConfig{
+		SampleRatio:  0.1,
+		StatusRatios: map[string]float64{"5xx": 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accessLogs = []*pb.HTTPAccessLog{}
+	for i := 0; i < 10000; i++ {
+		var status int32 = 200
+		if i%10 == 0 {
+			status = 500
+		}
+		accessLogs = append(accessLogs, &pb.HTTPAccessLog{
+			RequestId: strconv.Itoa(i),
+			Status:    status,
+		})
+	}
+	var result = filter.Filter(accessLogs)
+	var count5xx = 0
+	for _, accessLog := range result {
+		if accessLog.Status == 500 {
+			count5xx++
+		}
+	}
+	if count5xx != 1000 {
+		t.Fatal("all 5xx logs should be kept")
+	}
+	var count2xx = len(result) - count5xx
+	if count2xx < 700 || count2xx > 1100 {
+		t.Fatal("unexpected sample count:", count2xx)
+	}
+
+	// 结果应该是确定的
+	if len(filter.Filter(accessLogs)) != len(result) {
+		t.Fatal("sampling should be deterministic")
+	}
+
+	_, err = NewFilter(&FilterConfig{StatusRatios: map[string]float64{"6xx": 1}})
+	if err == nil {
+		t.Fatal("invalid status class should be rejected")
+	}
+}
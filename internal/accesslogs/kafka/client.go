@@ -0,0 +1,353 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Error 服务端返回的错误
+type Error struct {
+	Code int16
+}
+
+func (this *Error) Error() string {
+	var name = ""
+	switch this.Code {
+	case 2:
+		name = "CORRUPT_MESSAGE"
+	case 3:
+		name = "UNKNOWN_TOPIC_OR_PARTITION"
+	case 5:
+		name = "LEADER_NOT_AVAILABLE"
+	case 6:
+		name = "NOT_LEADER_OR_FOLLOWER"
+	case 7:
+		name = "REQUEST_TIMED_OUT"
+	case 10:
+		name = "MESSAGE_TOO_LARGE"
+	case 19:
+		name = "NOT_ENOUGH_REPLICAS"
+	case 20:
+		name = "NOT_ENOUGH_REPLICAS_AFTER_APPEND"
+	default:
+		name = "UNKNOWN"
+	}
+	return "kafka: server error " + strconv.Itoa(int(this.Code)) + " (" + name + ")"
+}
+
+// TopicMetadata 主题元数据
+type TopicMetadata struct {
+	Name       string
+	Partitions []int32         // 分区ID，按ID排序
+	Leaders    map[int32]int32 // partition => broker node id
+}
+
+// Client 一个最简的Kafka生产者客户端
+// 只实现了写入日志所需的Metadata和Produce请求
+type Client struct {
+	brokers  []string
+	clientId string
+	timeout  time.Duration
+
+	correlationId int32
+
+	locker      sync.Mutex
+	conns       map[string]*brokerConn // addr => conn
+	nodes       map[int32]string       // node id => addr
+	topicLocker sync.Mutex
+	topics      map[string]*TopicMetadata // topic => metadata
+}
+
+func NewClient(brokers []string, clientId string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Client{
+		brokers:  brokers,
+		clientId: clientId,
+		timeout:  timeout,
+		conns:    map[string]*brokerConn{},
+		nodes:    map[int32]string{},
+		topics:   map[string]*TopicMetadata{},
+	}
+}
+
+// Metadata 获取主题元数据，优先使用缓存
+func (this *Client) Metadata(topic string) (*TopicMetadata, error) {
+	this.topicLocker.Lock()
+	metadata, ok := this.topics[topic]
+	this.topicLocker.Unlock()
+	if ok {
+		return metadata, nil
+	}
+
+	var body = &encoder{}
+	body.int32(1)
+	body.string(topic)
+
+	var lastErr error = errors.New("kafka: no available brokers")
+	for _, addr := range this.brokers {
+		respData, err := this.request(addr, APIKeyMetadata, APIVersionMetadata, body.buf, true)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		metadata, err = this.decodeMetadata(respData, topic)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		this.topicLocker.Lock()
+		this.topics[topic] = metadata
+		this.topicLocker.Unlock()
+		return metadata, nil
+	}
+	return nil, lastErr
+}
+
+// Produce 向某个分区写入消息
+func (this *Client) Produce(topic string, partition int32, messages []*Message, acks int16) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	metadata, err := this.Metadata(topic)
+	if err != nil {
+		return err
+	}
+	leader, ok := metadata.Leaders[partition]
+	if !ok {
+		return errors.New("kafka: partition '" + strconv.Itoa(int(partition)) + "' not found in topic '" + topic + "'")
+	}
+	this.locker.Lock()
+	addr, ok := this.nodes[leader]
+	this.locker.Unlock()
+	if !ok {
+		this.resetMetadata(topic)
+		return errors.New("kafka: leader '" + strconv.Itoa(int(leader)) + "' not found")
+	}
+
+	var body = &encoder{}
+	body.nullableString(nil) // transactional_id
+	body.int16(acks)
+	body.int32(int32(this.timeout / time.Millisecond))
+	body.int32(1)
+	body.string(topic)
+	body.int32(1)
+	body.int32(partition)
+	body.bytes(encodeRecordBatch(messages))
+
+	respData, err := this.request(addr, APIKeyProduce, APIVersionProduce, body.buf, acks != AcksNone)
+	if err != nil {
+		this.resetMetadata(topic)
+		return err
+	}
+	if acks == AcksNone {
+		return nil
+	}
+
+	var d = &decoder{buf: respData}
+	var topicCount = d.int32()
+	for i := int32(0); i < topicCount; i++ {
+		d.string()
+		var partitionCount = d.int32()
+		for j := int32(0); j < partitionCount; j++ {
+			d.int32() // partition
+			var errorCode = d.int16()
+			d.int64() // base_offset
+			d.int64() // log_append_time
+			if d.err != nil {
+				return d.err
+			}
+			if errorCode != 0 {
+				this.resetMetadata(topic)
+				return &Error{Code: errorCode}
+			}
+		}
+	}
+	return d.err
+}
+
+// Close 关闭所有连接
+func (this *Client) Close() error {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	var resultErr error
+	for addr, conn := range this.conns {
+		err := conn.Close()
+		if err != nil {
+			resultErr = err
+		}
+		delete(this.conns, addr)
+	}
+	return resultErr
+}
+
+func (this *Client) resetMetadata(topic string) {
+	this.topicLocker.Lock()
+	delete(this.topics, topic)
+	this.topicLocker.Unlock()
+}
+
+func (this *Client) decodeMetadata(data []byte, topic string) (*TopicMetadata, error) {
+	var d = &decoder{buf: data}
+
+	var nodes = map[int32]string{}
+	var brokerCount = d.int32()
+	for i := int32(0); i < brokerCount; i++ {
+		var nodeId = d.int32()
+		var host = d.string()
+		var port = d.int32()
+		nodes[nodeId] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+
+	var result *TopicMetadata
+	var topicCount = d.int32()
+	for i := int32(0); i < topicCount; i++ {
+		var errorCode = d.int16()
+		var name = d.string()
+		var metadata = &TopicMetadata{
+			Name:    name,
+			Leaders: map[int32]int32{},
+		}
+		var partitionCount = d.int32()
+		for j := int32(0); j < partitionCount; j++ {
+			d.int16() // error_code
+			var partitionId = d.int32()
+			var leader = d.int32()
+			var replicaCount = d.int32()
+			for k := int32(0); k < replicaCount; k++ {
+				d.int32()
+			}
+			var isrCount = d.int32()
+			for k := int32(0); k < isrCount; k++ {
+				d.int32()
+			}
+			if leader >= 0 {
+				metadata.Partitions = append(metadata.Partitions, partitionId)
+				metadata.Leaders[partitionId] = leader
+			}
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		if name != topic {
+			continue
+		}
+		if errorCode != 0 {
+			return nil, &Error{Code: errorCode}
+		}
+		result = metadata
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if result == nil || len(result.Partitions) == 0 {
+		return nil, errors.New("kafka: no available partitions for topic '" + topic + "'")
+	}
+	sort.Slice(result.Partitions, func(i, j int) bool {
+		return result.Partitions[i] < result.Partitions[j]
+	})
+
+	this.locker.Lock()
+	for nodeId, addr := range nodes {
+		this.nodes[nodeId] = addr
+	}
+	this.locker.Unlock()
+
+	return result, nil
+}
+
+// 发送请求并读取响应
+func (this *Client) request(addr string, apiKey int16, apiVersion int16, body []byte, expectResponse bool) ([]byte, error) {
+	conn, err := this.conn(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var correlationId = atomic.AddInt32(&this.correlationId, 1)
+	respData, err := conn.roundTrip(encodeRequest(apiKey, apiVersion, correlationId, this.clientId, body), correlationId, expectResponse, this.timeout)
+	if err != nil {
+		this.locker.Lock()
+		if this.conns[addr] == conn {
+			delete(this.conns, addr)
+		}
+		this.locker.Unlock()
+		_ = conn.Close()
+		return nil, err
+	}
+	return respData, nil
+}
+
+func (this *Client) conn(addr string) (*brokerConn, error) {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	conn, ok := this.conns[addr]
+	if ok {
+		return conn, nil
+	}
+
+	rawConn, err := net.DialTimeout("tcp", addr, this.timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn = &brokerConn{conn: rawConn}
+	this.conns[addr] = conn
+	return conn, nil
+}
+
+// 和单个Broker之间的连接
+type brokerConn struct {
+	conn   net.Conn
+	locker sync.Mutex
+}
+
+func (this *brokerConn) roundTrip(request []byte, correlationId int32, expectResponse bool, timeout time.Duration) ([]byte, error) {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	_ = this.conn.SetDeadline(time.Now().Add(timeout))
+	_, err := this.conn.Write(request)
+	if err != nil {
+		return nil, err
+	}
+	if !expectResponse {
+		return nil, nil
+	}
+
+	var header = make([]byte, 8)
+	_, err = io.ReadFull(this.conn, header)
+	if err != nil {
+		return nil, err
+	}
+	var size = int(binary.BigEndian.Uint32(header))
+	if size < 4 {
+		return nil, errInsufficientData
+	}
+	if int32(binary.BigEndian.Uint32(header[4:])) != correlationId {
+		return nil, errors.New("kafka: correlation id mismatch")
+	}
+	var data = make([]byte, size-4)
+	_, err = io.ReadFull(this.conn, data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (this *brokerConn) Close() error {
+	return this.conn.Close()
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package kafka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClient_Produce(t *testing.T) {
+	broker, err := NewFakeBroker(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = broker.Close()
+	}()
+
+	var client = NewClient([]string{broker.Addr()}, "edge-api", 5*time.Second)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	metadata, err := client.Metadata("logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metadata.Partitions) != 3 {
+		t.Fatal("expect 3 partitions, but got", len(metadata.Partitions))
+	}
+
+	err = client.Produce("logs", 1, []*Message{
+		{Key: []byte("1"), Value: []byte("hello"), Timestamp: time.Now()},
+		{Key: []byte("1"), Value: []byte("world"), Timestamp: time.Now()},
+	}, AcksAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var messages = broker.Messages("logs", 1)
+	if len(messages) != 2 {
+		t.Fatal("expect 2 messages, but got", len(messages))
+	}
+	if string(messages[0].Value) != "hello" || string(messages[1].Value) != "world" {
+		t.Fatal("unexpected messages")
+	}
+	if len(broker.Messages("logs", 0)) != 0 {
+		t.Fatal("partition 0 should be empty")
+	}
+}
+
+func TestClient_Produce_NoAcks(t *testing.T) {
+	broker, err := NewFakeBroker(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = broker.Close()
+	}()
+
+	var client = NewClient([]string{broker.Addr()}, "edge-api", 5*time.Second)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	err = client.Produce("logs", 0, []*Message{
+		{Value: []byte("hello"), Timestamp: time.Now()},
+	}, AcksNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 不等待确认时需要等待Broker处理
+	for i := 0; i < 20 && broker.CountMessages("logs") == 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if broker.CountMessages("logs") != 1 {
+		t.Fatal("message not received")
+	}
+}
+
+func TestClient_Produce_Error(t *testing.T) {
+	broker, err := NewFakeBroker(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = broker.Close()
+	}()
+	broker.SetErrorCode(19)
+
+	var client = NewClient([]string{broker.Addr()}, "edge-api", 5*time.Second)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	err = client.Produce("logs", 0, []*Message{
+		{Value: []byte("hello"), Timestamp: time.Now()},
+	}, AcksAll)
+	if err == nil {
+		t.Fatal("expect error")
+	}
+	t.Log(err)
+}
+
+func TestRecordBatch(t *testing.T) {
+	var now = time.Now()
+	data := encodeRecordBatch([]*Message{
+		{Key: nil, Value: []byte("a"), Timestamp: now},
+		{Key: []byte("k"), Value: []byte("b"), Timestamp: now.Add(time.Second)},
+	})
+	messages, err := decodeRecordBatches(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatal("expect 2 messages")
+	}
+	if messages[0].Key != nil || string(messages[1].Key) != "k" || string(messages[1].Value) != "b" {
+		t.Fatal("decode failed")
+	}
+	if messages[1].Timestamp.Sub(messages[0].Timestamp) != time.Second {
+		t.Fatal("timestamp mismatch")
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// FakeBroker 进程内的模拟Broker，仅用于测试
+// 支持Metadata v0和Produce v3请求
+type FakeBroker struct {
+	listener   net.Listener
+	partitions int32
+
+	locker          sync.Mutex
+	messages        map[string]map[int32][]*Message // topic => partition => messages
+	errorCode       int16
+	partitionErrors map[int32]int16 // partition => error code
+}
+
+func NewFakeBroker(partitions int32) (*FakeBroker, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	if partitions <= 0 {
+		partitions = 1
+	}
+	var broker = &FakeBroker{
+		listener:        listener,
+		partitions:      partitions,
+		messages:        map[string]map[int32][]*Message{},
+		partitionErrors: map[int32]int16{},
+	}
+	go broker.serve()
+	return broker, nil
+}
+
+// Addr 监听地址
+func (this *FakeBroker) Addr() string {
+	return this.listener.Addr().String()
+}
+
+// SetErrorCode 设置Produce时返回的错误代码
+func (this *FakeBroker) SetErrorCode(errorCode int16) {
+	this.locker.Lock()
+	this.errorCode = errorCode
+	this.locker.Unlock()
+}
+
+// SetPartitionErrorCode 设置Produce某个分区时返回的错误代码
+func (this *FakeBroker) SetPartitionErrorCode(partition int32, errorCode int16) {
+	this.locker.Lock()
+	this.partitionErrors[partition] = errorCode
+	this.locker.Unlock()
+}
+
+// Messages 读取某个分区收到的消息
+func (this *FakeBroker) Messages(topic string, partition int32) []*Message {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	partitions, ok := this.messages[topic]
+	if !ok {
+		return nil
+	}
+	return partitions[partition]
+}
+
+// CountMessages 计算某个主题收到的消息数
+func (this *FakeBroker) CountMessages(topic string) int {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	var count = 0
+	for _, messages := range this.messages[topic] {
+		count += len(messages)
+	}
+	return count
+}
+
+// Close 关闭
+func (this *FakeBroker) Close() error {
+	return this.listener.Close()
+}
+
+func (this *FakeBroker) serve() {
+	for {
+		conn, err := this.listener.Accept()
+		if err != nil {
+			return
+		}
+		go this.handle(conn)
+	}
+}
+
+func (this *FakeBroker) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	var sizeBytes = make([]byte, 4)
+	for {
+		_, err := io.ReadFull(conn, sizeBytes)
+		if err != nil {
+			return
+		}
+		var data = make([]byte, binary.BigEndian.Uint32(sizeBytes))
+		_, err = io.ReadFull(conn, data)
+		if err != nil {
+			return
+		}
+
+		var d = &decoder{buf: data}
+		var apiKey = d.int16()
+		d.int16() // api version
+		var correlationId = d.int32()
+		d.string() // client id
+		if d.err != nil {
+			return
+		}
+
+		var body = &decoder{buf: data[d.offset:]}
+		var resp []byte
+		var hasResponse = true
+		switch apiKey {
+		case APIKeyMetadata:
+			resp = this.metadata(body)
+		case APIKeyProduce:
+			resp, hasResponse = this.produce(body)
+		default:
+			return
+		}
+		if resp == nil {
+			return
+		}
+		if !hasResponse {
+			continue
+		}
+
+		var e = &encoder{}
+		e.int32(int32(4 + len(resp)))
+		e.int32(correlationId)
+		e.raw(resp)
+		_, err = conn.Write(e.buf)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (this *FakeBroker) metadata(d *decoder) []byte {
+	var topics = []string{}
+	var count = d.int32()
+	for i := int32(0); i < count; i++ {
+		topics = append(topics, d.string())
+	}
+	if d.err != nil {
+		return nil
+	}
+
+	host, portString, err := net.SplitHostPort(this.Addr())
+	if err != nil {
+		return nil
+	}
+	port, _ := strconv.Atoi(portString)
+
+	var e = &encoder{}
+	e.int32(1)
+	e.int32(0) // node id
+	e.string(host)
+	e.int32(int32(port))
+
+	e.int32(int32(len(topics)))
+	for _, topic := range topics {
+		e.int16(0)
+		e.string(topic)
+		e.int32(this.partitions)
+		for partition := int32(0); partition < this.partitions; partition++ {
+			e.int16(0)
+			e.int32(partition)
+			e.int32(0) // leader
+			e.int32(1) // replicas
+			e.int32(0)
+			e.int32(1) // isr
+			e.int32(0)
+		}
+	}
+	return e.buf
+}
+
+func (this *FakeBroker) produce(d *decoder) (resp []byte, hasResponse bool) {
+	var transactionalIdLength = d.int16()
+	if transactionalIdLength > 0 {
+		d.take(int(transactionalIdLength))
+	}
+	var acks = d.int16()
+	d.int32() // timeout
+
+	this.locker.Lock()
+	var errorCode = this.errorCode
+	var partitionErrors = map[int32]int16{}
+	for partition, partitionErrorCode := range this.partitionErrors {
+		partitionErrors[partition] = partitionErrorCode
+	}
+	this.locker.Unlock()
+
+	var e = &encoder{}
+	var topicCount = d.int32()
+	e.int32(topicCount)
+	for i := int32(0); i < topicCount; i++ {
+		var topic = d.string()
+		e.string(topic)
+
+		var partitionCount = d.int32()
+		e.int32(partitionCount)
+		for j := int32(0); j < partitionCount; j++ {
+			var partition = d.int32()
+			var recordSet = d.bytes()
+			if d.err != nil {
+				return nil, false
+			}
+
+			var partitionErrorCode = errorCode
+			if partitionErrorCode == 0 {
+				partitionErrorCode = partitionErrors[partition]
+			}
+			if partitionErrorCode == 0 {
+				messages, err := decodeRecordBatches(recordSet)
+				if err != nil {
+					partitionErrorCode = 2 // CORRUPT_MESSAGE
+				} else {
+					this.locker.Lock()
+					partitions, ok := this.messages[topic]
+					if !ok {
+						partitions = map[int32][]*Message{}
+						this.messages[topic] = partitions
+					}
+					partitions[partition] = append(partitions[partition], messages...)
+					this.locker.Unlock()
+				}
+			}
+
+			e.int32(partition)
+			e.int16(partitionErrorCode)
+			e.int64(0)  // base_offset
+			e.int64(-1) // log_append_time
+		}
+	}
+	e.int32(0) // throttle_time_ms
+
+	return e.buf, acks != AcksNone
+}
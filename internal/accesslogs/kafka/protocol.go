@@ -0,0 +1,204 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// 使用到的API
+const (
+	APIKeyProduce  int16 = 0
+	APIKeyMetadata int16 = 3
+)
+
+// 使用到的API版本
+const (
+	APIVersionProduce  int16 = 3
+	APIVersionMetadata int16 = 0
+)
+
+// Acks 确认方式
+const (
+	AcksNone   int16 = 0  // 不等待确认
+	AcksLeader int16 = 1  // 等待Leader确认
+	AcksAll    int16 = -1 // 等待所有ISR确认
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+var errInsufficientData = errors.New("kafka: insufficient data to decode packet")
+
+// 编码器
+type encoder struct {
+	buf []byte
+}
+
+func (this *encoder) int8(v int8) {
+	this.buf = append(this.buf, byte(v))
+}
+
+func (this *encoder) int16(v int16) {
+	this.buf = append(this.buf, byte(uint16(v)>>8), byte(v))
+}
+
+func (this *encoder) int32(v int32) {
+	var b = make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(v))
+	this.buf = append(this.buf, b...)
+}
+
+func (this *encoder) int64(v int64) {
+	var b = make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	this.buf = append(this.buf, b...)
+}
+
+func (this *encoder) varint(v int64) {
+	var b = make([]byte, binary.MaxVarintLen64)
+	var n = binary.PutVarint(b, v)
+	this.buf = append(this.buf, b[:n]...)
+}
+
+func (this *encoder) string(s string) {
+	this.int16(int16(len(s)))
+	this.buf = append(this.buf, s...)
+}
+
+func (this *encoder) nullableString(s *string) {
+	if s == nil {
+		this.int16(-1)
+		return
+	}
+	this.string(*s)
+}
+
+func (this *encoder) bytes(b []byte) {
+	if b == nil {
+		this.int32(-1)
+		return
+	}
+	this.int32(int32(len(b)))
+	this.buf = append(this.buf, b...)
+}
+
+func (this *encoder) varintBytes(b []byte) {
+	if b == nil {
+		this.varint(-1)
+		return
+	}
+	this.varint(int64(len(b)))
+	this.buf = append(this.buf, b...)
+}
+
+func (this *encoder) raw(b []byte) {
+	this.buf = append(this.buf, b...)
+}
+
+// 解码器
+type decoder struct {
+	buf    []byte
+	offset int
+	err    error
+}
+
+func (this *decoder) remaining() int {
+	return len(this.buf) - this.offset
+}
+
+func (this *decoder) take(n int) []byte {
+	if this.err != nil {
+		return nil
+	}
+	if n < 0 || this.remaining() < n {
+		this.err = errInsufficientData
+		return nil
+	}
+	var b = this.buf[this.offset : this.offset+n]
+	this.offset += n
+	return b
+}
+
+func (this *decoder) int8() int8 {
+	var b = this.take(1)
+	if b == nil {
+		return 0
+	}
+	return int8(b[0])
+}
+
+func (this *decoder) int16() int16 {
+	var b = this.take(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (this *decoder) int32() int32 {
+	var b = this.take(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (this *decoder) int64() int64 {
+	var b = this.take(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (this *decoder) varint() int64 {
+	if this.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(this.buf[this.offset:])
+	if n <= 0 {
+		this.err = errInsufficientData
+		return 0
+	}
+	this.offset += n
+	return v
+}
+
+func (this *decoder) string() string {
+	var l = this.int16()
+	if l < 0 {
+		return ""
+	}
+	return string(this.take(int(l)))
+}
+
+func (this *decoder) bytes() []byte {
+	var l = this.int32()
+	if l < 0 {
+		return nil
+	}
+	return this.take(int(l))
+}
+
+func (this *decoder) varintBytes() []byte {
+	var l = this.varint()
+	if l < 0 {
+		return nil
+	}
+	return this.take(int(l))
+}
+
+// 封装请求
+func encodeRequest(apiKey int16, apiVersion int16, correlationId int32, clientId string, body []byte) []byte {
+	var e = &encoder{}
+	e.int32(0) // 先占位，最后再写入长度
+	e.int16(apiKey)
+	e.int16(apiVersion)
+	e.int32(correlationId)
+	e.string(clientId)
+	e.raw(body)
+	binary.BigEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+	return e.buf
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package kafka
+
+import (
+	"errors"
+	"hash/crc32"
+	"strconv"
+	"time"
+)
+
+// Message 单条消息
+type Message struct {
+	Key       []byte
+	Value     []byte
+	Timestamp time.Time
+}
+
+// 将消息编码为RecordBatch（magic=2）
+func encodeRecordBatch(messages []*Message) []byte {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	var baseTimestamp = messages[0].Timestamp.UnixNano() / int64(time.Millisecond)
+	var maxTimestamp = baseTimestamp
+
+	var records = &encoder{}
+	for index, message := range messages {
+		var timestamp = message.Timestamp.UnixNano() / int64(time.Millisecond)
+		if timestamp > maxTimestamp {
+			maxTimestamp = timestamp
+		}
+
+		var record = &encoder{}
+		record.int8(0) // attributes
+		record.varint(timestamp - baseTimestamp)
+		record.varint(int64(index))
+		record.varintBytes(message.Key)
+		record.varintBytes(message.Value)
+		record.varint(0) // headers
+
+		records.varint(int64(len(record.buf)))
+		records.raw(record.buf)
+	}
+
+	// CRC覆盖的部分：从attributes到结尾
+	var body = &encoder{}
+	body.int16(0) // attributes：不压缩
+	body.int32(int32(len(messages) - 1))
+	body.int64(baseTimestamp)
+	body.int64(maxTimestamp)
+	body.int64(-1) // producerId
+	body.int16(-1) // producerEpoch
+	body.int32(-1) // baseSequence
+	body.int32(int32(len(messages)))
+	body.raw(records.buf)
+
+	var batch = &encoder{}
+	batch.int64(0) // baseOffset
+	batch.int32(int32(4 + 1 + 4 + len(body.buf)))
+	batch.int32(-1) // partitionLeaderEpoch
+	batch.int8(2)   // magic
+	batch.int32(int32(crc32.Checksum(body.buf, castagnoliTable)))
+	batch.raw(body.buf)
+	return batch.buf
+}
+
+// 从RecordBatch中解析消息
+func decodeRecordBatches(data []byte) ([]*Message, error) {
+	var result = []*Message{}
+	var d = &decoder{buf: data}
+	for d.remaining() > 0 {
+		d.int64() // baseOffset
+		var batchLength = d.int32()
+		var batchData = d.take(int(batchLength))
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		var bd = &decoder{buf: batchData}
+		bd.int32() // partitionLeaderEpoch
+		var magic = bd.int8()
+		if magic != 2 {
+			return nil, errors.New("kafka: unsupported record batch magic '" + strconv.Itoa(int(magic)) + "'")
+		}
+		var crc = uint32(bd.int32())
+		if bd.err != nil {
+			return nil, bd.err
+		}
+		if crc32.Checksum(batchData[bd.offset:], castagnoliTable) != crc {
+			return nil, errors.New("kafka: record batch crc mismatch")
+		}
+		bd.int16() // attributes
+		bd.int32() // lastOffsetDelta
+		var baseTimestamp = bd.int64()
+		bd.int64() // maxTimestamp
+		bd.int64() // producerId
+		bd.int16() // producerEpoch
+		bd.int32() // baseSequence
+		var count = bd.int32()
+		for i := int32(0); i < count; i++ {
+			var length = bd.varint()
+			var recordData = bd.take(int(length))
+			if bd.err != nil {
+				return nil, bd.err
+			}
+			var rd = &decoder{buf: recordData}
+			rd.int8() // attributes
+			var timestampDelta = rd.varint()
+			rd.varint() // offsetDelta
+			var key = rd.varintBytes()
+			var value = rd.varintBytes()
+			if rd.err != nil {
+				return nil, rd.err
+			}
+			var timestamp = baseTimestamp + timestampDelta
+			result = append(result, &Message{
+				Key:       key,
+				Value:     value,
+				Timestamp: time.Unix(0, timestamp*int64(time.Millisecond)),
+			})
+		}
+		if bd.err != nil {
+			return nil, bd.err
+		}
+	}
+	return result, nil
+}
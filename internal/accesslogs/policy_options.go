@@ -0,0 +1,28 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"encoding/json"
+)
+
+// PolicyOptions 访问日志策略中和存储类型无关的通用选项
+// 和存储配置存放在同一个options中
+type PolicyOptions struct {
+	Spool  *SpoolConfig  `yaml:"spool" json:"spool"`   // 写入失败时的缓冲区，需要明确启用
+	Format *FormatConfig `yaml:"format" json:"format"` // 日志格式
+	Filter *FilterConfig `yaml:"filter" json:"filter"` // 过滤、采样和脱敏规则
+}
+
+// ParsePolicyOptions 从策略选项中分析通用选项
+func ParsePolicyOptions(optionsJSON []byte) (*PolicyOptions, error) {
+	var options = &PolicyOptions{}
+	if len(optionsJSON) == 0 {
+		return options, nil
+	}
+	err := json.Unmarshal(optionsJSON, options)
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
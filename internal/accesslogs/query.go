@@ -0,0 +1,47 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+)
+
+// MaxQuerySize 单次查询最多返回的日志数量
+const MaxQuerySize = 1000
+
+// Query 访问日志查询条件，和 HTTPAccessLogDAO.ListAccessLogs 中的条件保持一致
+type Query struct {
+	RequestId string // 上一页最后一条日志的请求ID
+	Size      int64  // 单页数量
+	Day       string // 日期，格式为 YYYYMMDD
+	Reverse   bool   // 是否往后查询（按请求ID从小到大）
+
+	ServerId  int64   // 服务ID
+	ServerIds []int64 // 限定的服务ID列表，用于查询某个用户的日志
+
+	HasError            bool
+	FirewallPolicyId    int64
+	FirewallRuleGroupId int64
+	FirewallRuleSetId   int64
+	HasFirewallPolicy   bool
+
+	Keyword string // 关键词
+	IP      string // IP或IP范围：IP1,IP2 或 IP1-IP2
+	Domain  string // 域名，支持 * 通配符
+}
+
+// QueryResult 查询结果
+type QueryResult struct {
+	AccessLogs []*pb.HTTPAccessLog
+	RequestId  string // 用于查询下一页的请求ID
+	HasMore    bool
+}
+
+// QueryableInterface 可以查询日志的存储
+type QueryableInterface interface {
+	// Query 查询单页日志
+	Query(query *Query) (*QueryResult, error)
+
+	// FindAccessLog 根据请求ID查找单条日志，找不到时返回nil
+	FindAccessLog(requestId string) (*pb.HTTPAccessLog, error)
+}
@@ -0,0 +1,327 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultSpoolMaxBytes int64 = 1 << 30 // 默认最多缓存1G
+	DefaultSpoolMaxAge         = 86400   // 默认最多缓存1天，单位秒
+)
+
+var spoolFileReg = regexp.MustCompile(`^(\d+)_(\d+)\.json$`)
+
+// SpoolConfig 缓冲区配置
+type SpoolConfig struct {
+	IsOn     bool  `yaml:"isOn" json:"isOn"`         // 是否启用
+	MaxBytes int64 `yaml:"maxBytes" json:"maxBytes"` // 最大占用空间，单位字节
+	MaxAge   int   `yaml:"maxAge" json:"maxAge"`     // 最长保留时间，单位秒
+}
+
+// SpoolStat 缓冲区统计
+type SpoolStat struct {
+	Batches     int64 // 等待重放的批次
+	Logs        int64 // 等待重放的日志数量
+	Bytes       int64 // 占用空间
+	DroppedLogs int64 // 因超出限制而丢弃的日志数量
+}
+
+type spoolItem struct {
+	path      string
+	createdAt int64 // 纳秒
+	count     int64
+	size      int64
+}
+
+// Spool 写入失败的日志在磁盘上的缓冲区
+// 每个批次单独存放在一个文件中，并按写入顺序重放
+type Spool struct {
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+
+	locker      sync.Mutex
+	items       []*spoolItem
+	bytes       int64
+	logs        int64
+	droppedLogs int64
+	lastTime    int64
+
+	isReplaying int32
+}
+
+// NewSpool 获取新对象，并加载目录中已有的批次
+func NewSpool(dir string, maxBytes int64, maxAge time.Duration) (*Spool, error) {
+	err := os.MkdirAll(dir, 0777)
+	if err != nil {
+		return nil, err
+	}
+
+	var spool = &Spool{dir: dir}
+	spool.SetLimits(maxBytes, maxAge)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		var matches = spoolFileReg.FindStringSubmatch(file.Name())
+		if len(matches) == 0 {
+			continue
+		}
+		createdAt, _ := strconv.ParseInt(matches[1], 10, 64)
+		count, _ := strconv.ParseInt(matches[2], 10, 64)
+		spool.items = append(spool.items, &spoolItem{
+			path:      filepath.Join(dir, file.Name()),
+			createdAt: createdAt,
+			count:     count,
+			size:      file.Size(),
+		})
+		spool.bytes += file.Size()
+		spool.logs += count
+		if createdAt > spool.lastTime {
+			spool.lastTime = createdAt
+		}
+	}
+	sort.Slice(spool.items, func(i, j int) bool {
+		return spool.items[i].createdAt < spool.items[j].createdAt
+	})
+
+	spool.locker.Lock()
+	spool.purge()
+	spool.locker.Unlock()
+
+	return spool, nil
+}
+
+// SetLimits 修改限制
+func (this *Spool) SetLimits(maxBytes int64, maxAge time.Duration) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultSpoolMaxBytes
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultSpoolMaxAge * time.Second
+	}
+
+	this.locker.Lock()
+	this.maxBytes = maxBytes
+	this.maxAge = maxAge
+	this.locker.Unlock()
+}
+
+// Push 放入一批日志
+func (this *Spool) Push(accessLogs []*pb.HTTPAccessLog) error {
+	if len(accessLogs) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(accessLogs)
+	if err != nil {
+		return err
+	}
+
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	// 保证文件名递增
+	var createdAt = time.Now().UnixNano()
+	if createdAt <= this.lastTime {
+		createdAt = this.lastTime + 1
+	}
+	this.lastTime = createdAt
+
+	var count = int64(len(accessLogs))
+	var path = filepath.Join(this.dir, fmt.Sprintf("%020d_%d.json", createdAt, count))
+	err = ioutil.WriteFile(path, data, 0666)
+	if err != nil {
+		return err
+	}
+
+	this.items = append(this.items, &spoolItem{
+		path:      path,
+		createdAt: createdAt,
+		count:     count,
+		size:      int64(len(data)),
+	})
+	this.bytes += int64(len(data))
+	this.logs += count
+
+	this.purge()
+
+	return nil
+}
+
+// Replay 按顺序重放所有批次，遇到错误时停止
+// 返回成功重放的日志数量
+func (this *Spool) Replay(writeFunc func(accessLogs []*pb.HTTPAccessLog) error) (int64, error) {
+	if !atomic.CompareAndSwapInt32(&this.isReplaying, 0, 1) {
+		return 0, nil
+	}
+	defer atomic.StoreInt32(&this.isReplaying, 0)
+
+	var total int64
+	for {
+		this.locker.Lock()
+		this.purge()
+		if len(this.items) == 0 {
+			this.locker.Unlock()
+			return total, nil
+		}
+		var item = this.items[0]
+		this.locker.Unlock()
+
+		data, err := ioutil.ReadFile(item.path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return total, err
+			}
+		} else {
+			var accessLogs = []*pb.HTTPAccessLog{}
+			err = json.Unmarshal(data, &accessLogs)
+			if err != nil {
+				// 文件已损坏，只能丢弃
+				this.locker.Lock()
+				this.droppedLogs += item.count
+				this.locker.Unlock()
+			} else {
+				err = writeFunc(accessLogs)
+				if err != nil {
+					// 只保留写入失败的日志，以免重复写入
+					partialErr, ok := err.(*PartialWriteError)
+					if ok {
+						total += int64(len(accessLogs) - len(partialErr.AccessLogs))
+						this.locker.Lock()
+						replaceErr := this.replaceFirst(item, partialErr.AccessLogs)
+						this.locker.Unlock()
+						if replaceErr != nil {
+							return total, replaceErr
+						}
+					}
+					return total, err
+				}
+				total += int64(len(accessLogs))
+			}
+		}
+
+		this.locker.Lock()
+		if len(this.items) > 0 && this.items[0] == item {
+			this.removeFirst()
+		}
+		this.locker.Unlock()
+	}
+}
+
+// Len 等待重放的批次数量
+func (this *Spool) Len() int {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	return len(this.items)
+}
+
+// Stat 统计信息
+func (this *Spool) Stat() *SpoolStat {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	return &SpoolStat{
+		Batches:     int64(len(this.items)),
+		Logs:        this.logs,
+		Bytes:       this.bytes,
+		DroppedLogs: this.droppedLogs,
+	}
+}
+
+// Clear 清除所有批次
+func (this *Spool) Clear() error {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	var resultErr error
+	for len(this.items) > 0 {
+		err := this.removeFirst()
+		if err != nil {
+			resultErr = err
+		}
+	}
+	return resultErr
+}
+
+// 清除超出限制的批次，需要在锁内调用
+func (this *Spool) purge() {
+	var minTime = time.Now().Add(-this.maxAge).UnixNano()
+	for len(this.items) > 0 {
+		var item = this.items[0]
+		if item.createdAt >= minTime && this.bytes <= this.maxBytes {
+			break
+		}
+		this.droppedLogs += item.count
+		_ = this.removeFirst()
+	}
+}
+
+// 删除第一个批次，需要在锁内调用
+// 使用新的日志替换第一个批次，保留原有的顺序
+func (this *Spool) replaceFirst(item *spoolItem, accessLogs []*pb.HTTPAccessLog) error {
+	if len(this.items) == 0 || this.items[0] != item {
+		return nil
+	}
+	if len(accessLogs) == 0 {
+		return this.removeFirst()
+	}
+
+	data, err := json.Marshal(accessLogs)
+	if err != nil {
+		return err
+	}
+	var count = int64(len(accessLogs))
+	var path = filepath.Join(this.dir, fmt.Sprintf("%020d_%d.json", item.createdAt, count))
+	err = ioutil.WriteFile(path, data, 0666)
+	if err != nil {
+		return err
+	}
+	if path != item.path {
+		err = os.Remove(item.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	this.bytes += int64(len(data)) - item.size
+	this.logs += count - item.count
+	item.path = path
+	item.count = count
+	item.size = int64(len(data))
+	return nil
+}
+
+func (this *Spool) removeFirst() error {
+	if len(this.items) == 0 {
+		return errors.New("spool is empty")
+	}
+	var item = this.items[0]
+	this.items = this.items[1:]
+	this.bytes -= item.size
+	this.logs -= item.count
+
+	err := os.Remove(item.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
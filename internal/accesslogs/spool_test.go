@@ -0,0 +1,150 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"errors"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"testing"
+	"time"
+)
+
+func TestSpool_Replay(t *testing.T) {
+	var dir = t.TempDir()
+	spool, err := NewSpool(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/1", "/2", "/3"} {
+		err = spool.Push([]*pb.HTTPAccessLog{{RequestPath: path}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if spool.Len() != 3 {
+		t.Fatal("expect 3 batches, but got", spool.Len())
+	}
+
+	// 重新加载
+	spool, err = NewSpool(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spool.Stat().Logs != 3 {
+		t.Fatal("expect 3 logs after reload")
+	}
+
+	// 失败时停止
+	var paths = []string{}
+	count, err := spool.Replay(func(accessLogs []*pb.HTTPAccessLog) error {
+		if len(paths) == 2 {
+			return errors.New("storage is down")
+		}
+		for _, accessLog := range accessLogs {
+			paths = append(paths, accessLog.RequestPath)
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expect error")
+	}
+	if count != 2 || spool.Len() != 1 {
+		t.Fatal("expect 2 replayed and 1 left, but got", count, spool.Len())
+	}
+
+	// 恢复后继续
+	count, err = spool.Replay(func(accessLogs []*pb.HTTPAccessLog) error {
+		for _, accessLog := range accessLogs {
+			paths = append(paths, accessLog.RequestPath)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || spool.Len() != 0 {
+		t.Fatal("spool should be empty")
+	}
+	if len(paths) != 3 || paths[0] != "/1" || paths[1] != "/2" || paths[2] != "/3" {
+		t.Fatal("replay out of order:", paths)
+	}
+}
+
+func TestSpool_Limits(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 1, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = spool.Push([]*pb.HTTPAccessLog{{RequestPath: "/1"}, {RequestPath: "/2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stat = spool.Stat()
+	if stat.Batches != 0 || stat.DroppedLogs != 2 {
+		t.Fatal("batch should be dropped:", stat)
+	}
+
+	spool.SetLimits(0, time.Nanosecond)
+	err = spool.Push([]*pb.HTTPAccessLog{{RequestPath: "/3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	_, err = spool.Replay(func(accessLogs []*pb.HTTPAccessLog) error {
+		t.Fatal("expired batch should not be replayed")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spool.Stat().DroppedLogs != 3 {
+		t.Fatal("expect 3 dropped logs")
+	}
+}
+
+func TestSpool_Replay_Partial(t *testing.T) {
+	var dir = t.TempDir()
+	spool, err := NewSpool(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = spool.Push([]*pb.HTTPAccessLog{{RequestPath: "/1"}, {RequestPath: "/2"}, {RequestPath: "/3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 只有一部分写入失败
+	count, err := spool.Replay(func(accessLogs []*pb.HTTPAccessLog) error {
+		return &PartialWriteError{
+			Err:        errors.New("partition is down"),
+			AccessLogs: accessLogs[2:],
+		}
+	})
+	if err == nil {
+		t.Fatal("expect error")
+	}
+	if count != 2 || spool.Stat().Logs != 1 {
+		t.Fatal("expect 2 replayed and 1 left, but got", count, spool.Stat().Logs)
+	}
+
+	// 重新加载后仍然只剩下失败的日志
+	spool, err = NewSpool(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths = []string{}
+	_, err = spool.Replay(func(accessLogs []*pb.HTTPAccessLog) error {
+		for _, accessLog := range accessLogs {
+			paths = append(paths, accessLog.RequestPath)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != "/3" {
+		t.Fatal("only failed logs should be replayed:", paths)
+	}
+}
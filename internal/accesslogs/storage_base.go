@@ -8,12 +8,16 @@ import (
 	"github.com/TeaOSLab/EdgeCommon/pkg/configutils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"strconv"
+	"sync"
 	"time"
 )
 
 type BaseStorage struct {
 	isOk    bool
 	version int
+
+	encoder       EncoderInterface
+	encoderLocker sync.RWMutex
 }
 
 func (this *BaseStorage) SetVersion(version int) {
@@ -32,8 +36,23 @@ func (this *BaseStorage) SetOk(isOk bool) {
 	this.isOk = isOk
 }
 
+// SetEncoder 设置编码器
+// 策略更新时可能和写入日志同时发生，所以需要加锁
+func (this *BaseStorage) SetEncoder(encoder EncoderInterface) {
+	this.encoderLocker.Lock()
+	this.encoder = encoder
+	this.encoderLocker.Unlock()
+}
+
 // Marshal 对日志进行编码
 func (this *BaseStorage) Marshal(accessLog *pb.HTTPAccessLog) ([]byte, error) {
+	this.encoderLocker.RLock()
+	var encoder = this.encoder
+	this.encoderLocker.RUnlock()
+
+	if encoder != nil {
+		return encoder.Encode(accessLog)
+	}
 	return json.Marshal(accessLog)
 }
 
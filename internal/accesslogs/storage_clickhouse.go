@@ -0,0 +1,212 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
+	"github.com/TeaOSLab/EdgeAPI/internal/remotelogs"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// AccessLogStorageTypeClickHouse ClickHouse存储类型
+const AccessLogStorageTypeClickHouse = "clickhouse"
+
+// ClickHouseStorageConfig ClickHouse存储配置
+type ClickHouseStorageConfig struct {
+	Endpoint string `yaml:"endpoint" json:"endpoint"` // HTTP接口地址，比如 http://127.0.0.1:8123
+	Database string `yaml:"database" json:"database"` // 数据库
+	Table    string `yaml:"table" json:"table"`       // 数据表
+	Username string `yaml:"username" json:"username"` // 用户名
+	Password string `yaml:"password" json:"password"` // 密码
+	TTLDays  int    `yaml:"ttlDays" json:"ttlDays"`   // 数据保留天数，0表示不限制
+}
+
+// clickHouseColumn 数据表字段定义
+type clickHouseColumn struct {
+	Name  string
+	Type  string
+	Value func(accessLog *pb.HTTPAccessLog) interface{}
+}
+
+var clickHouseColumns = []*clickHouseColumn{
+	{"timestamp", "DateTime", func(accessLog *pb.HTTPAccessLog) interface{} {
+		if accessLog.Timestamp > 0 {
+			return accessLog.Timestamp
+		}
+		return time.Now().Unix()
+	}},
+	{"requestId", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.RequestId }},
+	{"serverId", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.ServerId }},
+	{"nodeId", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.NodeId }},
+	{"locationId", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.LocationId }},
+	{"originId", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.OriginId }},
+	{"remoteAddr", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.RemoteAddr }},
+	{"rawRemoteAddr", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.RawRemoteAddr }},
+	{"remotePort", "UInt32", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.RemotePort }},
+	{"remoteUser", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.RemoteUser }},
+	{"requestMethod", "LowCardinality(String)", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.RequestMethod }},
+	{"scheme", "LowCardinality(String)", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.Scheme }},
+	{"proto", "LowCardinality(String)", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.Proto }},
+	{"host", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.Host }},
+	{"requestURI", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.RequestURI }},
+	{"requestPath", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.RequestPath }},
+	{"queryString", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.QueryString }},
+	{"requestLength", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.RequestLength }},
+	{"requestTime", "Float64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.RequestTime }},
+	{"status", "UInt16", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.Status }},
+	{"bytesSent", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.BytesSent }},
+	{"bodyBytesSent", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.BodyBytesSent }},
+	{"contentType", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.ContentType }},
+	{"referer", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.Referer }},
+	{"userAgent", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.UserAgent }},
+	{"originAddress", "String", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.OriginAddress }},
+	{"firewallPolicyId", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.FirewallPolicyId }},
+	{"firewallRuleGroupId", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.FirewallRuleGroupId }},
+	{"firewallRuleSetId", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.FirewallRuleSetId }},
+	{"firewallRuleId", "UInt64", func(accessLog *pb.HTTPAccessLog) interface{} { return accessLog.FirewallRuleId }},
+	{"errors", "Array(String)", func(accessLog *pb.HTTPAccessLog) interface{} {
+		if accessLog.Errors == nil {
+			return []string{}
+		}
+		return accessLog.Errors
+	}},
+}
+
+// ClickHouseStorage ClickHouse存储策略
+// 通过HTTP接口写入，并在启动时自动创建数据表
+type ClickHouseStorage struct {
+	BaseStorage
+
+	config *ClickHouseStorageConfig
+}
+
+func NewClickHouseStorage(config *ClickHouseStorageConfig) *ClickHouseStorage {
+	return &ClickHouseStorage{config: config}
+}
+
+func (this *ClickHouseStorage) Config() interface{} {
+	return this.config
+}
+
+// Start 开启
+func (this *ClickHouseStorage) Start() error {
+	if len(this.config.Endpoint) == 0 {
+		return errors.New("'endpoint' should not be empty")
+	}
+	if !regexp.MustCompile(`(?i)^(http|https)://`).MatchString(this.config.Endpoint) {
+		this.config.Endpoint = "http://" + this.config.Endpoint
+	}
+	this.config.Endpoint = strings.TrimRight(this.config.Endpoint, "/")
+	if len(this.config.Database) == 0 {
+		this.config.Database = "default"
+	}
+	if len(this.config.Table) == 0 {
+		return errors.New("'table' should not be empty")
+	}
+
+	var identifierReg = regexp.MustCompile(`^\w+$`)
+	if !identifierReg.MatchString(this.config.Database) {
+		return errors.New("invalid database name '" + this.config.Database + "'")
+	}
+	if !identifierReg.MatchString(this.config.Table) {
+		return errors.New("invalid table name '" + this.config.Table + "'")
+	}
+
+	// 创建数据表
+	return this.exec(this.createTableSQL(), nil)
+}
+
+// Write 写入日志
+func (this *ClickHouseStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
+	if len(accessLogs) == 0 {
+		return nil
+	}
+
+	var buf = &bytes.Buffer{}
+	var encoder = json.NewEncoder(buf)
+	for _, accessLog := range accessLogs {
+		var row = map[string]interface{}{}
+		for _, column := range clickHouseColumns {
+			row[column.Name] = column.Value(accessLog)
+		}
+		err := encoder.Encode(row)
+		if err != nil {
+			remotelogs.Error("ACCESS_LOG_CLICKHOUSE_STORAGE", "marshal data failed: "+err.Error())
+			continue
+		}
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	return this.exec("INSERT INTO "+this.fullTableName()+" FORMAT JSONEachRow", buf)
+}
+
+// Close 关闭
+func (this *ClickHouseStorage) Close() error {
+	return nil
+}
+
+func (this *ClickHouseStorage) fullTableName() string {
+	return "`" + this.config.Database + "`.`" + this.config.Table + "`"
+}
+
+func (this *ClickHouseStorage) createTableSQL() string {
+	var columnSQLs = []string{}
+	for _, column := range clickHouseColumns {
+		columnSQLs = append(columnSQLs, "`"+column.Name+"` "+column.Type)
+	}
+	var sql = "CREATE TABLE IF NOT EXISTS " + this.fullTableName() + " (\n  " + strings.Join(columnSQLs, ",\n  ") + "\n) ENGINE = MergeTree PARTITION BY toYYYYMMDD(timestamp) ORDER BY (serverId, timestamp)"
+	if this.config.TTLDays > 0 {
+		sql += fmt.Sprintf(" TTL timestamp + INTERVAL %d DAY", this.config.TTLDays)
+	}
+	return sql
+}
+
+// 执行SQL，如果有body，则SQL通过query参数传递
+func (this *ClickHouseStorage) exec(sql string, body io.Reader) error {
+	var endpoint = this.config.Endpoint + "/?database=" + url.QueryEscape(this.config.Database)
+	if body == nil {
+		body = strings.NewReader(sql)
+	} else {
+		endpoint += "&query=" + url.QueryEscape(sql)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", strings.ReplaceAll(teaconst.ProductName, " ", "-")+"/"+teaconst.Version)
+	if len(this.config.Username) > 0 {
+		req.Header.Set("X-ClickHouse-User", this.config.Username)
+		req.Header.Set("X-ClickHouse-Key", this.config.Password)
+	}
+
+	client := utils.SharedHttpClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyData, _ := ioutil.ReadAll(resp.Body)
+		return errors.New("ClickHouse response status code: " + fmt.Sprintf("%d", resp.StatusCode) + " content: " + string(bodyData))
+	}
+
+	return nil
+}
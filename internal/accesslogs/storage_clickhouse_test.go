@@ -0,0 +1,113 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClickHouseStorage_Write(t *testing.T) {
+	var locker sync.Mutex
+	var queries = []string{}
+	var rows = []map[string]interface{}{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		locker.Lock()
+		defer locker.Unlock()
+
+		if req.Header.Get("X-ClickHouse-User") != "default" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var query = req.URL.Query().Get("query")
+		if len(query) == 0 {
+			data, _ := ioutil.ReadAll(req.Body)
+			queries = append(queries, string(data))
+			return
+		}
+
+		queries = append(queries, query)
+		var scanner = bufio.NewScanner(req.Body)
+		for scanner.Scan() {
+			var row = map[string]interface{}{}
+			err := json.Unmarshal(scanner.Bytes(), &row)
+			if err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			rows = append(rows, row)
+		}
+	}))
+	defer server.Close()
+
+	storage := NewClickHouseStorage(&ClickHouseStorageConfig{
+		Endpoint: server.URL,
+		Database: "edge",
+		Table:    "accessLogs",
+		Username: "default",
+		TTLDays:  30,
+	})
+	err := storage.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.Write([]*pb.HTTPAccessLog{
+		{
+			ServerId:      1,
+			RequestMethod: "POST",
+			RequestPath:   "/1",
+			Status:        200,
+			Timestamp:     time.Now().Unix(),
+		},
+		{
+			ServerId:      2,
+			RequestMethod: "GET",
+			RequestPath:   "/2",
+			Status:        404,
+			Errors:        []string{"not found"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(queries) != 2 {
+		t.Fatal("expect 2 queries, but got", len(queries))
+	}
+	if !strings.HasPrefix(queries[0], "CREATE TABLE IF NOT EXISTS `edge`.`accessLogs`") || !strings.Contains(queries[0], "TTL timestamp + INTERVAL 30 DAY") {
+		t.Fatal("invalid create table sql:", queries[0])
+	}
+	if queries[1] != "INSERT INTO `edge`.`accessLogs` FORMAT JSONEachRow" {
+		t.Fatal("invalid insert sql:", queries[1])
+	}
+	if len(rows) != 2 {
+		t.Fatal("expect 2 rows, but got", len(rows))
+	}
+	if rows[1]["requestPath"] != "/2" || rows[1]["status"] != float64(404) {
+		t.Fatal("invalid row:", rows[1])
+	}
+	t.Log(queries[0])
+}
+
+func TestClickHouseStorage_Start(t *testing.T) {
+	storage := NewClickHouseStorage(&ClickHouseStorageConfig{
+		Endpoint: "127.0.0.1:8123",
+		Table:    "access-logs;",
+	})
+	err := storage.Start()
+	if err == nil {
+		t.Fatal("invalid table name should be rejected")
+	}
+	t.Log(err)
+}
@@ -1,6 +1,7 @@
 package accesslogs
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -8,10 +9,18 @@ import (
 	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
 	"github.com/TeaOSLab/EdgeAPI/internal/remotelogs"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/TeaOSLab/EdgeCommon/pkg/configutils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs"
+	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs/shared"
+	"github.com/iwind/TeaGo/lists"
+	"github.com/iwind/TeaGo/maps"
+	"github.com/iwind/TeaGo/types"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -79,7 +88,8 @@ func (this *ESStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
 			continue
 		}
 
-		data, err := this.Marshal(accessLog)
+		// ElasticSearch只能使用JSON格式
+		data, err := json.Marshal(accessLog)
 		if err != nil {
 			remotelogs.Error("ACCESS_LOG_ES_STORAGE", "marshal data failed: "+err.Error())
 			continue
@@ -95,15 +105,10 @@ func (this *ESStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
 		return nil
 	}
 
-	req, err := http.NewRequest(http.MethodPost, this.config.Endpoint+"/_bulk", strings.NewReader(bulk.String()))
+	req, err := this.newRequest(http.MethodPost, this.config.Endpoint+"/_bulk", strings.NewReader(bulk.String()))
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", strings.ReplaceAll(teaconst.ProductName, " ", "-")+"/"+teaconst.Version)
-	if len(this.config.Username) > 0 || len(this.config.Password) > 0 {
-		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(this.config.Username+":"+this.config.Password)))
-	}
 	client := utils.SharedHttpClient(10 * time.Second)
 	defer func() {
 		_ = req.Body.Close()
@@ -129,3 +134,297 @@ func (this *ESStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
 func (this *ESStorage) Close() error {
 	return nil
 }
+
+// Query 查询单页日志
+// 要求索引中的 requestId、host 等字符串字段带有 .keyword 子字段（ElasticSearch的默认动态映射）
+// IP范围查询要求 remoteAddr 字段映射为 ip 类型
+func (this *ESStorage) Query(query *Query) (*QueryResult, error) {
+	if len(query.Day) != 8 {
+		return &QueryResult{RequestId: query.RequestId}, nil
+	}
+	day, err := time.ParseInLocation("20060102", query.Day, time.Local)
+	if err != nil {
+		return nil, errors.New("invalid day '" + query.Day + "'")
+	}
+
+	var size = query.Size
+	if size <= 0 {
+		return &QueryResult{RequestId: query.RequestId}, nil
+	}
+	if size > MaxQuerySize {
+		size = MaxQuerySize
+	}
+
+	var filters = []maps.Map{
+		{
+			"range": maps.Map{
+				"timestamp": maps.Map{
+					"gte": day.Unix(),
+					"lt":  day.AddDate(0, 0, 1).Unix(),
+				},
+			},
+		},
+	}
+
+	if query.ServerId > 0 {
+		filters = append(filters, maps.Map{"term": maps.Map{"serverId": query.ServerId}})
+	} else if len(query.ServerIds) > 0 {
+		filters = append(filters, maps.Map{"terms": maps.Map{"serverId": query.ServerIds}})
+	}
+	if query.HasError {
+		filters = append(filters, maps.Map{"range": maps.Map{"status": maps.Map{"gte": 400}}})
+	}
+	if query.FirewallPolicyId > 0 {
+		filters = append(filters, maps.Map{"term": maps.Map{"firewallPolicyId": query.FirewallPolicyId}})
+	}
+	if query.FirewallRuleGroupId > 0 {
+		filters = append(filters, maps.Map{"term": maps.Map{"firewallRuleGroupId": query.FirewallRuleGroupId}})
+	}
+	if query.FirewallRuleSetId > 0 {
+		filters = append(filters, maps.Map{"term": maps.Map{"firewallRuleSetId": query.FirewallRuleSetId}})
+	}
+	if query.HasFirewallPolicy {
+		filters = append(filters, maps.Map{"range": maps.Map{"firewallPolicyId": maps.Map{"gt": 0}}})
+	}
+	if len(query.IP) > 0 {
+		filters = append(filters, this.ipFilter(query.IP))
+	}
+	if len(query.Domain) > 0 {
+		if strings.Contains(query.Domain, "*") {
+			filters = append(filters, maps.Map{"wildcard": maps.Map{"host.keyword": query.Domain}})
+		} else {
+			filters = append(filters, maps.Map{"term": maps.Map{"host.keyword": query.Domain}})
+		}
+	}
+	if len(query.Keyword) > 0 {
+		filters = append(filters, this.keywordFilter(query.Keyword))
+	}
+
+	// 分页
+	var order = "desc"
+	if query.Reverse {
+		order = "asc"
+	}
+	if len(query.RequestId) > 0 {
+		var op = "lt"
+		if query.Reverse {
+			op = "gt"
+		}
+		filters = append(filters, maps.Map{"range": maps.Map{"requestId.keyword": maps.Map{op: query.RequestId}}})
+	}
+
+	accessLogs, err := this.search(this.indexPattern(day), maps.Map{
+		"size": size + 1,
+		"query": maps.Map{
+			"bool": maps.Map{
+				"filter": filters,
+			},
+		},
+		"sort": []maps.Map{
+			{"requestId.keyword": maps.Map{"order": order}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result = &QueryResult{
+		RequestId: query.RequestId,
+	}
+	if int64(len(accessLogs)) > size {
+		accessLogs = accessLogs[:size]
+		result.HasMore = true
+	}
+	if len(accessLogs) == 0 {
+		return result, nil
+	}
+	result.RequestId = accessLogs[len(accessLogs)-1].RequestId
+	if query.Reverse {
+		lists.Reverse(accessLogs)
+	}
+	result.AccessLogs = accessLogs
+	return result, nil
+}
+
+// FindAccessLog 根据请求ID查找单条日志
+func (this *ESStorage) FindAccessLog(requestId string) (*pb.HTTPAccessLog, error) {
+	if len(requestId) == 0 {
+		return nil, nil
+	}
+
+	// 请求ID的前10位为时间戳
+	var day time.Time
+	if regexp.MustCompile(`^\d{10}`).MatchString(requestId) {
+		day = time.Unix(types.Int64(requestId[:10]), 0)
+	}
+
+	accessLogs, err := this.search(this.indexPattern(day), maps.Map{
+		"size": 1,
+		"query": maps.Map{
+			"ids": maps.Map{
+				"values": []string{requestId},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(accessLogs) == 0 {
+		return nil, nil
+	}
+	return accessLogs[0], nil
+}
+
+// 执行查询
+func (this *ESStorage) search(index string, body maps.Map) ([]*pb.HTTPAccessLog, error) {
+	bodyJSON, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := this.newRequest(http.MethodPost, this.config.Endpoint+"/"+url.PathEscape(index)+"/_search?ignore_unavailable=true", bytes.NewReader(bodyJSON))
+	if err != nil {
+		return nil, err
+	}
+	client := utils.SharedHttpClient(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 索引不存在
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("ElasticSearch response status code: " + fmt.Sprintf("%d", resp.StatusCode) + " content: " + string(data))
+	}
+
+	var searchResp = &struct {
+		Hits struct {
+			Hits []struct {
+				Source json.RawMessage `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}{}
+	err = json.Unmarshal(data, searchResp)
+	if err != nil {
+		return nil, errors.New("decode ElasticSearch response failed: " + err.Error())
+	}
+
+	var result = []*pb.HTTPAccessLog{}
+	for _, hit := range searchResp.Hits.Hits {
+		var accessLog = &pb.HTTPAccessLog{}
+		err = json.Unmarshal(hit.Source, accessLog)
+		if err != nil {
+			remotelogs.Error("ACCESS_LOG_ES_STORAGE", "decode access log failed: "+err.Error())
+			continue
+		}
+		result = append(result, accessLog)
+	}
+	return result, nil
+}
+
+// IP查询条件
+func (this *ESStorage) ipFilter(ip string) maps.Map {
+	if strings.Contains(ip, ",") || strings.Contains(ip, "-") {
+		rangeConfig, err := shared.ParseIPRange(ip)
+		if err == nil && len(rangeConfig.IPFrom) > 0 && len(rangeConfig.IPTo) > 0 {
+			return maps.Map{
+				"range": maps.Map{
+					"remoteAddr": maps.Map{
+						"gte": rangeConfig.IPFrom,
+						"lte": rangeConfig.IPTo,
+					},
+				},
+			}
+		}
+	}
+	return maps.Map{"match_phrase": maps.Map{"remoteAddr": ip}}
+}
+
+// 关键词查询条件，和MySQL中的查询方式保持一致
+func (this *ESStorage) keywordFilter(keyword string) maps.Map {
+	if net.ParseIP(keyword) != nil {
+		return this.ipFilter(keyword)
+	}
+	if regexp.MustCompile(`^ip:.+`).MatchString(keyword) {
+		return this.ipFilter(strings.TrimSuffix(keyword[3:], ","))
+	}
+
+	var wildcardKeyword = "*" + regexp.MustCompile(`[*?\\]`).ReplaceAllString(keyword, `\$0`) + "*"
+	var should = []maps.Map{}
+	for _, field := range []string{"remoteAddr", "requestURI", "host", "userAgent"} {
+		should = append(should, maps.Map{"wildcard": maps.Map{field + ".keyword": wildcardKeyword}})
+	}
+	should = append(should, maps.Map{"term": maps.Map{"tags.keyword": keyword}})
+
+	// 请求方法
+	switch keyword {
+	case http.MethodGet, http.MethodPost, http.MethodHead, http.MethodConnect, http.MethodPut, http.MethodTrace, http.MethodOptions, http.MethodDelete, http.MethodPatch:
+		should = append(should, maps.Map{"term": maps.Map{"requestMethod.keyword": keyword}})
+	}
+
+	// 响应状态码
+	if regexp.MustCompile(`^\d{3}$`).MatchString(keyword) {
+		should = append(should, maps.Map{"term": maps.Map{"status": types.Int(keyword)}})
+	}
+	if regexp.MustCompile(`^\d{3}-\d{3}$`).MatchString(keyword) {
+		pieces := strings.Split(keyword, "-")
+		should = append(should, maps.Map{"range": maps.Map{"status": maps.Map{"gte": types.Int(pieces[0]), "lte": types.Int(pieces[1])}}})
+	}
+
+	return maps.Map{
+		"bool": maps.Map{
+			"should":               should,
+			"minimum_should_match": 1,
+		},
+	}
+}
+
+// 某一天对应的索引名称，无法确定的变量使用通配符代替
+func (this *ESStorage) indexPattern(day time.Time) string {
+	return configutils.ParseVariables(this.config.Index, func(varName string) (value string) {
+		if day.IsZero() {
+			return "*"
+		}
+		switch varName {
+		case "year":
+			return strconv.Itoa(day.Year())
+		case "month":
+			return fmt.Sprintf("%02d", day.Month())
+		case "week":
+			_, week := day.ISOWeek()
+			return fmt.Sprintf("%02d", week)
+		case "day":
+			return fmt.Sprintf("%02d", day.Day())
+		case "hour", "minute", "second":
+			return "*"
+		case "date":
+			return fmt.Sprintf("%d%02d%02d", day.Year(), day.Month(), day.Day())
+		}
+		return varName
+	})
+}
+
+// 构造请求
+func (this *ESStorage) newRequest(method string, urlString string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, urlString, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", strings.ReplaceAll(teaconst.ProductName, " ", "-")+"/"+teaconst.Version)
+	if len(this.config.Username) > 0 || len(this.config.Password) > 0 {
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(this.config.Username+":"+this.config.Password)))
+	}
+	return req, nil
+}
@@ -1,8 +1,13 @@
 package accesslogs
 
 import (
+	"encoding/json"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs"
+	"github.com/iwind/TeaGo/types"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -51,3 +56,110 @@ func TestESStorage_Write(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestESStorage_Query(t *testing.T) {
+	var requestBody = map[string]interface{}{}
+	var requestPath string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		requestPath = req.URL.Path
+		data, _ := ioutil.ReadAll(req.Body)
+		_ = json.Unmarshal(data, &requestBody)
+
+		_, _ = writer.Write([]byte(`{"hits":{"hits":[
+{"_source":{"requestId":"1630000000000000000000000000003","serverId":1,"status":500}},
+{"_source":{"requestId":"1630000000000000000000000000002","serverId":1,"status":404}},
+{"_source":{"requestId":"1630000000000000000000000000001","serverId":1,"status":403}}
+]}}`))
+	}))
+	defer server.Close()
+
+	storage := NewESStorage(&serverconfigs.AccessLogESStorageConfig{
+		Endpoint:    server.URL,
+		Index:       "logs-${date}-${hour}",
+		MappingType: "accessLogs",
+	})
+	err := storage.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := storage.Query(&Query{
+		Size:     2,
+		Day:      "20210827",
+		ServerId: 1,
+		HasError: true,
+		Keyword:  "GET",
+		Domain:   "*.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requestPath != "/logs-20210827-*/_search" {
+		t.Fatal("invalid path:", requestPath)
+	}
+	if len(result.AccessLogs) != 2 || !result.HasMore || result.RequestId != "1630000000000000000000000000002" {
+		t.Fatal("invalid result:", result)
+	}
+	if result.AccessLogs[0].Status != 500 {
+		t.Fatal("invalid status:", result.AccessLogs[0].Status)
+	}
+	if types.Int(requestBody["size"]) != 3 {
+		t.Fatal("invalid size:", requestBody["size"])
+	}
+	filters := requestBody["query"].(map[string]interface{})["bool"].(map[string]interface{})["filter"].([]interface{})
+	if len(filters) != 5 {
+		t.Fatal("invalid filters:", filters)
+	}
+	t.Log(requestBody)
+
+	// 往后查询
+	result, err = storage.Query(&Query{
+		Size:      5,
+		Day:       "20210827",
+		RequestId: "1630000000000000000000000000000",
+		Reverse:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.HasMore || len(result.AccessLogs) != 3 || result.RequestId != "1630000000000000000000000000001" {
+		t.Fatal("invalid result:", result)
+	}
+	if result.AccessLogs[0].RequestId != "1630000000000000000000000000001" {
+		t.Fatal("result should be reversed")
+	}
+}
+
+func TestESStorage_FindAccessLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/logs-20210827/_search" {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = writer.Write([]byte(`{"hits":{"hits":[{"_source":{"requestId":"1630050000000000000000000000001","serverId":2}}]}}`))
+	}))
+	defer server.Close()
+
+	storage := NewESStorage(&serverconfigs.AccessLogESStorageConfig{
+		Endpoint:    server.URL,
+		Index:       "logs-${date}",
+		MappingType: "accessLogs",
+	})
+	err := storage.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1630050000 = 2021-08-27 07:40:00 UTC
+	var requestId = "1630050000000000000000000000001"
+	if time.Unix(1630050000, 0).Format("20060102") != "20210827" {
+		t.Skip("skip in current time zone")
+	}
+	accessLog, err := storage.FindAccessLog(requestId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accessLog == nil || accessLog.ServerId != 2 {
+		t.Fatal("invalid access log:", accessLog)
+	}
+}
@@ -1,30 +1,71 @@
 package accesslogs
 
 import (
+	"compress/gzip"
 	"errors"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs"
 	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/types"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
+// 路径中的变量，比如${year}
+var fileVariableRegexp = regexp.MustCompile(`\$\{[^}]+\}`)
+
+// 按时间轮转的周期
+const (
+	FileRotatePeriodHour = "hour"
+	FileRotatePeriodDay  = "day"
+)
+
+// FileRotationConfig 文件轮转配置
+type FileRotationConfig struct {
+	MaxSize  int64  `yaml:"maxSize" json:"maxSize"`   // 单个文件最大尺寸，单位字节，0表示不限制
+	Period   string `yaml:"period" json:"period"`     // 轮转周期：hour、day，为空表示不按时间轮转
+	Compress bool   `yaml:"compress" json:"compress"` // 是否使用gzip压缩轮转后的文件
+	MaxFiles int    `yaml:"maxFiles" json:"maxFiles"` // 最多保留的轮转文件数量，0表示不限制
+	MaxAge   int    `yaml:"maxAge" json:"maxAge"`     // 轮转文件最多保留天数，0表示不限制
+}
+
+// FileStorageConfig 文件存储配置
+type FileStorageConfig struct {
+	*serverconfigs.AccessLogFileStorageConfig
+
+	Rotation *FileRotationConfig `yaml:"rotation" json:"rotation"` // 轮转设置
+}
+
+// 已打开的文件
+type storageFile struct {
+	fp       *os.File
+	size     int64
+	periodId string
+}
+
 // FileStorage 文件存储策略
 type FileStorage struct {
 	BaseStorage
 
-	config *serverconfigs.AccessLogFileStorageConfig
+	config *FileStorageConfig
 
 	writeLocker sync.Mutex
 
-	files       map[string]*os.File // path => *File
+	files       map[string]*storageFile // path => *storageFile
 	filesLocker sync.Mutex
 }
 
 func NewFileStorage(config *serverconfigs.AccessLogFileStorageConfig) *FileStorage {
 	return &FileStorage{
-		config: config,
+		config: &FileStorageConfig{
+			AccessLogFileStorageConfig: config,
+		},
 	}
 }
 
@@ -32,13 +73,34 @@ func (this *FileStorage) Config() interface{} {
 	return this.config
 }
 
+// SetRotation 设置轮转配置
+func (this *FileStorage) SetRotation(rotation *FileRotationConfig) {
+	this.config.Rotation = rotation
+}
+
 // Start 开启
 func (this *FileStorage) Start() error {
 	if len(this.config.Path) == 0 {
 		return errors.New("'path' should not be empty")
 	}
 
-	this.files = map[string]*os.File{}
+	var rotation = this.config.Rotation
+	if rotation != nil {
+		switch rotation.Period {
+		case "", FileRotatePeriodHour, FileRotatePeriodDay:
+		default:
+			return errors.New("invalid rotation period '" + rotation.Period + "'")
+		}
+	}
+
+	this.filesLocker.Lock()
+	this.files = map[string]*storageFile{}
+	this.filesLocker.Unlock()
+
+	// 启动时清理一次过期文件
+	if rotation != nil {
+		this.sweep()
+	}
 
 	return nil
 }
@@ -49,57 +111,96 @@ func (this *FileStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
 		return nil
 	}
 
-	fp := this.fp()
-	if fp == nil {
-		return errors.New("file pointer should not be nil")
-	}
 	this.writeLocker.Lock()
 	defer this.writeLocker.Unlock()
 
+	path, file, err := this.fp()
+	if err != nil {
+		return err
+	}
+
 	for _, accessLog := range accessLogs {
 		data, err := this.Marshal(accessLog)
 		if err != nil {
 			logs.Error(err)
 			continue
 		}
-		_, err = fp.Write(data)
+		data = append(data, '\n')
+		n, err := file.fp.Write(data)
+		file.size += int64(n)
 		if err != nil {
-			_ = this.Close()
-			break
+			// 关闭文件，下次写入时重新打开
+			this.closeFile(path)
+			return err
 		}
-		_, _ = fp.WriteString("\n")
 	}
+
+	// 检查尺寸
+	var rotation = this.config.Rotation
+	if rotation != nil && rotation.MaxSize > 0 && file.size >= rotation.MaxSize {
+		this.rotate(path)
+	}
+
 	return nil
 }
 
+// Reopen 关闭所有文件，下次写入时重新打开
+// 用于文件被外部程序移动或删除后恢复写入
+func (this *FileStorage) Reopen() error {
+	this.writeLocker.Lock()
+	defer this.writeLocker.Unlock()
+
+	return this.Close()
+}
+
 // Close 关闭
 func (this *FileStorage) Close() error {
 	this.filesLocker.Lock()
 	defer this.filesLocker.Unlock()
 
 	var resultErr error
-	for _, f := range this.files {
-		err := f.Close()
+	for path, file := range this.files {
+		err := file.fp.Close()
 		if err != nil {
 			resultErr = err
 		}
+		delete(this.files, path)
 	}
 	return resultErr
 }
 
-func (this *FileStorage) fp() *os.File {
+func (this *FileStorage) fp() (string, *storageFile, error) {
 	path := this.FormatVariables(this.config.Path)
+	var periodId = this.periodId(time.Now())
 
 	this.filesLocker.Lock()
-	defer this.filesLocker.Unlock()
-	fp, ok := this.files[path]
+	file, ok := this.files[path]
+	this.filesLocker.Unlock()
 	if ok {
-		return fp
+		if file.periodId == periodId {
+			return path, file, nil
+		}
+
+		// 按时间轮转
+		this.rotate(path)
 	}
 
+	this.filesLocker.Lock()
+	defer this.filesLocker.Unlock()
+
 	// 关闭其他的文件
-	for _, f := range this.files {
-		_ = f.Close()
+	var pathChanged = false
+	for otherPath, f := range this.files {
+		_ = f.fp.Close()
+		delete(this.files, otherPath)
+		if otherPath != path {
+			pathChanged = true
+		}
+	}
+
+	// 路径中的变量变化后，清理以前的文件
+	if pathChanged && this.config.Rotation != nil {
+		go this.sweep()
 	}
 
 	// 是否创建文件目录
@@ -109,19 +210,203 @@ func (this *FileStorage) fp() *os.File {
 		if os.IsNotExist(err) {
 			err = os.MkdirAll(dir, 0777)
 			if err != nil {
-				logs.Error(err)
-				return nil
+				return "", nil, err
 			}
 		}
 	}
 
 	// 打开新文件
 	fp, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return "", nil, err
+	}
+	stat, err := fp.Stat()
+	if err != nil {
+		_ = fp.Close()
+		return "", nil, err
+	}
+	file = &storageFile{
+		fp:       fp,
+		size:     stat.Size(),
+		periodId: periodId,
+	}
+	this.files[path] = file
+
+	return path, file, nil
+}
+
+// 计算当前时间所在的轮转周期
+func (this *FileStorage) periodId(t time.Time) string {
+	if this.config.Rotation == nil {
+		return ""
+	}
+	switch this.config.Rotation.Period {
+	case FileRotatePeriodHour:
+		return t.Format("2006010215")
+	case FileRotatePeriodDay:
+		return t.Format("20060102")
+	}
+	return ""
+}
+
+func (this *FileStorage) closeFile(path string) {
+	this.filesLocker.Lock()
+	defer this.filesLocker.Unlock()
+
+	file, ok := this.files[path]
+	if ok {
+		_ = file.fp.Close()
+		delete(this.files, path)
+	}
+}
+
+// 轮转文件
+func (this *FileStorage) rotate(path string) {
+	this.closeFile(path)
+
+	var rotation = this.config.Rotation
+	if rotation == nil {
+		return
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil || stat.Size() == 0 {
+		return
+	}
+
+	var rotatedPath = path + "." + time.Now().Format("20060102150405")
+	for i := 1; ; i++ {
+		_, err = os.Stat(rotatedPath)
+		if os.IsNotExist(err) {
+			_, err = os.Stat(rotatedPath + ".gz")
+			if os.IsNotExist(err) {
+				break
+			}
+		}
+		rotatedPath = path + "." + time.Now().Format("20060102150405") + "-" + types.String(i)
+	}
+	err = os.Rename(path, rotatedPath)
 	if err != nil {
 		logs.Error(err)
-		return nil
+		return
+	}
+
+	go func() {
+		if rotation.Compress {
+			err := this.compress(rotatedPath)
+			if err != nil {
+				logs.Error(err)
+			}
+		}
+		this.sweep()
+	}()
+}
+
+// 压缩文件
+func (this *FileStorage) compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	var writer = gzip.NewWriter(dst)
+	_, err = io.Copy(writer, src)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = dst.Close()
+	} else {
+		_ = dst.Close()
+	}
+	if err != nil {
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// 按数量和时间清理轮转后的文件，以及路径变量变化后不再写入的文件
+func (this *FileStorage) sweep() {
+	var rotation = this.config.Rotation
+	if rotation == nil || (rotation.MaxFiles <= 0 && rotation.MaxAge <= 0) {
+		return
 	}
-	this.files[path] = fp
 
-	return fp
+	// 将路径中的变量替换为通配符
+	var configPath = filepath.Clean(this.config.Path)
+	var pieces = fileVariableRegexp.Split(configPath, -1)
+	for index, piece := range pieces {
+		pieces[index] = regexp.QuoteMeta(piece)
+	}
+	pathRegexp, err := regexp.Compile("^" + strings.Join(pieces, ".*") + `(\..+)?$`)
+	if err != nil {
+		return
+	}
+	matches, err := filepath.Glob(fileVariableRegexp.ReplaceAllString(configPath, "*") + "*")
+	if err != nil {
+		return
+	}
+
+	// 正在写入的文件
+	var currentPaths = map[string]bool{
+		filepath.Clean(this.FormatVariables(this.config.Path)): true,
+	}
+	this.filesLocker.Lock()
+	for path := range this.files {
+		currentPaths[filepath.Clean(path)] = true
+	}
+	this.filesLocker.Unlock()
+
+	type rotatedFile struct {
+		path    string
+		modTime time.Time
+	}
+	var files = []*rotatedFile{}
+	for _, match := range matches {
+		match = filepath.Clean(match)
+		if currentPaths[match] || !pathRegexp.MatchString(match) {
+			continue
+		}
+
+		// 正在压缩的文件
+		if strings.HasSuffix(match, ".gz") {
+			_, err = os.Stat(strings.TrimSuffix(match, ".gz"))
+			if err == nil {
+				continue
+			}
+		}
+		stat, err := os.Stat(match)
+		if err != nil || stat.IsDir() {
+			continue
+		}
+		files = append(files, &rotatedFile{
+			path:    match,
+			modTime: stat.ModTime(),
+		})
+	}
+
+	// 从新到旧排列
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	var minTime = time.Now().Add(-time.Duration(rotation.MaxAge) * 24 * time.Hour)
+	for index, file := range files {
+		if (rotation.MaxFiles > 0 && index >= rotation.MaxFiles) || (rotation.MaxAge > 0 && file.modTime.Before(minTime)) {
+			err = os.Remove(file.path)
+			if err != nil {
+				logs.Error(err)
+			}
+		}
+	}
 }
@@ -4,6 +4,10 @@ import (
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs"
 	"github.com/iwind/TeaGo/Tea"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -68,3 +72,135 @@ func TestFileStorage_Write(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestFileStorage_Rotate(t *testing.T) {
+	var dir = t.TempDir()
+	storage := NewFileStorage(&serverconfigs.AccessLogFileStorageConfig{
+		Path: dir + "/access.log",
+	})
+	storage.SetRotation(&FileRotationConfig{
+		MaxSize:  100,
+		Compress: true,
+		MaxFiles: 2,
+	})
+	err := storage.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		err = storage.Write([]*pb.HTTPAccessLog{
+			{
+				RequestPath: "/hello/world/" + strings.Repeat("a", 100),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	matches, err := filepath.Glob(dir + "/access.log.*.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatal("expect 2 rotated files, but got", matches)
+	}
+
+	err = storage.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStorage_Sweep_DatedPath(t *testing.T) {
+	var dir = t.TempDir()
+	var now = time.Now()
+	var files = map[string]time.Time{
+		"access-20200101.log":                   now.Add(-72 * time.Hour),
+		"access-20200101.log.20200101120000.gz": now.Add(-80 * time.Hour),
+		"access-20200102.log":                   now.Add(-3 * time.Hour),
+		"access-20200103.log":                   now.Add(-2 * time.Hour),
+		"access-20200104.log.20200104120000.gz": now.Add(-1 * time.Hour),
+		"other.log":                             now.Add(-72 * time.Hour),
+	}
+	for name, modTime := range files {
+		var path = dir + "/" + name
+		err := ioutil.WriteFile(path, []byte("hello"), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.Chtimes(path, modTime, modTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	storage := NewFileStorage(&serverconfigs.AccessLogFileStorageConfig{
+		Path: dir + "/access-${year}${month}${day}.log",
+	})
+	storage.SetRotation(&FileRotationConfig{
+		MaxFiles: 2,
+		MaxAge:   1,
+	})
+	err := storage.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, shouldExist := range map[string]bool{
+		"access-20200101.log":                   false,
+		"access-20200101.log.20200101120000.gz": false,
+		"access-20200102.log":                   false,
+		"access-20200103.log":                   true,
+		"access-20200104.log.20200104120000.gz": true,
+		"other.log":                             true,
+	} {
+		_, err = os.Stat(dir + "/" + name)
+		if (err == nil) != shouldExist {
+			t.Fatal("unexpected state of '"+name+"', should exist:", shouldExist)
+		}
+	}
+}
+
+func TestFileStorage_Reopen(t *testing.T) {
+	var dir = t.TempDir()
+	storage := NewFileStorage(&serverconfigs.AccessLogFileStorageConfig{
+		Path: dir + "/access.log",
+	})
+	err := storage.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = storage.Close()
+	}()
+
+	err = storage.Write([]*pb.HTTPAccessLog{{RequestPath: "/1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟logrotate移动文件
+	err = os.Rename(dir+"/access.log", dir+"/access.log.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.Reopen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.Write([]*pb.HTTPAccessLog{{RequestPath: "/2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(dir + "/access.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "/2") || strings.Contains(string(data), "/1") {
+		t.Fatal("file should be reopened:", string(data))
+	}
+}
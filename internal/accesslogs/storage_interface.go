@@ -16,6 +16,9 @@ type StorageInterface interface {
 
 	SetOk(ok bool)
 
+	// SetEncoder 设置编码器
+	SetEncoder(encoder EncoderInterface)
+
 	// Config 获取配置
 	Config() interface{}
 
@@ -28,3 +31,19 @@ type StorageInterface interface {
 	// Close 关闭
 	Close() error
 }
+
+// PartialWriteError 部分日志写入失败时返回的错误，只有失败的日志需要重新写入
+type PartialWriteError struct {
+	Err        error
+	AccessLogs []*pb.HTTPAccessLog // 写入失败的日志
+}
+
+func (this *PartialWriteError) Error() string {
+	return this.Err.Error()
+}
+
+// ReopenableInterface 可以重新打开文件的存储
+type ReopenableInterface interface {
+	// Reopen 重新打开
+	Reopen() error
+}
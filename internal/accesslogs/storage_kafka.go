@@ -0,0 +1,202 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/accesslogs/kafka"
+	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
+	"github.com/TeaOSLab/EdgeAPI/internal/remotelogs"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AccessLogStorageTypeKafka Kafka存储类型
+const AccessLogStorageTypeKafka = "kafka"
+
+// 分区方式
+const (
+	KafkaPartitionByServerId   = "serverId"   // 按网站ID分区
+	KafkaPartitionByRoundRobin = "roundRobin" // 轮询分区
+)
+
+// KafkaStorageConfig Kafka存储配置
+type KafkaStorageConfig struct {
+	Brokers     []string `yaml:"brokers" json:"brokers"`         // Broker地址列表，比如 127.0.0.1:9092
+	Topic       string   `yaml:"topic" json:"topic"`             // 主题，支持 ${year}、${day} 等变量
+	ClientId    string   `yaml:"clientId" json:"clientId"`       // 客户端ID
+	Acks        *int16   `yaml:"acks" json:"acks"`               // 确认方式：0 不等待，1 等待Leader，-1 等待所有ISR；不设置时默认为1
+	BatchSize   int      `yaml:"batchSize" json:"batchSize"`     // 每次请求最多发送的日志数量
+	Timeout     int      `yaml:"timeout" json:"timeout"`         // 超时时间，单位秒
+	PartitionBy string   `yaml:"partitionBy" json:"partitionBy"` // 分区方式
+}
+
+// KafkaStorage Kafka存储策略
+type KafkaStorage struct {
+	BaseStorage
+
+	config *KafkaStorageConfig
+	acks   int16
+
+	clientLocker sync.Mutex
+	client       *kafka.Client
+
+	roundRobin uint32
+}
+
+func NewKafkaStorage(config *KafkaStorageConfig) *KafkaStorage {
+	return &KafkaStorage{config: config}
+}
+
+func (this *KafkaStorage) Config() interface{} {
+	return this.config
+}
+
+// Start 开启
+func (this *KafkaStorage) Start() error {
+	var brokers = []string{}
+	for _, broker := range this.config.Brokers {
+		broker = strings.TrimSpace(broker)
+		if len(broker) > 0 {
+			brokers = append(brokers, broker)
+		}
+	}
+	if len(brokers) == 0 {
+		return errors.New("'brokers' should not be empty")
+	}
+	if len(this.config.Topic) == 0 {
+		return errors.New("'topic' should not be empty")
+	}
+	this.acks = kafka.AcksLeader
+	if this.config.Acks != nil {
+		this.acks = *this.config.Acks
+	}
+	switch this.acks {
+	case kafka.AcksNone, kafka.AcksLeader, kafka.AcksAll:
+	default:
+		return errors.New("invalid 'acks' value '" + strconv.Itoa(int(this.acks)) + "'")
+	}
+	if this.config.BatchSize <= 0 {
+		this.config.BatchSize = 1000
+	}
+	if this.config.Timeout <= 0 {
+		this.config.Timeout = 10
+	}
+	if len(this.config.PartitionBy) == 0 {
+		this.config.PartitionBy = KafkaPartitionByServerId
+	}
+
+	var clientId = this.config.ClientId
+	if len(clientId) == 0 {
+		clientId = teaconst.ProcessName
+	}
+
+	this.clientLocker.Lock()
+	this.client = kafka.NewClient(brokers, clientId, time.Duration(this.config.Timeout)*time.Second)
+	this.clientLocker.Unlock()
+
+	return nil
+}
+
+// Write 写入日志
+func (this *KafkaStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
+	if len(accessLogs) == 0 {
+		return nil
+	}
+
+	this.clientLocker.Lock()
+	var client = this.client
+	this.clientLocker.Unlock()
+	if client == nil {
+		return errors.New("kafka client should not be nil")
+	}
+
+	var topic = this.FormatVariables(this.config.Topic)
+	metadata, err := client.Metadata(topic)
+	if err != nil {
+		return err
+	}
+	var partitions = metadata.Partitions
+
+	// 按分区分组
+	var partitionMessages = map[int32][]*kafka.Message{}
+	var partitionLogs = map[int32][]*pb.HTTPAccessLog{} // 和消息一一对应的日志
+	var partitionIds = []int32{}
+	for _, accessLog := range accessLogs {
+		data, err := this.Marshal(accessLog)
+		if err != nil {
+			remotelogs.Error("ACCESS_LOG_KAFKA_STORAGE", "marshal data failed: "+err.Error())
+			continue
+		}
+
+		var partition int32
+		if this.config.PartitionBy == KafkaPartitionByRoundRobin {
+			partition = partitions[atomic.AddUint32(&this.roundRobin, 1)%uint32(len(partitions))]
+		} else {
+			partition = partitions[uint64(accessLog.ServerId)%uint64(len(partitions))]
+		}
+
+		var timestamp = time.Now()
+		if accessLog.Timestamp > 0 {
+			timestamp = time.Unix(accessLog.Timestamp, 0)
+		}
+
+		messages, ok := partitionMessages[partition]
+		if !ok {
+			partitionIds = append(partitionIds, partition)
+		}
+		partitionMessages[partition] = append(messages, &kafka.Message{
+			Key:       []byte(strconv.FormatInt(accessLog.ServerId, 10)),
+			Value:     data,
+			Timestamp: timestamp,
+		})
+		partitionLogs[partition] = append(partitionLogs[partition], accessLog)
+	}
+
+	// 分批发送，只返回发送失败的日志，以免重放时重复写入
+	var resultErr error
+	var failedLogs = []*pb.HTTPAccessLog{}
+	for _, partition := range partitionIds {
+		var messages = partitionMessages[partition]
+		var pendingLogs = partitionLogs[partition]
+		for len(messages) > 0 {
+			var size = this.config.BatchSize
+			if size > len(messages) {
+				size = len(messages)
+			}
+			err = client.Produce(topic, partition, messages[:size], this.acks)
+			if err != nil {
+				resultErr = err
+				failedLogs = append(failedLogs, pendingLogs...)
+				break
+			}
+			messages = messages[size:]
+			pendingLogs = pendingLogs[size:]
+		}
+	}
+	if resultErr != nil {
+		return &PartialWriteError{
+			Err:        resultErr,
+			AccessLogs: failedLogs,
+		}
+	}
+
+	return nil
+}
+
+// Close 关闭
+func (this *KafkaStorage) Close() error {
+	this.clientLocker.Lock()
+	defer this.clientLocker.Unlock()
+
+	if this.client != nil {
+		err := this.client.Close()
+		this.client = nil
+		return err
+	}
+	return nil
+}
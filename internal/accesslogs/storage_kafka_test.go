@@ -0,0 +1,184 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package accesslogs
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/accesslogs/kafka"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"testing"
+	"time"
+)
+
+func TestKafkaStorage_Write(t *testing.T) {
+	broker, err := kafka.NewFakeBroker(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = broker.Close()
+	}()
+
+	var acks = kafka.AcksAll
+	storage := NewKafkaStorage(&KafkaStorageConfig{
+		Brokers:   []string{broker.Addr()},
+		Topic:     "logs-${date}",
+		Acks:      &acks,
+		BatchSize: 2,
+	})
+	err = storage.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.Write([]*pb.HTTPAccessLog{
+		{
+			ServerId:      1,
+			RequestMethod: "POST",
+			RequestPath:   "/1",
+			Timestamp:     time.Now().Unix(),
+		},
+		{
+			ServerId:      1,
+			RequestMethod: "GET",
+			RequestPath:   "/2",
+			Timestamp:     time.Now().Unix(),
+		},
+		{
+			ServerId:      1,
+			RequestMethod: "GET",
+			RequestPath:   "/3",
+			Timestamp:     time.Now().Unix(),
+		},
+		{
+			ServerId:      2,
+			RequestMethod: "GET",
+			RequestPath:   "/4",
+			Timestamp:     time.Now().Unix(),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var topic = storage.FormatVariables("logs-${date}")
+	if broker.CountMessages(topic) != 4 {
+		t.Fatal("expect 4 messages, but got", broker.CountMessages(topic))
+	}
+	if len(broker.Messages(topic, 1)) != 3 || len(broker.Messages(topic, 2)) != 1 {
+		t.Fatal("messages should be partitioned by server id")
+	}
+	for _, message := range broker.Messages(topic, 1) {
+		t.Log(string(message.Key), string(message.Value))
+	}
+
+	err = storage.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKafkaStorage_Write_Error(t *testing.T) {
+	broker, err := kafka.NewFakeBroker(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = broker.Close()
+	}()
+	broker.SetErrorCode(7)
+
+	var acks = kafka.AcksLeader
+	storage := NewKafkaStorage(&KafkaStorageConfig{
+		Brokers: []string{broker.Addr()},
+		Topic:   "logs",
+		Acks:    &acks,
+	})
+	err = storage.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = storage.Close()
+	}()
+
+	err = storage.Write([]*pb.HTTPAccessLog{
+		{
+			ServerId:    1,
+			RequestPath: "/1",
+		},
+	})
+	if err == nil {
+		t.Fatal("expect error")
+	}
+	t.Log(err)
+}
+
+func TestKafkaStorage_Write_PartialError(t *testing.T) {
+	broker, err := kafka.NewFakeBroker(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = broker.Close()
+	}()
+	broker.SetPartitionErrorCode(1, 7)
+
+	var acks = kafka.AcksLeader
+	storage := NewKafkaStorage(&KafkaStorageConfig{
+		Brokers: []string{broker.Addr()},
+		Topic:   "logs",
+		Acks:    &acks,
+	})
+	err = storage.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = storage.Close()
+	}()
+
+	err = storage.Write([]*pb.HTTPAccessLog{
+		{
+			ServerId:    1,
+			RequestPath: "/1",
+		},
+		{
+			ServerId:    2,
+			RequestPath: "/2",
+		},
+	})
+	partialErr, ok := err.(*PartialWriteError)
+	if !ok {
+		t.Fatal("expect partial write error, but got", err)
+	}
+	if len(partialErr.AccessLogs) != 1 || partialErr.AccessLogs[0].RequestPath != "/1" {
+		t.Fatal("only logs in failed partition should be returned")
+	}
+	if broker.CountMessages("logs") != 1 {
+		t.Fatal("expect 1 message, but got", broker.CountMessages("logs"))
+	}
+}
+
+func TestKafkaStorage_Start_Acks(t *testing.T) {
+	for configJSON, expected := range map[string]int16{
+		`{"brokers":["127.0.0.1:9092"],"topic":"logs"}`:           kafka.AcksLeader,
+		`{"brokers":["127.0.0.1:9092"],"topic":"logs","acks":0}`:  kafka.AcksNone,
+		`{"brokers":["127.0.0.1:9092"],"topic":"logs","acks":-1}`: kafka.AcksAll,
+	} {
+		var config = &KafkaStorageConfig{}
+		err := json.Unmarshal([]byte(configJSON), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var storage = NewKafkaStorage(config)
+		err = storage.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if storage.acks != expected {
+			t.Fatal(configJSON, "expected acks:", expected, "but got:", storage.acks)
+		}
+		_ = storage.Close()
+	}
+}
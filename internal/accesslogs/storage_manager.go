@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/events"
 	"github.com/TeaOSLab/EdgeAPI/internal/remotelogs"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs"
@@ -18,6 +19,11 @@ var SharedStorageManager = NewStorageManager()
 
 type StorageManager struct {
 	storageMap map[int64]StorageInterface // policyId => Storage
+	spoolMap   map[int64]*Spool           // policyId => Spool
+	filterMap  map[int64]*Filter          // policyId => Filter
+	spoolDir   string
+
+	retiredSpoolMap map[int64]*Spool // policyId => 已关闭但仍有日志等待重放的Spool
 
 	locker sync.Mutex
 }
@@ -25,10 +31,20 @@ type StorageManager struct {
 func NewStorageManager() *StorageManager {
 	return &StorageManager{
 		storageMap: map[int64]StorageInterface{},
+		spoolMap:   map[int64]*Spool{},
+		filterMap:  map[int64]*Filter{},
+		spoolDir:   Tea.Root + "/data/accesslogs/spool",
+
+		retiredSpoolMap: map[int64]*Spool{},
 	}
 }
 
 func (this *StorageManager) Start() {
+	// 重新加载时重新打开文件
+	events.On(events.EventReload, func() {
+		this.Reopen()
+	})
+
 	var ticker = time.NewTicker(1 * time.Minute)
 	if Tea.IsTesting() {
 		ticker = time.NewTicker(5 * time.Second)
@@ -49,23 +65,135 @@ func (this *StorageManager) Start() {
 	}
 }
 
-// 写入日志
+// Write 写入日志
+// 如果写入失败，则放入缓冲区等待重放
 func (this *StorageManager) Write(policyId int64, accessLogs []*pb.HTTPAccessLog) error {
 	this.locker.Lock()
 	storage, ok := this.storageMap[policyId]
+	spool := this.spoolMap[policyId]
+	filter := this.filterMap[policyId]
 	this.locker.Unlock()
 
 	if !ok {
 		return nil
 	}
 
-	if !storage.IsOk() {
-		return nil
+	// 过滤
+	if filter != nil {
+		accessLogs = filter.Filter(accessLogs)
+		if len(accessLogs) == 0 {
+			return nil
+		}
+	}
+
+	// 缓冲区中仍有日志时，需要排在后面以保证顺序
+	if !storage.IsOk() || (spool != nil && spool.Len() > 0) {
+		if spool == nil {
+			return nil
+		}
+		return spool.Push(accessLogs)
 	}
 
+	err := storage.Write(accessLogs)
+	if err != nil && spool != nil {
+		remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "write policy '"+types.String(policyId)+"' failed: "+err.Error()+", push to spool")
+
+		// 只缓存写入失败的日志
+		partialErr, ok := err.(*PartialWriteError)
+		if ok {
+			return spool.Push(partialErr.AccessLogs)
+		}
+		return spool.Push(accessLogs)
+	}
+	return err
+}
+
+// WriteDirectly 直接写入日志，不经过缓冲区
+func (this *StorageManager) WriteDirectly(policyId int64, accessLogs []*pb.HTTPAccessLog) error {
+	this.locker.Lock()
+	storage, ok := this.storageMap[policyId]
+	filter := this.filterMap[policyId]
+	this.locker.Unlock()
+
+	if !ok {
+		return errors.New("policy '" + types.String(policyId) + "' not found")
+	}
+	if filter != nil {
+		accessLogs = filter.Filter(accessLogs)
+	}
+	if !storage.IsOk() {
+		return errors.New("policy '" + types.String(policyId) + "' is not ready")
+	}
 	return storage.Write(accessLogs)
 }
 
+// Reopen 重新打开所有存储使用的文件
+func (this *StorageManager) Reopen() {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	for policyId, storage := range this.storageMap {
+		reopenable, ok := storage.(ReopenableInterface)
+		if !ok {
+			continue
+		}
+		err := reopenable.Reopen()
+		if err != nil {
+			remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "reopen policy '"+types.String(policyId)+"' failed: "+err.Error())
+		}
+	}
+}
+
+// SpoolStat 读取某个策略的缓冲区统计信息
+func (this *StorageManager) SpoolStat(policyId int64) *SpoolStat {
+	this.locker.Lock()
+	spool, ok := this.spoolMap[policyId]
+	if !ok {
+		spool, ok = this.retiredSpoolMap[policyId]
+	}
+	this.locker.Unlock()
+
+	if !ok {
+		return &SpoolStat{}
+	}
+	return spool.Stat()
+}
+
+// Query 从某个策略的存储中查询日志
+// 如果存储不支持查询，则 ok 为 false
+func (this *StorageManager) Query(policyId int64, query *Query) (result *QueryResult, ok bool, err error) {
+	queryable, ok := this.findQueryable(policyId)
+	if !ok {
+		return nil, false, nil
+	}
+	result, err = queryable.Query(query)
+	return result, true, err
+}
+
+// FindAccessLog 从某个策略的存储中查找单条日志
+// 如果存储不支持查询，则 ok 为 false
+func (this *StorageManager) FindAccessLog(policyId int64, requestId string) (accessLog *pb.HTTPAccessLog, ok bool, err error) {
+	queryable, ok := this.findQueryable(policyId)
+	if !ok {
+		return nil, false, nil
+	}
+	accessLog, err = queryable.FindAccessLog(requestId)
+	return accessLog, true, err
+}
+
+// 查找可以查询的存储
+func (this *StorageManager) findQueryable(policyId int64) (QueryableInterface, bool) {
+	this.locker.Lock()
+	storage, ok := this.storageMap[policyId]
+	this.locker.Unlock()
+
+	if !ok || !storage.IsOk() {
+		return nil, false
+	}
+	queryable, ok := storage.(QueryableInterface)
+	return queryable, ok
+}
+
 // Loop 更新
 func (this *StorageManager) Loop() error {
 	policies, err := models.SharedHTTPAccessLogPolicyDAO.FindAllEnabledAndOnPolicies(nil)
@@ -90,6 +218,9 @@ func (this *StorageManager) Loop() error {
 				remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "close '"+types.String(policyId)+"' failed: "+err.Error())
 			}
 			delete(this.storageMap, policyId)
+			delete(this.spoolMap, policyId)
+			delete(this.retiredSpoolMap, policyId)
+			delete(this.filterMap, policyId)
 			remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "remove '"+types.String(policyId)+"'")
 		}
 	}
@@ -117,6 +248,19 @@ func (this *StorageManager) Loop() error {
 				}
 
 				storage.SetVersion(types.Int(policy.Version))
+				this.updateSpool(policyId, []byte(policy.Options))
+				err = this.updateFilter(policyId, []byte(policy.Options))
+				if err != nil {
+					remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "update policy '"+types.String(policyId)+"' filter failed: "+err.Error())
+					storage.SetOk(false)
+					continue
+				}
+				err = this.updateEncoder(storage, []byte(policy.Options))
+				if err != nil {
+					remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "update policy '"+types.String(policyId)+"' format failed: "+err.Error())
+					storage.SetOk(false)
+					continue
+				}
 				err := storage.Start()
 				if err != nil {
 					remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "start policy '"+types.String(policyId)+"' failed: "+err.Error())
@@ -133,6 +277,17 @@ func (this *StorageManager) Loop() error {
 			}
 			storage.SetVersion(types.Int(policy.Version))
 			this.storageMap[policyId] = storage
+			this.updateSpool(policyId, []byte(policy.Options))
+			err = this.updateFilter(policyId, []byte(policy.Options))
+			if err != nil {
+				remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "update policy '"+types.String(policyId)+"' filter failed: "+err.Error())
+				continue
+			}
+			err = this.updateEncoder(storage, []byte(policy.Options))
+			if err != nil {
+				remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "update policy '"+types.String(policyId)+"' format failed: "+err.Error())
+				continue
+			}
 			err = storage.Start()
 			if err != nil {
 				remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "start policy '"+types.String(policyId)+"' failed: "+err.Error())
@@ -143,20 +298,134 @@ func (this *StorageManager) Loop() error {
 		}
 	}
 
+	// 重放缓冲区中的日志
+	for policyId, spool := range this.spoolMap {
+		storage, ok := this.storageMap[policyId]
+		if !ok || !storage.IsOk() || spool.Len() == 0 {
+			continue
+		}
+		go this.replay(policyId, storage, spool)
+	}
+
+	// 继续重放已关闭的缓冲区，重放完成后删除
+	for policyId, spool := range this.retiredSpoolMap {
+		storage, ok := this.storageMap[policyId]
+		if !ok || spool.Len() == 0 {
+			delete(this.retiredSpoolMap, policyId)
+			continue
+		}
+		if !storage.IsOk() {
+			continue
+		}
+		go this.replay(policyId, storage, spool)
+	}
+
 	return nil
 }
 
+// 更新缓冲区设置，需要在锁内调用
+func (this *StorageManager) updateSpool(policyId int64, optionsJSON []byte) {
+	options, err := ParsePolicyOptions(optionsJSON)
+	if err != nil {
+		remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "parse policy '"+types.String(policyId)+"' options failed: "+err.Error())
+		return
+	}
+
+	spool, ok := this.spoolMap[policyId]
+	if options.Spool == nil || !options.Spool.IsOn {
+		if ok {
+			delete(this.spoolMap, policyId)
+
+			// 不再写入新的日志，但已缓冲的日志仍需要重放，以免丢失
+			var stat = spool.Stat()
+			if stat.Batches > 0 {
+				remotelogs.Println("ACCESS_LOG_STORAGE_MANAGER", "policy '"+types.String(policyId)+"' spool is turned off, "+types.String(stat.Logs)+" logs in "+types.String(stat.Batches)+" batches are waiting to be replayed")
+				this.retiredSpoolMap[policyId] = spool
+			}
+		}
+		return
+	}
+
+	// 重新启用已关闭的缓冲区
+	if !ok {
+		spool, ok = this.retiredSpoolMap[policyId]
+		if ok {
+			delete(this.retiredSpoolMap, policyId)
+			this.spoolMap[policyId] = spool
+		}
+	}
+
+	var maxAge = time.Duration(options.Spool.MaxAge) * time.Second
+	if ok {
+		spool.SetLimits(options.Spool.MaxBytes, maxAge)
+		return
+	}
+	spool, err = NewSpool(this.spoolDir+"/"+types.String(policyId), options.Spool.MaxBytes, maxAge)
+	if err != nil {
+		remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "create policy '"+types.String(policyId)+"' spool failed: "+err.Error())
+		return
+	}
+	this.spoolMap[policyId] = spool
+}
+
+// 更新过滤器，需要在锁内调用
+func (this *StorageManager) updateFilter(policyId int64, optionsJSON []byte) error {
+	options, err := ParsePolicyOptions(optionsJSON)
+	if err != nil {
+		return err
+	}
+	filter, err := NewFilter(options.Filter)
+	if err != nil {
+		return err
+	}
+	if filter == nil {
+		delete(this.filterMap, policyId)
+	} else {
+		this.filterMap[policyId] = filter
+	}
+	return nil
+}
+
+// 更新编码器
+func (this *StorageManager) updateEncoder(storage StorageInterface, optionsJSON []byte) error {
+	options, err := ParsePolicyOptions(optionsJSON)
+	if err != nil {
+		return err
+	}
+	encoder, err := NewEncoder(options.Format)
+	if err != nil {
+		return err
+	}
+	storage.SetEncoder(encoder)
+	return nil
+}
+
+// 重放缓冲区
+func (this *StorageManager) replay(policyId int64, storage StorageInterface, spool *Spool) {
+	count, err := spool.Replay(storage.Write)
+	if count > 0 {
+		remotelogs.Println("ACCESS_LOG_STORAGE_MANAGER", "replay "+types.String(count)+" logs to policy '"+types.String(policyId)+"'")
+	}
+	if err != nil {
+		remotelogs.Error("ACCESS_LOG_STORAGE_MANAGER", "replay policy '"+types.String(policyId)+"' failed: "+err.Error())
+	}
+}
+
 func (this *StorageManager) createStorage(storageType string, optionsJSON []byte) (StorageInterface, error) {
 	switch storageType {
 	case serverconfigs.AccessLogStorageTypeFile:
-		var config = &serverconfigs.AccessLogFileStorageConfig{}
+		var config = &FileStorageConfig{
+			AccessLogFileStorageConfig: &serverconfigs.AccessLogFileStorageConfig{},
+		}
 		if len(optionsJSON) > 0 {
 			err := json.Unmarshal(optionsJSON, config)
 			if err != nil {
 				return nil, err
 			}
 		}
-		return NewFileStorage(config), nil
+		var storage = NewFileStorage(config.AccessLogFileStorageConfig)
+		storage.SetRotation(config.Rotation)
+		return storage, nil
 	case serverconfigs.AccessLogStorageTypeES:
 		var config = &serverconfigs.AccessLogESStorageConfig{}
 		if len(optionsJSON) > 0 {
@@ -193,6 +462,24 @@ func (this *StorageManager) createStorage(storageType string, optionsJSON []byte
 			}
 		}
 		return NewCommandStorage(config), nil
+	case AccessLogStorageTypeKafka:
+		var config = &KafkaStorageConfig{}
+		if len(optionsJSON) > 0 {
+			err := json.Unmarshal(optionsJSON, config)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return NewKafkaStorage(config), nil
+	case AccessLogStorageTypeClickHouse:
+		var config = &ClickHouseStorageConfig{}
+		if len(optionsJSON) > 0 {
+			err := json.Unmarshal(optionsJSON, config)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return NewClickHouseStorage(config), nil
 	}
 
 	return nil, errors.New("invalid policy type '" + storageType + "'")
@@ -1,6 +1,7 @@
 package accesslogs
 
 import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"github.com/iwind/TeaGo/dbs"
 	"testing"
 )
@@ -15,3 +16,37 @@ func TestStorageManager_Loop(t *testing.T) {
 	}
 	t.Log(storage.storageMap)
 }
+
+func TestStorageManager_UpdateSpool_TurnOff(t *testing.T) {
+	var manager = NewStorageManager()
+	manager.spoolDir = t.TempDir()
+
+	manager.updateSpool(1, []byte(`{"spool":{"isOn":true}}`))
+	spool, ok := manager.spoolMap[1]
+	if !ok {
+		t.Fatal("spool should be created")
+	}
+	err := spool.Push([]*pb.HTTPAccessLog{{RequestPath: "/1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 关闭后保留已缓冲的日志等待重放
+	manager.updateSpool(1, []byte(`{}`))
+	_, ok = manager.spoolMap[1]
+	if ok {
+		t.Fatal("spool should not accept new logs after turned off")
+	}
+	if manager.retiredSpoolMap[1] != spool {
+		t.Fatal("spool with logs should be kept for replay")
+	}
+	if manager.SpoolStat(1).Logs != 1 {
+		t.Fatal("expect 1 log waiting for replay")
+	}
+
+	// 重新启用
+	manager.updateSpool(1, []byte(`{"spool":{"isOn":true}}`))
+	if manager.spoolMap[1] != spool || len(manager.retiredSpoolMap) != 0 {
+		t.Fatal("retired spool should be reused")
+	}
+}
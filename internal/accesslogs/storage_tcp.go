@@ -68,12 +68,12 @@ func (this *TCPStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
 		_, err = conn.Write(data)
 		if err != nil {
 			_ = this.Close()
-			break
+			return err
 		}
 		_, err = conn.Write([]byte("\n"))
 		if err != nil {
 			_ = this.Close()
-			break
+			return err
 		}
 	}
 
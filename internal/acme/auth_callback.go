@@ -1,3 +1,6 @@
 package acme
 
 type AuthCallback func(domain, token, keyAuth string)
+
+// TLSALPNAuthCallback TLS-ALPN-01认证回调，certData和keyData为PEM格式的认证证书和私钥
+type TLSALPNAuthCallback func(domain, token, keyAuth string, certData []byte, keyData []byte)
@@ -3,8 +3,43 @@ package acme
 import (
 	"crypto/x509"
 	"encoding/base64"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/go-acme/lego/v4/certcrypto"
 )
 
+type KeyType = string
+
+// 证书私钥类型
+const (
+	KeyTypeEC256   KeyType = "EC256"
+	KeyTypeEC384   KeyType = "EC384"
+	KeyTypeRSA2048 KeyType = "RSA2048"
+	KeyTypeRSA4096 KeyType = "RSA4096"
+)
+
+// DefaultKeyType 默认的证书私钥类型
+const DefaultKeyType = KeyTypeRSA2048
+
+// FindAllKeyTypes 所有支持的证书私钥类型
+func FindAllKeyTypes() []KeyType {
+	return []KeyType{KeyTypeEC256, KeyTypeEC384, KeyTypeRSA2048, KeyTypeRSA4096}
+}
+
+// ParseKeyType 转换为lego中的私钥类型，为空时使用默认类型
+func ParseKeyType(keyType KeyType) (certcrypto.KeyType, error) {
+	switch keyType {
+	case KeyTypeEC256:
+		return certcrypto.EC256, nil
+	case KeyTypeEC384:
+		return certcrypto.EC384, nil
+	case KeyTypeRSA2048, "":
+		return certcrypto.RSA2048, nil
+	case KeyTypeRSA4096:
+		return certcrypto.RSA4096, nil
+	}
+	return "", errors.New("unsupported key type '" + keyType + "'")
+}
+
 func ParsePrivateKeyFromBase64(base64String string) (interface{}, error) {
 	data, err := base64.StdEncoding.DecodeString(base64String)
 	if err != nil {
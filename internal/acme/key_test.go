@@ -0,0 +1,43 @@
+package acme
+
+import (
+	"github.com/go-acme/lego/v4/certcrypto"
+	"testing"
+)
+
+func TestParseKeyType(t *testing.T) {
+	for keyType, expected := range map[KeyType]certcrypto.KeyType{
+		"":             certcrypto.RSA2048,
+		KeyTypeEC256:   certcrypto.EC256,
+		KeyTypeEC384:   certcrypto.EC384,
+		KeyTypeRSA2048: certcrypto.RSA2048,
+		KeyTypeRSA4096: certcrypto.RSA4096,
+	} {
+		result, err := ParseKeyType(keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != expected {
+			t.Fatal("key type:", keyType, "expected:", expected, "but got:", result)
+		}
+	}
+
+	_, err := ParseKeyType("DSA")
+	if err == nil {
+		t.Fatal("should fail with unsupported key type")
+	}
+}
+
+func TestFindProviderWithCode(t *testing.T) {
+	var provider = FindProviderWithCode("zerossl")
+	if provider == nil || !provider.RequireEAB {
+		t.Fatal("zerossl should require EAB")
+	}
+	var found = FindProviderWithDirectoryURL(provider.DirectoryURL)
+	if found == nil || found.Code != provider.Code {
+		t.Fatal("should find provider with directory url")
+	}
+	if FindProviderWithCode("unknown") != nil {
+		t.Fatal("should not find unknown provider")
+	}
+}
@@ -0,0 +1,73 @@
+package acme
+
+import "github.com/go-acme/lego/v4/lego"
+
+// DefaultProviderCode 默认的证书服务商
+const DefaultProviderCode = "letsencrypt"
+
+// Provider ACME证书服务商
+type Provider struct {
+	Name         string `json:"name"`         // 名称
+	Code         string `json:"code"`         // 代号
+	Description  string `json:"description"`  // 描述
+	DirectoryURL string `json:"directoryURL"` // ACME目录URL
+	RequireEAB   bool   `json:"requireEAB"`   // 是否需要External Account Binding
+}
+
+// FindAllProviders 所有内置的证书服务商
+// 其他兼容ACME协议的服务（比如内部的step-ca）可以直接填写目录URL
+func FindAllProviders() []*Provider {
+	return []*Provider{
+		{
+			Name:         "Let's Encrypt",
+			Code:         "letsencrypt",
+			Description:  "非盈利组织Let's Encrypt提供的免费证书。",
+			DirectoryURL: lego.LEDirectoryProduction,
+		},
+		{
+			Name:         "Let's Encrypt（测试环境）",
+			Code:         "letsencryptStaging",
+			Description:  "Let's Encrypt的测试环境，频率限制较宽松，但证书不被浏览器信任，仅用于测试。",
+			DirectoryURL: lego.LEDirectoryStaging,
+		},
+		{
+			Name:         "ZeroSSL",
+			Code:         "zerossl",
+			Description:  "ZeroSSL提供的免费证书，需要在ZeroSSL控制台获取EAB凭证。",
+			DirectoryURL: "https://acme.zerossl.com/v2/DV90",
+			RequireEAB:   true,
+		},
+		{
+			Name:         "Buypass",
+			Code:         "buypass",
+			Description:  "Buypass Go SSL提供的免费证书，有效期为180天。",
+			DirectoryURL: "https://api.buypass.com/acme/directory",
+		},
+		{
+			Name:         "Buypass（测试环境）",
+			Code:         "buypassStaging",
+			Description:  "Buypass的测试环境，证书不被浏览器信任，仅用于测试。",
+			DirectoryURL: "https://api.test4.buypass.no/acme/directory",
+		},
+	}
+}
+
+// FindProviderWithCode 根据代号查找服务商
+func FindProviderWithCode(code string) *Provider {
+	for _, provider := range FindAllProviders() {
+		if provider.Code == code {
+			return provider
+		}
+	}
+	return nil
+}
+
+// FindProviderWithDirectoryURL 根据目录URL查找服务商
+func FindProviderWithDirectoryURL(directoryURL string) *Provider {
+	for _, provider := range FindAllProviders() {
+		if provider.DirectoryURL == directoryURL {
+			return provider
+		}
+	}
+	return nil
+}
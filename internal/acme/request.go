@@ -2,20 +2,25 @@ package acme
 
 import (
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
-	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/lego"
 	acmelog "github.com/go-acme/lego/v4/log"
 	"github.com/go-acme/lego/v4/registration"
 	"io/ioutil"
 	"log"
+	"strings"
+	"sync"
 )
 
+// 全局的ACME日志只需要设置一次，避免并发任务同时修改
+var discardLoggerOnce = &sync.Once{}
+
 type Request struct {
 	debug bool
 
-	task   *Task
-	onAuth AuthCallback
+	task          *Task
+	onAuth        AuthCallback
+	onTLSALPNAuth TLSALPNAuthCallback
 }
 
 func NewRequest(task *Task) *Request {
@@ -32,27 +37,48 @@ func (this *Request) OnAuth(onAuth AuthCallback) {
 	this.onAuth = onAuth
 }
 
+func (this *Request) OnTLSALPNAuth(onAuth TLSALPNAuthCallback) {
+	this.onTLSALPNAuth = onAuth
+}
+
+// Run 按顺序尝试各个认证方式，直到有一个成功为止
 func (this *Request) Run() (certData []byte, keyData []byte, err error) {
-	switch this.task.AuthType {
+	var authTypes = this.task.FindAuthTypes()
+	if len(authTypes) == 0 {
+		err = errors.New("'authType' must not be empty")
+		return
+	}
+
+	var errStrings = []string{}
+	for _, authType := range authTypes {
+		certData, keyData, err = this.runAuthType(authType)
+		if err == nil {
+			return
+		}
+		if len(authTypes) == 1 {
+			return
+		}
+		errStrings = append(errStrings, "["+authType+"]"+err.Error())
+	}
+	err = errors.New(strings.Join(errStrings, "; "))
+	return
+}
+
+func (this *Request) runAuthType(authType AuthType) (certData []byte, keyData []byte, err error) {
+	switch authType {
 	case AuthTypeDNS:
 		return this.runDNS()
 	case AuthTypeHTTP:
 		return this.runHTTP()
+	case AuthTypeTLSALPN:
+		return this.runTLSALPN()
 	default:
-		err = errors.New("invalid task type '" + this.task.AuthType + "'")
+		err = errors.New("invalid task type '" + authType + "'")
 		return
 	}
 }
 
 func (this *Request) runDNS() (certData []byte, keyData []byte, err error) {
-	if !this.debug {
-		acmelog.Logger = log.New(ioutil.Discard, "", log.LstdFlags)
-	}
-
-	if this.task.User == nil {
-		err = errors.New("'user' must not be nil")
-		return
-	}
 	if this.task.DNSProvider == nil {
 		err = errors.New("'dnsProvider' must not be nil")
 		return
@@ -66,66 +92,71 @@ func (this *Request) runDNS() (certData []byte, keyData []byte, err error) {
 		return
 	}
 
-	config := lego.NewConfig(this.task.User)
-	config.Certificate.KeyType = certcrypto.RSA2048
+	client, err := this.newClient()
+	if err != nil {
+		return nil, nil, err
+	}
 
-	client, err := lego.NewClient(config)
+	err = client.Challenge.SetDNS01Provider(NewDNSProvider(this.task.DNSProvider))
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// 注册用户
-	resource := this.task.User.GetRegistration()
-	if resource != nil {
-		resource, err = client.Registration.QueryRegistration()
-		if err != nil {
-			return nil, nil, err
-		}
-	} else {
-		resource, err := client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
-		if err != nil {
-			return nil, nil, err
-		}
-		err = this.task.User.Register(resource)
-		if err != nil {
-			return nil, nil, err
-		}
+	return this.obtain(client)
+}
+
+func (this *Request) runHTTP() (certData []byte, keyData []byte, err error) {
+	client, err := this.newClient()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	err = client.Challenge.SetDNS01Provider(NewDNSProvider(this.task.DNSProvider))
+	err = client.Challenge.SetHTTP01Provider(NewHTTPProvider(this.onAuth))
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// 申请证书
-	request := certificate.ObtainRequest{
-		Domains: this.task.Domains,
-		Bundle:  true,
+	return this.obtain(client)
+}
+
+func (this *Request) runTLSALPN() (certData []byte, keyData []byte, err error) {
+	client, err := this.newClient()
+	if err != nil {
+		return nil, nil, err
 	}
-	certResource, err := client.Certificate.Obtain(request)
+
+	err = client.Challenge.SetTLSALPN01Provider(NewTLSALPNProvider(this.onTLSALPNAuth))
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return certResource.Certificate, certResource.PrivateKey, nil
+	return this.obtain(client)
 }
 
-func (this *Request) runHTTP() (certData []byte, keyData []byte, err error) {
+// 创建客户端，并注册用户
+func (this *Request) newClient() (*lego.Client, error) {
 	if !this.debug {
-		acmelog.Logger = log.New(ioutil.Discard, "", log.LstdFlags)
+		discardLoggerOnce.Do(func() {
+			acmelog.Logger = log.New(ioutil.Discard, "", log.LstdFlags)
+		})
 	}
 
 	if this.task.User == nil {
-		err = errors.New("'user' must not be nil")
-		return
+		return nil, errors.New("'user' must not be nil")
+	}
+
+	keyType, err := ParseKeyType(this.task.KeyType)
+	if err != nil {
+		return nil, err
 	}
 
 	config := lego.NewConfig(this.task.User)
-	config.Certificate.KeyType = certcrypto.RSA2048
+	config.CADirURL = this.task.User.GetDirectoryURL()
+	config.Certificate.KeyType = keyType
 
 	client, err := lego.NewClient(config)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// 注册用户
@@ -133,25 +164,33 @@ func (this *Request) runHTTP() (certData []byte, keyData []byte, err error) {
 	if resource != nil {
 		resource, err = client.Registration.QueryRegistration()
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	} else {
-		resource, err := client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+		var eab = this.task.User.GetEAB()
+		if eab != nil {
+			resource, err = client.Registration.RegisterWithExternalAccountBinding(registration.RegisterEABOptions{
+				TermsOfServiceAgreed: true,
+				Kid:                  eab.KeyId,
+				HmacEncoded:          eab.HMACKey,
+			})
+		} else {
+			resource, err = client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+		}
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		err = this.task.User.Register(resource)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	}
 
-	err = client.Challenge.SetHTTP01Provider(NewHTTPProvider(this.onAuth))
-	if err != nil {
-		return nil, nil, err
-	}
+	return client, nil
+}
 
-	// 申请证书
+// 申请证书
+func (this *Request) obtain(client *lego.Client) (certData []byte, keyData []byte, err error) {
 	request := certificate.ObtainRequest{
 		Domains: this.task.Domains,
 		Bundle:  true,
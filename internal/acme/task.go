@@ -5,16 +5,56 @@ import "github.com/TeaOSLab/EdgeAPI/internal/dnsclients"
 type AuthType = string
 
 const (
-	AuthTypeDNS  AuthType = "dns"
-	AuthTypeHTTP AuthType = "http"
+	AuthTypeDNS     AuthType = "dns"
+	AuthTypeHTTP    AuthType = "http"
+	AuthTypeTLSALPN AuthType = "tls-alpn"
 )
 
+// FindAllAuthTypes 所有支持的认证方式
+func FindAllAuthTypes() []AuthType {
+	return []AuthType{AuthTypeDNS, AuthTypeHTTP, AuthTypeTLSALPN}
+}
+
+// IsValidAuthType 判断认证方式是否有效
+func IsValidAuthType(authType AuthType) bool {
+	for _, t := range FindAllAuthTypes() {
+		if t == authType {
+			return true
+		}
+	}
+	return false
+}
+
 type Task struct {
-	User     *User
-	AuthType AuthType
-	Domains  []string
+	User      *User
+	AuthType  AuthType
+	AuthTypes []AuthType // 按顺序尝试的认证方式，前一种失败后尝试下一种，为空时只使用AuthType
+	Domains   []string
+	KeyType   KeyType // 证书私钥类型，为空时使用默认类型
 
 	// DNS相关
 	DNSProvider dnsclients.ProviderInterface
 	DNSDomain   string
 }
+
+// FindAuthTypes 按顺序列出需要尝试的认证方式
+func (this *Task) FindAuthTypes() []AuthType {
+	var result = []AuthType{}
+	var authTypes = this.AuthTypes
+	if len(authTypes) == 0 && len(this.AuthType) > 0 {
+		authTypes = []AuthType{this.AuthType}
+	}
+	for _, authType := range authTypes {
+		var exists = false
+		for _, t := range result {
+			if t == authType {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			result = append(result, authType)
+		}
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package acme
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+	"testing"
+)
+
+func TestTask_FindAuthTypes(t *testing.T) {
+	for _, testCase := range []struct {
+		task     *Task
+		expected string
+	}{
+		{&Task{}, ""},
+		{&Task{AuthType: AuthTypeDNS}, "dns"},
+		{&Task{AuthType: AuthTypeDNS, AuthTypes: []AuthType{AuthTypeHTTP, AuthTypeDNS}}, "http,dns"},
+		{&Task{AuthTypes: []AuthType{AuthTypeTLSALPN, AuthTypeHTTP, AuthTypeTLSALPN}}, "tls-alpn,http"},
+	} {
+		var result = strings.Join(testCase.task.FindAuthTypes(), ",")
+		if result != testCase.expected {
+			t.Fatal("expected:", testCase.expected, "but got:", result)
+		}
+	}
+}
+
+func TestIsValidAuthType(t *testing.T) {
+	for _, authType := range FindAllAuthTypes() {
+		if !IsValidAuthType(authType) {
+			t.Fatal("'" + authType + "' should be valid")
+		}
+	}
+	if IsValidAuthType("email") {
+		t.Fatal("'email' should be invalid")
+	}
+}
+
+func TestTLSALPNProvider_Present(t *testing.T) {
+	var resultDomain string
+	var resultKeyAuth string
+	var resultCert []byte
+	var resultKey []byte
+	provider := NewTLSALPNProvider(func(domain, token, keyAuth string, certData []byte, keyData []byte) {
+		resultDomain = domain
+		resultKeyAuth = keyAuth
+		resultCert = certData
+		resultKey = keyData
+	})
+	err := provider.Present("example.com", "TOKEN", "TOKEN.KEY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resultDomain != "example.com" || resultKeyAuth != "TOKEN.KEY" {
+		t.Fatal("unexpected domain or key auth:", resultDomain, resultKeyAuth)
+	}
+
+	pair, err := tls.X509KeyPair(resultCert, resultKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cert.DNSNames) != 1 || cert.DNSNames[0] != "example.com" {
+		t.Fatal("unexpected dns names:", cert.DNSNames)
+	}
+}
@@ -0,0 +1,30 @@
+package acme
+
+import "github.com/go-acme/lego/v4/challenge/tlsalpn01"
+
+// TLSALPNProvider TLS-ALPN-01认证
+// 认证证书由边缘节点在ALPN协议为acme-tls/1的握手中提供
+type TLSALPNProvider struct {
+	onAuth TLSALPNAuthCallback
+}
+
+func NewTLSALPNProvider(onAuth TLSALPNAuthCallback) *TLSALPNProvider {
+	return &TLSALPNProvider{
+		onAuth: onAuth,
+	}
+}
+
+func (this *TLSALPNProvider) Present(domain, token, keyAuth string) error {
+	certData, keyData, err := tlsalpn01.ChallengeBlocks(domain, keyAuth)
+	if err != nil {
+		return err
+	}
+	if this.onAuth != nil {
+		this.onAuth(domain, token, keyAuth, certData, keyData)
+	}
+	return nil
+}
+
+func (this *TLSALPNProvider) CleanUp(domain, token, keyAuth string) error {
+	return nil
+}
@@ -3,6 +3,7 @@ package acme
 import (
 	"crypto"
 	"encoding/json"
+	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/registration"
 )
 
@@ -11,6 +12,15 @@ type User struct {
 	resource     *registration.Resource
 	key          crypto.PrivateKey
 	registerFunc func(resource *registration.Resource) error
+
+	directoryURL string
+	eab          *EAB
+}
+
+// EAB External Account Binding凭证，ZeroSSL等服务商注册用户时需要
+type EAB struct {
+	KeyId   string `json:"keyId"`   // Key ID
+	HMACKey string `json:"hmacKey"` // Base64URL编码的HMAC密钥
 }
 
 func NewUser(email string, key crypto.PrivateKey, registerFunc func(resource *registration.Resource) error) *User {
@@ -47,3 +57,29 @@ func (this *User) Register(resource *registration.Resource) error {
 	this.resource = resource
 	return this.registerFunc(resource)
 }
+
+// GetDirectoryURL 获取ACME目录URL，为空时使用Let's Encrypt
+func (this *User) GetDirectoryURL() string {
+	if len(this.directoryURL) == 0 {
+		return lego.LEDirectoryProduction
+	}
+	return this.directoryURL
+}
+
+// SetDirectoryURL 设置ACME目录URL
+func (this *User) SetDirectoryURL(directoryURL string) {
+	this.directoryURL = directoryURL
+}
+
+// GetEAB 获取External Account Binding凭证
+func (this *User) GetEAB() *EAB {
+	return this.eab
+}
+
+// SetEAB 设置External Account Binding凭证
+func (this *User) SetEAB(eab *EAB) {
+	if eab != nil && len(eab.KeyId) == 0 {
+		eab = nil
+	}
+	this.eab = eab
+}
@@ -139,6 +139,9 @@ func (this *AppCmd) Run(main func()) {
 		case "restart":
 			this.runRestart()
 			return
+		case "reload":
+			this.runReload()
+			return
 		case "status":
 			this.runStatus()
 			return
@@ -214,6 +217,23 @@ func (this *AppCmd) runRestart() {
 	this.runStart()
 }
 
+// 重新加载
+func (this *AppCmd) runReload() {
+	var pid = this.getPID()
+	if pid == 0 {
+		fmt.Println(this.product + " not started yet")
+		return
+	}
+
+	_, err := this.sock.Send(&gosock.Command{Code: "reload"})
+	if err != nil {
+		fmt.Println(this.product+" reload failed:", err.Error())
+		return
+	}
+
+	fmt.Println(this.product+" reloaded ok, pid:", types.String(pid))
+}
+
 // 状态
 func (this *AppCmd) runStatus() {
 	var pid = this.getPID()
@@ -13,11 +13,19 @@ import (
 var sharedAPIConfig *APIConfig = nil
 var PaddingId string
 
+// AccessLogConfig 访问日志写入配置
+type AccessLogConfig struct {
+	BatchSize     int `yaml:"batchSize" json:"batchSize"`         // 每条INSERT语句最多包含的日志数量
+	FlushInterval int `yaml:"flushInterval" json:"flushInterval"` // 缓冲区刷新间隔，单位毫秒，0表示不使用缓冲区
+}
+
 // API节点配置
 type APIConfig struct {
 	NodeId string `yaml:"nodeId" json:"nodeId"`
 	Secret string `yaml:"secret" json:"secret"`
 
+	AccessLog *AccessLogConfig `yaml:"accessLog,omitempty" json:"accessLog"` // 访问日志写入配置
+
 	numberId int64 // 数字ID
 }
 
@@ -0,0 +1,68 @@
+package configs
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/iwind/TeaGo/Tea"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	SecretKeysFilename = "secret.key"           // 主密钥文件，和api.yaml放在同一个目录下
+	SecretKeysEnv      = "EDGE_API_SECRET_KEYS" // 主密钥环境变量，多个密钥用逗号隔开
+	SecretKeyLength    = 32                     // 主密钥长度
+)
+
+// LoadSecretKeys 读取用来加密敏感数据的主密钥
+// 优先读取环境变量，其次读取密钥文件；第一个密钥用来加密，其余的只用来解密以前的数据，方便轮换密钥
+// 没有设置任何密钥时返回空，此时敏感数据不加密
+func LoadSecretKeys() ([][]byte, error) {
+	var pieces []string
+	var envValue = os.Getenv(SecretKeysEnv)
+	if len(envValue) > 0 {
+		pieces = strings.Split(envValue, ",")
+	} else {
+		data, err := ioutil.ReadFile(Tea.ConfigFile(SecretKeysFilename))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		pieces = strings.Split(string(data), "\n")
+	}
+	return ParseSecretKeys(pieces)
+}
+
+// ParseSecretKeys 解析主密钥列表，忽略空行和以#开头的注释
+func ParseSecretKeys(pieces []string) ([][]byte, error) {
+	var result = [][]byte{}
+	for _, piece := range pieces {
+		piece = strings.TrimSpace(piece)
+		if len(piece) == 0 || strings.HasPrefix(piece, "#") {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(piece)
+		if err != nil {
+			return nil, errors.New("invalid secret key: " + err.Error())
+		}
+		if len(key) != SecretKeyLength {
+			return nil, errors.New("invalid secret key: key length should be 32 bytes")
+		}
+		result = append(result, key)
+	}
+	return result, nil
+}
+
+// GenerateSecretKey 生成新的主密钥
+func GenerateSecretKey() (string, error) {
+	var key = make([]byte, SecretKeyLength)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
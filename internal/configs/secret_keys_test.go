@@ -0,0 +1,26 @@
+package configs
+
+import (
+	"testing"
+)
+
+func TestParseSecretKeys(t *testing.T) {
+	key, err := GenerateSecretKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("key:", key)
+
+	keys, err := ParseSecretKeys([]string{"# current key", key, "", "  " + key + "  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Fatal("expected 2 keys, but got:", len(keys))
+	}
+
+	_, err = ParseSecretKeys([]string{"MTIzNDU2"})
+	if err == nil {
+		t.Fatal("short key should be invalid")
+	}
+}
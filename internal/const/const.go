@@ -10,7 +10,7 @@ const (
 	Role = "api"
 
 	EncryptKey    = "8f983f4d69b83aaa0d74b21a212f6967"
-	EncryptMethod = "aes-256-cfb"
+	EncryptMethod = "aes-256-cfb" // 旧版本节点令牌使用的加密方法，新版本节点使用带认证的加密信封
 
 	ErrServer = "服务器出了点小问题，请稍后重试"
 
@@ -1,11 +1,16 @@
 package acme
 
 import (
+	"github.com/TeaOSLab/EdgeAPI/internal/acme"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
+	"time"
 )
 
+// TLS-ALPN认证证书的有效期，超出后不再提供给边缘节点
+const ACMETLSALPNAuthLifeSeconds = 3600
+
 type ACMEAuthenticationDAO dbs.DAO
 
 func NewACMEAuthenticationDAO() *ACMEAuthenticationDAO {
@@ -31,9 +36,24 @@ func init() {
 func (this *ACMEAuthenticationDAO) CreateAuth(tx *dbs.Tx, taskId int64, domain string, token string, key string) error {
 	op := NewACMEAuthenticationOperator()
 	op.TaskId = taskId
+	op.Type = acme.AuthTypeHTTP
 	op.Domain = domain
 	op.Token = token
 	op.Key = key
+	op.CreatedAt = time.Now().Unix()
+	err := this.Save(tx, op)
+	return err
+}
+
+// CreateTLSALPNAuth 创建TLS-ALPN认证信息
+func (this *ACMEAuthenticationDAO) CreateTLSALPNAuth(tx *dbs.Tx, taskId int64, domain string, certData []byte, keyData []byte) error {
+	op := NewACMEAuthenticationOperator()
+	op.TaskId = taskId
+	op.Type = acme.AuthTypeTLSALPN
+	op.Domain = domain
+	op.CertData = certData
+	op.KeyData = keyData
+	op.CreatedAt = time.Now().Unix()
 	err := this.Save(tx, op)
 	return err
 }
@@ -52,3 +72,20 @@ func (this *ACMEAuthenticationDAO) FindAuthWithToken(tx *dbs.Tx, token string) (
 	}
 	return one.(*ACMEAuthentication), nil
 }
+
+// FindTLSALPNAuthWithDomain 根据域名查找最近的TLS-ALPN认证信息
+func (this *ACMEAuthenticationDAO) FindTLSALPNAuthWithDomain(tx *dbs.Tx, domain string) (*ACMEAuthentication, error) {
+	one, err := this.Query(tx).
+		Attr("type", acme.AuthTypeTLSALPN).
+		Attr("domain", domain).
+		Gte("createdAt", time.Now().Unix()-ACMETLSALPNAuthLifeSeconds).
+		DescPk().
+		Find()
+	if err != nil {
+		return nil, err
+	}
+	if one == nil {
+		return nil, nil
+	}
+	return one.(*ACMEAuthentication), nil
+}
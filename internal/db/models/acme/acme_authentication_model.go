@@ -4,18 +4,24 @@ package acme
 type ACMEAuthentication struct {
 	Id        uint64 `field:"id"`        // ID
 	TaskId    uint64 `field:"taskId"`    // 任务ID
+	Type      string `field:"type"`      // 认证类型
 	Domain    string `field:"domain"`    // 域名
 	Token     string `field:"token"`     // 令牌
 	Key       string `field:"key"`       // 密钥
+	CertData  string `field:"certData"`  // TLS-ALPN认证证书
+	KeyData   string `field:"keyData"`   // TLS-ALPN认证证书私钥
 	CreatedAt uint64 `field:"createdAt"` // 创建时间
 }
 
 type ACMEAuthenticationOperator struct {
 	Id        interface{} // ID
 	TaskId    interface{} // 任务ID
+	Type      interface{} // 认证类型
 	Domain    interface{} // 域名
 	Token     interface{} // 令牌
 	Key       interface{} // 密钥
+	CertData  interface{} // TLS-ALPN认证证书
+	KeyData   interface{} // TLS-ALPN认证证书私钥
 	CreatedAt interface{} // 创建时间
 }
 
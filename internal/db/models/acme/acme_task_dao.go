@@ -167,11 +167,27 @@ func (this *ACMETaskDAO) ListEnabledACMETasks(tx *dbs.Tx, adminId int64, userId
 }
 
 // CreateACMETask 创建任务
-func (this *ACMETaskDAO) CreateACMETask(tx *dbs.Tx, adminId int64, userId int64, authType acme.AuthType, acmeUserId int64, dnsProviderId int64, dnsDomain string, domains []string, autoRenew bool, authURL string) (int64, error) {
+// authTypes 为按顺序尝试的认证类型，为空时只使用authType
+func (this *ACMETaskDAO) CreateACMETask(tx *dbs.Tx, adminId int64, userId int64, authType acme.AuthType, authTypes []acme.AuthType, acmeUserId int64, dnsProviderId int64, dnsDomain string, domains []string, autoRenew bool, authURL string, keyType acme.KeyType) (int64, error) {
+	_, err := acme.ParseKeyType(keyType)
+	if err != nil {
+		return 0, err
+	}
+
+	authTypes, err = this.checkAuthTypes(authType, authTypes)
+	if err != nil {
+		return 0, err
+	}
+	authTypesJSON, err := json.Marshal(authTypes)
+	if err != nil {
+		return 0, err
+	}
+
 	op := NewACMETaskOperator()
 	op.AdminId = adminId
 	op.UserId = userId
-	op.AuthType = authType
+	op.AuthType = authTypes[0]
+	op.AuthTypes = authTypesJSON
 	op.AcmeUserId = acmeUserId
 	op.DnsProviderId = dnsProviderId
 	op.DnsDomain = dnsDomain
@@ -188,9 +204,10 @@ func (this *ACMETaskDAO) CreateACMETask(tx *dbs.Tx, adminId int64, userId int64,
 
 	op.AutoRenew = autoRenew
 	op.AuthURL = authURL
+	op.KeyType = keyType
 	op.IsOn = true
 	op.State = ACMETaskStateEnabled
-	err := this.Save(tx, op)
+	err = this.Save(tx, op)
 	if err != nil {
 		return 0, err
 	}
@@ -198,13 +215,32 @@ func (this *ACMETaskDAO) CreateACMETask(tx *dbs.Tx, adminId int64, userId int64,
 }
 
 // UpdateACMETask 修改任务
-func (this *ACMETaskDAO) UpdateACMETask(tx *dbs.Tx, acmeTaskId int64, acmeUserId int64, dnsProviderId int64, dnsDomain string, domains []string, autoRenew bool, authURL string) error {
+// authTypes 为按顺序尝试的认证类型，为空时保持原有的认证类型
+func (this *ACMETaskDAO) UpdateACMETask(tx *dbs.Tx, acmeTaskId int64, authTypes []acme.AuthType, acmeUserId int64, dnsProviderId int64, dnsDomain string, domains []string, autoRenew bool, authURL string, keyType acme.KeyType) error {
 	if acmeTaskId <= 0 {
 		return errors.New("invalid acmeTaskId")
 	}
+	_, err := acme.ParseKeyType(keyType)
+	if err != nil {
+		return err
+	}
 
 	op := NewACMETaskOperator()
 	op.Id = acmeTaskId
+
+	if len(authTypes) > 0 {
+		authTypes, err = this.checkAuthTypes("", authTypes)
+		if err != nil {
+			return err
+		}
+		authTypesJSON, err := json.Marshal(authTypes)
+		if err != nil {
+			return err
+		}
+		op.AuthType = authTypes[0]
+		op.AuthTypes = authTypesJSON
+	}
+
 	op.AcmeUserId = acmeUserId
 	op.DnsProviderId = dnsProviderId
 	op.DnsDomain = dnsDomain
@@ -221,7 +257,8 @@ func (this *ACMETaskDAO) UpdateACMETask(tx *dbs.Tx, acmeTaskId int64, acmeUserId
 
 	op.AutoRenew = autoRenew
 	op.AuthURL = authURL
-	err := this.Save(tx, op)
+	op.KeyType = keyType
+	err = this.Save(tx, op)
 	return err
 }
 
@@ -256,9 +293,80 @@ func (this *ACMETaskDAO) RunTask(tx *dbs.Tx, taskId int64) (isOk bool, errMsg st
 		logs.Error(err)
 	}
 
+	// 成功后重置续期状态
+	if isOk {
+		err = this.ResetTaskRenewal(tx, taskId)
+		if err != nil {
+			logs.Error(err)
+		}
+	}
+
+	return
+}
+
+// FindAllRenewableTasks 查找需要续期的任务
+// 证书剩余有效期占整个有效期的比例小于 renewRatio 时需要续期
+func (this *ACMETaskDAO) FindAllRenewableTasks(tx *dbs.Tx, renewRatio float64, now int64, size int64) (result []*ACMETask, err error) {
+	_, err = this.Query(tx).
+		State(ACMETaskStateEnabled).
+		Attr("isOn", true).
+		Attr("autoRenew", true).
+		Gt("certId", 0).
+		Lte("nextRenewAt", now).
+		Where("certId IN (SELECT id FROM "+models.SharedSSLCertDAO.Table+" WHERE state=:certState AND timeEndAt>timeBeginAt AND timeEndAt-(timeEndAt-timeBeginAt)*:renewRatio<=:now)").
+		Param("certState", models.SSLCertStateEnabled).
+		Param("renewRatio", renewRatio).
+		Param("now", now).
+		Asc("nextRenewAt").
+		AscPk().
+		Limit(size).
+		Slice(&result).
+		FindAll()
 	return
 }
 
+// ClaimTaskRenewal 在续期前占用任务，防止多个API节点重复续期同一个任务
+// 只有nextRenewAt仍然是查询时的值才能占用成功，占用后在claimUntil之前不会再被查询到
+func (this *ACMETaskDAO) ClaimTaskRenewal(tx *dbs.Tx, taskId int64, nextRenewAt int64, claimUntil int64) (bool, error) {
+	if taskId <= 0 {
+		return false, errors.New("invalid taskId")
+	}
+	rows, err := this.Query(tx).
+		Pk(taskId).
+		Attr("nextRenewAt", nextRenewAt).
+		Set("nextRenewAt", claimUntil).
+		Update()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// UpdateTaskRenewalFailed 记录续期失败
+func (this *ACMETaskDAO) UpdateTaskRenewalFailed(tx *dbs.Tx, taskId int64, renewAttempts int, nextRenewAt int64) error {
+	if taskId <= 0 {
+		return errors.New("invalid taskId")
+	}
+	op := NewACMETaskOperator()
+	op.Id = taskId
+	op.RenewAttempts = renewAttempts
+	op.NextRenewAt = nextRenewAt
+	return this.Save(tx, op)
+}
+
+// ResetTaskRenewal 重置续期状态
+func (this *ACMETaskDAO) ResetTaskRenewal(tx *dbs.Tx, taskId int64) error {
+	if taskId <= 0 {
+		return errors.New("invalid taskId")
+	}
+	_, err := this.Query(tx).
+		Pk(taskId).
+		Set("renewAttempts", 0).
+		Set("nextRenewAt", 0).
+		Update()
+	return err
+}
+
 // 执行任务但并不记录日志
 func (this *ACMETaskDAO) runTaskWithoutLog(tx *dbs.Tx, taskId int64) (isOk bool, errMsg string, resultCertId int64) {
 	task, err := this.FindEnabledACMETask(tx, taskId)
@@ -302,6 +410,9 @@ func (this *ACMETaskDAO) runTaskWithoutLog(tx *dbs.Tx, taskId int64) (isOk bool,
 		return err
 	})
 
+	remoteUser.SetDirectoryURL(user.DirectoryURL)
+	remoteUser.SetEAB(user.DecodeEAB())
+
 	if len(user.Registration) > 0 {
 		err = remoteUser.SetRegistration([]byte(user.Registration))
 		if err != nil {
@@ -310,48 +421,39 @@ func (this *ACMETaskDAO) runTaskWithoutLog(tx *dbs.Tx, taskId int64) (isOk bool,
 		}
 	}
 
-	var acmeTask *acme.Task = nil
-	if task.AuthType == acme.AuthTypeDNS {
+	var acmeTask = &acme.Task{
+		User:     remoteUser,
+		AuthType: task.AuthType,
+		Domains:  task.DecodeDomains(),
+		KeyType:  task.KeyType,
+	}
+
+	var authTypes = task.DecodeAuthTypes()
+	if task.HasAuthType(acme.AuthTypeDNS) {
 		// DNS服务商
-		dnsProvider, err := dns.SharedDNSProviderDAO.FindEnabledDNSProvider(tx, int64(task.DnsProviderId))
-		if err != nil {
-			errMsg = "查找DNS服务商账号信息时出错：" + err.Error()
-			return
-		}
-		if dnsProvider == nil {
-			errMsg = "找不到DNS服务商账号"
-			return
-		}
-		providerInterface := dnsclients.FindProvider(dnsProvider.Type)
-		if providerInterface == nil {
-			errMsg = "暂不支持此类型的DNS服务商 '" + dnsProvider.Type + "'"
-			return
-		}
-		apiParams, err := dnsProvider.DecodeAPIParams()
-		if err != nil {
-			errMsg = "解析DNS服务商API参数时出错：" + err.Error()
-			return
-		}
-		err = providerInterface.Auth(apiParams)
-		if err != nil {
-			errMsg = "校验DNS服务商API参数时出错：" + err.Error()
-			return
-		}
+		providerInterface, dnsErrMsg := this.findDNSProvider(tx, int64(task.DnsProviderId))
+		if len(dnsErrMsg) > 0 {
+			// 没有其他认证方式可以尝试
+			if len(authTypes) <= 1 {
+				errMsg = dnsErrMsg
+				return
+			}
 
-		acmeTask = &acme.Task{
-			User:        remoteUser,
-			AuthType:    acme.AuthTypeDNS,
-			DNSProvider: providerInterface,
-			DNSDomain:   task.DnsDomain,
-			Domains:     task.DecodeDomains(),
-		}
-	} else if task.AuthType == acme.AuthTypeHTTP {
-		acmeTask = &acme.Task{
-			User:     remoteUser,
-			AuthType: acme.AuthTypeHTTP,
-			Domains:  task.DecodeDomains(),
+			// 跳过DNS认证，继续尝试其他认证方式
+			remotelogs.Warn("ACME", "skip dns authentication for task '"+types.String(taskId)+"': "+dnsErrMsg)
+			var newAuthTypes = []string{}
+			for _, authType := range authTypes {
+				if authType != acme.AuthTypeDNS {
+					newAuthTypes = append(newAuthTypes, authType)
+				}
+			}
+			authTypes = newAuthTypes
+		} else {
+			acmeTask.DNSProvider = providerInterface
+			acmeTask.DNSDomain = task.DnsDomain
 		}
 	}
+	acmeTask.AuthTypes = authTypes
 
 	acmeRequest := acme.NewRequest(acmeTask)
 	acmeRequest.OnAuth(func(domain, token, keyAuth string) {
@@ -360,30 +462,25 @@ func (this *ACMETaskDAO) runTaskWithoutLog(tx *dbs.Tx, taskId int64) (isOk bool,
 			remotelogs.Error("ACME", "write authentication to database error: "+err.Error())
 		} else {
 			// 调用校验URL
-			if len(task.AuthURL) > 0 {
-				authJSON, err := json.Marshal(maps.Map{
-					"domain": domain,
-					"token":  token,
-					"key":    keyAuth,
-				})
-				if err != nil {
-					remotelogs.Error("ACME", "encode auth data failed: '"+task.AuthURL+"'")
-				} else {
-					client := utils.SharedHttpClient(5 * time.Second)
-					req, err := http.NewRequest(http.MethodPost, task.AuthURL, bytes.NewReader(authJSON))
-					req.Header.Set("Content-Type", "application/json")
-					if err != nil {
-						remotelogs.Error("ACME", "parse auth url failed '"+task.AuthURL+"': "+err.Error())
-					} else {
-						resp, err := client.Do(req)
-						if err != nil {
-							remotelogs.Error("ACME", "call auth url failed '"+task.AuthURL+"': "+err.Error())
-						} else {
-							_ = resp.Body.Close()
-						}
-					}
-				}
-			}
+			this.callAuthURL(task.AuthURL, maps.Map{
+				"domain": domain,
+				"token":  token,
+				"key":    keyAuth,
+			})
+		}
+	})
+	acmeRequest.OnTLSALPNAuth(func(domain, token, keyAuth string, certData []byte, keyData []byte) {
+		err := SharedACMEAuthenticationDAO.CreateTLSALPNAuth(tx, taskId, domain, certData, keyData)
+		if err != nil {
+			remotelogs.Error("ACME", "write tls-alpn authentication to database error: "+err.Error())
+		} else {
+			// 调用校验URL，认证证书的私钥只通过API提供给边缘节点，不发送到外部地址
+			this.callAuthURL(task.AuthURL, maps.Map{
+				"type":   acme.AuthTypeTLSALPN,
+				"domain": domain,
+				"token":  token,
+				"key":    keyAuth,
+			})
 		}
 	})
 	certData, keyData, err := acmeRequest.Run()
@@ -452,3 +549,75 @@ func (this *ACMETaskDAO) runTaskWithoutLog(tx *dbs.Tx, taskId int64) (isOk bool,
 	isOk = true
 	return
 }
+
+// 查找并校验DNS服务商
+func (this *ACMETaskDAO) findDNSProvider(tx *dbs.Tx, dnsProviderId int64) (providerInterface dnsclients.ProviderInterface, errMsg string) {
+	dnsProvider, err := dns.SharedDNSProviderDAO.FindEnabledDNSProvider(tx, dnsProviderId)
+	if err != nil {
+		errMsg = "查找DNS服务商账号信息时出错：" + err.Error()
+		return
+	}
+	if dnsProvider == nil {
+		errMsg = "找不到DNS服务商账号"
+		return
+	}
+	providerInterface = dnsclients.FindProvider(dnsProvider.Type)
+	if providerInterface == nil {
+		errMsg = "暂不支持此类型的DNS服务商 '" + dnsProvider.Type + "'"
+		return
+	}
+	apiParams, err := dnsProvider.DecodeAPIParams()
+	if err != nil {
+		errMsg = "解析DNS服务商API参数时出错：" + err.Error()
+		return
+	}
+	err = providerInterface.Auth(apiParams)
+	if err != nil {
+		errMsg = "校验DNS服务商API参数时出错：" + err.Error()
+		return
+	}
+	return
+}
+
+// 调用校验URL
+func (this *ACMETaskDAO) callAuthURL(authURL string, data maps.Map) {
+	if len(authURL) == 0 {
+		return
+	}
+	authJSON, err := json.Marshal(data)
+	if err != nil {
+		remotelogs.Error("ACME", "encode auth data failed: '"+authURL+"'")
+		return
+	}
+	client := utils.SharedHttpClient(5 * time.Second)
+	req, err := http.NewRequest(http.MethodPost, authURL, bytes.NewReader(authJSON))
+	if err != nil {
+		remotelogs.Error("ACME", "parse auth url failed '"+authURL+"': "+err.Error())
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		remotelogs.Error("ACME", "call auth url failed '"+authURL+"': "+err.Error())
+		return
+	}
+	_ = resp.Body.Close()
+}
+
+// 检查认证类型，返回去重后的认证类型列表
+func (this *ACMETaskDAO) checkAuthTypes(authType acme.AuthType, authTypes []acme.AuthType) ([]acme.AuthType, error) {
+	var task = &acme.Task{
+		AuthType:  authType,
+		AuthTypes: authTypes,
+	}
+	authTypes = task.FindAuthTypes()
+	if len(authTypes) == 0 {
+		return nil, errors.New("'authType' should not be empty")
+	}
+	for _, t := range authTypes {
+		if !acme.IsValidAuthType(t) {
+			return nil, errors.New("invalid auth type '" + t + "'")
+		}
+	}
+	return authTypes, nil
+}
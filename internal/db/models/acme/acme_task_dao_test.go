@@ -2,4 +2,45 @@ package acme
 
 import (
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/iwind/TeaGo/bootstrap"
+	"github.com/iwind/TeaGo/dbs"
+	"testing"
+	"time"
 )
+
+func TestACMETaskDAO_ClaimTaskRenewal(t *testing.T) {
+	dbs.NotifyReady()
+
+	var tx *dbs.Tx
+	task, err := SharedACMETaskDAO.FindEnabledACMETask(tx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task == nil {
+		t.Log("task not found")
+		return
+	}
+
+	var claimUntil = time.Now().Unix() + 3600
+	ok, err := SharedACMETaskDAO.ClaimTaskRenewal(tx, 1, int64(task.NextRenewAt), claimUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("task should be claimed")
+	}
+
+	// 同样的状态不能被再次占用
+	ok, err = SharedACMETaskDAO.ClaimTaskRenewal(tx, 1, int64(task.NextRenewAt), claimUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("task should not be claimed twice")
+	}
+
+	err = SharedACMETaskDAO.UpdateTaskRenewalFailed(tx, 1, int(task.RenewAttempts), int64(task.NextRenewAt))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -15,7 +15,11 @@ type ACMETask struct {
 	CertId        uint64 `field:"certId"`        // 生成的证书ID
 	AutoRenew     uint8  `field:"autoRenew"`     // 是否自动更新
 	AuthType      string `field:"authType"`      // 认证类型
+	AuthTypes     string `field:"authTypes"`     // 按顺序尝试的认证类型
 	AuthURL       string `field:"authURL"`       // 认证URL
+	KeyType       string `field:"keyType"`       // 证书私钥类型
+	RenewAttempts uint32 `field:"renewAttempts"` // 连续续期失败次数
+	NextRenewAt   uint64 `field:"nextRenewAt"`   // 下次可以尝试续期的时间
 }
 
 type ACMETaskOperator struct {
@@ -32,7 +36,11 @@ type ACMETaskOperator struct {
 	CertId        interface{} // 生成的证书ID
 	AutoRenew     interface{} // 是否自动更新
 	AuthType      interface{} // 认证类型
+	AuthTypes     interface{} // 按顺序尝试的认证类型
 	AuthURL       interface{} // 认证URL
+	KeyType       interface{} // 证书私钥类型
+	RenewAttempts interface{} // 连续续期失败次数
+	NextRenewAt   interface{} // 下次可以尝试续期的时间
 }
 
 func NewACMETaskOperator() *ACMETaskOperator {
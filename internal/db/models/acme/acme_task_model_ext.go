@@ -18,3 +18,28 @@ func (this *ACMETask) DecodeDomains() []string {
 	}
 	return result
 }
+
+// DecodeAuthTypes 解析按顺序尝试的认证类型，没有设置时只使用authType
+func (this *ACMETask) DecodeAuthTypes() []string {
+	var result = []string{}
+	if len(this.AuthTypes) > 0 && this.AuthTypes != "null" {
+		err := json.Unmarshal([]byte(this.AuthTypes), &result)
+		if err != nil {
+			logs.Error(err)
+		}
+	}
+	if len(result) == 0 && len(this.AuthType) > 0 {
+		result = []string{this.AuthType}
+	}
+	return result
+}
+
+// HasAuthType 判断是否使用某个认证类型
+func (this *ACMETask) HasAuthType(authType string) bool {
+	for _, t := range this.DecodeAuthTypes() {
+		if t == authType {
+			return true
+		}
+	}
+	return false
+}
@@ -6,11 +6,14 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/acme"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/types"
+	"net/url"
 )
 
 const (
@@ -70,7 +73,23 @@ func (this *ACMEUserDAO) FindEnabledACMEUser(tx *dbs.Tx, id int64) (*ACMEUser, e
 }
 
 // 创建用户
-func (this *ACMEUserDAO) CreateACMEUser(tx *dbs.Tx, adminId int64, userId int64, email string, description string) (int64, error) {
+// directoryURL 为空时使用Let's Encrypt，用户注册后不能再修改，因为注册信息只在对应的服务商中有效
+func (this *ACMEUserDAO) CreateACMEUser(tx *dbs.Tx, adminId int64, userId int64, email string, description string, directoryURL string, eab *acme.EAB) (int64, error) {
+	// 校验服务商
+	if len(directoryURL) > 0 {
+		u, err := url.Parse(directoryURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || len(u.Host) == 0 {
+			return 0, errors.New("invalid directory url '" + directoryURL + "'")
+		}
+	}
+	if eab != nil && (len(eab.KeyId) == 0 || len(eab.HMACKey) == 0) {
+		eab = nil
+	}
+	var provider = acme.FindProviderWithDirectoryURL(directoryURL)
+	if provider != nil && provider.RequireEAB && eab == nil {
+		return 0, errors.New("'" + provider.Name + "' requires EAB credentials")
+	}
+
 	// 生成私钥
 	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
@@ -89,6 +108,14 @@ func (this *ACMEUserDAO) CreateACMEUser(tx *dbs.Tx, adminId int64, userId int64,
 	op.Email = email
 	op.Description = description
 	op.PrivateKey = privateKeyText
+	op.DirectoryURL = directoryURL
+	if eab != nil {
+		eabJSON, err := json.Marshal(eab)
+		if err != nil {
+			return 0, err
+		}
+		op.Eab = eabJSON
+	}
 	op.State = ACMEUserStateEnabled
 	err = this.Save(tx, op)
 	if err != nil {
@@ -1,6 +1,6 @@
 package acme
 
-//
+// ACMEUser ACME用户
 type ACMEUser struct {
 	Id           uint64 `field:"id"`           // ID
 	AdminId      uint32 `field:"adminId"`      // 管理员ID
@@ -11,6 +11,8 @@ type ACMEUser struct {
 	State        uint8  `field:"state"`        // 状态
 	Description  string `field:"description"`  // 备注介绍
 	Registration string `field:"registration"` // 注册信息
+	DirectoryURL string `field:"directoryURL"` // ACME目录URL
+	Eab          string `field:"eab"`          // External Account Binding凭证
 }
 
 type ACMEUserOperator struct {
@@ -23,6 +25,8 @@ type ACMEUserOperator struct {
 	State        interface{} // 状态
 	Description  interface{} // 备注介绍
 	Registration interface{} // 注册信息
+	DirectoryURL interface{} // ACME目录URL
+	Eab          interface{} // External Account Binding凭证
 }
 
 func NewACMEUserOperator() *ACMEUserOperator {
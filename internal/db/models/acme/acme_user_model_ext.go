@@ -1 +1,32 @@
 package acme
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/acme"
+)
+
+// DecodeEAB 解析External Account Binding凭证
+func (this *ACMEUser) DecodeEAB() *acme.EAB {
+	if len(this.Eab) == 0 || this.Eab == "null" {
+		return nil
+	}
+	var eab = &acme.EAB{}
+	err := json.Unmarshal([]byte(this.Eab), eab)
+	if err != nil || len(eab.KeyId) == 0 {
+		return nil
+	}
+	return eab
+}
+
+// ProviderName 证书服务商名称
+func (this *ACMEUser) ProviderName() string {
+	var directoryURL = this.DirectoryURL
+	if len(directoryURL) == 0 {
+		return acme.FindProviderWithCode(acme.DefaultProviderCode).Name
+	}
+	var provider = acme.FindProviderWithDirectoryURL(directoryURL)
+	if provider != nil {
+		return provider.Name
+	}
+	return directoryURL
+}
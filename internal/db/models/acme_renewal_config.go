@@ -0,0 +1,66 @@
+package models
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"math"
+)
+
+// SettingCodeACMERenewalConfig 证书自动续期设置在系统设置中的代号
+const SettingCodeACMERenewalConfig = "acmeRenewalConfig"
+
+// ACMERenewalConfig 证书自动续期设置
+type ACMERenewalConfig struct {
+	IsOn              bool    `json:"isOn"`              // 是否启用
+	RenewRatio        float64 `json:"renewRatio"`        // 剩余有效期占整个有效期的比例小于此值时开始续期，取值0-1
+	MaxConcurrent     int     `json:"maxConcurrent"`     // 最多同时执行的续期任务数
+	BackoffSeconds    int64   `json:"backoffSeconds"`    // 第一次失败后的重试间隔（秒），之后每次翻倍
+	MaxBackoffSeconds int64   `json:"maxBackoffSeconds"` // 最大重试间隔（秒）
+}
+
+// DefaultACMERenewalConfig 默认的证书自动续期设置
+func DefaultACMERenewalConfig() *ACMERenewalConfig {
+	return &ACMERenewalConfig{
+		IsOn:              true,
+		RenewRatio:        1.0 / 3,
+		MaxConcurrent:     3,
+		BackoffSeconds:    3600,
+		MaxBackoffSeconds: 86400,
+	}
+}
+
+// Validate 校验设置
+func (this *ACMERenewalConfig) Validate() error {
+	if this.RenewRatio <= 0 || this.RenewRatio >= 1 {
+		return errors.New("'renewRatio' should be between 0 and 1")
+	}
+	if this.MaxConcurrent <= 0 {
+		return errors.New("'maxConcurrent' should be greater than 0")
+	}
+	if this.BackoffSeconds < 0 || this.MaxBackoffSeconds < 0 {
+		return errors.New("'backoffSeconds' and 'maxBackoffSeconds' should not be negative")
+	}
+	return nil
+}
+
+// RenewAt 计算证书开始续期的时间
+func (this *ACMERenewalConfig) RenewAt(timeBeginAt int64, timeEndAt int64) int64 {
+	return timeEndAt - int64(math.Round(float64(timeEndAt-timeBeginAt)*this.RenewRatio))
+}
+
+// BackoffSecondsWithAttempts 第N次失败后的重试间隔
+func (this *ACMERenewalConfig) BackoffSecondsWithAttempts(attempts int) int64 {
+	if attempts <= 0 || this.BackoffSeconds <= 0 {
+		return 0
+	}
+	var seconds = this.BackoffSeconds
+	for i := 1; i < attempts; i++ {
+		seconds *= 2
+		if this.MaxBackoffSeconds > 0 && seconds >= this.MaxBackoffSeconds {
+			return this.MaxBackoffSeconds
+		}
+	}
+	if this.MaxBackoffSeconds > 0 && seconds > this.MaxBackoffSeconds {
+		return this.MaxBackoffSeconds
+	}
+	return seconds
+}
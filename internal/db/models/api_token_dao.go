@@ -1,6 +1,8 @@
 package models
 
 import (
+	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
+	"github.com/TeaOSLab/EdgeAPI/internal/encrypt"
 	"github.com/TeaOSLab/EdgeCommon/pkg/nodeconfigs"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
@@ -130,3 +132,48 @@ func (this *ApiTokenDAO) FindAllEnabledAPITokens(tx *dbs.Tx, role string) (resul
 		FindAll()
 	return
 }
+
+// UpdateTokenRequireEnvelope 设置节点只接受加密信封
+func (this *ApiTokenDAO) UpdateTokenRequireEnvelope(tx *dbs.Tx, nodeId string) error {
+	_, err := this.Query(tx).
+		Attr("nodeId", nodeId).
+		State(ApiTokenStateEnabled).
+		Set("requireEnvelope", 1).
+		Update()
+	if err != nil {
+		return err
+	}
+
+	// 替换缓存中的令牌，而不是修改正在被读取的对象
+	SharedCacheLocker.Lock()
+	token, ok := apiTokenCacheMap[nodeId]
+	if ok {
+		var newToken = *token
+		newToken.RequireEnvelope = 1
+		apiTokenCacheMap[nodeId] = &newToken
+	}
+	SharedCacheLocker.Unlock()
+
+	return nil
+}
+
+// DecodeToken 解密节点发送的令牌数据
+// 旧版本节点使用teaconst.EncryptMethod加密，节点一旦发送过加密信封，就不再接受旧的加密方法，防止被降级
+func (this *ApiTokenDAO) DecodeToken(tx *dbs.Tx, token *ApiToken, data []byte) ([]byte, error) {
+	var legacyMethod = teaconst.EncryptMethod
+	if token.RequireEnvelope == 1 {
+		legacyMethod = ""
+	}
+	result, err := encrypt.DecodeEnvelope(data, token.Secret, token.NodeId, legacyMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.RequireEnvelope == 0 && encrypt.IsEnvelope(data) {
+		err = this.UpdateTokenRequireEnvelope(tx, token.NodeId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
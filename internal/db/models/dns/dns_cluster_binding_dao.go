@@ -0,0 +1,257 @@
+package dns
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"time"
+)
+
+const (
+	DNSClusterBindingStateEnabled  = 1 // 已启用
+	DNSClusterBindingStateDisabled = 0 // 已禁用
+)
+
+type DNSClusterBindingDAO dbs.DAO
+
+func NewDNSClusterBindingDAO() *DNSClusterBindingDAO {
+	return dbs.NewDAO(&DNSClusterBindingDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeDNSClusterBindings",
+			Model:  new(DNSClusterBinding),
+			PkName: "id",
+		},
+	}).(*DNSClusterBindingDAO)
+}
+
+var SharedDNSClusterBindingDAO *DNSClusterBindingDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedDNSClusterBindingDAO = NewDNSClusterBindingDAO()
+	})
+}
+
+// DisableDNSClusterBinding 禁用条目
+func (this *DNSClusterBindingDAO) DisableDNSClusterBinding(tx *dbs.Tx, bindingId int64) error {
+	_, err := this.Query(tx).
+		Pk(bindingId).
+		Set("state", DNSClusterBindingStateDisabled).
+		Update()
+	if err != nil {
+		return err
+	}
+	return this.NotifyUpdate(tx, bindingId)
+}
+
+// FindEnabledDNSClusterBinding 查找启用中的条目
+func (this *DNSClusterBindingDAO) FindEnabledDNSClusterBinding(tx *dbs.Tx, bindingId int64) (*DNSClusterBinding, error) {
+	result, err := this.Query(tx).
+		Pk(bindingId).
+		Attr("state", DNSClusterBindingStateEnabled).
+		Find()
+	if result == nil {
+		return nil, err
+	}
+	return result.(*DNSClusterBinding), err
+}
+
+// CreateBinding 为集群增加一个额外的域名
+func (this *DNSClusterBindingDAO) CreateBinding(tx *dbs.Tx, clusterId int64, domainId int64) (int64, error) {
+	if clusterId <= 0 || domainId <= 0 {
+		return 0, errors.New("invalid clusterId or domainId")
+	}
+
+	// 是否已经存在
+	bindingId, err := this.findBindingId(tx, clusterId, domainId)
+	if err != nil {
+		return 0, err
+	}
+	if bindingId > 0 {
+		return 0, errors.New("the domain has been already bound to the cluster")
+	}
+
+	op := NewDNSClusterBindingOperator()
+	op.ClusterId = clusterId
+	op.DomainId = domainId
+	op.IsPrimary = false
+	op.IsOn = true
+	op.CreatedAt = time.Now().Unix()
+	op.State = DNSClusterBindingStateEnabled
+	bindingId, err = this.SaveInt64(tx, op)
+	if err != nil {
+		return 0, err
+	}
+
+	err = this.NotifyUpdate(tx, bindingId)
+	if err != nil {
+		return 0, err
+	}
+	return bindingId, nil
+}
+
+// UpdateBinding 修改绑定
+func (this *DNSClusterBindingDAO) UpdateBinding(tx *dbs.Tx, bindingId int64, isOn bool) error {
+	if bindingId <= 0 {
+		return errors.New("invalid bindingId")
+	}
+	op := NewDNSClusterBindingOperator()
+	op.Id = bindingId
+	op.IsOn = isOn
+	err := this.Save(tx, op)
+	if err != nil {
+		return err
+	}
+	return this.NotifyUpdate(tx, bindingId)
+}
+
+// UpdatePrimaryDomain 设置集群的主域名
+// 主域名仍然以集群中的dnsDomainId为准，这里只是为了记录它的同步状态
+// 以前主域名中的记录需要调用者在此之前删除，比如使用DNSTaskExecutor.RemoveClusterDomain()
+func (this *DNSClusterBindingDAO) UpdatePrimaryDomain(tx *dbs.Tx, clusterId int64, domainId int64) error {
+	if clusterId <= 0 {
+		return errors.New("invalid clusterId")
+	}
+
+	// 取消以前的主域名
+	err := this.Query(tx).
+		Attr("clusterId", clusterId).
+		Attr("isPrimary", true).
+		Neq("domainId", domainId).
+		State(DNSClusterBindingStateEnabled).
+		Set("state", DNSClusterBindingStateDisabled).
+		UpdateQuickly()
+	if err != nil {
+		return err
+	}
+	if domainId <= 0 {
+		return nil
+	}
+
+	bindingId, err := this.findBindingId(tx, clusterId, domainId)
+	if err != nil {
+		return err
+	}
+
+	op := NewDNSClusterBindingOperator()
+	if bindingId > 0 {
+		op.Id = bindingId
+	} else {
+		op.ClusterId = clusterId
+		op.DomainId = domainId
+		op.CreatedAt = time.Now().Unix()
+		op.State = DNSClusterBindingStateEnabled
+	}
+	op.IsPrimary = true
+	op.IsOn = true
+	return this.Save(tx, op)
+}
+
+// UpdateBindingStatus 记录某个域名的同步状态
+func (this *DNSClusterBindingDAO) UpdateBindingStatus(tx *dbs.Tx, clusterId int64, domainId int64, isPrimary bool, errString string) error {
+	if clusterId <= 0 || domainId <= 0 {
+		return nil
+	}
+
+	bindingId, err := this.findBindingId(tx, clusterId, domainId)
+	if err != nil {
+		return err
+	}
+
+	op := NewDNSClusterBindingOperator()
+	if bindingId > 0 {
+		op.Id = bindingId
+	} else {
+		// 以前版本中设置的主域名没有对应的绑定
+		if !isPrimary {
+			return nil
+		}
+		op.ClusterId = clusterId
+		op.DomainId = domainId
+		op.IsPrimary = true
+		op.IsOn = true
+		op.CreatedAt = time.Now().Unix()
+		op.State = DNSClusterBindingStateEnabled
+	}
+	op.IsOk = len(errString) == 0
+	op.Error = errString
+	op.SyncedAt = time.Now().Unix()
+	return this.Save(tx, op)
+}
+
+// FindAllEnabledBindingsWithClusterId 查找集群的所有绑定，主域名排在最前
+func (this *DNSClusterBindingDAO) FindAllEnabledBindingsWithClusterId(tx *dbs.Tx, clusterId int64) (result []*DNSClusterBinding, err error) {
+	_, err = this.Query(tx).
+		Attr("clusterId", clusterId).
+		State(DNSClusterBindingStateEnabled).
+		Desc("isPrimary").
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// FindAllEnabledSecondaryDomainIds 查找集群中所有启用的额外域名ID
+func (this *DNSClusterBindingDAO) FindAllEnabledSecondaryDomainIds(tx *dbs.Tx, clusterId int64) (result []int64, err error) {
+	ones, err := this.Query(tx).
+		Attr("clusterId", clusterId).
+		Attr("isPrimary", false).
+		Attr("isOn", true).
+		State(DNSClusterBindingStateEnabled).
+		Result("domainId").
+		AscPk().
+		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, one := range ones {
+		result = append(result, int64(one.(*DNSClusterBinding).DomainId))
+	}
+	return
+}
+
+// FindAllEnabledSecondaryClusterIds 查找把某个域名作为额外域名的集群ID
+func (this *DNSClusterBindingDAO) FindAllEnabledSecondaryClusterIds(tx *dbs.Tx, domainId int64) (result []int64, err error) {
+	ones, err := this.Query(tx).
+		Attr("domainId", domainId).
+		Attr("isPrimary", false).
+		Attr("isOn", true).
+		State(DNSClusterBindingStateEnabled).
+		Result("clusterId").
+		AscPk().
+		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, one := range ones {
+		result = append(result, int64(one.(*DNSClusterBinding).ClusterId))
+	}
+	return
+}
+
+// 查找集群和域名对应的绑定ID
+func (this *DNSClusterBindingDAO) findBindingId(tx *dbs.Tx, clusterId int64, domainId int64) (int64, error) {
+	return this.Query(tx).
+		Attr("clusterId", clusterId).
+		Attr("domainId", domainId).
+		State(DNSClusterBindingStateEnabled).
+		ResultPk().
+		FindInt64Col(0)
+}
+
+// NotifyUpdate 通知更新
+func (this *DNSClusterBindingDAO) NotifyUpdate(tx *dbs.Tx, bindingId int64) error {
+	clusterId, err := this.Query(tx).
+		Pk(bindingId).
+		Result("clusterId").
+		FindInt64Col(0)
+	if err != nil {
+		return err
+	}
+	if clusterId <= 0 {
+		return nil
+	}
+	return SharedDNSTaskDAO.CreateClusterTask(tx, clusterId, DNSTaskTypeClusterChange)
+}
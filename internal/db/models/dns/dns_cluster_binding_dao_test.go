@@ -0,0 +1,26 @@
+package dns
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/iwind/TeaGo/bootstrap"
+	"github.com/iwind/TeaGo/dbs"
+	"testing"
+)
+
+func TestDNSClusterBindingDAO_UpdateBindingStatus(t *testing.T) {
+	dbs.NotifyReady()
+
+	var tx *dbs.Tx
+	err := SharedDNSClusterBindingDAO.UpdateBindingStatus(tx, 1, 1, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bindings, err := SharedDNSClusterBindingDAO.FindAllEnabledBindingsWithClusterId(tx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, binding := range bindings {
+		t.Log(binding.DomainId, binding.IsPrimary, binding.IsOk, binding.Error)
+	}
+}
@@ -0,0 +1,32 @@
+package dns
+
+// DNSClusterBinding 集群DNS域名绑定
+type DNSClusterBinding struct {
+	Id        uint32 `field:"id"`        // ID
+	ClusterId uint32 `field:"clusterId"` // 集群ID
+	DomainId  uint32 `field:"domainId"`  // 域名ID
+	IsPrimary uint8  `field:"isPrimary"` // 是否为集群的主域名
+	IsOn      uint8  `field:"isOn"`      // 是否启用
+	IsOk      uint8  `field:"isOk"`      // 最后一次同步是否成功
+	Error     string `field:"error"`     // 最后一次同步错误
+	SyncedAt  uint64 `field:"syncedAt"`  // 最后同步时间
+	CreatedAt uint64 `field:"createdAt"` // 创建时间
+	State     uint8  `field:"state"`     // 状态
+}
+
+type DNSClusterBindingOperator struct {
+	Id        interface{} // ID
+	ClusterId interface{} // 集群ID
+	DomainId  interface{} // 域名ID
+	IsPrimary interface{} // 是否为集群的主域名
+	IsOn      interface{} // 是否启用
+	IsOk      interface{} // 最后一次同步是否成功
+	Error     interface{} // 最后一次同步错误
+	SyncedAt  interface{} // 最后同步时间
+	CreatedAt interface{} // 创建时间
+	State     interface{} // 状态
+}
+
+func NewDNSClusterBindingOperator() *DNSClusterBindingOperator {
+	return &DNSClusterBindingOperator{}
+}
@@ -1,7 +1,9 @@
 package dns
 
 import (
+	"encoding/json"
 	dbutils "github.com/TeaOSLab/EdgeAPI/internal/db/utils"
+	"github.com/TeaOSLab/EdgeAPI/internal/encrypt"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
@@ -63,7 +65,12 @@ func (this *DNSProviderDAO) FindEnabledDNSProvider(tx *dbs.Tx, id int64) (*DNSPr
 	if result == nil {
 		return nil, err
 	}
-	return result.(*DNSProvider), err
+	provider := result.(*DNSProvider)
+	err = this.decodeProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	return provider, nil
 }
 
 // 创建服务商
@@ -74,7 +81,11 @@ func (this *DNSProviderDAO) CreateDNSProvider(tx *dbs.Tx, adminId int64, userId
 	op.Type = providerType
 	op.Name = name
 	if len(apiParamsJSON) > 0 {
-		op.ApiParams = apiParamsJSON
+		encodedJSON, err := this.encodeAPIParams(apiParamsJSON)
+		if err != nil {
+			return 0, err
+		}
+		op.ApiParams = encodedJSON
 	}
 	op.State = DNSProviderStateEnabled
 	err := this.Save(tx, op)
@@ -96,7 +107,11 @@ func (this *DNSProviderDAO) UpdateDNSProvider(tx *dbs.Tx, dnsProviderId int64, n
 
 	// 如果留空则表示不修改
 	if len(apiParamsJSON) > 0 {
-		op.ApiParams = apiParamsJSON
+		encodedJSON, err := this.encodeAPIParams(apiParamsJSON)
+		if err != nil {
+			return err
+		}
+		op.ApiParams = encodedJSON
 	}
 
 	err := this.Save(tx, op)
@@ -122,6 +137,10 @@ func (this *DNSProviderDAO) ListEnabledDNSProviders(tx *dbs.Tx, adminId int64, u
 		DescPk().
 		Slice(&result).
 		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	err = this.decodeProviders(result)
 	return
 }
 
@@ -132,6 +151,10 @@ func (this *DNSProviderDAO) FindAllEnabledDNSProviders(tx *dbs.Tx, adminId int64
 		DescPk().
 		Slice(&result).
 		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	err = this.decodeProviders(result)
 	return
 }
 
@@ -143,6 +166,10 @@ func (this *DNSProviderDAO) FindAllEnabledDNSProvidersWithType(tx *dbs.Tx, provi
 		DescPk().
 		Slice(&result).
 		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	err = this.decodeProviders(result)
 	return
 }
 
@@ -154,3 +181,45 @@ func (this *DNSProviderDAO) UpdateProviderDataUpdatedTime(tx *dbs.Tx, providerId
 		Update()
 	return err
 }
+
+// 加密API参数
+// 字段类型为JSON，所以加密后的数据以JSON字符串的形式保存
+func (this *DNSProviderDAO) encodeAPIParams(apiParamsJSON []byte) ([]byte, error) {
+	encryptedData, err := encrypt.EncryptSecret(apiParamsJSON)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypt.IsEncryptedSecret(encryptedData) {
+		return apiParamsJSON, nil
+	}
+	return json.Marshal(string(encryptedData))
+}
+
+// 解密服务商中的API参数
+func (this *DNSProviderDAO) decodeProvider(provider *DNSProvider) error {
+	if len(provider.ApiParams) == 0 || provider.ApiParams[0] != '"' {
+		return nil
+	}
+	var encryptedData = ""
+	err := json.Unmarshal([]byte(provider.ApiParams), &encryptedData)
+	if err != nil {
+		return err
+	}
+	apiParamsJSON, err := encrypt.DecryptSecret([]byte(encryptedData))
+	if err != nil {
+		return errors.New("decrypt api params of dns provider '" + types.String(provider.Id) + "' failed: " + err.Error())
+	}
+	provider.ApiParams = string(apiParamsJSON)
+	return nil
+}
+
+// 解密多个服务商中的API参数
+func (this *DNSProviderDAO) decodeProviders(providers []*DNSProvider) error {
+	for _, provider := range providers {
+		err := this.decodeProvider(provider)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
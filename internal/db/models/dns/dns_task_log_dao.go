@@ -0,0 +1,101 @@
+package dns
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	timeutil "github.com/iwind/TeaGo/utils/time"
+	"time"
+)
+
+type DNSTaskLogDAO dbs.DAO
+
+func NewDNSTaskLogDAO() *DNSTaskLogDAO {
+	return dbs.NewDAO(&DNSTaskLogDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeDNSTaskLogs",
+			Model:  new(DNSTaskLog),
+			PkName: "id",
+		},
+	}).(*DNSTaskLogDAO)
+}
+
+var SharedDNSTaskLogDAO *DNSTaskLogDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedDNSTaskLogDAO = NewDNSTaskLogDAO()
+	})
+}
+
+// CreateLog 记录一次记录变更
+// record 为新的记录，删除时为被删除的记录；oldRecord 为修改前的记录，只有修改时才有
+func (this *DNSTaskLogDAO) CreateLog(tx *dbs.Tx, taskId int64, taskType DNSTaskType, clusterId int64, serverId int64, domainId int64, action string, record *dnstypes.Record, oldRecord *dnstypes.Record, errString string) error {
+	op := NewDNSTaskLogOperator()
+	op.TaskId = taskId
+	op.TaskType = taskType
+	op.ClusterId = clusterId
+	op.ServerId = serverId
+	op.DomainId = domainId
+	op.Action = action
+
+	if record != nil {
+		recordJSON, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		op.Record = recordJSON
+	}
+	if oldRecord != nil {
+		oldRecordJSON, err := json.Marshal(oldRecord)
+		if err != nil {
+			return err
+		}
+		op.OldRecord = oldRecordJSON
+	}
+
+	op.IsOk = len(errString) == 0
+	op.Error = errString
+	op.CreatedAt = time.Now().Unix()
+	op.Day = timeutil.Format("Ymd")
+	return this.Save(tx, op)
+}
+
+// CountLogs 计算日志数量
+func (this *DNSTaskLogDAO) CountLogs(tx *dbs.Tx, domainId int64, clusterId int64, serverId int64) (int64, error) {
+	query := this.Query(tx)
+	if domainId > 0 {
+		query.Attr("domainId", domainId)
+	}
+	if clusterId > 0 {
+		query.Attr("clusterId", clusterId)
+	}
+	if serverId > 0 {
+		query.Attr("serverId", serverId)
+	}
+	return query.Count()
+}
+
+// ListLogs 列出单页日志
+func (this *DNSTaskLogDAO) ListLogs(tx *dbs.Tx, domainId int64, clusterId int64, serverId int64, offset int64, size int64) (result []*DNSTaskLog, err error) {
+	query := this.Query(tx)
+	if domainId > 0 {
+		query.Attr("domainId", domainId)
+	}
+	if clusterId > 0 {
+		query.Attr("clusterId", clusterId)
+	}
+	if serverId > 0 {
+		query.Attr("serverId", serverId)
+	}
+	_, err = query.
+		Offset(offset).
+		Limit(size).
+		DescPk().
+		Slice(&result).
+		FindAll()
+	return
+}
@@ -0,0 +1,41 @@
+package dns
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/iwind/TeaGo/bootstrap"
+	"github.com/iwind/TeaGo/dbs"
+	"testing"
+)
+
+func TestDNSTaskLogDAO_CreateLog(t *testing.T) {
+	dbs.NotifyReady()
+
+	var tx *dbs.Tx
+	err := SharedDNSTaskLogDAO.CreateLog(tx, 1, DNSTaskTypeClusterChange, 1, 0, 1, "update", &dnstypes.Record{
+		Name:  "cluster1",
+		Type:  dnstypes.RecordTypeA,
+		Value: "192.168.1.101",
+		Route: "default",
+	}, &dnstypes.Record{
+		Name:  "cluster1",
+		Type:  dnstypes.RecordTypeA,
+		Value: "192.168.1.100",
+		Route: "default",
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := SharedDNSTaskLogDAO.ListLogs(tx, 1, 0, 0, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, log := range logs {
+		record, err := log.DecodeRecord()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log(log.Action, record)
+	}
+}
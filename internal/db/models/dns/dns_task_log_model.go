@@ -0,0 +1,38 @@
+package dns
+
+// DNSTaskLog DNS同步日志
+type DNSTaskLog struct {
+	Id        uint64 `field:"id"`        // ID
+	TaskId    uint64 `field:"taskId"`    // 任务ID
+	TaskType  string `field:"taskType"`  // 任务类型
+	ClusterId uint32 `field:"clusterId"` // 集群ID
+	ServerId  uint32 `field:"serverId"`  // 服务ID
+	DomainId  uint32 `field:"domainId"`  // 域名ID
+	Action    string `field:"action"`    // 操作：add, update, delete
+	Record    string `field:"record"`    // 记录
+	OldRecord string `field:"oldRecord"` // 修改前的记录
+	IsOk      uint8  `field:"isOk"`      // 是否成功
+	Error     string `field:"error"`     // 错误信息
+	CreatedAt uint64 `field:"createdAt"` // 创建时间
+	Day       string `field:"day"`       // 日期YYYYMMDD
+}
+
+type DNSTaskLogOperator struct {
+	Id        interface{} // ID
+	TaskId    interface{} // 任务ID
+	TaskType  interface{} // 任务类型
+	ClusterId interface{} // 集群ID
+	ServerId  interface{} // 服务ID
+	DomainId  interface{} // 域名ID
+	Action    interface{} // 操作：add, update, delete
+	Record    interface{} // 记录
+	OldRecord interface{} // 修改前的记录
+	IsOk      interface{} // 是否成功
+	Error     interface{} // 错误信息
+	CreatedAt interface{} // 创建时间
+	Day       interface{} // 日期YYYYMMDD
+}
+
+func NewDNSTaskLogOperator() *DNSTaskLogOperator {
+	return &DNSTaskLogOperator{}
+}
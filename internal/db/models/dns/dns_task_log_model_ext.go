@@ -0,0 +1,28 @@
+package dns
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
+)
+
+// DecodeRecord 解析记录
+func (this *DNSTaskLog) DecodeRecord() (*dnstypes.Record, error) {
+	return this.decodeRecord(this.Record)
+}
+
+// DecodeOldRecord 解析修改前的记录
+func (this *DNSTaskLog) DecodeOldRecord() (*dnstypes.Record, error) {
+	return this.decodeRecord(this.OldRecord)
+}
+
+func (this *DNSTaskLog) decodeRecord(recordJSON string) (*dnstypes.Record, error) {
+	if len(recordJSON) == 0 || recordJSON == "null" {
+		return nil, nil
+	}
+	var record = &dnstypes.Record{}
+	err := json.Unmarshal([]byte(recordJSON), record)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package models
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/types"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultHTTPAccessLogBatchSize = 100  // 默认每条INSERT语句包含的日志数量
+	MaxHTTPAccessLogBatchSize     = 1000 // 每条INSERT语句最多包含的日志数量
+
+	MaxHTTPAccessLogBufferSize = 100000 // 写入失败时缓冲区中最多保留的日志数量
+)
+
+var SharedHTTPAccessLogBuffer = NewHTTPAccessLogBuffer()
+
+// HTTPAccessLogBuffer 访问日志写入缓冲区
+// 在设置了刷新间隔后，收到的日志会先放入缓冲区，然后定时或者积累到一定数量后批量写入数据库
+type HTTPAccessLogBuffer struct {
+	batchSize     int
+	flushInterval time.Duration
+
+	accessLogs []*pb.HTTPAccessLog
+	locker     sync.Mutex
+
+	flushLocker sync.Mutex
+	notifyChan  chan bool
+	ticker      *time.Ticker
+}
+
+func NewHTTPAccessLogBuffer() *HTTPAccessLogBuffer {
+	return &HTTPAccessLogBuffer{
+		batchSize:  DefaultHTTPAccessLogBatchSize,
+		notifyChan: make(chan bool, 1),
+	}
+}
+
+// Configure 设置批量写入参数
+// batchSize 为每条INSERT语句最多包含的日志数量，flushInterval 为0时表示不使用缓冲区
+func (this *HTTPAccessLogBuffer) Configure(batchSize int, flushInterval time.Duration) {
+	if batchSize <= 0 {
+		batchSize = DefaultHTTPAccessLogBatchSize
+	}
+	if batchSize > MaxHTTPAccessLogBatchSize {
+		batchSize = MaxHTTPAccessLogBatchSize
+	}
+	if flushInterval < 0 {
+		flushInterval = 0
+	}
+
+	this.locker.Lock()
+	this.batchSize = batchSize
+	this.flushInterval = flushInterval
+	if this.ticker != nil && flushInterval > 0 {
+		this.ticker.Reset(flushInterval)
+	}
+	this.locker.Unlock()
+}
+
+// BatchSize 每条INSERT语句最多包含的日志数量
+func (this *HTTPAccessLogBuffer) BatchSize() int {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	return this.batchSize
+}
+
+// IsBuffered 是否使用缓冲区
+func (this *HTTPAccessLogBuffer) IsBuffered() bool {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	return this.flushInterval > 0 && this.ticker != nil
+}
+
+// Start 启动定时刷新
+func (this *HTTPAccessLogBuffer) Start() {
+	this.locker.Lock()
+	if this.ticker != nil || this.flushInterval <= 0 {
+		this.locker.Unlock()
+		return
+	}
+	this.ticker = time.NewTicker(this.flushInterval)
+	var ticker = this.ticker
+	this.locker.Unlock()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-this.notifyChan:
+		}
+		err := this.Flush()
+		if err != nil {
+			logs.Println("HTTP_ACCESS_LOG", "flush failed: "+err.Error())
+		}
+	}
+}
+
+// Push 放入日志
+func (this *HTTPAccessLogBuffer) Push(accessLogs []*pb.HTTPAccessLog) {
+	if len(accessLogs) == 0 {
+		return
+	}
+
+	this.locker.Lock()
+	this.accessLogs = append(this.accessLogs, accessLogs...)
+	var count = len(this.accessLogs)
+	var batchSize = this.batchSize
+	this.locker.Unlock()
+
+	// 积累过多时立即写入，防止占用过多内存
+	if count >= batchSize*10 {
+		err := this.Flush()
+		if err != nil {
+			logs.Println("HTTP_ACCESS_LOG", "flush failed: "+err.Error())
+		}
+		return
+	}
+
+	if count >= batchSize {
+		select {
+		case this.notifyChan <- true:
+		default:
+		}
+	}
+}
+
+// Flush 将缓冲区中的日志写入数据库
+func (this *HTTPAccessLogBuffer) Flush() error {
+	this.flushLocker.Lock()
+	defer this.flushLocker.Unlock()
+
+	this.locker.Lock()
+	var accessLogs = this.accessLogs
+	this.accessLogs = nil
+	this.locker.Unlock()
+
+	if len(accessLogs) == 0 {
+		return nil
+	}
+
+	// 每天的日志在一个事务中写入，失败时将尚未写入的日志放回缓冲区，等待下次写入
+	days, dayLogsMap := groupHTTPAccessLogsByDay(accessLogs)
+	for index, day := range days {
+		err := SharedHTTPAccessLogDAO.createHTTPAccessLogs(nil, dayLogsMap[day])
+		if err != nil {
+			var failedLogs = []*pb.HTTPAccessLog{}
+			for _, failedDay := range days[index:] {
+				failedLogs = append(failedLogs, dayLogsMap[failedDay]...)
+			}
+			this.requeue(failedLogs)
+			return err
+		}
+	}
+	return nil
+}
+
+// 将写入失败的日志放回缓冲区头部，超出限制时丢弃最早的日志
+func (this *HTTPAccessLogBuffer) requeue(accessLogs []*pb.HTTPAccessLog) {
+	this.locker.Lock()
+	this.accessLogs = append(accessLogs, this.accessLogs...)
+	var countDropped = len(this.accessLogs) - MaxHTTPAccessLogBufferSize
+	if countDropped > 0 {
+		this.accessLogs = this.accessLogs[countDropped:]
+	}
+	this.locker.Unlock()
+
+	if countDropped > 0 {
+		logs.Println("HTTP_ACCESS_LOG", "buffer is full, drop "+types.String(countDropped)+" access logs")
+	}
+}
+
+// Len 缓冲区中日志数量
+func (this *HTTPAccessLogBuffer) Len() int {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	return len(this.accessLogs)
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package models
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"testing"
+)
+
+func TestHTTPAccessLogBuffer_Requeue(t *testing.T) {
+	var buffer = NewHTTPAccessLogBuffer()
+	buffer.Push([]*pb.HTTPAccessLog{{RequestId: "3"}})
+	buffer.requeue([]*pb.HTTPAccessLog{{RequestId: "1"}, {RequestId: "2"}})
+	if buffer.Len() != 3 || buffer.accessLogs[0].RequestId != "1" || buffer.accessLogs[2].RequestId != "3" {
+		t.Fatal("failed logs should be put back before new logs")
+	}
+
+	// 超出限制时丢弃最早的日志
+	var accessLogs = []*pb.HTTPAccessLog{}
+	for i := 0; i < MaxHTTPAccessLogBufferSize; i++ {
+		accessLogs = append(accessLogs, &pb.HTTPAccessLog{})
+	}
+	buffer.requeue(accessLogs)
+	if buffer.Len() != MaxHTTPAccessLogBufferSize || buffer.accessLogs[buffer.Len()-1].RequestId != "3" {
+		t.Fatal("buffer should be bounded")
+	}
+}
@@ -46,7 +46,17 @@ func NewHTTPAccessLogDAO() *HTTPAccessLogDAO {
 }
 
 // CreateHTTPAccessLogs 创建访问日志
+// 如果开启了缓冲，则先放入缓冲区，由缓冲区定时批量写入
 func (this *HTTPAccessLogDAO) CreateHTTPAccessLogs(tx *dbs.Tx, accessLogs []*pb.HTTPAccessLog) error {
+	if tx == nil && SharedHTTPAccessLogBuffer.IsBuffered() {
+		SharedHTTPAccessLogBuffer.Push(accessLogs)
+		return nil
+	}
+	return this.createHTTPAccessLogs(tx, accessLogs)
+}
+
+// 使用随机的DAO创建访问日志
+func (this *HTTPAccessLogDAO) createHTTPAccessLogs(tx *dbs.Tx, accessLogs []*pb.HTTPAccessLog) error {
 	dao := randomHTTPAccessLogDAO()
 	if dao == nil {
 		dao = &HTTPAccessLogDAOWrapper{
@@ -58,6 +68,7 @@ func (this *HTTPAccessLogDAO) CreateHTTPAccessLogs(tx *dbs.Tx, accessLogs []*pb.
 }
 
 // CreateHTTPAccessLogsWithDAO 使用特定的DAO创建访问日志
+// 按日期分表，每个表中的日志使用多行INSERT语句在一个事务中批量写入
 func (this *HTTPAccessLogDAO) CreateHTTPAccessLogsWithDAO(tx *dbs.Tx, daoWrapper *HTTPAccessLogDAOWrapper, accessLogs []*pb.HTTPAccessLog) error {
 	if daoWrapper == nil {
 		return errors.New("dao should not be nil")
@@ -68,67 +79,170 @@ func (this *HTTPAccessLogDAO) CreateHTTPAccessLogsWithDAO(tx *dbs.Tx, daoWrapper
 
 	dao := daoWrapper.DAO
 
-	// TODO 改成事务批量提交，以加快速度
+	days, dayLogsMap := groupHTTPAccessLogsByDay(accessLogs)
+	var batchSize = SharedHTTPAccessLogBuffer.BatchSize()
+	for _, day := range days {
+		err := this.insertDayAccessLogs(tx, dao, day, dayLogsMap[day], batchSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
+// 按日期分组，保持原有顺序
+func groupHTTPAccessLogsByDay(accessLogs []*pb.HTTPAccessLog) (days []string, dayLogsMap map[string][]*pb.HTTPAccessLog) {
+	dayLogsMap = map[string][]*pb.HTTPAccessLog{} // day => logs
 	for _, accessLog := range accessLogs {
 		day := timeutil.Format("Ymd", time.Unix(accessLog.Timestamp, 0))
-		tableDef, err := findHTTPAccessLogTable(dao.Instance, day, false)
+		_, ok := dayLogsMap[day]
+		if !ok {
+			days = append(days, day)
+		}
+		dayLogsMap[day] = append(dayLogsMap[day], accessLog)
+	}
+	return
+}
+
+// 批量写入某一天的访问日志
+func (this *HTTPAccessLogDAO) insertDayAccessLogs(tx *dbs.Tx, dao *HTTPAccessLogDAO, day string, accessLogs []*pb.HTTPAccessLog, batchSize int) error {
+	tableDef, err := findHTTPAccessLogTable(dao.Instance, day, false)
+	if err != nil {
+		return err
+	}
+
+	var insertFunc = func(tx *dbs.Tx) error {
+		for i := 0; i < len(accessLogs); i += batchSize {
+			var j = i + batchSize
+			if j > len(accessLogs) {
+				j = len(accessLogs)
+			}
+			err := this.insertAccessLogRows(tx, tableDef, accessLogs[i:j])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if tx != nil {
+		err = insertFunc(tx)
+	} else {
+		err = dao.Instance.RunTx(insertFunc)
+	}
+	if err == nil {
+		return nil
+	}
+
+	// 是否为 Error 1146: Table 'xxx.xxx' doesn't exist  如果是，则创建表之后重试
+	if strings.Contains(err.Error(), "1146") {
+		tableDef, err = findHTTPAccessLogTable(dao.Instance, day, true)
 		if err != nil {
 			return err
 		}
+		if tx != nil {
+			return insertFunc(tx)
+		}
+		return dao.Instance.RunTx(insertFunc)
+	}
+
+	// 批量写入失败时逐条写入，防止个别日志导致整批日志丢失
+	logs.Println("HTTP_ACCESS_LOG", "batch insert failed: "+err.Error()+", retry one by one")
+	return this.insertAccessLogsOneByOne(tx, dao, tableDef, accessLogs)
+}
+
+// 使用多行INSERT语句写入一组日志
+func (this *HTTPAccessLogDAO) insertAccessLogRows(tx *dbs.Tx, tableDef *httpAccessLogDefinition, accessLogs []*pb.HTTPAccessLog) error {
+	if len(accessLogs) == 0 {
+		return nil
+	}
+
+	var columns = []string{"serverId", "nodeId", "status", "createdAt", "requestId", "firewallPolicyId", "firewallRuleGroupId", "firewallRuleSetId", "firewallRuleId", "content"}
+	if tableDef.HasRemoteAddr {
+		columns = append(columns, "remoteAddr")
+	}
+	if tableDef.HasDomain {
+		columns = append(columns, "domain")
+	}
 
-		fields := map[string]interface{}{}
-		fields["serverId"] = accessLog.ServerId
-		fields["nodeId"] = accessLog.NodeId
-		fields["status"] = accessLog.Status
-		fields["createdAt"] = accessLog.Timestamp
-		fields["requestId"] = accessLog.RequestId + strconv.FormatInt(time.Now().UnixNano(), 10) + configs.PaddingId
-		fields["firewallPolicyId"] = accessLog.FirewallPolicyId
-		fields["firewallRuleGroupId"] = accessLog.FirewallRuleGroupId
-		fields["firewallRuleSetId"] = accessLog.FirewallRuleSetId
-		fields["firewallRuleId"] = accessLog.FirewallRuleId
-
-		// TODO 根据集群、服务设置获取IP
-		if tableDef.HasRemoteAddr {
-			fields["remoteAddr"] = accessLog.RawRemoteAddr
+	var placeholder = "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+	var sqlBuilder = &strings.Builder{}
+	sqlBuilder.WriteString("INSERT INTO `" + tableDef.Name + "` (`" + strings.Join(columns, "`,`") + "`) VALUES ")
+
+	var args = make([]interface{}, 0, len(columns)*len(accessLogs))
+	var countRows = 0
+	for _, accessLog := range accessLogs {
+		fields, err := this.accessLogFields(tableDef, accessLog)
+		if err != nil {
+			logs.Println("HTTP_ACCESS_LOG", err.Error())
+			continue
+		}
+		if countRows > 0 {
+			sqlBuilder.WriteString(",")
 		}
-		if tableDef.HasDomain {
-			fields["domain"] = accessLog.Host
+		sqlBuilder.WriteString(placeholder)
+		for _, column := range columns {
+			args = append(args, fields[column])
 		}
+		countRows++
+	}
+	if countRows == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(sqlBuilder.String(), args...)
+	return err
+}
 
-		content, err := json.Marshal(accessLog)
+// 逐条写入日志
+func (this *HTTPAccessLogDAO) insertAccessLogsOneByOne(tx *dbs.Tx, dao *HTTPAccessLogDAO, tableDef *httpAccessLogDefinition, accessLogs []*pb.HTTPAccessLog) error {
+	for _, accessLog := range accessLogs {
+		fields, err := this.accessLogFields(tableDef, accessLog)
 		if err != nil {
 			return err
 		}
-		fields["content"] = content
 
 		_, err = dao.Query(tx).
 			Table(tableDef.Name).
 			Sets(fields).
 			Insert()
 		if err != nil {
-			// 是否为 Error 1146: Table 'xxx.xxx' doesn't exist  如果是，则创建表之后重试
-			if strings.Contains(err.Error(), "1146") {
-				tableDef, err = findHTTPAccessLogTable(dao.Instance, day, true)
-				if err != nil {
-					return err
-				}
-				_, err = dao.Query(tx).
-					Table(tableDef.Name).
-					Sets(fields).
-					Insert()
-				if err != nil {
-					return err
-				}
-			} else {
-				logs.Println("HTTP_ACCESS_LOG", err.Error())
-			}
+			logs.Println("HTTP_ACCESS_LOG", err.Error())
 		}
 	}
-
 	return nil
 }
 
+// 日志对应的数据表字段
+func (this *HTTPAccessLogDAO) accessLogFields(tableDef *httpAccessLogDefinition, accessLog *pb.HTTPAccessLog) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	fields["serverId"] = accessLog.ServerId
+	fields["nodeId"] = accessLog.NodeId
+	fields["status"] = accessLog.Status
+	fields["createdAt"] = accessLog.Timestamp
+	fields["requestId"] = accessLog.RequestId + strconv.FormatInt(time.Now().UnixNano(), 10) + configs.PaddingId
+	fields["firewallPolicyId"] = accessLog.FirewallPolicyId
+	fields["firewallRuleGroupId"] = accessLog.FirewallRuleGroupId
+	fields["firewallRuleSetId"] = accessLog.FirewallRuleSetId
+	fields["firewallRuleId"] = accessLog.FirewallRuleId
+
+	// TODO 根据集群、服务设置获取IP
+	if tableDef.HasRemoteAddr {
+		fields["remoteAddr"] = accessLog.RawRemoteAddr
+	}
+	if tableDef.HasDomain {
+		fields["domain"] = accessLog.Host
+	}
+
+	content, err := json.Marshal(accessLog)
+	if err != nil {
+		return nil, err
+	}
+	fields["content"] = content
+	return fields, nil
+}
+
 // ListAccessLogs 读取往前的 单页访问日志
 func (this *HTTPAccessLogDAO) ListAccessLogs(tx *dbs.Tx, lastRequestId string,
 	size int64,
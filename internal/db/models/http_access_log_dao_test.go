@@ -145,3 +145,88 @@ func TestHTTPAccessLogDAO_ListAccessLogs_Page_NotExists(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPAccessLogDAO_CreateHTTPAccessLogsWithDAO_Batch(t *testing.T) {
+	var tx *dbs.Tx
+
+	err := NewDBNodeInitializer().loop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var now = time.Now()
+	var accessLogs = []*pb.HTTPAccessLog{}
+	for i := 0; i < 250; i++ {
+		var timestamp = now.Unix()
+		if i%2 == 0 {
+			timestamp = now.AddDate(0, 0, -1).Unix()
+		}
+		accessLogs = append(accessLogs, &pb.HTTPAccessLog{
+			ServerId:  1,
+			NodeId:    4,
+			Status:    200,
+			Timestamp: timestamp,
+		})
+	}
+
+	dao := randomHTTPAccessLogDAO()
+	if dao == nil {
+		dao = &HTTPAccessLogDAOWrapper{DAO: SharedHTTPAccessLogDAO}
+	}
+	err = SharedHTTPAccessLogDAO.CreateHTTPAccessLogsWithDAO(tx, dao, accessLogs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("ok")
+}
+
+func BenchmarkHTTPAccessLogDAO_CreateHTTPAccessLogsWithDAO(b *testing.B) {
+	err := NewDBNodeInitializer().loop()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	dao := randomHTTPAccessLogDAO()
+	if dao == nil {
+		dao = &HTTPAccessLogDAOWrapper{DAO: SharedHTTPAccessLogDAO}
+	}
+
+	var accessLogs = []*pb.HTTPAccessLog{}
+	for i := 0; i < 100; i++ {
+		accessLogs = append(accessLogs, &pb.HTTPAccessLog{
+			ServerId:      1,
+			NodeId:        4,
+			Status:        200,
+			Timestamp:     time.Now().Unix(),
+			RemoteAddr:    "127.0.0.1",
+			RawRemoteAddr: "127.0.0.1",
+			Host:          "example.com",
+			RequestURI:    "/hello?name=world",
+			RequestMethod: "GET",
+			UserAgent:     "Mozilla/5.0",
+		})
+	}
+
+	tableDef, err := findHTTPAccessLogTable(dao.DAO.Instance, timeutil.Format("Ymd"), true)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			err := SharedHTTPAccessLogDAO.CreateHTTPAccessLogsWithDAO(nil, dao, accessLogs)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("oneByOne", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			err := SharedHTTPAccessLogDAO.insertAccessLogsOneByOne(nil, dao.DAO, tableDef, accessLogs)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -31,7 +31,10 @@ const (
 	MessageTypeHealthCheckNodeDown        MessageType = "HealthCheckNodeDown"        // 因健康检查节点下线
 	MessageTypeNodeInactive               MessageType = "NodeInactive"               // 边缘节点不活跃
 	MessageTypeNodeActive                 MessageType = "NodeActive"                 // 边缘节点活跃
+	MessageTypeNodeResourceOverload       MessageType = "NodeResourceOverload"       // 边缘节点资源持续超出限制
+	MessageTypeNodeResourceRecovered      MessageType = "NodeResourceRecovered"      // 边缘节点资源恢复正常
 	MessageTypeClusterDNSSyncFailed       MessageType = "ClusterDNSSyncFailed"       // DNS同步失败
+	MessageTypeClusterDNSFailover         MessageType = "ClusterDNSFailover"         // DNS故障切换
 	MessageTypeSSLCertExpiring            MessageType = "SSLCertExpiring"            // SSL证书即将过期
 	MessageTypeSSLCertACMETaskFailed      MessageType = "SSLCertACMETaskFailed"      // SSL证书任务执行失败
 	MessageTypeSSLCertACMETaskSuccess     MessageType = "SSLCertACMETaskSuccess"     // SSL证书任务执行成功
@@ -45,6 +48,14 @@ const (
 	MessageTypeNSNodeActive   MessageType = "NSNodeActive"   // 边缘节点活跃
 )
 
+// 可以表示某类消息已经恢复的消息类型
+var messageResolvingTypes = map[MessageType][]MessageType{
+	MessageTypeNodeInactive:         {MessageTypeNodeActive},
+	MessageTypeHealthCheckNodeDown:  {MessageTypeHealthCheckNodeUp},
+	MessageTypeNodeResourceOverload: {MessageTypeNodeResourceRecovered},
+	MessageTypeNSNodeInactive:       {MessageTypeNSNodeActive},
+}
+
 type MessageDAO dbs.DAO
 
 func NewMessageDAO() *MessageDAO {
@@ -98,11 +109,22 @@ func (this *MessageDAO) FindEnabledMessage(tx *dbs.Tx, id int64) (*Message, erro
 
 // CreateClusterMessage 创建集群消息
 func (this *MessageDAO) CreateClusterMessage(tx *dbs.Tx, role string, clusterId int64, messageType MessageType, level string, subject string, body string, paramsJSON []byte) error {
-	_, err := this.createMessage(tx, role, clusterId, 0, messageType, level, subject, body, paramsJSON)
+	messageId, err := this.createMessage(tx, role, clusterId, 0, 0, messageType, level, subject, body, paramsJSON)
 	if err != nil {
 		return err
 	}
 
+	// 按照路由规则发送
+	isRouted, err := SharedMessageRouteDAO.RouteMessage(tx, messageId, role, messageType, level, MessageTaskTarget{
+		ClusterId: clusterId,
+	}, subject, body)
+	if err != nil {
+		return err
+	}
+	if isRouted {
+		return nil
+	}
+
 	// 发送给媒介接收人
 	err = SharedMessageTaskDAO.CreateMessageTasks(tx, MessageTaskTarget{
 		ClusterId: clusterId,
@@ -131,11 +153,23 @@ func (this *MessageDAO) CreateNodeMessage(tx *dbs.Tx, role string, clusterId int
 		return nil
 	}
 
-	_, err = this.createMessage(tx, role, clusterId, nodeId, messageType, level, subject, body, paramsJSON)
+	messageId, err := this.createMessage(tx, role, clusterId, nodeId, 0, messageType, level, subject, body, paramsJSON)
 	if err != nil {
 		return err
 	}
 
+	// 按照路由规则发送
+	isRouted, err := SharedMessageRouteDAO.RouteMessage(tx, messageId, role, messageType, level, MessageTaskTarget{
+		ClusterId: clusterId,
+		NodeId:    nodeId,
+	}, subject, body)
+	if err != nil {
+		return err
+	}
+	if isRouted {
+		return nil
+	}
+
 	// TODO 目前只支持边缘节点发送消息，将来要支持NS节点
 	if role == nodeconfigs.NodeRoleNode {
 		// 发送给媒介接收人 - 集群
@@ -164,6 +198,32 @@ func (this *MessageDAO) CreateNodeMessage(tx *dbs.Tx, role string, clusterId int
 	return nil
 }
 
+// CreateServerMessage 创建服务消息
+func (this *MessageDAO) CreateServerMessage(tx *dbs.Tx, role string, clusterId int64, nodeId int64, serverId int64, messageType MessageType, level string, subject string, body string, paramsJSON []byte) error {
+	messageId, err := this.createMessage(tx, role, clusterId, nodeId, serverId, messageType, level, subject, body, paramsJSON)
+	if err != nil {
+		return err
+	}
+
+	var target = MessageTaskTarget{
+		ClusterId: clusterId,
+		NodeId:    nodeId,
+		ServerId:  serverId,
+	}
+
+	// 按照路由规则发送
+	isRouted, err := SharedMessageRouteDAO.RouteMessage(tx, messageId, role, messageType, level, target, subject, body)
+	if err != nil {
+		return err
+	}
+	if isRouted {
+		return nil
+	}
+
+	// 发送给媒介接收人
+	return SharedMessageTaskDAO.CreateMessageTasks(tx, target, messageType, subject, body)
+}
+
 // CreateMessage 创建普通消息
 func (this *MessageDAO) CreateMessage(tx *dbs.Tx, adminId int64, userId int64, messageType MessageType, level string, subject string, body string, paramsJSON []byte) error {
 	op := NewMessageOperator()
@@ -293,8 +353,43 @@ func (this *MessageDAO) CheckMessageUser(tx *dbs.Tx, messageId int64, adminId in
 	return query.Exist()
 }
 
+// ExistsRecentMessage 检查最近一段时间内是否已经有同样的消息
+// 用来在路由规则中去重，只比较消息类型、角色、集群、节点和服务，不比较内容
+func (this *MessageDAO) ExistsRecentMessage(tx *dbs.Tx, messageId int64, role string, messageType MessageType, clusterId int64, nodeId int64, serverId int64, seconds int64) (bool, error) {
+	return this.Query(tx).
+		Lt("id", messageId).
+		Attr("role", role).
+		Attr("type", messageType).
+		Attr("clusterId", clusterId).
+		Attr("nodeId", nodeId).
+		Attr("serverId", serverId).
+		Gte("createdAt", time.Now().Unix()-seconds).
+		State(MessageStateEnabled).
+		Exist()
+}
+
+// CheckMessageResolved 检查消息是否已经处理
+// 已读或者之后收到了对应的恢复消息都认为已经处理
+func (this *MessageDAO) CheckMessageResolved(tx *dbs.Tx, message *Message) (bool, error) {
+	if message.IsRead == 1 {
+		return true, nil
+	}
+	resolvingTypes, ok := messageResolvingTypes[message.Type]
+	if !ok || message.NodeId == 0 {
+		return false, nil
+	}
+	return this.Query(tx).
+		Gt("id", message.Id).
+		Attr("type", resolvingTypes).
+		Attr("role", message.Role).
+		Attr("clusterId", message.ClusterId).
+		Attr("nodeId", message.NodeId).
+		State(MessageStateEnabled).
+		Exist()
+}
+
 // 创建消息
-func (this *MessageDAO) createMessage(tx *dbs.Tx, role string, clusterId int64, nodeId int64, messageType MessageType, level string, subject string, body string, paramsJSON []byte) (int64, error) {
+func (this *MessageDAO) createMessage(tx *dbs.Tx, role string, clusterId int64, nodeId int64, serverId int64, messageType MessageType, level string, subject string, body string, paramsJSON []byte) (int64, error) {
 	// TODO 检查同样的消息最近是否发送过
 
 	// 创建新消息
@@ -304,6 +399,7 @@ func (this *MessageDAO) createMessage(tx *dbs.Tx, role string, clusterId int64,
 	op.Role = role
 	op.ClusterId = clusterId
 	op.NodeId = nodeId
+	op.ServerId = serverId
 	op.Type = messageType
 	op.Level = level
 
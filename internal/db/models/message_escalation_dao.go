@@ -0,0 +1,73 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"time"
+)
+
+type MessageEscalationStatus = int
+
+const (
+	MessageEscalationStatusWaiting   MessageEscalationStatus = 0 // 等待
+	MessageEscalationStatusEscalated MessageEscalationStatus = 1 // 已升级
+	MessageEscalationStatusCancelled MessageEscalationStatus = 2 // 已取消
+)
+
+type MessageEscalationDAO dbs.DAO
+
+func NewMessageEscalationDAO() *MessageEscalationDAO {
+	return dbs.NewDAO(&MessageEscalationDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeMessageEscalations",
+			Model:  new(MessageEscalation),
+			PkName: "id",
+		},
+	}).(*MessageEscalationDAO)
+}
+
+var SharedMessageEscalationDAO *MessageEscalationDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedMessageEscalationDAO = NewMessageEscalationDAO()
+	})
+}
+
+// CreateEscalation 创建升级
+func (this *MessageEscalationDAO) CreateEscalation(tx *dbs.Tx, messageId int64, routeId int64, escalateAt int64) error {
+	op := NewMessageEscalationOperator()
+	op.MessageId = messageId
+	op.RouteId = routeId
+	op.EscalateAt = escalateAt
+	op.Status = MessageEscalationStatusWaiting
+	op.CreatedAt = time.Now().Unix()
+	return this.Save(tx, op)
+}
+
+// FindAllWaitingEscalations 查找已经到期的升级
+func (this *MessageEscalationDAO) FindAllWaitingEscalations(tx *dbs.Tx, now int64, size int64) (result []*MessageEscalation, err error) {
+	_, err = this.Query(tx).
+		Attr("status", MessageEscalationStatusWaiting).
+		Lte("escalateAt", now).
+		AscPk().
+		Limit(size).
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// UpdateEscalationStatus 修改等待中的升级状态，如果已经被其他进程处理则返回false
+func (this *MessageEscalationDAO) UpdateEscalationStatus(tx *dbs.Tx, escalationId int64, status MessageEscalationStatus) (bool, error) {
+	rows, err := this.Query(tx).
+		Pk(escalationId).
+		Attr("status", MessageEscalationStatusWaiting).
+		Set("status", status).
+		Update()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
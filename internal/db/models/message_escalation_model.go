@@ -0,0 +1,24 @@
+package models
+
+// MessageEscalation 消息升级
+type MessageEscalation struct {
+	Id         uint64 `field:"id"`         // ID
+	MessageId  uint64 `field:"messageId"`  // 消息ID
+	RouteId    uint32 `field:"routeId"`    // 路由规则ID
+	EscalateAt uint64 `field:"escalateAt"` // 升级时间
+	Status     uint8  `field:"status"`     // 状态：0等待，1已升级，2已取消
+	CreatedAt  uint64 `field:"createdAt"`  // 创建时间
+}
+
+type MessageEscalationOperator struct {
+	Id         interface{} // ID
+	MessageId  interface{} // 消息ID
+	RouteId    interface{} // 路由规则ID
+	EscalateAt interface{} // 升级时间
+	Status     interface{} // 状态：0等待，1已升级，2已取消
+	CreatedAt  interface{} // 创建时间
+}
+
+func NewMessageEscalationOperator() *MessageEscalationOperator {
+	return &MessageEscalationOperator{}
+}
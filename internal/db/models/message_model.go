@@ -8,6 +8,7 @@ type Message struct {
 	Role      string `field:"role"`      // 角色
 	ClusterId uint32 `field:"clusterId"` // 集群ID
 	NodeId    uint32 `field:"nodeId"`    // 节点ID
+	ServerId  uint32 `field:"serverId"`  // 服务ID
 	Level     string `field:"level"`     // 级别
 	Subject   string `field:"subject"`   // 标题
 	Body      string `field:"body"`      // 内容
@@ -27,6 +28,7 @@ type MessageOperator struct {
 	Role      interface{} // 角色
 	ClusterId interface{} // 集群ID
 	NodeId    interface{} // 节点ID
+	ServerId  interface{} // 服务ID
 	Level     interface{} // 级别
 	Subject   interface{} // 标题
 	Body      interface{} // 内容
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"time"
+)
+
+const (
+	MessageRouteStateEnabled  = 1 // 已启用
+	MessageRouteStateDisabled = 0 // 已禁用
+)
+
+type MessageRouteDAO dbs.DAO
+
+func NewMessageRouteDAO() *MessageRouteDAO {
+	return dbs.NewDAO(&MessageRouteDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeMessageRoutes",
+			Model:  new(MessageRoute),
+			PkName: "id",
+		},
+	}).(*MessageRouteDAO)
+}
+
+var SharedMessageRouteDAO *MessageRouteDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedMessageRouteDAO = NewMessageRouteDAO()
+	})
+}
+
+// EnableMessageRoute 启用条目
+func (this *MessageRouteDAO) EnableMessageRoute(tx *dbs.Tx, id int64) error {
+	_, err := this.Query(tx).
+		Pk(id).
+		Set("state", MessageRouteStateEnabled).
+		Update()
+	return err
+}
+
+// DisableMessageRoute 禁用条目
+func (this *MessageRouteDAO) DisableMessageRoute(tx *dbs.Tx, id int64) error {
+	_, err := this.Query(tx).
+		Pk(id).
+		Set("state", MessageRouteStateDisabled).
+		Update()
+	return err
+}
+
+// FindEnabledMessageRoute 查找启用中的条目
+func (this *MessageRouteDAO) FindEnabledMessageRoute(tx *dbs.Tx, id int64) (*MessageRoute, error) {
+	result, err := this.Query(tx).
+		Pk(id).
+		Attr("state", MessageRouteStateEnabled).
+		Find()
+	if result == nil {
+		return nil, err
+	}
+	return result.(*MessageRoute), err
+}
+
+// CreateRoute 创建规则
+func (this *MessageRouteDAO) CreateRoute(tx *dbs.Tx, adminId int64, name string, messageTypes []string, levels []string, target MessageTaskTarget, recipientGroupIds []int64, dedupSeconds int32, groupSeconds int32, escalateMinutes int32, escalateGroupIds []int64, isFinal bool) (int64, error) {
+	op := NewMessageRouteOperator()
+	op.AdminId = adminId
+	op.CreatedAt = time.Now().Unix()
+	op.IsOn = true
+	op.State = MessageRouteStateEnabled
+	err := this.fillOperator(op, name, messageTypes, levels, target, recipientGroupIds, dedupSeconds, groupSeconds, escalateMinutes, escalateGroupIds, isFinal)
+	if err != nil {
+		return 0, err
+	}
+	return this.SaveInt64(tx, op)
+}
+
+// UpdateRoute 修改规则
+func (this *MessageRouteDAO) UpdateRoute(tx *dbs.Tx, routeId int64, name string, messageTypes []string, levels []string, target MessageTaskTarget, recipientGroupIds []int64, dedupSeconds int32, groupSeconds int32, escalateMinutes int32, escalateGroupIds []int64, isFinal bool, isOn bool) error {
+	if routeId <= 0 {
+		return errors.New("invalid routeId")
+	}
+	op := NewMessageRouteOperator()
+	op.Id = routeId
+	op.IsOn = isOn
+	err := this.fillOperator(op, name, messageTypes, levels, target, recipientGroupIds, dedupSeconds, groupSeconds, escalateMinutes, escalateGroupIds, isFinal)
+	if err != nil {
+		return err
+	}
+	return this.Save(tx, op)
+}
+
+// UpdateRouteOrders 修改规则排序
+func (this *MessageRouteDAO) UpdateRouteOrders(tx *dbs.Tx, routeIds []int64) error {
+	var order = len(routeIds)
+	for _, routeId := range routeIds {
+		_, err := this.Query(tx).
+			Pk(routeId).
+			Set("order", order).
+			Update()
+		if err != nil {
+			return err
+		}
+		order--
+	}
+	return nil
+}
+
+// FindAllEnabledRoutes 查找所有规则
+func (this *MessageRouteDAO) FindAllEnabledRoutes(tx *dbs.Tx) (result []*MessageRoute, err error) {
+	_, err = this.Query(tx).
+		State(MessageRouteStateEnabled).
+		Desc("order").
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// FindAllMatchedRoutes 查找和消息匹配的规则
+func (this *MessageRouteDAO) FindAllMatchedRoutes(tx *dbs.Tx, messageType MessageType, level string, target MessageTaskTarget) ([]*MessageRoute, error) {
+	routes, err := this.FindAllEnabledRoutes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return MatchMessageRoutes(routes, messageType, level, target), nil
+}
+
+// RouteMessage 根据规则发送消息，如果没有匹配的规则则返回false
+func (this *MessageRouteDAO) RouteMessage(tx *dbs.Tx, messageId int64, role string, messageType MessageType, level string, target MessageTaskTarget, subject string, body string) (isRouted bool, err error) {
+	routes, err := this.FindAllMatchedRoutes(tx, messageType, level, target)
+	if err != nil {
+		return false, err
+	}
+	if len(routes) == 0 {
+		return false, nil
+	}
+
+	var now = time.Now().Unix()
+	var groupIds = []int64{} // 需要立即发送的接收人分组
+	for _, route := range routes {
+		var routeId = int64(route.Id)
+
+		// 去重
+		if route.DedupSeconds > 0 {
+			exists, err := SharedMessageDAO.ExistsRecentMessage(tx, messageId, role, messageType, target.ClusterId, target.NodeId, target.ServerId, int64(route.DedupSeconds))
+			if err != nil {
+				return false, err
+			}
+			if exists {
+				continue
+			}
+		}
+
+		// 升级
+		if route.EscalateMinutes > 0 && len(route.DecodeEscalateGroupIds()) > 0 {
+			err = SharedMessageEscalationDAO.CreateEscalation(tx, messageId, routeId, now+int64(route.EscalateMinutes)*60)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		// 合并发送
+		if route.GroupSeconds > 0 {
+			err = SharedMessageRouteGroupDAO.AddMessage(tx, routeId, messageType+"@"+level, messageId, now+int64(route.GroupSeconds))
+			if err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		groupIds = append(groupIds, route.DecodeRecipientGroupIds()...)
+	}
+
+	if len(groupIds) > 0 {
+		err = SharedMessageTaskDAO.CreateMessageTasksWithGroupIds(tx, groupIds, subject, body)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// MatchMessageRoutes 从一组规则中选出和消息匹配的规则，遇到设置为停止匹配的规则时不再继续
+func MatchMessageRoutes(routes []*MessageRoute, messageType MessageType, level string, target MessageTaskTarget) []*MessageRoute {
+	var result = []*MessageRoute{}
+	for _, route := range routes {
+		if !route.Match(messageType, level, target) {
+			continue
+		}
+		result = append(result, route)
+		if route.IsFinal == 1 {
+			break
+		}
+	}
+	return result
+}
+
+// 设置规则选项
+func (this *MessageRouteDAO) fillOperator(op *MessageRouteOperator, name string, messageTypes []string, levels []string, target MessageTaskTarget, recipientGroupIds []int64, dedupSeconds int32, groupSeconds int32, escalateMinutes int32, escalateGroupIds []int64, isFinal bool) error {
+	if len(recipientGroupIds) == 0 {
+		return errors.New("'recipientGroupIds' should not be empty")
+	}
+	if dedupSeconds < 0 || groupSeconds < 0 || escalateMinutes < 0 {
+		return errors.New("'dedupSeconds', 'groupSeconds' and 'escalateMinutes' should not be negative")
+	}
+
+	op.Name = name
+
+	if messageTypes == nil {
+		messageTypes = []string{}
+	}
+	messageTypesJSON, err := json.Marshal(messageTypes)
+	if err != nil {
+		return err
+	}
+	op.MessageTypes = messageTypesJSON
+
+	if levels == nil {
+		levels = []string{}
+	}
+	levelsJSON, err := json.Marshal(levels)
+	if err != nil {
+		return err
+	}
+	op.Levels = levelsJSON
+
+	op.ClusterId = target.ClusterId
+	op.NodeId = target.NodeId
+	op.ServerId = target.ServerId
+
+	recipientGroupIdsJSON, err := json.Marshal(recipientGroupIds)
+	if err != nil {
+		return err
+	}
+	op.RecipientGroupIds = recipientGroupIdsJSON
+
+	op.DedupSeconds = dedupSeconds
+	op.GroupSeconds = groupSeconds
+	op.EscalateMinutes = escalateMinutes
+
+	if escalateGroupIds == nil {
+		escalateGroupIds = []int64{}
+	}
+	escalateGroupIdsJSON, err := json.Marshal(escalateGroupIds)
+	if err != nil {
+		return err
+	}
+	op.EscalateGroupIds = escalateGroupIdsJSON
+
+	op.IsFinal = isFinal
+	return nil
+}
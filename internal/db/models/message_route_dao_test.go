@@ -0,0 +1,71 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/iwind/TeaGo/bootstrap"
+	"github.com/iwind/TeaGo/dbs"
+	"testing"
+)
+
+func TestMessageRouteDAO_RouteMessage(t *testing.T) {
+	dbs.NotifyReady()
+
+	isRouted, err := SharedMessageRouteDAO.RouteMessage(nil, 1, "node", MessageTypeNodeInactive, MessageLevelError, MessageTaskTarget{
+		ClusterId: 1,
+		NodeId:    1,
+	}, "test subject", "test body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("isRouted:", isRouted)
+}
+
+func TestMatchMessageRoutes(t *testing.T) {
+	var routes = []*MessageRoute{
+		{Id: 1, IsOn: 1, MessageTypes: `["NodeInactive"]`, Levels: `["error"]`},
+		{Id: 2, IsOn: 1, ClusterId: 2},
+		{Id: 3, IsOn: 0},
+		{Id: 4, IsOn: 1, NodeId: 10, IsFinal: 1},
+		{Id: 5, IsOn: 1},
+	}
+
+	var matchIds = func(messageType MessageType, level string, target MessageTaskTarget) []uint32 {
+		var result = []uint32{}
+		for _, route := range MatchMessageRoutes(routes, messageType, level, target) {
+			result = append(result, route.Id)
+		}
+		return result
+	}
+
+	var ids = matchIds(MessageTypeNodeInactive, MessageLevelError, MessageTaskTarget{ClusterId: 1, NodeId: 1})
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 5 {
+		t.Fatal("unexpected routes:", ids)
+	}
+
+	ids = matchIds(MessageTypeNodeActive, MessageLevelSuccess, MessageTaskTarget{ClusterId: 2, NodeId: 10})
+	if len(ids) != 2 || ids[0] != 2 || ids[1] != 4 {
+		t.Fatal("unexpected routes:", ids)
+	}
+}
+
+func TestMatchMessageRoutes_Server(t *testing.T) {
+	var routes = []*MessageRoute{
+		{Id: 1, IsOn: 1, MessageTypes: `["FirewallEvent"]`, ServerId: 100, IsFinal: 1},
+		{Id: 2, IsOn: 1},
+	}
+
+	var result = MatchMessageRoutes(routes, MessageTypeFirewallEvent, MessageLevelWarning, MessageTaskTarget{ClusterId: 1, NodeId: 1, ServerId: 100})
+	if len(result) != 1 || result[0].Id != 1 {
+		t.Fatal("server route should match")
+	}
+
+	result = MatchMessageRoutes(routes, MessageTypeFirewallEvent, MessageLevelWarning, MessageTaskTarget{ClusterId: 1, NodeId: 1, ServerId: 101})
+	if len(result) != 1 || result[0].Id != 2 {
+		t.Fatal("server route should not match other servers")
+	}
+
+	result = MatchMessageRoutes(routes, MessageTypeFirewallEvent, MessageLevelWarning, MessageTaskTarget{ClusterId: 1, NodeId: 1})
+	if len(result) != 1 || result[0].Id != 2 {
+		t.Fatal("server route should not match messages without server")
+	}
+}
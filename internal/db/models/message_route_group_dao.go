@@ -0,0 +1,100 @@
+package models
+
+import (
+	"encoding/json"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/types"
+	"time"
+)
+
+type MessageRouteGroupDAO dbs.DAO
+
+func NewMessageRouteGroupDAO() *MessageRouteGroupDAO {
+	return dbs.NewDAO(&MessageRouteGroupDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeMessageRouteGroups",
+			Model:  new(MessageRouteGroup),
+			PkName: "id",
+		},
+	}).(*MessageRouteGroupDAO)
+}
+
+var SharedMessageRouteGroupDAO *MessageRouteGroupDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedMessageRouteGroupDAO = NewMessageRouteGroupDAO()
+	})
+}
+
+// AddMessage 将消息加入到尚未发送的合并中，如果没有则创建新的合并
+func (this *MessageRouteGroupDAO) AddMessage(tx *dbs.Tx, routeId int64, groupKey string, messageId int64, sendAt int64) error {
+	groupId, err := this.Query(tx).
+		Attr("routeId", routeId).
+		Attr("groupKey", groupKey).
+		Attr("isSent", false).
+		ResultPk().
+		DescPk().
+		FindInt64Col(0)
+	if err != nil {
+		return err
+	}
+	if groupId > 0 {
+		// 合并可能刚好被发送，此时需要创建新的合并
+		rows, err := this.Query(tx).
+			Pk(groupId).
+			Attr("isSent", false).
+			Set("messageIds", dbs.SQL("JSON_ARRAY_APPEND(messageIds, '$', "+types.String(messageId)+")")).
+			Set("countMessages", dbs.SQL("countMessages+1")).
+			Update()
+		if err != nil {
+			return err
+		}
+		if rows > 0 {
+			return nil
+		}
+	}
+
+	messageIdsJSON, err := json.Marshal([]int64{messageId})
+	if err != nil {
+		return err
+	}
+
+	op := NewMessageRouteGroupOperator()
+	op.RouteId = routeId
+	op.GroupKey = groupKey
+	op.MessageIds = messageIdsJSON
+	op.CountMessages = 1
+	op.CreatedAt = time.Now().Unix()
+	op.SendAt = sendAt
+	op.IsSent = false
+	return this.Save(tx, op)
+}
+
+// FindAllSendingGroups 查找需要发送的合并
+func (this *MessageRouteGroupDAO) FindAllSendingGroups(tx *dbs.Tx, now int64, size int64) (result []*MessageRouteGroup, err error) {
+	_, err = this.Query(tx).
+		Attr("isSent", false).
+		Lte("sendAt", now).
+		AscPk().
+		Limit(size).
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// UpdateGroupSent 设置为已发送，如果已经被其他进程发送则返回false
+func (this *MessageRouteGroupDAO) UpdateGroupSent(tx *dbs.Tx, groupId int64) (bool, error) {
+	rows, err := this.Query(tx).
+		Pk(groupId).
+		Attr("isSent", false).
+		Set("isSent", true).
+		Update()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,28 @@
+package models
+
+// MessageRouteGroup 消息路由合并发送
+type MessageRouteGroup struct {
+	Id            uint64 `field:"id"`            // ID
+	RouteId       uint32 `field:"routeId"`       // 路由规则ID
+	GroupKey      string `field:"groupKey"`      // 合并的依据
+	MessageIds    string `field:"messageIds"`    // 消息ID
+	CountMessages uint32 `field:"countMessages"` // 消息数量
+	CreatedAt     uint64 `field:"createdAt"`     // 创建时间
+	SendAt        uint64 `field:"sendAt"`        // 发送时间
+	IsSent        uint8  `field:"isSent"`        // 是否已发送
+}
+
+type MessageRouteGroupOperator struct {
+	Id            interface{} // ID
+	RouteId       interface{} // 路由规则ID
+	GroupKey      interface{} // 合并的依据
+	MessageIds    interface{} // 消息ID
+	CountMessages interface{} // 消息数量
+	CreatedAt     interface{} // 创建时间
+	SendAt        interface{} // 发送时间
+	IsSent        interface{} // 是否已发送
+}
+
+func NewMessageRouteGroupOperator() *MessageRouteGroupOperator {
+	return &MessageRouteGroupOperator{}
+}
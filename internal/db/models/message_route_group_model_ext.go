@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/iwind/TeaGo/logs"
+)
+
+// DecodeMessageIds 解析消息ID
+func (this *MessageRouteGroup) DecodeMessageIds() []int64 {
+	if len(this.MessageIds) == 0 {
+		return []int64{}
+	}
+	result := []int64{}
+	err := json.Unmarshal([]byte(this.MessageIds), &result)
+	if err != nil {
+		logs.Println("MessageRouteGroup.DecodeMessageIds(): " + err.Error())
+		// 不阻断执行
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package models
+
+// MessageRoute 消息路由规则
+type MessageRoute struct {
+	Id                uint32 `field:"id"`                // ID
+	AdminId           uint32 `field:"adminId"`           // 管理员ID
+	Name              string `field:"name"`              // 名称
+	IsOn              uint8  `field:"isOn"`              // 是否启用
+	Order             uint32 `field:"order"`             // 排序
+	MessageTypes      string `field:"messageTypes"`      // 消息类型，为空表示所有类型
+	Levels            string `field:"levels"`            // 消息级别，为空表示所有级别
+	ClusterId         uint32 `field:"clusterId"`         // 集群ID，0表示所有集群
+	NodeId            uint32 `field:"nodeId"`            // 节点ID，0表示所有节点
+	ServerId          uint32 `field:"serverId"`          // 服务ID，0表示所有服务
+	RecipientGroupIds string `field:"recipientGroupIds"` // 接收人分组ID
+	DedupSeconds      uint32 `field:"dedupSeconds"`      // 去重时间窗口（秒）
+	GroupSeconds      uint32 `field:"groupSeconds"`      // 合并时间窗口（秒）
+	EscalateMinutes   uint32 `field:"escalateMinutes"`   // 多少分钟未处理后升级
+	EscalateGroupIds  string `field:"escalateGroupIds"`  // 升级接收人分组ID
+	IsFinal           uint8  `field:"isFinal"`           // 匹配后是否停止匹配其他规则
+	CreatedAt         uint64 `field:"createdAt"`         // 创建时间
+	State             uint8  `field:"state"`             // 状态
+}
+
+type MessageRouteOperator struct {
+	Id                interface{} // ID
+	AdminId           interface{} // 管理员ID
+	Name              interface{} // 名称
+	IsOn              interface{} // 是否启用
+	Order             interface{} // 排序
+	MessageTypes      interface{} // 消息类型，为空表示所有类型
+	Levels            interface{} // 消息级别，为空表示所有级别
+	ClusterId         interface{} // 集群ID，0表示所有集群
+	NodeId            interface{} // 节点ID，0表示所有节点
+	ServerId          interface{} // 服务ID，0表示所有服务
+	RecipientGroupIds interface{} // 接收人分组ID
+	DedupSeconds      interface{} // 去重时间窗口（秒）
+	GroupSeconds      interface{} // 合并时间窗口（秒）
+	EscalateMinutes   interface{} // 多少分钟未处理后升级
+	EscalateGroupIds  interface{} // 升级接收人分组ID
+	IsFinal           interface{} // 匹配后是否停止匹配其他规则
+	CreatedAt         interface{} // 创建时间
+	State             interface{} // 状态
+}
+
+func NewMessageRouteOperator() *MessageRouteOperator {
+	return &MessageRouteOperator{}
+}
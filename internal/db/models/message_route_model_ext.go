@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/iwind/TeaGo/lists"
+	"github.com/iwind/TeaGo/logs"
+)
+
+// DecodeMessageTypes 解析消息类型
+func (this *MessageRoute) DecodeMessageTypes() []string {
+	return this.decodeStrings("messageTypes", this.MessageTypes)
+}
+
+// DecodeLevels 解析消息级别
+func (this *MessageRoute) DecodeLevels() []string {
+	return this.decodeStrings("levels", this.Levels)
+}
+
+// DecodeRecipientGroupIds 解析接收人分组ID
+func (this *MessageRoute) DecodeRecipientGroupIds() []int64 {
+	return this.decodeInt64s("recipientGroupIds", this.RecipientGroupIds)
+}
+
+// DecodeEscalateGroupIds 解析升级接收人分组ID
+func (this *MessageRoute) DecodeEscalateGroupIds() []int64 {
+	return this.decodeInt64s("escalateGroupIds", this.EscalateGroupIds)
+}
+
+// Match 检查消息是否匹配当前规则
+func (this *MessageRoute) Match(messageType MessageType, level string, target MessageTaskTarget) bool {
+	if this.IsOn != 1 {
+		return false
+	}
+
+	var messageTypes = this.DecodeMessageTypes()
+	if len(messageTypes) > 0 && !lists.ContainsString(messageTypes, messageType) {
+		return false
+	}
+
+	var levels = this.DecodeLevels()
+	if len(levels) > 0 && !lists.ContainsString(levels, level) {
+		return false
+	}
+
+	if this.ClusterId > 0 && int64(this.ClusterId) != target.ClusterId {
+		return false
+	}
+	if this.NodeId > 0 && int64(this.NodeId) != target.NodeId {
+		return false
+	}
+	if this.ServerId > 0 && int64(this.ServerId) != target.ServerId {
+		return false
+	}
+
+	return true
+}
+
+func (this *MessageRoute) decodeStrings(field string, data string) []string {
+	var result = []string{}
+	if len(data) == 0 || data == "null" {
+		return result
+	}
+	err := json.Unmarshal([]byte(data), &result)
+	if err != nil {
+		logs.Println("MessageRoute.decode '" + field + "': " + err.Error())
+		// 不阻断执行
+	}
+	return result
+}
+
+func (this *MessageRoute) decodeInt64s(field string, data string) []int64 {
+	var result = []int64{}
+	if len(data) == 0 || data == "null" {
+		return result
+	}
+	err := json.Unmarshal([]byte(data), &result)
+	if err != nil {
+		logs.Println("MessageRoute.decode '" + field + "': " + err.Error())
+		// 不阻断执行
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+)
+
+// SettingCodeMessageSenderConfig 内置消息发送设置在系统设置中的代号
+const SettingCodeMessageSenderConfig = "messageSenderConfig"
+
+// MessageSenderConfig API节点内置消息发送设置
+type MessageSenderConfig struct {
+	IsOn              bool  `json:"isOn"`              // 是否启用，启用后邮件、WebHook、脚本等媒介由API节点直接发送，不再交给监控节点
+	MaxAttempts       int   `json:"maxAttempts"`       // 最多尝试次数
+	BackoffSeconds    int64 `json:"backoffSeconds"`    // 第一次重试的间隔（秒），之后每次翻倍
+	MaxBackoffSeconds int64 `json:"maxBackoffSeconds"` // 最大重试间隔（秒）
+	BatchSize         int64 `json:"batchSize"`         // 每次最多处理的任务数
+}
+
+// DefaultMessageSenderConfig 默认的内置消息发送设置
+// 默认不启用，以免升级后原本由监控节点发送的消息被API节点接管
+func DefaultMessageSenderConfig() *MessageSenderConfig {
+	return &MessageSenderConfig{
+		IsOn:              false,
+		MaxAttempts:       3,
+		BackoffSeconds:    60,
+		MaxBackoffSeconds: 3600,
+		BatchSize:         100,
+	}
+}
+
+// Validate 校验设置
+func (this *MessageSenderConfig) Validate() error {
+	if this.MaxAttempts <= 0 {
+		return errors.New("'maxAttempts' should be greater than 0")
+	}
+	if this.BackoffSeconds < 0 || this.MaxBackoffSeconds < 0 {
+		return errors.New("'backoffSeconds' and 'maxBackoffSeconds' should not be negative")
+	}
+	if this.BatchSize <= 0 {
+		return errors.New("'batchSize' should be greater than 0")
+	}
+	return nil
+}
+
+// BackoffSecondsWithAttempts 第N次失败后的重试间隔
+func (this *MessageSenderConfig) BackoffSecondsWithAttempts(attempts int) int64 {
+	if attempts <= 0 || this.BackoffSeconds <= 0 {
+		return 0
+	}
+	var seconds = this.BackoffSeconds
+	for i := 1; i < attempts; i++ {
+		seconds *= 2
+		if this.MaxBackoffSeconds > 0 && seconds >= this.MaxBackoffSeconds {
+			return this.MaxBackoffSeconds
+		}
+	}
+	if this.MaxBackoffSeconds > 0 && seconds > this.MaxBackoffSeconds {
+		return this.MaxBackoffSeconds
+	}
+	return seconds
+}
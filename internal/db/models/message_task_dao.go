@@ -5,6 +5,8 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/types"
+	"strings"
 	"time"
 )
 
@@ -81,18 +83,28 @@ func (this *MessageTaskDAO) CreateMessageTask(tx *dbs.Tx, recipientId int64, ins
 	op.Body = body
 	op.IsPrimary = isPrimary
 	op.Status = MessageTaskStatusNone
+	op.CreatedAt = time.Now().Unix()
 	op.State = MessageTaskStateEnabled
 	return this.SaveInt64(tx, op)
 }
 
 // FindSendingMessageTasks 查找需要发送的任务
 func (this *MessageTaskDAO) FindSendingMessageTasks(tx *dbs.Tx, size int64) (result []*MessageTask, err error) {
+	return this.FindSendingMessageTasksWithMediaTypes(tx, nil, false, size)
+}
+
+// FindSendingMessageTasksWithMediaTypes 根据媒介类型查找需要发送的任务
+// isIncluded 为true时只查找使用这些媒介的任务，为false时排除使用这些媒介的任务
+func (this *MessageTaskDAO) FindSendingMessageTasksWithMediaTypes(tx *dbs.Tx, mediaTypes []string, isIncluded bool, size int64) (result []*MessageTask, err error) {
 	if size <= 0 {
 		return nil, nil
 	}
-	_, err = this.Query(tx).
+	query := this.Query(tx).
 		State(MessageTaskStateEnabled).
 		Attr("status", MessageTaskStatusNone).
+		Lte("nextAttemptAt", time.Now().Unix())
+	this.filterMediaTypes(query, mediaTypes, isIncluded)
+	_, err = query.
 		Desc("isPrimary").
 		AscPk().
 		Limit(size).
@@ -101,6 +113,53 @@ func (this *MessageTaskDAO) FindSendingMessageTasks(tx *dbs.Tx, size int64) (res
 	return
 }
 
+// ClaimMessageTask 将任务设置为发送中，防止被重复发送
+// 返回值表示是否已成功获取任务
+func (this *MessageTaskDAO) ClaimMessageTask(tx *dbs.Tx, taskId int64) (bool, error) {
+	rows, err := this.Query(tx).
+		Pk(taskId).
+		Attr("status", MessageTaskStatusNone).
+		Set("status", MessageTaskStatusSending).
+		Set("sentAt", time.Now().Unix()).
+		Update()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// UpdateMessageTaskAttempt 记录一次发送尝试
+// status 为 MessageTaskStatusNone 时表示在 nextAttemptAt 之后重试
+func (this *MessageTaskDAO) UpdateMessageTaskAttempt(tx *dbs.Tx, taskId int64, status MessageTaskStatus, nextAttemptAt int64, result []byte) error {
+	if taskId <= 0 {
+		return errors.New("invalid taskId")
+	}
+	query := this.Query(tx).
+		Pk(taskId).
+		Set("status", status).
+		Set("sentAt", time.Now().Unix()).
+		Set("countAttempts", dbs.SQL("countAttempts+1")).
+		Set("nextAttemptAt", nextAttemptAt)
+	if len(result) > 0 {
+		query.Set("result", result)
+	}
+	_, err := query.Update()
+	return err
+}
+
+// ResetTimeoutMessageTasks 重置长时间处于发送中的任务，以便重新发送
+func (this *MessageTaskDAO) ResetTimeoutMessageTasks(tx *dbs.Tx, mediaTypes []string, timeoutSeconds int64) error {
+	query := this.Query(tx).
+		State(MessageTaskStateEnabled).
+		Attr("status", MessageTaskStatusSending).
+		Lt("sentAt", time.Now().Unix()-timeoutSeconds)
+	this.filterMediaTypes(query, mediaTypes, true)
+	_, err := query.
+		Set("status", MessageTaskStatusNone).
+		Update()
+	return err
+}
+
 // UpdateMessageTaskStatus 设置发送的状态
 func (this *MessageTaskDAO) UpdateMessageTaskStatus(tx *dbs.Tx, taskId int64, status MessageTaskStatus, result []byte) error {
 	if taskId <= 0 {
@@ -135,8 +194,26 @@ func (this *MessageTaskDAO) CreateMessageTasks(tx *dbs.Tx, target MessageTaskTar
 		}
 	}
 
+	return this.createMessageTasksWithRecipientIds(tx, allRecipientIds, subject, body)
+}
+
+// CreateMessageTasksWithGroupIds 为一组接收人分组创建任务
+func (this *MessageTaskDAO) CreateMessageTasksWithGroupIds(tx *dbs.Tx, groupIds []int64, subject string, body string) error {
+	allRecipientIds := []int64{}
+	for _, groupId := range groupIds {
+		recipientIds, err := SharedMessageRecipientDAO.FindAllEnabledAndOnRecipientIdsWithGroup(tx, groupId)
+		if err != nil {
+			return err
+		}
+		allRecipientIds = append(allRecipientIds, recipientIds...)
+	}
+	return this.createMessageTasksWithRecipientIds(tx, allRecipientIds, subject, body)
+}
+
+// 为一组接收人创建任务
+func (this *MessageTaskDAO) createMessageTasksWithRecipientIds(tx *dbs.Tx, recipientIds []int64, subject string, body string) error {
 	sentMap := map[int64]bool{} // recipientId => bool 用来检查是否已经发送，防止重复发送给某个接收人
-	for _, recipientId := range allRecipientIds {
+	for _, recipientId := range recipientIds {
 		_, ok := sentMap[recipientId]
 		if ok {
 			continue
@@ -147,6 +224,30 @@ func (this *MessageTaskDAO) CreateMessageTasks(tx *dbs.Tx, target MessageTaskTar
 			return err
 		}
 	}
-
 	return nil
 }
+
+// 根据任务使用的媒介类型进行筛选
+func (this *MessageTaskDAO) filterMediaTypes(query *dbs.Query, mediaTypes []string, isIncluded bool) {
+	if len(mediaTypes) == 0 {
+		if isIncluded {
+			query.Where("1=0")
+		}
+		return
+	}
+
+	var paramNames = []string{}
+	for index, mediaType := range mediaTypes {
+		var paramName = "mediaType" + types.String(index)
+		paramNames = append(paramNames, ":"+paramName)
+		query.Param(paramName, mediaType)
+	}
+
+	// 指定了接收人时使用接收人的媒介实例，否则使用任务中的媒介实例
+	var instanceIdSQL = "IF(recipientId>0, (SELECT instanceId FROM " + SharedMessageRecipientDAO.Table + " WHERE id=" + this.Table + ".recipientId), instanceId)"
+	var op = "IN"
+	if !isIncluded {
+		op = "NOT IN"
+	}
+	query.Where(instanceIdSQL + " " + op + " (SELECT id FROM " + SharedMessageMediaInstanceDAO.Table + " WHERE mediaType IN (" + strings.Join(paramNames, ", ") + "))")
+}
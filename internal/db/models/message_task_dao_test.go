@@ -3,4 +3,25 @@ package models
 import (
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/iwind/TeaGo/bootstrap"
+	"testing"
 )
+
+func TestMessageSenderConfig_BackoffSecondsWithAttempts(t *testing.T) {
+	var config = &MessageSenderConfig{
+		BackoffSeconds:    60,
+		MaxBackoffSeconds: 300,
+	}
+	for attempts, expected := range map[int]int64{
+		0: 0,
+		1: 60,
+		2: 120,
+		3: 240,
+		4: 300,
+		9: 300,
+	} {
+		var seconds = config.BackoffSecondsWithAttempts(attempts)
+		if seconds != expected {
+			t.Fatal("attempts:", attempts, "expected:", expected, "but got:", seconds)
+		}
+	}
+}
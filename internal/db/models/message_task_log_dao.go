@@ -4,6 +4,7 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
+	"time"
 )
 
 type MessageTaskLogDAO dbs.DAO
@@ -31,6 +32,7 @@ func init() {
 func (this *MessageTaskLogDAO) CreateLog(tx *dbs.Tx, taskId int64, isOk bool, errMsg string, response string) error {
 	op := NewMessageTaskLogOperator()
 	op.TaskId = taskId
+	op.CreatedAt = time.Now().Unix()
 	op.IsOk = isOk
 	op.Error = errMsg
 	op.Response = response
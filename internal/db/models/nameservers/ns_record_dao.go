@@ -167,6 +167,32 @@ func (this *NSRecordDAO) UpdateRecord(tx *dbs.Tx, recordId int64, description st
 	return this.NotifyUpdate(tx, recordId)
 }
 
+// UpdateRecordWeight 修改记录权重
+func (this *NSRecordDAO) UpdateRecordWeight(tx *dbs.Tx, recordId int64, weight int32) error {
+	if recordId <= 0 {
+		return errors.New("invalid recordId")
+	}
+	if weight < 0 {
+		weight = 0
+	}
+
+	version, err := this.IncreaseVersion(tx)
+	if err != nil {
+		return err
+	}
+
+	op := NewNSRecordOperator()
+	op.Id = recordId
+	op.Weight = weight
+	op.Version = version
+	err = this.Save(tx, op)
+	if err != nil {
+		return err
+	}
+
+	return this.NotifyUpdate(tx, recordId)
+}
+
 // CountAllEnabledDomainRecords 计算域名中记录数量
 func (this *NSRecordDAO) CountAllEnabledDomainRecords(tx *dbs.Tx, domainId int64, dnsType dnsconfigs.RecordType, keyword string, routeCode string) (int64, error) {
 	query := this.Query(tx).
@@ -2,34 +2,15 @@ package nameservers
 
 import (
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
-	"github.com/TeaOSLab/EdgeAPI/internal/remotelogs"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/maps"
-	"github.com/iwind/TeaGo/rands"
-	timeutil "github.com/iwind/TeaGo/utils/time"
-	"time"
 )
 
 type NSRecordHourlyStatDAO dbs.DAO
 
-func init() {
-	dbs.OnReadyDone(func() {
-		// 清理数据任务
-		var ticker = time.NewTicker(time.Duration(rands.Int(24, 48)) * time.Hour)
-		go func() {
-			for range ticker.C {
-				err := SharedNSRecordHourlyStatDAO.Clean(nil, 60) // 只保留60天
-				if err != nil {
-					remotelogs.Error("NodeClusterTrafficDailyStatDAO", "clean expired data failed: "+err.Error())
-				}
-			}
-		}()
-	})
-}
-
 func NewNSRecordHourlyStatDAO() *NSRecordHourlyStatDAO {
 	return dbs.NewDAO(&NSRecordHourlyStatDAO{
 		DAOObject: dbs.DAOObject{
@@ -157,12 +138,3 @@ func (this *NSRecordHourlyStatDAO) ListTopDomains(tx *dbs.Tx, hourFrom string, h
 		FindAll()
 	return
 }
-
-// Clean 清理历史数据
-func (this *NSRecordHourlyStatDAO) Clean(tx *dbs.Tx, days int) error {
-	var hour = timeutil.Format("Ymd00", time.Now().AddDate(0, 0, -days))
-	_, err := this.Query(tx).
-		Lt("hour", hour).
-		Delete()
-	return err
-}
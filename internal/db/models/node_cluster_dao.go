@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models/dns"
+	"github.com/TeaOSLab/EdgeAPI/internal/healthchecks"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils/numberutils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/dnsconfigs"
 	"github.com/TeaOSLab/EdgeCommon/pkg/nodeconfigs"
@@ -59,7 +60,12 @@ func (this *NodeClusterDAO) DisableNodeCluster(tx *dbs.Tx, id int64) error {
 		Pk(id).
 		Set("state", NodeClusterStateDisabled).
 		Update()
-	return err
+	if err != nil {
+		return err
+	}
+
+	// 结束未结束的健康检查故障
+	return SharedNodeHealthCheckIncidentDAO.CloseClusterIncidents(tx, id)
 }
 
 // FindEnabledNodeCluster 查找集群
@@ -295,6 +301,21 @@ func (this *NodeClusterDAO) FindClusterHealthCheckConfig(tx *dbs.Tx, clusterId i
 	return config, nil
 }
 
+// FindClusterHealthCheckProbeConfig 查找健康检查的探测方式
+func (this *NodeClusterDAO) FindClusterHealthCheckProbeConfig(tx *dbs.Tx, clusterId int64) (*healthchecks.ProbeConfig, error) {
+	col, err := this.Query(tx).
+		Pk(clusterId).
+		Result("healthCheck").
+		FindStringCol("")
+	if err != nil {
+		return nil, err
+	}
+	if len(col) == 0 || col == "null" {
+		return healthchecks.DefaultProbeConfig(), nil
+	}
+	return healthchecks.DecodeProbeConfig([]byte(col))
+}
+
 // UpdateClusterHealthCheck 修改健康检查设置
 func (this *NodeClusterDAO) UpdateClusterHealthCheck(tx *dbs.Tx, clusterId int64, healthCheckJSON []byte) error {
 	if clusterId <= 0 {
@@ -307,6 +328,22 @@ func (this *NodeClusterDAO) UpdateClusterHealthCheck(tx *dbs.Tx, clusterId int64
 	if err != nil {
 		return err
 	}
+
+	// 关闭健康检查后结束未结束的故障
+	var healthCheckConfig = &serverconfigs.HealthCheckConfig{}
+	if len(healthCheckJSON) > 0 {
+		err = json.Unmarshal(healthCheckJSON, healthCheckConfig)
+		if err != nil {
+			return err
+		}
+	}
+	if !healthCheckConfig.IsOn {
+		err = SharedNodeHealthCheckIncidentDAO.CloseClusterIncidents(tx, clusterId)
+		if err != nil {
+			return err
+		}
+	}
+
 	return this.NotifyUpdate(tx, clusterId)
 }
 
@@ -409,7 +446,7 @@ func (this *NodeClusterDAO) FindClusterGrantId(tx *dbs.Tx, clusterId int64) (int
 func (this *NodeClusterDAO) FindClusterDNSInfo(tx *dbs.Tx, clusterId int64) (*NodeCluster, error) {
 	one, err := this.Query(tx).
 		Pk(clusterId).
-		Result("id", "name", "dnsName", "dnsDomainId", "dns", "isOn").
+		Result("id", "name", "dnsName", "dnsDomainId", "dns", "isOn", "state").
 		Find()
 	if err != nil {
 		return nil, err
@@ -431,7 +468,7 @@ func (this *NodeClusterDAO) ExistClusterDNSName(tx *dbs.Tx, dnsName string, excl
 }
 
 // UpdateClusterDNS 修改集群DNS相关信息
-func (this *NodeClusterDAO) UpdateClusterDNS(tx *dbs.Tx, clusterId int64, dnsName string, dnsDomainId int64, nodesAutoSync bool, serversAutoSync bool) error {
+func (this *NodeClusterDAO) UpdateClusterDNS(tx *dbs.Tx, clusterId int64, dnsName string, dnsDomainId int64, nodesAutoSync bool, serversAutoSync bool, ttl int32) error {
 	if clusterId <= 0 {
 		return errors.New("invalid clusterId")
 	}
@@ -440,9 +477,24 @@ func (this *NodeClusterDAO) UpdateClusterDNS(tx *dbs.Tx, clusterId int64, dnsNam
 	op.DnsName = dnsName
 	op.DnsDomainId = dnsDomainId
 
-	dnsConfig := &dnsconfigs.ClusterDNSConfig{
-		NodesAutoSync:   nodesAutoSync,
-		ServersAutoSync: serversAutoSync,
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	// 保留权重和故障切换设置
+	oldDNSConfig, err := this.findClusterDNSConfig(tx, clusterId)
+	if err != nil {
+		return err
+	}
+
+	dnsConfig := &NodeClusterDNSConfig{
+		ClusterDNSConfig: &dnsconfigs.ClusterDNSConfig{
+			NodesAutoSync:   nodesAutoSync,
+			ServersAutoSync: serversAutoSync,
+		},
+		TTL:      ttl,
+		Weights:  oldDNSConfig.Weights,
+		Failover: oldDNSConfig.Failover,
 	}
 	dnsJSON, err := json.Marshal(dnsConfig)
 	if err != nil {
@@ -454,6 +506,13 @@ func (this *NodeClusterDAO) UpdateClusterDNS(tx *dbs.Tx, clusterId int64, dnsNam
 	if err != nil {
 		return err
 	}
+
+	// 同步主域名绑定
+	err = dns.SharedDNSClusterBindingDAO.UpdatePrimaryDomain(tx, clusterId, dnsDomainId)
+	if err != nil {
+		return err
+	}
+
 	err = this.NotifyUpdate(tx, clusterId)
 	if err != nil {
 		return err
@@ -461,6 +520,86 @@ func (this *NodeClusterDAO) UpdateClusterDNS(tx *dbs.Tx, clusterId int64, dnsNam
 	return this.NotifyDNSUpdate(tx, clusterId)
 }
 
+// UpdateClusterDNSFailover 修改集群的DNS故障切换设置
+func (this *NodeClusterDAO) UpdateClusterDNSFailover(tx *dbs.Tx, clusterId int64, failoverConfig *NodeClusterDNSFailoverConfig) error {
+	if failoverConfig == nil {
+		failoverConfig = DefaultNodeClusterDNSFailoverConfig()
+	}
+	return this.updateClusterDNSConfig(tx, clusterId, func(dnsConfig *NodeClusterDNSConfig) {
+		dnsConfig.Failover = failoverConfig
+	})
+}
+
+// UpdateClusterDNSWeights 修改集群节点记录的权重设置
+func (this *NodeClusterDAO) UpdateClusterDNSWeights(tx *dbs.Tx, clusterId int64, weightConfig *NodeClusterDNSWeightConfig) error {
+	if weightConfig != nil {
+		err := weightConfig.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	return this.updateClusterDNSConfig(tx, clusterId, func(dnsConfig *NodeClusterDNSConfig) {
+		dnsConfig.Weights = weightConfig
+	})
+}
+
+// 修改集群DNS配置中的部分设置
+func (this *NodeClusterDAO) updateClusterDNSConfig(tx *dbs.Tx, clusterId int64, update func(dnsConfig *NodeClusterDNSConfig)) error {
+	if clusterId <= 0 {
+		return errors.New("invalid clusterId")
+	}
+
+	cluster, err := this.FindClusterDNSInfo(tx, clusterId)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return nil
+	}
+
+	var dnsConfig = &NodeClusterDNSConfig{}
+	if len(cluster.Dns) > 0 && cluster.Dns != "null" {
+		err = json.Unmarshal([]byte(cluster.Dns), dnsConfig)
+		if err != nil {
+			return err
+		}
+	}
+	if dnsConfig.ClusterDNSConfig == nil {
+		dnsConfig.ClusterDNSConfig = &dnsconfigs.ClusterDNSConfig{}
+	}
+	update(dnsConfig)
+
+	dnsJSON, err := json.Marshal(dnsConfig)
+	if err != nil {
+		return err
+	}
+	_, err = this.Query(tx).
+		Pk(clusterId).
+		Set("dns", dnsJSON).
+		Update()
+	if err != nil {
+		return err
+	}
+	return this.NotifyDNSUpdate(tx, clusterId)
+}
+
+// 查找集群已有的DNS配置，找不到或者解析失败时返回空配置
+func (this *NodeClusterDAO) findClusterDNSConfig(tx *dbs.Tx, clusterId int64) (*NodeClusterDNSConfig, error) {
+	var dnsConfig = &NodeClusterDNSConfig{}
+	cluster, err := this.FindClusterDNSInfo(tx, clusterId)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil || len(cluster.Dns) == 0 || cluster.Dns == "null" {
+		return dnsConfig, nil
+	}
+	err = json.Unmarshal([]byte(cluster.Dns), dnsConfig)
+	if err != nil {
+		return &NodeClusterDNSConfig{}, nil
+	}
+	return dnsConfig, nil
+}
+
 // CheckClusterDNS 检查集群的DNS问题
 func (this *NodeClusterDAO) CheckClusterDNS(tx *dbs.Tx, cluster *NodeCluster) (issues []*pb.DNSIssue, err error) {
 	clusterId := int64(cluster.Id)
@@ -2,9 +2,94 @@ package models
 
 import (
 	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/healthchecks"
 	"github.com/TeaOSLab/EdgeCommon/pkg/dnsconfigs"
+	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs"
+	"github.com/iwind/TeaGo/types"
 )
 
+// NodeClusterHealthCheckConfig 集群健康检查配置
+// 在通用的健康检查配置基础上增加探测方式
+type NodeClusterHealthCheckConfig struct {
+	*serverconfigs.HealthCheckConfig
+
+	Probe *healthchecks.ProbeConfig `json:"probe"` // 探测方式
+}
+
+// NodeClusterDNSConfig 集群DNS配置
+// 在通用的集群DNS配置基础上增加记录TTL、权重和故障切换设置
+type NodeClusterDNSConfig struct {
+	*dnsconfigs.ClusterDNSConfig
+
+	TTL      int32                         `json:"ttl"`                // 记录TTL（秒），0表示使用DNS服务商的默认值
+	Weights  *NodeClusterDNSWeightConfig   `json:"weights,omitempty"`  // 节点记录权重
+	Failover *NodeClusterDNSFailoverConfig `json:"failover,omitempty"` // 故障切换设置
+}
+
+// NodeClusterDNSWeightConfig 节点记录的负载均衡权重
+// 优先使用节点的权重，其次使用线路的权重，最后使用默认权重；0表示不设置
+type NodeClusterDNSWeightConfig struct {
+	Default int32            `json:"default"` // 默认权重
+	Nodes   map[int64]int32  `json:"nodes"`   // 节点ID => 权重
+	Routes  map[string]int32 `json:"routes"`  // 线路代号 => 权重
+}
+
+// Weight 计算某个节点在某个线路上的记录权重
+func (this *NodeClusterDNSWeightConfig) Weight(nodeId int64, route string) int32 {
+	if this == nil {
+		return 0
+	}
+	weight, ok := this.Nodes[nodeId]
+	if ok && weight > 0 {
+		return weight
+	}
+	weight, ok = this.Routes[route]
+	if ok && weight > 0 {
+		return weight
+	}
+	if this.Default > 0 {
+		return this.Default
+	}
+	return 0
+}
+
+// Validate 校验设置
+func (this *NodeClusterDNSWeightConfig) Validate() error {
+	if this.Default < 0 {
+		return errors.New("'default' should not be negative")
+	}
+	for nodeId, weight := range this.Nodes {
+		if weight < 0 {
+			return errors.New("weight of node '" + types.String(nodeId) + "' should not be negative")
+		}
+	}
+	for route, weight := range this.Routes {
+		if weight < 0 {
+			return errors.New("weight of route '" + route + "' should not be negative")
+		}
+	}
+	return nil
+}
+
+// NodeClusterDNSFailoverConfig 根据健康检查结果自动摘除和恢复节点的DNS记录
+type NodeClusterDNSFailoverConfig struct {
+	IsOn              bool `json:"isOn"`              // 是否启用
+	MinHealthyRecords int  `json:"minHealthyRecords"` // 每个线路最少保留的记录数量，达到此数量后不再摘除记录
+	FlapWindow        int  `json:"flapWindow"`        // 抖动检测时间窗口（秒）
+	MaxFlaps          int  `json:"maxFlaps"`          // 时间窗口内最多切换次数，超出后暂缓恢复记录
+}
+
+// DefaultNodeClusterDNSFailoverConfig 默认的故障切换设置
+func DefaultNodeClusterDNSFailoverConfig() *NodeClusterDNSFailoverConfig {
+	return &NodeClusterDNSFailoverConfig{
+		IsOn:              false,
+		MinHealthyRecords: 1,
+		FlapWindow:        600,
+		MaxFlaps:          3,
+	}
+}
+
 // 解析DNS配置
 func (this *NodeCluster) DecodeDNSConfig() (*dnsconfigs.ClusterDNSConfig, error) {
 	if len(this.Dns) == 0 || this.Dns == "null" {
@@ -21,3 +106,53 @@ func (this *NodeCluster) DecodeDNSConfig() (*dnsconfigs.ClusterDNSConfig, error)
 	}
 	return dnsConfig, nil
 }
+
+// DecodeDNSTTL 解析DNS记录的TTL，0表示使用DNS服务商的默认值
+func (this *NodeCluster) DecodeDNSTTL() int32 {
+	if len(this.Dns) == 0 || this.Dns == "null" {
+		return 0
+	}
+	var dnsConfig = &NodeClusterDNSConfig{}
+	err := json.Unmarshal([]byte(this.Dns), dnsConfig)
+	if err != nil || dnsConfig.TTL < 0 {
+		return 0
+	}
+	return dnsConfig.TTL
+}
+
+// DecodeDNSWeightConfig 解析节点记录权重设置
+func (this *NodeCluster) DecodeDNSWeightConfig() *NodeClusterDNSWeightConfig {
+	if len(this.Dns) == 0 || this.Dns == "null" {
+		return &NodeClusterDNSWeightConfig{}
+	}
+	var dnsConfig = &NodeClusterDNSConfig{}
+	err := json.Unmarshal([]byte(this.Dns), dnsConfig)
+	if err != nil || dnsConfig.Weights == nil {
+		return &NodeClusterDNSWeightConfig{}
+	}
+	return dnsConfig.Weights
+}
+
+// DecodeDNSFailoverConfig 解析DNS故障切换设置
+func (this *NodeCluster) DecodeDNSFailoverConfig() *NodeClusterDNSFailoverConfig {
+	var config = DefaultNodeClusterDNSFailoverConfig()
+	if len(this.Dns) == 0 || this.Dns == "null" {
+		return config
+	}
+	var dnsConfig = &NodeClusterDNSConfig{}
+	err := json.Unmarshal([]byte(this.Dns), dnsConfig)
+	if err != nil || dnsConfig.Failover == nil {
+		return config
+	}
+	config = dnsConfig.Failover
+	if config.MinHealthyRecords < 0 {
+		config.MinHealthyRecords = 0
+	}
+	if config.FlapWindow <= 0 {
+		config.FlapWindow = 600
+	}
+	if config.MaxFlaps <= 0 {
+		config.MaxFlaps = 3
+	}
+	return config
+}
@@ -92,6 +92,12 @@ func (this *NodeDAO) DisableNode(tx *dbs.Tx, nodeId int64) (err error) {
 		return err
 	}
 
+	// 结束未结束的健康检查故障
+	err = SharedNodeHealthCheckIncidentDAO.CloseNodeIncidents(tx, nodeId)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -205,6 +211,14 @@ func (this *NodeDAO) UpdateNode(tx *dbs.Tx, nodeId int64, name string, clusterId
 		return err
 	}
 
+	// 停用的节点不再做健康检查，需要结束未结束的故障
+	if !isOn {
+		err = SharedNodeHealthCheckIncidentDAO.CloseNodeIncidents(tx, nodeId)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = this.NotifyUpdate(tx, nodeId)
 	if err != nil {
 		return err
@@ -442,6 +456,36 @@ func (this *NodeDAO) FindAllInactiveNodesWithClusterId(tx *dbs.Tx, clusterId int
 	return
 }
 
+// FindAllRecoveredNodesWithClusterId 取得一个集群中已经标记为离线但重新上报了状态的节点
+func (this *NodeDAO) FindAllRecoveredNodesWithClusterId(tx *dbs.Tx, clusterId int64) (result []*Node, err error) {
+	_, err = this.Query(tx).
+		State(NodeStateEnabled).
+		Attr("clusterId", clusterId).
+		Attr("isOn", true).
+		Attr("isInstalled", true).
+		Attr("isActive", false). // 当前已经标记为离线的
+		Where("(JSON_EXTRACT(status, '$.isActive') AND UNIX_TIMESTAMP()-JSON_EXTRACT(status, '$.updatedAt')<=60)").
+		Result("id", "name").
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// FindAllActiveNodesWithClusterId 取得一个集群中所有在线的节点
+func (this *NodeDAO) FindAllActiveNodesWithClusterId(tx *dbs.Tx, clusterId int64) (result []*Node, err error) {
+	_, err = this.Query(tx).
+		State(NodeStateEnabled).
+		Attr("clusterId", clusterId).
+		Attr("isOn", true).
+		Attr("isInstalled", true).
+		Attr("isActive", true).
+		Result("id", "name").
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
 // CountAllEnabledNodesMatch 计算节点数量
 func (this *NodeDAO) CountAllEnabledNodesMatch(tx *dbs.Tx,
 	clusterId int64,
@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/encrypt"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
@@ -60,7 +61,12 @@ func (this *NodeGrantDAO) FindEnabledNodeGrant(tx *dbs.Tx, id int64) (*NodeGrant
 	if result == nil {
 		return nil, err
 	}
-	return result.(*NodeGrant), err
+	grant := result.(*NodeGrant)
+	err = this.decodeGrant(grant)
+	if err != nil {
+		return nil, err
+	}
+	return grant, nil
 }
 
 // FindNodeGrantName 根据主键查找名称
@@ -82,11 +88,19 @@ func (this *NodeGrantDAO) CreateGrant(tx *dbs.Tx, adminId int64, name string, me
 	switch method {
 	case "user":
 		op.Username = username
-		op.Password = password
+		encryptedPassword, err := encrypt.EncryptSecret([]byte(password))
+		if err != nil {
+			return 0, err
+		}
+		op.Password = encryptedPassword
 		op.Su = false // TODO 需要做到前端可以配置
 	case "privateKey":
 		op.Username = username
-		op.PrivateKey = privateKey
+		encryptedPrivateKey, err := encrypt.EncryptSecret([]byte(privateKey))
+		if err != nil {
+			return 0, err
+		}
+		op.PrivateKey = encryptedPrivateKey
 	}
 	op.Description = description
 	op.NodeId = nodeId
@@ -109,11 +123,19 @@ func (this *NodeGrantDAO) UpdateGrant(tx *dbs.Tx, grantId int64, name string, me
 	switch method {
 	case "user":
 		op.Username = username
-		op.Password = password
+		encryptedPassword, err := encrypt.EncryptSecret([]byte(password))
+		if err != nil {
+			return err
+		}
+		op.Password = encryptedPassword
 		op.Su = false // TODO 需要做到前端可以配置
 	case "privateKey":
 		op.Username = username
-		op.PrivateKey = privateKey
+		encryptedPrivateKey, err := encrypt.EncryptSecret([]byte(privateKey))
+		if err != nil {
+			return err
+		}
+		op.PrivateKey = encryptedPrivateKey
 	}
 	op.Description = description
 	op.NodeId = nodeId
@@ -146,6 +168,10 @@ func (this *NodeGrantDAO) ListEnabledGrants(tx *dbs.Tx, keyword string, offset i
 		DescPk().
 		Slice(&result).
 		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	err = this.decodeGrants(result)
 	return
 }
 
@@ -156,5 +182,36 @@ func (this *NodeGrantDAO) FindAllEnabledGrants(tx *dbs.Tx) (result []*NodeGrant,
 		DescPk().
 		Slice(&result).
 		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	err = this.decodeGrants(result)
 	return
 }
+
+// 解密认证信息中的敏感数据
+func (this *NodeGrantDAO) decodeGrant(grant *NodeGrant) error {
+	password, err := encrypt.DecryptSecret([]byte(grant.Password))
+	if err != nil {
+		return errors.New("decrypt password of grant '" + types.String(grant.Id) + "' failed: " + err.Error())
+	}
+	grant.Password = string(password)
+
+	privateKey, err := encrypt.DecryptSecret([]byte(grant.PrivateKey))
+	if err != nil {
+		return errors.New("decrypt private key of grant '" + types.String(grant.Id) + "' failed: " + err.Error())
+	}
+	grant.PrivateKey = string(privateKey)
+	return nil
+}
+
+// 解密多个认证信息中的敏感数据
+func (this *NodeGrantDAO) decodeGrants(grants []*NodeGrant) error {
+	for _, grant := range grants {
+		err := this.decodeGrant(grant)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/maps"
+	"github.com/iwind/TeaGo/types"
+)
+
+type NodeHealthCheckHourlyStatDAO dbs.DAO
+
+func NewNodeHealthCheckHourlyStatDAO() *NodeHealthCheckHourlyStatDAO {
+	return dbs.NewDAO(&NodeHealthCheckHourlyStatDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeNodeHealthCheckHourlyStats",
+			Model:  new(NodeHealthCheckHourlyStat),
+			PkName: "id",
+		},
+	}).(*NodeHealthCheckHourlyStatDAO)
+}
+
+var SharedNodeHealthCheckHourlyStatDAO *NodeHealthCheckHourlyStatDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedNodeHealthCheckHourlyStatDAO = NewNodeHealthCheckHourlyStatDAO()
+	})
+}
+
+// IncreaseStat 记录一次检查结果
+func (this *NodeHealthCheckHourlyStatDAO) IncreaseStat(tx *dbs.Tx, clusterId int64, nodeId int64, hour string, isOk bool, costMs float64) error {
+	if len(hour) != 10 {
+		return errors.New("invalid hour '" + hour + "'")
+	}
+	if costMs < 0 {
+		costMs = 0
+	}
+
+	var bucket = FindNodeHealthCheckCostBucket(costMs)
+	var buckets = make([]int64, len(NodeHealthCheckCostBuckets)+1)
+	buckets[bucket] = 1
+	bucketsJSON, err := json.Marshal(buckets)
+	if err != nil {
+		return err
+	}
+	var bucketPath = "'$[" + types.String(bucket) + "]'"
+
+	var countFailed = 0
+	if !isOk {
+		countFailed = 1
+	}
+
+	return this.Query(tx).
+		Param("countFailed", countFailed).
+		Param("costMs", costMs).
+		Param("maxCostMs", costMs).
+		InsertOrUpdateQuickly(maps.Map{
+			"clusterId":   clusterId,
+			"nodeId":      nodeId,
+			"hour":        hour,
+			"countChecks": 1,
+			"countFailed": countFailed,
+			"totalCostMs": costMs,
+			"maxCostMs":   costMs,
+			"costBuckets": bucketsJSON,
+		}, maps.Map{
+			"countChecks": dbs.SQL("countChecks+1"),
+			"countFailed": dbs.SQL("countFailed+:countFailed"),
+			"totalCostMs": dbs.SQL("totalCostMs+:costMs"),
+			"maxCostMs":   dbs.SQL("GREATEST(maxCostMs, :maxCostMs)"),
+			"costBuckets": dbs.SQL("JSON_SET(costBuckets, " + bucketPath + ", JSON_EXTRACT(costBuckets, " + bucketPath + ")+1)"),
+		})
+}
+
+// SumNodeStats 汇总节点一段时间内的统计
+func (this *NodeHealthCheckHourlyStatDAO) SumNodeStats(tx *dbs.Tx, nodeId int64, hourFrom string, hourTo string) (*NodeHealthCheckSummary, error) {
+	var summary = NewNodeHealthCheckSummary()
+	ones, err := this.Query(tx).
+		Attr("nodeId", nodeId).
+		Between("hour", hourFrom, hourTo).
+		Result("countChecks", "countFailed", "totalCostMs", "maxCostMs", "costBuckets").
+		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, one := range ones {
+		summary.Add(one.(*NodeHealthCheckHourlyStat))
+	}
+	return summary, nil
+}
+
+// SumClusterNodeStats 汇总集群中每个节点一段时间内的统计
+func (this *NodeHealthCheckHourlyStatDAO) SumClusterNodeStats(tx *dbs.Tx, clusterId int64, hourFrom string, hourTo string) (map[int64]*NodeHealthCheckSummary, error) {
+	var result = map[int64]*NodeHealthCheckSummary{} // nodeId => summary
+	ones, err := this.Query(tx).
+		Attr("clusterId", clusterId).
+		Between("hour", hourFrom, hourTo).
+		Result("nodeId", "countChecks", "countFailed", "totalCostMs", "maxCostMs", "costBuckets").
+		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, one := range ones {
+		var stat = one.(*NodeHealthCheckHourlyStat)
+		var nodeId = int64(stat.NodeId)
+		summary, ok := result[nodeId]
+		if !ok {
+			summary = NewNodeHealthCheckSummary()
+			result[nodeId] = summary
+		}
+		summary.Add(stat)
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/iwind/TeaGo/bootstrap"
+	"github.com/iwind/TeaGo/dbs"
+	timeutil "github.com/iwind/TeaGo/utils/time"
+	"testing"
+)
+
+func TestNodeHealthCheckHourlyStatDAO_IncreaseStat(t *testing.T) {
+	dbs.NotifyReady()
+
+	var tx *dbs.Tx
+	var hour = timeutil.Format("YmdH")
+	err := SharedNodeHealthCheckHourlyStatDAO.IncreaseStat(tx, 1, 1, hour, true, 12.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SharedNodeHealthCheckHourlyStatDAO.IncreaseStat(tx, 1, 1, hour, false, 3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := SharedNodeHealthCheckHourlyStatDAO.SumNodeStats(tx, 1, hour, hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", summary)
+	t.Log("uptime:", summary.UptimePercent(), "p95:", summary.PercentileCostMs(95))
+}
+
+func TestNodeHealthCheckSummary(t *testing.T) {
+	var summary = NewNodeHealthCheckSummary()
+	if summary.UptimePercent() != 100 {
+		t.Fatal("uptime should be 100 without any checks")
+	}
+
+	var buckets = make([]int64, len(NodeHealthCheckCostBuckets)+1)
+	buckets[FindNodeHealthCheckCostBucket(3)] = 90
+	buckets[FindNodeHealthCheckCostBucket(80)] = 8
+	buckets[FindNodeHealthCheckCostBucket(20000)] = 2
+	summary.CountChecks = 100
+	summary.CountFailed = 2
+	summary.TotalCostMs = 41000
+	summary.MaxCostMs = 20000
+	summary.CostBuckets = buckets
+
+	if summary.UptimePercent() != 98 {
+		t.Fatal("expect uptime 98, but got", summary.UptimePercent())
+	}
+	if summary.AvgCostMs() != 410 {
+		t.Fatal("expect avg 410, but got", summary.AvgCostMs())
+	}
+	if summary.PercentileCostMs(50) != 5 {
+		t.Fatal("expect p50 5, but got", summary.PercentileCostMs(50))
+	}
+	if summary.PercentileCostMs(95) != 100 {
+		t.Fatal("expect p95 100, but got", summary.PercentileCostMs(95))
+	}
+	if summary.PercentileCostMs(99) != 20000 {
+		t.Fatal("expect p99 20000, but got", summary.PercentileCostMs(99))
+	}
+
+	var total = NewNodeHealthCheckSummary()
+	total.Merge(summary)
+	total.Merge(summary)
+	if total.CountChecks != 200 || total.CostBuckets[0] != 180 || total.MaxCostMs != 20000 {
+		t.Fatalf("merge failed: %+v", total)
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+// NodeHealthCheckHourlyStat 节点健康检查小时统计
+type NodeHealthCheckHourlyStat struct {
+	Id          uint64  `field:"id"`          // ID
+	ClusterId   uint32  `field:"clusterId"`   // 集群ID
+	NodeId      uint32  `field:"nodeId"`      // 节点ID
+	Hour        string  `field:"hour"`        // YYYYMMDDHH
+	CountChecks uint32  `field:"countChecks"` // 检查次数
+	CountFailed uint32  `field:"countFailed"` // 失败次数
+	TotalCostMs float64 `field:"totalCostMs"` // 总耗时（毫秒）
+	MaxCostMs   float64 `field:"maxCostMs"`   // 最大耗时（毫秒）
+	CostBuckets string  `field:"costBuckets"` // 耗时分布
+}
+
+type NodeHealthCheckHourlyStatOperator struct {
+	Id          interface{} // ID
+	ClusterId   interface{} // 集群ID
+	NodeId      interface{} // 节点ID
+	Hour        interface{} // YYYYMMDDHH
+	CountChecks interface{} // 检查次数
+	CountFailed interface{} // 失败次数
+	TotalCostMs interface{} // 总耗时（毫秒）
+	MaxCostMs   interface{} // 最大耗时（毫秒）
+	CostBuckets interface{} // 耗时分布
+}
+
+func NewNodeHealthCheckHourlyStatOperator() *NodeHealthCheckHourlyStatOperator {
+	return &NodeHealthCheckHourlyStatOperator{}
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// NodeHealthCheckCostBuckets 耗时分布区间的上限（毫秒），超出最后一个区间的放在额外的一个区间中
+var NodeHealthCheckCostBuckets = []float64{5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
+// FindNodeHealthCheckCostBucket 查找耗时所在区间
+func FindNodeHealthCheckCostBucket(costMs float64) int {
+	for index, bound := range NodeHealthCheckCostBuckets {
+		if costMs <= bound {
+			return index
+		}
+	}
+	return len(NodeHealthCheckCostBuckets)
+}
+
+// DecodeCostBuckets 解析耗时分布
+func (this *NodeHealthCheckHourlyStat) DecodeCostBuckets() []int64 {
+	var buckets = make([]int64, len(NodeHealthCheckCostBuckets)+1)
+	if len(this.CostBuckets) == 0 || this.CostBuckets == "null" {
+		return buckets
+	}
+	var values = []int64{}
+	err := json.Unmarshal([]byte(this.CostBuckets), &values)
+	if err != nil {
+		return buckets
+	}
+	for index, value := range values {
+		if index < len(buckets) {
+			buckets[index] = value
+		}
+	}
+	return buckets
+}
+
+// NodeHealthCheckSummary 一段时间内的健康检查汇总
+type NodeHealthCheckSummary struct {
+	CountChecks int64
+	CountFailed int64
+	TotalCostMs float64
+	MaxCostMs   float64
+	CostBuckets []int64
+}
+
+// NewNodeHealthCheckSummary 获取新汇总对象
+func NewNodeHealthCheckSummary() *NodeHealthCheckSummary {
+	return &NodeHealthCheckSummary{
+		CostBuckets: make([]int64, len(NodeHealthCheckCostBuckets)+1),
+	}
+}
+
+// Add 增加小时统计
+func (this *NodeHealthCheckSummary) Add(stat *NodeHealthCheckHourlyStat) {
+	this.CountChecks += int64(stat.CountChecks)
+	this.CountFailed += int64(stat.CountFailed)
+	this.TotalCostMs += stat.TotalCostMs
+	if stat.MaxCostMs > this.MaxCostMs {
+		this.MaxCostMs = stat.MaxCostMs
+	}
+	for index, count := range stat.DecodeCostBuckets() {
+		this.CostBuckets[index] += count
+	}
+}
+
+// Merge 合并另外一个汇总
+func (this *NodeHealthCheckSummary) Merge(summary *NodeHealthCheckSummary) {
+	this.CountChecks += summary.CountChecks
+	this.CountFailed += summary.CountFailed
+	this.TotalCostMs += summary.TotalCostMs
+	if summary.MaxCostMs > this.MaxCostMs {
+		this.MaxCostMs = summary.MaxCostMs
+	}
+	for index, count := range summary.CostBuckets {
+		if index < len(this.CostBuckets) {
+			this.CostBuckets[index] += count
+		}
+	}
+}
+
+// UptimePercent 检查成功的百分比，没有检查数据时返回100
+func (this *NodeHealthCheckSummary) UptimePercent() float64 {
+	if this.CountChecks == 0 {
+		return 100
+	}
+	return float64(this.CountChecks-this.CountFailed) * 100 / float64(this.CountChecks)
+}
+
+// AvgCostMs 平均耗时
+func (this *NodeHealthCheckSummary) AvgCostMs() float64 {
+	if this.CountChecks == 0 {
+		return 0
+	}
+	return this.TotalCostMs / float64(this.CountChecks)
+}
+
+// PercentileCostMs 耗时百分位数，percent 取值为0-100
+// 返回值为所在区间的上限，最后一个区间使用最大耗时
+func (this *NodeHealthCheckSummary) PercentileCostMs(percent float64) float64 {
+	var total int64
+	for _, count := range this.CostBuckets {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var rank = int64(math.Ceil(float64(total) * percent / 100))
+	if rank < 1 {
+		rank = 1
+	}
+	var sum int64
+	for index, count := range this.CostBuckets {
+		sum += count
+		if sum >= rank {
+			if index >= len(NodeHealthCheckCostBuckets) {
+				return this.MaxCostMs
+			}
+			return math.Min(NodeHealthCheckCostBuckets[index], this.MaxCostMs)
+		}
+	}
+	return this.MaxCostMs
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"time"
+)
+
+type NodeHealthCheckIncidentDAO dbs.DAO
+
+func NewNodeHealthCheckIncidentDAO() *NodeHealthCheckIncidentDAO {
+	return dbs.NewDAO(&NodeHealthCheckIncidentDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeNodeHealthCheckIncidents",
+			Model:  new(NodeHealthCheckIncident),
+			PkName: "id",
+		},
+	}).(*NodeHealthCheckIncidentDAO)
+}
+
+var SharedNodeHealthCheckIncidentDAO *NodeHealthCheckIncidentDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedNodeHealthCheckIncidentDAO = NewNodeHealthCheckIncidentDAO()
+	})
+}
+
+// UpdateIncident 根据检查结果开始或结束故障
+// 失败时如果没有未结束的故障则创建新故障，成功时结束未结束的故障
+func (this *NodeHealthCheckIncidentDAO) UpdateIncident(tx *dbs.Tx, clusterId int64, nodeId int64, isOk bool, errString string, checkedAt int64) error {
+	incidentId, err := this.Query(tx).
+		Attr("nodeId", nodeId).
+		Attr("endedAt", 0).
+		ResultPk().
+		DescPk().
+		FindInt64Col(0)
+	if err != nil {
+		return err
+	}
+
+	if isOk {
+		if incidentId == 0 {
+			return nil
+		}
+		_, err = this.Query(tx).
+			Pk(incidentId).
+			Set("endedAt", checkedAt).
+			Update()
+		return err
+	}
+
+	if incidentId > 0 {
+		_, err = this.Query(tx).
+			Pk(incidentId).
+			Set("countFailed", dbs.SQL("countFailed+1")).
+			Update()
+		return err
+	}
+
+	// 按字符截取，避免截断多字节字符
+	var errRunes = []rune(errString)
+	if len(errRunes) > 1024 {
+		errString = string(errRunes[:1024])
+	}
+
+	op := NewNodeHealthCheckIncidentOperator()
+	op.ClusterId = clusterId
+	op.NodeId = nodeId
+	op.StartedAt = checkedAt
+	op.EndedAt = 0
+	op.CountFailed = 1
+	op.Error = errString
+	return this.Save(tx, op)
+}
+
+// CloseNodeIncidents 结束节点所有未结束的故障，用于节点删除后
+func (this *NodeHealthCheckIncidentDAO) CloseNodeIncidents(tx *dbs.Tx, nodeId int64) error {
+	if nodeId <= 0 {
+		return nil
+	}
+	_, err := this.Query(tx).
+		Attr("nodeId", nodeId).
+		Attr("endedAt", 0).
+		Set("endedAt", time.Now().Unix()).
+		Update()
+	return err
+}
+
+// CloseClusterIncidents 结束集群所有未结束的故障，用于关闭健康检查或者删除集群后
+func (this *NodeHealthCheckIncidentDAO) CloseClusterIncidents(tx *dbs.Tx, clusterId int64) error {
+	if clusterId <= 0 {
+		return nil
+	}
+	_, err := this.Query(tx).
+		Attr("clusterId", clusterId).
+		Attr("endedAt", 0).
+		Set("endedAt", time.Now().Unix()).
+		Update()
+	return err
+}
+
+// CountIncidents 计算一段时间内的故障数量
+func (this *NodeHealthCheckIncidentDAO) CountIncidents(tx *dbs.Tx, clusterId int64, nodeId int64, timeFrom int64, timeTo int64) (int64, error) {
+	return this.rangeQuery(tx, clusterId, nodeId, timeFrom, timeTo).
+		Count()
+}
+
+// ListIncidents 列出一段时间内的单页故障
+func (this *NodeHealthCheckIncidentDAO) ListIncidents(tx *dbs.Tx, clusterId int64, nodeId int64, timeFrom int64, timeTo int64, offset int64, size int64) (result []*NodeHealthCheckIncident, err error) {
+	_, err = this.rangeQuery(tx, clusterId, nodeId, timeFrom, timeTo).
+		Offset(offset).
+		Limit(size).
+		DescPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// FindAllIncidents 查找一段时间内的所有故障
+func (this *NodeHealthCheckIncidentDAO) FindAllIncidents(tx *dbs.Tx, clusterId int64, nodeId int64, timeFrom int64, timeTo int64) (result []*NodeHealthCheckIncident, err error) {
+	_, err = this.rangeQuery(tx, clusterId, nodeId, timeFrom, timeTo).
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 和某个时间范围有交集的故障
+func (this *NodeHealthCheckIncidentDAO) rangeQuery(tx *dbs.Tx, clusterId int64, nodeId int64, timeFrom int64, timeTo int64) *dbs.Query {
+	query := this.Query(tx)
+	if clusterId > 0 {
+		query.Attr("clusterId", clusterId)
+	}
+	if nodeId > 0 {
+		query.Attr("nodeId", nodeId)
+	}
+	return query.
+		Lte("startedAt", timeTo).
+		Where("(endedAt=0 OR endedAt>=:timeFrom)").
+		Param("timeFrom", timeFrom)
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/iwind/TeaGo/bootstrap"
+	"github.com/iwind/TeaGo/dbs"
+	"testing"
+	"time"
+)
+
+func TestNodeHealthCheckIncidentDAO_UpdateIncident(t *testing.T) {
+	dbs.NotifyReady()
+
+	var tx *dbs.Tx
+	var now = time.Now().Unix()
+	err := SharedNodeHealthCheckIncidentDAO.UpdateIncident(tx, 1, 1, false, "connection refused", now-60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SharedNodeHealthCheckIncidentDAO.UpdateIncident(tx, 1, 1, false, "connection refused", now-30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SharedNodeHealthCheckIncidentDAO.UpdateIncident(tx, 1, 1, true, "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	incidents, err := SharedNodeHealthCheckIncidentDAO.FindAllIncidents(tx, 1, 1, now-3600, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, incident := range incidents {
+		t.Log(incident.Id, incident.StartedAt, incident.EndedAt, incident.CountFailed, incident.DurationSeconds(now-3600, now, now))
+	}
+}
+
+func TestNodeHealthCheckIncidentDAO_CloseNodeIncidents(t *testing.T) {
+	dbs.NotifyReady()
+
+	var tx *dbs.Tx
+	var now = time.Now().Unix()
+	err := SharedNodeHealthCheckIncidentDAO.UpdateIncident(tx, 1, 1, false, "连接被拒绝", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = SharedNodeHealthCheckIncidentDAO.CloseNodeIncidents(tx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	incidents, err := SharedNodeHealthCheckIncidentDAO.FindAllIncidents(tx, 1, 1, now, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, incident := range incidents {
+		if incident.EndedAt == 0 {
+			t.Fatal("incident should be closed")
+		}
+	}
+}
+
+func TestNodeHealthCheckIncident_DurationSeconds(t *testing.T) {
+	var incident = &NodeHealthCheckIncident{StartedAt: 100, EndedAt: 200}
+	if incident.DurationSeconds(0, 1000, 1000) != 100 {
+		t.Fatal("expect 100")
+	}
+	if incident.DurationSeconds(150, 1000, 1000) != 50 {
+		t.Fatal("expect 50")
+	}
+	if incident.DurationSeconds(300, 1000, 1000) != 0 {
+		t.Fatal("expect 0")
+	}
+
+	// 尚未结束
+	incident.EndedAt = 0
+	if incident.DurationSeconds(0, 500, 400) != 300 {
+		t.Fatal("expect 300")
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+// NodeHealthCheckIncident 节点健康检查故障
+type NodeHealthCheckIncident struct {
+	Id          uint64 `field:"id"`          // ID
+	ClusterId   uint32 `field:"clusterId"`   // 集群ID
+	NodeId      uint32 `field:"nodeId"`      // 节点ID
+	StartedAt   uint64 `field:"startedAt"`   // 开始时间
+	EndedAt     uint64 `field:"endedAt"`     // 结束时间，0表示尚未结束
+	CountFailed uint32 `field:"countFailed"` // 失败次数
+	Error       string `field:"error"`       // 第一次失败的错误信息
+}
+
+type NodeHealthCheckIncidentOperator struct {
+	Id          interface{} // ID
+	ClusterId   interface{} // 集群ID
+	NodeId      interface{} // 节点ID
+	StartedAt   interface{} // 开始时间
+	EndedAt     interface{} // 结束时间，0表示尚未结束
+	CountFailed interface{} // 失败次数
+	Error       interface{} // 第一次失败的错误信息
+}
+
+func NewNodeHealthCheckIncidentOperator() *NodeHealthCheckIncidentOperator {
+	return &NodeHealthCheckIncidentOperator{}
+}
@@ -0,0 +1,21 @@
+package models
+
+// DurationSeconds 故障在某个时间范围内持续的秒数
+// 尚未结束的故障以now作为结束时间
+func (this *NodeHealthCheckIncident) DurationSeconds(timeFrom int64, timeTo int64, now int64) int64 {
+	var startedAt = int64(this.StartedAt)
+	var endedAt = int64(this.EndedAt)
+	if endedAt == 0 {
+		endedAt = now
+	}
+	if startedAt < timeFrom {
+		startedAt = timeFrom
+	}
+	if endedAt > timeTo {
+		endedAt = timeTo
+	}
+	if endedAt <= startedAt {
+		return 0
+	}
+	return endedAt - startedAt
+}
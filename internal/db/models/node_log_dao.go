@@ -10,7 +10,6 @@ import (
 	"github.com/iwind/TeaGo/types"
 	stringutil "github.com/iwind/TeaGo/utils/string"
 	timeutil "github.com/iwind/TeaGo/utils/time"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -75,20 +74,6 @@ func (this *NodeLogDAO) CreateLog(tx *dbs.Tx, nodeRole nodeconfigs.NodeRole, nod
 	return err
 }
 
-// DeleteExpiredLogs 清除超出一定日期的日志
-func (this *NodeLogDAO) DeleteExpiredLogs(tx *dbs.Tx, days int) error {
-	if days <= 0 {
-		return errors.New("invalid days '" + strconv.Itoa(days) + "'")
-	}
-	date := time.Now().AddDate(0, 0, -days)
-	expireDay := timeutil.Format("Ymd", date)
-	_, err := this.Query(tx).
-		Where("day<=:day").
-		Param("day", expireDay).
-		Delete()
-	return err
-}
-
 // CountNodeLogs 计算节点日志数量
 func (this *NodeLogDAO) CountNodeLogs(tx *dbs.Tx, role string, nodeId int64, serverId int64, originId int64, dayFrom string, dayTo string, keyword string, level string) (int64, error) {
 	query := this.Query(tx).
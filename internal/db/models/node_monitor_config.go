@@ -0,0 +1,92 @@
+package models
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeCommon/pkg/nodeconfigs"
+	"regexp"
+)
+
+// SettingCodeNodeMonitorConfig 节点资源监控设置在系统设置中的代号
+const SettingCodeNodeMonitorConfig = "nodeMonitorConfig"
+
+// 参数名会用来拼接JSON路径，所以只允许字母、数字和下划线
+var nodeValueParamReg = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// IsValidNodeValueParam 判断参数名是否有效
+func IsValidNodeValueParam(param string) bool {
+	return nodeValueParamReg.MatchString(param)
+}
+
+// NodeMonitorConfig 节点资源监控设置
+type NodeMonitorConfig struct {
+	IsOn    bool                     `json:"isOn"`    // 是否启用
+	Minutes int                      `json:"minutes"` // 持续超出限制多少分钟后才提醒
+	Items   []*NodeMonitorItemConfig `json:"items"`   // 监控项
+}
+
+// NodeMonitorItemConfig 单个监控项设置
+type NodeMonitorItemConfig struct {
+	IsOn     bool    `json:"isOn"`     // 是否启用
+	Item     string  `json:"item"`     // 监控项，比如cpu、memory、disk
+	Param    string  `json:"param"`    // 参数，比如usage
+	MaxValue float64 `json:"maxValue"` // 最大值，使用率的取值为0-1
+}
+
+// DefaultNodeMonitorConfig 默认的节点资源监控设置
+func DefaultNodeMonitorConfig() *NodeMonitorConfig {
+	return &NodeMonitorConfig{
+		IsOn:    true,
+		Minutes: 5,
+		Items: []*NodeMonitorItemConfig{
+			{
+				IsOn:     true,
+				Item:     nodeconfigs.NodeValueItemCPU,
+				Param:    "usage",
+				MaxValue: 0.9,
+			},
+			{
+				IsOn:     true,
+				Item:     nodeconfigs.NodeValueItemMemory,
+				Param:    "usage",
+				MaxValue: 0.9,
+			},
+			{
+				IsOn:     true,
+				Item:     "disk",
+				Param:    "usage",
+				MaxValue: 0.9,
+			},
+		},
+	}
+}
+
+// Validate 校验设置
+func (this *NodeMonitorConfig) Validate() error {
+	if this.Minutes <= 0 {
+		return errors.New("'minutes' should be greater than 0")
+	}
+	for _, item := range this.Items {
+		if len(item.Item) == 0 || len(item.Param) == 0 {
+			return errors.New("'item' and 'param' should not be empty")
+		}
+		if !IsValidNodeValueParam(item.Param) {
+			return errors.New("invalid param '" + item.Param + "' of '" + item.Item + "'")
+		}
+		if item.MaxValue <= 0 {
+			return errors.New("'maxValue' of '" + item.Item + "' should be greater than 0")
+		}
+	}
+	return nil
+}
+
+// ItemName 监控项名称
+func (this *NodeMonitorItemConfig) ItemName() string {
+	if this.Item == "disk" {
+		return "磁盘"
+	}
+	var name = nodeconfigs.FindNodeValueItemName(this.Item)
+	if len(name) == 0 {
+		return this.Item
+	}
+	return name
+}
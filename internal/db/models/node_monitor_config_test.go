@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestNodeMonitorConfig_Validate(t *testing.T) {
+	var config = DefaultNodeMonitorConfig()
+	err := config.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.Items[0].Param = "usage') OR 1=1 -- "
+	err = config.Validate()
+	if err == nil {
+		t.Fatal("param with sql should be invalid")
+	}
+	t.Log("expected error:", err)
+}
+
+func TestIsValidNodeValueParam(t *testing.T) {
+	for param, expected := range map[string]bool{
+		"usage":     true,
+		"load_1m":   true,
+		"Usage2":    true,
+		"":          false,
+		"a.b":       false,
+		"usage')":   false,
+		"$.usage":   false,
+		"usage -- ": false,
+	} {
+		if IsValidNodeValueParam(param) != expected {
+			t.Fatal("param:", param, "expected:", expected)
+		}
+	}
+}
@@ -202,6 +202,38 @@ func (this *NodeValueDAO) SumValues(tx *dbs.Tx, role string, nodeId int64, item
 	return query.FindFloat64Col(0)
 }
 
+// SumNodeValuesWithClusterId 汇总集群中每个节点最近几分钟内某个参数的最小值和最大值
+func (this *NodeValueDAO) SumNodeValuesWithClusterId(tx *dbs.Tx, role string, clusterId int64, item string, param string, minutes int) (map[int64]*NodeValueSummary, error) {
+	var result = map[int64]*NodeValueSummary{} // nodeId => summary
+	if minutes <= 0 || len(param) == 0 {
+		return result, nil
+	}
+	if !IsValidNodeValueParam(param) {
+		return nil, errors.New("invalid param '" + param + "'")
+	}
+
+	fromMinute := timeutil.FormatTime("YmdHi", time.Now().Unix()-int64(minutes*60))
+	ones, _, err := this.Query(tx).
+		Attr("role", role).
+		Attr("clusterId", clusterId).
+		Attr("item", item).
+		Gte("minute", fromMinute).
+		Result("nodeId", "MIN(JSON_EXTRACT(value, '$."+param+"')) AS minValue", "MAX(JSON_EXTRACT(value, '$."+param+"')) AS maxValue", "COUNT(DISTINCT minute) AS countMinutes").
+		Group("nodeId").
+		FindOnes()
+	if err != nil {
+		return nil, err
+	}
+	for _, one := range ones {
+		result[one.GetInt64("nodeId")] = &NodeValueSummary{
+			MinValue:     one.GetFloat64("minValue"),
+			MaxValue:     one.GetFloat64("maxValue"),
+			CountMinutes: one.GetInt("countMinutes"),
+		}
+	}
+	return result, nil
+}
+
 // FindLatestNodeValue 获取最近一条数据
 func (this *NodeValueDAO) FindLatestNodeValue(tx *dbs.Tx, role string, nodeId int64, item string) (*NodeValue, error) {
 	one, err := this.Query(tx).
@@ -17,3 +17,10 @@ func (this *NodeValue) DecodeMapValue() maps.Map {
 	}
 	return m
 }
+
+// NodeValueSummary 节点一段时间内某个参数的汇总
+type NodeValueSummary struct {
+	MinValue     float64 // 最小值
+	MaxValue     float64 // 最大值
+	CountMinutes int     // 有数据的分钟数
+}
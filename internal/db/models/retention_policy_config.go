@@ -0,0 +1,199 @@
+package models
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/iwind/TeaGo/maps"
+)
+
+// 数据保留策略在系统设置中的代号
+const (
+	SettingCodeRetentionPolicyConfig = "retentionPolicyConfig" // 保留策略
+	SettingCodeRetentionPolicyReport = "retentionPolicyReport" // 最近一次清理的结果
+)
+
+type RetentionFamily = string
+
+// 需要定期清理的数据分类
+const (
+	RetentionFamilyMessages        RetentionFamily = "messages"        // 消息
+	RetentionFamilyMessageTaskLogs RetentionFamily = "messageTaskLogs" // 消息发送日志
+	RetentionFamilyMessageRoutes   RetentionFamily = "messageRoutes"   // 消息路由合并和升级记录
+	RetentionFamilyNodeLogs        RetentionFamily = "nodeLogs"        // 节点日志
+	RetentionFamilyMetricStats     RetentionFamily = "metricStats"     // 指标统计
+	RetentionFamilyHourlyStats     RetentionFamily = "hourlyStats"     // 按小时统计
+	RetentionFamilyDailyStats      RetentionFamily = "dailyStats"      // 按天统计
+	RetentionFamilyMonthlyStats    RetentionFamily = "monthlyStats"    // 按月统计
+	RetentionFamilyHealthChecks    RetentionFamily = "healthChecks"    // 健康检查记录
+	RetentionFamilyACMEAuths       RetentionFamily = "acmeAuths"       // ACME认证信息
+	RetentionFamilyACMETaskLogs    RetentionFamily = "acmeTaskLogs"    // ACME任务运行日志
+	RetentionFamilyDNSTaskLogs     RetentionFamily = "dnsTaskLogs"     // DNS同步日志
+)
+
+// FindAllRetentionFamilies 所有数据分类
+// defaultDays为0的分类以前没有清理过，默认永久保留
+func FindAllRetentionFamilies() []maps.Map {
+	return []maps.Map{
+		{
+			"name":        "消息",
+			"code":        RetentionFamilyMessages,
+			"description": "集群、节点等发出的系统消息。",
+			"defaultDays": 30,
+		},
+		{
+			"name":        "消息发送日志",
+			"code":        RetentionFamilyMessageTaskLogs,
+			"description": "每次通过媒介发送消息的记录。",
+			"defaultDays": 0,
+		},
+		{
+			"name":        "消息路由记录",
+			"code":        RetentionFamilyMessageRoutes,
+			"description": "消息路由规则已发送的合并和已处理的升级记录。",
+			"defaultDays": 7,
+		},
+		{
+			"name":        "节点日志",
+			"code":        RetentionFamilyNodeLogs,
+			"description": "边缘节点、DNS节点等上报的运行日志。",
+			"defaultDays": 30,
+		},
+		{
+			"name":        "指标统计",
+			"code":        RetentionFamilyMetricStats,
+			"description": "自定义指标的统计数据。",
+			"defaultDays": 120,
+		},
+		{
+			"name":        "按小时统计",
+			"code":        RetentionFamilyHourlyStats,
+			"description": "流量、域名、WAF、DNS记录等按小时的统计数据。",
+			"defaultDays": 60,
+		},
+		{
+			"name":        "按天统计",
+			"code":        RetentionFamilyDailyStats,
+			"description": "服务、节点、集群流量和WAF等按天的统计数据。",
+			"defaultDays": 60,
+		},
+		{
+			"name":        "按月统计",
+			"code":        RetentionFamilyMonthlyStats,
+			"description": "地区、运营商、浏览器、操作系统等按月的统计数据。",
+			"defaultDays": 0,
+		},
+		{
+			"name":        "健康检查记录",
+			"code":        RetentionFamilyHealthChecks,
+			"description": "节点健康检查的小时统计和已结束的故障记录。",
+			"defaultDays": 100,
+		},
+		{
+			"name":        "ACME认证信息",
+			"code":        RetentionFamilyACMEAuths,
+			"description": "申请证书时生成的HTTP和TLS-ALPN认证信息，认证完成后不再需要。",
+			"defaultDays": 7,
+		},
+		{
+			"name":        "ACME任务日志",
+			"code":        RetentionFamilyACMETaskLogs,
+			"description": "申请和续期证书任务的运行日志。",
+			"defaultDays": 90,
+		},
+		{
+			"name":        "DNS同步日志",
+			"code":        RetentionFamilyDNSTaskLogs,
+			"description": "集群、服务等同步到DNS服务商时每条记录的操作日志。",
+			"defaultDays": 30,
+		},
+	}
+}
+
+// FindRetentionFamily 查找数据分类
+func FindRetentionFamily(code RetentionFamily) maps.Map {
+	for _, family := range FindAllRetentionFamilies() {
+		if family.GetString("code") == code {
+			return family
+		}
+	}
+	return nil
+}
+
+// RetentionPolicyConfig 数据保留策略
+type RetentionPolicyConfig struct {
+	BatchSize  int64                        `json:"batchSize"`  // 每批最多删除的记录数
+	MaxBatches int                          `json:"maxBatches"` // 每次清理时每张表最多删除的批数，剩余的数据在下次清理时删除
+	Items      []*RetentionPolicyItemConfig `json:"items"`      // 每个分类的保留天数，没有设置的分类使用默认值
+}
+
+// RetentionPolicyItemConfig 单个分类的保留策略
+type RetentionPolicyItemConfig struct {
+	Family RetentionFamily `json:"family"` // 分类
+	Days   int             `json:"days"`   // 保留天数，0表示永久保留
+}
+
+// DefaultRetentionPolicyConfig 默认的数据保留策略
+func DefaultRetentionPolicyConfig() *RetentionPolicyConfig {
+	return &RetentionPolicyConfig{
+		BatchSize:  1000,
+		MaxBatches: 100,
+	}
+}
+
+// Validate 校验设置
+func (this *RetentionPolicyConfig) Validate() error {
+	if this.BatchSize <= 0 {
+		return errors.New("'batchSize' should be greater than 0")
+	}
+	if this.MaxBatches <= 0 {
+		return errors.New("'maxBatches' should be greater than 0")
+	}
+	for _, item := range this.Items {
+		if FindRetentionFamily(item.Family) == nil {
+			return errors.New("unknown retention family '" + item.Family + "'")
+		}
+		if item.Days < 0 {
+			return errors.New("'days' of '" + item.Family + "' should not be negative")
+		}
+	}
+	return nil
+}
+
+// FindDays 查找某个分类的保留天数
+func (this *RetentionPolicyConfig) FindDays(family RetentionFamily) int {
+	for _, item := range this.Items {
+		if item.Family == family {
+			return item.Days
+		}
+	}
+	var familyMap = FindRetentionFamily(family)
+	if familyMap == nil {
+		return 0
+	}
+	return familyMap.GetInt("defaultDays")
+}
+
+// RetentionPolicyReport 最近一次清理的结果
+type RetentionPolicyReport struct {
+	StartedAt  int64                        `json:"startedAt"`  // 开始时间
+	FinishedAt int64                        `json:"finishedAt"` // 结束时间
+	Items      []*RetentionPolicyReportItem `json:"items"`      // 每张表的清理结果
+}
+
+// RetentionPolicyReportItem 单张表的清理结果
+type RetentionPolicyReportItem struct {
+	Family       RetentionFamily `json:"family"`       // 分类
+	Table        string          `json:"table"`        // 表名
+	Days         int             `json:"days"`         // 保留天数
+	CountDeleted int64           `json:"countDeleted"` // 删除的记录数
+	IsFinished   bool            `json:"isFinished"`   // 是否已经删除完所有过期数据
+	Error        string          `json:"error"`        // 错误信息
+}
+
+// CountDeleted 删除的总记录数
+func (this *RetentionPolicyReport) CountDeleted() int64 {
+	var count int64
+	for _, item := range this.Items {
+		count += item.CountDeleted
+	}
+	return count
+}
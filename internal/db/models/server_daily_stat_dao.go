@@ -6,31 +6,13 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
-	"github.com/iwind/TeaGo/logs"
 	"github.com/iwind/TeaGo/maps"
-	"github.com/iwind/TeaGo/rands"
 	timeutil "github.com/iwind/TeaGo/utils/time"
 	"regexp"
-	"time"
 )
 
 type ServerDailyStatDAO dbs.DAO
 
-func init() {
-	dbs.OnReadyDone(func() {
-		// 清理数据任务
-		var ticker = time.NewTicker(time.Duration(rands.Int(24, 48)) * time.Hour)
-		go func() {
-			for range ticker.C {
-				err := SharedServerDailyStatDAO.Clean(nil, 60) // 只保留60天
-				if err != nil {
-					logs.Println("ServerDailyStatDAO", "clean expired data failed: "+err.Error())
-				}
-			}
-		}()
-	})
-}
-
 func NewServerDailyStatDAO() *ServerDailyStatDAO {
 	return dbs.NewDAO(&ServerDailyStatDAO{
 		DAOObject: dbs.DAOObject{
@@ -335,12 +317,3 @@ func (this *ServerDailyStatDAO) FindTopUserStats(tx *dbs.Tx, hourFrom string, ho
 		FindAll()
 	return
 }
-
-// Clean 清理历史数据
-func (this *ServerDailyStatDAO) Clean(tx *dbs.Tx, days int) error {
-	var day = timeutil.Format("Ymd", time.Now().AddDate(0, 0, -days))
-	_, err := this.Query(tx).
-		Lt("day", day).
-		Delete()
-	return err
-}
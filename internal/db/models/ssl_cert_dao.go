@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/encrypt"
 	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs/sslconfigs"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
@@ -73,7 +74,12 @@ func (this *SSLCertDAO) FindEnabledSSLCert(tx *dbs.Tx, id int64) (*SSLCert, erro
 	if result == nil {
 		return nil, err
 	}
-	return result.(*SSLCert), err
+	cert := result.(*SSLCert)
+	err = this.decodeCert(cert)
+	if err != nil {
+		return nil, err
+	}
+	return cert, nil
 }
 
 // 根据主键查找名称
@@ -96,7 +102,13 @@ func (this *SSLCertDAO) CreateCert(tx *dbs.Tx, adminId int64, userId int64, isOn
 	op.ServerName = serverName
 	op.IsCA = isCA
 	op.CertData = certData
+
+	keyData, err := encrypt.EncryptSecret(keyData)
+	if err != nil {
+		return 0, err
+	}
 	op.KeyData = keyData
+
 	op.TimeBeginAt = timeBeginAt
 	op.TimeEndAt = timeEndAt
 
@@ -137,6 +149,10 @@ func (this *SSLCertDAO) UpdateCert(tx *dbs.Tx, certId int64, isOn bool, name str
 		op.CertData = certData
 	}
 	if len(keyData) > 0 {
+		keyData, err := encrypt.EncryptSecret(keyData)
+		if err != nil {
+			return err
+		}
 		op.KeyData = keyData
 	}
 
@@ -367,3 +383,13 @@ func (this *SSLCertDAO) NotifyUpdate(tx *dbs.Tx, certId int64) error {
 	}
 	return nil
 }
+
+// 解密证书中的敏感数据
+func (this *SSLCertDAO) decodeCert(cert *SSLCert) error {
+	keyData, err := encrypt.DecryptSecret([]byte(cert.KeyData))
+	if err != nil {
+		return errors.New("decrypt key data of cert '" + types.String(cert.Id) + "' failed: " + err.Error())
+	}
+	cert.KeyData = string(keyData)
+	return nil
+}
@@ -2,34 +2,15 @@ package stats
 
 import (
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
-	"github.com/TeaOSLab/EdgeAPI/internal/remotelogs"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/maps"
-	"github.com/iwind/TeaGo/rands"
-	timeutil "github.com/iwind/TeaGo/utils/time"
-	"time"
 )
 
 type NodeTrafficHourlyStatDAO dbs.DAO
 
-func init() {
-	dbs.OnReadyDone(func() {
-		// 清理数据任务
-		var ticker = time.NewTicker(time.Duration(rands.Int(24, 48)) * time.Hour)
-		go func() {
-			for range ticker.C {
-				err := SharedNodeTrafficHourlyStatDAO.Clean(nil, 60) // 只保留60天
-				if err != nil {
-					remotelogs.Error("NodeTrafficHourlyStatDAO", "clean expired data failed: "+err.Error())
-				}
-			}
-		}()
-	})
-}
-
 func NewNodeTrafficHourlyStatDAO() *NodeTrafficHourlyStatDAO {
 	return dbs.NewDAO(&NodeTrafficHourlyStatDAO{
 		DAOObject: dbs.DAOObject{
@@ -177,12 +158,3 @@ func (this *NodeTrafficHourlyStatDAO) FindHourlyStatsWithNodeId(tx *dbs.Tx, role
 	}
 	return result, nil
 }
-
-// Clean 清理历史数据
-func (this *NodeTrafficHourlyStatDAO) Clean(tx *dbs.Tx, days int) error {
-	var hour = timeutil.Format("Ymd00", time.Now().AddDate(0, 0, -days))
-	_, err := this.Query(tx).
-		Lt("hour", hour).
-		Delete()
-	return err
-}
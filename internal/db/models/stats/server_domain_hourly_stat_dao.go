@@ -2,33 +2,14 @@ package stats
 
 import (
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
-	"github.com/TeaOSLab/EdgeAPI/internal/remotelogs"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/maps"
-	"github.com/iwind/TeaGo/rands"
-	timeutil "github.com/iwind/TeaGo/utils/time"
-	"time"
 )
 
 type ServerDomainHourlyStatDAO dbs.DAO
 
-func init() {
-	dbs.OnReadyDone(func() {
-		// 清理数据任务
-		var ticker = time.NewTicker(time.Duration(rands.Int(24, 48)) * time.Hour)
-		go func() {
-			for range ticker.C {
-				err := SharedServerDomainHourlyStatDAO.Clean(nil, 60) // 只保留60天
-				if err != nil {
-					remotelogs.Error("ServerDomainHourlyStatDAO", "clean expired data failed: "+err.Error())
-				}
-			}
-		}()
-	})
-}
-
 func NewServerDomainHourlyStatDAO() *ServerDomainHourlyStatDAO {
 	return dbs.NewDAO(&ServerDomainHourlyStatDAO{
 		DAOObject: dbs.DAOObject{
@@ -144,12 +125,3 @@ func (this *ServerDomainHourlyStatDAO) FindTopDomainStatsWithServerId(tx *dbs.Tx
 		FindAll()
 	return
 }
-
-// Clean 清理历史数据
-func (this *ServerDomainHourlyStatDAO) Clean(tx *dbs.Tx, days int) error {
-	var hour = timeutil.Format("Ymd00", time.Now().AddDate(0, 0, -days))
-	_, err := this.Query(tx).
-		Lt("hour", hour).
-		Delete()
-	return err
-}
@@ -103,6 +103,15 @@ func (this *SysLockerDAO) Lock(tx *dbs.Tx, key string, timeout int64) (ok bool,
 	}
 }
 
+// Renew 延长锁的有效期，只能由已经获得锁的调用者使用
+func (this *SysLockerDAO) Renew(tx *dbs.Tx, key string, timeout int64) error {
+	_, err := this.Query(tx).
+		Attr("key", key).
+		Set("timeoutAt", time.Now().Unix()+timeout).
+		Update()
+	return err
+}
+
 // 解锁
 func (this *SysLockerDAO) Unlock(tx *dbs.Tx, key string) error {
 	_, err := this.Query(tx).
@@ -121,3 +121,92 @@ func (this *SysSettingDAO) ReadGlobalConfig(tx *dbs.Tx) (*serverconfigs.GlobalCo
 	}
 	return config, nil
 }
+
+// ReadNodeMonitorConfig 读取节点资源监控设置
+func (this *SysSettingDAO) ReadNodeMonitorConfig(tx *dbs.Tx) (*NodeMonitorConfig, error) {
+	configJSON, err := this.ReadSetting(tx, SettingCodeNodeMonitorConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(configJSON) == 0 {
+		return DefaultNodeMonitorConfig(), nil
+	}
+	config := &NodeMonitorConfig{}
+	err = json.Unmarshal(configJSON, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Minutes <= 0 {
+		config.Minutes = DefaultNodeMonitorConfig().Minutes
+	}
+	return config, nil
+}
+
+// ReadMessageSenderConfig 读取内置消息发送设置
+func (this *SysSettingDAO) ReadMessageSenderConfig(tx *dbs.Tx) (*MessageSenderConfig, error) {
+	configJSON, err := this.ReadSetting(tx, SettingCodeMessageSenderConfig)
+	if err != nil {
+		return nil, err
+	}
+	var config = DefaultMessageSenderConfig()
+	if len(configJSON) == 0 {
+		return config, nil
+	}
+	err = json.Unmarshal(configJSON, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMessageSenderConfig().MaxAttempts
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultMessageSenderConfig().BatchSize
+	}
+	return config, nil
+}
+
+// ReadRetentionPolicyConfig 读取数据保留策略
+func (this *SysSettingDAO) ReadRetentionPolicyConfig(tx *dbs.Tx) (*RetentionPolicyConfig, error) {
+	configJSON, err := this.ReadSetting(tx, SettingCodeRetentionPolicyConfig)
+	if err != nil {
+		return nil, err
+	}
+	var config = DefaultRetentionPolicyConfig()
+	if len(configJSON) == 0 {
+		return config, nil
+	}
+	err = json.Unmarshal(configJSON, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultRetentionPolicyConfig().BatchSize
+	}
+	if config.MaxBatches <= 0 {
+		config.MaxBatches = DefaultRetentionPolicyConfig().MaxBatches
+	}
+	return config, nil
+}
+
+// ReadACMERenewalConfig 读取证书自动续期设置
+func (this *SysSettingDAO) ReadACMERenewalConfig(tx *dbs.Tx) (*ACMERenewalConfig, error) {
+	configJSON, err := this.ReadSetting(tx, SettingCodeACMERenewalConfig)
+	if err != nil {
+		return nil, err
+	}
+	var config = DefaultACMERenewalConfig()
+	if len(configJSON) == 0 {
+		return config, nil
+	}
+	err = json.Unmarshal(configJSON, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.RenewRatio <= 0 || config.RenewRatio >= 1 {
+		config.RenewRatio = DefaultACMERenewalConfig().RenewRatio
+	}
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = DefaultACMERenewalConfig().MaxConcurrent
+	}
+	return config, nil
+}
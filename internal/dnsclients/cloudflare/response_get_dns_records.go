@@ -11,6 +11,7 @@ type GetDNSRecordsResponse struct {
 		Name     string `json:"name"`
 		Content  string `json:"content"`
 		Ttl      int    `json:"ttl"`
+		Priority int    `json:"priority"`
 		ZoneId   string `json:"zoneId"`
 		ZoneName string `json:"zoneName"`
 	} `json:"result"`
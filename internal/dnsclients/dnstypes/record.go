@@ -1,5 +1,10 @@
 package dnstypes
 
+import (
+	"strconv"
+	"strings"
+)
+
 type RecordType = string
 
 const (
@@ -7,12 +12,63 @@ const (
 	RecordTypeAAAA  RecordType = "AAAA"
 	RecordTypeCNAME RecordType = "CNAME"
 	RecordTypeTXT   RecordType = "TXT"
+	RecordTypeMX    RecordType = "MX"
+	RecordTypeNS    RecordType = "NS"
+	RecordTypeSRV   RecordType = "SRV"
+	RecordTypeCAA   RecordType = "CAA"
 )
 
+// Record 解析记录
+// 对于MX和SRV记录，Value中不包含优先级，优先级放在Priority中，比如：
+//   - MX：Value为 mail.example.com.
+//   - SRV：Value为 "权重 端口 目标"，比如 5 5060 sip.example.com.
+//
+// CAA记录的Value为 "标志 标签 值"，比如 0 issue "letsencrypt.org"
 type Record struct {
-	Id    string     `json:"id"`
-	Name  string     `json:"name"`
-	Type  RecordType `json:"type"`
-	Value string     `json:"value"`
-	Route string     `json:"route"`
+	Id       string     `json:"id"`
+	Name     string     `json:"name"`
+	Type     RecordType `json:"type"`
+	Value    string     `json:"value"`
+	Route    string     `json:"route"`
+	TTL      int32      `json:"ttl"`      // TTL（秒），0表示使用服务商默认值
+	Weight   int32      `json:"weight"`   // 负载均衡权重，0表示不设置
+	Priority int32      `json:"priority"` // 优先级，仅对MX和SRV记录有效
+}
+
+// HasPriority 是否为需要优先级的记录类型
+func (this *Record) HasPriority() bool {
+	return IsPriorityRecordType(this.Type)
+}
+
+// FullValue 包含优先级的完整记录值，比如 10 mail.example.com.
+// 用于值中需要包含优先级的服务商
+func (this *Record) FullValue() string {
+	if !this.HasPriority() {
+		return this.Value
+	}
+	return strconv.Itoa(int(this.Priority)) + " " + this.Value
+}
+
+// SetFullValue 设置包含优先级的完整记录值，并从中分离出优先级
+func (this *Record) SetFullValue(value string) {
+	this.Value = value
+	if !this.HasPriority() {
+		return
+	}
+
+	var pieces = strings.Fields(value)
+	if len(pieces) < 2 {
+		return
+	}
+	priority, err := strconv.Atoi(pieces[0])
+	if err != nil {
+		return
+	}
+	this.Priority = int32(priority)
+	this.Value = strings.Join(pieces[1:], " ")
+}
+
+// IsPriorityRecordType 判断某个记录类型是否需要优先级
+func IsPriorityRecordType(recordType RecordType) bool {
+	return recordType == RecordTypeMX || recordType == RecordTypeSRV
 }
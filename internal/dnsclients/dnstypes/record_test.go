@@ -0,0 +1,38 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package dnstypes
+
+import "testing"
+
+func TestRecord_FullValue(t *testing.T) {
+	for _, record := range []*Record{
+		{Type: RecordTypeA, Value: "1.1.1.1"},
+		{Type: RecordTypeMX, Value: "mail.example.com.", Priority: 10},
+		{Type: RecordTypeSRV, Value: "5 5060 sip.example.com.", Priority: 0},
+		{Type: RecordTypeCAA, Value: `0 issue "letsencrypt.org"`},
+	} {
+		var newRecord = &Record{Type: record.Type}
+		newRecord.SetFullValue(record.FullValue())
+		if newRecord.Value != record.Value || newRecord.Priority != record.Priority {
+			t.Fatal("invalid value:", record.FullValue(), "=>", newRecord.Value, newRecord.Priority)
+		}
+		t.Log(record.Type, record.FullValue())
+	}
+}
+
+func TestRecord_SetFullValue(t *testing.T) {
+	{
+		var record = &Record{Type: RecordTypeMX}
+		record.SetFullValue("mail.example.com.")
+		if record.Value != "mail.example.com." || record.Priority != 0 {
+			t.Fatal("value without priority should be kept:", record)
+		}
+	}
+	{
+		var record = &Record{Type: RecordTypeSRV}
+		record.SetFullValue("10  5 5060  sip.example.com.")
+		if record.Value != "5 5060 sip.example.com." || record.Priority != 10 {
+			t.Fatal("invalid srv record:", record)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package powerdns
+
+// ErrorResponse 错误信息
+type ErrorResponse struct {
+	Error  string   `json:"error"`
+	Errors []string `json:"errors"`
+}
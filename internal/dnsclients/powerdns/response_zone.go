@@ -0,0 +1,22 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package powerdns
+
+// ZoneResponse 区域信息
+type ZoneResponse struct {
+	Id     string   `json:"id"`
+	Name   string   `json:"name"`
+	Kind   string   `json:"kind"`
+	Serial int64    `json:"serial"`
+	RRSets []*RRSet `json:"rrsets"`
+}
+
+// FindRRSet 根据名称和类型查找记录集
+func (this *ZoneResponse) FindRRSet(name string, recordType string) *RRSet {
+	for _, rrset := range this.RRSets {
+		if rrset.Name == name && rrset.Type == recordType {
+			return rrset
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package powerdns
+
+// 记录集变更类型
+const (
+	ChangeTypeReplace = "REPLACE"
+	ChangeTypeDelete  = "DELETE"
+)
+
+// RRSet 记录集，名称和类型相同的一组记录
+type RRSet struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	TTL        int       `json:"ttl,omitempty"`
+	ChangeType string    `json:"changetype,omitempty"`
+	Records    []*Record `json:"records"`
+	Comments   []struct {
+		Content    string `json:"content"`
+		Account    string `json:"account"`
+		ModifiedAt int64  `json:"modified_at"`
+	} `json:"comments,omitempty"`
+}
+
+// Record 单条记录
+type Record struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+// PatchZoneRequest 修改区域记录集的请求
+type PatchZoneRequest struct {
+	RRSets []*RRSet `json:"rrsets"`
+}
@@ -53,13 +53,21 @@ func (this *AliDNSProvider) GetRecords(domain string) (records []*dnstypes.Recor
 				record.Value += "."
 			}
 
-			records = append(records, &dnstypes.Record{
-				Id:    record.RecordId,
-				Name:  record.RR,
-				Type:  record.Type,
-				Value: record.Value,
-				Route: record.Line,
-			})
+			var newRecord = &dnstypes.Record{
+				Id:     record.RecordId,
+				Name:   record.RR,
+				Type:   record.Type,
+				Route:  record.Line,
+				TTL:    int32(record.TTL),
+				Weight: int32(record.Weight),
+			}
+			if record.Type == dnstypes.RecordTypeMX {
+				newRecord.Value = record.Value
+				newRecord.Priority = int32(record.Priority)
+			} else {
+				newRecord.SetFullValue(record.Value)
+			}
+			records = append(records, newRecord)
 		}
 
 		pageNumber++
@@ -109,20 +117,26 @@ func (this *AliDNSProvider) AddRecord(domain string, newRecord *dnstypes.Record)
 	req := alidns.CreateAddDomainRecordRequest()
 	req.RR = newRecord.Name
 	req.Type = newRecord.Type
-	req.Value = newRecord.Value
+	req.Value = this.recordValue(newRecord)
 	req.DomainName = domain
 	req.Line = newRecord.Route
+	if newRecord.TTL > 0 {
+		req.TTL = requests.NewInteger(int(newRecord.TTL))
+	}
+	if newRecord.Type == dnstypes.RecordTypeMX {
+		req.Priority = requests.NewInteger(int(newRecord.Priority))
+	}
 
 	resp := alidns.CreateAddDomainRecordResponse()
 	err := this.doAPI(req, resp)
 	if err != nil {
 		return err
 	}
-	if resp.IsSuccess() {
-		return nil
+	if !resp.IsSuccess() {
+		return errors.New(resp.GetHttpContentString())
 	}
 
-	return errors.New(resp.GetHttpContentString())
+	return this.updateWeight(resp.RecordId, newRecord.Weight)
 }
 
 // UpdateRecord 修改记录
@@ -131,12 +145,25 @@ func (this *AliDNSProvider) UpdateRecord(domain string, record *dnstypes.Record,
 	req.RecordId = record.Id
 	req.RR = newRecord.Name
 	req.Type = newRecord.Type
-	req.Value = newRecord.Value
+	req.Value = this.recordValue(newRecord)
 	req.Line = newRecord.Route
+	if newRecord.TTL > 0 {
+		req.TTL = requests.NewInteger(int(newRecord.TTL))
+	}
+	if newRecord.Type == dnstypes.RecordTypeMX {
+		req.Priority = requests.NewInteger(int(newRecord.Priority))
+	}
 
 	resp := alidns.CreateUpdateDomainRecordResponse()
 	err := this.doAPI(req, resp)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if newRecord.Weight != record.Weight {
+		return this.updateWeight(record.Id, newRecord.Weight)
+	}
+	return nil
 }
 
 // DeleteRecord 删除记录
@@ -154,6 +181,29 @@ func (this *AliDNSProvider) DefaultRoute() string {
 	return "default"
 }
 
+// 提交给阿里云的记录值
+// MX记录的优先级使用单独的参数，SRV记录的优先级需要放在记录值中
+func (this *AliDNSProvider) recordValue(record *dnstypes.Record) string {
+	if record.Type == dnstypes.RecordTypeMX {
+		return record.Value
+	}
+	return record.FullValue()
+}
+
+// 修改负载均衡权重，只有开启了负载均衡的记录才能修改成功
+func (this *AliDNSProvider) updateWeight(recordId string, weight int32) error {
+	if weight <= 0 || len(recordId) == 0 {
+		return nil
+	}
+
+	req := alidns.CreateUpdateDNSSLBWeightRequest()
+	req.RecordId = recordId
+	req.Weight = requests.NewInteger(int(weight))
+
+	resp := alidns.CreateUpdateDNSSLBWeightResponse()
+	return this.doAPI(req, resp)
+}
+
 // 执行请求
 func (this *AliDNSProvider) doAPI(req requests.AcsRequest, resp responses.AcsResponse) error {
 	req.SetScheme("https")
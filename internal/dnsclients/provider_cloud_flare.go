@@ -10,6 +10,7 @@ import (
 	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
 	"github.com/iwind/TeaGo/maps"
+	"github.com/iwind/TeaGo/types"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -89,11 +90,13 @@ func (this *CloudFlareProvider) GetRecords(domain string) (records []*dnstypes.R
 			record.Name = strings.TrimSuffix(record.Name, "."+domain)
 
 			records = append(records, &dnstypes.Record{
-				Id:    record.Id,
-				Name:  record.Name,
-				Type:  record.Type,
-				Value: record.Content,
-				Route: CloudFlareDefaultRoute,
+				Id:       record.Id,
+				Name:     record.Name,
+				Type:     record.Type,
+				Value:    record.Content,
+				Route:    CloudFlareDefaultRoute,
+				TTL:      this.decodeTTL(record.Ttl),
+				Priority: int32(record.Priority),
 			})
 		}
 	}
@@ -139,11 +142,13 @@ func (this *CloudFlareProvider) QueryRecord(domain string, name string, recordTy
 	record.Name = strings.TrimSuffix(record.Name, "."+domain)
 
 	return &dnstypes.Record{
-		Id:    record.Id,
-		Name:  record.Name,
-		Type:  record.Type,
-		Value: record.Content,
-		Route: CloudFlareDefaultRoute,
+		Id:       record.Id,
+		Name:     record.Name,
+		Type:     record.Type,
+		Value:    record.Content,
+		Route:    CloudFlareDefaultRoute,
+		TTL:      this.decodeTTL(record.Ttl),
+		Priority: int32(record.Priority),
 	}, nil
 }
 
@@ -155,12 +160,7 @@ func (this *CloudFlareProvider) AddRecord(domain string, newRecord *dnstypes.Rec
 	}
 
 	resp := new(cloudflare.CreateDNSRecordResponse)
-	err = this.doAPI(http.MethodPost, "zones/"+zoneId+"/dns_records", nil, maps.Map{
-		"type":    newRecord.Type,
-		"name":    newRecord.Name + "." + domain,
-		"content": newRecord.Value,
-		"ttl":     1,
-	}, resp)
+	err = this.doAPI(http.MethodPost, "zones/"+zoneId+"/dns_records", nil, this.recordParams(domain, newRecord), resp)
 	if err != nil {
 		return err
 	}
@@ -175,12 +175,7 @@ func (this *CloudFlareProvider) UpdateRecord(domain string, record *dnstypes.Rec
 	}
 
 	resp := new(cloudflare.UpdateDNSRecordResponse)
-	return this.doAPI(http.MethodPut, "zones/"+zoneId+"/dns_records/"+record.Id, nil, maps.Map{
-		"type":    newRecord.Type,
-		"name":    newRecord.Name + "." + domain,
-		"content": newRecord.Value,
-		"ttl":     1,
-	}, resp)
+	return this.doAPI(http.MethodPut, "zones/"+zoneId+"/dns_records/"+record.Id, nil, this.recordParams(domain, newRecord), resp)
 }
 
 // DeleteRecord 删除记录
@@ -203,6 +198,64 @@ func (this *CloudFlareProvider) DefaultRoute() string {
 	return CloudFlareDefaultRoute
 }
 
+// 记录参数
+// SRV和CAA记录需要使用结构化的data参数
+func (this *CloudFlareProvider) recordParams(domain string, record *dnstypes.Record) maps.Map {
+	var ttl = int(record.TTL)
+	if ttl <= 0 {
+		ttl = 1 // 1表示自动
+	}
+	var params = maps.Map{
+		"type":    record.Type,
+		"name":    record.Name + "." + domain,
+		"content": record.Value,
+		"ttl":     ttl,
+	}
+
+	switch record.Type {
+	case dnstypes.RecordTypeMX:
+		params["priority"] = record.Priority
+	case dnstypes.RecordTypeSRV:
+		// 记录名：_service._proto.name
+		var pieces = strings.SplitN(record.Name, ".", 3)
+		var fields = strings.Fields(record.Value)
+		if len(pieces) >= 2 && len(fields) == 3 {
+			var name = domain
+			if len(pieces) == 3 {
+				name = pieces[2] + "." + domain
+			}
+			params["data"] = maps.Map{
+				"service":  pieces[0],
+				"proto":    pieces[1],
+				"name":     name,
+				"priority": record.Priority,
+				"weight":   types.Int(fields[0]),
+				"port":     types.Int(fields[1]),
+				"target":   fields[2],
+			}
+		}
+	case dnstypes.RecordTypeCAA:
+		var fields = strings.SplitN(record.Value, " ", 3)
+		if len(fields) == 3 {
+			params["data"] = maps.Map{
+				"flags": types.Int(fields[0]),
+				"tag":   fields[1],
+				"value": strings.Trim(fields[2], "\""),
+			}
+		}
+	}
+
+	return params
+}
+
+// TTL为1时表示自动
+func (this *CloudFlareProvider) decodeTTL(ttl int) int32 {
+	if ttl <= 1 {
+		return 0
+	}
+	return int32(ttl)
+}
+
 // 执行API
 func (this *CloudFlareProvider) doAPI(method string, apiPath string, args map[string]string, bodyMap maps.Map, respPtr cloudflare.ResponseInterface) error {
 	apiURL := CloudFlareAPIEndpoint + strings.TrimLeft(apiPath, "/")
@@ -26,6 +26,8 @@ var customHTTPClient = &http.Client{
 }
 
 // CustomHTTPProvider HTTP自定义DNS
+// 记录以JSON格式传递，包含 id、name、type、value、route、ttl、weight、priority 等字段，
+// 其中MX和SRV记录的优先级放在 priority 字段中，不包含在 value 中
 type CustomHTTPProvider struct {
 	url    string
 	secret string
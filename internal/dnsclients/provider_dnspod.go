@@ -54,13 +54,21 @@ func (this *DNSPodProvider) GetRecords(domain string) (records []*dnstypes.Recor
 		recordSlice := recordsResp.GetSlice("records")
 		for _, record := range recordSlice {
 			recordMap := maps.NewMap(record)
-			records = append(records, &dnstypes.Record{
-				Id:    recordMap.GetString("id"),
-				Name:  recordMap.GetString("name"),
-				Type:  recordMap.GetString("type"),
-				Value: recordMap.GetString("value"),
-				Route: recordMap.GetString("line"),
-			})
+			var newRecord = &dnstypes.Record{
+				Id:     recordMap.GetString("id"),
+				Name:   recordMap.GetString("name"),
+				Type:   recordMap.GetString("type"),
+				Route:  recordMap.GetString("line"),
+				TTL:    recordMap.GetInt32("ttl"),
+				Weight: recordMap.GetInt32("weight"),
+			}
+			if newRecord.Type == dnstypes.RecordTypeMX {
+				newRecord.Value = recordMap.GetString("value")
+				newRecord.Priority = recordMap.GetInt32("mx")
+			} else {
+				newRecord.SetFullValue(recordMap.GetString("value"))
+			}
+			records = append(records, newRecord)
 		}
 
 		// 检查是否到头
@@ -131,13 +139,14 @@ func (this *DNSPodProvider) AddRecord(domain string, newRecord *dnstypes.Record)
 	if newRecord.Type == dnstypes.RecordTypeCNAME && !strings.HasSuffix(newRecord.Value, ".") {
 		newRecord.Value += "."
 	}
-	_, err := this.post("/Record.Create", map[string]string{
+	var params = map[string]string{
 		"domain":      domain,
 		"sub_domain":  newRecord.Name,
 		"record_type": newRecord.Type,
-		"value":       newRecord.Value,
 		"record_line": newRecord.Route,
-	})
+	}
+	this.setRecordParams(params, newRecord)
+	_, err := this.post("/Record.Create", params)
 	return err
 }
 
@@ -154,14 +163,15 @@ func (this *DNSPodProvider) UpdateRecord(domain string, record *dnstypes.Record,
 	if newRecord.Type == dnstypes.RecordTypeCNAME && !strings.HasSuffix(newRecord.Value, ".") {
 		newRecord.Value += "."
 	}
-	_, err := this.post("/Record.Modify", map[string]string{
+	var params = map[string]string{
 		"domain":      domain,
 		"record_id":   record.Id,
 		"sub_domain":  newRecord.Name,
 		"record_type": newRecord.Type,
-		"value":       newRecord.Value,
 		"record_line": newRecord.Route,
-	})
+	}
+	this.setRecordParams(params, newRecord)
+	_, err := this.post("/Record.Modify", params)
 	return err
 }
 
@@ -179,6 +189,23 @@ func (this *DNSPodProvider) DeleteRecord(domain string, record *dnstypes.Record)
 	return err
 }
 
+// 设置记录值、TTL、权重等参数
+// MX记录的优先级使用单独的参数，SRV记录的优先级需要放在记录值中
+func (this *DNSPodProvider) setRecordParams(params map[string]string, record *dnstypes.Record) {
+	if record.Type == dnstypes.RecordTypeMX {
+		params["value"] = record.Value
+		params["mx"] = types.String(record.Priority)
+	} else {
+		params["value"] = record.FullValue()
+	}
+	if record.TTL > 0 {
+		params["ttl"] = types.String(record.TTL)
+	}
+	if record.Weight > 0 {
+		params["weight"] = types.String(record.Weight)
+	}
+}
+
 // 发送请求
 func (this *DNSPodProvider) post(path string, params map[string]string) (maps.Map, error) {
 	apiHost := "https://dnsapi.cn"
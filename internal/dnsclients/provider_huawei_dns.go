@@ -79,13 +79,16 @@ func (this *HuaweiDNSProvider) GetRecords(domain string) (records []*dnstypes.Re
 			for _, value := range recordSet.Records {
 				name := strings.TrimSuffix(recordSet.Name, "."+domain+".")
 
-				records = append(records, &dnstypes.Record{
-					Id:    recordSet.Id + "@" + value,
-					Name:  name,
-					Type:  recordSet.Type,
-					Value: value,
-					Route: recordSet.Line,
-				})
+				var record = &dnstypes.Record{
+					Id:     recordSet.Id + "@" + value,
+					Name:   name,
+					Type:   recordSet.Type,
+					Route:  recordSet.Line,
+					TTL:    int32(recordSet.Ttl),
+					Weight: int32(recordSet.Weight),
+				}
+				record.SetFullValue(value)
+				records = append(records, record)
 			}
 		}
 	}
@@ -1299,13 +1302,16 @@ func (this *HuaweiDNSProvider) QueryRecord(domain string, name string, recordTyp
 		return nil, nil
 	}
 
-	return &dnstypes.Record{
-		Id:    recordSet.Id + "@" + recordSet.Records[0],
-		Name:  name,
-		Type:  recordType,
-		Value: recordSet.Records[0],
-		Route: recordSet.Line,
-	}, nil
+	var record = &dnstypes.Record{
+		Id:     recordSet.Id + "@" + recordSet.Records[0],
+		Name:   name,
+		Type:   recordType,
+		Route:  recordSet.Line,
+		TTL:    int32(recordSet.Ttl),
+		Weight: int32(recordSet.Weight),
+	}
+	record.SetFullValue(recordSet.Records[0])
+	return record, nil
 }
 
 // AddRecord 设置记录
@@ -1316,18 +1322,12 @@ func (this *HuaweiDNSProvider) AddRecord(domain string, newRecord *dnstypes.Reco
 	}
 
 	var resp = new(huaweidns.ZonesCreateRecordSetResponse)
-	err = this.doAPI(http.MethodPost, "/v2.1/zones/"+zoneId+"/recordsets", map[string]string{}, maps.Map{
-		"name":        newRecord.Name + "." + domain + ".",
-		"description": "CDN系统自动创建",
-		"type":        newRecord.Type,
-		"records":     []string{newRecord.Value},
-		"line":        newRecord.Route,
-	}, resp)
+	err = this.doAPI(http.MethodPost, "/v2.1/zones/"+zoneId+"/recordsets", map[string]string{}, this.recordSetParams(domain, newRecord), resp)
 	if err != nil {
 		return err
 	}
 
-	newRecord.Id = resp.Id + "@" + newRecord.Value
+	newRecord.Id = resp.Id + "@" + newRecord.FullValue()
 
 	return nil
 }
@@ -1348,13 +1348,8 @@ func (this *HuaweiDNSProvider) UpdateRecord(domain string, record *dnstypes.Reco
 	}
 
 	var resp = new(huaweidns.ZonesUpdateRecordSetResponse)
-	err = this.doAPI(http.MethodPut, "/v2.1/zones/"+zoneId+"/recordsets/"+recordId, map[string]string{}, maps.Map{
-		"name":        newRecord.Name + "." + domain + ".",
-		"description": "CDN系统自动创建",
-		"type":        newRecord.Type,
-		"records":     []string{newRecord.Value},
-		"line":        newRecord.Route, // TODO 华为云此API无法修改线路，API地址：https://support.huaweicloud.com/api-dns/dns_api_65006.html
-	}, resp)
+	// TODO 华为云此API无法修改线路，API地址：https://support.huaweicloud.com/api-dns/dns_api_65006.html
+	err = this.doAPI(http.MethodPut, "/v2.1/zones/"+zoneId+"/recordsets/"+recordId, map[string]string{}, this.recordSetParams(domain, newRecord), resp)
 	if err != nil {
 		return err
 	}
@@ -1391,6 +1386,24 @@ func (this *HuaweiDNSProvider) DefaultRoute() string {
 	return "default_view"
 }
 
+// 记录集参数，MX和SRV记录的优先级需要放在记录值中
+func (this *HuaweiDNSProvider) recordSetParams(domain string, record *dnstypes.Record) maps.Map {
+	var params = maps.Map{
+		"name":        record.Name + "." + domain + ".",
+		"description": "CDN系统自动创建",
+		"type":        record.Type,
+		"records":     []string{record.FullValue()},
+		"line":        record.Route,
+	}
+	if record.TTL > 0 {
+		params["ttl"] = record.TTL
+	}
+	if record.Weight > 0 {
+		params["weight"] = record.Weight
+	}
+	return params
+}
+
 func (this *HuaweiDNSProvider) doAPI(method string, apiPath string, args map[string]string, bodyMap maps.Map, respPtr interface{}) error {
 	apiURL := HuaweiDNSEndpoint + strings.TrimLeft(apiPath, "/")
 	u, err := url.Parse(HuaweiDNSEndpoint)
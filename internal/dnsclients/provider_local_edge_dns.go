@@ -64,13 +64,7 @@ func (this *LocalEdgeDNSProvider) GetRecords(domain string) (records []*dnstypes
 			if len(routeIds) == 0 {
 				routeIds = []string{dnsconfigs.DefaultRouteCode}
 			}
-			records = append(records, &dnstypes.Record{
-				Id:    fmt.Sprintf("%d", record.Id),
-				Name:  record.Name,
-				Type:  record.Type,
-				Value: record.Value,
-				Route: routeIds[0],
-			})
+			records = append(records, this.convertRecord(record, routeIds[0]))
 		}
 
 		offset += size
@@ -164,13 +158,7 @@ func (this *LocalEdgeDNSProvider) QueryRecord(domain string, name string, record
 		routeIdString = dnsconfigs.DefaultRouteCode
 	}
 
-	return &dnstypes.Record{
-		Id:    fmt.Sprintf("%d", record.Id),
-		Name:  record.Name,
-		Type:  record.Type,
-		Value: record.Value,
-		Route: routeIdString,
-	}, nil
+	return this.convertRecord(record, routeIdString), nil
 }
 
 // AddRecord 设置记录
@@ -189,11 +177,18 @@ func (this *LocalEdgeDNSProvider) AddRecord(domain string, newRecord *dnstypes.R
 		routeIds = append(routeIds, newRecord.Route)
 	}
 
-	_, err = nameservers.SharedNSRecordDAO.CreateRecord(tx, domainId, "", newRecord.Name, newRecord.Type, newRecord.Value, this.ttl, routeIds)
+	recordId, err := nameservers.SharedNSRecordDAO.CreateRecord(tx, domainId, "", newRecord.Name, newRecord.Type, newRecord.FullValue(), this.recordTTL(newRecord), routeIds)
 	if err != nil {
 		return err
 	}
 
+	if newRecord.Weight > 0 {
+		err = nameservers.SharedNSRecordDAO.UpdateRecordWeight(tx, recordId, newRecord.Weight)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -213,21 +208,29 @@ func (this *LocalEdgeDNSProvider) UpdateRecord(domain string, record *dnstypes.R
 		routeIds = append(routeIds, newRecord.Route)
 	}
 
+	var recordId int64
 	if len(record.Id) > 0 {
-		err = nameservers.SharedNSRecordDAO.UpdateRecord(tx, types.Int64(record.Id), "", newRecord.Name, newRecord.Type, newRecord.Value, this.ttl, routeIds, true)
-		if err != nil {
-			return err
-		}
+		recordId = types.Int64(record.Id)
 	} else {
 		realRecord, err := nameservers.SharedNSRecordDAO.FindEnabledRecordWithName(tx, domainId, record.Name, record.Type)
 		if err != nil {
 			return err
 		}
-		if realRecord != nil {
-			err = nameservers.SharedNSRecordDAO.UpdateRecord(tx, types.Int64(realRecord.Id), "", newRecord.Name, newRecord.Type, newRecord.Value, this.ttl, routeIds, true)
-			if err != nil {
-				return err
-			}
+		if realRecord == nil {
+			return nil
+		}
+		recordId = int64(realRecord.Id)
+	}
+
+	err = nameservers.SharedNSRecordDAO.UpdateRecord(tx, recordId, "", newRecord.Name, newRecord.Type, newRecord.FullValue(), this.recordTTL(newRecord), routeIds, true)
+	if err != nil {
+		return err
+	}
+
+	if newRecord.Weight != record.Weight {
+		err = nameservers.SharedNSRecordDAO.UpdateRecordWeight(tx, recordId, newRecord.Weight)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -270,3 +273,26 @@ func (this *LocalEdgeDNSProvider) DeleteRecord(domain string, record *dnstypes.R
 func (this *LocalEdgeDNSProvider) DefaultRoute() string {
 	return "default"
 }
+
+// 转换为通用的记录
+// MX和SRV记录的优先级保存在记录值中
+func (this *LocalEdgeDNSProvider) convertRecord(record *nameservers.NSRecord, route string) *dnstypes.Record {
+	var result = &dnstypes.Record{
+		Id:     fmt.Sprintf("%d", record.Id),
+		Name:   record.Name,
+		Type:   record.Type,
+		Route:  route,
+		TTL:    int32(record.Ttl),
+		Weight: int32(record.Weight),
+	}
+	result.SetFullValue(record.Value)
+	return result
+}
+
+// 记录的TTL，如果记录中没有指定，则使用默认的TTL
+func (this *LocalEdgeDNSProvider) recordTTL(record *dnstypes.Record) int32 {
+	if record.TTL > 0 {
+		return record.TTL
+	}
+	return this.ttl
+}
@@ -0,0 +1,412 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package dnsclients
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/powerdns"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/iwind/TeaGo/maps"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const PowerDNSDefaultRoute = "default"
+const PowerDNSDefaultTTL = 600
+
+var powerDNSHTTPClient = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+// 每个服务器上的每个区域一个锁，防止不同的Provider对象同时修改同一个记录集时互相覆盖
+var powerDNSZoneLockers = map[string]*sync.Mutex{} // apiURL/serverId/zone => locker
+var powerDNSZoneLockersLocker = &sync.Mutex{}
+
+// PowerDNSProvider 通过HTTP API管理PowerDNS权威服务器上的记录
+// PowerDNS以记录集（名称+类型）为单位修改记录，所以单条记录的增删改都需要先读取整个记录集
+type PowerDNSProvider struct {
+	BaseProvider
+
+	apiURL   string // API地址，比如 http://127.0.0.1:8081
+	apiKey   string // API密钥
+	serverId string // 服务器ID，默认为 localhost
+	ttl      int    // 新记录集的TTL
+}
+
+// Auth 认证
+func (this *PowerDNSProvider) Auth(params maps.Map) error {
+	this.apiURL = strings.TrimRight(params.GetString("apiURL"), "/")
+	if len(this.apiURL) == 0 {
+		return errors.New("'apiURL' should not be empty")
+	}
+	if !regexp.MustCompile(`(?i)^(http|https)://`).MatchString(this.apiURL) {
+		this.apiURL = "http://" + this.apiURL
+	}
+
+	this.apiKey = params.GetString("apiKey")
+	if len(this.apiKey) == 0 {
+		return errors.New("'apiKey' should not be empty")
+	}
+
+	this.serverId = params.GetString("serverId")
+	if len(this.serverId) == 0 {
+		this.serverId = "localhost"
+	}
+
+	this.ttl = params.GetInt("ttl")
+	if this.ttl <= 0 {
+		this.ttl = PowerDNSDefaultTTL
+	}
+
+	return nil
+}
+
+// GetRecords 获取域名解析记录列表
+func (this *PowerDNSProvider) GetRecords(domain string) (records []*dnstypes.Record, err error) {
+	zone, err := this.findZone(domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, rrset := range zone.RRSets {
+		for _, record := range rrset.Records {
+			records = append(records, this.convertRecord(domain, rrset, record))
+		}
+	}
+	return
+}
+
+// GetRoutes 读取域名支持的线路数据
+func (this *PowerDNSProvider) GetRoutes(domain string) (routes []*dnstypes.Route, err error) {
+	routes = []*dnstypes.Route{
+		{Name: "默认", Code: PowerDNSDefaultRoute},
+	}
+	return
+}
+
+// QueryRecord 查询单个记录
+func (this *PowerDNSProvider) QueryRecord(domain string, name string, recordType dnstypes.RecordType) (*dnstypes.Record, error) {
+	zone, err := this.findZone(domain)
+	if err != nil {
+		return nil, err
+	}
+	rrset := zone.FindRRSet(this.fqdn(name, domain), recordType)
+	if rrset == nil || len(rrset.Records) == 0 {
+		return nil, nil
+	}
+	return this.convertRecord(domain, rrset, rrset.Records[0]), nil
+}
+
+// AddRecord 设置记录
+func (this *PowerDNSProvider) AddRecord(domain string, newRecord *dnstypes.Record) error {
+	defer this.lockZone(domain).Unlock()
+
+	zone, err := this.findZone(domain)
+	if err != nil {
+		return err
+	}
+
+	var name = this.fqdn(newRecord.Name, domain)
+	var rrset = this.cloneRRSet(zone.FindRRSet(name, newRecord.Type), name, newRecord.Type)
+	var ttlChanged = this.setTTL(rrset, newRecord.TTL)
+	if !this.addContent(rrset, this.encodeContent(newRecord)) && !ttlChanged {
+		// 记录已存在
+		return nil
+	}
+	return this.patchZone(zone.Id, []*powerdns.RRSet{rrset})
+}
+
+// UpdateRecord 修改记录
+func (this *PowerDNSProvider) UpdateRecord(domain string, record *dnstypes.Record, newRecord *dnstypes.Record) error {
+	defer this.lockZone(domain).Unlock()
+
+	zone, err := this.findZone(domain)
+	if err != nil {
+		return err
+	}
+
+	oldName, oldType, oldContent := this.decodeRecordId(domain, record)
+	var newName = this.fqdn(newRecord.Name, domain)
+	var newContent = this.encodeContent(newRecord)
+
+	var oldRRSet = this.cloneRRSet(zone.FindRRSet(oldName, oldType), oldName, oldType)
+	this.removeContent(oldRRSet, oldContent)
+
+	// 在同一个记录集中修改
+	if oldName == newName && oldType == newRecord.Type {
+		this.setTTL(oldRRSet, newRecord.TTL)
+		this.addContent(oldRRSet, newContent)
+		return this.patchZone(zone.Id, []*powerdns.RRSet{oldRRSet})
+	}
+
+	var newRRSet = this.cloneRRSet(zone.FindRRSet(newName, newRecord.Type), newName, newRecord.Type)
+	this.setTTL(newRRSet, newRecord.TTL)
+	this.addContent(newRRSet, newContent)
+	return this.patchZone(zone.Id, []*powerdns.RRSet{oldRRSet, newRRSet})
+}
+
+// DeleteRecord 删除记录
+func (this *PowerDNSProvider) DeleteRecord(domain string, record *dnstypes.Record) error {
+	defer this.lockZone(domain).Unlock()
+
+	zone, err := this.findZone(domain)
+	if err != nil {
+		return err
+	}
+
+	name, recordType, content := this.decodeRecordId(domain, record)
+	var existRRSet = zone.FindRRSet(name, recordType)
+	if existRRSet == nil {
+		return nil
+	}
+	var rrset = this.cloneRRSet(existRRSet, name, recordType)
+	if !this.removeContent(rrset, content) {
+		return nil
+	}
+	return this.patchZone(zone.Id, []*powerdns.RRSet{rrset})
+}
+
+// DefaultRoute 默认线路
+func (this *PowerDNSProvider) DefaultRoute() string {
+	return PowerDNSDefaultRoute
+}
+
+// 锁定域名对应的区域，返回的锁需要在修改完成后解锁
+func (this *PowerDNSProvider) lockZone(domain string) *sync.Mutex {
+	var key = this.apiURL + "/" + this.serverId + "/" + this.canonical(domain)
+
+	powerDNSZoneLockersLocker.Lock()
+	locker, ok := powerDNSZoneLockers[key]
+	if !ok {
+		locker = &sync.Mutex{}
+		powerDNSZoneLockers[key] = locker
+	}
+	powerDNSZoneLockersLocker.Unlock()
+
+	locker.Lock()
+	return locker
+}
+
+// 查找域名对应的区域，包含所有的记录集
+func (this *PowerDNSProvider) findZone(domain string) (*powerdns.ZoneResponse, error) {
+	var zone = &powerdns.ZoneResponse{}
+	err := this.doAPI(http.MethodGet, "zones/"+url.PathEscape(this.canonical(domain)), nil, zone)
+	if err != nil {
+		return nil, err
+	}
+	if len(zone.Id) == 0 {
+		return nil, errors.New("can not found zone for domain '" + domain + "'")
+	}
+	return zone, nil
+}
+
+// 提交记录集变更，记录集中没有记录时删除记录集
+func (this *PowerDNSProvider) patchZone(zoneId string, rrsets []*powerdns.RRSet) error {
+	for _, rrset := range rrsets {
+		if len(rrset.Records) == 0 {
+			rrset.ChangeType = powerdns.ChangeTypeDelete
+			rrset.TTL = 0
+		} else {
+			rrset.ChangeType = powerdns.ChangeTypeReplace
+		}
+	}
+	return this.doAPI(http.MethodPatch, "zones/"+url.PathEscape(zoneId), &powerdns.PatchZoneRequest{RRSets: rrsets}, nil)
+}
+
+// 执行API
+func (this *PowerDNSProvider) doAPI(method string, apiPath string, body interface{}, respPtr interface{}) error {
+	var apiURL = this.apiURL + "/api/v1/servers/" + url.PathEscape(this.serverId) + "/" + strings.TrimLeft(apiPath, "/")
+
+	var bodyReader io.Reader = nil
+	if body != nil {
+		bodyData, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(bodyData)
+	}
+
+	req, err := http.NewRequest(method, apiURL, bodyReader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-API-Key", this.apiKey)
+	resp, err := powerDNSHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 400 {
+		var errorResp = &powerdns.ErrorResponse{}
+		if json.Unmarshal(data, errorResp) == nil && len(errorResp.Error) > 0 {
+			return errors.New("response error: " + errorResp.Error)
+		}
+		return errors.New("invalid response status '" + strconv.Itoa(resp.StatusCode) + "', response '" + string(data) + "'")
+	}
+
+	if respPtr != nil && len(data) > 0 {
+		err = json.Unmarshal(data, respPtr)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 转换为通用的记录
+func (this *PowerDNSProvider) convertRecord(domain string, rrset *powerdns.RRSet, record *powerdns.Record) *dnstypes.Record {
+	var name = strings.TrimSuffix(rrset.Name, ".")
+	if name == domain {
+		name = "@"
+	} else {
+		name = strings.TrimSuffix(name, "."+domain)
+	}
+
+	var result = &dnstypes.Record{
+		Id:    rrset.Name + "$" + rrset.Type + "$" + record.Content,
+		Name:  name,
+		Type:  rrset.Type,
+		Route: PowerDNSDefaultRoute,
+		TTL:   int32(rrset.TTL),
+	}
+	result.SetFullValue(this.decodeContent(rrset.Type, record.Content))
+	return result
+}
+
+// 从记录ID中读取记录集名称、类型和记录内容
+func (this *PowerDNSProvider) decodeRecordId(domain string, record *dnstypes.Record) (name string, recordType string, content string) {
+	var pieces = strings.SplitN(record.Id, "$", 3)
+	if len(pieces) == 3 {
+		return pieces[0], pieces[1], pieces[2]
+	}
+	return this.fqdn(record.Name, domain), record.Type, this.encodeContent(record)
+}
+
+// 复制记录集，如果记录集不存在则创建一个新的
+func (this *PowerDNSProvider) cloneRRSet(rrset *powerdns.RRSet, name string, recordType string) *powerdns.RRSet {
+	var result = &powerdns.RRSet{
+		Name: name,
+		Type: recordType,
+		TTL:  this.ttl,
+	}
+	if rrset != nil {
+		if rrset.TTL > 0 {
+			result.TTL = rrset.TTL
+		}
+		for _, record := range rrset.Records {
+			result.Records = append(result.Records, &powerdns.Record{
+				Content:  record.Content,
+				Disabled: record.Disabled,
+			})
+		}
+	}
+	return result
+}
+
+// 设置记录集的TTL，如果TTL有变化则返回true
+func (this *PowerDNSProvider) setTTL(rrset *powerdns.RRSet, ttl int32) bool {
+	if ttl <= 0 || rrset.TTL == int(ttl) {
+		return false
+	}
+	rrset.TTL = int(ttl)
+	return true
+}
+
+// 添加记录，如果记录已存在则返回false
+func (this *PowerDNSProvider) addContent(rrset *powerdns.RRSet, content string) bool {
+	for _, record := range rrset.Records {
+		if record.Content == content {
+			return false
+		}
+	}
+	rrset.Records = append(rrset.Records, &powerdns.Record{Content: content})
+	return true
+}
+
+// 删除记录，如果记录不存在则返回false
+func (this *PowerDNSProvider) removeContent(rrset *powerdns.RRSet, content string) bool {
+	var found = false
+	var records = []*powerdns.Record{}
+	for _, record := range rrset.Records {
+		if record.Content == content {
+			found = true
+			continue
+		}
+		records = append(records, record)
+	}
+	rrset.Records = records
+	return found
+}
+
+// 完整的域名，以点结尾
+func (this *PowerDNSProvider) fqdn(name string, domain string) string {
+	if len(name) == 0 || name == "@" {
+		return this.canonical(domain)
+	}
+	return this.canonical(name + "." + domain)
+}
+
+func (this *PowerDNSProvider) canonical(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// 将记录值转换为PowerDNS中的记录内容
+// MX和SRV记录的优先级需要放在记录内容中
+func (this *PowerDNSProvider) encodeContent(record *dnstypes.Record) string {
+	var value = record.FullValue()
+	switch record.Type {
+	case dnstypes.RecordTypeCNAME, dnstypes.RecordTypeNS, dnstypes.RecordTypeMX, dnstypes.RecordTypeSRV:
+		return this.canonical(value)
+	case dnstypes.RecordTypeTXT:
+		if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") && len(value) >= 2 {
+			return value
+		}
+		return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(value) + "\""
+	}
+	return value
+}
+
+// 将PowerDNS中的记录内容转换为记录值
+func (this *PowerDNSProvider) decodeContent(recordType string, content string) string {
+	if recordType != dnstypes.RecordTypeTXT || !strings.HasPrefix(content, "\"") {
+		return content
+	}
+
+	// TXT记录可能由多个字符串组成："abc" "def"
+	var result = &strings.Builder{}
+	var inQuote = false
+	for i := 0; i < len(content); i++ {
+		var c = content[i]
+		switch {
+		case c == '\\' && inQuote && i+1 < len(content):
+			i++
+			result.WriteByte(content[i])
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+			result.WriteByte(c)
+		}
+	}
+	return result.String()
+}
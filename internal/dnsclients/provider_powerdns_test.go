@@ -0,0 +1,313 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package dnsclients
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/powerdns"
+	"github.com/iwind/TeaGo/maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// 模拟PowerDNS API
+func newPowerDNSTestServer(t *testing.T) (*httptest.Server, *powerdns.ZoneResponse) {
+	var zone = &powerdns.ZoneResponse{
+		Id:   "example.com.",
+		Name: "example.com.",
+		Kind: "Native",
+		RRSets: []*powerdns.RRSet{
+			{
+				Name:    "example.com.",
+				Type:    "SOA",
+				TTL:     3600,
+				Records: []*powerdns.Record{{Content: "ns1.example.com. admin.example.com. 1 10800 3600 604800 3600"}},
+			},
+			{
+				Name:    "www.example.com.",
+				Type:    "A",
+				TTL:     60,
+				Records: []*powerdns.Record{{Content: "1.1.1.1"}, {Content: "2.2.2.2"}},
+			},
+		},
+	}
+	var locker sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		locker.Lock()
+		defer locker.Unlock()
+
+		if req.Header.Get("X-API-Key") != "secret" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			_, _ = writer.Write([]byte(`{"error":"Unauthorized"}`))
+			return
+		}
+		if req.URL.Path != "/api/v1/servers/localhost/zones/example.com." {
+			writer.WriteHeader(http.StatusNotFound)
+			_, _ = writer.Write([]byte(`{"error":"Could not find domain"}`))
+			return
+		}
+
+		switch req.Method {
+		case http.MethodGet:
+			data, _ := json.Marshal(zone)
+			_, _ = writer.Write(data)
+		case http.MethodPatch:
+			var patchReq = &powerdns.PatchZoneRequest{}
+			err := json.NewDecoder(req.Body).Decode(patchReq)
+			if err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			for _, rrset := range patchReq.RRSets {
+				var rrsets = []*powerdns.RRSet{}
+				for _, old := range zone.RRSets {
+					if old.Name != rrset.Name || old.Type != rrset.Type {
+						rrsets = append(rrsets, old)
+					}
+				}
+				switch rrset.ChangeType {
+				case powerdns.ChangeTypeReplace:
+					if rrset.TTL <= 0 {
+						t.Log("ttl should be set for REPLACE")
+						writer.WriteHeader(http.StatusUnprocessableEntity)
+						return
+					}
+					rrset.ChangeType = ""
+					rrsets = append(rrsets, rrset)
+				case powerdns.ChangeTypeDelete:
+				default:
+					writer.WriteHeader(http.StatusUnprocessableEntity)
+					return
+				}
+				zone.RRSets = rrsets
+			}
+			writer.WriteHeader(http.StatusNoContent)
+		default:
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	return server, zone
+}
+
+func TestPowerDNSProvider_Records(t *testing.T) {
+	server, zone := newPowerDNSTestServer(t)
+	defer server.Close()
+
+	var provider = &PowerDNSProvider{}
+	err := provider.Auth(maps.Map{
+		"apiURL": server.URL,
+		"apiKey": "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := provider.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatal("expect 3 records, but got", len(records))
+	}
+
+	// 查询
+	record, err := provider.QueryRecord("example.com", "www", dnstypes.RecordTypeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Value != "1.1.1.1" || record.Name != "www" {
+		t.Fatal("invalid record:", record)
+	}
+
+	// 添加到已有的记录集中，需要保留TTL
+	err = provider.AddRecord("example.com", &dnstypes.Record{
+		Name:  "www",
+		Type:  dnstypes.RecordTypeA,
+		Value: "3.3.3.3",
+		Route: provider.DefaultRoute(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rrset = zone.FindRRSet("www.example.com.", "A")
+	if rrset == nil || len(rrset.Records) != 3 || rrset.TTL != 60 {
+		t.Fatal("invalid rrset after adding:", rrset)
+	}
+
+	// 修改
+	err = provider.UpdateRecord("example.com", record, &dnstypes.Record{
+		Name:  "www",
+		Type:  dnstypes.RecordTypeA,
+		Value: "4.4.4.4",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rrset = zone.FindRRSet("www.example.com.", "A")
+	var contents = []string{}
+	for _, r := range rrset.Records {
+		contents = append(contents, r.Content)
+	}
+	if strings.Join(contents, ",") != "2.2.2.2,3.3.3.3,4.4.4.4" {
+		t.Fatal("invalid contents after updating:", contents)
+	}
+
+	// CNAME
+	err = provider.AddRecord("example.com", &dnstypes.Record{
+		Name:  "cdn",
+		Type:  dnstypes.RecordTypeCNAME,
+		Value: "cdn.example.net",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cnameRecord, err := provider.QueryRecord("example.com", "cdn", dnstypes.RecordTypeCNAME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cnameRecord == nil || cnameRecord.Value != "cdn.example.net." {
+		t.Fatal("invalid cname record:", cnameRecord)
+	}
+	if zone.FindRRSet("cdn.example.com.", "CNAME").TTL != PowerDNSDefaultTTL {
+		t.Fatal("new rrset should use default ttl")
+	}
+
+	// 删除最后一条记录时删除整个记录集
+	err = provider.DeleteRecord("example.com", cnameRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zone.FindRRSet("cdn.example.com.", "CNAME") != nil {
+		t.Fatal("rrset should be deleted")
+	}
+}
+
+func TestPowerDNSProvider_ACME(t *testing.T) {
+	server, zone := newPowerDNSTestServer(t)
+	defer server.Close()
+
+	var provider = &PowerDNSProvider{}
+	err := provider.Auth(maps.Map{
+		"apiURL": server.URL,
+		"apiKey": "secret",
+		"ttl":    120,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 和 acme.DNSProvider 中的调用方式保持一致
+	for _, value := range []string{"first \"token\"", "second-token"} {
+		record, err := provider.QueryRecord("example.com", "_acme-challenge.www", dnstypes.RecordTypeTXT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record == nil {
+			err = provider.AddRecord("example.com", &dnstypes.Record{
+				Name:  "_acme-challenge.www",
+				Type:  dnstypes.RecordTypeTXT,
+				Value: value,
+				Route: provider.DefaultRoute(),
+			})
+		} else {
+			err = provider.UpdateRecord("example.com", record, &dnstypes.Record{
+				Name:  "_acme-challenge.www",
+				Type:  dnstypes.RecordTypeTXT,
+				Value: value,
+				Route: provider.DefaultRoute(),
+			})
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		record, err = provider.QueryRecord("example.com", "_acme-challenge.www", dnstypes.RecordTypeTXT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record == nil || record.Value != value {
+			t.Fatal("invalid txt record:", record)
+		}
+	}
+
+	var rrset = zone.FindRRSet("_acme-challenge.www.example.com.", "TXT")
+	if rrset == nil || len(rrset.Records) != 1 || rrset.Records[0].Content != `"second-token"` || rrset.TTL != 120 {
+		t.Fatal("invalid txt rrset:", rrset)
+	}
+}
+
+func TestPowerDNSProvider_Priority(t *testing.T) {
+	server, zone := newPowerDNSTestServer(t)
+	defer server.Close()
+
+	var provider = &PowerDNSProvider{}
+	err := provider.Auth(maps.Map{
+		"apiURL": server.URL,
+		"apiKey": "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = provider.AddRecord("example.com", &dnstypes.Record{
+		Name:     "@",
+		Type:     dnstypes.RecordTypeMX,
+		Value:    "mail.example.com",
+		Priority: 10,
+		TTL:      300,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rrset = zone.FindRRSet("example.com.", "MX")
+	if rrset == nil || len(rrset.Records) != 1 || rrset.Records[0].Content != "10 mail.example.com." || rrset.TTL != 300 {
+		t.Fatal("invalid mx rrset:", rrset)
+	}
+
+	record, err := provider.QueryRecord("example.com", "@", dnstypes.RecordTypeMX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Value != "mail.example.com." || record.Priority != 10 || record.TTL != 300 {
+		t.Fatal("invalid mx record:", record)
+	}
+
+	// 只修改TTL
+	err = provider.AddRecord("example.com", &dnstypes.Record{
+		Name:     "@",
+		Type:     dnstypes.RecordTypeMX,
+		Value:    "mail.example.com.",
+		Priority: 10,
+		TTL:      120,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rrset = zone.FindRRSet("example.com.", "MX")
+	if rrset == nil || len(rrset.Records) != 1 || rrset.TTL != 120 {
+		t.Fatal("invalid mx rrset after changing ttl:", rrset)
+	}
+}
+
+func TestPowerDNSProvider_Error(t *testing.T) {
+	server, _ := newPowerDNSTestServer(t)
+	defer server.Close()
+
+	var provider = &PowerDNSProvider{}
+	err := provider.Auth(maps.Map{
+		"apiURL": server.URL,
+		"apiKey": "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = provider.GetRecords("example.org")
+	if err == nil || !strings.Contains(err.Error(), "Could not find domain") {
+		t.Fatal("expect not found error, but got", err)
+	}
+}
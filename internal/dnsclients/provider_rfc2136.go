@@ -0,0 +1,359 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package dnsclients
+
+import (
+	"encoding/base64"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/iwind/TeaGo/maps"
+	"github.com/miekg/dns"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const RFC2136DefaultRoute = "default"
+const RFC2136DefaultTTL = 600
+
+// TSIG算法
+var rfc2136TSIGAlgorithms = map[string]string{
+	"hmac-md5":    dns.HmacMD5,
+	"hmac-sha1":   dns.HmacSHA1,
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+// RFC2136Provider 使用标准的DNS UPDATE消息（RFC 2136）管理记录，使用AXFR读取记录列表
+// 适用于BIND、Knot等支持动态更新的DNS服务器
+type RFC2136Provider struct {
+	BaseProvider
+
+	server        string // 服务器地址，host:port
+	tsigName      string // TSIG密钥名称
+	tsigSecret    string // TSIG密钥，Base64编码
+	tsigAlgorithm string // TSIG算法
+	ttl           int    // 新记录的TTL
+	timeout       time.Duration
+}
+
+// Auth 认证
+func (this *RFC2136Provider) Auth(params maps.Map) error {
+	this.server = params.GetString("server")
+	if len(this.server) == 0 {
+		return errors.New("'server' should not be empty")
+	}
+	_, _, err := net.SplitHostPort(this.server)
+	if err != nil {
+		this.server = net.JoinHostPort(strings.Trim(this.server, "[]"), "53")
+	}
+
+	this.tsigName = params.GetString("tsigName")
+	this.tsigSecret = params.GetString("tsigSecret")
+	if len(this.tsigName) > 0 {
+		if len(this.tsigSecret) == 0 {
+			return errors.New("'tsigSecret' should not be empty")
+		}
+		_, err = base64.StdEncoding.DecodeString(this.tsigSecret)
+		if err != nil {
+			return errors.New("'tsigSecret' should be base64 encoded")
+		}
+		this.tsigName = dns.Fqdn(strings.ToLower(this.tsigName))
+
+		var algorithm = strings.ToLower(params.GetString("tsigAlgorithm"))
+		if len(algorithm) == 0 {
+			algorithm = "hmac-sha256"
+		}
+		this.tsigAlgorithm = rfc2136TSIGAlgorithms[strings.TrimSuffix(algorithm, ".")]
+		if len(this.tsigAlgorithm) == 0 {
+			return errors.New("invalid 'tsigAlgorithm': " + algorithm)
+		}
+	}
+
+	this.ttl = params.GetInt("ttl")
+	if this.ttl <= 0 {
+		this.ttl = RFC2136DefaultTTL
+	}
+
+	this.timeout = 10 * time.Second
+
+	return nil
+}
+
+// GetRecords 获取域名解析记录列表
+func (this *RFC2136Provider) GetRecords(domain string) (records []*dnstypes.Record, err error) {
+	var msg = &dns.Msg{}
+	msg.SetAxfr(dns.Fqdn(domain))
+	this.sign(msg)
+
+	var transfer = &dns.Transfer{
+		DialTimeout:  this.timeout,
+		ReadTimeout:  this.timeout,
+		WriteTimeout: this.timeout,
+	}
+	if len(this.tsigName) > 0 {
+		transfer.TsigSecret = map[string]string{this.tsigName: this.tsigSecret}
+	}
+	envelopes, err := transfer.In(msg, this.server)
+	if err != nil {
+		return nil, err
+	}
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, envelope.Error
+		}
+		for _, rr := range envelope.RR {
+			// 忽略SOA记录，SOA记录在AXFR的开头和结尾各出现一次
+			if rr.Header().Rrtype == dns.TypeSOA {
+				continue
+			}
+			if len(dns.TypeToString[rr.Header().Rrtype]) == 0 {
+				continue
+			}
+			records = append(records, this.convertRecord(domain, rr))
+		}
+	}
+	return
+}
+
+// GetRoutes 读取域名支持的线路数据
+func (this *RFC2136Provider) GetRoutes(domain string) (routes []*dnstypes.Route, err error) {
+	routes = []*dnstypes.Route{
+		{Name: "默认", Code: RFC2136DefaultRoute},
+	}
+	return
+}
+
+// QueryRecord 查询单个记录
+func (this *RFC2136Provider) QueryRecord(domain string, name string, recordType dnstypes.RecordType) (*dnstypes.Record, error) {
+	rrType, ok := dns.StringToType[recordType]
+	if !ok {
+		return nil, errors.New("invalid record type '" + recordType + "'")
+	}
+
+	var msg = &dns.Msg{}
+	msg.SetQuestion(this.fqdn(name, domain), rrType)
+	msg.RecursionDesired = false
+
+	resp, err := this.exchange(msg)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rcode == dns.RcodeNameError {
+		return nil, nil
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return nil, errors.New("query failed: " + dns.RcodeToString[resp.Rcode])
+	}
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype == rrType {
+			return this.convertRecord(domain, rr), nil
+		}
+	}
+	return nil, nil
+}
+
+// AddRecord 设置记录
+func (this *RFC2136Provider) AddRecord(domain string, newRecord *dnstypes.Record) error {
+	rr, err := this.newRR(domain, newRecord)
+	if err != nil {
+		return err
+	}
+
+	var msg = &dns.Msg{}
+	msg.SetUpdate(dns.Fqdn(domain))
+	msg.Insert([]dns.RR{rr})
+	return this.update(msg)
+}
+
+// UpdateRecord 修改记录
+// 删除旧记录和添加新记录在同一个UPDATE消息中完成
+func (this *RFC2136Provider) UpdateRecord(domain string, record *dnstypes.Record, newRecord *dnstypes.Record) error {
+	oldRR, err := this.recordRR(domain, record)
+	if err != nil {
+		return err
+	}
+	newRR, err := this.newRR(domain, newRecord)
+	if err != nil {
+		return err
+	}
+
+	var msg = &dns.Msg{}
+	msg.SetUpdate(dns.Fqdn(domain))
+	msg.Remove([]dns.RR{oldRR})
+	msg.Insert([]dns.RR{newRR})
+	return this.update(msg)
+}
+
+// DeleteRecord 删除记录
+func (this *RFC2136Provider) DeleteRecord(domain string, record *dnstypes.Record) error {
+	rr, err := this.recordRR(domain, record)
+	if err != nil {
+		return err
+	}
+
+	var msg = &dns.Msg{}
+	msg.SetUpdate(dns.Fqdn(domain))
+	msg.Remove([]dns.RR{rr})
+	return this.update(msg)
+}
+
+// DefaultRoute 默认线路
+func (this *RFC2136Provider) DefaultRoute() string {
+	return RFC2136DefaultRoute
+}
+
+// 发送UPDATE消息
+func (this *RFC2136Provider) update(msg *dns.Msg) error {
+	this.sign(msg)
+	resp, err := this.exchange(msg)
+	if err != nil {
+		return err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return errors.New("update failed: " + dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
+
+// 发送消息，如果UDP响应被截断则使用TCP重试
+func (this *RFC2136Provider) exchange(msg *dns.Msg) (*dns.Msg, error) {
+	var client = &dns.Client{
+		Net:     "udp",
+		Timeout: this.timeout,
+	}
+	if len(this.tsigName) > 0 {
+		client.TsigSecret = map[string]string{this.tsigName: this.tsigSecret}
+	}
+	resp, _, err := client.Exchange(msg, this.server)
+	if err == nil && resp.Truncated {
+		client.Net = "tcp"
+		resp, _, err = client.Exchange(msg, this.server)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// 使用TSIG签名
+func (this *RFC2136Provider) sign(msg *dns.Msg) {
+	if len(this.tsigName) > 0 {
+		msg.SetTsig(this.tsigName, this.tsigAlgorithm, 300, time.Now().Unix())
+	}
+}
+
+// 转换为通用的记录
+func (this *RFC2136Provider) convertRecord(domain string, rr dns.RR) *dnstypes.Record {
+	var header = rr.Header()
+
+	var value string
+	switch r := rr.(type) {
+	case *dns.A:
+		value = r.A.String()
+	case *dns.AAAA:
+		value = r.AAAA.String()
+	case *dns.CNAME:
+		value = r.Target
+	case *dns.TXT:
+		value = strings.Join(r.Txt, "")
+	default:
+		value = strings.TrimSpace(strings.TrimPrefix(rr.String(), header.String()))
+	}
+
+	var record = &dnstypes.Record{
+		Id:    rr.String(),
+		Name:  this.name(header.Name, domain),
+		Type:  dns.TypeToString[header.Rrtype],
+		Route: RFC2136DefaultRoute,
+		TTL:   int32(header.Ttl),
+	}
+	record.SetFullValue(value)
+	return record
+}
+
+// 根据记录ID还原记录，记录ID为记录的文本形式
+func (this *RFC2136Provider) recordRR(domain string, record *dnstypes.Record) (dns.RR, error) {
+	if len(record.Id) > 0 {
+		rr, err := dns.NewRR(record.Id)
+		if err == nil && rr != nil {
+			return rr, nil
+		}
+	}
+	return this.newRR(domain, record)
+}
+
+// 构造新的记录
+func (this *RFC2136Provider) newRR(domain string, record *dnstypes.Record) (dns.RR, error) {
+	var ttl = this.ttl
+	if record.TTL > 0 {
+		ttl = int(record.TTL)
+	}
+	var header = dns.RR_Header{
+		Name:   this.fqdn(record.Name, domain),
+		Class:  dns.ClassINET,
+		Ttl:    uint32(ttl),
+		Rrtype: dns.StringToType[record.Type],
+	}
+	if header.Rrtype == dns.TypeNone {
+		return nil, errors.New("invalid record type '" + record.Type + "'")
+	}
+
+	switch header.Rrtype {
+	case dns.TypeA:
+		var ip = net.ParseIP(record.Value)
+		if ip == nil || ip.To4() == nil {
+			return nil, errors.New("invalid A record value '" + record.Value + "'")
+		}
+		return &dns.A{Hdr: header, A: ip.To4()}, nil
+	case dns.TypeAAAA:
+		var ip = net.ParseIP(record.Value)
+		if ip == nil {
+			return nil, errors.New("invalid AAAA record value '" + record.Value + "'")
+		}
+		return &dns.AAAA{Hdr: header, AAAA: ip}, nil
+	case dns.TypeCNAME:
+		return &dns.CNAME{Hdr: header, Target: dns.Fqdn(record.Value)}, nil
+	case dns.TypeTXT:
+		return &dns.TXT{Hdr: header, Txt: this.splitTXT(record.Value)}, nil
+	}
+
+	// MX、SRV等记录的优先级需要放在记录值中
+	rr, err := dns.NewRR(header.Name + " " + strconv.Itoa(ttl) + " IN " + record.Type + " " + record.FullValue())
+	if err != nil {
+		return nil, errors.New("invalid record: " + err.Error())
+	}
+	if rr == nil {
+		return nil, errors.New("invalid record value '" + record.Value + "'")
+	}
+	return rr, nil
+}
+
+// TXT记录中单个字符串最长255字节
+func (this *RFC2136Provider) splitTXT(value string) []string {
+	var result = []string{}
+	for len(value) > 255 {
+		result = append(result, value[:255])
+		value = value[255:]
+	}
+	return append(result, value)
+}
+
+// 完整的域名，以点结尾
+func (this *RFC2136Provider) fqdn(name string, domain string) string {
+	if len(name) == 0 || name == "@" {
+		return dns.Fqdn(domain)
+	}
+	return dns.Fqdn(name + "." + domain)
+}
+
+// 相对于域名的记录名
+func (this *RFC2136Provider) name(fqdn string, domain string) string {
+	var name = strings.TrimSuffix(strings.ToLower(fqdn), ".")
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if name == domain {
+		return "@"
+	}
+	return strings.TrimSuffix(name, "."+domain)
+}
@@ -0,0 +1,418 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package dnsclients
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
+	"github.com/iwind/TeaGo/maps"
+	"github.com/miekg/dns"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const rfc2136TestKeyName = "edge-key."
+const rfc2136TestSecret = "c2VjcmV0LWtleS1mb3ItdGVzdGluZw=="
+
+// 用于测试的支持UPDATE和AXFR的DNS服务器
+type rfc2136TestServer struct {
+	zone    string
+	rrs     []dns.RR
+	locker  sync.Mutex
+	servers []*dns.Server
+	addr    string
+}
+
+func newRFC2136TestServer(t *testing.T, zone string) *rfc2136TestServer {
+	var server = &rfc2136TestServer{zone: dns.Fqdn(zone)}
+	for _, s := range []string{
+		"www." + server.zone + " 60 IN A 1.1.1.1",
+		"www." + server.zone + " 60 IN A 2.2.2.2",
+		server.zone + " 3600 IN NS ns1." + server.zone,
+	} {
+		rr, err := dns.NewRR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server.rrs = append(server.rrs, rr)
+	}
+
+	// UDP和TCP使用同一个端口
+	var packetConn net.PacketConn
+	var listener net.Listener
+	for i := 0; i < 10; i++ {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := net.Listen("tcp", conn.LocalAddr().String())
+		if err != nil {
+			_ = conn.Close()
+			continue
+		}
+		packetConn = conn
+		listener = l
+		break
+	}
+	if packetConn == nil {
+		t.Fatal("can not listen")
+	}
+	server.addr = packetConn.LocalAddr().String()
+
+	var secrets = map[string]string{rfc2136TestKeyName: rfc2136TestSecret}
+
+	// 默认不接受UPDATE消息
+	var acceptFunc = func(dh dns.Header) dns.MsgAcceptAction {
+		return dns.MsgAccept
+	}
+
+	var wg = &sync.WaitGroup{}
+	wg.Add(2)
+	for _, s := range []*dns.Server{
+		{PacketConn: packetConn, TsigSecret: secrets, Handler: server, NotifyStartedFunc: wg.Done, MsgAcceptFunc: acceptFunc},
+		{Listener: listener, TsigSecret: secrets, Handler: server, NotifyStartedFunc: wg.Done, MsgAcceptFunc: acceptFunc},
+	} {
+		server.servers = append(server.servers, s)
+		go func(s *dns.Server) {
+			_ = s.ActivateAndServe()
+		}(s)
+	}
+	wg.Wait()
+	return server
+}
+
+func (this *rfc2136TestServer) ServeDNS(writer dns.ResponseWriter, req *dns.Msg) {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	var resp = &dns.Msg{}
+	resp.SetReply(req)
+	resp.Authoritative = true
+
+	var tsig = req.IsTsig()
+	var needTSIG = req.Opcode == dns.OpcodeUpdate || (len(req.Question) > 0 && req.Question[0].Qtype == dns.TypeAXFR)
+	if needTSIG && (tsig == nil || writer.TsigStatus() != nil) {
+		resp.SetRcode(req, dns.RcodeNotAuth)
+		_ = writer.WriteMsg(resp)
+		return
+	}
+
+	switch {
+	case req.Opcode == dns.OpcodeUpdate:
+		for _, rr := range req.Ns {
+			if rr.Header().Class == dns.ClassNONE {
+				this.remove(rr)
+			} else {
+				this.insert(rr)
+			}
+		}
+	case req.Question[0].Qtype == dns.TypeAXFR:
+		soa, _ := dns.NewRR(this.zone + " 3600 IN SOA ns1." + this.zone + " admin." + this.zone + " 1 3600 600 86400 60")
+		var rrs = append([]dns.RR{soa}, this.rrs...)
+		rrs = append(rrs, soa)
+
+		var ch = make(chan *dns.Envelope)
+		var transfer = &dns.Transfer{}
+		var done = make(chan bool)
+		go func() {
+			_ = transfer.Out(writer, req, ch)
+			close(done)
+		}()
+		ch <- &dns.Envelope{RR: rrs}
+		close(ch)
+		<-done
+		return
+	default:
+		var question = req.Question[0]
+		var nameExists = false
+		for _, rr := range this.rrs {
+			if strings.EqualFold(rr.Header().Name, question.Name) {
+				nameExists = true
+				if rr.Header().Rrtype == question.Qtype {
+					resp.Answer = append(resp.Answer, rr)
+				}
+			}
+		}
+		if !nameExists {
+			resp.SetRcode(req, dns.RcodeNameError)
+		}
+	}
+
+	if tsig != nil {
+		resp.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+	}
+	_ = writer.WriteMsg(resp)
+}
+
+func (this *rfc2136TestServer) insert(rr dns.RR) {
+	for _, existRR := range this.rrs {
+		if this.equal(existRR, rr) {
+			return
+		}
+	}
+	this.rrs = append(this.rrs, rr)
+}
+
+func (this *rfc2136TestServer) remove(rr dns.RR) {
+	var rrs = []dns.RR{}
+	for _, existRR := range this.rrs {
+		if !this.equal(existRR, rr) {
+			rrs = append(rrs, existRR)
+		}
+	}
+	this.rrs = rrs
+}
+
+// 比较名称、类型和记录内容
+func (this *rfc2136TestServer) equal(rr1 dns.RR, rr2 dns.RR) bool {
+	return strings.EqualFold(rr1.Header().Name, rr2.Header().Name) &&
+		rr1.Header().Rrtype == rr2.Header().Rrtype &&
+		strings.TrimPrefix(rr1.String(), rr1.Header().String()) == strings.TrimPrefix(rr2.String(), rr2.Header().String())
+}
+
+func (this *rfc2136TestServer) Close() {
+	for _, server := range this.servers {
+		_ = server.Shutdown()
+	}
+}
+
+func TestRFC2136Provider_Records(t *testing.T) {
+	var server = newRFC2136TestServer(t, "example.com")
+	defer server.Close()
+
+	var provider = &RFC2136Provider{}
+	err := provider.Auth(maps.Map{
+		"server":        server.addr,
+		"tsigName":      "edge-key",
+		"tsigSecret":    rfc2136TestSecret,
+		"tsigAlgorithm": "hmac-sha256",
+		"ttl":           120,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := provider.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatal("expect 3 records, but got", len(records))
+	}
+	if records[2].Name != "@" || records[2].Type != "NS" || records[2].Value != "ns1.example.com." {
+		t.Fatal("invalid NS record:", records[2])
+	}
+
+	// 添加
+	err = provider.AddRecord("example.com", &dnstypes.Record{
+		Name:  "cdn",
+		Type:  dnstypes.RecordTypeCNAME,
+		Value: "cdn.example.net",
+		Route: provider.DefaultRoute(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := provider.QueryRecord("example.com", "cdn", dnstypes.RecordTypeCNAME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Value != "cdn.example.net." || record.Name != "cdn" {
+		t.Fatal("invalid record:", record)
+	}
+
+	// 修改
+	err = provider.UpdateRecord("example.com", record, &dnstypes.Record{
+		Name:  "cdn",
+		Type:  dnstypes.RecordTypeCNAME,
+		Value: "cdn2.example.net.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err = provider.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values = []string{}
+	for _, r := range records {
+		if r.Name == "cdn" {
+			values = append(values, r.Value)
+		}
+	}
+	if strings.Join(values, ",") != "cdn2.example.net." {
+		t.Fatal("invalid values after updating:", values)
+	}
+
+	// 删除A记录中的一条
+	aRecord, err := provider.QueryRecord("example.com", "www", dnstypes.RecordTypeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = provider.DeleteRecord("example.com", aRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aRecord, err = provider.QueryRecord("example.com", "www", dnstypes.RecordTypeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aRecord == nil || aRecord.Value != "2.2.2.2" {
+		t.Fatal("invalid A record after deleting:", aRecord)
+	}
+
+	// 不存在的记录
+	record, err = provider.QueryRecord("example.com", "none", dnstypes.RecordTypeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record != nil {
+		t.Fatal("record should be nil")
+	}
+}
+
+func TestRFC2136Provider_ACME(t *testing.T) {
+	var server = newRFC2136TestServer(t, "example.com")
+	defer server.Close()
+
+	var provider = &RFC2136Provider{}
+	err := provider.Auth(maps.Map{
+		"server":     server.addr,
+		"tsigName":   "edge-key.",
+		"tsigSecret": rfc2136TestSecret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 和 acme.DNSProvider 中的调用方式保持一致
+	for _, value := range []string{"first-token", "second token"} {
+		record, err := provider.QueryRecord("example.com", "_acme-challenge", dnstypes.RecordTypeTXT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var newRecord = &dnstypes.Record{
+			Name:  "_acme-challenge",
+			Type:  dnstypes.RecordTypeTXT,
+			Value: value,
+			Route: provider.DefaultRoute(),
+		}
+		if record == nil {
+			err = provider.AddRecord("example.com", newRecord)
+		} else {
+			err = provider.UpdateRecord("example.com", record, newRecord)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := provider.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var txtValues = []string{}
+	for _, record := range records {
+		if record.Type == dnstypes.RecordTypeTXT {
+			txtValues = append(txtValues, record.Value)
+		}
+	}
+	if strings.Join(txtValues, ",") != "second token" {
+		t.Fatal("invalid txt values:", txtValues)
+	}
+}
+
+func TestRFC2136Provider_BadKey(t *testing.T) {
+	var server = newRFC2136TestServer(t, "example.com")
+	defer server.Close()
+
+	var provider = &RFC2136Provider{}
+	err := provider.Auth(maps.Map{
+		"server":     server.addr,
+		"tsigName":   "edge-key",
+		"tsigSecret": "d3Jvbmcta2V5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = provider.AddRecord("example.com", &dnstypes.Record{
+		Name:  "www",
+		Type:  dnstypes.RecordTypeA,
+		Value: "3.3.3.3",
+	})
+	if err == nil {
+		t.Fatal("update with bad key should fail")
+	}
+	t.Log(err)
+}
+
+func TestRFC2136Provider_Priority(t *testing.T) {
+	var server = newRFC2136TestServer(t, "example.com")
+	defer server.Close()
+
+	var provider = &RFC2136Provider{}
+	err := provider.Auth(maps.Map{
+		"server":     server.addr,
+		"tsigName":   "edge-key",
+		"tsigSecret": rfc2136TestSecret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, record := range []*dnstypes.Record{
+		{Name: "@", Type: dnstypes.RecordTypeMX, Value: "mail.example.com.", Priority: 10, TTL: 300},
+		{Name: "_sip._tcp", Type: dnstypes.RecordTypeSRV, Value: "5 5060 sip.example.com.", Priority: 20},
+		{Name: "@", Type: dnstypes.RecordTypeCAA, Value: `0 issue "letsencrypt.org"`},
+	} {
+		err = provider.AddRecord("example.com", record)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mxRecord, err := provider.QueryRecord("example.com", "@", dnstypes.RecordTypeMX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mxRecord == nil || mxRecord.Value != "mail.example.com." || mxRecord.Priority != 10 || mxRecord.TTL != 300 {
+		t.Fatal("invalid mx record:", mxRecord)
+	}
+
+	srvRecord, err := provider.QueryRecord("example.com", "_sip._tcp", dnstypes.RecordTypeSRV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srvRecord == nil || srvRecord.Value != "5 5060 sip.example.com." || srvRecord.Priority != 20 || srvRecord.TTL != RFC2136DefaultTTL {
+		t.Fatal("invalid srv record:", srvRecord)
+	}
+
+	caaRecord, err := provider.QueryRecord("example.com", "@", dnstypes.RecordTypeCAA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caaRecord == nil || caaRecord.Value != `0 issue "letsencrypt.org"` {
+		t.Fatal("invalid caa record:", caaRecord)
+	}
+
+	// 修改优先级
+	err = provider.UpdateRecord("example.com", mxRecord, &dnstypes.Record{
+		Name:     "@",
+		Type:     dnstypes.RecordTypeMX,
+		Value:    "mail.example.com.",
+		Priority: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mxRecord, err = provider.QueryRecord("example.com", "@", dnstypes.RecordTypeMX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mxRecord == nil || mxRecord.Priority != 5 {
+		t.Fatal("invalid mx record after updating:", mxRecord)
+	}
+}
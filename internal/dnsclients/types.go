@@ -17,6 +17,8 @@ const (
 	ProviderTypeLocalEdgeDNS ProviderType = "localEdgeDNS" // 和当前系统集成的EdgeDNS
 	ProviderTypeUserEdgeDNS  ProviderType = "userEdgeDNS"  // 通过API连接的EdgeDNS
 	ProviderTypeCustomHTTP   ProviderType = "customHTTP"   // 自定义HTTP接口
+	ProviderTypePowerDNS     ProviderType = "powerDNS"     // PowerDNS
+	ProviderTypeRFC2136      ProviderType = "rfc2136"      // 支持RFC 2136动态更新的DNS服务器
 )
 
 // FindAllProviderTypes 所有的服务商类型
@@ -47,6 +49,16 @@ func FindAllProviderTypes() []maps.Map {
 			"code":        ProviderTypeCloudFlare,
 			"description": "CloudFlare提供的DNS服务。",
 		},
+		{
+			"name":        "PowerDNS",
+			"code":        ProviderTypePowerDNS,
+			"description": "通过HTTP API连接自建的PowerDNS权威服务器。",
+		},
+		{
+			"name":        "RFC 2136动态更新",
+			"code":        ProviderTypeRFC2136,
+			"description": "通过DNS UPDATE和TSIG连接BIND、Knot等支持动态更新的DNS服务器。",
+		},
 	}
 
 	if teaconst.IsPlus {
@@ -90,6 +102,10 @@ func FindProvider(providerType ProviderType) ProviderInterface {
 		return &UserEdgeDNSProvider{}
 	case ProviderTypeCustomHTTP:
 		return &CustomHTTPProvider{}
+	case ProviderTypePowerDNS:
+		return &PowerDNSProvider{}
+	case ProviderTypeRFC2136:
+		return &RFC2136Provider{}
 	}
 	return nil
 }
@@ -0,0 +1,96 @@
+package encrypt
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// 加密信封格式：
+//   | "ENV" | 版本（1字节）| 加密方法名称长度（1字节）| 加密方法名称 | 加密后的数据 |
+// 读取方根据信封中的方法名称选择解密方法，不是信封格式的数据则认为是旧版本节点使用固定方法加密的数据
+
+const (
+	EnvelopeVersion = 1 // 当前信封格式版本
+)
+
+var envelopeMagic = []byte("ENV")
+
+// EncodeEnvelope 使用某个加密方法加密数据，并封装成信封
+// 信封中只能使用带认证的加密方法
+func EncodeEnvelope(method string, key string, iv string, data []byte) ([]byte, error) {
+	if !IsAuthenticatedMethod(method) {
+		return nil, errors.New("method '" + method + "' is not an authenticated method")
+	}
+	instance, err := NewMethodInstance(method, key, iv)
+	if err != nil {
+		return nil, err
+	}
+	encryptedData, err := instance.Encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result = make([]byte, 0, len(envelopeMagic)+2+len(method)+len(encryptedData))
+	result = append(result, envelopeMagic...)
+	result = append(result, EnvelopeVersion, byte(len(method)))
+	result = append(result, method...)
+	result = append(result, encryptedData...)
+	return result, nil
+}
+
+// ParseEnvelope 解析信封，返回格式版本、加密方法和加密后的数据，ok表示数据是否为信封格式
+func ParseEnvelope(data []byte) (version int, method string, encryptedData []byte, ok bool) {
+	var headerSize = len(envelopeMagic) + 2
+	if len(data) < headerSize || !bytes.HasPrefix(data, envelopeMagic) {
+		return
+	}
+	version = int(data[len(envelopeMagic)])
+	var methodLen = int(data[len(envelopeMagic)+1])
+	if methodLen == 0 || len(data) < headerSize+methodLen {
+		return
+	}
+	method = string(data[headerSize : headerSize+methodLen])
+	if _, found := methods[method]; !found {
+		return
+	}
+	encryptedData = data[headerSize+methodLen:]
+	ok = true
+	return
+}
+
+// IsEnvelope 判断数据是否为信封格式
+func IsEnvelope(data []byte) bool {
+	_, _, _, ok := ParseEnvelope(data)
+	return ok
+}
+
+// DecodeEnvelope 解密信封中的数据
+// 如果数据不是信封格式，则使用legacyMethod解密，以兼容仍在使用旧加密方法（比如aes-256-cfb）的节点；legacyMethod为空时不兼容旧数据
+func DecodeEnvelope(data []byte, key string, iv string, legacyMethod string) ([]byte, error) {
+	version, method, encryptedData, ok := ParseEnvelope(data)
+	if !ok {
+		if len(legacyMethod) == 0 {
+			return nil, errors.New("invalid envelope")
+		}
+		instance, err := NewMethodInstance(legacyMethod, key, iv)
+		if err != nil {
+			return nil, err
+		}
+		return instance.Decrypt(data)
+	}
+
+	if version != EnvelopeVersion {
+		return nil, errors.New("unsupported envelope version '" + strconv.Itoa(version) + "'")
+	}
+
+	// 防止使用raw等不带认证的方法伪造数据
+	if !IsAuthenticatedMethod(method) {
+		return nil, errors.New("method '" + method + "' is not allowed in envelope")
+	}
+	instance, err := NewMethodInstance(method, key, iv)
+	if err != nil {
+		return nil, err
+	}
+	return instance.Decrypt(encryptedData)
+}
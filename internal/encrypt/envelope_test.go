@@ -0,0 +1,107 @@
+package encrypt
+
+import (
+	"testing"
+)
+
+func TestEncodeEnvelope(t *testing.T) {
+	for _, methodName := range authenticatedMethods {
+		data, err := EncodeEnvelope(methodName, "abc", "123", []byte("Hello, World"))
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+
+		version, method, _, ok := ParseEnvelope(data)
+		if !ok || version != EnvelopeVersion || method != methodName {
+			t.Fatal(methodName, "parse envelope failed:", version, method, ok)
+		}
+
+		src, err := DecodeEnvelope(data, "abc", "123", "")
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		if string(src) != "Hello, World" {
+			t.Fatal(methodName, "unexpected result:", string(src))
+		}
+	}
+
+	_, err := EncodeEnvelope("aes-256-cfb", "abc", "123", []byte("Hello, World"))
+	if err == nil {
+		t.Fatal("cfb method should not be allowed")
+	}
+}
+
+func TestDecodeEnvelope_Legacy(t *testing.T) {
+	method, err := NewMethodInstance("aes-256-cfb", "abc", "123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := method.Encrypt([]byte("Hello, World"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := DecodeEnvelope(data, "abc", "123", "aes-256-cfb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != "Hello, World" {
+		t.Fatal("unexpected result:", string(src))
+	}
+
+	// 不兼容旧数据
+	_, err = DecodeEnvelope(data, "abc", "123", "")
+	if err == nil {
+		t.Fatal("legacy data should be rejected without legacy method")
+	}
+}
+
+func TestDecodeEnvelope_Raw(t *testing.T) {
+	// 伪造使用raw方法的信封
+	var data = append([]byte("ENV"), EnvelopeVersion, 3)
+	data = append(data, "raw"...)
+	data = append(data, "Hello, World"...)
+	_, err := DecodeEnvelope(data, "abc", "123", "aes-256-cfb")
+	if err == nil {
+		t.Fatal("raw method should not be allowed")
+	}
+	t.Log("expected error:", err)
+}
+
+func TestDecodeEnvelope_Empty(t *testing.T) {
+	// 伪造没有密文的信封
+	var data = append([]byte("ENV"), EnvelopeVersion, byte(len("aes-256-gcm")))
+	data = append(data, "aes-256-gcm"...)
+	_, err := DecodeEnvelope(data, "abc", "123", "")
+	if err == nil {
+		t.Fatal("envelope without encrypted data should be rejected")
+	}
+}
+
+func TestIsEnvelope(t *testing.T) {
+	data, err := EncodeEnvelope("aes-256-gcm", "abc", "123", []byte("Hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEnvelope(data) {
+		t.Fatal("should be envelope")
+	}
+
+	legacyMethod, err := NewMethodInstance("aes-256-cfb", "abc", "123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyData, err := legacyMethod.Encrypt([]byte("Hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsEnvelope(legacyData) {
+		t.Fatal("should not be envelope")
+	}
+
+	// 不接受旧加密方法时应当拒绝旧数据
+	_, err = DecodeEnvelope(legacyData, "abc", "123", "")
+	if err == nil {
+		t.Fatal("legacy data should be rejected")
+	}
+}
@@ -0,0 +1,54 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+// AEADMethod 带认证的加密方法的公共实现
+// 每次加密都会随机生成nonce并放在密文最前面，Init中的iv不再作为IV使用，而是作为附加数据参与认证
+type AEADMethod struct {
+	aead           cipher.AEAD
+	additionalData []byte
+}
+
+func (this *AEADMethod) initAEAD(aead cipher.AEAD, iv []byte) {
+	this.aead = aead
+	if len(iv) > 0 {
+		this.additionalData = append([]byte{}, iv...)
+	}
+}
+
+// Encrypt 加密数据，空数据也会生成nonce和认证标签，以便解密时校验
+func (this *AEADMethod) Encrypt(src []byte) (dst []byte, err error) {
+	var nonceSize = this.aead.NonceSize()
+	var nonce = make([]byte, nonceSize, nonceSize+len(src)+this.aead.Overhead())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	dst = this.aead.Seal(nonce, nonce, src, this.additionalData)
+	return
+}
+
+// Decrypt 解密数据，长度不足nonce和认证标签的数据（包括空数据）都认为是无效数据
+func (this *AEADMethod) Decrypt(dst []byte) (src []byte, err error) {
+	var nonceSize = this.aead.NonceSize()
+	if len(dst) < nonceSize+this.aead.Overhead() {
+		return nil, errors.New("invalid encrypted data")
+	}
+	return this.aead.Open(nil, dst[:nonceSize], dst[nonceSize:], this.additionalData)
+}
+
+// 将密钥调整为指定的长度，和CFB方法中的处理方式保持一致
+func fixMethodKey(key []byte, size int) []byte {
+	l := len(key)
+	if l > size {
+		return key[:size]
+	} else if l < size {
+		return append(append([]byte{}, key...), bytes.Repeat([]byte{' '}, size-l)...)
+	}
+	return key
+}
@@ -0,0 +1,131 @@
+package encrypt
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAEADMethod_Encrypt(t *testing.T) {
+	for _, methodName := range authenticatedMethods {
+		method, err := NewMethodInstance(methodName, "abc", "123")
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		src := []byte("Hello, World")
+		dst, err := method.Encrypt(src)
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+
+		// 每次加密使用不同的nonce
+		dst2, err := method.Encrypt(src)
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		if bytes.Equal(dst, dst2) {
+			t.Fatal(methodName, "encrypted data should be different")
+		}
+
+		result, err := method.Decrypt(dst)
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		if !bytes.Equal(result, src) {
+			t.Fatal(methodName, "expected:", string(src), "but got:", string(result))
+		}
+	}
+}
+
+func TestAEADMethod_Tampered(t *testing.T) {
+	for _, methodName := range authenticatedMethods {
+		method, err := NewMethodInstance(methodName, "abc", "123")
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		dst, err := method.Encrypt([]byte("Hello, World"))
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		dst[len(dst)-1] ^= 1
+		_, err = method.Decrypt(dst)
+		if err == nil {
+			t.Fatal(methodName, "tampered data should fail to decrypt")
+		}
+
+		_, err = method.Decrypt([]byte{1})
+		if err == nil {
+			t.Fatal(methodName, "short data should fail to decrypt")
+		}
+
+		_, err = method.Decrypt(nil)
+		if err == nil {
+			t.Fatal(methodName, "empty data should fail to decrypt")
+		}
+	}
+}
+
+func TestAEADMethod_IV(t *testing.T) {
+	for _, methodName := range authenticatedMethods {
+		method, err := NewMethodInstance(methodName, "abc", "123")
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		dst, err := method.Encrypt([]byte("Hello, World"))
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+
+		// iv参与认证，不同的iv无法解密
+		method2, err := NewMethodInstance(methodName, "abc", "456")
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		_, err = method2.Decrypt(dst)
+		if err == nil {
+			t.Fatal(methodName, "should fail to decrypt with another iv")
+		}
+	}
+}
+
+func BenchmarkAES256GCMMethod_Encrypt(b *testing.B) {
+	runtime.GOMAXPROCS(1)
+
+	method, err := NewMethodInstance("aes-256-gcm", "abc", "123")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	src := []byte(strings.Repeat("Hello", 1024))
+	for i := 0; i < b.N; i++ {
+		dst, err := method.Encrypt(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_ = dst
+	}
+}
+
+func TestAEADMethod_Empty(t *testing.T) {
+	for _, methodName := range authenticatedMethods {
+		method, err := NewMethodInstance(methodName, "abc", "123")
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		dst, err := method.Encrypt(nil)
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		if len(dst) == 0 {
+			t.Fatal(methodName, "empty data should also be sealed")
+		}
+		src, err := method.Decrypt(dst)
+		if err != nil {
+			t.Fatal(methodName, err)
+		}
+		if len(src) != 0 {
+			t.Fatal(methodName, "expected empty data, but got:", string(src))
+		}
+	}
+}
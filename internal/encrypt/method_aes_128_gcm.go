@@ -0,0 +1,23 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+)
+
+type AES128GCMMethod struct {
+	AEADMethod
+}
+
+func (this *AES128GCMMethod) Init(key, iv []byte) error {
+	block, err := aes.NewCipher(fixMethodKey(key, 16))
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	this.initAEAD(aead, iv)
+	return nil
+}
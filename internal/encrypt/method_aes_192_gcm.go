@@ -0,0 +1,23 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+)
+
+type AES192GCMMethod struct {
+	AEADMethod
+}
+
+func (this *AES192GCMMethod) Init(key, iv []byte) error {
+	block, err := aes.NewCipher(fixMethodKey(key, 24))
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	this.initAEAD(aead, iv)
+	return nil
+}
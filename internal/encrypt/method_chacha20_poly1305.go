@@ -0,0 +1,18 @@
+package encrypt
+
+import (
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+type ChaCha20Poly1305Method struct {
+	AEADMethod
+}
+
+func (this *ChaCha20Poly1305Method) Init(key, iv []byte) error {
+	aead, err := chacha20poly1305.New(fixMethodKey(key, chacha20poly1305.KeySize))
+	if err != nil {
+		return err
+	}
+	this.initAEAD(aead, iv)
+	return nil
+}
@@ -6,12 +6,19 @@ import (
 )
 
 var methods = map[string]reflect.Type{
-	"raw":         reflect.TypeOf(new(RawMethod)).Elem(),
-	"aes-128-cfb": reflect.TypeOf(new(AES128CFBMethod)).Elem(),
-	"aes-192-cfb": reflect.TypeOf(new(AES192CFBMethod)).Elem(),
-	"aes-256-cfb": reflect.TypeOf(new(AES256CFBMethod)).Elem(),
+	"raw":               reflect.TypeOf(new(RawMethod)).Elem(),
+	"aes-128-cfb":       reflect.TypeOf(new(AES128CFBMethod)).Elem(),
+	"aes-192-cfb":       reflect.TypeOf(new(AES192CFBMethod)).Elem(),
+	"aes-256-cfb":       reflect.TypeOf(new(AES256CFBMethod)).Elem(),
+	"aes-128-gcm":       reflect.TypeOf(new(AES128GCMMethod)).Elem(),
+	"aes-192-gcm":       reflect.TypeOf(new(AES192GCMMethod)).Elem(),
+	"aes-256-gcm":       reflect.TypeOf(new(AES256GCMMethod)).Elem(),
+	"chacha20-poly1305": reflect.TypeOf(new(ChaCha20Poly1305Method)).Elem(),
 }
 
+// 带认证的加密方法，密文被篡改后解密会失败
+var authenticatedMethods = []string{"aes-128-gcm", "aes-192-gcm", "aes-256-gcm", "chacha20-poly1305"}
+
 func NewMethodInstance(method string, key string, iv string) (MethodInterface, error) {
 	valueType, ok := methods[method]
 	if !ok {
@@ -25,6 +32,16 @@ func NewMethodInstance(method string, key string, iv string) (MethodInterface, e
 	return instance, err
 }
 
+// IsAuthenticatedMethod 判断是否为带认证的加密方法
+func IsAuthenticatedMethod(method string) bool {
+	for _, m := range authenticatedMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
 func RecoverMethodPanic(err interface{}) error {
 	if err != nil {
 		s, ok := err.(string)
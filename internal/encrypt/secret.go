@@ -0,0 +1,41 @@
+package encrypt
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/configs"
+	"sync"
+)
+
+var sharedSecretBox *SecretBox
+var sharedSecretBoxErr error
+var sharedSecretBoxOnce = &sync.Once{}
+
+// SharedSecretBox 获取共享的敏感数据加密对象，主密钥从环境变量或密钥文件中读取
+func SharedSecretBox() (*SecretBox, error) {
+	sharedSecretBoxOnce.Do(func() {
+		keys, err := configs.LoadSecretKeys()
+		if err != nil {
+			sharedSecretBoxErr = err
+			return
+		}
+		sharedSecretBox, sharedSecretBoxErr = NewSecretBox(keys)
+	})
+	return sharedSecretBox, sharedSecretBoxErr
+}
+
+// EncryptSecret 加密需要保存的敏感数据
+func EncryptSecret(data []byte) ([]byte, error) {
+	box, err := SharedSecretBox()
+	if err != nil {
+		return nil, err
+	}
+	return box.Encrypt(data)
+}
+
+// DecryptSecret 解密读取的敏感数据
+func DecryptSecret(data []byte) ([]byte, error) {
+	box, err := SharedSecretBox()
+	if err != nil {
+		return nil, err
+	}
+	return box.Decrypt(data)
+}
@@ -0,0 +1,184 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	secretPrefix     = "$edge-secret$1$" // 加密后数据的前缀，其中的1为格式版本
+	secretMethod     = "aes-256-gcm"     // 加密主密钥和数据使用的方法
+	secretDataKeyLen = 32
+)
+
+// SecretBox 使用信封加密保存敏感数据
+// 每条数据使用随机生成的数据密钥加密，数据密钥再使用主密钥加密后和数据保存在一起，轮换主密钥时只需要重新加密数据密钥
+// 加密后的格式为：$edge-secret$1$主密钥ID$加密后的数据密钥$加密后的数据
+type SecretBox struct {
+	currentKeyId string
+	keys         map[string]MethodInterface // key id => method
+}
+
+// NewSecretBox 获取新对象，第一个主密钥用来加密，其余的只用来解密
+func NewSecretBox(keys [][]byte) (*SecretBox, error) {
+	var box = &SecretBox{
+		keys: map[string]MethodInterface{},
+	}
+	for index, key := range keys {
+		if len(key) != secretDataKeyLen {
+			return nil, errors.New("invalid secret key: key length should be 32 bytes")
+		}
+		var keyId = SecretKeyId(key)
+
+		// 主密钥ID作为附加数据参与认证
+		method, err := NewMethodInstance(secretMethod, string(key), keyId)
+		if err != nil {
+			return nil, err
+		}
+		box.keys[keyId] = method
+		if index == 0 {
+			box.currentKeyId = keyId
+		}
+	}
+	return box, nil
+}
+
+// SecretKeyId 计算主密钥ID
+func SecretKeyId(key []byte) string {
+	var sum = sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// IsEncryptedSecret 判断数据是否已加密
+func IsEncryptedSecret(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(secretPrefix))
+}
+
+// IsOn 是否设置了主密钥
+func (this *SecretBox) IsOn() bool {
+	return len(this.currentKeyId) > 0
+}
+
+// CurrentKeyId 当前用来加密的主密钥ID
+func (this *SecretBox) CurrentKeyId() string {
+	return this.currentKeyId
+}
+
+// Encrypt 加密数据，没有设置主密钥时返回原数据
+func (this *SecretBox) Encrypt(data []byte) ([]byte, error) {
+	if len(data) == 0 || !this.IsOn() || IsEncryptedSecret(data) {
+		return data, nil
+	}
+
+	var dataKey = make([]byte, secretDataKeyLen)
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	dataMethod, err := NewMethodInstance(secretMethod, string(dataKey), "")
+	if err != nil {
+		return nil, err
+	}
+	encryptedData, err := dataMethod.Encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := this.keys[this.currentKeyId].Encrypt(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return composeSecret(this.currentKeyId, wrappedKey, encryptedData), nil
+}
+
+// Decrypt 解密数据，未加密的数据原样返回
+func (this *SecretBox) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncryptedSecret(data) {
+		return data, nil
+	}
+
+	keyId, wrappedKey, encryptedData, err := parseSecret(data)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := this.unwrapKey(keyId, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	dataMethod, err := NewMethodInstance(secretMethod, string(dataKey), "")
+	if err != nil {
+		return nil, err
+	}
+	return dataMethod.Decrypt(encryptedData)
+}
+
+// Rewrap 使用当前的主密钥重新加密数据
+// 已加密的数据只重新加密数据密钥，未加密的数据会被加密；返回的changed表示数据是否有变化
+func (this *SecretBox) Rewrap(data []byte) (result []byte, changed bool, err error) {
+	if !this.IsOn() {
+		return nil, false, errors.New("secret key not found")
+	}
+	if len(data) == 0 {
+		return data, false, nil
+	}
+	if !IsEncryptedSecret(data) {
+		result, err = this.Encrypt(data)
+		if err != nil {
+			return nil, false, err
+		}
+		return result, true, nil
+	}
+
+	keyId, wrappedKey, encryptedData, err := parseSecret(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if keyId == this.currentKeyId {
+		return data, false, nil
+	}
+	dataKey, err := this.unwrapKey(keyId, wrappedKey)
+	if err != nil {
+		return nil, false, err
+	}
+	wrappedKey, err = this.keys[this.currentKeyId].Encrypt(dataKey)
+	if err != nil {
+		return nil, false, err
+	}
+	return composeSecret(this.currentKeyId, wrappedKey, encryptedData), true, nil
+}
+
+// 使用主密钥解密数据密钥
+func (this *SecretBox) unwrapKey(keyId string, wrappedKey []byte) ([]byte, error) {
+	method, ok := this.keys[keyId]
+	if !ok {
+		return nil, errors.New("secret key '" + keyId + "' not found")
+	}
+	dataKey, err := method.Decrypt(wrappedKey)
+	if err != nil {
+		return nil, errors.New("decrypt data key failed: " + err.Error())
+	}
+	return dataKey, nil
+}
+
+func composeSecret(keyId string, wrappedKey []byte, encryptedData []byte) []byte {
+	return []byte(secretPrefix + keyId + "$" + base64.StdEncoding.EncodeToString(wrappedKey) + "$" + base64.StdEncoding.EncodeToString(encryptedData))
+}
+
+func parseSecret(data []byte) (keyId string, wrappedKey []byte, encryptedData []byte, err error) {
+	var pieces = strings.Split(string(data[len(secretPrefix):]), "$")
+	if len(pieces) != 3 {
+		err = errors.New("invalid encrypted data")
+		return
+	}
+	keyId = pieces[0]
+	wrappedKey, err = base64.StdEncoding.DecodeString(pieces[1])
+	if err != nil {
+		return
+	}
+	encryptedData, err = base64.StdEncoding.DecodeString(pieces[2])
+	return
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"regexp"
+)
+
+// ProbeConfig 探测设置
+// 保存在集群健康检查设置的probe字段中，没有设置时使用HTTP方式
+type ProbeConfig struct {
+	Type         ProbeType `json:"type"`         // 探测方式
+	Port         int       `json:"port"`         // 端口，TCP、TLS、DNS和gRPC方式使用
+	MaxLatencyMs int64     `json:"maxLatencyMs"` // 最大响应时间（毫秒），超出后认为检查失败，0表示不限制
+
+	// HTTP
+	URL         string               `json:"-"`          // 从健康检查设置中读取
+	StatusCodes []int                `json:"-"`          // 从健康检查设置中读取
+	BodyRegexp  string               `json:"bodyRegexp"` // 响应内容需要匹配的正则表达式
+	Headers     []*ProbeHeaderConfig `json:"headers"`    // 响应报头需要满足的条件
+
+	// TLS
+	ServerName  string `json:"serverName"`  // SNI主机名
+	MinCertDays int    `json:"minCertDays"` // 证书有效期最少剩余天数，0表示只检查是否已过期
+
+	// DNS
+	DNSName          string `json:"dnsName"`          // 查询的域名
+	DNSType          string `json:"dnsType"`          // 查询的记录类型，默认为A
+	DNSNetwork       string `json:"dnsNetwork"`       // udp或tcp，默认为udp
+	DNSExpectedValue string `json:"dnsExpectedValue"` // 应答中需要包含的记录值
+
+	// gRPC
+	GRPCService string `json:"grpcService"` // 服务名，为空表示检查整个服务器
+	GRPCTLS     bool   `json:"grpcTLS"`     // 是否使用TLS连接
+
+	bodyReg *regexp.Regexp
+}
+
+// ProbeHeaderConfig 响应报头条件
+type ProbeHeaderConfig struct {
+	Name     string `json:"name"`     // 报头名称
+	Value    string `json:"value"`    // 报头值，为空表示只需要报头存在
+	IsRegexp bool   `json:"isRegexp"` // Value是否为正则表达式
+
+	valueReg *regexp.Regexp
+}
+
+// DefaultProbeConfig 默认的探测设置
+func DefaultProbeConfig() *ProbeConfig {
+	return &ProbeConfig{
+		Type: ProbeTypeHTTP,
+	}
+}
+
+// DecodeProbeConfig 从健康检查设置中解析探测设置
+func DecodeProbeConfig(healthCheckJSON []byte) (*ProbeConfig, error) {
+	var config = DefaultProbeConfig()
+	if len(healthCheckJSON) == 0 {
+		return config, nil
+	}
+
+	var wrapper = &struct {
+		Probe *ProbeConfig `json:"probe"`
+	}{}
+	err := json.Unmarshal(healthCheckJSON, wrapper)
+	if err != nil {
+		return nil, err
+	}
+	if wrapper.Probe != nil {
+		config = wrapper.Probe
+	}
+	if len(config.Type) == 0 {
+		config.Type = ProbeTypeHTTP
+	}
+	return config, nil
+}
+
+// Init 初始化，并校验设置
+func (this *ProbeConfig) Init() error {
+	if FindProbe(this.Type) == nil {
+		return errors.New("unsupported probe type '" + this.Type + "'")
+	}
+	if this.Port < 0 || this.Port > 65535 {
+		return errors.New("invalid port")
+	}
+
+	if len(this.BodyRegexp) > 0 {
+		reg, err := regexp.Compile(this.BodyRegexp)
+		if err != nil {
+			return errors.New("invalid body regexp: " + err.Error())
+		}
+		this.bodyReg = reg
+	}
+
+	for _, header := range this.Headers {
+		if len(header.Name) == 0 {
+			return errors.New("header name should not be empty")
+		}
+		if header.IsRegexp && len(header.Value) > 0 {
+			reg, err := regexp.Compile(header.Value)
+			if err != nil {
+				return errors.New("invalid regexp for header '" + header.Name + "': " + err.Error())
+			}
+			header.valueReg = reg
+		}
+	}
+
+	return nil
+}
+
+// Match 判断报头值是否满足条件
+func (this *ProbeHeaderConfig) Match(values []string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	if len(this.Value) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if this.valueReg != nil {
+			if this.valueReg.MatchString(value) {
+				return true
+			}
+		} else if value == this.Value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import "testing"
+
+func TestDecodeProbeConfig(t *testing.T) {
+	{
+		config, err := DecodeProbeConfig([]byte(`{"isOn":true,"url":"http://${host}/"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Type != ProbeTypeHTTP {
+			t.Fatal("default probe type should be http")
+		}
+	}
+
+	{
+		config, err := DecodeProbeConfig([]byte(`{"isOn":true,"probe":{"type":"tls","port":8443,"minCertDays":7}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Type != ProbeTypeTLS || config.Port != 8443 || config.MinCertDays != 7 {
+			t.Fatal("invalid config:", config)
+		}
+	}
+}
+
+func TestProbeConfig_Init(t *testing.T) {
+	for _, config := range []*ProbeConfig{
+		{Type: "ping"},
+		{Type: ProbeTypeHTTP, BodyRegexp: "("},
+		{Type: ProbeTypeHTTP, Headers: []*ProbeHeaderConfig{{Name: "X-Status", Value: "[", IsRegexp: true}}},
+	} {
+		if config.Init() == nil {
+			t.Fatal("config should be invalid:", config.Type)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/miekg/dns"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DNSProbe 向节点发送DNS查询
+type DNSProbe struct {
+	config *ProbeConfig
+
+	qType uint16
+}
+
+// Init 初始化
+func (this *DNSProbe) Init(config *ProbeConfig) error {
+	if len(config.DNSName) == 0 {
+		return errors.New("'dnsName' should not be empty")
+	}
+	if config.Port <= 0 {
+		config.Port = 53
+	}
+	if len(config.DNSType) == 0 {
+		config.DNSType = "A"
+	}
+	qType, ok := dns.StringToType[strings.ToUpper(config.DNSType)]
+	if !ok {
+		return errors.New("invalid dns type '" + config.DNSType + "'")
+	}
+	this.qType = qType
+
+	switch config.DNSNetwork {
+	case "":
+		config.DNSNetwork = "udp"
+	case "udp", "tcp":
+	default:
+		return errors.New("invalid dns network '" + config.DNSNetwork + "'")
+	}
+
+	this.config = config
+	return nil
+}
+
+// Check 检查节点
+func (this *DNSProbe) Check(addr string, timeout time.Duration) error {
+	var msg = new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(this.config.DNSName), this.qType)
+
+	var client = &dns.Client{
+		Net:     this.config.DNSNetwork,
+		Timeout: timeout,
+	}
+	resp, _, err := client.Exchange(msg, net.JoinHostPort(addr, strconv.Itoa(this.config.Port)))
+	if err != nil {
+		return err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return errors.New("invalid response code '" + dns.RcodeToString[resp.Rcode] + "'")
+	}
+
+	if len(this.config.DNSExpectedValue) == 0 {
+		return nil
+	}
+	for _, rr := range resp.Answer {
+		var value = strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String()))
+		if strings.Contains(value, this.config.DNSExpectedValue) {
+			return nil
+		}
+	}
+	return errors.New("can not find '" + this.config.DNSExpectedValue + "' in answers")
+}
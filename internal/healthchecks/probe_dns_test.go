@@ -0,0 +1,68 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"github.com/miekg/dns"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDNSProbe_Check(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var port = conn.LocalAddr().(*net.UDPAddr).Port
+
+	var server = &dns.Server{
+		PacketConn: conn,
+		Handler: dns.HandlerFunc(func(writer dns.ResponseWriter, req *dns.Msg) {
+			var resp = new(dns.Msg)
+			resp.SetReply(req)
+			if req.Question[0].Name != "example.com." {
+				resp.Rcode = dns.RcodeNameError
+			} else {
+				resp.Answer = append(resp.Answer, &dns.A{
+					Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+					A:   net.ParseIP("192.168.1.100"),
+				})
+			}
+			_ = writer.WriteMsg(resp)
+		}),
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	defer func() {
+		_ = server.Shutdown()
+	}()
+
+	var newProbe = func(config *ProbeConfig) *DNSProbe {
+		config.Port = port
+		var probe = &DNSProbe{}
+		err := probe.Init(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return probe
+	}
+
+	err = newProbe(&ProbeConfig{DNSName: "example.com", DNSExpectedValue: "192.168.1.100"}).Check("127.0.0.1", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = newProbe(&ProbeConfig{DNSName: "example.com", DNSExpectedValue: "192.168.1.101"}).Check("127.0.0.1", 2*time.Second)
+	if err == nil {
+		t.Fatal("value should not match")
+	}
+	t.Log(err)
+
+	err = newProbe(&ProbeConfig{DNSName: "example.org"}).Check("127.0.0.1", 2*time.Second)
+	if err == nil {
+		t.Fatal("should return NXDOMAIN")
+	}
+	t.Log(err)
+}
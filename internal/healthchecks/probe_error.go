@@ -0,0 +1,27 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+// NonRetryableError 不需要重试的检查失败
+// 比如节点已经返回了明确的响应，但响应不符合要求，重试也不会有不同的结果
+type NonRetryableError struct {
+	message string
+}
+
+// NewNonRetryableError 获取新的不需要重试的错误
+func NewNonRetryableError(message string) error {
+	return &NonRetryableError{message: message}
+}
+
+func (this *NonRetryableError) Error() string {
+	return this.message
+}
+
+// IsNonRetryableError 判断是否为不需要重试的错误
+func IsNonRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	_, ok := err.(*NonRetryableError)
+	return ok
+}
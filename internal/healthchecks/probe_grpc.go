@@ -0,0 +1,63 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"net"
+	"strconv"
+	"time"
+)
+
+// GRPCProbe 使用gRPC标准健康检查协议检查服务状态
+type GRPCProbe struct {
+	config *ProbeConfig
+}
+
+// Init 初始化
+func (this *GRPCProbe) Init(config *ProbeConfig) error {
+	if config.Port <= 0 {
+		return errors.New("'port' should be greater than 0")
+	}
+	this.config = config
+	return nil
+}
+
+// Check 检查节点
+func (this *GRPCProbe) Check(addr string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var option grpc.DialOption
+	if this.config.GRPCTLS {
+		option = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			ServerName:         this.config.ServerName,
+			InsecureSkipVerify: true,
+		}))
+	} else {
+		option = grpc.WithInsecure()
+	}
+	conn, err := grpc.DialContext(ctx, net.JoinHostPort(addr, strconv.Itoa(this.config.Port)), option, grpc.WithBlock())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{
+		Service: this.config.GRPCService,
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		return errors.New("invalid serving status '" + resp.Status.String() + "'")
+	}
+	return nil
+}
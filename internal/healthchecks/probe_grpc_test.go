@@ -0,0 +1,56 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestGRPCProbe_Check(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var port = listener.Addr().(*net.TCPAddr).Port
+
+	var healthServer = health.NewServer()
+	healthServer.SetServingStatus("edge", grpc_health_v1.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("stopped", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+
+	var server = grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	{
+		var probe = &GRPCProbe{}
+		err = probe.Init(&ProbeConfig{Port: port, GRPCService: "edge"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = probe.Check("127.0.0.1", 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	{
+		var probe = &GRPCProbe{}
+		err = probe.Init(&ProbeConfig{Port: port, GRPCService: "stopped"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = probe.Check("127.0.0.1", 5*time.Second)
+		if err == nil {
+			t.Fatal("service should not be serving")
+		}
+		t.Log(err)
+	}
+}
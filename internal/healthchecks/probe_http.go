@@ -0,0 +1,111 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/iwind/TeaGo/lists"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 检查响应内容时最多读取的字节数
+const maxHTTPBodySize = 1 << 20
+
+// HTTPProbe 请求URL检查状态码、响应内容和响应报头
+type HTTPProbe struct {
+	config *ProbeConfig
+}
+
+// Init 初始化
+func (this *HTTPProbe) Init(config *ProbeConfig) error {
+	if len(config.URL) == 0 {
+		return errors.New("'url' should not be empty")
+	}
+	this.config = config
+	return nil
+}
+
+// Check 检查节点
+func (this *HTTPProbe) Check(addr string, timeout time.Duration) error {
+	// 支持IPv6
+	if utils.IsIPv6(addr) {
+		addr = "[" + addr + "]"
+	}
+
+	url := strings.ReplaceAll(this.config.URL, "${host}", addr)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, dialAddr string) (net.Conn, error) {
+				_, port, err := net.SplitHostPort(dialAddr)
+				if err != nil {
+					return nil, err
+				}
+				conn, err := net.Dial(network, addr+":"+port)
+				if err == nil {
+					return conn, nil
+				}
+				return net.DialTimeout(network, addr+":"+port, timeout)
+			},
+			MaxIdleConns:          1,
+			MaxIdleConnsPerHost:   1,
+			MaxConnsPerHost:       1,
+			IdleConnTimeout:       2 * time.Minute,
+			ExpectContinueTimeout: 1 * time.Second,
+			TLSHandshakeTimeout:   0,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+	defer func() {
+		client.CloseIdleConnections()
+	}()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	// 状态码不符合要求时立即失败，不再重试
+	if len(this.config.StatusCodes) > 0 && !lists.ContainsInt(this.config.StatusCodes, resp.StatusCode) {
+		return NewNonRetryableError("invalid response status code '" + strconv.Itoa(resp.StatusCode) + "'")
+	}
+
+	// 响应报头
+	for _, header := range this.config.Headers {
+		if !header.Match(resp.Header.Values(header.Name)) {
+			return errors.New("response header '" + header.Name + "' does not match")
+		}
+	}
+
+	// 响应内容
+	if this.config.bodyReg != nil {
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxHTTPBodySize))
+		if err != nil {
+			return err
+		}
+		if !this.config.bodyReg.Match(body) {
+			return errors.New("response body does not match '" + this.config.BodyRegexp + "'")
+		}
+	}
+
+	return nil
+}
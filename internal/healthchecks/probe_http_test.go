@@ -0,0 +1,96 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestHTTPProbe_Check(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		writer.Header().Set("X-Status", "node-ok-1")
+		_, _ = writer.Write([]byte("status: ok"))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var newProbe = func(config *ProbeConfig) *HTTPProbe {
+		config.URL = "http://${host}:" + port + "/"
+		err := config.Init()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var probe = &HTTPProbe{}
+		err = probe.Init(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return probe
+	}
+
+	{
+		var probe = newProbe(&ProbeConfig{
+			StatusCodes: []int{200},
+			BodyRegexp:  `status:\s*ok`,
+			Headers: []*ProbeHeaderConfig{
+				{Name: "X-Status", Value: `^node-ok-\d+$`, IsRegexp: true},
+				{Name: "Content-Type"},
+			},
+		})
+		err = probe.Check(host, 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	{
+		var probe = newProbe(&ProbeConfig{
+			StatusCodes: []int{204},
+		})
+		err = probe.Check(host, 5*time.Second)
+		if err == nil {
+			t.Fatal("status code should not match")
+		}
+		if !IsNonRetryableError(err) {
+			t.Fatal("status code mismatch should not be retried")
+		}
+		t.Log(err)
+	}
+
+	{
+		var probe = newProbe(&ProbeConfig{
+			BodyRegexp: "failed",
+		})
+		err = probe.Check(host, 5*time.Second)
+		if err == nil {
+			t.Fatal("body should not match")
+		}
+		t.Log(err)
+	}
+
+	{
+		var probe = newProbe(&ProbeConfig{
+			Headers: []*ProbeHeaderConfig{
+				{Name: "X-Status", Value: "node-ok-2"},
+			},
+		})
+		err = probe.Check(host, 5*time.Second)
+		if err == nil {
+			t.Fatal("header should not match")
+		}
+		t.Log(err)
+	}
+}
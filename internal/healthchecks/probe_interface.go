@@ -0,0 +1,14 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import "time"
+
+// ProbeInterface 健康检查探测接口
+type ProbeInterface interface {
+	// Init 初始化
+	Init(config *ProbeConfig) error
+
+	// Check 检查节点，addr为节点的IP地址，返回nil表示检查通过
+	Check(addr string, timeout time.Duration) error
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"net"
+	"strconv"
+	"time"
+)
+
+// TCPProbe 检查端口是否可以连接
+type TCPProbe struct {
+	config *ProbeConfig
+}
+
+// Init 初始化
+func (this *TCPProbe) Init(config *ProbeConfig) error {
+	if config.Port <= 0 {
+		return errors.New("'port' should be greater than 0")
+	}
+	this.config = config
+	return nil
+}
+
+// Check 检查节点
+func (this *TCPProbe) Check(addr string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(addr, strconv.Itoa(this.config.Port)), timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPProbe_Check(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var port = listener.Addr().(*net.TCPAddr).Port
+
+	var probe = &TCPProbe{}
+	err = probe.Init(&ProbeConfig{Port: port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = probe.Check("127.0.0.1", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 关闭后应该失败
+	_ = listener.Close()
+	err = probe.Check("127.0.0.1", 2*time.Second)
+	if err == nil {
+		t.Fatal("should fail after listener closed")
+	}
+	t.Log(err)
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"crypto/tls"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"net"
+	"strconv"
+	"time"
+)
+
+// TLSProbe 检查TLS握手是否成功，以及证书是否即将过期
+type TLSProbe struct {
+	config *ProbeConfig
+}
+
+// Init 初始化
+func (this *TLSProbe) Init(config *ProbeConfig) error {
+	if config.Port <= 0 {
+		config.Port = 443
+	}
+	if config.MinCertDays < 0 {
+		return errors.New("'minCertDays' should not be negative")
+	}
+	this.config = config
+	return nil
+}
+
+// Check 检查节点
+func (this *TLSProbe) Check(addr string, timeout time.Duration) error {
+	// 节点通过IP访问，所以不校验证书链，只检查有效期
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", net.JoinHostPort(addr, strconv.Itoa(this.config.Port)), &tls.Config{
+		ServerName:         this.config.ServerName,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("no certificate found")
+	}
+
+	var now = time.Now()
+	var notAfter = certs[0].NotAfter
+	if now.After(notAfter) {
+		return errors.New("certificate has been expired at " + notAfter.Format("2006-01-02 15:04:05"))
+	}
+	if this.config.MinCertDays > 0 && notAfter.Sub(now) < time.Duration(this.config.MinCertDays)*24*time.Hour {
+		return errors.New("certificate will expire at " + notAfter.Format("2006-01-02 15:04:05") + ", less than " + strconv.Itoa(this.config.MinCertDays) + " days")
+	}
+	return nil
+}
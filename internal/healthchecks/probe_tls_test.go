@@ -0,0 +1,46 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTLSProbe_Check(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+
+	var port = server.Listener.Addr().(*net.TCPAddr).Port
+	var notAfter = server.Certificate().NotAfter
+	var days = int(time.Until(notAfter).Hours() / 24)
+
+	{
+		var probe = &TLSProbe{}
+		err := probe.Init(&ProbeConfig{Port: port, MinCertDays: 30})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = probe.Check("127.0.0.1", 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 要求的剩余天数超过证书有效期
+	{
+		var probe = &TLSProbe{}
+		err := probe.Init(&ProbeConfig{Port: port, MinCertDays: days + 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = probe.Check("127.0.0.1", 5*time.Second)
+		if err == nil {
+			t.Fatal("certificate should be expiring")
+		}
+		t.Log(err)
+	}
+}
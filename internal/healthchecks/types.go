@@ -0,0 +1,74 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package healthchecks
+
+import "github.com/iwind/TeaGo/maps"
+
+type ProbeType = string
+
+// 探测方式代号
+const (
+	ProbeTypeHTTP ProbeType = "http" // HTTP请求
+	ProbeTypeTCP  ProbeType = "tcp"  // TCP连接
+	ProbeTypeTLS  ProbeType = "tls"  // TLS握手
+	ProbeTypeDNS  ProbeType = "dns"  // DNS查询
+	ProbeTypeGRPC ProbeType = "grpc" // gRPC健康检查协议
+)
+
+// FindAllProbeTypes 所有的探测方式
+func FindAllProbeTypes() []maps.Map {
+	return []maps.Map{
+		{
+			"name":        "HTTP",
+			"code":        ProbeTypeHTTP,
+			"description": "请求URL，检查状态码、响应内容、响应报头和响应时间。",
+		},
+		{
+			"name":        "TCP",
+			"code":        ProbeTypeTCP,
+			"description": "检查端口是否可以连接。",
+		},
+		{
+			"name":        "TLS",
+			"code":        ProbeTypeTLS,
+			"description": "检查TLS握手是否成功，以及证书是否即将过期。",
+		},
+		{
+			"name":        "DNS",
+			"code":        ProbeTypeDNS,
+			"description": "向节点发送DNS查询，适用于NS集群。",
+		},
+		{
+			"name":        "gRPC",
+			"code":        ProbeTypeGRPC,
+			"description": "使用gRPC标准健康检查协议检查服务状态。",
+		},
+	}
+}
+
+// FindProbe 查找探测实例
+func FindProbe(probeType ProbeType) ProbeInterface {
+	switch probeType {
+	case ProbeTypeHTTP, "":
+		return &HTTPProbe{}
+	case ProbeTypeTCP:
+		return &TCPProbe{}
+	case ProbeTypeTLS:
+		return &TLSProbe{}
+	case ProbeTypeDNS:
+		return &DNSProbe{}
+	case ProbeTypeGRPC:
+		return &GRPCProbe{}
+	}
+	return nil
+}
+
+// FindProbeTypeName 查找探测方式名称
+func FindProbeTypeName(probeType ProbeType) string {
+	for _, t := range FindAllProbeTypes() {
+		if t.GetString("code") == probeType {
+			return t.GetString("name")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package messagemedias
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailMedia 邮件媒介
+type EmailMedia struct {
+	SMTP     string `json:"smtp"`     // SMTP服务地址，格式为host:port
+	Username string `json:"username"` // 用户名，为空表示不需要认证
+	Password string `json:"password"` // 密码
+	From     string `json:"from"`     // 发件人地址，为空时使用用户名
+	FromName string `json:"fromName"` // 发件人名称
+
+	Timeout time.Duration `json:"-"`
+}
+
+// NewEmailMedia 获取新对象
+func NewEmailMedia() *EmailMedia {
+	return &EmailMedia{}
+}
+
+// RequireUser 是否需要接收人标识
+func (this *EmailMedia) RequireUser() bool {
+	return true
+}
+
+// Send 发送消息
+func (this *EmailMedia) Send(user string, subject string, body string) (response []byte, err error) {
+	if len(this.SMTP) == 0 {
+		return nil, errors.New("'smtp' should not be empty")
+	}
+	if len(user) == 0 {
+		return nil, errors.New("recipient email should not be empty")
+	}
+
+	host, port, err := net.SplitHostPort(this.SMTP)
+	if err != nil {
+		// 没有端口时使用默认端口
+		host = this.SMTP
+		port = "25"
+	}
+
+	var from = this.From
+	if len(from) == 0 {
+		from = this.Username
+	}
+	if len(from) == 0 {
+		return nil, errors.New("'from' should not be empty")
+	}
+
+	var timeout = this.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	// 465端口使用TLS直接连接，其他端口在服务支持时使用STARTTLS
+	var addr = net.JoinHostPort(host, port)
+	var conn net.Conn
+	if port == "465" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout * 3))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if port != "465" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			err = client.StartTLS(&tls.Config{ServerName: host})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(this.Username) > 0 {
+		if ok, _ := client.Extension("AUTH"); ok {
+			err = client.Auth(smtp.PlainAuth("", this.Username, this.Password, host))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = client.Mail(from)
+	if err != nil {
+		return nil, err
+	}
+	for _, to := range strings.Split(user, ",") {
+		to = strings.TrimSpace(to)
+		if len(to) == 0 {
+			continue
+		}
+		err = client.Rcpt(to)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return nil, err
+	}
+	_, err = writer.Write(this.composeMessage(from, user, subject, body))
+	if err != nil {
+		return nil, err
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, client.Quit()
+}
+
+// 组合邮件内容
+func (this *EmailMedia) composeMessage(from string, to string, subject string, body string) []byte {
+	var fromHeader = from
+	if len(this.FromName) > 0 {
+		fromHeader = mime.BEncoding.Encode("UTF-8", this.FromName) + " <" + from + ">"
+	}
+
+	var buf = &bytes.Buffer{}
+	buf.WriteString("From: " + fromHeader + "\r\n")
+	buf.WriteString("To: " + to + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("\r\n")
+
+	// 每行不超过76个字符
+	var encoded = base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package messagemedias
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+// 一个只用于测试的最简SMTP服务
+func startTestSMTPServer(t *testing.T) (addr string, messages chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	messages = make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		var reader = bufio.NewReader(conn)
+		var reply = func(s string) {
+			_, _ = conn.Write([]byte(s + "\r\n"))
+		}
+		reply("220 localhost ESMTP")
+
+		var data = &strings.Builder{}
+		var inData = false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					messages <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			var command = strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "DATA"):
+				inData = true
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case strings.HasPrefix(command, "QUIT"):
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), messages
+}
+
+func TestEmailMedia_Send(t *testing.T) {
+	addr, messages := startTestSMTPServer(t)
+
+	media, err := NewMedia(MediaTypeEmail, []byte(`{"smtp":"`+addr+`","from":"edge@example.com","fromName":"GoEdge"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = media.Send("admin@example.com", "节点异常", "Hello, World")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var message = <-messages
+	if !strings.Contains(message, "To: admin@example.com") {
+		t.Fatal("recipient not found in message:", message)
+	}
+	if !strings.Contains(message, "Subject: =?UTF-8?b?") {
+		t.Fatal("subject should be encoded:", message)
+	}
+	t.Log(message)
+}
+
+func TestEmailMedia_Send_NoUser(t *testing.T) {
+	var media = &EmailMedia{SMTP: "127.0.0.1:25", From: "edge@example.com"}
+	_, err := media.Send("", "Hello", "World")
+	if err == nil {
+		t.Fatal("should fail without recipient")
+	}
+}
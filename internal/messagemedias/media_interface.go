@@ -0,0 +1,12 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package messagemedias
+
+// MediaInterface 消息媒介接口
+type MediaInterface interface {
+	// RequireUser 是否需要接收人标识
+	RequireUser() bool
+
+	// Send 发送消息，返回对方的响应内容
+	Send(user string, subject string, body string) (response []byte, err error)
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package messagemedias
+
+import (
+	"bytes"
+	"context"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ScriptMedia 脚本媒介
+// 消息内容通过环境变量MessageUser、MessageSubject、MessageBody传入脚本
+// 同时兼容监控节点中scriptType为code的设置，此时脚本代码会写入临时文件后运行
+type ScriptMedia struct {
+	Path    string          `json:"path"`    // 脚本路径
+	Cwd     string          `json:"cwd"`     // 工作目录
+	Env     []*ScriptEnvVar `json:"env"`     // 附加的环境变量
+	Timeout int             `json:"timeout"` // 超时时间（秒）
+
+	ScriptType string `json:"scriptType"` // 脚本类型：path|code
+	ScriptLang string `json:"scriptLang"` // 脚本语言，比如bash、python
+	Script     string `json:"script"`     // 脚本代码
+}
+
+// ScriptEnvVar 环境变量
+type ScriptEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NewScriptMedia 获取新对象
+func NewScriptMedia() *ScriptMedia {
+	return &ScriptMedia{}
+}
+
+// RequireUser 是否需要接收人标识
+func (this *ScriptMedia) RequireUser() bool {
+	return false
+}
+
+// Send 发送消息
+func (this *ScriptMedia) Send(user string, subject string, body string) (response []byte, err error) {
+	var path = this.Path
+	if this.ScriptType == "code" {
+		if len(this.Script) == 0 {
+			return nil, errors.New("'script' should not be empty")
+		}
+		path, err = this.writeScript()
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = os.Remove(path)
+		}()
+	}
+	if len(path) == 0 {
+		return nil, errors.New("'path' should not be empty")
+	}
+
+	var timeout = this.Timeout
+	if timeout <= 0 {
+		timeout = 30
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	var cmd = exec.CommandContext(ctx, path)
+	if len(this.Cwd) > 0 {
+		cmd.Dir = this.Cwd
+	}
+	cmd.Env = append(os.Environ(), "MessageUser="+user, "MessageSubject="+subject, "MessageBody="+body)
+	for _, env := range this.Env {
+		if len(env.Name) == 0 {
+			continue
+		}
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+
+	var stdout = &bytes.Buffer{}
+	var stderr = &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return stdout.Bytes(), errors.New("run script timeout")
+	}
+	if err != nil {
+		var errString = strings.TrimSpace(stderr.String())
+		if len(errString) > 0 {
+			return stdout.Bytes(), errors.New(err.Error() + ": " + errString)
+		}
+		return stdout.Bytes(), err
+	}
+	return stdout.Bytes(), nil
+}
+
+// 将脚本代码写入临时文件
+func (this *ScriptMedia) writeScript() (string, error) {
+	var script = this.Script
+	if !strings.HasPrefix(script, "#!") {
+		var lang = this.ScriptLang
+		if len(lang) == 0 {
+			lang = "sh"
+		}
+		script = "#!/usr/bin/env " + lang + "\n" + script
+	}
+
+	fp, err := ioutil.TempFile("", "message-script-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = fp.WriteString(script)
+	if err == nil {
+		err = fp.Chmod(0700)
+	}
+	closeErr := fp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(fp.Name())
+		return "", err
+	}
+	return fp.Name(), nil
+}
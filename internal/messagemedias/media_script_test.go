@@ -0,0 +1,66 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package messagemedias
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestScriptMedia_Send(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skip on windows")
+	}
+
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "send.sh")
+	err := ioutil.WriteFile(path, []byte(`#!/bin/sh
+if [ "$MessageSubject" = "fail" ]; then
+	echo "something wrong" >&2
+	exit 1
+fi
+echo "$MessageUser|$MessageSubject|$MessageBody|$Extra"
+`), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var media = &ScriptMedia{
+		Path: path,
+		Env:  []*ScriptEnvVar{{Name: "Extra", Value: "1"}},
+	}
+	response, err := media.Send("admin", "Hello", "World")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(response)) != "admin|Hello|World|1" {
+		t.Fatal("unexpected output:", string(response))
+	}
+
+	_, err = media.Send("admin", "fail", "World")
+	if err == nil || !strings.Contains(err.Error(), "something wrong") {
+		t.Fatal("should fail with stderr, but got:", err)
+	}
+}
+
+func TestScriptMedia_Send_Code(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skip on windows")
+	}
+
+	// 监控节点中的设置
+	media, err := NewMedia(MediaTypeScript, []byte(`{"path":"","scriptType":"code","scriptLang":"sh","script":"echo \"$MessageSubject|$Extra\"","cwd":"","env":[{"name":"Extra","value":"1"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := media.Send("admin", "Hello", "World")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(response)) != "Hello|1" {
+		t.Fatal("unexpected output:", string(response))
+	}
+}
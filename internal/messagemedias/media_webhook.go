@@ -0,0 +1,245 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package messagemedias
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/iwind/TeaGo/maps"
+	"github.com/iwind/TeaGo/types"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 响应内容最大读取长度
+const webHookMaxResponseSize = 64 * 1024
+
+// WebHookMedia WebHook媒介
+// 以JSON格式发送消息，如果设置了密钥，则在报头中加入签名：
+// X-Edge-Timestamp: 发送时间戳
+// X-Edge-Signature: hex(HMAC-SHA256(secret, timestamp + "." + body))
+//
+// 同时兼容监控节点的WebHook设置：params为name/value列表，contentType为params|body，
+// URL、参数和body中可以使用${MessageUser}、${MessageSubject}、${MessageBody}变量
+type WebHookMedia struct {
+	URL     string           `json:"url"`     // URL
+	Method  string           `json:"method"`  // 请求方法，默认为POST
+	Headers []*WebHookHeader `json:"headers"` // 自定义报头
+	Secret  string           `json:"secret"`  // 签名密钥
+	Timeout int              `json:"timeout"` // 超时时间（秒）
+	Params  maps.Map         `json:"params"`  // 附加到请求内容中的参数
+
+	ContentType string `json:"contentType"` // 监控节点设置中的内容类型：params|body
+	Body        string `json:"body"`        // 监控节点设置中的请求内容
+
+	legacyParams []*WebHookHeader // 监控节点设置中的参数
+	isLegacy     bool
+}
+
+// WebHookHeader 自定义报头
+type WebHookHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NewWebHookMedia 获取新对象
+func NewWebHookMedia() *WebHookMedia {
+	return &WebHookMedia{}
+}
+
+// UnmarshalJSON 解析参数，兼容监控节点中的设置格式
+func (this *WebHookMedia) UnmarshalJSON(data []byte) error {
+	type webHookMedia WebHookMedia
+	var media = &struct {
+		*webHookMedia
+		Params json.RawMessage `json:"params"`
+	}{
+		webHookMedia: (*webHookMedia)(this),
+	}
+	err := json.Unmarshal(data, media)
+	if err != nil {
+		return err
+	}
+
+	this.isLegacy = len(this.ContentType) > 0 || len(this.Body) > 0
+
+	var paramsJSON = bytes.TrimSpace(media.Params)
+	if len(paramsJSON) == 0 || string(paramsJSON) == "null" {
+		return nil
+	}
+	if paramsJSON[0] == '[' {
+		this.isLegacy = true
+		return json.Unmarshal(paramsJSON, &this.legacyParams)
+	}
+	return json.Unmarshal(paramsJSON, &this.Params)
+}
+
+// RequireUser 是否需要接收人标识
+func (this *WebHookMedia) RequireUser() bool {
+	return false
+}
+
+// Send 发送消息
+func (this *WebHookMedia) Send(user string, subject string, body string) (response []byte, err error) {
+	if len(this.URL) == 0 {
+		return nil, errors.New("'url' should not be empty")
+	}
+
+	var req *http.Request
+	if this.isLegacy {
+		req, err = this.newLegacyRequest(user, subject, body)
+	} else {
+		req, err = this.newRequest(user, subject, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "GoEdge-API")
+	for _, header := range this.Headers {
+		if len(header.Name) == 0 {
+			continue
+		}
+		req.Header.Set(header.Name, header.Value)
+	}
+
+	var timeout = this.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	var client = &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	response, err = ioutil.ReadAll(io.LimitReader(resp.Body, webHookMaxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return response, errors.New("unexpected response status code: " + types.String(resp.StatusCode))
+	}
+	return response, nil
+}
+
+// 构造JSON格式的请求
+func (this *WebHookMedia) newRequest(user string, subject string, body string) (*http.Request, error) {
+	var method = strings.ToUpper(this.Method)
+	if len(method) == 0 {
+		method = http.MethodPost
+	}
+
+	var timestamp = types.String(time.Now().Unix())
+	var payload = maps.Map{
+		"user":      user,
+		"subject":   subject,
+		"body":      body,
+		"timestamp": timestamp,
+	}
+	for k, v := range this.Params {
+		_, ok := payload[k]
+		if !ok {
+			payload[k] = v
+		}
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, this.URL, bytes.NewReader(payloadJSON))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if len(this.Secret) > 0 {
+		req.Header.Set("X-Edge-Timestamp", timestamp)
+		req.Header.Set("X-Edge-Signature", SignWebHook(this.Secret, timestamp, payloadJSON))
+	}
+	return req, nil
+}
+
+// 按照监控节点的方式构造请求
+func (this *WebHookMedia) newLegacyRequest(user string, subject string, body string) (*http.Request, error) {
+	var method = strings.ToUpper(this.Method)
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+
+	var replacer = func(s string, escape func(string) string) string {
+		return strings.NewReplacer(
+			"${MessageUser}", escape(user),
+			"${MessageSubject}", escape(subject),
+			"${MessageBody}", escape(body),
+		).Replace(s)
+	}
+	var rawString = func(s string) string {
+		return s
+	}
+	var jsonString = func(s string) string {
+		data, err := json.Marshal(s)
+		if err != nil {
+			return s
+		}
+		return string(data[1 : len(data)-1])
+	}
+
+	var requestURL = replacer(this.URL, url.QueryEscape)
+	if method == http.MethodGet {
+		return http.NewRequest(method, requestURL, nil)
+	}
+
+	var contentType = "application/x-www-form-urlencoded"
+	var postBody string
+	switch this.ContentType {
+	case "params":
+		var values = url.Values{}
+		for _, param := range this.legacyParams {
+			if len(param.Name) == 0 {
+				continue
+			}
+			values.Add(param.Name, replacer(param.Value, rawString))
+		}
+		postBody = values.Encode()
+	case "body":
+		contentType = ""
+		postBody = replacer(this.Body, jsonString)
+	default:
+		postBody = url.Values{
+			"MessageUser":    []string{user},
+			"MessageSubject": []string{subject},
+			"MessageBody":    []string{body},
+		}.Encode()
+	}
+
+	req, err := http.NewRequest(method, requestURL, strings.NewReader(postBody))
+	if err != nil {
+		return nil, err
+	}
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+// SignWebHook 计算WebHook签名
+func SignWebHook(secret string, timestamp string, body []byte) string {
+	var h = hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package messagemedias
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebHookMedia_Send(t *testing.T) {
+	var payload = map[string]string{}
+	var isSigned bool
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		isSigned = req.Header.Get("X-Edge-Signature") == SignWebHook("123456", req.Header.Get("X-Edge-Timestamp"), data)
+		_ = json.Unmarshal(data, &payload)
+		if req.URL.Path == "/fail" {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = writer.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	{
+		media, err := NewMedia(MediaTypeWebHook, []byte(`{"url":"`+server.URL+`/hook","secret":"123456"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response, err := media.Send("admin", "Hello", "World")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(response) != "ok" {
+			t.Fatal("unexpected response:", string(response))
+		}
+		if !isSigned {
+			t.Fatal("signature should be valid")
+		}
+		if payload["user"] != "admin" || payload["subject"] != "Hello" || payload["body"] != "World" {
+			t.Fatal("unexpected payload:", payload)
+		}
+	}
+
+	{
+		var media = &WebHookMedia{URL: server.URL + "/fail"}
+		_, err := media.Send("", "Hello", "World")
+		if err == nil {
+			t.Fatal("should fail with 500 status code")
+		}
+		t.Log("expected error:", err)
+	}
+}
+
+func TestWebHookMedia_Send_Legacy(t *testing.T) {
+	var query string
+	var contentType string
+	var requestBody string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		data, _ := ioutil.ReadAll(req.Body)
+		query = req.URL.RawQuery
+		contentType = req.Header.Get("Content-Type")
+		requestBody = string(data)
+		_, _ = writer.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// 监控节点中的设置
+	{
+		media, err := NewMedia(MediaTypeWebHook, []byte(`{"url":"`+server.URL+`/hook","method":"POST","contentType":"params","headers":[{"name":"X-Token","value":"abc"}],"params":[{"name":"title","value":"${MessageSubject}"},{"name":"content","value":"${MessageBody}"}],"body":""}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = media.Send("admin", "Hello", "World & more")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contentType != "application/x-www-form-urlencoded" || requestBody != "content=World+%26+more&title=Hello" {
+			t.Fatal("unexpected request:", contentType, requestBody)
+		}
+	}
+
+	{
+		media, err := NewMedia(MediaTypeWebHook, []byte(`{"url":"`+server.URL+`/hook","method":"POST","contentType":"body","headers":[],"params":[],"body":"{\"text\":\"${MessageSubject}: ${MessageBody}\"}"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = media.Send("admin", "Hello", "\"World\"")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var payload = map[string]string{}
+		err = json.Unmarshal([]byte(requestBody), &payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if payload["text"] != "Hello: \"World\"" {
+			t.Fatal("unexpected body:", requestBody)
+		}
+	}
+
+	{
+		media, err := NewMedia(MediaTypeWebHook, []byte(`{"url":"`+server.URL+`/hook?subject=${MessageSubject}","method":"GET","contentType":"params","headers":[],"params":[],"body":""}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = media.Send("admin", "Hello World", "World")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != "subject=Hello+World" {
+			t.Fatal("unexpected query:", query)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package messagemedias
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/iwind/TeaGo/maps"
+)
+
+type MediaType = string
+
+// 媒介类型代号
+// 这里只列出API节点可以直接发送的媒介，其他媒介仍然由监控节点发送
+const (
+	MediaTypeEmail   MediaType = "email"   // 邮件
+	MediaTypeWebHook MediaType = "webHook" // WebHook
+	MediaTypeScript  MediaType = "script"  // 脚本
+)
+
+// FindAllMediaTypes 所有可以直接发送的媒介
+func FindAllMediaTypes() []maps.Map {
+	return []maps.Map{
+		{
+			"name":        "邮件",
+			"code":        MediaTypeEmail,
+			"description": "通过SMTP服务发送邮件。",
+		},
+		{
+			"name":        "WebHook",
+			"code":        MediaTypeWebHook,
+			"description": "以JSON格式向URL发送请求，可以使用密钥对内容进行HMAC-SHA256签名。",
+		},
+		{
+			"name":        "脚本",
+			"code":        MediaTypeScript,
+			"description": "在API节点上运行脚本，消息通过环境变量传入。",
+		},
+	}
+}
+
+// FindAllMediaTypeCodes 所有可以直接发送的媒介代号
+func FindAllMediaTypeCodes() []string {
+	var result = []string{}
+	for _, t := range FindAllMediaTypes() {
+		result = append(result, t.GetString("code"))
+	}
+	return result
+}
+
+// NewMedia 根据类型和参数创建媒介
+func NewMedia(mediaType MediaType, paramsJSON []byte) (MediaInterface, error) {
+	var media MediaInterface
+	switch mediaType {
+	case MediaTypeEmail:
+		media = NewEmailMedia()
+	case MediaTypeWebHook:
+		media = NewWebHookMedia()
+	case MediaTypeScript:
+		media = NewScriptMedia()
+	default:
+		return nil, errors.New("unsupported media type '" + mediaType + "'")
+	}
+
+	if len(paramsJSON) > 0 && string(paramsJSON) != "null" {
+		err := json.Unmarshal(paramsJSON, media)
+		if err != nil {
+			return nil, errors.New("decode params failed: " + err.Error())
+		}
+	}
+	return media, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/TeaOSLab/EdgeAPI/internal/configs"
 	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/encrypt"
 	"github.com/TeaOSLab/EdgeAPI/internal/events"
 	"github.com/TeaOSLab/EdgeAPI/internal/remotelogs"
 	"github.com/TeaOSLab/EdgeAPI/internal/setup"
@@ -28,9 +29,11 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"os/signal"
 	"regexp"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
 )
 
@@ -60,6 +63,13 @@ func (this *APINode) Start() {
 		return
 	}
 
+	// 检查敏感数据主密钥
+	_, err = encrypt.SharedSecretBox()
+	if err != nil {
+		logs.Println("[API_NODE]load secret keys failed: " + err.Error())
+		return
+	}
+
 	// 本地Sock
 	err = this.listenSock()
 	if err != nil {
@@ -111,6 +121,18 @@ func (this *APINode) Start() {
 	// 状态变更计时器
 	go NewNodeStatusExecutor().Listen()
 
+	// 访问日志批量写入
+	if config.AccessLog != nil {
+		models.SharedHTTPAccessLogBuffer.Configure(config.AccessLog.BatchSize, time.Duration(config.AccessLog.FlushInterval)*time.Millisecond)
+	}
+	go models.SharedHTTPAccessLogBuffer.Start()
+	events.On(events.EventQuit, func() {
+		err := models.SharedHTTPAccessLogBuffer.Flush()
+		if err != nil {
+			logs.Println("[API_NODE]flush access logs failed: " + err.Error())
+		}
+	})
+
 	// 访问日志存储管理器
 	go accesslogs.SharedStorageManager.Start()
 
@@ -505,6 +527,9 @@ func (this *APINode) listenSock() error {
 				// 退出主进程
 				events.Notify(events.EventQuit)
 				os.Exit(0)
+			case "reload":
+				events.Notify(events.EventReload)
+				_ = cmd.ReplyOk()
 			}
 		})
 
@@ -519,5 +544,14 @@ func (this *APINode) listenSock() error {
 		_ = this.sock.Close()
 	})
 
+	// 收到SIGHUP信号时重新加载
+	go func() {
+		var signalChan = make(chan os.Signal, 1)
+		signal.Notify(signalChan, syscall.SIGHUP)
+		for range signalChan {
+			events.Notify(events.EventReload)
+		}
+	}()
+
 	return nil
 }
@@ -188,6 +188,11 @@ func (this *APINode) registerServices(server *grpc.Server) {
 		pb.RegisterMessageRecipientGroupServiceServer(server, instance)
 		this.rest(instance)
 	}
+	{
+		instance := this.serviceInstance(&services.MessageRouteService{}).(*services.MessageRouteService)
+		pb.RegisterMessageRouteServiceServer(server, instance)
+		this.rest(instance)
+	}
 	{
 		instance := this.serviceInstance(&services.MessageMediaInstanceService{}).(*services.MessageMediaInstanceService)
 		pb.RegisterMessageMediaInstanceServiceServer(server, instance)
@@ -33,3 +33,28 @@ func (this *ACMEAuthenticationService) FindACMEAuthenticationKeyWithToken(ctx co
 	}
 	return &pb.FindACMEAuthenticationKeyWithTokenResponse{Key: auth.Key}, nil
 }
+
+// FindACMEAuthenticationCertWithDomain 获取TLS-ALPN认证证书
+func (this *ACMEAuthenticationService) FindACMEAuthenticationCertWithDomain(ctx context.Context, req *pb.FindACMEAuthenticationCertWithDomainRequest) (*pb.FindACMEAuthenticationCertWithDomainResponse, error) {
+	_, err := this.ValidateNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Domain) == 0 {
+		return nil, errors.New("'domain' should not be empty")
+	}
+
+	tx := this.NullTx()
+
+	auth, err := acme.SharedACMEAuthenticationDAO.FindTLSALPNAuthWithDomain(tx, req.Domain)
+	if err != nil {
+		return nil, err
+	}
+	if auth == nil {
+		return &pb.FindACMEAuthenticationCertWithDomainResponse{}, nil
+	}
+	return &pb.FindACMEAuthenticationCertWithDomainResponse{
+		CertData: []byte(auth.CertData),
+		KeyData:  []byte(auth.KeyData),
+	}, nil
+}
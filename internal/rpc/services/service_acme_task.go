@@ -99,7 +99,7 @@ func (this *ACMETaskService) ListEnabledACMETasks(ctx context.Context, req *pb.L
 		}
 
 		var pbProvider *pb.DNSProvider
-		if task.AuthType == acme.AuthTypeDNS {
+		if task.HasAuthType(acme.AuthTypeDNS) {
 			// DNS
 			provider, err := dns.SharedDNSProviderDAO.FindEnabledDNSProvider(tx, int64(task.DnsProviderId))
 			if err != nil {
@@ -162,7 +162,9 @@ func (this *ACMETaskService) ListEnabledACMETasks(ctx context.Context, req *pb.L
 			SslCert:           pbCert,
 			LatestACMETaskLog: pbTaskLog,
 			AuthType:          task.AuthType,
+			AuthTypes:         task.DecodeAuthTypes(),
 			AuthURL:           task.AuthURL,
+			KeyType:           task.KeyType,
 		})
 	}
 
@@ -176,12 +178,12 @@ func (this *ACMETaskService) CreateACMETask(ctx context.Context, req *pb.CreateA
 		return nil, err
 	}
 
-	if len(req.AuthType) == 0 {
+	if len(req.AuthType) == 0 && len(req.AuthTypes) == 0 {
 		req.AuthType = acme.AuthTypeDNS
 	}
 
 	tx := this.NullTx()
-	taskId, err := acmemodels.SharedACMETaskDAO.CreateACMETask(tx, adminId, userId, req.AuthType, req.AcmeUserId, req.DnsProviderId, req.DnsDomain, req.Domains, req.AutoRenew, req.AuthURL)
+	taskId, err := acmemodels.SharedACMETaskDAO.CreateACMETask(tx, adminId, userId, req.AuthType, req.AuthTypes, req.AcmeUserId, req.DnsProviderId, req.DnsDomain, req.Domains, req.AutoRenew, req.AuthURL, req.KeyType)
 	if err != nil {
 		return nil, err
 	}
@@ -205,7 +207,7 @@ func (this *ACMETaskService) UpdateACMETask(ctx context.Context, req *pb.UpdateA
 		return nil, this.PermissionError()
 	}
 
-	err = acmemodels.SharedACMETaskDAO.UpdateACMETask(tx, req.AcmeTaskId, req.AcmeUserId, req.DnsProviderId, req.DnsDomain, req.Domains, req.AutoRenew, req.AuthURL)
+	err = acmemodels.SharedACMETaskDAO.UpdateACMETask(tx, req.AcmeTaskId, req.AuthTypes, req.AcmeUserId, req.DnsProviderId, req.DnsDomain, req.Domains, req.AutoRenew, req.AuthURL, req.KeyType)
 	if err != nil {
 		return nil, err
 	}
@@ -329,6 +331,8 @@ func (this *ACMETaskService) FindEnabledACMETask(ctx context.Context, req *pb.Fi
 		DnsProvider: pbProvider,
 		AcmeUser:    pbACMEUser,
 		AuthType:    task.AuthType,
+		AuthTypes:   task.DecodeAuthTypes(),
 		AuthURL:     task.AuthURL,
+		KeyType:     task.KeyType,
 	}}, nil
 }
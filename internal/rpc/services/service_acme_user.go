@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"github.com/TeaOSLab/EdgeAPI/internal/acme"
 	acmemodels "github.com/TeaOSLab/EdgeAPI/internal/db/models/acme"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 )
@@ -21,7 +22,14 @@ func (this *ACMEUserService) CreateACMEUser(ctx context.Context, req *pb.CreateA
 
 	tx := this.NullTx()
 
-	acmeUserId, err := acmemodels.SharedACMEUserDAO.CreateACMEUser(tx, adminId, userId, req.Email, req.Description)
+	var eab *acme.EAB
+	if len(req.EabKid) > 0 {
+		eab = &acme.EAB{
+			KeyId:   req.EabKid,
+			HMACKey: req.EabHMACKey,
+		}
+	}
+	acmeUserId, err := acmemodels.SharedACMEUserDAO.CreateACMEUser(tx, adminId, userId, req.Email, req.Description, req.DirectoryURL, eab)
 	if err != nil {
 		return nil, err
 	}
@@ -114,10 +122,13 @@ func (this *ACMEUserService) ListACMEUsers(ctx context.Context, req *pb.ListACME
 	result := []*pb.ACMEUser{}
 	for _, user := range acmeUsers {
 		result = append(result, &pb.ACMEUser{
-			Id:          int64(user.Id),
-			Email:       user.Email,
-			Description: user.Description,
-			CreatedAt:   int64(user.CreatedAt),
+			Id:           int64(user.Id),
+			Email:        user.Email,
+			Description:  user.Description,
+			CreatedAt:    int64(user.CreatedAt),
+			DirectoryURL: user.DirectoryURL,
+			ProviderName: user.ProviderName(),
+			HasEAB:       user.DecodeEAB() != nil,
 		})
 	}
 	return &pb.ListACMEUsersResponse{AcmeUsers: result}, nil
@@ -150,10 +161,13 @@ func (this *ACMEUserService) FindEnabledACMEUser(ctx context.Context, req *pb.Fi
 		return &pb.FindEnabledACMEUserResponse{AcmeUser: nil}, nil
 	}
 	return &pb.FindEnabledACMEUserResponse{AcmeUser: &pb.ACMEUser{
-		Id:          int64(acmeUser.Id),
-		Email:       acmeUser.Email,
-		Description: acmeUser.Description,
-		CreatedAt:   int64(acmeUser.CreatedAt),
+		Id:           int64(acmeUser.Id),
+		Email:        acmeUser.Email,
+		Description:  acmeUser.Description,
+		CreatedAt:    int64(acmeUser.CreatedAt),
+		DirectoryURL: acmeUser.DirectoryURL,
+		ProviderName: acmeUser.ProviderName(),
+		HasEAB:       acmeUser.DecodeEAB() != nil,
 	}}, nil
 }
 
@@ -174,11 +188,38 @@ func (this *ACMEUserService) FindAllACMEUsers(ctx context.Context, req *pb.FindA
 	result := []*pb.ACMEUser{}
 	for _, user := range acmeUsers {
 		result = append(result, &pb.ACMEUser{
-			Id:          int64(user.Id),
-			Email:       user.Email,
-			Description: user.Description,
-			CreatedAt:   int64(user.CreatedAt),
+			Id:           int64(user.Id),
+			Email:        user.Email,
+			Description:  user.Description,
+			CreatedAt:    int64(user.CreatedAt),
+			DirectoryURL: user.DirectoryURL,
+			ProviderName: user.ProviderName(),
+			HasEAB:       user.DecodeEAB() != nil,
 		})
 	}
 	return &pb.FindAllACMEUsersResponse{AcmeUsers: result}, nil
 }
+
+// FindAllACMEProviders 查找所有内置的证书服务商
+func (this *ACMEUserService) FindAllACMEProviders(ctx context.Context, req *pb.FindAllACMEProvidersRequest) (*pb.FindAllACMEProvidersResponse, error) {
+	_, _, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var pbProviders = []*pb.ACMEProvider{}
+	for _, provider := range acme.FindAllProviders() {
+		pbProviders = append(pbProviders, &pb.ACMEProvider{
+			Name:         provider.Name,
+			Code:         provider.Code,
+			Description:  provider.Description,
+			DirectoryURL: provider.DirectoryURL,
+			RequireEAB:   provider.RequireEAB,
+		})
+	}
+	return &pb.FindAllACMEProvidersResponse{
+		AcmeProviders: pbProviders,
+		KeyTypes:      acme.FindAllKeyTypes(),
+		AuthTypes:     acme.FindAllAuthTypes(),
+	}, nil
+}
@@ -4,13 +4,10 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
-	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models/authority"
-	"github.com/TeaOSLab/EdgeAPI/internal/encrypt"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
 	rpcutils "github.com/TeaOSLab/EdgeAPI/internal/rpc/utils"
-	"github.com/TeaOSLab/EdgeAPI/internal/utils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/lists"
@@ -143,12 +140,8 @@ func (this *BaseService) ValidateNodeId(ctx context.Context, roles ...rpcutils.U
 		return rpcutils.UserTypeNone, 0, err
 	}
 
-	method, err := encrypt.NewMethodInstance(teaconst.EncryptMethod, apiToken.Secret, nodeId)
-	if err != nil {
-		utils.PrintError(err)
-		return rpcutils.UserTypeNone, 0, err
-	}
-	data, err = method.Decrypt(data)
+	// 新版本节点使用带认证的加密信封，旧版本节点仍然使用teaconst.EncryptMethod，直到节点升级后发送加密信封
+	data, err = models.SharedApiTokenDAO.DecodeToken(nil, apiToken, data)
 	if err != nil {
 		return rpcutils.UserTypeNone, 0, err
 	}
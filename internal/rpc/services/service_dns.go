@@ -3,6 +3,9 @@ package services
 import (
 	"context"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/tasks"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 )
 
@@ -50,3 +53,97 @@ func (this *DNSService) FindAllDNSIssues(ctx context.Context, req *pb.FindAllDNS
 
 	return &pb.FindAllDNSIssuesResponse{Issues: result}, nil
 }
+
+// PlanDNSChanges 计算集群、服务或域名需要同步的记录变更，但不执行
+// 优先级：服务 > 集群 > 域名
+func (this *DNSService) PlanDNSChanges(ctx context.Context, req *pb.PlanDNSChangesRequest) (*pb.PlanDNSChangesResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = this.NullTx()
+	var executor = tasks.NewDNSTaskExecutor()
+	var plans []*tasks.DNSPlan
+	switch {
+	case req.ServerId > 0:
+		plans, err = executor.PlanServer(tx, req.ServerId)
+	case req.NodeClusterId > 0:
+		plans, err = executor.PlanCluster(tx, req.NodeClusterId)
+	case req.DnsDomainId > 0:
+		plan, planErr := executor.PlanDomain(tx, req.DnsDomainId)
+		if plan != nil {
+			plans = []*tasks.DNSPlan{plan}
+		}
+		err = planErr
+	default:
+		return nil, errors.New("'serverId', 'nodeClusterId' or 'dnsDomainId' should be specified")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return &pb.PlanDNSChangesResponse{}, nil
+	}
+
+	var pbPlans = []*pb.DNSPlan{}
+	for _, plan := range plans {
+		pbPlans = append(pbPlans, this.convertPlanToPB(plan))
+	}
+
+	// 为了兼容，同时返回主域名的计划
+	return &pb.PlanDNSChangesResponse{
+		DnsDomain:  pbPlans[0].DnsDomain,
+		DnsChanges: pbPlans[0].DnsChanges,
+		DnsPlans:   pbPlans,
+	}, nil
+}
+
+// 转换计划信息
+func (this *DNSService) convertPlanToPB(plan *tasks.DNSPlan) *pb.DNSPlan {
+	var pbChanges = []*pb.DNSChange{}
+	for _, change := range plan.Changes {
+		var pbChange = &pb.DNSChange{
+			Action:        change.Action,
+			NodeClusterId: change.ClusterId,
+			ServerId:      change.ServerId,
+			DnsRecord:     convertRecordToPB(change.Record),
+		}
+		if change.OldRecord != nil {
+			pbChange.OldDnsRecord = convertRecordToPB(change.OldRecord)
+		}
+		pbChanges = append(pbChanges, pbChange)
+	}
+
+	return &pb.DNSPlan{
+		DnsDomain: &pb.DNSDomain{
+			Id:   plan.DomainId,
+			Name: plan.Domain,
+		},
+		DnsProvider: &pb.DNSProvider{
+			Id:   plan.ProviderId,
+			Name: plan.ProviderName,
+		},
+		IsPrimary:  plan.IsPrimary,
+		DnsChanges: pbChanges,
+		Error:      plan.Error,
+	}
+}
+
+// 转换DNS记录信息，DNS相关的服务共用
+func convertRecordToPB(record *dnstypes.Record) *pb.DNSRecord {
+	if record == nil {
+		return nil
+	}
+	return &pb.DNSRecord{
+		Id:       record.Id,
+		Name:     record.Name,
+		Value:    record.Value,
+		Type:     record.Type,
+		Route:    record.Route,
+		Ttl:      record.TTL,
+		Weight:   record.Weight,
+		Priority: record.Priority,
+	}
+}
@@ -117,3 +117,60 @@ func (this *DNSTaskService) DeleteDNSTask(ctx context.Context, req *pb.DeleteDNS
 	}
 	return this.Success()
 }
+
+// CountDNSTaskLogs 计算同步日志数量
+func (this *DNSTaskService) CountDNSTaskLogs(ctx context.Context, req *pb.CountDNSTaskLogsRequest) (*pb.RPCCountResponse, error) {
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = this.NullTx()
+	count, err := dns.SharedDNSTaskLogDAO.CountLogs(tx, req.DnsDomainId, req.NodeClusterId, req.ServerId)
+	if err != nil {
+		return nil, err
+	}
+	return this.SuccessCount(count)
+}
+
+// ListDNSTaskLogs 列出单页同步日志
+func (this *DNSTaskService) ListDNSTaskLogs(ctx context.Context, req *pb.ListDNSTaskLogsRequest) (*pb.ListDNSTaskLogsResponse, error) {
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = this.NullTx()
+	logs, err := dns.SharedDNSTaskLogDAO.ListLogs(tx, req.DnsDomainId, req.NodeClusterId, req.ServerId, req.Offset, req.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	var pbLogs = []*pb.DNSTaskLog{}
+	for _, log := range logs {
+		record, err := log.DecodeRecord()
+		if err != nil {
+			return nil, err
+		}
+		oldRecord, err := log.DecodeOldRecord()
+		if err != nil {
+			return nil, err
+		}
+
+		pbLogs = append(pbLogs, &pb.DNSTaskLog{
+			Id:            int64(log.Id),
+			DnsTaskId:     int64(log.TaskId),
+			Type:          log.TaskType,
+			NodeClusterId: int64(log.ClusterId),
+			ServerId:      int64(log.ServerId),
+			DnsDomainId:   int64(log.DomainId),
+			Action:        log.Action,
+			DnsRecord:     convertRecordToPB(record),
+			OldDnsRecord:  convertRecordToPB(oldRecord),
+			IsOk:          log.IsOk == 1,
+			Error:         log.Error,
+			CreatedAt:     int64(log.CreatedAt),
+		})
+	}
+	return &pb.ListDNSTaskLogsResponse{DnsTaskLogs: pbLogs}, nil
+}
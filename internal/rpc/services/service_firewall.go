@@ -7,7 +7,9 @@ import (
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models/stats"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/TeaOSLab/EdgeCommon/pkg/nodeconfigs"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
 	"github.com/iwind/TeaGo/types"
 	timeutil "github.com/iwind/TeaGo/utils/time"
 	"strconv"
@@ -224,11 +226,11 @@ func (this *FirewallService) NotifyHTTPFirewallEvent(ctx context.Context, req *p
 		"\n规则分组：" + ruleGroupName +
 		"\n规则集：" + ruleSetName +
 		"\n时间：" + timeutil.FormatTime("Y-m-d H:i:s", req.CreatedAt)
-	err = models.SharedMessageTaskDAO.CreateMessageTasks(tx, models.MessageTaskTarget{
-		ClusterId: clusterId,
-		NodeId:    nodeId,
-		ServerId:  req.ServerId,
-	}, models.MessageTypeFirewallEvent, "发生防火墙事件", msg)
+	err = models.SharedMessageDAO.CreateServerMessage(tx, nodeconfigs.NodeRoleNode, clusterId, nodeId, req.ServerId, models.MessageTypeFirewallEvent, models.MessageLevelWarning, "发生防火墙事件", msg, maps.Map{
+		"serverId":                req.ServerId,
+		"httpFirewallRuleGroupId": req.HttpFirewallRuleGroupId,
+		"httpFirewallRuleSetId":   req.HttpFirewallRuleSetId,
+	}.AsJSON())
 	if err != nil {
 		return nil, err
 	}
@@ -6,6 +6,7 @@ import (
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	rpcutils "github.com/TeaOSLab/EdgeAPI/internal/rpc/utils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/dbs"
 )
 
 // HTTPAccessLogService 访问日志相关服务
@@ -72,7 +73,7 @@ func (this *HTTPAccessLogService) ListHTTPAccessLogs(ctx context.Context, req *p
 		}
 	}
 
-	accessLogs, requestId, hasMore, err := models.SharedHTTPAccessLogDAO.ListAccessLogs(tx, req.RequestId, req.Size, req.Day, req.ServerId, req.Reverse, req.HasError, req.FirewallPolicyId, req.FirewallRuleGroupId, req.FirewallRuleSetId, req.HasFirewallPolicy, req.UserId, req.Keyword, req.Ip, req.Domain)
+	pbAccessLogs, requestId, hasMore, err := this.listAccessLogs(tx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -80,12 +81,7 @@ func (this *HTTPAccessLogService) ListHTTPAccessLogs(ctx context.Context, req *p
 	result := []*pb.HTTPAccessLog{}
 	var pbNodeMap = map[int64]*pb.Node{}
 	var pbClusterMap = map[int64]*pb.NodeCluster{}
-	for _, accessLog := range accessLogs {
-		a, err := accessLog.ToPB()
-		if err != nil {
-			return nil, err
-		}
-
+	for _, a := range pbAccessLogs {
 		// 节点 & 集群
 		pbNode, ok := pbNodeMap[a.NodeId]
 		if ok {
@@ -143,25 +139,99 @@ func (this *HTTPAccessLogService) FindHTTPAccessLog(ctx context.Context, req *pb
 
 	tx := this.NullTx()
 
-	accessLog, err := models.SharedHTTPAccessLogDAO.FindAccessLogWithRequestId(tx, req.RequestId)
+	a, err := this.findAccessLog(tx, req.RequestId)
 	if err != nil {
 		return nil, err
 	}
-	if accessLog == nil {
+	if a == nil {
 		return &pb.FindHTTPAccessLogResponse{HttpAccessLog: nil}, nil
 	}
 
 	// 检查权限
 	if userId > 0 {
-		err = models.SharedServerDAO.CheckUserServer(tx, userId, int64(accessLog.ServerId))
+		err = models.SharedServerDAO.CheckUserServer(tx, userId, a.ServerId)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	a, err := accessLog.ToPB()
+	return &pb.FindHTTPAccessLogResponse{HttpAccessLog: a}, nil
+}
+
+// 列出单页访问日志
+// 如果当前公用的日志策略支持查询，则从日志策略的存储中读取，否则从数据库中读取
+func (this *HTTPAccessLogService) listAccessLogs(tx *dbs.Tx, req *pb.ListHTTPAccessLogsRequest) (result []*pb.HTTPAccessLog, requestId string, hasMore bool, err error) {
+	policyId, err := models.SharedHTTPAccessLogPolicyDAO.FindCurrentPublicPolicyId(tx)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if policyId > 0 {
+		var query = &accesslogs.Query{
+			RequestId:           req.RequestId,
+			Size:                req.Size,
+			Day:                 req.Day,
+			Reverse:             req.Reverse,
+			ServerId:            req.ServerId,
+			HasError:            req.HasError,
+			FirewallPolicyId:    req.FirewallPolicyId,
+			FirewallRuleGroupId: req.FirewallRuleGroupId,
+			FirewallRuleSetId:   req.FirewallRuleSetId,
+			HasFirewallPolicy:   req.HasFirewallPolicy,
+			Keyword:             req.Keyword,
+			IP:                  req.Ip,
+			Domain:              req.Domain,
+		}
+		if req.ServerId <= 0 && req.UserId > 0 {
+			query.ServerIds, err = models.SharedServerDAO.FindAllEnabledServerIdsWithUserId(tx, req.UserId)
+			if err != nil {
+				return nil, "", false, err
+			}
+			if len(query.ServerIds) == 0 {
+				return nil, req.RequestId, false, nil
+			}
+		}
+		queryResult, ok, err := accesslogs.SharedStorageManager.Query(policyId, query)
+		if ok {
+			if err != nil {
+				return nil, "", false, err
+			}
+			return queryResult.AccessLogs, queryResult.RequestId, queryResult.HasMore, nil
+		}
+	}
+
+	accessLogs, requestId, hasMore, err := models.SharedHTTPAccessLogDAO.ListAccessLogs(tx, req.RequestId, req.Size, req.Day, req.ServerId, req.Reverse, req.HasError, req.FirewallPolicyId, req.FirewallRuleGroupId, req.FirewallRuleSetId, req.HasFirewallPolicy, req.UserId, req.Keyword, req.Ip, req.Domain)
+	if err != nil {
+		return nil, "", false, err
+	}
+	for _, accessLog := range accessLogs {
+		a, err := accessLog.ToPB()
+		if err != nil {
+			return nil, "", false, err
+		}
+		result = append(result, a)
+	}
+	return result, requestId, hasMore, nil
+}
+
+// 查找单条访问日志
+func (this *HTTPAccessLogService) findAccessLog(tx *dbs.Tx, requestId string) (*pb.HTTPAccessLog, error) {
+	policyId, err := models.SharedHTTPAccessLogPolicyDAO.FindCurrentPublicPolicyId(tx)
 	if err != nil {
 		return nil, err
 	}
-	return &pb.FindHTTPAccessLogResponse{HttpAccessLog: a}, nil
+	if policyId > 0 {
+		accessLog, ok, err := accesslogs.SharedStorageManager.FindAccessLog(policyId, requestId)
+		if ok {
+			return accessLog, err
+		}
+	}
+
+	accessLog, err := models.SharedHTTPAccessLogDAO.FindAccessLogWithRequestId(tx, requestId)
+	if err != nil {
+		return nil, err
+	}
+	if accessLog == nil {
+		return nil, nil
+	}
+	return accessLog.ToPB()
 }
@@ -151,9 +151,25 @@ func (this *HTTPAccessLogPolicyService) WriteHTTPAccessLogPolicy(ctx context.Con
 		return nil, err
 	}
 
-	err = accesslogs.SharedStorageManager.Write(req.HttpAccessLogPolicyId, []*pb.HTTPAccessLog{req.HttpAccessLog})
+	err = accesslogs.SharedStorageManager.WriteDirectly(req.HttpAccessLogPolicyId, []*pb.HTTPAccessLog{req.HttpAccessLog})
 	if err != nil {
 		return nil, err
 	}
 	return this.Success()
 }
+
+// FindHTTPAccessLogPolicySpoolStat 查看访问日志策略缓冲区状态
+func (this *HTTPAccessLogPolicyService) FindHTTPAccessLogPolicySpoolStat(ctx context.Context, req *pb.FindHTTPAccessLogPolicySpoolStatRequest) (*pb.FindHTTPAccessLogPolicySpoolStatResponse, error) {
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var stat = accesslogs.SharedStorageManager.SpoolStat(req.HttpAccessLogPolicyId)
+	return &pb.FindHTTPAccessLogPolicySpoolStatResponse{
+		Batches:     stat.Batches,
+		Logs:        stat.Logs,
+		Bytes:       stat.Bytes,
+		DroppedLogs: stat.DroppedLogs,
+	}, nil
+}
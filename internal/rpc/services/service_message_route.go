@@ -0,0 +1,140 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package services
+
+import (
+	"context"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/types"
+)
+
+// MessageRouteService 消息路由规则
+type MessageRouteService struct {
+	BaseService
+}
+
+// CreateMessageRoute 创建规则
+func (this *MessageRouteService) CreateMessageRoute(ctx context.Context, req *pb.CreateMessageRouteRequest) (*pb.CreateMessageRouteResponse, error) {
+	adminId, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = this.NullTx()
+	routeId, err := models.SharedMessageRouteDAO.CreateRoute(tx, adminId, req.Name, req.MessageTypes, req.Levels, models.MessageTaskTarget{
+		ClusterId: req.NodeClusterId,
+		NodeId:    req.NodeId,
+		ServerId:  req.ServerId,
+	}, req.MessageRecipientGroupIds, req.DedupSeconds, req.GroupSeconds, req.EscalateMinutes, req.EscalateMessageRecipientGroupIds, req.IsFinal)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateMessageRouteResponse{MessageRouteId: routeId}, nil
+}
+
+// UpdateMessageRoute 修改规则
+func (this *MessageRouteService) UpdateMessageRoute(ctx context.Context, req *pb.UpdateMessageRouteRequest) (*pb.RPCSuccess, error) {
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = this.NullTx()
+	err = models.SharedMessageRouteDAO.UpdateRoute(tx, req.MessageRouteId, req.Name, req.MessageTypes, req.Levels, models.MessageTaskTarget{
+		ClusterId: req.NodeClusterId,
+		NodeId:    req.NodeId,
+		ServerId:  req.ServerId,
+	}, req.MessageRecipientGroupIds, req.DedupSeconds, req.GroupSeconds, req.EscalateMinutes, req.EscalateMessageRecipientGroupIds, req.IsFinal, req.IsOn)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// DeleteMessageRoute 删除规则
+func (this *MessageRouteService) DeleteMessageRoute(ctx context.Context, req *pb.DeleteMessageRouteRequest) (*pb.RPCSuccess, error) {
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = this.NullTx()
+	err = models.SharedMessageRouteDAO.DisableMessageRoute(tx, req.MessageRouteId)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// UpdateMessageRouteOrders 修改规则排序
+func (this *MessageRouteService) UpdateMessageRouteOrders(ctx context.Context, req *pb.UpdateMessageRouteOrdersRequest) (*pb.RPCSuccess, error) {
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = this.NullTx()
+	err = models.SharedMessageRouteDAO.UpdateRouteOrders(tx, req.MessageRouteIds)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// FindEnabledMessageRoute 查找单个规则
+func (this *MessageRouteService) FindEnabledMessageRoute(ctx context.Context, req *pb.FindEnabledMessageRouteRequest) (*pb.FindEnabledMessageRouteResponse, error) {
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = this.NullTx()
+	route, err := models.SharedMessageRouteDAO.FindEnabledMessageRoute(tx, req.MessageRouteId)
+	if err != nil {
+		return nil, err
+	}
+	if route == nil {
+		return &pb.FindEnabledMessageRouteResponse{MessageRoute: nil}, nil
+	}
+	return &pb.FindEnabledMessageRouteResponse{MessageRoute: this.convertRouteToPB(route)}, nil
+}
+
+// FindAllEnabledMessageRoutes 查找所有规则
+func (this *MessageRouteService) FindAllEnabledMessageRoutes(ctx context.Context, req *pb.FindAllEnabledMessageRoutesRequest) (*pb.FindAllEnabledMessageRoutesResponse, error) {
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = this.NullTx()
+	routes, err := models.SharedMessageRouteDAO.FindAllEnabledRoutes(tx)
+	if err != nil {
+		return nil, err
+	}
+	pbRoutes := []*pb.MessageRoute{}
+	for _, route := range routes {
+		pbRoutes = append(pbRoutes, this.convertRouteToPB(route))
+	}
+	return &pb.FindAllEnabledMessageRoutesResponse{MessageRoutes: pbRoutes}, nil
+}
+
+// 转换规则
+func (this *MessageRouteService) convertRouteToPB(route *models.MessageRoute) *pb.MessageRoute {
+	return &pb.MessageRoute{
+		Id:                               int64(route.Id),
+		Name:                             route.Name,
+		IsOn:                             route.IsOn == 1,
+		MessageTypes:                     route.DecodeMessageTypes(),
+		Levels:                           route.DecodeLevels(),
+		NodeClusterId:                    int64(route.ClusterId),
+		NodeId:                           int64(route.NodeId),
+		ServerId:                         int64(route.ServerId),
+		MessageRecipientGroupIds:         route.DecodeRecipientGroupIds(),
+		DedupSeconds:                     types.Int32(route.DedupSeconds),
+		GroupSeconds:                     types.Int32(route.GroupSeconds),
+		EscalateMinutes:                  types.Int32(route.EscalateMinutes),
+		EscalateMessageRecipientGroupIds: route.DecodeEscalateGroupIds(),
+		IsFinal:                          route.IsFinal == 1,
+	}
+}
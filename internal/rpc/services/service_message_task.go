@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/messagemedias"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"github.com/iwind/TeaGo/maps"
 	"github.com/iwind/TeaGo/types"
@@ -37,7 +38,18 @@ func (this *MessageTaskService) FindSendingMessageTasks(ctx context.Context, req
 	}
 
 	var tx = this.NullTx()
-	tasks, err := models.SharedMessageTaskDAO.FindSendingMessageTasks(tx, req.Size)
+
+	// 如果启用了内置发送，则邮件、WebHook和脚本等媒介由API节点直接发送
+	senderConfig, err := models.SharedSysSettingDAO.ReadMessageSenderConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	var excludedMediaTypes []string
+	if senderConfig.IsOn {
+		excludedMediaTypes = messagemedias.FindAllMediaTypeCodes()
+	}
+
+	tasks, err := models.SharedMessageTaskDAO.FindSendingMessageTasksWithMediaTypes(tx, excludedMediaTypes, false, req.Size)
 	if err != nil {
 		return nil, err
 	}
@@ -7,6 +7,7 @@ import (
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models/dns"
 	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/healthchecks"
 	"github.com/TeaOSLab/EdgeAPI/internal/tasks"
 	"github.com/TeaOSLab/EdgeCommon/pkg/nodeconfigs"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
@@ -16,7 +17,10 @@ import (
 	"github.com/iwind/TeaGo/maps"
 	"github.com/iwind/TeaGo/rands"
 	"github.com/iwind/TeaGo/types"
+	timeutil "github.com/iwind/TeaGo/utils/time"
+	"sort"
 	"strconv"
+	"time"
 )
 
 type NodeClusterService struct {
@@ -293,7 +297,23 @@ func (this *NodeClusterService) FindNodeClusterHealthCheckConfig(ctx context.Con
 	if err != nil {
 		return nil, err
 	}
-	configJSON, err := json.Marshal(config)
+	if config == nil {
+		configJSON, err := json.Marshal(config)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.FindNodeClusterHealthCheckConfigResponse{HealthCheckJSON: configJSON}, nil
+	}
+
+	// 探测方式
+	probeConfig, err := models.SharedNodeClusterDAO.FindClusterHealthCheckProbeConfig(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+	configJSON, err := json.Marshal(&models.NodeClusterHealthCheckConfig{
+		HealthCheckConfig: config,
+		Probe:             probeConfig,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -308,6 +328,22 @@ func (this *NodeClusterService) UpdateNodeClusterHealthCheck(ctx context.Context
 		return nil, err
 	}
 
+	// 校验探测方式
+	probeConfig, err := healthchecks.DecodeProbeConfig(req.HealthCheckJSON)
+	if err != nil {
+		return nil, err
+	}
+	err = probeConfig.Init()
+	if err != nil {
+		return nil, errors.New("invalid probe config: " + err.Error())
+	}
+	if probeConfig.Type != healthchecks.ProbeTypeHTTP {
+		err = healthchecks.FindProbe(probeConfig.Type).Init(probeConfig)
+		if err != nil {
+			return nil, errors.New("invalid probe config: " + err.Error())
+		}
+	}
+
 	tx := this.NullTx()
 
 	err = models.SharedNodeClusterDAO.UpdateClusterHealthCheck(tx, req.NodeClusterId, req.HealthCheckJSON)
@@ -346,6 +382,206 @@ func (this *NodeClusterService) ExecuteNodeClusterHealthCheck(ctx context.Contex
 	return &pb.ExecuteNodeClusterHealthCheckResponse{Results: pbResults}, nil
 }
 
+// FindNodeHealthCheckStats 查找单个节点一段时间内的健康检查统计
+func (this *NodeClusterService) FindNodeHealthCheckStats(ctx context.Context, req *pb.FindNodeHealthCheckStatsRequest) (*pb.FindNodeHealthCheckStatsResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	timeFrom, timeTo := this.healthCheckTimeRange(req.TimeFrom, req.TimeTo)
+	hourFrom, hourTo := this.healthCheckHourRange(timeFrom, timeTo)
+
+	summary, err := models.SharedNodeHealthCheckHourlyStatDAO.SumNodeStats(tx, req.NodeId, hourFrom, hourTo)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := models.SharedNodeHealthCheckIncidentDAO.FindAllIncidents(tx, 0, req.NodeId, timeFrom, timeTo)
+	if err != nil {
+		return nil, err
+	}
+	nodeName, err := models.SharedNodeDAO.FindNodeName(tx, req.NodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	var now = time.Now().Unix()
+	var downtimeSeconds int64
+	var pbIncidents = []*pb.NodeHealthCheckIncident{}
+	for _, incident := range incidents {
+		downtimeSeconds += incident.DurationSeconds(timeFrom, timeTo, now)
+		pbIncidents = append(pbIncidents, this.convertHealthCheckIncidentToPB(incident, nodeName, timeFrom, timeTo, now))
+	}
+
+	var pbStat = this.convertHealthCheckSummaryToPB(summary, downtimeSeconds)
+	pbStat.NodeId = req.NodeId
+	pbStat.NodeName = nodeName
+
+	return &pb.FindNodeHealthCheckStatsResponse{
+		Stat:      pbStat,
+		Incidents: pbIncidents,
+	}, nil
+}
+
+// FindNodeClusterHealthCheckStats 查找集群及集群中每个节点一段时间内的健康检查统计
+func (this *NodeClusterService) FindNodeClusterHealthCheckStats(ctx context.Context, req *pb.FindNodeClusterHealthCheckStatsRequest) (*pb.FindNodeClusterHealthCheckStatsResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	timeFrom, timeTo := this.healthCheckTimeRange(req.TimeFrom, req.TimeTo)
+	hourFrom, hourTo := this.healthCheckHourRange(timeFrom, timeTo)
+
+	nodeSummaries, err := models.SharedNodeHealthCheckHourlyStatDAO.SumClusterNodeStats(tx, req.NodeClusterId, hourFrom, hourTo)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := models.SharedNodeHealthCheckIncidentDAO.FindAllIncidents(tx, req.NodeClusterId, 0, timeFrom, timeTo)
+	if err != nil {
+		return nil, err
+	}
+
+	var now = time.Now().Unix()
+	var nodeDowntimes = map[int64]int64{} // nodeId => seconds
+	for _, incident := range incidents {
+		nodeDowntimes[int64(incident.NodeId)] += incident.DurationSeconds(timeFrom, timeTo, now)
+	}
+
+	// 集群的统计为所有节点统计之和
+	// 集群的停机时间为所有节点停机时间之和，单位是"节点·秒"而不是实际经过的时间：比如两个节点同时停机10秒，集群停机时间为20秒
+	var clusterSummary = models.NewNodeHealthCheckSummary()
+	var clusterDowntimeNodeSeconds int64
+	var pbNodeStats = []*pb.NodeHealthCheckStat{}
+	for nodeId, summary := range nodeSummaries {
+		clusterSummary.Merge(summary)
+		clusterDowntimeNodeSeconds += nodeDowntimes[nodeId]
+
+		nodeName, err := models.SharedNodeDAO.FindNodeName(tx, nodeId)
+		if err != nil {
+			return nil, err
+		}
+
+		var pbStat = this.convertHealthCheckSummaryToPB(summary, nodeDowntimes[nodeId])
+		pbStat.NodeId = nodeId
+		pbStat.NodeName = nodeName
+		pbNodeStats = append(pbNodeStats, pbStat)
+	}
+	sort.Slice(pbNodeStats, func(i, j int) bool {
+		return pbNodeStats[i].NodeId < pbNodeStats[j].NodeId
+	})
+
+	return &pb.FindNodeClusterHealthCheckStatsResponse{
+		Stat:      this.convertHealthCheckSummaryToPB(clusterSummary, clusterDowntimeNodeSeconds),
+		NodeStats: pbNodeStats,
+	}, nil
+}
+
+// CountNodeClusterHealthCheckIncidents 计算一段时间内的健康检查故障数量
+func (this *NodeClusterService) CountNodeClusterHealthCheckIncidents(ctx context.Context, req *pb.CountNodeClusterHealthCheckIncidentsRequest) (*pb.RPCCountResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	timeFrom, timeTo := this.healthCheckTimeRange(req.TimeFrom, req.TimeTo)
+	count, err := models.SharedNodeHealthCheckIncidentDAO.CountIncidents(tx, req.NodeClusterId, req.NodeId, timeFrom, timeTo)
+	if err != nil {
+		return nil, err
+	}
+	return this.SuccessCount(count)
+}
+
+// ListNodeClusterHealthCheckIncidents 列出一段时间内的单页健康检查故障
+func (this *NodeClusterService) ListNodeClusterHealthCheckIncidents(ctx context.Context, req *pb.ListNodeClusterHealthCheckIncidentsRequest) (*pb.ListNodeClusterHealthCheckIncidentsResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	timeFrom, timeTo := this.healthCheckTimeRange(req.TimeFrom, req.TimeTo)
+	incidents, err := models.SharedNodeHealthCheckIncidentDAO.ListIncidents(tx, req.NodeClusterId, req.NodeId, timeFrom, timeTo, req.Offset, req.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	var now = time.Now().Unix()
+	var nodeNames = map[int64]string{}
+	var pbIncidents = []*pb.NodeHealthCheckIncident{}
+	for _, incident := range incidents {
+		var nodeId = int64(incident.NodeId)
+		nodeName, ok := nodeNames[nodeId]
+		if !ok {
+			nodeName, err = models.SharedNodeDAO.FindNodeName(tx, nodeId)
+			if err != nil {
+				return nil, err
+			}
+			nodeNames[nodeId] = nodeName
+		}
+		pbIncidents = append(pbIncidents, this.convertHealthCheckIncidentToPB(incident, nodeName, timeFrom, timeTo, now))
+	}
+	return &pb.ListNodeClusterHealthCheckIncidentsResponse{Incidents: pbIncidents}, nil
+}
+
+// 健康检查统计的时间范围，默认为最近24小时
+func (this *NodeClusterService) healthCheckTimeRange(timeFrom int64, timeTo int64) (int64, int64) {
+	var now = time.Now().Unix()
+	if timeTo <= 0 || timeTo > now {
+		timeTo = now
+	}
+	if timeFrom <= 0 || timeFrom > timeTo {
+		timeFrom = timeTo - 86400
+	}
+	return timeFrom, timeTo
+}
+
+// 时间范围对应的小时
+func (this *NodeClusterService) healthCheckHourRange(timeFrom int64, timeTo int64) (string, string) {
+	return timeutil.FormatTime("YmdH", timeFrom), timeutil.FormatTime("YmdH", timeTo)
+}
+
+// 转换健康检查统计
+// 对于集群统计，downtimeSeconds是所有节点停机时间之和（节点·秒）
+func (this *NodeClusterService) convertHealthCheckSummaryToPB(summary *models.NodeHealthCheckSummary, downtimeSeconds int64) *pb.NodeHealthCheckStat {
+	return &pb.NodeHealthCheckStat{
+		CountChecks:     summary.CountChecks,
+		CountFailed:     summary.CountFailed,
+		UptimePercent:   types.Float32(summary.UptimePercent()),
+		DowntimeSeconds: downtimeSeconds,
+		AvgCostMs:       types.Float32(summary.AvgCostMs()),
+		P50CostMs:       types.Float32(summary.PercentileCostMs(50)),
+		P95CostMs:       types.Float32(summary.PercentileCostMs(95)),
+		P99CostMs:       types.Float32(summary.PercentileCostMs(99)),
+		MaxCostMs:       types.Float32(summary.MaxCostMs),
+	}
+}
+
+// 转换健康检查故障
+func (this *NodeClusterService) convertHealthCheckIncidentToPB(incident *models.NodeHealthCheckIncident, nodeName string, timeFrom int64, timeTo int64, now int64) *pb.NodeHealthCheckIncident {
+	return &pb.NodeHealthCheckIncident{
+		Id:              int64(incident.Id),
+		NodeId:          int64(incident.NodeId),
+		NodeName:        nodeName,
+		StartedAt:       int64(incident.StartedAt),
+		EndedAt:         int64(incident.EndedAt),
+		CountFailed:     int64(incident.CountFailed),
+		Error:           incident.Error,
+		DurationSeconds: incident.DurationSeconds(timeFrom, timeTo, now),
+	}
+}
+
 // CountAllEnabledNodeClustersWithNodeGrantId 计算使用某个认证的集群数量
 func (this *NodeClusterService) CountAllEnabledNodeClustersWithNodeGrantId(ctx context.Context, req *pb.CountAllEnabledNodeClustersWithNodeGrantIdRequest) (*pb.RPCCountResponse, error) {
 	// 校验请求
@@ -419,6 +655,15 @@ func (this *NodeClusterService) FindEnabledNodeClusterDNS(ctx context.Context, r
 		return nil, err
 	}
 
+	failoverJSON, err := json.Marshal(dnsInfo.DecodeDNSFailoverConfig())
+	if err != nil {
+		return nil, err
+	}
+	weightsJSON, err := json.Marshal(dnsInfo.DecodeDNSWeightConfig())
+	if err != nil {
+		return nil, err
+	}
+
 	if dnsInfo.DnsDomainId == 0 {
 		return &pb.FindEnabledNodeClusterDNSResponse{
 			Name:            dnsInfo.DnsName,
@@ -426,6 +671,9 @@ func (this *NodeClusterService) FindEnabledNodeClusterDNS(ctx context.Context, r
 			Provider:        nil,
 			NodesAutoSync:   dnsConfig.NodesAutoSync,
 			ServersAutoSync: dnsConfig.ServersAutoSync,
+			Ttl:             dnsInfo.DecodeDNSTTL(),
+			FailoverJSON:    failoverJSON,
+			WeightsJSON:     weightsJSON,
 		}, nil
 	}
 
@@ -467,6 +715,9 @@ func (this *NodeClusterService) FindEnabledNodeClusterDNS(ctx context.Context, r
 		Provider:        pbProvider,
 		NodesAutoSync:   dnsConfig.NodesAutoSync,
 		ServersAutoSync: dnsConfig.ServersAutoSync,
+		Ttl:             dnsInfo.DecodeDNSTTL(),
+		FailoverJSON:    failoverJSON,
+		WeightsJSON:     weightsJSON,
 	}, nil
 }
 
@@ -559,13 +810,288 @@ func (this *NodeClusterService) UpdateNodeClusterDNS(ctx context.Context, req *p
 
 	tx := this.NullTx()
 
-	err = models.SharedNodeClusterDAO.UpdateClusterDNS(tx, req.NodeClusterId, req.DnsName, req.DnsDomainId, req.NodesAutoSync, req.ServersAutoSync)
+	// 更换主域名时先删除以前主域名中的记录，需要在修改集群设置之前执行，以便找到以前使用的子域名
+	cluster, err := models.SharedNodeClusterDAO.FindClusterDNSInfo(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+	if cluster != nil && cluster.DnsDomainId > 0 && int64(cluster.DnsDomainId) != req.DnsDomainId {
+		err = tasks.NewDNSTaskExecutor().RemoveClusterDomain(tx, req.NodeClusterId, int64(cluster.DnsDomainId))
+		if err != nil {
+			return nil, errors.New("remove dns records failed: " + err.Error())
+		}
+	}
+
+	err = models.SharedNodeClusterDAO.UpdateClusterDNS(tx, req.NodeClusterId, req.DnsName, req.DnsDomainId, req.NodesAutoSync, req.ServersAutoSync, req.Ttl)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// UpdateNodeClusterDNSFailover 修改集群的DNS故障切换设置
+func (this *NodeClusterService) UpdateNodeClusterDNSFailover(ctx context.Context, req *pb.UpdateNodeClusterDNSFailoverRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var failoverConfig = models.DefaultNodeClusterDNSFailoverConfig()
+	if len(req.FailoverJSON) > 0 {
+		err = json.Unmarshal(req.FailoverJSON, failoverConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if failoverConfig.MinHealthyRecords < 0 {
+		return nil, errors.New("'minHealthyRecords' should not be negative")
+	}
+	if failoverConfig.FlapWindow <= 0 {
+		return nil, errors.New("'flapWindow' should be greater than 0")
+	}
+	if failoverConfig.MaxFlaps <= 0 {
+		return nil, errors.New("'maxFlaps' should be greater than 0")
+	}
+
+	tx := this.NullTx()
+
+	err = models.SharedNodeClusterDAO.UpdateClusterDNSFailover(tx, req.NodeClusterId, failoverConfig)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// UpdateNodeClusterDNSWeights 修改集群节点记录的权重设置
+func (this *NodeClusterService) UpdateNodeClusterDNSWeights(ctx context.Context, req *pb.UpdateNodeClusterDNSWeightsRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var weightConfig = &models.NodeClusterDNSWeightConfig{}
+	if len(req.WeightsJSON) > 0 {
+		err = json.Unmarshal(req.WeightsJSON, weightConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx := this.NullTx()
+
+	err = models.SharedNodeClusterDAO.UpdateClusterDNSWeights(tx, req.NodeClusterId, weightConfig)
 	if err != nil {
 		return nil, err
 	}
 	return this.Success()
 }
 
+// CreateNodeClusterDNSBinding 为集群增加额外的DNS域名
+// 集群的记录会同时发布到主域名和所有额外域名
+func (this *NodeClusterService) CreateNodeClusterDNSBinding(ctx context.Context, req *pb.CreateNodeClusterDNSBindingRequest) (*pb.CreateNodeClusterDNSBindingResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	cluster, err := models.SharedNodeClusterDAO.FindClusterDNSInfo(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, errors.New("can not find cluster with id '" + types.String(req.NodeClusterId) + "'")
+	}
+	if int64(cluster.DnsDomainId) == req.DnsDomainId {
+		return nil, errors.New("the domain is already the primary domain of the cluster")
+	}
+
+	domain, err := dns.SharedDNSDomainDAO.FindEnabledDNSDomain(tx, req.DnsDomainId)
+	if err != nil {
+		return nil, err
+	}
+	if domain == nil {
+		return nil, errors.New("can not find domain with id '" + types.String(req.DnsDomainId) + "'")
+	}
+
+	bindingId, err := dns.SharedDNSClusterBindingDAO.CreateBinding(tx, req.NodeClusterId, req.DnsDomainId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateNodeClusterDNSBindingResponse{NodeClusterDNSBindingId: bindingId}, nil
+}
+
+// UpdateNodeClusterDNSBinding 修改集群的额外DNS域名
+func (this *NodeClusterService) UpdateNodeClusterDNSBinding(ctx context.Context, req *pb.UpdateNodeClusterDNSBindingRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	binding, err := dns.SharedDNSClusterBindingDAO.FindEnabledDNSClusterBinding(tx, req.NodeClusterDNSBindingId)
+	if err != nil {
+		return nil, err
+	}
+	if binding == nil {
+		return nil, errors.New("can not find binding with id '" + types.String(req.NodeClusterDNSBindingId) + "'")
+	}
+	if binding.IsPrimary == 1 {
+		if !req.IsOn {
+			return nil, errors.New("the primary domain can not be turned off, please change the cluster dns settings instead")
+		}
+		return this.Success()
+	}
+
+	// 停用时先删除服务商中的记录
+	if !req.IsOn && binding.IsOn == 1 {
+		err = tasks.NewDNSTaskExecutor().RemoveClusterDomain(tx, int64(binding.ClusterId), int64(binding.DomainId))
+		if err != nil {
+			return nil, errors.New("remove dns records failed: " + err.Error())
+		}
+	}
+
+	err = dns.SharedDNSClusterBindingDAO.UpdateBinding(tx, req.NodeClusterDNSBindingId, req.IsOn)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// DeleteNodeClusterDNSBinding 删除集群的额外DNS域名
+func (this *NodeClusterService) DeleteNodeClusterDNSBinding(ctx context.Context, req *pb.DeleteNodeClusterDNSBindingRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	binding, err := dns.SharedDNSClusterBindingDAO.FindEnabledDNSClusterBinding(tx, req.NodeClusterDNSBindingId)
+	if err != nil {
+		return nil, err
+	}
+	if binding == nil {
+		return this.Success()
+	}
+	if binding.IsPrimary == 1 {
+		return nil, errors.New("the primary domain can not be deleted, please change the cluster dns settings instead")
+	}
+
+	// 先删除服务商中的记录
+	if binding.IsOn == 1 {
+		err = tasks.NewDNSTaskExecutor().RemoveClusterDomain(tx, int64(binding.ClusterId), int64(binding.DomainId))
+		if err != nil {
+			return nil, errors.New("remove dns records failed: " + err.Error())
+		}
+	}
+
+	err = dns.SharedDNSClusterBindingDAO.DisableDNSClusterBinding(tx, req.NodeClusterDNSBindingId)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// FindAllNodeClusterDNSBindings 查找集群所有的DNS域名绑定及其同步状态，主域名排在最前
+func (this *NodeClusterService) FindAllNodeClusterDNSBindings(ctx context.Context, req *pb.FindAllNodeClusterDNSBindingsRequest) (*pb.FindAllNodeClusterDNSBindingsResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	cluster, err := models.SharedNodeClusterDAO.FindClusterDNSInfo(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return &pb.FindAllNodeClusterDNSBindingsResponse{NodeClusterDNSBindings: nil}, nil
+	}
+	var primaryDomainId = int64(cluster.DnsDomainId)
+
+	bindings, err := dns.SharedDNSClusterBindingDAO.FindAllEnabledBindingsWithClusterId(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 以前版本设置的主域名可能还没有绑定记录
+	var hasPrimary = false
+	for _, binding := range bindings {
+		if int64(binding.DomainId) == primaryDomainId {
+			hasPrimary = true
+			break
+		}
+	}
+	if !hasPrimary && primaryDomainId > 0 {
+		bindings = append([]*dns.DNSClusterBinding{{
+			ClusterId: uint32(req.NodeClusterId),
+			DomainId:  uint32(primaryDomainId),
+			IsPrimary: 1,
+			IsOn:      1,
+		}}, bindings...)
+	}
+
+	var pbBindings = []*pb.NodeClusterDNSBinding{}
+	for _, binding := range bindings {
+		var domainId = int64(binding.DomainId)
+		var isPrimary = domainId == primaryDomainId
+		if binding.IsPrimary == 1 && !isPrimary {
+			continue
+		}
+
+		domain, err := dns.SharedDNSDomainDAO.FindEnabledDNSDomain(tx, domainId)
+		if err != nil {
+			return nil, err
+		}
+		if domain == nil {
+			continue
+		}
+		var pbDomain = &pb.DNSDomain{
+			Id:   domainId,
+			Name: domain.Name,
+			IsOn: domain.IsOn == 1,
+		}
+
+		var pbProvider *pb.DNSProvider
+		provider, err := dns.SharedDNSProviderDAO.FindEnabledDNSProvider(tx, int64(domain.ProviderId))
+		if err != nil {
+			return nil, err
+		}
+		if provider != nil {
+			pbProvider = &pb.DNSProvider{
+				Id:       int64(provider.Id),
+				Name:     provider.Name,
+				Type:     provider.Type,
+				TypeName: dnsclients.FindProviderTypeName(provider.Type),
+			}
+		}
+
+		pbBindings = append(pbBindings, &pb.NodeClusterDNSBinding{
+			Id:            int64(binding.Id),
+			NodeClusterId: req.NodeClusterId,
+			DnsDomain:     pbDomain,
+			DnsProvider:   pbProvider,
+			IsPrimary:     isPrimary,
+			IsOn:          binding.IsOn == 1,
+			IsOk:          binding.IsOk == 1,
+			Error:         binding.Error,
+			SyncedAt:      int64(binding.SyncedAt),
+		})
+	}
+
+	return &pb.FindAllNodeClusterDNSBindingsResponse{NodeClusterDNSBindings: pbBindings}, nil
+}
+
 // CheckNodeClusterDNSChanges 检查集群的DNS是否有变化
 func (this *NodeClusterService) CheckNodeClusterDNSChanges(ctx context.Context, req *pb.CheckNodeClusterDNSChangesRequest) (*pb.CheckNodeClusterDNSChangesResponse, error) {
 	// 校验请求
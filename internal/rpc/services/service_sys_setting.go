@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
 	rpcutils "github.com/TeaOSLab/EdgeAPI/internal/rpc/utils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 )
@@ -19,6 +21,58 @@ func (this *SysSettingService) UpdateSysSetting(ctx context.Context, req *pb.Upd
 		return nil, err
 	}
 
+	// 校验节点资源监控设置
+	if req.Code == models.SettingCodeNodeMonitorConfig {
+		config := &models.NodeMonitorConfig{}
+		err = json.Unmarshal(req.ValueJSON, config)
+		if err != nil {
+			return nil, errors.New("decode node monitor config failed: " + err.Error())
+		}
+		err = config.Validate()
+		if err != nil {
+			return nil, errors.New("validate node monitor config failed: " + err.Error())
+		}
+	}
+
+	// 校验内置消息发送设置
+	if req.Code == models.SettingCodeMessageSenderConfig {
+		config := &models.MessageSenderConfig{}
+		err = json.Unmarshal(req.ValueJSON, config)
+		if err != nil {
+			return nil, errors.New("decode message sender config failed: " + err.Error())
+		}
+		err = config.Validate()
+		if err != nil {
+			return nil, errors.New("validate message sender config failed: " + err.Error())
+		}
+	}
+
+	// 校验数据保留策略
+	if req.Code == models.SettingCodeRetentionPolicyConfig {
+		config := &models.RetentionPolicyConfig{}
+		err = json.Unmarshal(req.ValueJSON, config)
+		if err != nil {
+			return nil, errors.New("decode retention policy config failed: " + err.Error())
+		}
+		err = config.Validate()
+		if err != nil {
+			return nil, errors.New("validate retention policy config failed: " + err.Error())
+		}
+	}
+
+	// 校验证书自动续期设置
+	if req.Code == models.SettingCodeACMERenewalConfig {
+		config := &models.ACMERenewalConfig{}
+		err = json.Unmarshal(req.ValueJSON, config)
+		if err != nil {
+			return nil, errors.New("decode acme renewal config failed: " + err.Error())
+		}
+		err = config.Validate()
+		if err != nil {
+			return nil, errors.New("validate acme renewal config failed: " + err.Error())
+		}
+	}
+
 	tx := this.NullTx()
 
 	err = models.SharedSysSettingDAO.UpdateSetting(tx, req.Code, req.ValueJSON)
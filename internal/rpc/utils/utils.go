@@ -5,10 +5,8 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
-	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models/authority"
-	"github.com/TeaOSLab/EdgeAPI/internal/encrypt"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils"
 	"github.com/iwind/TeaGo/lists"
 	"github.com/iwind/TeaGo/maps"
@@ -105,12 +103,8 @@ func ValidateRequest(ctx context.Context, userTypes ...UserType) (userType UserT
 		return UserTypeNone, 0, 0, err
 	}
 
-	method, err := encrypt.NewMethodInstance(teaconst.EncryptMethod, apiToken.Secret, nodeId)
-	if err != nil {
-		utils.PrintError(err)
-		return UserTypeNone, 0, 0, err
-	}
-	data, err = method.Decrypt(data)
+	// 新版本节点使用带认证的加密信封，旧版本节点仍然使用teaconst.EncryptMethod，直到节点升级后发送加密信封
+	data, err = models.SharedApiTokenDAO.DecodeToken(nil, apiToken, data)
 	if err != nil {
 		return UserTypeNone, 0, 0, err
 	}
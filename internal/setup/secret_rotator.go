@@ -0,0 +1,159 @@
+package setup
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/encrypt"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-yaml/yaml"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"io/ioutil"
+)
+
+// 需要加密保存的字段
+var secretColumns = []*secretColumn{
+	{Table: "edgeSSLCerts", Column: "keyData"},
+	{Table: "edgeNodeGrants", Column: "password"},
+	{Table: "edgeNodeGrants", Column: "privateKey"},
+	{Table: "edgeDNSProviders", Column: "apiParams", IsJSON: true},
+}
+
+type secretColumn struct {
+	Table  string
+	Column string
+	IsJSON bool // 字段类型是否为JSON，如果是则加密后的数据以JSON字符串的形式保存
+}
+
+// SecretRotator 使用当前的主密钥重新加密数据库中的敏感数据
+// 用于在轮换主密钥，或者第一次设置主密钥之后处理以前的数据
+type SecretRotator struct {
+	dbConfig  *dbs.DBConfig
+	batchSize int
+}
+
+func NewSecretRotator(dbConfig *dbs.DBConfig) *SecretRotator {
+	return &SecretRotator{
+		dbConfig:  dbConfig,
+		batchSize: 100,
+	}
+}
+
+func NewSecretRotatorFromCmd() (*SecretRotator, error) {
+	config := &dbs.Config{}
+	configData, err := ioutil.ReadFile(Tea.ConfigFile("db.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(configData, config)
+	if err != nil {
+		return nil, err
+	}
+	return NewSecretRotator(config.DBs[Tea.Env]), nil
+}
+
+// Run 执行重新加密，返回修改的数据条数
+func (this *SecretRotator) Run() (count int64, err error) {
+	box, err := encrypt.SharedSecretBox()
+	if err != nil {
+		return 0, err
+	}
+	if !box.IsOn() {
+		return 0, errors.New("secret key not found, please set it in environment variable 'EDGE_API_SECRET_KEYS' or file 'configs/secret.key'")
+	}
+
+	db, err := dbs.NewInstanceFromConfig(this.dbConfig)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	for _, column := range secretColumns {
+		columnCount, err := this.rotateColumn(db, box, column)
+		count += columnCount
+		if err != nil {
+			return count, errors.New("rotate '" + column.Table + "." + column.Column + "' failed: " + err.Error())
+		}
+	}
+	return count, nil
+}
+
+// 重新加密某个字段
+func (this *SecretRotator) rotateColumn(db *dbs.DB, box *encrypt.SecretBox, column *secretColumn) (count int64, err error) {
+	var lastId int64 = 0
+	for {
+		ones, _, err := db.FindOnes("SELECT `id`, `"+column.Column+"` FROM `"+column.Table+"` WHERE `id`>? ORDER BY `id` ASC LIMIT ?", lastId, this.batchSize)
+		if err != nil {
+			return count, err
+		}
+		if len(ones) == 0 {
+			return count, nil
+		}
+
+		for _, one := range ones {
+			var id = one.GetInt64("id")
+			lastId = id
+
+			var rawValue = one.GetString(column.Column)
+			var data = []byte(rawValue)
+			if column.IsJSON {
+				data, err = this.decodeJSONString(data)
+				if err != nil {
+					return count, err
+				}
+			}
+
+			result, changed, err := box.Rewrap(data)
+			if err != nil {
+				return count, err
+			}
+			if !changed {
+				continue
+			}
+
+			if column.IsJSON {
+				result, err = json.Marshal(string(result))
+				if err != nil {
+					return count, err
+				}
+			}
+			// 只有在数据没有被其他进程修改时才更新，以免覆盖在读取之后新保存的数据
+			var columnExpr = "`" + column.Column + "`"
+			if column.IsJSON {
+				columnExpr = "CAST(" + columnExpr + " AS CHAR)"
+			}
+			execResult, err := db.Exec("UPDATE `"+column.Table+"` SET `"+column.Column+"`=? WHERE `id`=? AND "+columnExpr+"=?", result, id, rawValue)
+			if err != nil {
+				return count, err
+			}
+			rows, err := execResult.RowsAffected()
+			if err != nil {
+				return count, err
+			}
+			if rows == 0 {
+				// 数据在读取之后已被修改，跳过此条数据，下次运行时再重新加密
+				continue
+			}
+			count++
+		}
+	}
+}
+
+// JSON字段中已加密的数据保存为JSON字符串，这里取出字符串中的数据
+func (this *SecretRotator) decodeJSONString(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != '"' {
+		// 未加密的数据，null等同于没有数据
+		if string(data) == "null" {
+			return nil, nil
+		}
+		return data, nil
+	}
+	var s = ""
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
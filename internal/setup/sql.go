@@ -8,7 +8,7 @@ import (
 // 最新版本的数据库SQL语句，用来对比并升级已有的数据库
 // 由 sql-dump/main.go 自动生成
 func init() {
-	err := json.Unmarshal([]byte("{\"tables\":[{\"name\":\"edgeACMEAuthentications\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeACMEAuthentications` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `taskId` bigint(11) unsigned DEFAULT '0' COMMENT '任务ID',\\n  `domain` varchar(255) DEFAULT NULL COMMENT '域名',\\n  `token` varchar(255) DEFAULT NULL COMMENT '令牌',\\n  `key` varchar(1024) DEFAULT NULL COMMENT '密钥',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `token` (`token`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME认证'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"taskId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '任务ID'\"},{\"name\":\"domain\",\"definition\":\"varchar(255) COMMENT '域名'\"},{\"name\":\"token\",\"definition\":\"varchar(255) COMMENT '令牌'\"},{\"name\":\"key\",\"definition\":\"varchar(1024) COMMENT '密钥'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"token\",\"definition\":\"KEY `token` (`token`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeACMETaskLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeACMETaskLogs` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `taskId` bigint(11) unsigned DEFAULT '0' COMMENT '任务ID',\\n  `isOk` tinyint(1) unsigned DEFAULT '1' COMMENT '是否成功',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '错误信息',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '运行时间',\\n  PRIMARY KEY (`id`),\\n  KEY `taskId` (`taskId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME任务运行日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"taskId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '任务ID'\"},{\"name\":\"isOk\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否成功'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '错误信息'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '运行时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"taskId\",\"definition\":\"KEY `taskId` (`taskId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeACMETasks\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeACMETasks` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `acmeUserId` int(11) unsigned DEFAULT '0' COMMENT 'ACME用户ID',\\n  `dnsDomain` varchar(255) DEFAULT NULL COMMENT 'DNS主域名',\\n  `dnsProviderId` bigint(11) unsigned DEFAULT '0' COMMENT 'DNS服务商',\\n  `domains` json DEFAULT NULL COMMENT '证书域名',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `certId` bigint(11) unsigned DEFAULT '0' COMMENT '生成的证书ID',\\n  `autoRenew` tinyint(1) unsigned DEFAULT '0' COMMENT '是否自动更新',\\n  `authType` varchar(64) DEFAULT NULL COMMENT '认证类型',\\n  `authURL` varchar(1024) DEFAULT NULL COMMENT '认证URL',\\n  PRIMARY KEY (`id`),\\n  KEY `adminId` (`adminId`),\\n  KEY `userId` (`userId`),\\n  KEY `acmeUserId` (`acmeUserId`),\\n  KEY `certId` (`certId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME任务'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"acmeUserId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'ACME用户ID'\"},{\"name\":\"dnsDomain\",\"definition\":\"varchar(255) COMMENT 'DNS主域名'\"},{\"name\":\"dnsProviderId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT 'DNS服务商'\"},{\"name\":\"domains\",\"definition\":\"json COMMENT '证书域名'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"certId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '生成的证书ID'\"},{\"name\":\"autoRenew\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否自动更新'\"},{\"name\":\"authType\",\"definition\":\"varchar(64) COMMENT '认证类型'\"},{\"name\":\"authURL\",\"definition\":\"varchar(1024) COMMENT '认证URL'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"acmeUserId\",\"definition\":\"KEY `acmeUserId` (`acmeUserId`) USING BTREE\"},{\"name\":\"certId\",\"definition\":\"KEY `certId` (`certId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeACMEUsers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeACMEUsers` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `privateKey` text COMMENT '私钥',\\n  `email` varchar(255) DEFAULT NULL COMMENT 'E-mail',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `description` varchar(512) DEFAULT NULL COMMENT '备注介绍',\\n  `registration` json DEFAULT NULL COMMENT '注册信息',\\n  PRIMARY KEY (`id`),\\n  KEY `adminId` (`adminId`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME用户'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"privateKey\",\"definition\":\"text COMMENT '私钥'\"},{\"name\":\"email\",\"definition\":\"varchar(255) COMMENT 'E-mail'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '备注介绍'\"},{\"name\":\"registration\",\"definition\":\"json COMMENT '注册信息'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAPIAccessTokens\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAPIAccessTokens` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `token` varchar(255) DEFAULT NULL COMMENT '令牌',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `expiredAt` bigint(11) unsigned DEFAULT '0' COMMENT '过期时间',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `token` (`token`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='API访问令牌'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"token\",\"definition\":\"varchar(255) COMMENT '令牌'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"expiredAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '过期时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"token\",\"definition\":\"KEY `token` (`token`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAPINodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAPINodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '专用集群ID',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `http` json DEFAULT NULL COMMENT '监听的HTTP配置',\\n  `https` json DEFAULT NULL COMMENT '监听的HTTPS配置',\\n  `restIsOn` tinyint(1) unsigned DEFAULT '0' COMMENT '是否开放REST',\\n  `restHTTP` json DEFAULT NULL COMMENT 'REST HTTP配置',\\n  `restHTTPS` json DEFAULT NULL COMMENT 'REST HTTPS配置',\\n  `accessAddrs` json DEFAULT NULL COMMENT '外部访问地址',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `status` json DEFAULT NULL COMMENT '运行状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='API节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '专用集群ID'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"http\",\"definition\":\"json COMMENT '监听的HTTP配置'\"},{\"name\":\"https\",\"definition\":\"json COMMENT '监听的HTTPS配置'\"},{\"name\":\"restIsOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否开放REST'\"},{\"name\":\"restHTTP\",\"definition\":\"json COMMENT 'REST HTTP配置'\"},{\"name\":\"restHTTPS\",\"definition\":\"json COMMENT 'REST HTTPS配置'\"},{\"name\":\"accessAddrs\",\"definition\":\"json COMMENT '外部访问地址'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '运行状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAPITokens\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAPITokens` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `nodeId` varchar(32) DEFAULT NULL COMMENT '节点ID',\\n  `secret` varchar(255) DEFAULT NULL COMMENT '节点密钥',\\n  `role` varchar(64) DEFAULT NULL COMMENT '节点角色',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='API令牌管理'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"nodeId\",\"definition\":\"varchar(32) COMMENT '节点ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(255) COMMENT '节点密钥'\"},{\"name\":\"role\",\"definition\":\"varchar(64) COMMENT '节点角色'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAdmins\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAdmins` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `username` varchar(64) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(32) DEFAULT NULL COMMENT '密码',\\n  `fullname` varchar(64) DEFAULT NULL COMMENT '全名',\\n  `isSuper` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为超级管理员',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `modules` json DEFAULT NULL COMMENT '允许的模块',\\n  `canLogin` tinyint(1) unsigned DEFAULT '1' COMMENT '是否可以登录',\\n  `theme` varchar(64) DEFAULT NULL COMMENT '模板设置',\\n  PRIMARY KEY (`id`),\\n  KEY `username` (`username`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='管理员'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"username\",\"definition\":\"varchar(64) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(32) COMMENT '密码'\"},{\"name\":\"fullname\",\"definition\":\"varchar(64) COMMENT '全名'\"},{\"name\":\"isSuper\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为超级管理员'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"modules\",\"definition\":\"json COMMENT '允许的模块'\"},{\"name\":\"canLogin\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否可以登录'\"},{\"name\":\"theme\",\"definition\":\"varchar(64) COMMENT '模板设置'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"username\",\"definition\":\"KEY `username` (`username`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAuthorityKeys\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAuthorityKeys` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `value` varchar(4096) DEFAULT NULL COMMENT 'Key值',\\n  `dayFrom` date DEFAULT NULL COMMENT '开始日期',\\n  `dayTo` date DEFAULT NULL COMMENT '结束日期',\\n  `hostname` varchar(255) DEFAULT NULL COMMENT 'Hostname',\\n  `macAddresses` json DEFAULT NULL COMMENT 'MAC地址',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建/修改时间',\\n  `company` varchar(512) DEFAULT NULL COMMENT '公司组织',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='企业版认证信息'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"value\",\"definition\":\"varchar(4096) COMMENT 'Key值'\"},{\"name\":\"dayFrom\",\"definition\":\"date COMMENT '开始日期'\"},{\"name\":\"dayTo\",\"definition\":\"date COMMENT '结束日期'\"},{\"name\":\"hostname\",\"definition\":\"varchar(255) COMMENT 'Hostname'\"},{\"name\":\"macAddresses\",\"definition\":\"json COMMENT 'MAC地址'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建/修改时间'\"},{\"name\":\"company\",\"definition\":\"varchar(512) COMMENT '公司组织'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAuthorityNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAuthorityNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `status` json DEFAULT NULL COMMENT '运行状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='监控节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '运行状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeClientBrowsers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeClientBrowsers` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '浏览器名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `name` (`name`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='终端浏览器信息'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '浏览器名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"name\",\"definition\":\"UNIQUE KEY `name` (`name`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeClientSystems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeClientSystems` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '系统名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `name` (`name`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='终端操作系统信息'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '系统名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"name\",\"definition\":\"UNIQUE KEY `name` (`name`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeDBNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeDBNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `role` varchar(255) DEFAULT NULL COMMENT '数据库角色',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `host` varchar(255) DEFAULT NULL COMMENT '主机',\\n  `port` int(11) unsigned DEFAULT '0' COMMENT '端口',\\n  `database` varchar(255) DEFAULT NULL COMMENT '数据库名称',\\n  `username` varchar(255) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(255) DEFAULT NULL COMMENT '密码',\\n  `charset` varchar(255) DEFAULT NULL COMMENT '通讯字符集',\\n  `connTimeout` int(11) unsigned DEFAULT '0' COMMENT '连接超时时间（秒）',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='数据库节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"role\",\"definition\":\"varchar(255) COMMENT '数据库角色'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"host\",\"definition\":\"varchar(255) COMMENT '主机'\"},{\"name\":\"port\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '端口'\"},{\"name\":\"database\",\"definition\":\"varchar(255) COMMENT '数据库名称'\"},{\"name\":\"username\",\"definition\":\"varchar(255) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(255) COMMENT '密码'\"},{\"name\":\"charset\",\"definition\":\"varchar(255) COMMENT '通讯字符集'\"},{\"name\":\"connTimeout\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '连接超时时间（秒）'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeDNSDomains\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeDNSDomains` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `providerId` int(11) unsigned DEFAULT '0' COMMENT '服务商ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否可用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '域名',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `dataUpdatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '数据更新时间',\\n  `dataError` varchar(512) DEFAULT NULL COMMENT '数据更新错误',\\n  `data` text COMMENT '原始数据信息',\\n  `records` json DEFAULT NULL COMMENT '所有解析记录',\\n  `routes` json DEFAULT NULL COMMENT '线路数据',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `providerId` (`providerId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='管理的域名'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"providerId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务商ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否可用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '域名'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"dataUpdatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '数据更新时间'\"},{\"name\":\"dataError\",\"definition\":\"varchar(512) COMMENT '数据更新错误'\"},{\"name\":\"data\",\"definition\":\"text COMMENT '原始数据信息'\"},{\"name\":\"records\",\"definition\":\"json COMMENT '所有解析记录'\"},{\"name\":\"routes\",\"definition\":\"json COMMENT '线路数据'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"providerId\",\"definition\":\"KEY `providerId` (`providerId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeDNSProviders\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeDNSProviders` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '供应商类型',\\n  `apiParams` json DEFAULT NULL COMMENT 'API参数',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `dataUpdatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '数据同步时间',\\n  PRIMARY KEY (`id`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS服务商'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '供应商类型'\"},{\"name\":\"apiParams\",\"definition\":\"json COMMENT 'API参数'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"dataUpdatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '数据同步时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeDNSTasks\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeDNSTasks` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '任务类型',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '更新时间',\\n  `isDone` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成',\\n  `isOk` tinyint(1) unsigned DEFAULT '0' COMMENT '是否成功',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '错误信息',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`clusterId`,`serverId`,`nodeId`,`domainId`,`type`) USING BTREE,\\n  KEY `isDone` (`isDone`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS更新任务'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '任务类型'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '更新时间'\"},{\"name\":\"isDone\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成'\"},{\"name\":\"isOk\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否成功'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '错误信息'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`clusterId`,`serverId`,`nodeId`,`domainId`,`type`) USING BTREE\"},{\"name\":\"isDone\",\"definition\":\"KEY `isDone` (`isDone`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeFileChunks\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeFileChunks` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `fileId` int(11) unsigned DEFAULT '0' COMMENT '文件ID',\\n  `data` longblob COMMENT '分块内容',\\n  PRIMARY KEY (`id`),\\n  KEY `fileId` (`fileId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文件片段'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"fileId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '文件ID'\"},{\"name\":\"data\",\"definition\":\"longblob COMMENT '分块内容'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"fileId\",\"definition\":\"KEY `fileId` (`fileId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeFiles\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeFiles` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `description` varchar(255) DEFAULT NULL COMMENT '文件描述',\\n  `filename` varchar(255) DEFAULT NULL COMMENT '文件名',\\n  `size` int(11) unsigned DEFAULT '0' COMMENT '文件尺寸',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `type` varchar(64) DEFAULT '' COMMENT '类型',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `isFinished` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成上传',\\n  `isPublic` tinyint(1) unsigned DEFAULT '0' COMMENT '是否可以公开访问',\\n  PRIMARY KEY (`id`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文件管理'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"description\",\"definition\":\"varchar(255) COMMENT '文件描述'\"},{\"name\":\"filename\",\"definition\":\"varchar(255) COMMENT '文件名'\"},{\"name\":\"size\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '文件尺寸'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"type\",\"definition\":\"varchar(64) COMMENT '类型'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"isFinished\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成上传'\"},{\"name\":\"isPublic\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否可以公开访问'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPAccessLogPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPAccessLogPolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `type` varchar(255) DEFAULT NULL COMMENT '存储类型',\\n  `options` json DEFAULT NULL COMMENT '存储选项',\\n  `conds` json DEFAULT NULL COMMENT '请求条件',\\n  `isPublic` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为公用',\\n  `version` int(10) unsigned DEFAULT '0' COMMENT '版本号',\\n  PRIMARY KEY (`id`),\\n  KEY `isPublic` (`isPublic`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='访问日志策略'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '存储类型'\"},{\"name\":\"options\",\"definition\":\"json COMMENT '存储选项'\"},{\"name\":\"conds\",\"definition\":\"json COMMENT '请求条件'\"},{\"name\":\"isPublic\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为公用'\"},{\"name\":\"version\",\"definition\":\"int(10) unsigned DEFAULT '0' COMMENT '版本号'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"isPublic\",\"definition\":\"KEY `isPublic` (`isPublic`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPAccessLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPAccessLogs` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `status` int(3) unsigned DEFAULT '0' COMMENT '状态码',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `content` json DEFAULT NULL COMMENT '日志内容',\\n  `requestId` varchar(128) DEFAULT NULL COMMENT '请求ID',\\n  `firewallPolicyId` int(11) unsigned DEFAULT '0' COMMENT 'WAF策略ID',\\n  `firewallRuleGroupId` int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID',\\n  `firewallRuleSetId` int(11) unsigned DEFAULT '0' COMMENT 'WAF集ID',\\n  `firewallRuleId` int(11) unsigned DEFAULT '0' COMMENT 'WAF规则ID',\\n  `remoteAddr` varchar(64) DEFAULT NULL COMMENT 'IP地址',\\n  `domain` varchar(128) DEFAULT NULL COMMENT '域名',\\n  PRIMARY KEY (`id`),\\n  KEY `serverId` (`serverId`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `serverId_status` (`serverId`,`status`),\\n  KEY `requestId` (`requestId`),\\n  KEY `firewallPolicyId` (`firewallPolicyId`),\\n  KEY `firewallRuleGroupId` (`firewallRuleGroupId`),\\n  KEY `firewallRuleSetId` (`firewallRuleSetId`),\\n  KEY `firewallRuleId` (`firewallRuleId`),\\n  KEY `remoteAddr` (`remoteAddr`),\\n  KEY `domain` (`domain`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='访问日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"status\",\"definition\":\"int(3) unsigned DEFAULT '0' COMMENT '状态码'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"content\",\"definition\":\"json COMMENT '日志内容'\"},{\"name\":\"requestId\",\"definition\":\"varchar(128) COMMENT '请求ID'\"},{\"name\":\"firewallPolicyId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF策略ID'\"},{\"name\":\"firewallRuleGroupId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID'\"},{\"name\":\"firewallRuleSetId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF集ID'\"},{\"name\":\"firewallRuleId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF规则ID'\"},{\"name\":\"remoteAddr\",\"definition\":\"varchar(64) COMMENT 'IP地址'\"},{\"name\":\"domain\",\"definition\":\"varchar(128) COMMENT '域名'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"serverId_status\",\"definition\":\"KEY `serverId_status` (`serverId`,`status`) USING BTREE\"},{\"name\":\"requestId\",\"definition\":\"KEY `requestId` (`requestId`) USING BTREE\"},{\"name\":\"firewallPolicyId\",\"definition\":\"KEY `firewallPolicyId` (`firewallPolicyId`) USING BTREE\"},{\"name\":\"firewallRuleGroupId\",\"definition\":\"KEY `firewallRuleGroupId` (`firewallRuleGroupId`) USING BTREE\"},{\"name\":\"firewallRuleSetId\",\"definition\":\"KEY `firewallRuleSetId` (`firewallRuleSetId`) USING BTREE\"},{\"name\":\"firewallRuleId\",\"definition\":\"KEY `firewallRuleId` (`firewallRuleId`) USING BTREE\"},{\"name\":\"remoteAddr\",\"definition\":\"KEY `remoteAddr` (`remoteAddr`) USING BTREE\"},{\"name\":\"domain\",\"definition\":\"KEY `domain` (`domain`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPAuthPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPAuthPolicies` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(128) DEFAULT NULL COMMENT '名称',\\n  `type` varchar(32) DEFAULT NULL COMMENT '类型',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='HTTP认证策略'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(128) COMMENT '名称'\"},{\"name\":\"type\",\"definition\":\"varchar(32) COMMENT '类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPCachePolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPCachePolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `capacity` json DEFAULT NULL COMMENT '容量数据',\\n  `maxKeys` bigint(20) unsigned DEFAULT '0' COMMENT '最多Key值',\\n  `maxSize` json DEFAULT NULL COMMENT '最大缓存内容尺寸',\\n  `type` varchar(255) DEFAULT NULL COMMENT '存储类型',\\n  `options` json DEFAULT NULL COMMENT '存储选项',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `refs` json DEFAULT NULL COMMENT '默认的缓存设置',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='HTTP缓存策略'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"capacity\",\"definition\":\"json COMMENT '容量数据'\"},{\"name\":\"maxKeys\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '最多Key值'\"},{\"name\":\"maxSize\",\"definition\":\"json COMMENT '最大缓存内容尺寸'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '存储类型'\"},{\"name\":\"options\",\"definition\":\"json COMMENT '存储选项'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"refs\",\"definition\":\"json COMMENT '默认的缓存设置'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPFastcgis\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPFastcgis` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `address` varchar(255) DEFAULT NULL COMMENT '地址',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `readTimeout` json DEFAULT NULL COMMENT '读取超时',\\n  `connTimeout` json DEFAULT NULL COMMENT '连接超时',\\n  `poolSize` int(11) unsigned DEFAULT '0' COMMENT '连接池尺寸',\\n  `pathInfoPattern` varchar(512) DEFAULT NULL COMMENT 'PATH_INFO匹配',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Fastcgi设置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"address\",\"definition\":\"varchar(255) COMMENT '地址'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"readTimeout\",\"definition\":\"json COMMENT '读取超时'\"},{\"name\":\"connTimeout\",\"definition\":\"json COMMENT '连接超时'\"},{\"name\":\"poolSize\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '连接池尺寸'\"},{\"name\":\"pathInfoPattern\",\"definition\":\"varchar(512) COMMENT 'PATH_INFO匹配'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPFirewallPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPFirewallPolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `inbound` json DEFAULT NULL COMMENT '入站规则',\\n  `outbound` json DEFAULT NULL COMMENT '出站规则',\\n  `blockOptions` json DEFAULT NULL COMMENT 'BLOCK选项',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='HTTP防火墙'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"inbound\",\"definition\":\"json COMMENT '入站规则'\"},{\"name\":\"outbound\",\"definition\":\"json COMMENT '出站规则'\"},{\"name\":\"blockOptions\",\"definition\":\"json COMMENT 'BLOCK选项'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPFirewallRuleGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPFirewallRuleGroups` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `sets` json DEFAULT NULL COMMENT '规则集列表',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `code` (`code`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='防火墙规则分组'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"sets\",\"definition\":\"json COMMENT '规则集列表'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"code\",\"definition\":\"KEY `code` (`code`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPFirewallRuleSets\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPFirewallRuleSets` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `rules` json DEFAULT NULL COMMENT '规则列表',\\n  `connector` varchar(64) DEFAULT NULL COMMENT '规则之间的关系',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `action` varchar(255) DEFAULT NULL COMMENT '执行的动作（过期）',\\n  `actionOptions` json DEFAULT NULL COMMENT '动作的选项（过期）',\\n  `actions` json DEFAULT NULL COMMENT '一组动作',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='防火墙规则集'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"rules\",\"definition\":\"json COMMENT '规则列表'\"},{\"name\":\"connector\",\"definition\":\"varchar(64) COMMENT '规则之间的关系'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"action\",\"definition\":\"varchar(255) COMMENT '执行的动作（过期）'\"},{\"name\":\"actionOptions\",\"definition\":\"json COMMENT '动作的选项（过期）'\"},{\"name\":\"actions\",\"definition\":\"json COMMENT '一组动作'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPFirewallRules\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPFirewallRules` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '说明',\\n  `param` varchar(1024) DEFAULT NULL COMMENT '参数',\\n  `paramFilters` json DEFAULT NULL COMMENT '处理器',\\n  `operator` varchar(255) DEFAULT NULL COMMENT '操作符',\\n  `value` varchar(4096) DEFAULT NULL COMMENT '对比值',\\n  `isCaseInsensitive` tinyint(1) unsigned DEFAULT '1' COMMENT '是否大小写不敏感',\\n  `checkpointOptions` json DEFAULT NULL COMMENT '检查点参数',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='防火墙规则'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '说明'\"},{\"name\":\"param\",\"definition\":\"varchar(1024) COMMENT '参数'\"},{\"name\":\"paramFilters\",\"definition\":\"json COMMENT '处理器'\"},{\"name\":\"operator\",\"definition\":\"varchar(255) COMMENT '操作符'\"},{\"name\":\"value\",\"definition\":\"varchar(4096) COMMENT '对比值'\"},{\"name\":\"isCaseInsensitive\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否大小写不敏感'\"},{\"name\":\"checkpointOptions\",\"definition\":\"json COMMENT '检查点参数'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPGzips\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPGzips` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `level` int(11) unsigned DEFAULT '0' COMMENT '压缩级别',\\n  `minLength` json DEFAULT NULL COMMENT '可压缩最小值',\\n  `maxLength` json DEFAULT NULL COMMENT '可压缩最大值',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `conds` json DEFAULT NULL COMMENT '条件',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Gzip配置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"level\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '压缩级别'\"},{\"name\":\"minLength\",\"definition\":\"json COMMENT '可压缩最小值'\"},{\"name\":\"maxLength\",\"definition\":\"json COMMENT '可压缩最大值'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"conds\",\"definition\":\"json COMMENT '条件'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPHeaderPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPHeaderPolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned NOT NULL DEFAULT '1' COMMENT '是否启用',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `addHeaders` json DEFAULT NULL COMMENT '添加的Header',\\n  `addTrailers` json DEFAULT NULL COMMENT '添加的Trailers',\\n  `setHeaders` json DEFAULT NULL COMMENT '设置Header',\\n  `replaceHeaders` json DEFAULT NULL COMMENT '替换Header内容',\\n  `expires` json DEFAULT NULL COMMENT 'Expires单独设置',\\n  `deleteHeaders` json DEFAULT NULL COMMENT '删除的Headers',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Header定义'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"addHeaders\",\"definition\":\"json COMMENT '添加的Header'\"},{\"name\":\"addTrailers\",\"definition\":\"json COMMENT '添加的Trailers'\"},{\"name\":\"setHeaders\",\"definition\":\"json COMMENT '设置Header'\"},{\"name\":\"replaceHeaders\",\"definition\":\"json COMMENT '替换Header内容'\"},{\"name\":\"expires\",\"definition\":\"json COMMENT 'Expires单独设置'\"},{\"name\":\"deleteHeaders\",\"definition\":\"json COMMENT '删除的Headers'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPHeaders\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPHeaders` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `value` varchar(1024) DEFAULT NULL COMMENT '值',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `status` json DEFAULT NULL COMMENT '状态码设置',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='HTTP Header'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"value\",\"definition\":\"varchar(1024) COMMENT '值'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '状态码设置'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPLocations\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPLocations` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `parentId` int(11) unsigned DEFAULT '0' COMMENT '父级ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `pattern` varchar(1024) DEFAULT NULL COMMENT '匹配规则',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `webId` int(11) unsigned DEFAULT '0' COMMENT 'Web配置ID',\\n  `reverseProxy` json DEFAULT NULL COMMENT '反向代理',\\n  `urlPrefix` varchar(1024) DEFAULT NULL COMMENT 'URL前缀',\\n  `isBreak` tinyint(1) unsigned DEFAULT '0' COMMENT '是否终止匹配',\\n  `conds` json DEFAULT NULL COMMENT '匹配条件',\\n  PRIMARY KEY (`id`),\\n  KEY `webId` (`webId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='路由规则配置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"parentId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '父级ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"pattern\",\"definition\":\"varchar(1024) COMMENT '匹配规则'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"webId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'Web配置ID'\"},{\"name\":\"reverseProxy\",\"definition\":\"json COMMENT '反向代理'\"},{\"name\":\"urlPrefix\",\"definition\":\"varchar(1024) COMMENT 'URL前缀'\"},{\"name\":\"isBreak\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否终止匹配'\"},{\"name\":\"conds\",\"definition\":\"json COMMENT '匹配条件'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"webId\",\"definition\":\"KEY `webId` (`webId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPPages\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPPages` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '0' COMMENT '是否启用',\\n  `statusList` json DEFAULT NULL COMMENT '状态列表',\\n  `url` varchar(1024) DEFAULT NULL COMMENT '页面URL',\\n  `newStatus` int(3) DEFAULT NULL COMMENT '新状态码',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='特殊页面'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否启用'\"},{\"name\":\"statusList\",\"definition\":\"json COMMENT '状态列表'\"},{\"name\":\"url\",\"definition\":\"varchar(1024) COMMENT '页面URL'\"},{\"name\":\"newStatus\",\"definition\":\"int(3) COMMENT '新状态码'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPRewriteRules\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPRewriteRules` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `pattern` varchar(1024) DEFAULT NULL COMMENT '匹配规则',\\n  `replace` varchar(1024) DEFAULT NULL COMMENT '跳转后的地址',\\n  `mode` varchar(255) DEFAULT NULL COMMENT '替换模式',\\n  `redirectStatus` int(3) unsigned DEFAULT '0' COMMENT '跳转的状态码',\\n  `proxyHost` varchar(255) DEFAULT NULL COMMENT '代理的主机名',\\n  `isBreak` tinyint(1) unsigned DEFAULT '1' COMMENT '是否终止解析',\\n  `withQuery` tinyint(1) unsigned DEFAULT '1' COMMENT '是否保留URI参数',\\n  `conds` json DEFAULT NULL COMMENT '匹配条件',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='重写规则'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"pattern\",\"definition\":\"varchar(1024) COMMENT '匹配规则'\"},{\"name\":\"replace\",\"definition\":\"varchar(1024) COMMENT '跳转后的地址'\"},{\"name\":\"mode\",\"definition\":\"varchar(255) COMMENT '替换模式'\"},{\"name\":\"redirectStatus\",\"definition\":\"int(3) unsigned DEFAULT '0' COMMENT '跳转的状态码'\"},{\"name\":\"proxyHost\",\"definition\":\"varchar(255) COMMENT '代理的主机名'\"},{\"name\":\"isBreak\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否终止解析'\"},{\"name\":\"withQuery\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否保留URI参数'\"},{\"name\":\"conds\",\"definition\":\"json COMMENT '匹配条件'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPWebs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPWebs` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `root` json DEFAULT NULL COMMENT '根目录',\\n  `charset` json DEFAULT NULL COMMENT '字符集',\\n  `shutdown` json DEFAULT NULL COMMENT '临时关闭页面配置',\\n  `pages` json DEFAULT NULL COMMENT '特殊页面',\\n  `redirectToHttps` json DEFAULT NULL COMMENT '跳转到HTTPS设置',\\n  `indexes` json DEFAULT NULL COMMENT '首页文件列表',\\n  `maxRequestBodySize` json DEFAULT NULL COMMENT '最大允许的请求内容尺寸',\\n  `requestHeader` json DEFAULT NULL COMMENT '请求Header配置',\\n  `responseHeader` json DEFAULT NULL COMMENT '响应Header配置',\\n  `accessLog` json DEFAULT NULL COMMENT '访问日志配置',\\n  `stat` json DEFAULT NULL COMMENT '统计配置',\\n  `gzip` json DEFAULT NULL COMMENT 'Gzip配置',\\n  `cache` json DEFAULT NULL COMMENT '缓存配置',\\n  `firewall` json DEFAULT NULL COMMENT '防火墙设置',\\n  `locations` json DEFAULT NULL COMMENT '路由规则配置',\\n  `websocket` json DEFAULT NULL COMMENT 'Websocket设置',\\n  `rewriteRules` json DEFAULT NULL COMMENT '重写规则配置',\\n  `hostRedirects` json DEFAULT NULL COMMENT '域名跳转',\\n  `fastcgi` json DEFAULT NULL COMMENT 'Fastcgi配置',\\n  `auth` json DEFAULT NULL COMMENT '认证策略配置',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='HTTP Web'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"root\",\"definition\":\"json COMMENT '根目录'\"},{\"name\":\"charset\",\"definition\":\"json COMMENT '字符集'\"},{\"name\":\"shutdown\",\"definition\":\"json COMMENT '临时关闭页面配置'\"},{\"name\":\"pages\",\"definition\":\"json COMMENT '特殊页面'\"},{\"name\":\"redirectToHttps\",\"definition\":\"json COMMENT '跳转到HTTPS设置'\"},{\"name\":\"indexes\",\"definition\":\"json COMMENT '首页文件列表'\"},{\"name\":\"maxRequestBodySize\",\"definition\":\"json COMMENT '最大允许的请求内容尺寸'\"},{\"name\":\"requestHeader\",\"definition\":\"json COMMENT '请求Header配置'\"},{\"name\":\"responseHeader\",\"definition\":\"json COMMENT '响应Header配置'\"},{\"name\":\"accessLog\",\"definition\":\"json COMMENT '访问日志配置'\"},{\"name\":\"stat\",\"definition\":\"json COMMENT '统计配置'\"},{\"name\":\"gzip\",\"definition\":\"json COMMENT 'Gzip配置'\"},{\"name\":\"cache\",\"definition\":\"json COMMENT '缓存配置'\"},{\"name\":\"firewall\",\"definition\":\"json COMMENT '防火墙设置'\"},{\"name\":\"locations\",\"definition\":\"json COMMENT '路由规则配置'\"},{\"name\":\"websocket\",\"definition\":\"json COMMENT 'Websocket设置'\"},{\"name\":\"rewriteRules\",\"definition\":\"json COMMENT '重写规则配置'\"},{\"name\":\"hostRedirects\",\"definition\":\"json COMMENT '域名跳转'\"},{\"name\":\"fastcgi\",\"definition\":\"json COMMENT 'Fastcgi配置'\"},{\"name\":\"auth\",\"definition\":\"json COMMENT '认证策略配置'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPWebsockets\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPWebsockets` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `handshakeTimeout` json DEFAULT NULL COMMENT '握手超时时间',\\n  `allowAllOrigins` tinyint(1) unsigned DEFAULT '1' COMMENT '是否支持所有源',\\n  `allowedOrigins` json DEFAULT NULL COMMENT '支持的源域名列表',\\n  `requestSameOrigin` tinyint(1) unsigned DEFAULT '1' COMMENT '是否请求一样的Origin',\\n  `requestOrigin` varchar(255) DEFAULT NULL COMMENT '请求Origin',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Websocket设置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"handshakeTimeout\",\"definition\":\"json COMMENT '握手超时时间'\"},{\"name\":\"allowAllOrigins\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否支持所有源'\"},{\"name\":\"allowedOrigins\",\"definition\":\"json COMMENT '支持的源域名列表'\"},{\"name\":\"requestSameOrigin\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否请求一样的Origin'\"},{\"name\":\"requestOrigin\",\"definition\":\"varchar(255) COMMENT '请求Origin'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeIPItems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeIPItems` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `listId` int(11) unsigned DEFAULT '0' COMMENT '所属名单ID',\\n  `type` varchar(64) DEFAULT 'ipv4' COMMENT '类型',\\n  `ipFrom` varchar(64) DEFAULT NULL COMMENT '开始IP',\\n  `ipTo` varchar(64) DEFAULT NULL COMMENT '结束IP',\\n  `ipFromLong` bigint(20) unsigned DEFAULT '0' COMMENT '开始IP整型',\\n  `ipToLong` bigint(20) unsigned DEFAULT '0' COMMENT '结束IP整型',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `reason` varchar(255) DEFAULT NULL COMMENT '加入说明',\\n  `eventLevel` varchar(64) DEFAULT NULL COMMENT '事件级别',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `expiredAt` bigint(11) unsigned DEFAULT '0' COMMENT '过期时间',\\n  PRIMARY KEY (`id`),\\n  KEY `listId` (`listId`),\\n  KEY `version_expiredAt` (`version`,`expiredAt`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='IP'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"listId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '所属名单ID'\"},{\"name\":\"type\",\"definition\":\"varchar(64) DEFAULT 'ipv4' COMMENT '类型'\"},{\"name\":\"ipFrom\",\"definition\":\"varchar(64) COMMENT '开始IP'\"},{\"name\":\"ipTo\",\"definition\":\"varchar(64) COMMENT '结束IP'\"},{\"name\":\"ipFromLong\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '开始IP整型'\"},{\"name\":\"ipToLong\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '结束IP整型'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"reason\",\"definition\":\"varchar(255) COMMENT '加入说明'\"},{\"name\":\"eventLevel\",\"definition\":\"varchar(64) COMMENT '事件级别'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"expiredAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '过期时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"listId\",\"definition\":\"KEY `listId` (`listId`) USING BTREE\"},{\"name\":\"version_expiredAt\",\"definition\":\"KEY `version_expiredAt` (`version`,`expiredAt`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeIPLibraries\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeIPLibraries` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `fileId` int(11) unsigned DEFAULT '0' COMMENT '文件ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='IP库'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"fileId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '文件ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeIPLists\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeIPLists` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '列表名',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `timeout` json DEFAULT NULL COMMENT '默认超时时间',\\n  `actions` json DEFAULT NULL COMMENT 'IP触发的动作',\\n  `description` varchar(512) DEFAULT NULL COMMENT '描述',\\n  `isPublic` tinyint(1) unsigned DEFAULT '0' COMMENT '是否公用',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='IP名单'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '列表名'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"timeout\",\"definition\":\"json COMMENT '默认超时时间'\"},{\"name\":\"actions\",\"definition\":\"json COMMENT 'IP触发的动作'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '描述'\"},{\"name\":\"isPublic\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否公用'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeLatestItems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeLatestItems` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `itemType` varchar(255) DEFAULT NULL COMMENT 'Item类型',\\n  `itemId` bigint(20) unsigned DEFAULT '0' COMMENT 'itemID',\\n  `count` bigint(11) unsigned DEFAULT '0' COMMENT '数量',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '更新时间',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `item` (`itemType`,`itemId`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='最近的条目统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"itemType\",\"definition\":\"varchar(255) COMMENT 'Item类型'\"},{\"name\":\"itemId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT 'itemID'\"},{\"name\":\"count\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '数量'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '更新时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"item\",\"definition\":\"UNIQUE KEY `item` (`itemType`,`itemId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeLogins\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeLogins` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `type` varchar(255) DEFAULT NULL COMMENT '认证方式',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `adminId_type` (`adminId`,`type`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='第三方登录认证'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '认证方式'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"adminId_type\",\"definition\":\"KEY `adminId_type` (`adminId`,`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeLogs` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `level` varchar(32) DEFAULT NULL COMMENT '级别',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `action` varchar(255) DEFAULT NULL COMMENT '动作',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `providerId` int(11) unsigned DEFAULT '0' COMMENT '供应商ID',\\n  `ip` varchar(32) DEFAULT NULL COMMENT 'IP地址',\\n  `type` varchar(255) DEFAULT 'admin' COMMENT '类型：admin, user',\\n  `day` varchar(10) DEFAULT NULL COMMENT '日期',\\n  `billId` int(11) unsigned DEFAULT '0' COMMENT '账单ID',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `adminid` (`adminId`),\\n  KEY `day` (`day`),\\n  KEY `billId` (`billId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='操作日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"level\",\"definition\":\"varchar(32) COMMENT '级别'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"action\",\"definition\":\"varchar(255) COMMENT '动作'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"providerId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '供应商ID'\"},{\"name\":\"ip\",\"definition\":\"varchar(32) COMMENT 'IP地址'\"},{\"name\":\"type\",\"definition\":\"varchar(255) DEFAULT 'admin' COMMENT '类型：admin, user'\"},{\"name\":\"day\",\"definition\":\"varchar(10) COMMENT '日期'\"},{\"name\":\"billId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '账单ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"adminid\",\"definition\":\"KEY `adminid` (`adminId`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"},{\"name\":\"billId\",\"definition\":\"KEY `billId` (`billId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageMediaInstances\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageMediaInstances` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `mediaType` varchar(255) DEFAULT NULL COMMENT '媒介类型',\\n  `params` json DEFAULT NULL COMMENT '媒介参数',\\n  `description` varchar(512) DEFAULT NULL COMMENT '备注',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `mediaType` (`mediaType`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息媒介接收人'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"mediaType\",\"definition\":\"varchar(255) COMMENT '媒介类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '媒介参数'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '备注'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"mediaType\",\"definition\":\"KEY `mediaType` (`mediaType`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageMedias\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageMedias` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型',\\n  `description` varchar(512) DEFAULT NULL COMMENT '描述',\\n  `userDescription` varchar(512) DEFAULT NULL COMMENT '用户描述',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息媒介'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '描述'\"},{\"name\":\"userDescription\",\"definition\":\"varchar(512) COMMENT '用户描述'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"UNIQUE KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageReceivers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageReceivers` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `recipientId` int(11) unsigned DEFAULT '0' COMMENT '接收人ID',\\n  `recipientGroupId` int(11) unsigned DEFAULT '0' COMMENT '接收人分组ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `serverId` (`serverId`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息通知接收人'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"recipientId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '接收人ID'\"},{\"name\":\"recipientGroupId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '接收人分组ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageRecipientGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageRecipientGroups` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '分组名',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息接收人分组'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '分组名'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageRecipients\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageRecipients` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `instanceId` int(11) unsigned DEFAULT '0' COMMENT '实例ID',\\n  `user` varchar(1024) DEFAULT NULL COMMENT '接收人信息',\\n  `groupIds` json DEFAULT NULL COMMENT '分组ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `description` varchar(512) DEFAULT NULL COMMENT '备注',\\n  PRIMARY KEY (`id`),\\n  KEY `adminId` (`adminId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息媒介接收人'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"instanceId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '实例ID'\"},{\"name\":\"user\",\"definition\":\"varchar(1024) COMMENT '接收人信息'\"},{\"name\":\"groupIds\",\"definition\":\"json COMMENT '分组ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '备注'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageTaskLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageTaskLogs` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `taskId` bigint(20) unsigned DEFAULT '0' COMMENT '任务ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `isOk` tinyint(1) unsigned DEFAULT '0' COMMENT '是否成功',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '错误信息',\\n  `response` varchar(1024) DEFAULT NULL COMMENT '响应信息',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息发送日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"taskId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '任务ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"isOk\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否成功'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '错误信息'\"},{\"name\":\"response\",\"definition\":\"varchar(1024) COMMENT '响应信息'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageTasks\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageTasks` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `recipientId` int(11) unsigned DEFAULT '0' COMMENT '接收人ID',\\n  `instanceId` int(11) unsigned DEFAULT '0' COMMENT '媒介实例ID',\\n  `user` varchar(255) DEFAULT NULL COMMENT '接收用户标识',\\n  `subject` varchar(255) DEFAULT NULL COMMENT '标题',\\n  `body` varchar(1024) DEFAULT NULL COMMENT '内容',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `status` tinyint(1) unsigned DEFAULT '0' COMMENT '发送状态',\\n  `sentAt` bigint(11) unsigned DEFAULT '0' COMMENT '最后一次发送时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `result` json DEFAULT NULL COMMENT '结果',\\n  `isPrimary` tinyint(1) unsigned DEFAULT '0' COMMENT '是否优先',\\n  PRIMARY KEY (`id`),\\n  KEY `status` (`status`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息发送相关任务'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"recipientId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '接收人ID'\"},{\"name\":\"instanceId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '媒介实例ID'\"},{\"name\":\"user\",\"definition\":\"varchar(255) COMMENT '接收用户标识'\"},{\"name\":\"subject\",\"definition\":\"varchar(255) COMMENT '标题'\"},{\"name\":\"body\",\"definition\":\"varchar(1024) COMMENT '内容'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"status\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '发送状态'\"},{\"name\":\"sentAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '最后一次发送时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"result\",\"definition\":\"json COMMENT '结果'\"},{\"name\":\"isPrimary\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否优先'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"status\",\"definition\":\"KEY `status` (`status`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessages\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessages` (\\n  `id` bigint(16) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '角色',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `level` varchar(32) DEFAULT NULL COMMENT '级别',\\n  `subject` varchar(255) DEFAULT NULL COMMENT '标题',\\n  `body` varchar(2048) DEFAULT NULL COMMENT '内容',\\n  `type` varchar(128) DEFAULT NULL COMMENT '消息类型',\\n  `params` json DEFAULT NULL COMMENT '额外的参数',\\n  `isRead` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已读',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `day` varchar(8) DEFAULT NULL COMMENT '日期YYYYMMDD',\\n  `hash` varchar(32) DEFAULT NULL COMMENT '消息内容的Hash',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `day` (`day`),\\n  KEY `hash` (`hash`),\\n  KEY `userId` (`userId`),\\n  KEY `adminId` (`adminId`),\\n  KEY `isRead` (`isRead`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息通知'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(16) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '角色'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"level\",\"definition\":\"varchar(32) COMMENT '级别'\"},{\"name\":\"subject\",\"definition\":\"varchar(255) COMMENT '标题'\"},{\"name\":\"body\",\"definition\":\"varchar(2048) COMMENT '内容'\"},{\"name\":\"type\",\"definition\":\"varchar(128) COMMENT '消息类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '额外的参数'\"},{\"name\":\"isRead\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已读'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT '日期YYYYMMDD'\"},{\"name\":\"hash\",\"definition\":\"varchar(32) COMMENT '消息内容的Hash'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"},{\"name\":\"hash\",\"definition\":\"KEY `hash` (`hash`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"},{\"name\":\"isRead\",\"definition\":\"KEY `isRead` (`isRead`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMetricCharts\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMetricCharts` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `itemId` int(11) unsigned DEFAULT '0' COMMENT '指标ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `code` varchar(128) DEFAULT NULL COMMENT '代号',\\n  `type` varchar(32) DEFAULT NULL COMMENT '图形类型',\\n  `widthDiv` int(11) DEFAULT NULL COMMENT '宽度划分',\\n  `params` json DEFAULT NULL COMMENT '图形参数',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `maxItems` int(11) unsigned DEFAULT '0' COMMENT '最多条目',\\n  `ignoreEmptyKeys` tinyint(1) unsigned DEFAULT '1' COMMENT '忽略空的键值',\\n  `ignoredKeys` json DEFAULT NULL COMMENT '忽略键值',\\n  PRIMARY KEY (`id`),\\n  KEY `itemId` (`itemId`),\\n  KEY `code` (`code`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='指标图表'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"itemId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '指标ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"code\",\"definition\":\"varchar(128) COMMENT '代号'\"},{\"name\":\"type\",\"definition\":\"varchar(32) COMMENT '图形类型'\"},{\"name\":\"widthDiv\",\"definition\":\"int(11) COMMENT '宽度划分'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '图形参数'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"maxItems\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最多条目'\"},{\"name\":\"ignoreEmptyKeys\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '忽略空的键值'\"},{\"name\":\"ignoredKeys\",\"definition\":\"json COMMENT '忽略键值'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"itemId\",\"definition\":\"KEY `itemId` (`itemId`) USING BTREE\"},{\"name\":\"code\",\"definition\":\"KEY `code` (`code`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMetricItems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMetricItems` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `code` varchar(128) DEFAULT NULL COMMENT '代号（用来区分是否内置）',\\n  `category` varchar(32) DEFAULT NULL COMMENT '类型，比如http, tcp等',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '指标名称',\\n  `keys` json DEFAULT NULL COMMENT '统计的Key',\\n  `period` int(11) unsigned DEFAULT '0' COMMENT '周期',\\n  `periodUnit` varchar(32) DEFAULT NULL COMMENT '周期单位',\\n  `value` varchar(512) DEFAULT NULL COMMENT '值运算',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '版本号',\\n  `isPublic` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为公用',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `category` (`category`),\\n  KEY `code` (`code`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='指标定义'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"code\",\"definition\":\"varchar(128) COMMENT '代号（用来区分是否内置）'\"},{\"name\":\"category\",\"definition\":\"varchar(32) COMMENT '类型，比如http, tcp等'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '指标名称'\"},{\"name\":\"keys\",\"definition\":\"json COMMENT '统计的Key'\"},{\"name\":\"period\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '周期'\"},{\"name\":\"periodUnit\",\"definition\":\"varchar(32) COMMENT '周期单位'\"},{\"name\":\"value\",\"definition\":\"varchar(512) COMMENT '值运算'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"isPublic\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为公用'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"category\",\"definition\":\"KEY `category` (`category`) USING BTREE\"},{\"name\":\"code\",\"definition\":\"KEY `code` (`code`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMetricStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMetricStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `hash` varchar(32) DEFAULT NULL COMMENT 'Hash值',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `itemId` bigint(20) unsigned DEFAULT '0' COMMENT '指标',\\n  `keys` json DEFAULT NULL COMMENT '键值',\\n  `value` decimal(20,2) DEFAULT '0.00' COMMENT '数值',\\n  `time` varchar(14) DEFAULT NULL COMMENT '分钟值YYYYMMDDHHII',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '版本号',\\n  `createdDay` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `hash` (`hash`),\\n  KEY `serverId` (`serverId`),\\n  KEY `createdDay` (`createdDay`),\\n  KEY `itemId` (`itemId`) USING BTREE,\\n  KEY `item_time` (`itemId`,`time`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='指标统计数据'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"hash\",\"definition\":\"varchar(32) COMMENT 'Hash值'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"itemId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '指标'\"},{\"name\":\"keys\",\"definition\":\"json COMMENT '键值'\"},{\"name\":\"value\",\"definition\":\"decimal(20,2) DEFAULT '0.00' COMMENT '数值'\"},{\"name\":\"time\",\"definition\":\"varchar(14) COMMENT '分钟值YYYYMMDDHHII'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"createdDay\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"hash\",\"definition\":\"UNIQUE KEY `hash` (`hash`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"createdDay\",\"definition\":\"KEY `createdDay` (`createdDay`) USING BTREE\"},{\"name\":\"itemId\",\"definition\":\"KEY `itemId` (`itemId`) USING BTREE\"},{\"name\":\"item_time\",\"definition\":\"KEY `item_time` (`itemId`,`time`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMetricSumStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMetricSumStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `itemId` bigint(20) unsigned DEFAULT '0' COMMENT '指标',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  `total` decimal(20,2) DEFAULT '0.00' COMMENT '总和',\\n  `time` varchar(14) DEFAULT NULL COMMENT '分钟值YYYYMMDDHHII',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '版本号',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `node_server_item_time_version` (`nodeId`,`serverId`,`itemId`,`time`,`version`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='指标统计总和数据'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"itemId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '指标'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"},{\"name\":\"total\",\"definition\":\"decimal(20,2) DEFAULT '0.00' COMMENT '总和'\"},{\"name\":\"time\",\"definition\":\"varchar(14) COMMENT '分钟值YYYYMMDDHHII'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '版本号'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"node_server_item_time_version\",\"definition\":\"UNIQUE KEY `node_server_item_time_version` (`nodeId`,`serverId`,`itemId`,`time`,`version`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMonitorNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMonitorNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `status` json DEFAULT NULL COMMENT '运行状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='监控节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '运行状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSAccessLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSAccessLogs` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `recordId` int(11) unsigned DEFAULT '0' COMMENT '记录ID',\\n  `content` json DEFAULT NULL COMMENT '访问数据',\\n  `requestId` varchar(128) DEFAULT NULL COMMENT '请求ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `remoteAddr` varchar(128) DEFAULT NULL COMMENT 'IP',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `domainId` (`domainId`),\\n  KEY `recordId` (`recordId`),\\n  KEY `requestId` (`requestId`),\\n  KEY `remoteAddr` (`remoteAddr`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='域名服务访问日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"recordId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '记录ID'\"},{\"name\":\"content\",\"definition\":\"json COMMENT '访问数据'\"},{\"name\":\"requestId\",\"definition\":\"varchar(128) COMMENT '请求ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"remoteAddr\",\"definition\":\"varchar(128) COMMENT 'IP'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"},{\"name\":\"recordId\",\"definition\":\"KEY `recordId` (`recordId`) USING BTREE\"},{\"name\":\"requestId\",\"definition\":\"KEY `requestId` (`requestId`) USING BTREE\"},{\"name\":\"remoteAddr\",\"definition\":\"KEY `remoteAddr` (`remoteAddr`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSClusters\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSClusters` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '集群名',\\n  `installDir` varchar(512) DEFAULT NULL COMMENT '安装目录',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `accessLog` json DEFAULT NULL COMMENT '访问日志配置',\\n  `grantId` int(11) unsigned DEFAULT '0' COMMENT '授权ID',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='域名服务器集群'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '集群名'\"},{\"name\":\"installDir\",\"definition\":\"varchar(512) COMMENT '安装目录'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"accessLog\",\"definition\":\"json COMMENT '访问日志配置'\"},{\"name\":\"grantId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '授权ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSDomains\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSDomains` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '域名',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `tsig` json DEFAULT NULL COMMENT 'TSIG配置',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `name` (`name`),\\n  KEY `version` (`version`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS域名'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '域名'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"tsig\",\"definition\":\"json COMMENT 'TSIG配置'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"name\",\"definition\":\"KEY `name` (`name`) USING BTREE\"},{\"name\":\"version\",\"definition\":\"KEY `version` (`version`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSKeys\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSKeys` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `domainId` bigint(20) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `zoneId` bigint(20) unsigned DEFAULT '0' COMMENT '子域ID',\\n  `algo` varchar(64) DEFAULT NULL COMMENT '算法',\\n  `secret` varchar(4096) DEFAULT NULL COMMENT '密码',\\n  `secretType` varchar(32) DEFAULT NULL COMMENT '密码类型',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `zoneId` (`zoneId`),\\n  KEY `domainId` (`domainId`),\\n  KEY `version` (`version`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='密钥管理'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"domainId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"zoneId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '子域ID'\"},{\"name\":\"algo\",\"definition\":\"varchar(64) COMMENT '算法'\"},{\"name\":\"secret\",\"definition\":\"varchar(4096) COMMENT '密码'\"},{\"name\":\"secretType\",\"definition\":\"varchar(32) COMMENT '密码类型'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"zoneId\",\"definition\":\"KEY `zoneId` (`zoneId`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"},{\"name\":\"version\",\"definition\":\"KEY `version` (`version`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '节点名称',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `status` json DEFAULT NULL COMMENT '运行状态',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '节点ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `isUp` tinyint(1) unsigned DEFAULT '1' COMMENT '是否运行',\\n  `isInstalled` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已安装',\\n  `installStatus` json DEFAULT NULL COMMENT '安装状态',\\n  `installDir` varchar(512) DEFAULT NULL COMMENT '安装目录',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `isActive` tinyint(1) unsigned DEFAULT '1' COMMENT '是否活跃',\\n  `statusIsNotified` tinyint(1) unsigned DEFAULT '1' COMMENT '活跃状态已经通知',\\n  `connectedAPINodes` json DEFAULT NULL COMMENT '当前连接的API节点',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='域名服务器节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '节点名称'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '运行状态'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '节点ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"isUp\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否运行'\"},{\"name\":\"isInstalled\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已安装'\"},{\"name\":\"installStatus\",\"definition\":\"json COMMENT '安装状态'\"},{\"name\":\"installDir\",\"definition\":\"varchar(512) COMMENT '安装目录'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"isActive\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否活跃'\"},{\"name\":\"statusIsNotified\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '活跃状态已经通知'\"},{\"name\":\"connectedAPINodes\",\"definition\":\"json COMMENT '当前连接的API节点'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSQuestionOptions\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSQuestionOptions` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(128) DEFAULT NULL COMMENT '选项名',\\n  `values` json DEFAULT NULL COMMENT '选项值',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS请求选项'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(128) COMMENT '选项名'\"},{\"name\":\"values\",\"definition\":\"json COMMENT '选项值'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSRecordHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSRecordHourlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(110) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `recordId` bigint(11) unsigned DEFAULT '0' COMMENT '记录ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `countRequests` int(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `bytes` bigint(20) unsigned DEFAULT '0' COMMENT '流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `node_record_hour` (`nodeId`,`recordId`,`hour`) USING BTREE,\\n  KEY `day` (`day`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='NS记录统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(110) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"recordId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '记录ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"countRequests\",\"definition\":\"int(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"node_record_hour\",\"definition\":\"UNIQUE KEY `node_record_hour` (`nodeId`,`recordId`,`hour`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSRecords\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSRecords` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `description` varchar(512) DEFAULT NULL COMMENT '备注',\\n  `name` varchar(255) DEFAULT NULL COMMENT '记录名',\\n  `type` varchar(32) DEFAULT NULL COMMENT '类型',\\n  `value` varchar(4096) DEFAULT NULL COMMENT '值',\\n  `ttl` int(11) unsigned DEFAULT '0' COMMENT 'TTL（秒）',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `routeIds` json DEFAULT NULL COMMENT '线路',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `domainId` (`domainId`),\\n  KEY `version` (`version`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS记录'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '备注'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '记录名'\"},{\"name\":\"type\",\"definition\":\"varchar(32) COMMENT '类型'\"},{\"name\":\"value\",\"definition\":\"varchar(4096) COMMENT '值'\"},{\"name\":\"ttl\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'TTL（秒）'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"routeIds\",\"definition\":\"json COMMENT '线路'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"},{\"name\":\"version\",\"definition\":\"KEY `version` (`version`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSRoutes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSRoutes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '0' COMMENT '是否启用',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `ranges` json DEFAULT NULL COMMENT '范围',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本号',\\n  `code` varchar(128) DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `domainId` (`domainId`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS线路'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否启用'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"ranges\",\"definition\":\"json COMMENT '范围'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"code\",\"definition\":\"varchar(128) COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSZones\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSZones` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `domainId` bigint(20) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本',\\n  `tsig` json DEFAULT NULL COMMENT 'TSIG配置',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `domainId` (`domainId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='域名子域'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"domainId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本'\"},{\"name\":\"tsig\",\"definition\":\"json COMMENT 'TSIG配置'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeClusterFirewallActions\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeClusterFirewallActions` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `eventLevel` varchar(255) DEFAULT NULL COMMENT '级别',\\n  `type` varchar(64) DEFAULT NULL COMMENT '动作类型',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='防火墙动作'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"eventLevel\",\"definition\":\"varchar(255) COMMENT '级别'\"},{\"name\":\"type\",\"definition\":\"varchar(64) COMMENT '动作类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeClusterMetricItems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeClusterMetricItems` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `itemId` bigint(20) unsigned DEFAULT '0' COMMENT '指标ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `itemId` (`itemId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='集群使用的指标'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"itemId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '指标ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"itemId\",\"definition\":\"KEY `itemId` (`itemId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeClusterTrafficDailyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeClusterTrafficDailyStats` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `bytes` bigint(20) unsigned DEFAULT NULL COMMENT '流量字节',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `cluster_day` (`clusterId`,`day`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='总的流量统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned COMMENT '流量字节'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"cluster_day\",\"definition\":\"UNIQUE KEY `cluster_day` (`clusterId`,`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeClusters\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeClusters` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `useAllAPINodes` tinyint(1) unsigned DEFAULT '1' COMMENT '是否使用所有API节点',\\n  `apiNodes` json DEFAULT NULL COMMENT '使用的API节点',\\n  `installDir` varchar(512) DEFAULT NULL COMMENT '安装目录',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `grantId` int(11) unsigned DEFAULT '0' COMMENT '默认认证方式',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `autoRegister` tinyint(1) unsigned DEFAULT '1' COMMENT '是否开启自动注册',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `healthCheck` json DEFAULT NULL COMMENT '健康检查',\\n  `dnsName` varchar(255) DEFAULT NULL COMMENT 'DNS名称',\\n  `dnsDomainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `dns` json DEFAULT NULL COMMENT 'DNS配置',\\n  `toa` json DEFAULT NULL COMMENT 'TOA配置',\\n  `cachePolicyId` int(11) unsigned DEFAULT '0' COMMENT '缓存策略ID',\\n  `httpFirewallPolicyId` int(11) unsigned DEFAULT '0' COMMENT 'WAF策略ID',\\n  `accessLog` json DEFAULT NULL COMMENT '访问日志设置',\\n  `systemServices` json DEFAULT NULL COMMENT '系统服务设置',\\n  PRIMARY KEY (`id`),\\n  KEY `uniqueId` (`uniqueId`),\\n  KEY `grantId` (`grantId`),\\n  KEY `dnsDomainId` (`dnsDomainId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点集群'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"useAllAPINodes\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否使用所有API节点'\"},{\"name\":\"apiNodes\",\"definition\":\"json COMMENT '使用的API节点'\"},{\"name\":\"installDir\",\"definition\":\"varchar(512) COMMENT '安装目录'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"grantId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '默认认证方式'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"autoRegister\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否开启自动注册'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"healthCheck\",\"definition\":\"json COMMENT '健康检查'\"},{\"name\":\"dnsName\",\"definition\":\"varchar(255) COMMENT 'DNS名称'\"},{\"name\":\"dnsDomainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"dns\",\"definition\":\"json COMMENT 'DNS配置'\"},{\"name\":\"toa\",\"definition\":\"json COMMENT 'TOA配置'\"},{\"name\":\"cachePolicyId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '缓存策略ID'\"},{\"name\":\"httpFirewallPolicyId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF策略ID'\"},{\"name\":\"accessLog\",\"definition\":\"json COMMENT '访问日志设置'\"},{\"name\":\"systemServices\",\"definition\":\"json COMMENT '系统服务设置'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"KEY `uniqueId` (`uniqueId`) USING BTREE\"},{\"name\":\"grantId\",\"definition\":\"KEY `grantId` (`grantId`) USING BTREE\"},{\"name\":\"dnsDomainId\",\"definition\":\"KEY `dnsDomainId` (`dnsDomainId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeGrants\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeGrants` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `method` varchar(64) DEFAULT NULL COMMENT '登录方式',\\n  `username` varchar(255) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(255) DEFAULT NULL COMMENT '密码',\\n  `su` tinyint(1) unsigned DEFAULT '1' COMMENT '是否需要su',\\n  `privateKey` varchar(4096) DEFAULT NULL COMMENT '密钥',\\n  `description` varchar(255) DEFAULT NULL COMMENT '备注',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '专有节点',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '角色',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点授权'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"method\",\"definition\":\"varchar(64) COMMENT '登录方式'\"},{\"name\":\"username\",\"definition\":\"varchar(255) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(255) COMMENT '密码'\"},{\"name\":\"su\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否需要su'\"},{\"name\":\"privateKey\",\"definition\":\"varchar(4096) COMMENT '密钥'\"},{\"name\":\"description\",\"definition\":\"varchar(255) COMMENT '备注'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '专有节点'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '角色'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeGroups` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点分组'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeIPAddresses\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeIPAddresses` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '节点角色',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `ip` varchar(128) DEFAULT NULL COMMENT 'IP地址',\\n  `description` varchar(255) DEFAULT NULL COMMENT '描述',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `canAccess` tinyint(1) unsigned DEFAULT '1' COMMENT '是否可以访问',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点IP地址'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '节点角色'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"ip\",\"definition\":\"varchar(128) COMMENT 'IP地址'\"},{\"name\":\"description\",\"definition\":\"varchar(255) COMMENT '描述'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"canAccess\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否可以访问'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeLogins\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeLogins` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '角色',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型：ssh,agent',\\n  `params` json DEFAULT NULL COMMENT '配置参数',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点登录信息'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '角色'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型：ssh,agent'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '配置参数'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeLogs` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `role` varchar(64) DEFAULT NULL COMMENT '节点角色',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `tag` varchar(255) DEFAULT NULL COMMENT '标签',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `level` varchar(32) DEFAULT NULL COMMENT '级别',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT '日期',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `originId` int(11) unsigned DEFAULT '0' COMMENT '源站ID',\\n  `hash` varchar(32) DEFAULT NULL COMMENT '信息内容Hash',\\n  `count` int(11) unsigned DEFAULT '0' COMMENT '重复次数',\\n  `isFixed` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已处理',\\n  PRIMARY KEY (`id`),\\n  KEY `level` (`level`),\\n  KEY `day` (`day`),\\n  KEY `role_nodeId` (`role`,`nodeId`) USING BTREE,\\n  KEY `hash` (`hash`),\\n  KEY `serverId` (`serverId`),\\n  KEY `originId` (`originId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"role\",\"definition\":\"varchar(64) COMMENT '节点角色'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"tag\",\"definition\":\"varchar(255) COMMENT '标签'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"level\",\"definition\":\"varchar(32) COMMENT '级别'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT '日期'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"originId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '源站ID'\"},{\"name\":\"hash\",\"definition\":\"varchar(32) COMMENT '信息内容Hash'\"},{\"name\":\"count\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '重复次数'\"},{\"name\":\"isFixed\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已处理'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"level\",\"definition\":\"KEY `level` (`level`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"},{\"name\":\"role_nodeId\",\"definition\":\"KEY `role_nodeId` (`role`,`nodeId`) USING BTREE\"},{\"name\":\"hash\",\"definition\":\"KEY `hash` (`hash`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"originId\",\"definition\":\"KEY `originId` (`originId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodePriceItems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodePriceItems` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `type` varchar(64) DEFAULT NULL COMMENT '类型：峰值|流量',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `bitsFrom` bigint(20) unsigned DEFAULT '0' COMMENT '起始值',\\n  `bitsTo` bigint(20) unsigned DEFAULT '0' COMMENT '结束值',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='区域计费设置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"type\",\"definition\":\"varchar(64) COMMENT '类型：峰值|流量'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"bitsFrom\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '起始值'\"},{\"name\":\"bitsTo\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '结束值'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeRegions\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeRegions` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `prices` json DEFAULT NULL COMMENT '价格',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点区域'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"prices\",\"definition\":\"json COMMENT '价格'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeTasks\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeTasks` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '节点角色',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '任务类型',\\n  `uniqueId` varchar(255) DEFAULT NULL COMMENT '唯一ID：nodeId@type',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `isDone` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成',\\n  `isOk` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '错误信息',\\n  `isNotified` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已通知更新',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE,\\n  KEY `nodeId` (`nodeId`),\\n  KEY `isDone` (`isDone`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `isNotified` (`isNotified`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点同步任务'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '节点角色'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '任务类型'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(255) COMMENT '唯一ID：nodeId@type'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"isDone\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成'\"},{\"name\":\"isOk\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '错误信息'\"},{\"name\":\"isNotified\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已通知更新'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"isDone\",\"definition\":\"KEY `isDone` (`isDone`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"isNotified\",\"definition\":\"KEY `isNotified` (`isNotified`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeThresholds\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeThresholds` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `role` varchar(32) DEFAULT NULL COMMENT '节点角色',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `item` varchar(255) DEFAULT NULL COMMENT '监控项',\\n  `param` varchar(255) DEFAULT NULL COMMENT '参数',\\n  `operator` varchar(255) DEFAULT NULL COMMENT '操作符',\\n  `value` json DEFAULT NULL COMMENT '对比值',\\n  `message` varchar(512) DEFAULT NULL COMMENT '消息内容',\\n  `notifyDuration` int(11) unsigned DEFAULT '0' COMMENT '通知间隔（单位分钟）',\\n  `notifiedAt` int(11) unsigned DEFAULT '0' COMMENT '上次通知时间',\\n  `duration` int(11) unsigned DEFAULT '0' COMMENT '时间段',\\n  `durationUnit` varchar(16) DEFAULT NULL COMMENT '时间段单位',\\n  `sumMethod` varchar(32) DEFAULT NULL COMMENT '聚合方法',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `clusterId` (`clusterId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='集群阈值设置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) COMMENT '节点角色'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"item\",\"definition\":\"varchar(255) COMMENT '监控项'\"},{\"name\":\"param\",\"definition\":\"varchar(255) COMMENT '参数'\"},{\"name\":\"operator\",\"definition\":\"varchar(255) COMMENT '操作符'\"},{\"name\":\"value\",\"definition\":\"json COMMENT '对比值'\"},{\"name\":\"message\",\"definition\":\"varchar(512) COMMENT '消息内容'\"},{\"name\":\"notifyDuration\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '通知间隔（单位分钟）'\"},{\"name\":\"notifiedAt\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '上次通知时间'\"},{\"name\":\"duration\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '时间段'\"},{\"name\":\"durationUnit\",\"definition\":\"varchar(16) COMMENT '时间段单位'\"},{\"name\":\"sumMethod\",\"definition\":\"varchar(32) COMMENT '聚合方法'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeTrafficDailyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeTrafficDailyStats` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '节点角色',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `bytes` bigint(20) unsigned DEFAULT NULL COMMENT '流量字节',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `node_day` (`clusterId`,`nodeId`,`day`) USING BTREE,\\n  KEY `clusterId` (`clusterId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='总的流量统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '节点角色'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned COMMENT '流量字节'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"node_day\",\"definition\":\"UNIQUE KEY `node_day` (`clusterId`,`nodeId`,`day`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeTrafficHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeTrafficHourlyStats` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '节点角色',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `bytes` bigint(20) unsigned DEFAULT NULL COMMENT '流量字节',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `node_day` (`clusterId`,`nodeId`,`hour`) USING BTREE,\\n  KEY `clusterId` (`clusterId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='总的流量统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '节点角色'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned COMMENT '流量字节'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"node_day\",\"definition\":\"UNIQUE KEY `node_day` (`clusterId`,`nodeId`,`hour`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeValues\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeValues` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `role` varchar(64) DEFAULT NULL COMMENT '节点角色',\\n  `item` varchar(255) DEFAULT NULL COMMENT '监控项',\\n  `value` json DEFAULT NULL COMMENT '数据',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `day` varchar(8) DEFAULT NULL COMMENT '日期',\\n  `hour` varchar(10) DEFAULT NULL COMMENT '小时',\\n  `minute` varchar(12) DEFAULT NULL COMMENT '分钟',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `nodeId_role_time` (`nodeId`,`role`,`item`,`day`,`hour`,`minute`) USING BTREE,\\n  KEY `nodeId` (`nodeId`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `role_item_minute` (`role`,`item`,`minute`),\\n  KEY `day` (`day`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点监控数据'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"role\",\"definition\":\"varchar(64) COMMENT '节点角色'\"},{\"name\":\"item\",\"definition\":\"varchar(255) COMMENT '监控项'\"},{\"name\":\"value\",\"definition\":\"json COMMENT '数据'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT '日期'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT '小时'\"},{\"name\":\"minute\",\"definition\":\"varchar(12) COMMENT '分钟'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId_role_time\",\"definition\":\"UNIQUE KEY `nodeId_role_time` (`nodeId`,`role`,`item`,`day`,`hour`,`minute`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"role_item_minute\",\"definition\":\"KEY `role_item_minute` (`role`,`item`,`minute`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `isUp` tinyint(1) unsigned DEFAULT '1' COMMENT '是否在线',\\n  `countUp` int(11) unsigned DEFAULT '0' COMMENT '连续在线次数',\\n  `countDown` int(11) unsigned DEFAULT '0' COMMENT '连续下线次数',\\n  `isActive` tinyint(1) unsigned DEFAULT '1' COMMENT '是否活跃',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '节点ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `name` varchar(255) DEFAULT NULL COMMENT '节点名',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '主集群ID',\\n  `secondaryClusterIds` json DEFAULT NULL COMMENT '从集群ID',\\n  `regionId` int(11) unsigned DEFAULT '0' COMMENT '区域ID',\\n  `groupId` int(11) unsigned DEFAULT '0' COMMENT '分组ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `status` json DEFAULT NULL COMMENT '最新的状态',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '当前版本号',\\n  `latestVersion` int(11) unsigned DEFAULT '0' COMMENT '最后版本号',\\n  `installDir` varchar(512) DEFAULT NULL COMMENT '安装目录',\\n  `isInstalled` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已安装',\\n  `installStatus` json DEFAULT NULL COMMENT '安装状态',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `connectedAPINodes` json DEFAULT NULL COMMENT '当前连接的API节点',\\n  `maxCPU` int(4) unsigned DEFAULT '0' COMMENT '可以使用的最多CPU',\\n  `dnsRoutes` json DEFAULT NULL COMMENT 'DNS线路设置',\\n  `maxCacheDiskCapacity` json DEFAULT NULL COMMENT '硬盘缓存容量',\\n  `maxCacheMemoryCapacity` json DEFAULT NULL COMMENT '内存缓存容量',\\n  PRIMARY KEY (`id`),\\n  KEY `uniqueId` (`uniqueId`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `groupId` (`groupId`),\\n  KEY `regionId` (`regionId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"isUp\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否在线'\"},{\"name\":\"countUp\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '连续在线次数'\"},{\"name\":\"countDown\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '连续下线次数'\"},{\"name\":\"isActive\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否活跃'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '节点ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '节点名'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '主集群ID'\"},{\"name\":\"secondaryClusterIds\",\"definition\":\"json COMMENT '从集群ID'\"},{\"name\":\"regionId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '区域ID'\"},{\"name\":\"groupId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '分组ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '最新的状态'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '当前版本号'\"},{\"name\":\"latestVersion\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最后版本号'\"},{\"name\":\"installDir\",\"definition\":\"varchar(512) COMMENT '安装目录'\"},{\"name\":\"isInstalled\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已安装'\"},{\"name\":\"installStatus\",\"definition\":\"json COMMENT '安装状态'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"connectedAPINodes\",\"definition\":\"json COMMENT '当前连接的API节点'\"},{\"name\":\"maxCPU\",\"definition\":\"int(4) unsigned DEFAULT '0' COMMENT '可以使用的最多CPU'\"},{\"name\":\"dnsRoutes\",\"definition\":\"json COMMENT 'DNS线路设置'\"},{\"name\":\"maxCacheDiskCapacity\",\"definition\":\"json COMMENT '硬盘缓存容量'\"},{\"name\":\"maxCacheMemoryCapacity\",\"definition\":\"json COMMENT '内存缓存容量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"KEY `uniqueId` (`uniqueId`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"groupId\",\"definition\":\"KEY `groupId` (`groupId`) USING BTREE\"},{\"name\":\"regionId\",\"definition\":\"KEY `regionId` (`regionId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeOrigins\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeOrigins` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '版本',\\n  `addr` json DEFAULT NULL COMMENT '地址',\\n  `description` varchar(512) DEFAULT NULL COMMENT '描述',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `connTimeout` json DEFAULT NULL COMMENT '连接超时',\\n  `readTimeout` json DEFAULT NULL COMMENT '读超时',\\n  `idleTimeout` json DEFAULT NULL COMMENT '空闲连接超时',\\n  `maxFails` int(11) unsigned DEFAULT '0' COMMENT '最多失败次数',\\n  `maxConns` int(11) unsigned DEFAULT '0' COMMENT '最大并发连接数',\\n  `maxIdleConns` int(11) unsigned DEFAULT '0' COMMENT '最多空闲连接数',\\n  `httpRequestURI` varchar(1024) DEFAULT NULL COMMENT '转发后的请求URI',\\n  `httpRequestHeader` json DEFAULT NULL COMMENT '请求Header配置',\\n  `httpResponseHeader` json DEFAULT NULL COMMENT '响应Header配置',\\n  `host` varchar(255) DEFAULT NULL COMMENT '自定义主机名',\\n  `healthCheck` json DEFAULT NULL COMMENT '健康检查设置',\\n  `cert` json DEFAULT NULL COMMENT '证书设置',\\n  `ftp` json DEFAULT NULL COMMENT 'FTP相关设置',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='源站'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '版本'\"},{\"name\":\"addr\",\"definition\":\"json COMMENT '地址'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '描述'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"connTimeout\",\"definition\":\"json COMMENT '连接超时'\"},{\"name\":\"readTimeout\",\"definition\":\"json COMMENT '读超时'\"},{\"name\":\"idleTimeout\",\"definition\":\"json COMMENT '空闲连接超时'\"},{\"name\":\"maxFails\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最多失败次数'\"},{\"name\":\"maxConns\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最大并发连接数'\"},{\"name\":\"maxIdleConns\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最多空闲连接数'\"},{\"name\":\"httpRequestURI\",\"definition\":\"varchar(1024) COMMENT '转发后的请求URI'\"},{\"name\":\"httpRequestHeader\",\"definition\":\"json COMMENT '请求Header配置'\"},{\"name\":\"httpResponseHeader\",\"definition\":\"json COMMENT '响应Header配置'\"},{\"name\":\"host\",\"definition\":\"varchar(255) COMMENT '自定义主机名'\"},{\"name\":\"healthCheck\",\"definition\":\"json COMMENT '健康检查设置'\"},{\"name\":\"cert\",\"definition\":\"json COMMENT '证书设置'\"},{\"name\":\"ftp\",\"definition\":\"json COMMENT 'FTP相关设置'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeProviders\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeProviders` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `username` varchar(64) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(32) DEFAULT NULL COMMENT '密码',\\n  `fullname` varchar(64) DEFAULT NULL COMMENT '真实姓名',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='供应商'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"username\",\"definition\":\"varchar(64) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(32) COMMENT '密码'\"},{\"name\":\"fullname\",\"definition\":\"varchar(64) COMMENT '真实姓名'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeRegionCities\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeRegionCities` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `provinceId` int(11) unsigned DEFAULT '0' COMMENT '省份ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `dataId` varchar(255) DEFAULT NULL COMMENT '原始数据ID',\\n  PRIMARY KEY (`id`),\\n  KEY `proviceId` (`provinceId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='区域-城市'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"provinceId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '省份ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"dataId\",\"definition\":\"varchar(255) COMMENT '原始数据ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"proviceId\",\"definition\":\"KEY `proviceId` (`provinceId`) USING BTREE\"}],\"records\":[{\"id\":1,\"values\":{\"codes\":\"[\\\"北京市\\\"]\",\"dataId\":\"215\",\"id\":\"1\",\"name\":\"北京市\",\"provinceId\":\"1\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":2,\"values\":{\"codes\":\"[\\\"天津市\\\"]\",\"dataId\":\"235\",\"id\":\"2\",\"name\":\"天津市\",\"provinceId\":\"2\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":3,\"values\":{\"codes\":\"[\\\"石家庄市\\\"]\",\"dataId\":\"255\",\"id\":\"3\",\"name\":\"石家庄市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":4,\"values\":{\"codes\":\"[\\\"唐山市\\\"]\",\"dataId\":\"279\",\"id\":\"4\",\"name\":\"唐山市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":5,\"values\":{\"codes\":\"[\\\"秦皇岛市\\\"]\",\"dataId\":\"294\",\"id\":\"5\",\"name\":\"秦皇岛市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":6,\"values\":{\"codes\":\"[\\\"邯郸市\\\"]\",\"dataId\":\"302\",\"id\":\"6\",\"name\":\"邯郸市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":7,\"values\":{\"codes\":\"[\\\"邢台市\\\"]\",\"dataId\":\"322\",\"id\":\"7\",\"name\":\"邢台市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":8,\"values\":{\"codes\":\"[\\\"保定市\\\"]\",\"dataId\":\"340\",\"id\":\"8\",\"name\":\"保定市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":9,\"values\":{\"codes\":\"[\\\"张家口市\\\"]\",\"dataId\":\"366\",\"id\":\"9\",\"name\":\"张家口市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":10,\"values\":{\"codes\":\"[\\\"承德市\\\"]\",\"dataId\":\"382\",\"id\":\"10\",\"name\":\"承德市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":11,\"values\":{\"codes\":\"[\\\"沧州市\\\"]\",\"dataId\":\"394\",\"id\":\"11\",\"name\":\"沧州市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":12,\"values\":{\"codes\":\"[\\\"廊坊市\\\"]\",\"dataId\":\"410\",\"id\":\"12\",\"name\":\"廊坊市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":13,\"values\":{\"codes\":\"[\\\"衡水市\\\"]\",\"dataId\":\"421\",\"id\":\"13\",\"name\":\"衡水市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":14,\"values\":{\"codes\":\"[\\\"太原市\\\"]\",\"dataId\":\"434\",\"id\":\"14\",\"name\":\"太原市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":15,\"values\":{\"codes\":\"[\\\"大同市\\\"]\",\"dataId\":\"445\",\"id\":\"15\",\"name\":\"大同市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":16,\"values\":{\"codes\":\"[\\\"阳泉市\\\"]\",\"dataId\":\"457\",\"id\":\"16\",\"name\":\"阳泉市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":17,\"values\":{\"codes\":\"[\\\"长治市\\\"]\",\"dataId\":\"461\",\"id\":\"17\",\"name\":\"长治市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":18,\"values\":{\"codes\":\"[\\\"晋城市\\\"]\",\"dataId\":\"473\",\"id\":\"18\",\"name\":\"晋城市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":19,\"values\":{\"codes\":\"[\\\"朔州市\\\"]\",\"dataId\":\"479\",\"id\":\"19\",\"name\":\"朔州市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":20,\"values\":{\"codes\":\"[\\\"晋中市\\\"]\",\"dataId\":\"486\",\"id\":\"20\",\"name\":\"晋中市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":21,\"values\":{\"codes\":\"[\\\"运城市\\\"]\",\"dataId\":\"498\",\"id\":\"21\",\"name\":\"运城市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":22,\"values\":{\"codes\":\"[\\\"忻州市\\\"]\",\"dataId\":\"512\",\"id\":\"22\",\"name\":\"忻州市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":23,\"values\":{\"codes\":\"[\\\"临汾市\\\"]\",\"dataId\":\"527\",\"id\":\"23\",\"name\":\"临汾市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":24,\"values\":{\"codes\":\"[\\\"吕梁市\\\"]\",\"dataId\":\"545\",\"id\":\"24\",\"name\":\"吕梁市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":25,\"values\":{\"codes\":\"[\\\"呼和浩特市\\\"]\",\"dataId\":\"560\",\"id\":\"25\",\"name\":\"呼和浩特市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":26,\"values\":{\"codes\":\"[\\\"包头市\\\"]\",\"dataId\":\"570\",\"id\":\"26\",\"name\":\"包头市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":27,\"values\":{\"codes\":\"[\\\"乌海市\\\"]\",\"dataId\":\"580\",\"id\":\"27\",\"name\":\"乌海市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":28,\"values\":{\"codes\":\"[\\\"赤峰市\\\"]\",\"dataId\":\"584\",\"id\":\"28\",\"name\":\"赤峰市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":29,\"values\":{\"codes\":\"[\\\"通辽市\\\"]\",\"dataId\":\"597\",\"id\":\"29\",\"name\":\"通辽市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":30,\"values\":{\"codes\":\"[\\\"鄂尔多斯市\\\"]\",\"dataId\":\"606\",\"id\":\"30\",\"name\":\"鄂尔多斯市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":31,\"values\":{\"codes\":\"[\\\"呼伦贝尔市\\\"]\",\"dataId\":\"615\",\"id\":\"31\",\"name\":\"呼伦贝尔市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":32,\"values\":{\"codes\":\"[\\\"巴彦淖尔市\\\"]\",\"dataId\":\"629\",\"id\":\"32\",\"name\":\"巴彦淖尔市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":33,\"values\":{\"codes\":\"[\\\"乌兰察布市\\\"]\",\"dataId\":\"637\",\"id\":\"33\",\"name\":\"乌兰察布市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":34,\"values\":{\"codes\":\"[\\\"兴安盟\\\"]\",\"dataId\":\"649\",\"id\":\"34\",\"name\":\"兴安盟\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":35,\"values\":{\"codes\":\"[\\\"锡林郭勒盟\\\"]\",\"dataId\":\"656\",\"id\":\"35\",\"name\":\"锡林郭勒盟\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":36,\"values\":{\"codes\":\"[\\\"阿拉善盟\\\"]\",\"dataId\":\"669\",\"id\":\"36\",\"name\":\"阿拉善盟\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":37,\"values\":{\"codes\":\"[\\\"沈阳市\\\"]\",\"dataId\":\"674\",\"id\":\"37\",\"name\":\"沈阳市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":38,\"values\":{\"codes\":\"[\\\"大连市\\\"]\",\"dataId\":\"687\",\"id\":\"38\",\"name\":\"大连市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":39,\"values\":{\"codes\":\"[\\\"鞍山市\\\"]\",\"dataId\":\"698\",\"id\":\"39\",\"name\":\"鞍山市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":40,\"values\":{\"codes\":\"[\\\"抚顺市\\\"]\",\"dataId\":\"705\",\"id\":\"40\",\"name\":\"抚顺市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":41,\"values\":{\"codes\":\"[\\\"本溪市\\\"]\",\"dataId\":\"713\",\"id\":\"41\",\"name\":\"本溪市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":42,\"values\":{\"codes\":\"[\\\"丹东市\\\"]\",\"dataId\":\"720\",\"id\":\"42\",\"name\":\"丹东市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":43,\"values\":{\"codes\":\"[\\\"锦州市\\\"]\",\"dataId\":\"727\",\"id\":\"43\",\"name\":\"锦州市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":44,\"values\":{\"codes\":\"[\\\"营口市\\\"]\",\"dataId\":\"735\",\"id\":\"44\",\"name\":\"营口市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":45,\"values\":{\"codes\":\"[\\\"阜新市\\\"]\",\"dataId\":\"742\",\"id\":\"45\",\"name\":\"阜新市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":46,\"values\":{\"codes\":\"[\\\"辽阳市\\\"]\",\"dataId\":\"750\",\"id\":\"46\",\"name\":\"辽阳市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":47,\"values\":{\"codes\":\"[\\\"盘锦市\\\"]\",\"dataId\":\"758\",\"id\":\"47\",\"name\":\"盘锦市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":48,\"values\":{\"codes\":\"[\\\"铁岭市\\\"]\",\"dataId\":\"763\",\"id\":\"48\",\"name\":\"铁岭市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":49,\"values\":{\"codes\":\"[\\\"朝阳市\\\"]\",\"dataId\":\"771\",\"id\":\"49\",\"name\":\"朝阳市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":50,\"values\":{\"codes\":\"[\\\"葫芦岛市\\\"]\",\"dataId\":\"779\",\"id\":\"50\",\"name\":\"葫芦岛市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":51,\"values\":{\"codes\":\"[\\\"长春市\\\"]\",\"dataId\":\"787\",\"id\":\"51\",\"name\":\"长春市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":52,\"values\":{\"codes\":\"[\\\"吉林市\\\"]\",\"dataId\":\"797\",\"id\":\"52\",\"name\":\"吉林市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":53,\"values\":{\"codes\":\"[\\\"四平市\\\"]\",\"dataId\":\"807\",\"id\":\"53\",\"name\":\"四平市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":54,\"values\":{\"codes\":\"[\\\"辽源市\\\"]\",\"dataId\":\"812\",\"id\":\"54\",\"name\":\"辽源市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":55,\"values\":{\"codes\":\"[\\\"通化市\\\"]\",\"dataId\":\"817\",\"id\":\"55\",\"name\":\"通化市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":56,\"values\":{\"codes\":\"[\\\"白山市\\\"]\",\"dataId\":\"825\",\"id\":\"56\",\"name\":\"白山市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":57,\"values\":{\"codes\":\"[\\\"松原市\\\"]\",\"dataId\":\"832\",\"id\":\"57\",\"name\":\"松原市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":58,\"values\":{\"codes\":\"[\\\"白城市\\\"]\",\"dataId\":\"838\",\"id\":\"58\",\"name\":\"白城市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":59,\"values\":{\"codes\":\"[\\\"延边朝鲜族自治州\\\"]\",\"dataId\":\"844\",\"id\":\"59\",\"name\":\"延边朝鲜族自治州\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":60,\"values\":{\"codes\":\"[\\\"哈尔滨市\\\"]\",\"dataId\":\"854\",\"id\":\"60\",\"name\":\"哈尔滨市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":61,\"values\":{\"codes\":\"[\\\"齐齐哈尔市\\\"]\",\"dataId\":\"874\",\"id\":\"61\",\"name\":\"齐齐哈尔市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":62,\"values\":{\"codes\":\"[\\\"鸡西市\\\"]\",\"dataId\":\"891\",\"id\":\"62\",\"name\":\"鸡西市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":63,\"values\":{\"codes\":\"[\\\"鹤岗市\\\"]\",\"dataId\":\"901\",\"id\":\"63\",\"name\":\"鹤岗市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":64,\"values\":{\"codes\":\"[\\\"双鸭山市\\\"]\",\"dataId\":\"910\",\"id\":\"64\",\"name\":\"双鸭山市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":65,\"values\":{\"codes\":\"[\\\"大庆市\\\"]\",\"dataId\":\"919\",\"id\":\"65\",\"name\":\"大庆市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":66,\"values\":{\"codes\":\"[\\\"伊春市\\\"]\",\"dataId\":\"929\",\"id\":\"66\",\"name\":\"伊春市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":67,\"values\":{\"codes\":\"[\\\"佳木斯市\\\"]\",\"dataId\":\"947\",\"id\":\"67\",\"name\":\"佳木斯市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":68,\"values\":{\"codes\":\"[\\\"七台河市\\\"]\",\"dataId\":\"957\",\"id\":\"68\",\"name\":\"七台河市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":69,\"values\":{\"codes\":\"[\\\"牡丹江市\\\"]\",\"dataId\":\"962\",\"id\":\"69\",\"name\":\"牡丹江市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":70,\"values\":{\"codes\":\"[\\\"黑河市\\\"]\",\"dataId\":\"972\",\"id\":\"70\",\"name\":\"黑河市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":71,\"values\":{\"codes\":\"[\\\"绥化市\\\"]\",\"dataId\":\"979\",\"id\":\"71\",\"name\":\"绥化市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":72,\"values\":{\"codes\":\"[\\\"大兴安岭地区\\\"]\",\"dataId\":\"990\",\"id\":\"72\",\"name\":\"大兴安岭地区\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":73,\"values\":{\"codes\":\"[\\\"上海市\\\"]\",\"dataId\":\"995\",\"id\":\"73\",\"name\":\"上海市\",\"provinceId\":\"9\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":74,\"values\":{\"codes\":\"[\\\"南京市\\\"]\",\"dataId\":\"1015\",\"id\":\"74\",\"name\":\"南京市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":75,\"values\":{\"codes\":\"[\\\"无锡市\\\"]\",\"dataId\":\"1029\",\"id\":\"75\",\"name\":\"无锡市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":76,\"values\":{\"codes\":\"[\\\"徐州市\\\"]\",\"dataId\":\"1038\",\"id\":\"76\",\"name\":\"徐州市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":77,\"values\":{\"codes\":\"[\\\"常州市\\\"]\",\"dataId\":\"1049\",\"id\":\"77\",\"name\":\"常州市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":78,\"values\":{\"codes\":\"[\\\"苏州市\\\"]\",\"dataId\":\"1057\",\"id\":\"78\",\"name\":\"苏州市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":79,\"values\":{\"codes\":\"[\\\"南通市\\\"]\",\"dataId\":\"1069\",\"id\":\"79\",\"name\":\"南通市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":80,\"values\":{\"codes\":\"[\\\"连云港市\\\"]\",\"dataId\":\"1078\",\"id\":\"80\",\"name\":\"连云港市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":81,\"values\":{\"codes\":\"[\\\"淮安市\\\"]\",\"dataId\":\"1085\",\"id\":\"81\",\"name\":\"淮安市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":82,\"values\":{\"codes\":\"[\\\"盐城市\\\"]\",\"dataId\":\"1093\",\"id\":\"82\",\"name\":\"盐城市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":83,\"values\":{\"codes\":\"[\\\"扬州市\\\"]\",\"dataId\":\"1103\",\"id\":\"83\",\"name\":\"扬州市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":84,\"values\":{\"codes\":\"[\\\"镇江市\\\"]\",\"dataId\":\"1111\",\"id\":\"84\",\"name\":\"镇江市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":85,\"values\":{\"codes\":\"[\\\"泰州市\\\"]\",\"dataId\":\"1118\",\"id\":\"85\",\"name\":\"泰州市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":86,\"values\":{\"codes\":\"[\\\"宿迁市\\\"]\",\"dataId\":\"1125\",\"id\":\"86\",\"name\":\"宿迁市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":87,\"values\":{\"codes\":\"[\\\"杭州市\\\"]\",\"dataId\":\"1132\",\"id\":\"87\",\"name\":\"杭州市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":88,\"values\":{\"codes\":\"[\\\"宁波市\\\"]\",\"dataId\":\"1146\",\"id\":\"88\",\"name\":\"宁波市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":89,\"values\":{\"codes\":\"[\\\"温州市\\\"]\",\"dataId\":\"1158\",\"id\":\"89\",\"name\":\"温州市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":90,\"values\":{\"codes\":\"[\\\"嘉兴市\\\"]\",\"dataId\":\"1170\",\"id\":\"90\",\"name\":\"嘉兴市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":91,\"values\":{\"codes\":\"[\\\"湖州市\\\"]\",\"dataId\":\"1178\",\"id\":\"91\",\"name\":\"湖州市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":92,\"values\":{\"codes\":\"[\\\"绍兴市\\\"]\",\"dataId\":\"1184\",\"id\":\"92\",\"name\":\"绍兴市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":93,\"values\":{\"codes\":\"[\\\"金华市\\\"]\",\"dataId\":\"1191\",\"id\":\"93\",\"name\":\"金华市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":94,\"values\":{\"codes\":\"[\\\"衢州市\\\"]\",\"dataId\":\"1201\",\"id\":\"94\",\"name\":\"衢州市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":95,\"values\":{\"codes\":\"[\\\"舟山市\\\"]\",\"dataId\":\"1208\",\"id\":\"95\",\"name\":\"舟山市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":96,\"values\":{\"codes\":\"[\\\"台州市\\\"]\",\"dataId\":\"1212\",\"id\":\"96\",\"name\":\"台州市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":97,\"values\":{\"codes\":\"[\\\"丽水市\\\"]\",\"dataId\":\"1222\",\"id\":\"97\",\"name\":\"丽水市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":98,\"values\":{\"codes\":\"[\\\"合肥市\\\"]\",\"dataId\":\"1233\",\"id\":\"98\",\"name\":\"合肥市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":99,\"values\":{\"codes\":\"[\\\"芜湖市\\\"]\",\"dataId\":\"1241\",\"id\":\"99\",\"name\":\"芜湖市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":100,\"values\":{\"codes\":\"[\\\"蚌埠市\\\"]\",\"dataId\":\"1249\",\"id\":\"100\",\"name\":\"蚌埠市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":101,\"values\":{\"codes\":\"[\\\"淮南市\\\"]\",\"dataId\":\"1257\",\"id\":\"101\",\"name\":\"淮南市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":102,\"values\":{\"codes\":\"[\\\"马鞍山市\\\"]\",\"dataId\":\"1264\",\"id\":\"102\",\"name\":\"马鞍山市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":103,\"values\":{\"codes\":\"[\\\"淮北市\\\"]\",\"dataId\":\"1269\",\"id\":\"103\",\"name\":\"淮北市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":104,\"values\":{\"codes\":\"[\\\"铜陵市\\\"]\",\"dataId\":\"1274\",\"id\":\"104\",\"name\":\"铜陵市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":105,\"values\":{\"codes\":\"[\\\"安庆市\\\"]\",\"dataId\":\"1278\",\"id\":\"105\",\"name\":\"安庆市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":106,\"values\":{\"codes\":\"[\\\"黄山市\\\"]\",\"dataId\":\"1289\",\"id\":\"106\",\"name\":\"黄山市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":107,\"values\":{\"codes\":\"[\\\"滁州市\\\"]\",\"dataId\":\"1297\",\"id\":\"107\",\"name\":\"滁州市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":108,\"values\":{\"codes\":\"[\\\"阜阳市\\\"]\",\"dataId\":\"1306\",\"id\":\"108\",\"name\":\"阜阳市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":109,\"values\":{\"codes\":\"[\\\"宿州市\\\"]\",\"dataId\":\"1315\",\"id\":\"109\",\"name\":\"宿州市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":110,\"values\":{\"codes\":\"[\\\"巢湖市\\\"]\",\"dataId\":\"1321\",\"id\":\"110\",\"name\":\"巢湖市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":111,\"values\":{\"codes\":\"[\\\"六安市\\\"]\",\"dataId\":\"1327\",\"id\":\"111\",\"name\":\"六安市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":112,\"values\":{\"codes\":\"[\\\"亳州市\\\"]\",\"dataId\":\"1335\",\"id\":\"112\",\"name\":\"亳州市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":113,\"values\":{\"codes\":\"[\\\"池州市\\\"]\",\"dataId\":\"1340\",\"id\":\"113\",\"name\":\"池州市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":114,\"values\":{\"codes\":\"[\\\"宣城市\\\"]\",\"dataId\":\"1345\",\"id\":\"114\",\"name\":\"宣城市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":115,\"values\":{\"codes\":\"[\\\"福州市\\\"]\",\"dataId\":\"1354\",\"id\":\"115\",\"name\":\"福州市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":116,\"values\":{\"codes\":\"[\\\"厦门市\\\"]\",\"dataId\":\"1367\",\"id\":\"116\",\"name\":\"厦门市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":117,\"values\":{\"codes\":\"[\\\"莆田市\\\"]\",\"dataId\":\"1374\",\"id\":\"117\",\"name\":\"莆田市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":118,\"values\":{\"codes\":\"[\\\"三明市\\\"]\",\"dataId\":\"1380\",\"id\":\"118\",\"name\":\"三明市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":119,\"values\":{\"codes\":\"[\\\"泉州市\\\"]\",\"dataId\":\"1393\",\"id\":\"119\",\"name\":\"泉州市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":120,\"values\":{\"codes\":\"[\\\"漳州市\\\"]\",\"dataId\":\"1406\",\"id\":\"120\",\"name\":\"漳州市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":121,\"values\":{\"codes\":\"[\\\"南平市\\\"]\",\"dataId\":\"1418\",\"id\":\"121\",\"name\":\"南平市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":122,\"values\":{\"codes\":\"[\\\"龙岩市\\\"]\",\"dataId\":\"1429\",\"id\":\"122\",\"name\":\"龙岩市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":123,\"values\":{\"codes\":\"[\\\"宁德市\\\"]\",\"dataId\":\"1437\",\"id\":\"123\",\"name\":\"宁德市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":124,\"values\":{\"codes\":\"[\\\"南昌市\\\"]\",\"dataId\":\"1448\",\"id\":\"124\",\"name\":\"南昌市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":125,\"values\":{\"codes\":\"[\\\"景德镇市\\\"]\",\"dataId\":\"1457\",\"id\":\"125\",\"name\":\"景德镇市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":126,\"values\":{\"codes\":\"[\\\"萍乡市\\\"]\",\"dataId\":\"1462\",\"id\":\"126\",\"name\":\"萍乡市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":127,\"values\":{\"codes\":\"[\\\"九江市\\\"]\",\"dataId\":\"1468\",\"id\":\"127\",\"name\":\"九江市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":128,\"values\":{\"codes\":\"[\\\"新余市\\\"]\",\"dataId\":\"1481\",\"id\":\"128\",\"name\":\"新余市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":129,\"values\":{\"codes\":\"[\\\"鹰潭市\\\"]\",\"dataId\":\"1484\",\"id\":\"129\",\"name\":\"鹰潭市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":130,\"values\":{\"codes\":\"[\\\"赣州市\\\"]\",\"dataId\":\"1488\",\"id\":\"130\",\"name\":\"赣州市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":131,\"values\":{\"codes\":\"[\\\"吉安市\\\"]\",\"dataId\":\"1507\",\"id\":\"131\",\"name\":\"吉安市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":132,\"values\":{\"codes\":\"[\\\"宜春市\\\"]\",\"dataId\":\"1521\",\"id\":\"132\",\"name\":\"宜春市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":133,\"values\":{\"codes\":\"[\\\"抚州市\\\"]\",\"dataId\":\"1532\",\"id\":\"133\",\"name\":\"抚州市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":134,\"values\":{\"codes\":\"[\\\"上饶市\\\"]\",\"dataId\":\"1544\",\"id\":\"134\",\"name\":\"上饶市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":135,\"values\":{\"codes\":\"[\\\"济南市\\\"]\",\"dataId\":\"1558\",\"id\":\"135\",\"name\":\"济南市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":136,\"values\":{\"codes\":\"[\\\"青岛市\\\"]\",\"dataId\":\"1569\",\"id\":\"136\",\"name\":\"青岛市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":137,\"values\":{\"codes\":\"[\\\"淄博市\\\"]\",\"dataId\":\"1582\",\"id\":\"137\",\"name\":\"淄博市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":138,\"values\":{\"codes\":\"[\\\"枣庄市\\\"]\",\"dataId\":\"1591\",\"id\":\"138\",\"name\":\"枣庄市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":139,\"values\":{\"codes\":\"[\\\"东营市\\\"]\",\"dataId\":\"1597\",\"id\":\"139\",\"name\":\"东营市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":140,\"values\":{\"codes\":\"[\\\"烟台市\\\"]\",\"dataId\":\"1603\",\"id\":\"140\",\"name\":\"烟台市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":141,\"values\":{\"codes\":\"[\\\"潍坊市\\\"]\",\"dataId\":\"1616\",\"id\":\"141\",\"name\":\"潍坊市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":142,\"values\":{\"codes\":\"[\\\"济宁市\\\"]\",\"dataId\":\"1629\",\"id\":\"142\",\"name\":\"济宁市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":143,\"values\":{\"codes\":\"[\\\"泰安市\\\"]\",\"dataId\":\"1641\",\"id\":\"143\",\"name\":\"泰安市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":144,\"values\":{\"codes\":\"[\\\"威海市\\\"]\",\"dataId\":\"1648\",\"id\":\"144\",\"name\":\"威海市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":145,\"values\":{\"codes\":\"[\\\"日照市\\\"]\",\"dataId\":\"1653\",\"id\":\"145\",\"name\":\"日照市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":146,\"values\":{\"codes\":\"[\\\"莱芜市\\\"]\",\"dataId\":\"1658\",\"id\":\"146\",\"name\":\"莱芜市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":147,\"values\":{\"codes\":\"[\\\"临沂市\\\"]\",\"dataId\":\"1661\",\"id\":\"147\",\"name\":\"临沂市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":148,\"values\":{\"codes\":\"[\\\"德州市\\\"]\",\"dataId\":\"1673\",\"id\":\"148\",\"name\":\"德州市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":149,\"values\":{\"codes\":\"[\\\"聊城市\\\"]\",\"dataId\":\"1685\",\"id\":\"149\",\"name\":\"聊城市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":150,\"values\":{\"codes\":\"[\\\"滨州市\\\"]\",\"dataId\":\"1694\",\"id\":\"150\",\"name\":\"滨州市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":151,\"values\":{\"codes\":\"[\\\"菏泽市\\\"]\",\"dataId\":\"1702\",\"id\":\"151\",\"name\":\"菏泽市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":152,\"values\":{\"codes\":\"[\\\"郑州市\\\"]\",\"dataId\":\"1713\",\"id\":\"152\",\"name\":\"郑州市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":153,\"values\":{\"codes\":\"[\\\"开封市\\\"]\",\"dataId\":\"1726\",\"id\":\"153\",\"name\":\"开封市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":154,\"values\":{\"codes\":\"[\\\"洛阳市\\\"]\",\"dataId\":\"1734\",\"id\":\"154\",\"name\":\"洛阳市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":155,\"values\":{\"codes\":\"[\\\"平顶山市\\\"]\",\"dataId\":\"1750\",\"id\":\"155\",\"name\":\"平顶山市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":156,\"values\":{\"codes\":\"[\\\"安阳市\\\"]\",\"dataId\":\"1760\",\"id\":\"156\",\"name\":\"安阳市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":157,\"values\":{\"codes\":\"[\\\"鹤壁市\\\"]\",\"dataId\":\"1770\",\"id\":\"157\",\"name\":\"鹤壁市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":158,\"values\":{\"codes\":\"[\\\"新乡市\\\"]\",\"dataId\":\"1776\",\"id\":\"158\",\"name\":\"新乡市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":159,\"values\":{\"codes\":\"[\\\"焦作市\\\"]\",\"dataId\":\"1789\",\"id\":\"159\",\"name\":\"焦作市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":160,\"values\":{\"codes\":\"[\\\"濮阳市\\\"]\",\"dataId\":\"1801\",\"id\":\"160\",\"name\":\"濮阳市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":161,\"values\":{\"codes\":\"[\\\"许昌市\\\"]\",\"dataId\":\"1808\",\"id\":\"161\",\"name\":\"许昌市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":162,\"values\":{\"codes\":\"[\\\"漯河市\\\"]\",\"dataId\":\"1815\",\"id\":\"162\",\"name\":\"漯河市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":163,\"values\":{\"codes\":\"[\\\"三门峡市\\\"]\",\"dataId\":\"1821\",\"id\":\"163\",\"name\":\"三门峡市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":164,\"values\":{\"codes\":\"[\\\"南阳市\\\"]\",\"dataId\":\"1829\",\"id\":\"164\",\"name\":\"南阳市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":165,\"values\":{\"codes\":\"[\\\"商丘市\\\"]\",\"dataId\":\"1843\",\"id\":\"165\",\"name\":\"商丘市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":166,\"values\":{\"codes\":\"[\\\"信阳市\\\"]\",\"dataId\":\"1853\",\"id\":\"166\",\"name\":\"信阳市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":167,\"values\":{\"codes\":\"[\\\"周口市\\\"]\",\"dataId\":\"1864\",\"id\":\"167\",\"name\":\"周口市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":168,\"values\":{\"codes\":\"[\\\"驻马店市\\\"]\",\"dataId\":\"1875\",\"id\":\"168\",\"name\":\"驻马店市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":169,\"values\":{\"codes\":\"[\\\"武汉市\\\"]\",\"dataId\":\"1887\",\"id\":\"169\",\"name\":\"武汉市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":170,\"values\":{\"codes\":\"[\\\"黄石市\\\"]\",\"dataId\":\"1900\",\"id\":\"170\",\"name\":\"黄石市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":171,\"values\":{\"codes\":\"[\\\"十堰市\\\"]\",\"dataId\":\"1907\",\"id\":\"171\",\"name\":\"十堰市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":172,\"values\":{\"codes\":\"[\\\"宜昌市\\\"]\",\"dataId\":\"1916\",\"id\":\"172\",\"name\":\"宜昌市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":173,\"values\":{\"codes\":\"[\\\"襄樊市\\\"]\",\"dataId\":\"1930\",\"id\":\"173\",\"name\":\"襄樊市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":174,\"values\":{\"codes\":\"[\\\"鄂州市\\\"]\",\"dataId\":\"1940\",\"id\":\"174\",\"name\":\"鄂州市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":175,\"values\":{\"codes\":\"[\\\"荆门市\\\"]\",\"dataId\":\"1944\",\"id\":\"175\",\"name\":\"荆门市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":176,\"values\":{\"codes\":\"[\\\"孝感市\\\"]\",\"dataId\":\"1950\",\"id\":\"176\",\"name\":\"孝感市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":177,\"values\":{\"codes\":\"[\\\"荆州市\\\"]\",\"dataId\":\"1958\",\"id\":\"177\",\"name\":\"荆州市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":178,\"values\":{\"codes\":\"[\\\"黄冈市\\\"]\",\"dataId\":\"1967\",\"id\":\"178\",\"name\":\"黄冈市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":179,\"values\":{\"codes\":\"[\\\"咸宁市\\\"]\",\"dataId\":\"1978\",\"id\":\"179\",\"name\":\"咸宁市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":180,\"values\":{\"codes\":\"[\\\"随州市\\\"]\",\"dataId\":\"1985\",\"id\":\"180\",\"name\":\"随州市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":181,\"values\":{\"codes\":\"[\\\"恩施土家族苗族自治州\\\"]\",\"dataId\":\"1988\",\"id\":\"181\",\"name\":\"恩施土家族苗族自治州\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":182,\"values\":{\"codes\":\"[\\\"神农架\\\"]\",\"dataId\":\"1997\",\"id\":\"182\",\"name\":\"神农架\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":183,\"values\":{\"codes\":\"[\\\"长沙市\\\"]\",\"dataId\":\"2003\",\"id\":\"183\",\"name\":\"长沙市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":184,\"values\":{\"codes\":\"[\\\"株洲市\\\"]\",\"dataId\":\"2013\",\"id\":\"184\",\"name\":\"株洲市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":185,\"values\":{\"codes\":\"[\\\"湘潭市\\\"]\",\"dataId\":\"2023\",\"id\":\"185\",\"name\":\"湘潭市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":186,\"values\":{\"codes\":\"[\\\"衡阳市\\\"]\",\"dataId\":\"2029\",\"id\":\"186\",\"name\":\"衡阳市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":187,\"values\":{\"codes\":\"[\\\"邵阳市\\\"]\",\"dataId\":\"2042\",\"id\":\"187\",\"name\":\"邵阳市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":188,\"values\":{\"codes\":\"[\\\"岳阳市\\\"]\",\"dataId\":\"2055\",\"id\":\"188\",\"name\":\"岳阳市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":189,\"values\":{\"codes\":\"[\\\"常德市\\\"]\",\"dataId\":\"2065\",\"id\":\"189\",\"name\":\"常德市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":190,\"values\":{\"codes\":\"[\\\"张家界市\\\"]\",\"dataId\":\"2075\",\"id\":\"190\",\"name\":\"张家界市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":191,\"values\":{\"codes\":\"[\\\"益阳市\\\"]\",\"dataId\":\"2080\",\"id\":\"191\",\"name\":\"益阳市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":192,\"values\":{\"codes\":\"[\\\"郴州市\\\"]\",\"dataId\":\"2087\",\"id\":\"192\",\"name\":\"郴州市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":193,\"values\":{\"codes\":\"[\\\"永州市\\\"]\",\"dataId\":\"2099\",\"id\":\"193\",\"name\":\"永州市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":194,\"values\":{\"codes\":\"[\\\"怀化市\\\"]\",\"dataId\":\"2111\",\"id\":\"194\",\"name\":\"怀化市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":195,\"values\":{\"codes\":\"[\\\"娄底市\\\"]\",\"dataId\":\"2124\",\"id\":\"195\",\"name\":\"娄底市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":196,\"values\":{\"codes\":\"[\\\"湘西土家族苗族自治州\\\"]\",\"dataId\":\"2130\",\"id\":\"196\",\"name\":\"湘西土家族苗族自治州\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":197,\"values\":{\"codes\":\"[\\\"广州市\\\"]\",\"dataId\":\"2140\",\"id\":\"197\",\"name\":\"广州市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":198,\"values\":{\"codes\":\"[\\\"韶关市\\\"]\",\"dataId\":\"2152\",\"id\":\"198\",\"name\":\"韶关市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":199,\"values\":{\"codes\":\"[\\\"深圳市\\\"]\",\"dataId\":\"2163\",\"id\":\"199\",\"name\":\"深圳市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":200,\"values\":{\"codes\":\"[\\\"珠海市\\\"]\",\"dataId\":\"2169\",\"id\":\"200\",\"name\":\"珠海市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":201,\"values\":{\"codes\":\"[\\\"汕头市\\\"]\",\"dataId\":\"2173\",\"id\":\"201\",\"name\":\"汕头市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":202,\"values\":{\"codes\":\"[\\\"佛山市\\\"]\",\"dataId\":\"2181\",\"id\":\"202\",\"name\":\"佛山市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":203,\"values\":{\"codes\":\"[\\\"江门市\\\"]\",\"dataId\":\"2187\",\"id\":\"203\",\"name\":\"江门市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":204,\"values\":{\"codes\":\"[\\\"湛江市\\\"]\",\"dataId\":\"2195\",\"id\":\"204\",\"name\":\"湛江市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":205,\"values\":{\"codes\":\"[\\\"茂名市\\\"]\",\"dataId\":\"2205\",\"id\":\"205\",\"name\":\"茂名市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":206,\"values\":{\"codes\":\"[\\\"肇庆市\\\"]\",\"dataId\":\"2212\",\"id\":\"206\",\"name\":\"肇庆市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":207,\"values\":{\"codes\":\"[\\\"惠州市\\\"]\",\"dataId\":\"2221\",\"id\":\"207\",\"name\":\"惠州市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":208,\"values\":{\"codes\":\"[\\\"梅州市\\\"]\",\"dataId\":\"2227\",\"id\":\"208\",\"name\":\"梅州市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":209,\"values\":{\"codes\":\"[\\\"汕尾市\\\"]\",\"dataId\":\"2236\",\"id\":\"209\",\"name\":\"汕尾市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":210,\"values\":{\"codes\":\"[\\\"河源市\\\"]\",\"dataId\":\"2240\",\"id\":\"210\",\"name\":\"河源市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":211,\"values\":{\"codes\":\"[\\\"阳江市\\\"]\",\"dataId\":\"2247\",\"id\":\"211\",\"name\":\"阳江市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":212,\"values\":{\"codes\":\"[\\\"清远市\\\"]\",\"dataId\":\"2252\",\"id\":\"212\",\"name\":\"清远市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":213,\"values\":{\"codes\":\"[\\\"东莞市\\\"]\",\"dataId\":\"2261\",\"id\":\"213\",\"name\":\"东莞市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":214,\"values\":{\"codes\":\"[\\\"中山市\\\"]\",\"dataId\":\"2262\",\"id\":\"214\",\"name\":\"中山市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":215,\"values\":{\"codes\":\"[\\\"潮州市\\\"]\",\"dataId\":\"2263\",\"id\":\"215\",\"name\":\"潮州市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":216,\"values\":{\"codes\":\"[\\\"揭阳市\\\"]\",\"dataId\":\"2267\",\"id\":\"216\",\"name\":\"揭阳市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":217,\"values\":{\"codes\":\"[\\\"云浮市\\\"]\",\"dataId\":\"2273\",\"id\":\"217\",\"name\":\"云浮市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":218,\"values\":{\"codes\":\"[\\\"南宁市\\\"]\",\"dataId\":\"2280\",\"id\":\"218\",\"name\":\"南宁市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":219,\"values\":{\"codes\":\"[\\\"柳州市\\\"]\",\"dataId\":\"2293\",\"id\":\"219\",\"name\":\"柳州市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":220,\"values\":{\"codes\":\"[\\\"桂林市\\\"]\",\"dataId\":\"2304\",\"id\":\"220\",\"name\":\"桂林市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":221,\"values\":{\"codes\":\"[\\\"梧州市\\\"]\",\"dataId\":\"2322\",\"id\":\"221\",\"name\":\"梧州市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":222,\"values\":{\"codes\":\"[\\\"北海市\\\"]\",\"dataId\":\"2330\",\"id\":\"222\",\"name\":\"北海市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":223,\"values\":{\"codes\":\"[\\\"防城港市\\\"]\",\"dataId\":\"2335\",\"id\":\"223\",\"name\":\"防城港市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":224,\"values\":{\"codes\":\"[\\\"钦州市\\\"]\",\"dataId\":\"2340\",\"id\":\"224\",\"name\":\"钦州市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":225,\"values\":{\"codes\":\"[\\\"贵港市\\\"]\",\"dataId\":\"2345\",\"id\":\"225\",\"name\":\"贵港市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":226,\"values\":{\"codes\":\"[\\\"玉林市\\\"]\",\"dataId\":\"2351\",\"id\":\"226\",\"name\":\"玉林市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":227,\"values\":{\"codes\":\"[\\\"百色市\\\"]\",\"dataId\":\"2358\",\"id\":\"227\",\"name\":\"百色市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":228,\"values\":{\"codes\":\"[\\\"贺州市\\\"]\",\"dataId\":\"2371\",\"id\":\"228\",\"name\":\"贺州市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":229,\"values\":{\"codes\":\"[\\\"河池市\\\"]\",\"dataId\":\"2376\",\"id\":\"229\",\"name\":\"河池市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":230,\"values\":{\"codes\":\"[\\\"来宾市\\\"]\",\"dataId\":\"2388\",\"id\":\"230\",\"name\":\"来宾市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":231,\"values\":{\"codes\":\"[\\\"崇左市\\\"]\",\"dataId\":\"2395\",\"id\":\"231\",\"name\":\"崇左市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":232,\"values\":{\"codes\":\"[\\\"海口市\\\"]\",\"dataId\":\"2404\",\"id\":\"232\",\"name\":\"海口市\",\"provinceId\":\"21\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":233,\"values\":{\"codes\":\"[\\\"三亚市\\\"]\",\"dataId\":\"2409\",\"id\":\"233\",\"name\":\"三亚市\",\"provinceId\":\"21\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":234,\"values\":{\"codes\":\"[\\\"重庆市\\\"]\",\"dataId\":\"2430\",\"id\":\"234\",\"name\":\"重庆市\",\"provinceId\":\"22\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":235,\"values\":{\"codes\":\"[\\\"成都市\\\"]\",\"dataId\":\"2470\",\"id\":\"235\",\"name\":\"成都市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":236,\"values\":{\"codes\":\"[\\\"自贡市\\\"]\",\"dataId\":\"2490\",\"id\":\"236\",\"name\":\"自贡市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":237,\"values\":{\"codes\":\"[\\\"攀枝花市\\\"]\",\"dataId\":\"2497\",\"id\":\"237\",\"name\":\"攀枝花市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":238,\"values\":{\"codes\":\"[\\\"泸州市\\\"]\",\"dataId\":\"2503\",\"id\":\"238\",\"name\":\"泸州市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":239,\"values\":{\"codes\":\"[\\\"德阳市\\\"]\",\"dataId\":\"2511\",\"id\":\"239\",\"name\":\"德阳市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":240,\"values\":{\"codes\":\"[\\\"绵阳市\\\"]\",\"dataId\":\"2518\",\"id\":\"240\",\"name\":\"绵阳市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":241,\"values\":{\"codes\":\"[\\\"广元市\\\"]\",\"dataId\":\"2528\",\"id\":\"241\",\"name\":\"广元市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":242,\"values\":{\"codes\":\"[\\\"遂宁市\\\"]\",\"dataId\":\"2535\",\"id\":\"242\",\"name\":\"遂宁市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":243,\"values\":{\"codes\":\"[\\\"内江市\\\"]\",\"dataId\":\"2541\",\"id\":\"243\",\"name\":\"内江市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":244,\"values\":{\"codes\":\"[\\\"乐山市\\\"]\",\"dataId\":\"2546\",\"id\":\"244\",\"name\":\"乐山市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":245,\"values\":{\"codes\":\"[\\\"南充市\\\"]\",\"dataId\":\"2557\",\"id\":\"245\",\"name\":\"南充市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":246,\"values\":{\"codes\":\"[\\\"眉山市\\\"]\",\"dataId\":\"2567\",\"id\":\"246\",\"name\":\"眉山市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":247,\"values\":{\"codes\":\"[\\\"宜宾市\\\"]\",\"dataId\":\"2574\",\"id\":\"247\",\"name\":\"宜宾市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":248,\"values\":{\"codes\":\"[\\\"广安市\\\"]\",\"dataId\":\"2585\",\"id\":\"248\",\"name\":\"广安市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":249,\"values\":{\"codes\":\"[\\\"达州市\\\"]\",\"dataId\":\"2591\",\"id\":\"249\",\"name\":\"达州市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":250,\"values\":{\"codes\":\"[\\\"雅安市\\\"]\",\"dataId\":\"2599\",\"id\":\"250\",\"name\":\"雅安市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":251,\"values\":{\"codes\":\"[\\\"巴中市\\\"]\",\"dataId\":\"2608\",\"id\":\"251\",\"name\":\"巴中市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":252,\"values\":{\"codes\":\"[\\\"资阳市\\\"]\",\"dataId\":\"2613\",\"id\":\"252\",\"name\":\"资阳市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":253,\"values\":{\"codes\":\"[\\\"阿坝藏族羌族自治州\\\"]\",\"dataId\":\"2618\",\"id\":\"253\",\"name\":\"阿坝藏族羌族自治州\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":254,\"values\":{\"codes\":\"[\\\"甘孜藏族自治州\\\"]\",\"dataId\":\"2632\",\"id\":\"254\",\"name\":\"甘孜藏族自治州\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":255,\"values\":{\"codes\":\"[\\\"凉山彝族自治州\\\"]\",\"dataId\":\"2651\",\"id\":\"255\",\"name\":\"凉山彝族自治州\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":256,\"values\":{\"codes\":\"[\\\"贵阳市\\\"]\",\"dataId\":\"2670\",\"id\":\"256\",\"name\":\"贵阳市\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":257,\"values\":{\"codes\":\"[\\\"六盘水市\\\"]\",\"dataId\":\"2680\",\"id\":\"257\",\"name\":\"六盘水市\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":258,\"values\":{\"codes\":\"[\\\"遵义市\\\"]\",\"dataId\":\"2685\",\"id\":\"258\",\"name\":\"遵义市\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":259,\"values\":{\"codes\":\"[\\\"安顺市\\\"]\",\"dataId\":\"2700\",\"id\":\"259\",\"name\":\"安顺市\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":260,\"values\":{\"codes\":\"[\\\"铜仁地区\\\"]\",\"dataId\":\"2707\",\"id\":\"260\",\"name\":\"铜仁地区\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":261,\"values\":{\"codes\":\"[\\\"黔西南布依族苗族自治州\\\"]\",\"dataId\":\"2718\",\"id\":\"261\",\"name\":\"黔西南布依族苗族自治州\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":262,\"values\":{\"codes\":\"[\\\"毕节地区\\\"]\",\"dataId\":\"2727\",\"id\":\"262\",\"name\":\"毕节地区\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":263,\"values\":{\"codes\":\"[\\\"黔东南苗族侗族自治州\\\"]\",\"dataId\":\"2736\",\"id\":\"263\",\"name\":\"黔东南苗族侗族自治州\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":264,\"values\":{\"codes\":\"[\\\"黔南布依族苗族自治州\\\"]\",\"dataId\":\"2753\",\"id\":\"264\",\"name\":\"黔南布依族苗族自治州\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":265,\"values\":{\"codes\":\"[\\\"昆明市\\\"]\",\"dataId\":\"2767\",\"id\":\"265\",\"name\":\"昆明市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":266,\"values\":{\"codes\":\"[\\\"曲靖市\\\"]\",\"dataId\":\"2782\",\"id\":\"266\",\"name\":\"曲靖市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":267,\"values\":{\"codes\":\"[\\\"玉溪市\\\"]\",\"dataId\":\"2792\",\"id\":\"267\",\"name\":\"玉溪市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":268,\"values\":{\"codes\":\"[\\\"保山市\\\"]\",\"dataId\":\"2802\",\"id\":\"268\",\"name\":\"保山市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":269,\"values\":{\"codes\":\"[\\\"昭通市\\\"]\",\"dataId\":\"2808\",\"id\":\"269\",\"name\":\"昭通市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":270,\"values\":{\"codes\":\"[\\\"丽江市\\\"]\",\"dataId\":\"2820\",\"id\":\"270\",\"name\":\"丽江市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":271,\"values\":{\"codes\":\"[\\\"思茅市\\\"]\",\"dataId\":\"2826\",\"id\":\"271\",\"name\":\"思茅市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":272,\"values\":{\"codes\":\"[\\\"临沧市\\\"]\",\"dataId\":\"2837\",\"id\":\"272\",\"name\":\"临沧市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":273,\"values\":{\"codes\":\"[\\\"楚雄彝族自治州\\\"]\",\"dataId\":\"2846\",\"id\":\"273\",\"name\":\"楚雄彝族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":274,\"values\":{\"codes\":\"[\\\"红河哈尼族彝族自治州\\\"]\",\"dataId\":\"2857\",\"id\":\"274\",\"name\":\"红河哈尼族彝族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":275,\"values\":{\"codes\":\"[\\\"文山壮族苗族自治州\\\"]\",\"dataId\":\"2871\",\"id\":\"275\",\"name\":\"文山壮族苗族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":276,\"values\":{\"codes\":\"[\\\"西双版纳傣族自治州\\\"]\",\"dataId\":\"2880\",\"id\":\"276\",\"name\":\"西双版纳傣族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":277,\"values\":{\"codes\":\"[\\\"大理白族自治州\\\"]\",\"dataId\":\"2884\",\"id\":\"277\",\"name\":\"大理白族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":278,\"values\":{\"codes\":\"[\\\"德宏傣族景颇族自治州\\\"]\",\"dataId\":\"2897\",\"id\":\"278\",\"name\":\"德宏傣族景颇族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":279,\"values\":{\"codes\":\"[\\\"怒江傈僳族自治州\\\"]\",\"dataId\":\"2903\",\"id\":\"279\",\"name\":\"怒江傈僳族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":280,\"values\":{\"codes\":\"[\\\"迪庆藏族自治州\\\"]\",\"dataId\":\"2908\",\"id\":\"280\",\"name\":\"迪庆藏族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":281,\"values\":{\"codes\":\"[\\\"拉萨市\\\"]\",\"dataId\":\"2913\",\"id\":\"281\",\"name\":\"拉萨市\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":282,\"values\":{\"codes\":\"[\\\"昌都地区\\\"]\",\"dataId\":\"2922\",\"id\":\"282\",\"name\":\"昌都地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":283,\"values\":{\"codes\":\"[\\\"山南地区\\\"]\",\"dataId\":\"2934\",\"id\":\"283\",\"name\":\"山南地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":284,\"values\":{\"codes\":\"[\\\"日喀则地区\\\"]\",\"dataId\":\"2947\",\"id\":\"284\",\"name\":\"日喀则地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":285,\"values\":{\"codes\":\"[\\\"那曲地区\\\"]\",\"dataId\":\"2966\",\"id\":\"285\",\"name\":\"那曲地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":286,\"values\":{\"codes\":\"[\\\"阿里地区\\\"]\",\"dataId\":\"2977\",\"id\":\"286\",\"name\":\"阿里地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":287,\"values\":{\"codes\":\"[\\\"林芝地区\\\"]\",\"dataId\":\"2985\",\"id\":\"287\",\"name\":\"林芝地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":288,\"values\":{\"codes\":\"[\\\"西安市\\\"]\",\"dataId\":\"2994\",\"id\":\"288\",\"name\":\"西安市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":289,\"values\":{\"codes\":\"[\\\"铜川市\\\"]\",\"dataId\":\"3006\",\"id\":\"289\",\"name\":\"铜川市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":290,\"values\":{\"codes\":\"[\\\"宝鸡市\\\"]\",\"dataId\":\"3011\",\"id\":\"290\",\"name\":\"宝鸡市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":291,\"values\":{\"codes\":\"[\\\"咸阳市\\\"]\",\"dataId\":\"3024\",\"id\":\"291\",\"name\":\"咸阳市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":292,\"values\":{\"codes\":\"[\\\"渭南市\\\"]\",\"dataId\":\"3039\",\"id\":\"292\",\"name\":\"渭南市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":293,\"values\":{\"codes\":\"[\\\"延安市\\\"]\",\"dataId\":\"3051\",\"id\":\"293\",\"name\":\"延安市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":294,\"values\":{\"codes\":\"[\\\"汉中市\\\"]\",\"dataId\":\"3065\",\"id\":\"294\",\"name\":\"汉中市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":295,\"values\":{\"codes\":\"[\\\"榆林市\\\"]\",\"dataId\":\"3077\",\"id\":\"295\",\"name\":\"榆林市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":296,\"values\":{\"codes\":\"[\\\"安康市\\\"]\",\"dataId\":\"3090\",\"id\":\"296\",\"name\":\"安康市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":297,\"values\":{\"codes\":\"[\\\"商洛市\\\"]\",\"dataId\":\"3101\",\"id\":\"297\",\"name\":\"商洛市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":298,\"values\":{\"codes\":\"[\\\"兰州市\\\"]\",\"dataId\":\"3110\",\"id\":\"298\",\"name\":\"兰州市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":299,\"values\":{\"codes\":\"[\\\"嘉峪关市\\\"]\",\"dataId\":\"3118\",\"id\":\"299\",\"name\":\"嘉峪关市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":300,\"values\":{\"codes\":\"[\\\"金昌市\\\"]\",\"dataId\":\"3119\",\"id\":\"300\",\"name\":\"金昌市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":301,\"values\":{\"codes\":\"[\\\"白银市\\\"]\",\"dataId\":\"3122\",\"id\":\"301\",\"name\":\"白银市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":302,\"values\":{\"codes\":\"[\\\"天水市\\\"]\",\"dataId\":\"3128\",\"id\":\"302\",\"name\":\"天水市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":303,\"values\":{\"codes\":\"[\\\"武威市\\\"]\",\"dataId\":\"3136\",\"id\":\"303\",\"name\":\"武威市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":304,\"values\":{\"codes\":\"[\\\"张掖市\\\"]\",\"dataId\":\"3141\",\"id\":\"304\",\"name\":\"张掖市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":305,\"values\":{\"codes\":\"[\\\"平凉市\\\"]\",\"dataId\":\"3148\",\"id\":\"305\",\"name\":\"平凉市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":306,\"values\":{\"codes\":\"[\\\"酒泉市\\\"]\",\"dataId\":\"3156\",\"id\":\"306\",\"name\":\"酒泉市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":307,\"values\":{\"codes\":\"[\\\"庆阳市\\\"]\",\"dataId\":\"3164\",\"id\":\"307\",\"name\":\"庆阳市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":308,\"values\":{\"codes\":\"[\\\"定西市\\\"]\",\"dataId\":\"3173\",\"id\":\"308\",\"name\":\"定西市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":309,\"values\":{\"codes\":\"[\\\"陇南市\\\"]\",\"dataId\":\"3181\",\"id\":\"309\",\"name\":\"陇南市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":310,\"values\":{\"codes\":\"[\\\"临夏回族自治州\\\"]\",\"dataId\":\"3191\",\"id\":\"310\",\"name\":\"临夏回族自治州\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":311,\"values\":{\"codes\":\"[\\\"甘南藏族自治州\\\"]\",\"dataId\":\"3200\",\"id\":\"311\",\"name\":\"甘南藏族自治州\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":312,\"values\":{\"codes\":\"[\\\"西宁市\\\"]\",\"dataId\":\"3210\",\"id\":\"312\",\"name\":\"西宁市\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":313,\"values\":{\"codes\":\"[\\\"海东地区\\\"]\",\"dataId\":\"3217\",\"id\":\"313\",\"name\":\"海东地区\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":314,\"values\":{\"codes\":\"[\\\"海北藏族自治州\\\"]\",\"dataId\":\"3224\",\"id\":\"314\",\"name\":\"海北藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":315,\"values\":{\"codes\":\"[\\\"黄南藏族自治州\\\"]\",\"dataId\":\"3229\",\"id\":\"315\",\"name\":\"黄南藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":316,\"values\":{\"codes\":\"[\\\"海南藏族自治州\\\"]\",\"dataId\":\"3234\",\"id\":\"316\",\"name\":\"海南藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":317,\"values\":{\"codes\":\"[\\\"果洛藏族自治州\\\"]\",\"dataId\":\"3240\",\"id\":\"317\",\"name\":\"果洛藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":318,\"values\":{\"codes\":\"[\\\"玉树藏族自治州\\\"]\",\"dataId\":\"3247\",\"id\":\"318\",\"name\":\"玉树藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":319,\"values\":{\"codes\":\"[\\\"海西蒙古族藏族自治州\\\"]\",\"dataId\":\"3254\",\"id\":\"319\",\"name\":\"海西蒙古族藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":320,\"values\":{\"codes\":\"[\\\"银川市\\\"]\",\"dataId\":\"3261\",\"id\":\"320\",\"name\":\"银川市\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":321,\"values\":{\"codes\":\"[\\\"石嘴山市\\\"]\",\"dataId\":\"3268\",\"id\":\"321\",\"name\":\"石嘴山市\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":322,\"values\":{\"codes\":\"[\\\"吴忠市\\\"]\",\"dataId\":\"3272\",\"id\":\"322\",\"name\":\"吴忠市\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":323,\"values\":{\"codes\":\"[\\\"固原市\\\"]\",\"dataId\":\"3277\",\"id\":\"323\",\"name\":\"固原市\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":324,\"values\":{\"codes\":\"[\\\"中卫市\\\"]\",\"dataId\":\"3283\",\"id\":\"324\",\"name\":\"中卫市\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":325,\"values\":{\"codes\":\"[\\\"乌鲁木齐市\\\"]\",\"dataId\":\"3288\",\"id\":\"325\",\"name\":\"乌鲁木齐市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":326,\"values\":{\"codes\":\"[\\\"克拉玛依市\\\"]\",\"dataId\":\"3295\",\"id\":\"326\",\"name\":\"克拉玛依市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":327,\"values\":{\"codes\":\"[\\\"吐鲁番地区\\\"]\",\"dataId\":\"3300\",\"id\":\"327\",\"name\":\"吐鲁番地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":328,\"values\":{\"codes\":\"[\\\"哈密地区\\\"]\",\"dataId\":\"3304\",\"id\":\"328\",\"name\":\"哈密地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":329,\"values\":{\"codes\":\"[\\\"昌吉回族自治州\\\"]\",\"dataId\":\"3308\",\"id\":\"329\",\"name\":\"昌吉回族自治州\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":330,\"values\":{\"codes\":\"[\\\"博尔塔拉蒙古自治州\\\"]\",\"dataId\":\"3317\",\"id\":\"330\",\"name\":\"博尔塔拉蒙古自治州\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":331,\"values\":{\"codes\":\"[\\\"巴音郭楞蒙古自治州\\\"]\",\"dataId\":\"3321\",\"id\":\"331\",\"name\":\"巴音郭楞蒙古自治州\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":332,\"values\":{\"codes\":\"[\\\"阿克苏地区\\\"]\",\"dataId\":\"3331\",\"id\":\"332\",\"name\":\"阿克苏地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":333,\"values\":{\"codes\":\"[\\\"克孜勒苏柯尔克孜自治州\\\"]\",\"dataId\":\"3341\",\"id\":\"333\",\"name\":\"克孜勒苏柯尔克孜自治州\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":334,\"values\":{\"codes\":\"[\\\"喀什地区\\\"]\",\"dataId\":\"3346\",\"id\":\"334\",\"name\":\"喀什地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":335,\"values\":{\"codes\":\"[\\\"和田地区\\\"]\",\"dataId\":\"3359\",\"id\":\"335\",\"name\":\"和田地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":336,\"values\":{\"codes\":\"[\\\"伊犁哈萨克自治州\\\"]\",\"dataId\":\"3368\",\"id\":\"336\",\"name\":\"伊犁哈萨克自治州\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":337,\"values\":{\"codes\":\"[\\\"塔城地区\\\"]\",\"dataId\":\"3379\",\"id\":\"337\",\"name\":\"塔城地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":338,\"values\":{\"codes\":\"[\\\"阿勒泰地区\\\"]\",\"dataId\":\"3387\",\"id\":\"338\",\"name\":\"阿勒泰地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":339,\"values\":{\"codes\":\"[\\\"石河子市\\\"]\",\"dataId\":\"3395\",\"id\":\"339\",\"name\":\"石河子市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":340,\"values\":{\"codes\":\"[\\\"阿拉尔市\\\"]\",\"dataId\":\"3396\",\"id\":\"340\",\"name\":\"阿拉尔市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":341,\"values\":{\"codes\":\"[\\\"图木舒克市\\\"]\",\"dataId\":\"3397\",\"id\":\"341\",\"name\":\"图木舒克市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":342,\"values\":{\"codes\":\"[\\\"五家渠市\\\"]\",\"dataId\":\"3398\",\"id\":\"342\",\"name\":\"五家渠市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":343,\"values\":{\"codes\":\"[\\\"香港特别行政区\\\"]\",\"dataId\":\"3400\",\"id\":\"343\",\"name\":\"香港特别行政区\",\"provinceId\":\"32\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":344,\"values\":{\"codes\":\"[\\\"澳门特别行政区\\\"]\",\"dataId\":\"3402\",\"id\":\"344\",\"name\":\"澳门特别行政区\",\"provinceId\":\"33\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":345,\"values\":{\"codes\":\"[\\\"台湾省\\\"]\",\"dataId\":\"3404\",\"id\":\"345\",\"name\":\"台湾省\",\"provinceId\":\"34\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":346,\"values\":{\"codes\":\"[\\\"自治区直辖县级行政区划\\\"]\",\"dataId\":\"3426\",\"id\":\"346\",\"name\":\"自治区直辖县级行政区划\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":347,\"values\":{\"codes\":\"[\\\"省直辖县级行政区划\\\"]\",\"dataId\":\"3427\",\"id\":\"347\",\"name\":\"省直辖县级行政区划\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":348,\"values\":{\"codes\":\"[\\\"省直辖县级行政区划\\\"]\",\"dataId\":\"3428\",\"id\":\"348\",\"name\":\"省直辖县级行政区划\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":349,\"values\":{\"codes\":\"[\\\"普洱市\\\"]\",\"dataId\":\"3429\",\"id\":\"349\",\"name\":\"普洱市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":350,\"values\":{\"codes\":\"[\\\"省直辖县级行政区划\\\"]\",\"dataId\":\"3430\",\"id\":\"350\",\"name\":\"省直辖县级行政区划\",\"provinceId\":\"21\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":351,\"values\":{\"codes\":\"[\\\"湖北省省直辖县级行政区划\\\"]\",\"dataId\":\"3446\",\"id\":\"351\",\"name\":\"湖北省省直辖县级行政区划\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":352,\"values\":{\"codes\":\"[\\\"河南省省直辖县级行政区划\\\"]\",\"dataId\":\"3447\",\"id\":\"352\",\"name\":\"河南省省直辖县级行政区划\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":353,\"values\":{\"codes\":\"[\\\"海南省省直辖县级行政区划\\\"]\",\"dataId\":\"3448\",\"id\":\"353\",\"name\":\"海南省省直辖县级行政区划\",\"provinceId\":\"21\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":354,\"values\":{\"codes\":\"[\\\"新疆维吾尔自治区省直辖县级行政区划\\\"]\",\"dataId\":\"3449\",\"id\":\"354\",\"name\":\"新疆维吾尔自治区省直辖县级行政区划\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":355,\"values\":{\"codes\":\"[\\\"宁夏回族自治区省直辖县级行政区划\\\"]\",\"dataId\":\"3450\",\"id\":\"355\",\"name\":\"宁夏回族自治区省直辖县级行政区划\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":356,\"values\":{\"codes\":\"[\\\"辽宁省省直辖县级行政区划\\\"]\",\"dataId\":\"3451\",\"id\":\"356\",\"name\":\"辽宁省省直辖县级行政区划\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":357,\"values\":{\"codes\":\"[\\\"广东省省直辖县级行政区划\\\"]\",\"dataId\":\"3452\",\"id\":\"357\",\"name\":\"广东省省直辖县级行政区划\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":358,\"values\":{\"codes\":\"[\\\"浙江省省直辖县级行政区划\\\"]\",\"dataId\":\"3453\",\"id\":\"358\",\"name\":\"浙江省省直辖县级行政区划\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":359,\"values\":{\"codes\":\"[\\\"吉林省省直辖县级行政区划\\\"]\",\"dataId\":\"3454\",\"id\":\"359\",\"name\":\"吉林省省直辖县级行政区划\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":360,\"values\":{\"codes\":\"[\\\"安徽省省直辖县级行政区划\\\"]\",\"dataId\":\"3455\",\"id\":\"360\",\"name\":\"安徽省省直辖县级行政区划\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":361,\"values\":{\"codes\":\"[\\\"陕西省省直辖县级行政区划\\\"]\",\"dataId\":\"3456\",\"id\":\"361\",\"name\":\"陕西省省直辖县级行政区划\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":362,\"values\":{\"codes\":\"[\\\"河北省省直辖县级行政区划\\\"]\",\"dataId\":\"3457\",\"id\":\"362\",\"name\":\"河北省省直辖县级行政区划\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":363,\"values\":{\"codes\":\"[\\\"江苏省省直辖县级行政区划\\\"]\",\"dataId\":\"3458\",\"id\":\"363\",\"name\":\"江苏省省直辖县级行政区划\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":364,\"values\":{\"codes\":\"[\\\"黑龙江省省直辖县级行政区划\\\"]\",\"dataId\":\"3459\",\"id\":\"364\",\"name\":\"黑龙江省省直辖县级行政区划\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":365,\"values\":{\"codes\":\"[\\\"江西省省直辖县级行政区划\\\"]\",\"dataId\":\"3460\",\"id\":\"365\",\"name\":\"江西省省直辖县级行政区划\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":366,\"values\":{\"codes\":\"[\\\"贵州省省直辖县级行政区划\\\"]\",\"dataId\":\"3461\",\"id\":\"366\",\"name\":\"贵州省省直辖县级行政区划\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":367,\"values\":{\"codes\":\"[\\\"湖南省省直辖县级行政区划\\\"]\",\"dataId\":\"3462\",\"id\":\"367\",\"name\":\"湖南省省直辖县级行政区划\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]}]},{\"name\":\"edgeRegionCountries\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeRegionCountries` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `dataId` varchar(255) DEFAULT NULL COMMENT '原始数据ID',\\n  `pinyin` json DEFAULT NULL COMMENT '拼音',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='区域-国家/地区'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"dataId\",\"definition\":\"varchar(255) COMMENT '原始数据ID'\"},{\"name\":\"pinyin\",\"definition\":\"json COMMENT '拼音'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[{\"id\":1,\"values\":{\"codes\":\"[\\\"中国\\\"]\",\"dataId\":\"1\",\"id\":\"1\",\"name\":\"中国\",\"pinyin\":\"[\\\"zhong guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":2,\"values\":{\"codes\":\"[\\\"蒙古\\\"]\",\"dataId\":\"2\",\"id\":\"2\",\"name\":\"蒙古\",\"pinyin\":\"[\\\"meng gu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":3,\"values\":{\"codes\":\"[\\\"朝鲜\\\"]\",\"dataId\":\"3\",\"id\":\"3\",\"name\":\"朝鲜\",\"pinyin\":\"[\\\"chao xian\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":4,\"values\":{\"codes\":\"[\\\"韩国\\\"]\",\"dataId\":\"4\",\"id\":\"4\",\"name\":\"韩国\",\"pinyin\":\"[\\\"han guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":5,\"values\":{\"codes\":\"[\\\"日本\\\"]\",\"dataId\":\"5\",\"id\":\"5\",\"name\":\"日本\",\"pinyin\":\"[\\\"ri ben\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":6,\"values\":{\"codes\":\"[\\\"菲律宾\\\"]\",\"dataId\":\"6\",\"id\":\"6\",\"name\":\"菲律宾\",\"pinyin\":\"[\\\"fei lv bin\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":7,\"values\":{\"codes\":\"[\\\"越南\\\"]\",\"dataId\":\"7\",\"id\":\"7\",\"name\":\"越南\",\"pinyin\":\"[\\\"yue nan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":8,\"values\":{\"codes\":\"[\\\"老挝\\\"]\",\"dataId\":\"8\",\"id\":\"8\",\"name\":\"老挝\",\"pinyin\":\"[\\\"lao wo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":9,\"values\":{\"codes\":\"[\\\"柬埔寨\\\"]\",\"dataId\":\"9\",\"id\":\"9\",\"name\":\"柬埔寨\",\"pinyin\":\"[\\\"jian pu zhai\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":10,\"values\":{\"codes\":\"[\\\"缅甸\\\"]\",\"dataId\":\"10\",\"id\":\"10\",\"name\":\"缅甸\",\"pinyin\":\"[\\\"mian dian\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":11,\"values\":{\"codes\":\"[\\\"泰国\\\"]\",\"dataId\":\"11\",\"id\":\"11\",\"name\":\"泰国\",\"pinyin\":\"[\\\"tai guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":12,\"values\":{\"codes\":\"[\\\"马来西亚\\\"]\",\"dataId\":\"12\",\"id\":\"12\",\"name\":\"马来西亚\",\"pinyin\":\"[\\\"ma lai xi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":13,\"values\":{\"codes\":\"[\\\"文莱\\\"]\",\"dataId\":\"13\",\"id\":\"13\",\"name\":\"文莱\",\"pinyin\":\"[\\\"wen lai\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":14,\"values\":{\"codes\":\"[\\\"新加坡\\\"]\",\"dataId\":\"14\",\"id\":\"14\",\"name\":\"新加坡\",\"pinyin\":\"[\\\"xin jia po\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":15,\"values\":{\"codes\":\"[\\\"印度尼西亚\\\"]\",\"dataId\":\"15\",\"id\":\"15\",\"name\":\"印度尼西亚\",\"pinyin\":\"[\\\"yin du ni xi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":16,\"values\":{\"codes\":\"[\\\"东帝汶\\\"]\",\"dataId\":\"16\",\"id\":\"16\",\"name\":\"东帝汶\",\"pinyin\":\"[\\\"dong di wen\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":17,\"values\":{\"codes\":\"[\\\"尼泊尔\\\"]\",\"dataId\":\"17\",\"id\":\"17\",\"name\":\"尼泊尔\",\"pinyin\":\"[\\\"ni po er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":18,\"values\":{\"codes\":\"[\\\"不丹\\\"]\",\"dataId\":\"18\",\"id\":\"18\",\"name\":\"不丹\",\"pinyin\":\"[\\\"bu dan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":19,\"values\":{\"codes\":\"[\\\"孟加拉国\\\", \\\"孟加拉\\\"]\",\"dataId\":\"19\",\"id\":\"19\",\"name\":\"孟加拉国\",\"pinyin\":\"[\\\"meng jia la guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":20,\"values\":{\"codes\":\"[\\\"印度\\\"]\",\"dataId\":\"20\",\"id\":\"20\",\"name\":\"印度\",\"pinyin\":\"[\\\"yin du\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":21,\"values\":{\"codes\":\"[\\\"巴基斯坦\\\"]\",\"dataId\":\"21\",\"id\":\"21\",\"name\":\"巴基斯坦\",\"pinyin\":\"[\\\"ba ji si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":22,\"values\":{\"codes\":\"[\\\"斯里兰卡\\\"]\",\"dataId\":\"22\",\"id\":\"22\",\"name\":\"斯里兰卡\",\"pinyin\":\"[\\\"si li lan ka\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":23,\"values\":{\"codes\":\"[\\\"马尔代夫\\\"]\",\"dataId\":\"23\",\"id\":\"23\",\"name\":\"马尔代夫\",\"pinyin\":\"[\\\"ma er dai fu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":24,\"values\":{\"codes\":\"[\\\"哈萨克斯坦\\\"]\",\"dataId\":\"24\",\"id\":\"24\",\"name\":\"哈萨克斯坦\",\"pinyin\":\"[\\\"ha sa ke si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":25,\"values\":{\"codes\":\"[\\\"吉尔吉斯斯坦\\\"]\",\"dataId\":\"25\",\"id\":\"25\",\"name\":\"吉尔吉斯斯坦\",\"pinyin\":\"[\\\"ji er ji si si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":26,\"values\":{\"codes\":\"[\\\"塔吉克斯坦\\\"]\",\"dataId\":\"26\",\"id\":\"26\",\"name\":\"塔吉克斯坦\",\"pinyin\":\"[\\\"ta ji ke si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":27,\"values\":{\"codes\":\"[\\\"乌兹别克斯坦\\\"]\",\"dataId\":\"27\",\"id\":\"27\",\"name\":\"乌兹别克斯坦\",\"pinyin\":\"[\\\"wu zi bie ke si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":28,\"values\":{\"codes\":\"[\\\"土库曼斯坦\\\"]\",\"dataId\":\"28\",\"id\":\"28\",\"name\":\"土库曼斯坦\",\"pinyin\":\"[\\\"tu ku man si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":29,\"values\":{\"codes\":\"[\\\"阿富汗\\\"]\",\"dataId\":\"29\",\"id\":\"29\",\"name\":\"阿富汗\",\"pinyin\":\"[\\\"a fu han\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":30,\"values\":{\"codes\":\"[\\\"伊拉克\\\"]\",\"dataId\":\"30\",\"id\":\"30\",\"name\":\"伊拉克\",\"pinyin\":\"[\\\"yi la ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":31,\"values\":{\"codes\":\"[\\\"伊朗\\\"]\",\"dataId\":\"31\",\"id\":\"31\",\"name\":\"伊朗\",\"pinyin\":\"[\\\"yi lang\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":32,\"values\":{\"codes\":\"[\\\"叙利亚\\\"]\",\"dataId\":\"32\",\"id\":\"32\",\"name\":\"叙利亚\",\"pinyin\":\"[\\\"xu li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":33,\"values\":{\"codes\":\"[\\\"约旦\\\"]\",\"dataId\":\"33\",\"id\":\"33\",\"name\":\"约旦\",\"pinyin\":\"[\\\"yue dan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":34,\"values\":{\"codes\":\"[\\\"黎巴嫩\\\"]\",\"dataId\":\"34\",\"id\":\"34\",\"name\":\"黎巴嫩\",\"pinyin\":\"[\\\"li ba nen\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":35,\"values\":{\"codes\":\"[\\\"以色列\\\"]\",\"dataId\":\"35\",\"id\":\"35\",\"name\":\"以色列\",\"pinyin\":\"[\\\"yi se lie\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":36,\"values\":{\"codes\":\"[\\\"巴勒斯坦\\\"]\",\"dataId\":\"36\",\"id\":\"36\",\"name\":\"巴勒斯坦\",\"pinyin\":\"[\\\"ba lei si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":37,\"values\":{\"codes\":\"[\\\"沙特阿拉伯\\\"]\",\"dataId\":\"37\",\"id\":\"37\",\"name\":\"沙特阿拉伯\",\"pinyin\":\"[\\\"sha te a la bo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":38,\"values\":{\"codes\":\"[\\\"巴林\\\"]\",\"dataId\":\"38\",\"id\":\"38\",\"name\":\"巴林\",\"pinyin\":\"[\\\"ba lin\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":39,\"values\":{\"codes\":\"[\\\"卡塔尔\\\"]\",\"dataId\":\"39\",\"id\":\"39\",\"name\":\"卡塔尔\",\"pinyin\":\"[\\\"ka ta er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":40,\"values\":{\"codes\":\"[\\\"科威特\\\"]\",\"dataId\":\"40\",\"id\":\"40\",\"name\":\"科威特\",\"pinyin\":\"[\\\"ke wei te\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":41,\"values\":{\"codes\":\"[\\\"阿拉伯联合酋长国\\\", \\\"阿联酋\\\"]\",\"dataId\":\"41\",\"id\":\"41\",\"name\":\"阿拉伯联合酋长国\",\"pinyin\":\"[\\\"a la bo lian he qiu zhang guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":42,\"values\":{\"codes\":\"[\\\"阿曼\\\"]\",\"dataId\":\"42\",\"id\":\"42\",\"name\":\"阿曼\",\"pinyin\":\"[\\\"a man\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":43,\"values\":{\"codes\":\"[\\\"也门\\\"]\",\"dataId\":\"43\",\"id\":\"43\",\"name\":\"也门\",\"pinyin\":\"[\\\"ye men\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":44,\"values\":{\"codes\":\"[\\\"格鲁吉亚\\\"]\",\"dataId\":\"44\",\"id\":\"44\",\"name\":\"格鲁吉亚\",\"pinyin\":\"[\\\"ge lu ji ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":45,\"values\":{\"codes\":\"[\\\"亚美尼亚\\\"]\",\"dataId\":\"45\",\"id\":\"45\",\"name\":\"亚美尼亚\",\"pinyin\":\"[\\\"ya mei ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":46,\"values\":{\"codes\":\"[\\\"阿塞拜疆\\\"]\",\"dataId\":\"46\",\"id\":\"46\",\"name\":\"阿塞拜疆\",\"pinyin\":\"[\\\"a sai bai jiang\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":47,\"values\":{\"codes\":\"[\\\"土耳其\\\"]\",\"dataId\":\"47\",\"id\":\"47\",\"name\":\"土耳其\",\"pinyin\":\"[\\\"tu er qi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":48,\"values\":{\"codes\":\"[\\\"塞浦路斯\\\"]\",\"dataId\":\"48\",\"id\":\"48\",\"name\":\"塞浦路斯\",\"pinyin\":\"[\\\"sai pu lu si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":49,\"values\":{\"codes\":\"[\\\"芬兰\\\"]\",\"dataId\":\"49\",\"id\":\"49\",\"name\":\"芬兰\",\"pinyin\":\"[\\\"fen lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":50,\"values\":{\"codes\":\"[\\\"瑞典\\\"]\",\"dataId\":\"50\",\"id\":\"50\",\"name\":\"瑞典\",\"pinyin\":\"[\\\"rui dian\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":51,\"values\":{\"codes\":\"[\\\"挪威\\\"]\",\"dataId\":\"51\",\"id\":\"51\",\"name\":\"挪威\",\"pinyin\":\"[\\\"nuo wei\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":52,\"values\":{\"codes\":\"[\\\"冰岛\\\"]\",\"dataId\":\"52\",\"id\":\"52\",\"name\":\"冰岛\",\"pinyin\":\"[\\\"bing dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":53,\"values\":{\"codes\":\"[\\\"丹麦\\\"]\",\"dataId\":\"53\",\"id\":\"53\",\"name\":\"丹麦\",\"pinyin\":\"[\\\"dan mai\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":54,\"values\":{\"codes\":\"[\\\"爱沙尼亚\\\"]\",\"dataId\":\"54\",\"id\":\"54\",\"name\":\"爱沙尼亚\",\"pinyin\":\"[\\\"ai sha ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":55,\"values\":{\"codes\":\"[\\\"拉脱维亚\\\"]\",\"dataId\":\"55\",\"id\":\"55\",\"name\":\"拉脱维亚\",\"pinyin\":\"[\\\"la tuo wei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":56,\"values\":{\"codes\":\"[\\\"立陶宛\\\"]\",\"dataId\":\"56\",\"id\":\"56\",\"name\":\"立陶宛\",\"pinyin\":\"[\\\"li tao wan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":57,\"values\":{\"codes\":\"[\\\"白俄罗斯\\\"]\",\"dataId\":\"57\",\"id\":\"57\",\"name\":\"白俄罗斯\",\"pinyin\":\"[\\\"bai e luo si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":58,\"values\":{\"codes\":\"[\\\"俄罗斯\\\"]\",\"dataId\":\"58\",\"id\":\"58\",\"name\":\"俄罗斯\",\"pinyin\":\"[\\\"e luo si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":59,\"values\":{\"codes\":\"[\\\"乌克兰\\\"]\",\"dataId\":\"59\",\"id\":\"59\",\"name\":\"乌克兰\",\"pinyin\":\"[\\\"wu ke lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":60,\"values\":{\"codes\":\"[\\\"摩尔多瓦\\\"]\",\"dataId\":\"60\",\"id\":\"60\",\"name\":\"摩尔多瓦\",\"pinyin\":\"[\\\"mo er duo wa\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":61,\"values\":{\"codes\":\"[\\\"波兰\\\"]\",\"dataId\":\"61\",\"id\":\"61\",\"name\":\"波兰\",\"pinyin\":\"[\\\"bo lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":62,\"values\":{\"codes\":\"[\\\"捷克\\\"]\",\"dataId\":\"62\",\"id\":\"62\",\"name\":\"捷克\",\"pinyin\":\"[\\\"jie ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":63,\"values\":{\"codes\":\"[\\\"斯洛伐克\\\"]\",\"dataId\":\"63\",\"id\":\"63\",\"name\":\"斯洛伐克\",\"pinyin\":\"[\\\"si luo fa ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":64,\"values\":{\"codes\":\"[\\\"匈牙利\\\"]\",\"dataId\":\"64\",\"id\":\"64\",\"name\":\"匈牙利\",\"pinyin\":\"[\\\"xiong ya li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":65,\"values\":{\"codes\":\"[\\\"德国\\\"]\",\"dataId\":\"65\",\"id\":\"65\",\"name\":\"德国\",\"pinyin\":\"[\\\"de guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":66,\"values\":{\"codes\":\"[\\\"奥地利\\\"]\",\"dataId\":\"66\",\"id\":\"66\",\"name\":\"奥地利\",\"pinyin\":\"[\\\"ao de li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":67,\"values\":{\"codes\":\"[\\\"瑞士\\\"]\",\"dataId\":\"67\",\"id\":\"67\",\"name\":\"瑞士\",\"pinyin\":\"[\\\"rui shi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":68,\"values\":{\"codes\":\"[\\\"列支敦士登\\\"]\",\"dataId\":\"68\",\"id\":\"68\",\"name\":\"列支敦士登\",\"pinyin\":\"[\\\"lie zhi dun shi deng\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":69,\"values\":{\"codes\":\"[\\\"英国\\\"]\",\"dataId\":\"69\",\"id\":\"69\",\"name\":\"英国\",\"pinyin\":\"[\\\"ying guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":70,\"values\":{\"codes\":\"[\\\"爱尔兰\\\"]\",\"dataId\":\"70\",\"id\":\"70\",\"name\":\"爱尔兰\",\"pinyin\":\"[\\\"ai er lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":71,\"values\":{\"codes\":\"[\\\"荷兰\\\"]\",\"dataId\":\"71\",\"id\":\"71\",\"name\":\"荷兰\",\"pinyin\":\"[\\\"he lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":72,\"values\":{\"codes\":\"[\\\"比利时\\\"]\",\"dataId\":\"72\",\"id\":\"72\",\"name\":\"比利时\",\"pinyin\":\"[\\\"bi li shi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":73,\"values\":{\"codes\":\"[\\\"卢森堡\\\"]\",\"dataId\":\"73\",\"id\":\"73\",\"name\":\"卢森堡\",\"pinyin\":\"[\\\"lu sen bao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":74,\"values\":{\"codes\":\"[\\\"法国\\\"]\",\"dataId\":\"74\",\"id\":\"74\",\"name\":\"法国\",\"pinyin\":\"[\\\"fa guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":75,\"values\":{\"codes\":\"[\\\"摩纳哥\\\"]\",\"dataId\":\"75\",\"id\":\"75\",\"name\":\"摩纳哥\",\"pinyin\":\"[\\\"mo na ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":76,\"values\":{\"codes\":\"[\\\"罗马尼亚\\\"]\",\"dataId\":\"76\",\"id\":\"76\",\"name\":\"罗马尼亚\",\"pinyin\":\"[\\\"luo ma ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":77,\"values\":{\"codes\":\"[\\\"保加利亚\\\"]\",\"dataId\":\"77\",\"id\":\"77\",\"name\":\"保加利亚\",\"pinyin\":\"[\\\"bao jia li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":78,\"values\":{\"codes\":\"[\\\"塞尔维亚\\\"]\",\"dataId\":\"78\",\"id\":\"78\",\"name\":\"塞尔维亚\",\"pinyin\":\"[\\\"sai er wei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":79,\"values\":{\"codes\":\"[\\\"马其顿\\\"]\",\"dataId\":\"79\",\"id\":\"79\",\"name\":\"马其顿\",\"pinyin\":\"[\\\"ma qi dun\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":80,\"values\":{\"codes\":\"[\\\"阿尔巴尼亚\\\"]\",\"dataId\":\"80\",\"id\":\"80\",\"name\":\"阿尔巴尼亚\",\"pinyin\":\"[\\\"a er ba ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":81,\"values\":{\"codes\":\"[\\\"希腊\\\"]\",\"dataId\":\"81\",\"id\":\"81\",\"name\":\"希腊\",\"pinyin\":\"[\\\"xi la\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":82,\"values\":{\"codes\":\"[\\\"斯洛文尼亚\\\"]\",\"dataId\":\"82\",\"id\":\"82\",\"name\":\"斯洛文尼亚\",\"pinyin\":\"[\\\"si luo wen ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":83,\"values\":{\"codes\":\"[\\\"克罗地亚\\\"]\",\"dataId\":\"83\",\"id\":\"83\",\"name\":\"克罗地亚\",\"pinyin\":\"[\\\"ke luo de ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":84,\"values\":{\"codes\":\"[\\\"波斯尼亚和墨塞哥维那\\\"]\",\"dataId\":\"84\",\"id\":\"84\",\"name\":\"波斯尼亚和墨塞哥维那\",\"pinyin\":\"[\\\"bo si ni ya he mo sai ge wei na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":85,\"values\":{\"codes\":\"[\\\"意大利\\\"]\",\"dataId\":\"85\",\"id\":\"85\",\"name\":\"意大利\",\"pinyin\":\"[\\\"yi da li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":86,\"values\":{\"codes\":\"[\\\"梵蒂冈\\\"]\",\"dataId\":\"86\",\"id\":\"86\",\"name\":\"梵蒂冈\",\"pinyin\":\"[\\\"fan di gang\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":87,\"values\":{\"codes\":\"[\\\"圣马力诺\\\"]\",\"dataId\":\"87\",\"id\":\"87\",\"name\":\"圣马力诺\",\"pinyin\":\"[\\\"sheng ma li nuo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":88,\"values\":{\"codes\":\"[\\\"马耳他\\\"]\",\"dataId\":\"88\",\"id\":\"88\",\"name\":\"马耳他\",\"pinyin\":\"[\\\"ma er ta\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":89,\"values\":{\"codes\":\"[\\\"西班牙\\\"]\",\"dataId\":\"89\",\"id\":\"89\",\"name\":\"西班牙\",\"pinyin\":\"[\\\"xi ban ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":90,\"values\":{\"codes\":\"[\\\"葡萄牙\\\"]\",\"dataId\":\"90\",\"id\":\"90\",\"name\":\"葡萄牙\",\"pinyin\":\"[\\\"pu tao ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":91,\"values\":{\"codes\":\"[\\\"安道尔共和国\\\", \\\"安道尔\\\"]\",\"dataId\":\"91\",\"id\":\"91\",\"name\":\"安道尔共和国\",\"pinyin\":\"[\\\"an dao er gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":92,\"values\":{\"codes\":\"[\\\"埃及\\\"]\",\"dataId\":\"92\",\"id\":\"92\",\"name\":\"埃及\",\"pinyin\":\"[\\\"ai ji\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":93,\"values\":{\"codes\":\"[\\\"利比亚\\\"]\",\"dataId\":\"93\",\"id\":\"93\",\"name\":\"利比亚\",\"pinyin\":\"[\\\"li bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":94,\"values\":{\"codes\":\"[\\\"苏丹\\\"]\",\"dataId\":\"94\",\"id\":\"94\",\"name\":\"苏丹\",\"pinyin\":\"[\\\"su dan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":95,\"values\":{\"codes\":\"[\\\"突尼斯\\\"]\",\"dataId\":\"95\",\"id\":\"95\",\"name\":\"突尼斯\",\"pinyin\":\"[\\\"tu ni si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":96,\"values\":{\"codes\":\"[\\\"阿尔及利亚\\\"]\",\"dataId\":\"96\",\"id\":\"96\",\"name\":\"阿尔及利亚\",\"pinyin\":\"[\\\"a er ji li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":97,\"values\":{\"codes\":\"[\\\"摩洛哥\\\"]\",\"dataId\":\"97\",\"id\":\"97\",\"name\":\"摩洛哥\",\"pinyin\":\"[\\\"mo luo ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":98,\"values\":{\"codes\":\"[\\\"亚速尔群岛\\\"]\",\"dataId\":\"98\",\"id\":\"98\",\"name\":\"亚速尔群岛\",\"pinyin\":\"[\\\"ya su er qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":99,\"values\":{\"codes\":\"[\\\"马德拉群岛\\\"]\",\"dataId\":\"99\",\"id\":\"99\",\"name\":\"马德拉群岛\",\"pinyin\":\"[\\\"ma de la qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":100,\"values\":{\"codes\":\"[\\\"埃塞俄比亚\\\"]\",\"dataId\":\"100\",\"id\":\"100\",\"name\":\"埃塞俄比亚\",\"pinyin\":\"[\\\"ai sai e bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":101,\"values\":{\"codes\":\"[\\\"厄立特里亚\\\"]\",\"dataId\":\"101\",\"id\":\"101\",\"name\":\"厄立特里亚\",\"pinyin\":\"[\\\"e li te li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":102,\"values\":{\"codes\":\"[\\\"索马里\\\"]\",\"dataId\":\"102\",\"id\":\"102\",\"name\":\"索马里\",\"pinyin\":\"[\\\"suo ma li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":103,\"values\":{\"codes\":\"[\\\"吉布提\\\"]\",\"dataId\":\"103\",\"id\":\"103\",\"name\":\"吉布提\",\"pinyin\":\"[\\\"ji bu ti\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":104,\"values\":{\"codes\":\"[\\\"肯尼亚\\\"]\",\"dataId\":\"104\",\"id\":\"104\",\"name\":\"肯尼亚\",\"pinyin\":\"[\\\"ken ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":105,\"values\":{\"codes\":\"[\\\"坦桑尼亚\\\"]\",\"dataId\":\"105\",\"id\":\"105\",\"name\":\"坦桑尼亚\",\"pinyin\":\"[\\\"tan sang ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":106,\"values\":{\"codes\":\"[\\\"乌干达\\\"]\",\"dataId\":\"106\",\"id\":\"106\",\"name\":\"乌干达\",\"pinyin\":\"[\\\"wu gan da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":107,\"values\":{\"codes\":\"[\\\"卢旺达\\\"]\",\"dataId\":\"107\",\"id\":\"107\",\"name\":\"卢旺达\",\"pinyin\":\"[\\\"lu wang da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":108,\"values\":{\"codes\":\"[\\\"布隆迪\\\"]\",\"dataId\":\"108\",\"id\":\"108\",\"name\":\"布隆迪\",\"pinyin\":\"[\\\"bu long di\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":109,\"values\":{\"codes\":\"[\\\"塞舌尔\\\"]\",\"dataId\":\"109\",\"id\":\"109\",\"name\":\"塞舌尔\",\"pinyin\":\"[\\\"sai she er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":110,\"values\":{\"codes\":\"[\\\"圣多美及普林西比\\\", \\\"圣多美和普林西比\\\"]\",\"dataId\":\"110\",\"id\":\"110\",\"name\":\"圣多美及普林西比\",\"pinyin\":\"[\\\"sheng duo mei ji pu lin xi bi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":111,\"values\":{\"codes\":\"[\\\"塞内加尔\\\"]\",\"dataId\":\"111\",\"id\":\"111\",\"name\":\"塞内加尔\",\"pinyin\":\"[\\\"sai nei jia er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":112,\"values\":{\"codes\":\"[\\\"冈比亚\\\"]\",\"dataId\":\"112\",\"id\":\"112\",\"name\":\"冈比亚\",\"pinyin\":\"[\\\"gang bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":113,\"values\":{\"codes\":\"[\\\"马里\\\"]\",\"dataId\":\"113\",\"id\":\"113\",\"name\":\"马里\",\"pinyin\":\"[\\\"ma li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":114,\"values\":{\"codes\":\"[\\\"布基纳法索\\\"]\",\"dataId\":\"114\",\"id\":\"114\",\"name\":\"布基纳法索\",\"pinyin\":\"[\\\"bu ji na fa suo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":115,\"values\":{\"codes\":\"[\\\"几内亚\\\"]\",\"dataId\":\"115\",\"id\":\"115\",\"name\":\"几内亚\",\"pinyin\":\"[\\\"ji nei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":116,\"values\":{\"codes\":\"[\\\"几内亚比绍\\\"]\",\"dataId\":\"116\",\"id\":\"116\",\"name\":\"几内亚比绍\",\"pinyin\":\"[\\\"ji nei ya bi shao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":117,\"values\":{\"codes\":\"[\\\"佛得角\\\"]\",\"dataId\":\"117\",\"id\":\"117\",\"name\":\"佛得角\",\"pinyin\":\"[\\\"fu de jiao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":118,\"values\":{\"codes\":\"[\\\"塞拉利昂\\\"]\",\"dataId\":\"118\",\"id\":\"118\",\"name\":\"塞拉利昂\",\"pinyin\":\"[\\\"sai la li ang\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":119,\"values\":{\"codes\":\"[\\\"利比里亚\\\"]\",\"dataId\":\"119\",\"id\":\"119\",\"name\":\"利比里亚\",\"pinyin\":\"[\\\"li bi li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":120,\"values\":{\"codes\":\"[\\\"科特迪瓦\\\"]\",\"dataId\":\"120\",\"id\":\"120\",\"name\":\"科特迪瓦\",\"pinyin\":\"[\\\"ke te di wa\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":121,\"values\":{\"codes\":\"[\\\"加纳\\\"]\",\"dataId\":\"121\",\"id\":\"121\",\"name\":\"加纳\",\"pinyin\":\"[\\\"jia na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":122,\"values\":{\"codes\":\"[\\\"多哥\\\"]\",\"dataId\":\"122\",\"id\":\"122\",\"name\":\"多哥\",\"pinyin\":\"[\\\"duo ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":123,\"values\":{\"codes\":\"[\\\"贝宁\\\"]\",\"dataId\":\"123\",\"id\":\"123\",\"name\":\"贝宁\",\"pinyin\":\"[\\\"bei ning\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":124,\"values\":{\"codes\":\"[\\\"尼日尔\\\"]\",\"dataId\":\"124\",\"id\":\"124\",\"name\":\"尼日尔\",\"pinyin\":\"[\\\"ni ri er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":125,\"values\":{\"codes\":\"[\\\"加那利群岛\\\"]\",\"dataId\":\"125\",\"id\":\"125\",\"name\":\"加那利群岛\",\"pinyin\":\"[\\\"jia na li qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":126,\"values\":{\"codes\":\"[\\\"赞比亚\\\"]\",\"dataId\":\"126\",\"id\":\"126\",\"name\":\"赞比亚\",\"pinyin\":\"[\\\"zan bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":127,\"values\":{\"codes\":\"[\\\"安哥拉\\\"]\",\"dataId\":\"127\",\"id\":\"127\",\"name\":\"安哥拉\",\"pinyin\":\"[\\\"an ge la\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":128,\"values\":{\"codes\":\"[\\\"津巴布韦\\\"]\",\"dataId\":\"128\",\"id\":\"128\",\"name\":\"津巴布韦\",\"pinyin\":\"[\\\"jin ba bu wei\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":129,\"values\":{\"codes\":\"[\\\"马拉维\\\"]\",\"dataId\":\"129\",\"id\":\"129\",\"name\":\"马拉维\",\"pinyin\":\"[\\\"ma la wei\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":130,\"values\":{\"codes\":\"[\\\"莫桑比克\\\"]\",\"dataId\":\"130\",\"id\":\"130\",\"name\":\"莫桑比克\",\"pinyin\":\"[\\\"mo sang bi ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":131,\"values\":{\"codes\":\"[\\\"博茨瓦纳\\\"]\",\"dataId\":\"131\",\"id\":\"131\",\"name\":\"博茨瓦纳\",\"pinyin\":\"[\\\"bo ci wa na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":132,\"values\":{\"codes\":\"[\\\"纳米比亚\\\"]\",\"dataId\":\"132\",\"id\":\"132\",\"name\":\"纳米比亚\",\"pinyin\":\"[\\\"na mi bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":133,\"values\":{\"codes\":\"[\\\"南非\\\"]\",\"dataId\":\"133\",\"id\":\"133\",\"name\":\"南非\",\"pinyin\":\"[\\\"nan fei\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":134,\"values\":{\"codes\":\"[\\\"斯威士兰\\\"]\",\"dataId\":\"134\",\"id\":\"134\",\"name\":\"斯威士兰\",\"pinyin\":\"[\\\"si wei shi lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":135,\"values\":{\"codes\":\"[\\\"莱索托\\\"]\",\"dataId\":\"135\",\"id\":\"135\",\"name\":\"莱索托\",\"pinyin\":\"[\\\"lai suo tuo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":136,\"values\":{\"codes\":\"[\\\"马达加斯加\\\"]\",\"dataId\":\"136\",\"id\":\"136\",\"name\":\"马达加斯加\",\"pinyin\":\"[\\\"ma da jia si jia\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":137,\"values\":{\"codes\":\"[\\\"科摩罗\\\"]\",\"dataId\":\"137\",\"id\":\"137\",\"name\":\"科摩罗\",\"pinyin\":\"[\\\"ke mo luo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":138,\"values\":{\"codes\":\"[\\\"毛里求斯\\\"]\",\"dataId\":\"138\",\"id\":\"138\",\"name\":\"毛里求斯\",\"pinyin\":\"[\\\"mao li qiu si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":139,\"values\":{\"codes\":\"[\\\"留尼旺\\\", \\\"留尼汪岛\\\", \\\"留尼汪\\\"]\",\"dataId\":\"139\",\"id\":\"139\",\"name\":\"留尼旺\",\"pinyin\":\"[\\\"liu ni wang\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":140,\"values\":{\"codes\":\"[\\\"圣赫勒拿\\\"]\",\"dataId\":\"140\",\"id\":\"140\",\"name\":\"圣赫勒拿\",\"pinyin\":\"[\\\"sheng he lei na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":141,\"values\":{\"codes\":\"[\\\"澳大利亚\\\"]\",\"dataId\":\"141\",\"id\":\"141\",\"name\":\"澳大利亚\",\"pinyin\":\"[\\\"ao da li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":142,\"values\":{\"codes\":\"[\\\"新西兰\\\"]\",\"dataId\":\"142\",\"id\":\"142\",\"name\":\"新西兰\",\"pinyin\":\"[\\\"xin xi lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":143,\"values\":{\"codes\":\"[\\\"巴布亚新几内亚\\\"]\",\"dataId\":\"143\",\"id\":\"143\",\"name\":\"巴布亚新几内亚\",\"pinyin\":\"[\\\"ba bu ya xin ji nei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":144,\"values\":{\"codes\":\"[\\\"所罗门群岛\\\"]\",\"dataId\":\"144\",\"id\":\"144\",\"name\":\"所罗门群岛\",\"pinyin\":\"[\\\"suo luo men qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":145,\"values\":{\"codes\":\"[\\\"瓦努阿图共和国\\\", \\\"瓦努阿图\\\"]\",\"dataId\":\"145\",\"id\":\"145\",\"name\":\"瓦努阿图共和国\",\"pinyin\":\"[\\\"wa nu a tu gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":146,\"values\":{\"codes\":\"[\\\"密克罗尼西亚\\\"]\",\"dataId\":\"146\",\"id\":\"146\",\"name\":\"密克罗尼西亚\",\"pinyin\":\"[\\\"mi ke luo ni xi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":147,\"values\":{\"codes\":\"[\\\"马绍尔群岛\\\"]\",\"dataId\":\"147\",\"id\":\"147\",\"name\":\"马绍尔群岛\",\"pinyin\":\"[\\\"ma shao er qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":148,\"values\":{\"codes\":\"[\\\"帕劳\\\"]\",\"dataId\":\"148\",\"id\":\"148\",\"name\":\"帕劳\",\"pinyin\":\"[\\\"pa lao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":149,\"values\":{\"codes\":\"[\\\"瑙鲁\\\"]\",\"dataId\":\"149\",\"id\":\"149\",\"name\":\"瑙鲁\",\"pinyin\":\"[\\\"nao lu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":150,\"values\":{\"codes\":\"[\\\"基里巴斯\\\"]\",\"dataId\":\"150\",\"id\":\"150\",\"name\":\"基里巴斯\",\"pinyin\":\"[\\\"ji li ba si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":151,\"values\":{\"codes\":\"[\\\"图瓦卢\\\"]\",\"dataId\":\"151\",\"id\":\"151\",\"name\":\"图瓦卢\",\"pinyin\":\"[\\\"tu wa lu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":152,\"values\":{\"codes\":\"[\\\"萨摩亚\\\"]\",\"dataId\":\"152\",\"id\":\"152\",\"name\":\"萨摩亚\",\"pinyin\":\"[\\\"sa mo ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":153,\"values\":{\"codes\":\"[\\\"斐济\\\"]\",\"dataId\":\"153\",\"id\":\"153\",\"name\":\"斐济\",\"pinyin\":\"[\\\"fei ji\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":154,\"values\":{\"codes\":\"[\\\"汤加\\\"]\",\"dataId\":\"154\",\"id\":\"154\",\"name\":\"汤加\",\"pinyin\":\"[\\\"tang jia\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":155,\"values\":{\"codes\":\"[\\\"库克群岛\\\"]\",\"dataId\":\"155\",\"id\":\"155\",\"name\":\"库克群岛\",\"pinyin\":\"[\\\"ku ke qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":156,\"values\":{\"codes\":\"[\\\"关岛\\\"]\",\"dataId\":\"156\",\"id\":\"156\",\"name\":\"关岛\",\"pinyin\":\"[\\\"guan dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":157,\"values\":{\"codes\":\"[\\\"新喀里多尼亚\\\", \\\"新喀里多尼亚群岛\\\"]\",\"dataId\":\"157\",\"id\":\"157\",\"name\":\"新喀里多尼亚\",\"pinyin\":\"[\\\"xin ka li duo ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":158,\"values\":{\"codes\":\"[\\\"法属波利尼西亚\\\"]\",\"dataId\":\"158\",\"id\":\"158\",\"name\":\"法属波利尼西亚\",\"pinyin\":\"[\\\"fa shu bo li ni xi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":159,\"values\":{\"codes\":\"[\\\"皮特凯恩岛\\\"]\",\"dataId\":\"159\",\"id\":\"159\",\"name\":\"皮特凯恩岛\",\"pinyin\":\"[\\\"pi te kai en dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":160,\"values\":{\"codes\":\"[\\\"瓦利斯与富图纳\\\", \\\"瓦利斯和富图纳群岛\\\"]\",\"dataId\":\"160\",\"id\":\"160\",\"name\":\"瓦利斯与富图纳\",\"pinyin\":\"[\\\"wa li si yu fu tu na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":161,\"values\":{\"codes\":\"[\\\"纽埃\\\"]\",\"dataId\":\"161\",\"id\":\"161\",\"name\":\"纽埃\",\"pinyin\":\"[\\\"niu ai\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":162,\"values\":{\"codes\":\"[\\\"托克劳\\\", \\\"托克劳群岛\\\"]\",\"dataId\":\"162\",\"id\":\"162\",\"name\":\"托克劳\",\"pinyin\":\"[\\\"tuo ke lao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":163,\"values\":{\"codes\":\"[\\\"美属萨摩亚\\\"]\",\"dataId\":\"163\",\"id\":\"163\",\"name\":\"美属萨摩亚\",\"pinyin\":\"[\\\"mei shu sa mo ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":164,\"values\":{\"codes\":\"[\\\"北马里亚纳\\\", \\\"北马里亚纳群岛\\\"]\",\"dataId\":\"164\",\"id\":\"164\",\"name\":\"北马里亚纳\",\"pinyin\":\"[\\\"bei ma li ya na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":165,\"values\":{\"codes\":\"[\\\"加拿大\\\"]\",\"dataId\":\"165\",\"id\":\"165\",\"name\":\"加拿大\",\"pinyin\":\"[\\\"jia na da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":166,\"values\":{\"codes\":\"[\\\"美国\\\"]\",\"dataId\":\"166\",\"id\":\"166\",\"name\":\"美国\",\"pinyin\":\"[\\\"mei guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":167,\"values\":{\"codes\":\"[\\\"墨西哥\\\"]\",\"dataId\":\"167\",\"id\":\"167\",\"name\":\"墨西哥\",\"pinyin\":\"[\\\"mo xi ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":168,\"values\":{\"codes\":\"[\\\"格陵兰\\\", \\\"格陵兰岛\\\"]\",\"dataId\":\"168\",\"id\":\"168\",\"name\":\"格陵兰\",\"pinyin\":\"[\\\"ge ling lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":169,\"values\":{\"codes\":\"[\\\"危地马拉\\\"]\",\"dataId\":\"169\",\"id\":\"169\",\"name\":\"危地马拉\",\"pinyin\":\"[\\\"wei de ma la\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":170,\"values\":{\"codes\":\"[\\\"伯利兹\\\"]\",\"dataId\":\"170\",\"id\":\"170\",\"name\":\"伯利兹\",\"pinyin\":\"[\\\"bo li zi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":171,\"values\":{\"codes\":\"[\\\"萨尔瓦多\\\"]\",\"dataId\":\"171\",\"id\":\"171\",\"name\":\"萨尔瓦多\",\"pinyin\":\"[\\\"sa er wa duo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":172,\"values\":{\"codes\":\"[\\\"洪都拉斯\\\"]\",\"dataId\":\"172\",\"id\":\"172\",\"name\":\"洪都拉斯\",\"pinyin\":\"[\\\"hong dou la si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":173,\"values\":{\"codes\":\"[\\\"尼加拉瓜\\\"]\",\"dataId\":\"173\",\"id\":\"173\",\"name\":\"尼加拉瓜\",\"pinyin\":\"[\\\"ni jia la gua\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":174,\"values\":{\"codes\":\"[\\\"哥斯达黎加\\\"]\",\"dataId\":\"174\",\"id\":\"174\",\"name\":\"哥斯达黎加\",\"pinyin\":\"[\\\"ge si da li jia\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":175,\"values\":{\"codes\":\"[\\\"巴拿马\\\"]\",\"dataId\":\"175\",\"id\":\"175\",\"name\":\"巴拿马\",\"pinyin\":\"[\\\"ba na ma\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":176,\"values\":{\"codes\":\"[\\\"巴哈马\\\"]\",\"dataId\":\"176\",\"id\":\"176\",\"name\":\"巴哈马\",\"pinyin\":\"[\\\"ba ha ma\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":177,\"values\":{\"codes\":\"[\\\"古巴\\\"]\",\"dataId\":\"177\",\"id\":\"177\",\"name\":\"古巴\",\"pinyin\":\"[\\\"gu ba\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":178,\"values\":{\"codes\":\"[\\\"牙买加\\\"]\",\"dataId\":\"178\",\"id\":\"178\",\"name\":\"牙买加\",\"pinyin\":\"[\\\"ya mai jia\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":179,\"values\":{\"codes\":\"[\\\"海地\\\"]\",\"dataId\":\"179\",\"id\":\"179\",\"name\":\"海地\",\"pinyin\":\"[\\\"hai de\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":180,\"values\":{\"codes\":\"[\\\"多米尼加共和国\\\", \\\"多米尼加\\\"]\",\"dataId\":\"180\",\"id\":\"180\",\"name\":\"多米尼加共和国\",\"pinyin\":\"[\\\"duo mi ni jia gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":181,\"values\":{\"codes\":\"[\\\"安提瓜和巴布达\\\"]\",\"dataId\":\"181\",\"id\":\"181\",\"name\":\"安提瓜和巴布达\",\"pinyin\":\"[\\\"an ti gua he ba bu da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":182,\"values\":{\"codes\":\"[\\\"圣基茨和尼维斯\\\"]\",\"dataId\":\"182\",\"id\":\"182\",\"name\":\"圣基茨和尼维斯\",\"pinyin\":\"[\\\"sheng ji ci he ni wei si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":183,\"values\":{\"codes\":\"[\\\"多米尼克\\\"]\",\"dataId\":\"183\",\"id\":\"183\",\"name\":\"多米尼克\",\"pinyin\":\"[\\\"duo mi ni ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":184,\"values\":{\"codes\":\"[\\\"圣卢西亚\\\"]\",\"dataId\":\"184\",\"id\":\"184\",\"name\":\"圣卢西亚\",\"pinyin\":\"[\\\"sheng lu xi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":185,\"values\":{\"codes\":\"[\\\"圣文森特和格林纳丁斯\\\"]\",\"dataId\":\"185\",\"id\":\"185\",\"name\":\"圣文森特和格林纳丁斯\",\"pinyin\":\"[\\\"sheng wen sen te he ge lin na ding si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":186,\"values\":{\"codes\":\"[\\\"格林纳达\\\"]\",\"dataId\":\"186\",\"id\":\"186\",\"name\":\"格林纳达\",\"pinyin\":\"[\\\"ge lin na da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":187,\"values\":{\"codes\":\"[\\\"巴巴多斯\\\"]\",\"dataId\":\"187\",\"id\":\"187\",\"name\":\"巴巴多斯\",\"pinyin\":\"[\\\"ba ba duo si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":188,\"values\":{\"codes\":\"[\\\"特立尼达和多巴哥\\\"]\",\"dataId\":\"188\",\"id\":\"188\",\"name\":\"特立尼达和多巴哥\",\"pinyin\":\"[\\\"te li ni da he duo ba ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":189,\"values\":{\"codes\":\"[\\\"波多黎各\\\"]\",\"dataId\":\"189\",\"id\":\"189\",\"name\":\"波多黎各\",\"pinyin\":\"[\\\"bo duo li ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":190,\"values\":{\"codes\":\"[\\\"英属维尔京群岛\\\"]\",\"dataId\":\"190\",\"id\":\"190\",\"name\":\"英属维尔京群岛\",\"pinyin\":\"[\\\"ying shu wei er jing qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":191,\"values\":{\"codes\":\"[\\\"美属维尔京群岛\\\"]\",\"dataId\":\"191\",\"id\":\"191\",\"name\":\"美属维尔京群岛\",\"pinyin\":\"[\\\"mei shu wei er jing qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":192,\"values\":{\"codes\":\"[\\\"安圭拉\\\"]\",\"dataId\":\"192\",\"id\":\"192\",\"name\":\"安圭拉\",\"pinyin\":\"[\\\"an gui la\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":193,\"values\":{\"codes\":\"[\\\"蒙特塞拉特岛\\\", \\\"蒙塞拉特岛\\\"]\",\"dataId\":\"193\",\"id\":\"193\",\"name\":\"蒙特塞拉特岛\",\"pinyin\":\"[\\\"meng te sai la te dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":194,\"values\":{\"codes\":\"[\\\"瓜德罗普\\\"]\",\"dataId\":\"194\",\"id\":\"194\",\"name\":\"瓜德罗普\",\"pinyin\":\"[\\\"gua de luo pu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":195,\"values\":{\"codes\":\"[\\\"马提尼克\\\"]\",\"dataId\":\"195\",\"id\":\"195\",\"name\":\"马提尼克\",\"pinyin\":\"[\\\"ma ti ni ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":196,\"values\":{\"codes\":\"[\\\"荷属安的列斯\\\", \\\"安的列斯\\\"]\",\"dataId\":\"196\",\"id\":\"196\",\"name\":\"荷属安的列斯\",\"pinyin\":\"[\\\"he shu an de lie si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":197,\"values\":{\"codes\":\"[\\\"阿鲁巴\\\"]\",\"dataId\":\"197\",\"id\":\"197\",\"name\":\"阿鲁巴\",\"pinyin\":\"[\\\"a lu ba\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":198,\"values\":{\"codes\":\"[\\\"特克斯和凯科斯群岛\\\"]\",\"dataId\":\"198\",\"id\":\"198\",\"name\":\"特克斯和凯科斯群岛\",\"pinyin\":\"[\\\"te ke si he kai ke si qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":199,\"values\":{\"codes\":\"[\\\"开曼群岛\\\"]\",\"dataId\":\"199\",\"id\":\"199\",\"name\":\"开曼群岛\",\"pinyin\":\"[\\\"kai man qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":200,\"values\":{\"codes\":\"[\\\"百慕大\\\", \\\"百慕大群岛\\\"]\",\"dataId\":\"200\",\"id\":\"200\",\"name\":\"百慕大\",\"pinyin\":\"[\\\"bai mu da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":201,\"values\":{\"codes\":\"[\\\"哥伦比亚\\\"]\",\"dataId\":\"201\",\"id\":\"201\",\"name\":\"哥伦比亚\",\"pinyin\":\"[\\\"ge lun bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":202,\"values\":{\"codes\":\"[\\\"委内瑞拉\\\"]\",\"dataId\":\"202\",\"id\":\"202\",\"name\":\"委内瑞拉\",\"pinyin\":\"[\\\"wei nei rui la\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":203,\"values\":{\"codes\":\"[\\\"圭亚那\\\"]\",\"dataId\":\"203\",\"id\":\"203\",\"name\":\"圭亚那\",\"pinyin\":\"[\\\"gui ya na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":204,\"values\":{\"codes\":\"[\\\"法属圭亚那\\\"]\",\"dataId\":\"204\",\"id\":\"204\",\"name\":\"法属圭亚那\",\"pinyin\":\"[\\\"fa shu gui ya na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":205,\"values\":{\"codes\":\"[\\\"苏里南\\\"]\",\"dataId\":\"205\",\"id\":\"205\",\"name\":\"苏里南\",\"pinyin\":\"[\\\"su li nan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":206,\"values\":{\"codes\":\"[\\\"厄瓜多尔\\\"]\",\"dataId\":\"206\",\"id\":\"206\",\"name\":\"厄瓜多尔\",\"pinyin\":\"[\\\"e gua duo er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":207,\"values\":{\"codes\":\"[\\\"秘鲁\\\"]\",\"dataId\":\"207\",\"id\":\"207\",\"name\":\"秘鲁\",\"pinyin\":\"[\\\"mi lu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":208,\"values\":{\"codes\":\"[\\\"玻利维亚\\\"]\",\"dataId\":\"208\",\"id\":\"208\",\"name\":\"玻利维亚\",\"pinyin\":\"[\\\"bo li wei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":209,\"values\":{\"codes\":\"[\\\"巴西\\\"]\",\"dataId\":\"209\",\"id\":\"209\",\"name\":\"巴西\",\"pinyin\":\"[\\\"ba xi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":210,\"values\":{\"codes\":\"[\\\"智利\\\"]\",\"dataId\":\"210\",\"id\":\"210\",\"name\":\"智利\",\"pinyin\":\"[\\\"zhi li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":211,\"values\":{\"codes\":\"[\\\"阿根廷\\\"]\",\"dataId\":\"211\",\"id\":\"211\",\"name\":\"阿根廷\",\"pinyin\":\"[\\\"a gen ting\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":212,\"values\":{\"codes\":\"[\\\"乌拉圭\\\"]\",\"dataId\":\"212\",\"id\":\"212\",\"name\":\"乌拉圭\",\"pinyin\":\"[\\\"wu la gui\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":213,\"values\":{\"codes\":\"[\\\"巴拉圭\\\"]\",\"dataId\":\"213\",\"id\":\"213\",\"name\":\"巴拉圭\",\"pinyin\":\"[\\\"ba la gui\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":214,\"values\":{\"codes\":\"[\\\"波黑\\\"]\",\"dataId\":\"3405\",\"id\":\"214\",\"name\":\"波黑\",\"pinyin\":\"[\\\"bo hei\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":215,\"values\":{\"codes\":\"[\\\"直布罗陀\\\"]\",\"dataId\":\"3406\",\"id\":\"215\",\"name\":\"直布罗陀\",\"pinyin\":\"[\\\"zhi bu luo tuo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":216,\"values\":{\"codes\":\"[\\\"新喀里多尼亚群岛\\\"]\",\"dataId\":\"3407\",\"id\":\"216\",\"name\":\"新喀里多尼亚群岛\",\"pinyin\":\"[\\\"xin ka li duo ni ya qun dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":217,\"values\":{\"codes\":\"[\\\"瓦利斯和富图纳群岛\\\"]\",\"dataId\":\"3408\",\"id\":\"217\",\"name\":\"瓦利斯和富图纳群岛\",\"pinyin\":\"[\\\"wa li si he fu tu na qun dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":218,\"values\":{\"codes\":\"[\\\"泽西岛\\\"]\",\"dataId\":\"3409\",\"id\":\"218\",\"name\":\"泽西岛\",\"pinyin\":\"[\\\"ze xi dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":219,\"values\":{\"codes\":\"[\\\"黑山\\\"]\",\"dataId\":\"3410\",\"id\":\"219\",\"name\":\"黑山\",\"pinyin\":\"[\\\"hei shan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":220,\"values\":{\"codes\":\"[\\\"英属马恩岛\\\", \\\"马恩岛\\\"]\",\"dataId\":\"3411\",\"id\":\"220\",\"name\":\"英属马恩岛\",\"pinyin\":\"[\\\"ying shu ma en dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":221,\"values\":{\"codes\":\"[\\\"尼日利亚\\\"]\",\"dataId\":\"3412\",\"id\":\"221\",\"name\":\"尼日利亚\",\"pinyin\":\"[\\\"ni ri li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":222,\"values\":{\"codes\":\"[\\\"喀麦隆\\\"]\",\"dataId\":\"3413\",\"id\":\"222\",\"name\":\"喀麦隆\",\"pinyin\":\"[\\\"ka mai long\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":223,\"values\":{\"codes\":\"[\\\"加蓬\\\"]\",\"dataId\":\"3414\",\"id\":\"223\",\"name\":\"加蓬\",\"pinyin\":\"[\\\"jia peng\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":224,\"values\":{\"codes\":\"[\\\"乍得\\\"]\",\"dataId\":\"3415\",\"id\":\"224\",\"name\":\"乍得\",\"pinyin\":\"[\\\"zha de\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":225,\"values\":{\"codes\":\"[\\\"刚果共和国\\\", \\\"刚果布\\\"]\",\"dataId\":\"3416\",\"id\":\"225\",\"name\":\"刚果共和国\",\"pinyin\":\"[\\\"gang guo gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":226,\"values\":{\"codes\":\"[\\\"中非共和国\\\", \\\"中非\\\"]\",\"dataId\":\"3417\",\"id\":\"226\",\"name\":\"中非共和国\",\"pinyin\":\"[\\\"zhong fei gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":227,\"values\":{\"codes\":\"[\\\"南苏丹\\\"]\",\"dataId\":\"3418\",\"id\":\"227\",\"name\":\"南苏丹\",\"pinyin\":\"[\\\"nan su dan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":228,\"values\":{\"codes\":\"[\\\"赤道几内亚\\\"]\",\"dataId\":\"3419\",\"id\":\"228\",\"name\":\"赤道几内亚\",\"pinyin\":\"[\\\"chi dao ji nei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":229,\"values\":{\"codes\":\"[\\\"毛里塔尼亚\\\"]\",\"dataId\":\"3420\",\"id\":\"229\",\"name\":\"毛里塔尼亚\",\"pinyin\":\"[\\\"mao li ta ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":230,\"values\":{\"codes\":\"[\\\"刚果民主共和国\\\", \\\"刚果金\\\"]\",\"dataId\":\"3421\",\"id\":\"230\",\"name\":\"刚果民主共和国\",\"pinyin\":\"[\\\"gang guo min zhu gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":231,\"values\":{\"codes\":\"[\\\"留尼汪岛\\\"]\",\"dataId\":\"3422\",\"id\":\"231\",\"name\":\"留尼汪岛\",\"pinyin\":\"[\\\"liu ni wang dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":232,\"values\":{\"codes\":\"[\\\"格陵兰岛\\\"]\",\"dataId\":\"3423\",\"id\":\"232\",\"name\":\"格陵兰岛\",\"pinyin\":\"[\\\"ge ling lan dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":233,\"values\":{\"codes\":\"[\\\"法罗群岛\\\"]\",\"dataId\":\"3424\",\"id\":\"233\",\"name\":\"法罗群岛\",\"pinyin\":\"[\\\"fa luo qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":234,\"values\":{\"codes\":\"[\\\"根西岛\\\"]\",\"dataId\":\"3425\",\"id\":\"234\",\"name\":\"根西岛\",\"pinyin\":\"[\\\"gen xi dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":235,\"values\":{\"codes\":\"[\\\"百慕大群岛\\\"]\",\"dataId\":\"3432\",\"id\":\"235\",\"name\":\"百慕大群岛\",\"pinyin\":\"[\\\"bai mu da qun dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":236,\"values\":{\"codes\":\"[\\\"圣皮埃尔和密克隆群岛\\\"]\",\"dataId\":\"3433\",\"id\":\"236\",\"name\":\"圣皮埃尔和密克隆群岛\",\"pinyin\":\"[\\\"sheng pi ai er he mi ke long qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":237,\"values\":{\"codes\":\"[\\\"法属圣马丁\\\"]\",\"dataId\":\"3434\",\"id\":\"237\",\"name\":\"法属圣马丁\",\"pinyin\":\"[\\\"fa shu sheng ma ding\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":238,\"values\":{\"codes\":\"[\\\"奥兰群岛\\\"]\",\"dataId\":\"3435\",\"id\":\"238\",\"name\":\"奥兰群岛\",\"pinyin\":\"[\\\"ao lan qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":239,\"values\":{\"codes\":\"[\\\"北马里亚纳群岛\\\"]\",\"dataId\":\"3436\",\"id\":\"239\",\"name\":\"北马里亚纳群岛\",\"pinyin\":\"[\\\"bei ma li ya na qun dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":240,\"values\":{\"codes\":\"[\\\"库拉索\\\"]\",\"dataId\":\"3437\",\"id\":\"240\",\"name\":\"库拉索\",\"pinyin\":\"[\\\"ku la suo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":241,\"values\":{\"codes\":\"[\\\"博内尔岛\\\"]\",\"dataId\":\"3438\",\"id\":\"241\",\"name\":\"博内尔岛\",\"pinyin\":\"[\\\"bo nei er dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":242,\"values\":{\"codes\":\"[\\\"圣马丁岛\\\", \\\"圣马丁\\\"]\",\"dataId\":\"3439\",\"id\":\"242\",\"name\":\"圣马丁岛\",\"pinyin\":\"[\\\"sheng ma ding dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":243,\"values\":{\"codes\":\"[\\\"圣巴泰勒米岛\\\", \\\"圣巴泰勒米\\\"]\",\"dataId\":\"3440\",\"id\":\"243\",\"name\":\"圣巴泰勒米岛\",\"pinyin\":\"[\\\"sheng ba tai lei mi dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":244,\"values\":{\"codes\":\"[\\\"福克兰群岛\\\"]\",\"dataId\":\"3441\",\"id\":\"244\",\"name\":\"福克兰群岛\",\"pinyin\":\"[\\\"fu ke lan qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":245,\"values\":{\"codes\":\"[\\\"圣多美和普林西比\\\"]\",\"dataId\":\"3442\",\"id\":\"245\",\"name\":\"圣多美和普林西比\",\"pinyin\":\"[\\\"sheng duo mei he pu lin xi bi\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":246,\"values\":{\"codes\":\"[\\\"英属印度洋领地\\\"]\",\"dataId\":\"3443\",\"id\":\"246\",\"name\":\"英属印度洋领地\",\"pinyin\":\"[\\\"ying shu yin du yang ling de\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":247,\"values\":{\"codes\":\"[\\\"东萨摩亚\\\"]\",\"dataId\":\"3444\",\"id\":\"247\",\"name\":\"东萨摩亚\",\"pinyin\":\"[\\\"dong sa mo ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":248,\"values\":{\"codes\":\"[\\\"诺福克岛\\\"]\",\"dataId\":\"3445\",\"id\":\"248\",\"name\":\"诺福克岛\",\"pinyin\":\"[\\\"nuo fu ke dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":249,\"values\":{\"codes\":\"[\\\"法属马约特岛\\\", \\\"马约特岛\\\", \\\"马约特\\\"]\",\"dataId\":\"\",\"id\":\"249\",\"name\":\"马约特岛\",\"pinyin\":\"[\\\"ma yue te dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":250,\"values\":{\"codes\":\"[\\\"科索沃\\\"]\",\"dataId\":\"\",\"id\":\"250\",\"name\":\"科索沃\",\"pinyin\":\"[\\\"ke suo wo\\\", \\\"Kosovo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":251,\"values\":{\"codes\":\"[\\\"荷属圣马丁\\\"]\",\"dataId\":\"\",\"id\":\"251\",\"name\":\"荷属圣马丁\",\"pinyin\":\"[\\\"he shu sheng ma ding\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":252,\"values\":{\"codes\":\"[\\\"南乔治亚岛和南桑威奇群岛\\\"]\",\"dataId\":\"\",\"id\":\"252\",\"name\":\"南乔治亚岛和南桑威奇群岛\",\"pinyin\":\"[\\\"nan qiao zhi ya dao he nan sang wei qi qun dao\\\", \\\"South Georgia and The South Sandwich Islands\\\", \\\"SGSSI\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":253,\"values\":{\"codes\":\"[\\\"南极洲\\\"]\",\"dataId\":\"\",\"id\":\"253\",\"name\":\"南极洲\",\"pinyin\":\"[\\\"nan ji zhou\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":254,\"values\":{\"codes\":\"[\\\"阿森松岛\\\"]\",\"dataId\":\"\",\"id\":\"254\",\"name\":\"阿森松岛\",\"pinyin\":\"[\\\"a sen song dao\\\", \\\"Ascension Island\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":255,\"values\":{\"codes\":\"[\\\"圣诞岛\\\"]\",\"dataId\":\"\",\"id\":\"255\",\"name\":\"圣诞岛\",\"pinyin\":\"[\\\"sheng dan dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]}]},{\"name\":\"edgeRegionProviders\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeRegionProviders` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='区域-运营商'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[{\"id\":1,\"values\":{\"codes\":\"[\\\"电信\\\"]\",\"id\":\"1\",\"name\":\"电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":2,\"values\":{\"codes\":\"[\\\"TOT\\\"]\",\"id\":\"2\",\"name\":\"TOT\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":3,\"values\":{\"codes\":\"[\\\"联通\\\"]\",\"id\":\"3\",\"name\":\"联通\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":4,\"values\":{\"codes\":\"[\\\"谷歌\\\"]\",\"id\":\"4\",\"name\":\"谷歌\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":5,\"values\":{\"codes\":\"[\\\"软银\\\"]\",\"id\":\"5\",\"name\":\"软银\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":6,\"values\":{\"codes\":\"[\\\"Sify\\\"]\",\"id\":\"6\",\"name\":\"Sify\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":7,\"values\":{\"codes\":\"[\\\"马来西亚电信\\\"]\",\"id\":\"7\",\"name\":\"马来西亚电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":8,\"values\":{\"codes\":\"[\\\"方正宽带\\\"]\",\"id\":\"8\",\"name\":\"方正宽带\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":9,\"values\":{\"codes\":\"[\\\"Tikona\\\"]\",\"id\":\"9\",\"name\":\"Tikona\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":10,\"values\":{\"codes\":\"[\\\"新加坡电信\\\"]\",\"id\":\"10\",\"name\":\"新加坡电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":11,\"values\":{\"codes\":\"[\\\"日本电信电话\\\"]\",\"id\":\"11\",\"name\":\"日本电信电话\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":12,\"values\":{\"codes\":\"[\\\"中华电信\\\"]\",\"id\":\"12\",\"name\":\"中华电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":13,\"values\":{\"codes\":\"[\\\"电讯盈科\\\"]\",\"id\":\"13\",\"name\":\"电讯盈科\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":14,\"values\":{\"codes\":\"[\\\"Globe-Telecom\\\"]\",\"id\":\"14\",\"name\":\"Globe-Telecom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":15,\"values\":{\"codes\":\"[\\\"沃达丰印度\\\"]\",\"id\":\"15\",\"name\":\"沃达丰印度\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":16,\"values\":{\"codes\":\"[\\\"Optus\\\"]\",\"id\":\"16\",\"name\":\"Optus\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":17,\"values\":{\"codes\":\"[\\\"DTAC\\\"]\",\"id\":\"17\",\"name\":\"DTAC\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":18,\"values\":{\"codes\":\"[\\\"教育网\\\"]\",\"id\":\"18\",\"name\":\"教育网\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":19,\"values\":{\"codes\":\"[\\\"Finance-and-Promoting-Technology\\\"]\",\"id\":\"19\",\"name\":\"Finance-and-Promoting-Technology\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":20,\"values\":{\"codes\":\"[\\\"多科莫\\\"]\",\"id\":\"20\",\"name\":\"多科莫\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":21,\"values\":{\"codes\":\"[\\\"歌华\\\"]\",\"id\":\"21\",\"name\":\"歌华\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":22,\"values\":{\"codes\":\"[\\\"鹏博士\\\"]\",\"id\":\"22\",\"name\":\"鹏博士\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":23,\"values\":{\"codes\":\"[\\\"韩国电信\\\"]\",\"id\":\"23\",\"name\":\"韩国电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":24,\"values\":{\"codes\":\"[\\\"腾讯\\\"]\",\"id\":\"24\",\"name\":\"腾讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":25,\"values\":{\"codes\":\"[\\\"皓宽网络\\\"]\",\"id\":\"25\",\"name\":\"皓宽网络\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":26,\"values\":{\"codes\":\"[\\\"澳大利亚电信\\\"]\",\"id\":\"26\",\"name\":\"澳大利亚电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":27,\"values\":{\"codes\":\"[\\\"阿里云\\\"]\",\"id\":\"27\",\"name\":\"阿里云\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":28,\"values\":{\"codes\":\"[\\\"Dvois\\\"]\",\"id\":\"28\",\"name\":\"Dvois\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":29,\"values\":{\"codes\":\"[\\\"台湾之星\\\"]\",\"id\":\"29\",\"name\":\"台湾之星\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":30,\"values\":{\"codes\":\"[\\\"KINX\\\"]\",\"id\":\"30\",\"name\":\"KINX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":31,\"values\":{\"codes\":\"[\\\"SK宽带\\\"]\",\"id\":\"31\",\"name\":\"SK宽带\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":32,\"values\":{\"codes\":\"[\\\"橘子电信\\\"]\",\"id\":\"32\",\"name\":\"橘子电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":33,\"values\":{\"codes\":\"[\\\"阿卡迈\\\"]\",\"id\":\"33\",\"name\":\"阿卡迈\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":34,\"values\":{\"codes\":\"[\\\"沃达丰\\\"]\",\"id\":\"34\",\"name\":\"沃达丰\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":35,\"values\":{\"codes\":\"[\\\"科进\\\"]\",\"id\":\"35\",\"name\":\"科进\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":36,\"values\":{\"codes\":\"[\\\"GTT\\\"]\",\"id\":\"36\",\"name\":\"GTT\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":37,\"values\":{\"codes\":\"[\\\"康卡斯特\\\"]\",\"id\":\"37\",\"name\":\"康卡斯特\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":38,\"values\":{\"codes\":\"[\\\"俄罗斯电信\\\"]\",\"id\":\"38\",\"name\":\"俄罗斯电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":39,\"values\":{\"codes\":\"[\\\"VimpelCom\\\"]\",\"id\":\"39\",\"name\":\"VimpelCom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":40,\"values\":{\"codes\":\"[\\\"意大利电信\\\"]\",\"id\":\"40\",\"name\":\"意大利电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":41,\"values\":{\"codes\":\"[\\\"Telenor\\\"]\",\"id\":\"41\",\"name\":\"Telenor\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":42,\"values\":{\"codes\":\"[\\\"哈萨克斯坦电信\\\"]\",\"id\":\"42\",\"name\":\"哈萨克斯坦电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":43,\"values\":{\"codes\":\"[\\\"西班牙电信\\\"]\",\"id\":\"43\",\"name\":\"西班牙电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":44,\"values\":{\"codes\":\"[\\\"FastWeb\\\"]\",\"id\":\"44\",\"name\":\"FastWeb\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":45,\"values\":{\"codes\":\"[\\\"Telia-Company\\\"]\",\"id\":\"45\",\"name\":\"Telia-Company\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":46,\"values\":{\"codes\":\"[\\\"亚马逊\\\"]\",\"id\":\"46\",\"name\":\"亚马逊\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":47,\"values\":{\"codes\":\"[\\\"Level3\\\"]\",\"id\":\"47\",\"name\":\"Level3\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":48,\"values\":{\"codes\":\"[\\\"微软\\\"]\",\"id\":\"48\",\"name\":\"微软\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":49,\"values\":{\"codes\":\"[\\\"Trans-Telecom\\\"]\",\"id\":\"49\",\"name\":\"Trans-Telecom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":50,\"values\":{\"codes\":\"[\\\"Mobile-TeleSystems\\\"]\",\"id\":\"50\",\"name\":\"Mobile-TeleSystems\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":51,\"values\":{\"codes\":\"[\\\"Liberty\\\"]\",\"id\":\"51\",\"name\":\"Liberty\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":52,\"values\":{\"codes\":\"[\\\"SoftLayer\\\"]\",\"id\":\"52\",\"name\":\"SoftLayer\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":53,\"values\":{\"codes\":\"[\\\"卡塔尔电信\\\"]\",\"id\":\"53\",\"name\":\"卡塔尔电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":54,\"values\":{\"codes\":\"[\\\"Tata\\\"]\",\"id\":\"54\",\"name\":\"Tata\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":55,\"values\":{\"codes\":\"[\\\"阿曼电信\\\"]\",\"id\":\"55\",\"name\":\"阿曼电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":56,\"values\":{\"codes\":\"[\\\"T-Mobile\\\"]\",\"id\":\"56\",\"name\":\"T-Mobile\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":57,\"values\":{\"codes\":\"[\\\"Altice\\\"]\",\"id\":\"57\",\"name\":\"Altice\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":58,\"values\":{\"codes\":\"[\\\"威瑞森\\\"]\",\"id\":\"58\",\"name\":\"威瑞森\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":59,\"values\":{\"codes\":\"[\\\"美国电话电报\\\"]\",\"id\":\"59\",\"name\":\"美国电话电报\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":60,\"values\":{\"codes\":\"[\\\"Hurricane-Electric\\\"]\",\"id\":\"60\",\"name\":\"Hurricane-Electric\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":61,\"values\":{\"codes\":\"[\\\"Equinix\\\"]\",\"id\":\"61\",\"name\":\"Equinix\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":62,\"values\":{\"codes\":\"[\\\"移动\\\"]\",\"id\":\"62\",\"name\":\"移动\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":63,\"values\":{\"codes\":\"[\\\"CAT\\\"]\",\"id\":\"63\",\"name\":\"CAT\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":64,\"values\":{\"codes\":\"[\\\"Kvant-Telecom\\\"]\",\"id\":\"64\",\"name\":\"Kvant-Telecom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":65,\"values\":{\"codes\":\"[\\\"俄罗斯Tele2\\\"]\",\"id\":\"65\",\"name\":\"俄罗斯Tele2\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":66,\"values\":{\"codes\":\"[\\\"Smart\\\"]\",\"id\":\"66\",\"name\":\"Smart\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":67,\"values\":{\"codes\":\"[\\\"RETN\\\"]\",\"id\":\"67\",\"name\":\"RETN\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":68,\"values\":{\"codes\":\"[\\\"Net1\\\"]\",\"id\":\"68\",\"name\":\"Net1\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":69,\"values\":{\"codes\":\"[\\\"阿里巴巴\\\"]\",\"id\":\"69\",\"name\":\"阿里巴巴\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":70,\"values\":{\"codes\":\"[\\\"KDDI\\\"]\",\"id\":\"70\",\"name\":\"KDDI\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":71,\"values\":{\"codes\":\"[\\\"广州驰联\\\"]\",\"id\":\"71\",\"name\":\"广州驰联\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":72,\"values\":{\"codes\":\"[\\\"香港宽频\\\"]\",\"id\":\"72\",\"name\":\"香港宽频\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":73,\"values\":{\"codes\":\"[\\\"雅虎\\\"]\",\"id\":\"73\",\"name\":\"雅虎\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":74,\"values\":{\"codes\":\"[\\\"层峰网络\\\"]\",\"id\":\"74\",\"name\":\"层峰网络\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":75,\"values\":{\"codes\":\"[\\\"越南邮电\\\"]\",\"id\":\"75\",\"name\":\"越南邮电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":76,\"values\":{\"codes\":\"[\\\"Ctrls\\\"]\",\"id\":\"76\",\"name\":\"Ctrls\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":77,\"values\":{\"codes\":\"[\\\"Indusind\\\"]\",\"id\":\"77\",\"name\":\"Indusind\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":78,\"values\":{\"codes\":\"[\\\"GTPL\\\"]\",\"id\":\"78\",\"name\":\"GTPL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":79,\"values\":{\"codes\":\"[\\\"MAXIS\\\"]\",\"id\":\"79\",\"name\":\"MAXIS\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":80,\"values\":{\"codes\":\"[\\\"TPG电信\\\"]\",\"id\":\"80\",\"name\":\"TPG电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":81,\"values\":{\"codes\":\"[\\\"Jastel\\\"]\",\"id\":\"81\",\"name\":\"Jastel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":82,\"values\":{\"codes\":\"[\\\"苹果\\\"]\",\"id\":\"82\",\"name\":\"苹果\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":83,\"values\":{\"codes\":\"[\\\"印度电信\\\"]\",\"id\":\"83\",\"name\":\"印度电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":84,\"values\":{\"codes\":\"[\\\"ACT\\\"]\",\"id\":\"84\",\"name\":\"ACT\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":85,\"values\":{\"codes\":\"[\\\"Telus\\\"]\",\"id\":\"85\",\"name\":\"Telus\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":86,\"values\":{\"codes\":\"[\\\"JIO\\\"]\",\"id\":\"86\",\"name\":\"JIO\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":87,\"values\":{\"codes\":\"[\\\"Telin\\\"]\",\"id\":\"87\",\"name\":\"Telin\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":88,\"values\":{\"codes\":\"[\\\"Claro\\\"]\",\"id\":\"88\",\"name\":\"Claro\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":89,\"values\":{\"codes\":\"[\\\"Fastly\\\"]\",\"id\":\"89\",\"name\":\"Fastly\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":90,\"values\":{\"codes\":\"[\\\"CenturyLink\\\"]\",\"id\":\"90\",\"name\":\"CenturyLink\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":91,\"values\":{\"codes\":\"[\\\"罗杰斯通信\\\"]\",\"id\":\"91\",\"name\":\"罗杰斯通信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":92,\"values\":{\"codes\":\"[\\\"EarthLink\\\"]\",\"id\":\"92\",\"name\":\"EarthLink\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":93,\"values\":{\"codes\":\"[\\\"Sprint\\\"]\",\"id\":\"93\",\"name\":\"Sprint\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":94,\"values\":{\"codes\":\"[\\\"BSNL\\\"]\",\"id\":\"94\",\"name\":\"BSNL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":95,\"values\":{\"codes\":\"[\\\"HathWay\\\"]\",\"id\":\"95\",\"name\":\"HathWay\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":96,\"values\":{\"codes\":\"[\\\"远传电信\\\"]\",\"id\":\"96\",\"name\":\"远传电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":97,\"values\":{\"codes\":\"[\\\"星和移动\\\"]\",\"id\":\"97\",\"name\":\"星和移动\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":98,\"values\":{\"codes\":\"[\\\"信实通信\\\"]\",\"id\":\"98\",\"name\":\"信实通信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":99,\"values\":{\"codes\":\"[\\\"True\\\"]\",\"id\":\"99\",\"name\":\"True\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":100,\"values\":{\"codes\":\"[\\\"Mahataa\\\"]\",\"id\":\"100\",\"name\":\"Mahataa\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":101,\"values\":{\"codes\":\"[\\\"越南军用通讯\\\"]\",\"id\":\"101\",\"name\":\"越南军用通讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":102,\"values\":{\"codes\":\"[\\\"Ezecom\\\"]\",\"id\":\"102\",\"name\":\"Ezecom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":103,\"values\":{\"codes\":\"[\\\"AIS\\\"]\",\"id\":\"103\",\"name\":\"AIS\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":104,\"values\":{\"codes\":\"[\\\"新加坡第一通\\\"]\",\"id\":\"104\",\"name\":\"新加坡第一通\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":105,\"values\":{\"codes\":\"[\\\"So-Net\\\"]\",\"id\":\"105\",\"name\":\"So-Net\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":106,\"values\":{\"codes\":\"[\\\"Syscon\\\"]\",\"id\":\"106\",\"name\":\"Syscon\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":107,\"values\":{\"codes\":\"[\\\"DiGi-Telecommunication\\\"]\",\"id\":\"107\",\"name\":\"DiGi-Telecommunication\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":108,\"values\":{\"codes\":\"[\\\"澳门电讯\\\"]\",\"id\":\"108\",\"name\":\"澳门电讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":109,\"values\":{\"codes\":\"[\\\"菲律宾长途电话公司\\\"]\",\"id\":\"109\",\"name\":\"菲律宾长途电话公司\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":110,\"values\":{\"codes\":\"[\\\"互联网泰国\\\"]\",\"id\":\"110\",\"name\":\"互联网泰国\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":111,\"values\":{\"codes\":\"[\\\"Ani-Network\\\"]\",\"id\":\"111\",\"name\":\"Ani-Network\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":112,\"values\":{\"codes\":\"[\\\"UMobile\\\"]\",\"id\":\"112\",\"name\":\"UMobile\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":113,\"values\":{\"codes\":\"[\\\"Biglobe\\\"]\",\"id\":\"113\",\"name\":\"Biglobe\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":114,\"values\":{\"codes\":\"[\\\"重庆广电\\\"]\",\"id\":\"114\",\"name\":\"重庆广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":115,\"values\":{\"codes\":\"[\\\"SK电讯\\\"]\",\"id\":\"115\",\"name\":\"SK电讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":116,\"values\":{\"codes\":\"[\\\"Aircel\\\"]\",\"id\":\"116\",\"name\":\"Aircel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":117,\"values\":{\"codes\":\"[\\\"Quadrant\\\"]\",\"id\":\"117\",\"name\":\"Quadrant\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":118,\"values\":{\"codes\":\"[\\\"Elisa\\\"]\",\"id\":\"118\",\"name\":\"Elisa\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":119,\"values\":{\"codes\":\"[\\\"脸书\\\"]\",\"id\":\"119\",\"name\":\"脸书\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":120,\"values\":{\"codes\":\"[\\\"MegaFon\\\"]\",\"id\":\"120\",\"name\":\"MegaFon\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":121,\"values\":{\"codes\":\"[\\\"扎因\\\"]\",\"id\":\"121\",\"name\":\"扎因\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":122,\"values\":{\"codes\":\"[\\\"ASK4\\\"]\",\"id\":\"122\",\"name\":\"ASK4\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":123,\"values\":{\"codes\":\"[\\\"XTRA-Telecom\\\"]\",\"id\":\"123\",\"name\":\"XTRA-Telecom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":124,\"values\":{\"codes\":\"[\\\"天威\\\"]\",\"id\":\"124\",\"name\":\"天威\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":125,\"values\":{\"codes\":\"[\\\"和记电讯\\\"]\",\"id\":\"125\",\"name\":\"和记电讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":126,\"values\":{\"codes\":\"[\\\"铁通\\\"]\",\"id\":\"126\",\"name\":\"铁通\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":127,\"values\":{\"codes\":\"[\\\"科技网\\\"]\",\"id\":\"127\",\"name\":\"科技网\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":128,\"values\":{\"codes\":\"[\\\"德国电信\\\"]\",\"id\":\"128\",\"name\":\"德国电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":129,\"values\":{\"codes\":\"[\\\"GrameenPhone\\\"]\",\"id\":\"129\",\"name\":\"GrameenPhone\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":130,\"values\":{\"codes\":\"[\\\"巴基斯坦电信\\\"]\",\"id\":\"130\",\"name\":\"巴基斯坦电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":131,\"values\":{\"codes\":\"[\\\"Workonline\\\"]\",\"id\":\"131\",\"name\":\"Workonline\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":132,\"values\":{\"codes\":\"[\\\"Safaricom\\\"]\",\"id\":\"132\",\"name\":\"Safaricom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":133,\"values\":{\"codes\":\"[\\\"MTNL\\\"]\",\"id\":\"133\",\"name\":\"MTNL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":134,\"values\":{\"codes\":\"[\\\"宁夏广电\\\"]\",\"id\":\"134\",\"name\":\"宁夏广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":135,\"values\":{\"codes\":\"[\\\"Webe-Digital\\\"]\",\"id\":\"135\",\"name\":\"Webe-Digital\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":136,\"values\":{\"codes\":\"[\\\"广电\\\"]\",\"id\":\"136\",\"name\":\"广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":137,\"values\":{\"codes\":\"[\\\"西安交通大学\\\"]\",\"id\":\"137\",\"name\":\"西安交通大学\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":138,\"values\":{\"codes\":\"[\\\"老挝电信\\\"]\",\"id\":\"138\",\"name\":\"老挝电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":139,\"values\":{\"codes\":\"[\\\"湖南广电\\\"]\",\"id\":\"139\",\"name\":\"湖南广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":140,\"values\":{\"codes\":\"[\\\"黑龙江广电\\\"]\",\"id\":\"140\",\"name\":\"黑龙江广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":141,\"values\":{\"codes\":\"[\\\"湖北广电\\\"]\",\"id\":\"141\",\"name\":\"湖北广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":142,\"values\":{\"codes\":\"[\\\"Unitel\\\"]\",\"id\":\"142\",\"name\":\"Unitel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":143,\"values\":{\"codes\":\"[\\\"You-Broadband\\\"]\",\"id\":\"143\",\"name\":\"You-Broadband\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":144,\"values\":{\"codes\":\"[\\\"Siti\\\"]\",\"id\":\"144\",\"name\":\"Siti\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":145,\"values\":{\"codes\":\"[\\\"Cogetel\\\"]\",\"id\":\"145\",\"name\":\"Cogetel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":146,\"values\":{\"codes\":\"[\\\"SMTeleMedia\\\"]\",\"id\":\"146\",\"name\":\"SMTeleMedia\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":147,\"values\":{\"codes\":\"[\\\"VNPL\\\"]\",\"id\":\"147\",\"name\":\"VNPL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":148,\"values\":{\"codes\":\"[\\\"台湾固网\\\"]\",\"id\":\"148\",\"name\":\"台湾固网\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":149,\"values\":{\"codes\":\"[\\\"亚太电信\\\"]\",\"id\":\"149\",\"name\":\"亚太电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":150,\"values\":{\"codes\":\"[\\\"台湾大哥大\\\"]\",\"id\":\"150\",\"name\":\"台湾大哥大\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":151,\"values\":{\"codes\":\"[\\\"Intech\\\"]\",\"id\":\"151\",\"name\":\"Intech\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":152,\"values\":{\"codes\":\"[\\\"MegaPort\\\"]\",\"id\":\"152\",\"name\":\"MegaPort\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":153,\"values\":{\"codes\":\"[\\\"SpeedCast\\\"]\",\"id\":\"153\",\"name\":\"SpeedCast\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":154,\"values\":{\"codes\":\"[\\\"广东广电\\\"]\",\"id\":\"154\",\"name\":\"广东广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":155,\"values\":{\"codes\":\"[\\\"Banglalink\\\"]\",\"id\":\"155\",\"name\":\"Banglalink\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":156,\"values\":{\"codes\":\"[\\\"SINET\\\"]\",\"id\":\"156\",\"name\":\"SINET\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":157,\"values\":{\"codes\":\"[\\\"WiCAM\\\"]\",\"id\":\"157\",\"name\":\"WiCAM\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":158,\"values\":{\"codes\":\"[\\\"Mtel\\\"]\",\"id\":\"158\",\"name\":\"Mtel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":159,\"values\":{\"codes\":\"[\\\"华数\\\"]\",\"id\":\"159\",\"name\":\"华数\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":160,\"values\":{\"codes\":\"[\\\"上海驰联\\\"]\",\"id\":\"160\",\"name\":\"上海驰联\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":161,\"values\":{\"codes\":\"[\\\"OpenNet\\\"]\",\"id\":\"161\",\"name\":\"OpenNet\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":162,\"values\":{\"codes\":\"[\\\"Asianet\\\"]\",\"id\":\"162\",\"name\":\"Asianet\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":163,\"values\":{\"codes\":\"[\\\"老挝电信企业\\\"]\",\"id\":\"163\",\"name\":\"老挝电信企业\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":164,\"values\":{\"codes\":\"[\\\"Alliance\\\"]\",\"id\":\"164\",\"name\":\"Alliance\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":165,\"values\":{\"codes\":\"[\\\"广西广电\\\"]\",\"id\":\"165\",\"name\":\"广西广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":166,\"values\":{\"codes\":\"[\\\"江苏广电\\\"]\",\"id\":\"166\",\"name\":\"江苏广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":167,\"values\":{\"codes\":\"[\\\"世纪互联\\\"]\",\"id\":\"167\",\"name\":\"世纪互联\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":168,\"values\":{\"codes\":\"[\\\"SkyCable\\\"]\",\"id\":\"168\",\"name\":\"SkyCable\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":169,\"values\":{\"codes\":\"[\\\"SmileServ\\\"]\",\"id\":\"169\",\"name\":\"SmileServ\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":170,\"values\":{\"codes\":\"[\\\"SmarTone\\\"]\",\"id\":\"170\",\"name\":\"SmarTone\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":171,\"values\":{\"codes\":\"[\\\"BBIX\\\"]\",\"id\":\"171\",\"name\":\"BBIX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":172,\"values\":{\"codes\":\"[\\\"FastNet\\\"]\",\"id\":\"172\",\"name\":\"FastNet\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":173,\"values\":{\"codes\":\"[\\\"百度\\\"]\",\"id\":\"173\",\"name\":\"百度\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":174,\"values\":{\"codes\":\"[\\\"Railtel\\\"]\",\"id\":\"174\",\"name\":\"Railtel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":175,\"values\":{\"codes\":\"[\\\"内蒙古广电\\\"]\",\"id\":\"175\",\"name\":\"内蒙古广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":176,\"values\":{\"codes\":\"[\\\"吉视传媒\\\"]\",\"id\":\"176\",\"name\":\"吉视传媒\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":177,\"values\":{\"codes\":\"[\\\"Excitel\\\"]\",\"id\":\"177\",\"name\":\"Excitel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":178,\"values\":{\"codes\":\"[\\\"MyRepublic\\\"]\",\"id\":\"178\",\"name\":\"MyRepublic\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":179,\"values\":{\"codes\":\"[\\\"MobiFone\\\"]\",\"id\":\"179\",\"name\":\"MobiFone\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":180,\"values\":{\"codes\":\"[\\\"Den-Digital\\\"]\",\"id\":\"180\",\"name\":\"Den-Digital\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":181,\"values\":{\"codes\":\"[\\\"网上网\\\"]\",\"id\":\"181\",\"name\":\"网上网\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":182,\"values\":{\"codes\":\"[\\\"Biznet\\\"]\",\"id\":\"182\",\"name\":\"Biznet\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":183,\"values\":{\"codes\":\"[\\\"Netplus\\\"]\",\"id\":\"183\",\"name\":\"Netplus\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":184,\"values\":{\"codes\":\"[\\\"网宿\\\"]\",\"id\":\"184\",\"name\":\"网宿\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":185,\"values\":{\"codes\":\"[\\\"Telecom-Cambodia\\\"]\",\"id\":\"185\",\"name\":\"Telecom-Cambodia\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":186,\"values\":{\"codes\":\"[\\\"CIX\\\"]\",\"id\":\"186\",\"name\":\"CIX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":187,\"values\":{\"codes\":\"[\\\"加拿大贝尔\\\"]\",\"id\":\"187\",\"name\":\"加拿大贝尔\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":188,\"values\":{\"codes\":\"[\\\"AIMS\\\"]\",\"id\":\"188\",\"name\":\"AIMS\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":189,\"values\":{\"codes\":\"[\\\"SKBB\\\"]\",\"id\":\"189\",\"name\":\"SKBB\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":190,\"values\":{\"codes\":\"[\\\"Nift\\\"]\",\"id\":\"190\",\"name\":\"Nift\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":191,\"values\":{\"codes\":\"[\\\"有线通\\\"]\",\"id\":\"191\",\"name\":\"有线通\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":192,\"values\":{\"codes\":\"[\\\"香港新世界\\\"]\",\"id\":\"192\",\"name\":\"香港新世界\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":193,\"values\":{\"codes\":\"[\\\"华数传媒\\\"]\",\"id\":\"193\",\"name\":\"华数传媒\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":194,\"values\":{\"codes\":\"[\\\"3BB\\\"]\",\"id\":\"194\",\"name\":\"3BB\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":195,\"values\":{\"codes\":\"[\\\"辽宁广电\\\"]\",\"id\":\"195\",\"name\":\"辽宁广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":196,\"values\":{\"codes\":\"[\\\"河南广电\\\"]\",\"id\":\"196\",\"name\":\"河南广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":197,\"values\":{\"codes\":\"[\\\"陕西广电\\\"]\",\"id\":\"197\",\"name\":\"陕西广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":198,\"values\":{\"codes\":\"[\\\"新疆广电\\\"]\",\"id\":\"198\",\"name\":\"新疆广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":199,\"values\":{\"codes\":\"[\\\"山东广电\\\"]\",\"id\":\"199\",\"name\":\"山东广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":200,\"values\":{\"codes\":\"[\\\"四川广电\\\"]\",\"id\":\"200\",\"name\":\"四川广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":201,\"values\":{\"codes\":\"[\\\"文莱电信\\\"]\",\"id\":\"201\",\"name\":\"文莱电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":202,\"values\":{\"codes\":\"[\\\"香港有线\\\"]\",\"id\":\"202\",\"name\":\"香港有线\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":203,\"values\":{\"codes\":\"[\\\"天驰网络\\\"]\",\"id\":\"203\",\"name\":\"天驰网络\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":204,\"values\":{\"codes\":\"[\\\"湖南巨亚\\\"]\",\"id\":\"204\",\"name\":\"湖南巨亚\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":205,\"values\":{\"codes\":\"[\\\"MSK-IX\\\"]\",\"id\":\"205\",\"name\":\"MSK-IX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":206,\"values\":{\"codes\":\"[\\\"法国电信\\\"]\",\"id\":\"206\",\"name\":\"法国电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":207,\"values\":{\"codes\":\"[\\\"缅甸电信\\\"]\",\"id\":\"207\",\"name\":\"缅甸电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":208,\"values\":{\"codes\":\"[\\\"星通讯\\\"]\",\"id\":\"208\",\"name\":\"星通讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":209,\"values\":{\"codes\":\"[\\\"Netnod\\\"]\",\"id\":\"209\",\"name\":\"Netnod\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":210,\"values\":{\"codes\":\"[\\\"Masmovil\\\"]\",\"id\":\"210\",\"name\":\"Masmovil\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":211,\"values\":{\"codes\":\"[\\\"AMS-IX\\\"]\",\"id\":\"211\",\"name\":\"AMS-IX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":212,\"values\":{\"codes\":\"[\\\"Telefonica-O2\\\"]\",\"id\":\"212\",\"name\":\"Telefonica-O2\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":213,\"values\":{\"codes\":\"[\\\"Asiacell\\\"]\",\"id\":\"213\",\"name\":\"Asiacell\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":214,\"values\":{\"codes\":\"[\\\"布伊格电信\\\"]\",\"id\":\"214\",\"name\":\"布伊格电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":215,\"values\":{\"codes\":\"[\\\"Vivo\\\"]\",\"id\":\"215\",\"name\":\"Vivo\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":216,\"values\":{\"codes\":\"[\\\"XL\\\"]\",\"id\":\"216\",\"name\":\"XL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":217,\"values\":{\"codes\":\"[\\\"EE-Mobile\\\"]\",\"id\":\"217\",\"name\":\"EE-Mobile\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":218,\"values\":{\"codes\":\"[\\\"Tesco\\\"]\",\"id\":\"218\",\"name\":\"Tesco\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":219,\"values\":{\"codes\":\"[\\\"Netnam\\\"]\",\"id\":\"219\",\"name\":\"Netnam\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":220,\"values\":{\"codes\":\"[\\\"河北广电\\\"]\",\"id\":\"220\",\"name\":\"河北广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":221,\"values\":{\"codes\":\"[\\\"JPIX\\\"]\",\"id\":\"221\",\"name\":\"JPIX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":222,\"values\":{\"codes\":\"[\\\"Celcom\\\"]\",\"id\":\"222\",\"name\":\"Celcom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":223,\"values\":{\"codes\":\"[\\\"印尼金光\\\"]\",\"id\":\"223\",\"name\":\"印尼金光\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":224,\"values\":{\"codes\":\"[\\\"和记电讯印尼\\\"]\",\"id\":\"224\",\"name\":\"和记电讯印尼\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":225,\"values\":{\"codes\":\"[\\\"Indosat\\\"]\",\"id\":\"225\",\"name\":\"Indosat\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":226,\"values\":{\"codes\":\"[\\\"Excell\\\"]\",\"id\":\"226\",\"name\":\"Excell\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":227,\"values\":{\"codes\":\"[\\\"StackPath\\\"]\",\"id\":\"227\",\"name\":\"StackPath\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":228,\"values\":{\"codes\":\"[\\\"贵州广电\\\"]\",\"id\":\"228\",\"name\":\"贵州广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":229,\"values\":{\"codes\":\"[\\\"Camintel\\\"]\",\"id\":\"229\",\"name\":\"Camintel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":230,\"values\":{\"codes\":\"[\\\"Vnetwork\\\"]\",\"id\":\"230\",\"name\":\"Vnetwork\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":231,\"values\":{\"codes\":\"[\\\"湖南有线\\\"]\",\"id\":\"231\",\"name\":\"湖南有线\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":232,\"values\":{\"codes\":\"[\\\"山东乐享广电\\\"]\",\"id\":\"232\",\"name\":\"山东乐享广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":233,\"values\":{\"codes\":\"[\\\"BroadWay\\\"]\",\"id\":\"233\",\"name\":\"BroadWay\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":234,\"values\":{\"codes\":\"[\\\"First-Media\\\"]\",\"id\":\"234\",\"name\":\"First-Media\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":235,\"values\":{\"codes\":\"[\\\"西贡电信\\\"]\",\"id\":\"235\",\"name\":\"西贡电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":236,\"values\":{\"codes\":\"[\\\"APSFL\\\"]\",\"id\":\"236\",\"name\":\"APSFL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":237,\"values\":{\"codes\":\"[\\\"Teletalk\\\"]\",\"id\":\"237\",\"name\":\"Teletalk\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":238,\"values\":{\"codes\":\"[\\\"越南电信\\\"]\",\"id\":\"238\",\"name\":\"越南电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":239,\"values\":{\"codes\":\"[\\\"2Degrees\\\"]\",\"id\":\"239\",\"name\":\"2Degrees\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":240,\"values\":{\"codes\":\"[\\\"湄公网\\\"]\",\"id\":\"240\",\"name\":\"湄公网\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":241,\"values\":{\"codes\":\"[\\\"大连理工大学\\\"]\",\"id\":\"241\",\"name\":\"大连理工大学\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":242,\"values\":{\"codes\":\"[\\\"山东青岛广电\\\"]\",\"id\":\"242\",\"name\":\"山东青岛广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":243,\"values\":{\"codes\":\"[\\\"浙江华数\\\"]\",\"id\":\"243\",\"name\":\"浙江华数\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":244,\"values\":{\"codes\":\"[\\\"Spark-New-Zealand\\\"]\",\"id\":\"244\",\"name\":\"Spark-New-Zealand\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":245,\"values\":{\"codes\":\"[\\\"珠江宽频\\\"]\",\"id\":\"245\",\"name\":\"珠江宽频\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":246,\"values\":{\"codes\":\"[\\\"海南广电\\\"]\",\"id\":\"246\",\"name\":\"海南广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":247,\"values\":{\"codes\":\"[\\\"香港流动通讯\\\"]\",\"id\":\"247\",\"name\":\"香港流动通讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":248,\"values\":{\"codes\":\"[\\\"墨西哥电信\\\"]\",\"id\":\"248\",\"name\":\"墨西哥电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":249,\"values\":{\"codes\":\"[\\\"柬埔寨信威\\\"]\",\"id\":\"249\",\"name\":\"柬埔寨信威\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":250,\"values\":{\"codes\":\"[\\\"MIX\\\"]\",\"id\":\"250\",\"name\":\"MIX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":251,\"values\":{\"codes\":\"[\\\"Linx\\\"]\",\"id\":\"251\",\"name\":\"Linx\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":252,\"values\":{\"codes\":\"[\\\"TPIX\\\"]\",\"id\":\"252\",\"name\":\"TPIX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":253,\"values\":{\"codes\":\"[\\\"Movistar\\\"]\",\"id\":\"253\",\"name\":\"Movistar\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":254,\"values\":{\"codes\":\"[\\\"墨西哥美洲移动\\\"]\",\"id\":\"254\",\"name\":\"墨西哥美洲移动\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":255,\"values\":{\"codes\":\"[\\\"互联优势\\\"]\",\"id\":\"255\",\"name\":\"互联优势\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":256,\"values\":{\"codes\":\"[\\\"上海教委\\\"]\",\"id\":\"256\",\"name\":\"上海教委\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":257,\"values\":{\"codes\":\"[\\\"福建广电\\\"]\",\"id\":\"257\",\"name\":\"福建广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":258,\"values\":{\"codes\":\"[\\\"内网IP\\\"]\",\"id\":\"258\",\"name\":\"内网IP\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]}]},{\"name\":\"edgeRegionProvinces\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeRegionProvinces` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `countryId` int(11) unsigned DEFAULT '0' COMMENT '国家ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `dataId` varchar(255) DEFAULT NULL COMMENT '原始数据ID',\\n  PRIMARY KEY (`id`),\\n  KEY `countryId` (`countryId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='区域-省份'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"countryId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '国家ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"dataId\",\"definition\":\"varchar(255) COMMENT '原始数据ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"countryId\",\"definition\":\"KEY `countryId` (`countryId`) USING BTREE\"}],\"records\":[{\"id\":1,\"values\":{\"codes\":\"[\\\"北京市\\\", \\\"北京\\\"]\",\"countryId\":\"1\",\"dataId\":\"214\",\"id\":\"1\",\"name\":\"北京市\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":2,\"values\":{\"codes\":\"[\\\"天津市\\\", \\\"天津\\\"]\",\"countryId\":\"1\",\"dataId\":\"234\",\"id\":\"2\",\"name\":\"天津市\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":3,\"values\":{\"codes\":\"[\\\"河北省\\\"]\",\"countryId\":\"1\",\"dataId\":\"254\",\"id\":\"3\",\"name\":\"河北省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":4,\"values\":{\"codes\":\"[\\\"山西省\\\"]\",\"countryId\":\"1\",\"dataId\":\"433\",\"id\":\"4\",\"name\":\"山西省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":5,\"values\":{\"codes\":\"[\\\"内蒙古自治区\\\", \\\"内蒙古\\\"]\",\"countryId\":\"1\",\"dataId\":\"559\",\"id\":\"5\",\"name\":\"内蒙古自治区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":6,\"values\":{\"codes\":\"[\\\"辽宁省\\\"]\",\"countryId\":\"1\",\"dataId\":\"673\",\"id\":\"6\",\"name\":\"辽宁省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":7,\"values\":{\"codes\":\"[\\\"吉林省\\\"]\",\"countryId\":\"1\",\"dataId\":\"786\",\"id\":\"7\",\"name\":\"吉林省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":8,\"values\":{\"codes\":\"[\\\"黑龙江省\\\"]\",\"countryId\":\"1\",\"dataId\":\"853\",\"id\":\"8\",\"name\":\"黑龙江省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":9,\"values\":{\"codes\":\"[\\\"上海市\\\", \\\"上海\\\"]\",\"countryId\":\"1\",\"dataId\":\"994\",\"id\":\"9\",\"name\":\"上海市\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":10,\"values\":{\"codes\":\"[\\\"江苏省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1014\",\"id\":\"10\",\"name\":\"江苏省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":11,\"values\":{\"codes\":\"[\\\"浙江省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1131\",\"id\":\"11\",\"name\":\"浙江省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":12,\"values\":{\"codes\":\"[\\\"安徽省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1232\",\"id\":\"12\",\"name\":\"安徽省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":13,\"values\":{\"codes\":\"[\\\"福建省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1353\",\"id\":\"13\",\"name\":\"福建省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":14,\"values\":{\"codes\":\"[\\\"江西省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1447\",\"id\":\"14\",\"name\":\"江西省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":15,\"values\":{\"codes\":\"[\\\"山东省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1557\",\"id\":\"15\",\"name\":\"山东省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":16,\"values\":{\"codes\":\"[\\\"河南省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1712\",\"id\":\"16\",\"name\":\"河南省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":17,\"values\":{\"codes\":\"[\\\"湖北省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1886\",\"id\":\"17\",\"name\":\"湖北省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":18,\"values\":{\"codes\":\"[\\\"湖南省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2002\",\"id\":\"18\",\"name\":\"湖南省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":19,\"values\":{\"codes\":\"[\\\"广东省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2139\",\"id\":\"19\",\"name\":\"广东省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":20,\"values\":{\"codes\":\"[\\\"广西壮族自治区\\\", \\\"广西\\\"]\",\"countryId\":\"1\",\"dataId\":\"2279\",\"id\":\"20\",\"name\":\"广西壮族自治区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":21,\"values\":{\"codes\":\"[\\\"海南省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2403\",\"id\":\"21\",\"name\":\"海南省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":22,\"values\":{\"codes\":\"[\\\"重庆市\\\", \\\"重庆\\\"]\",\"countryId\":\"1\",\"dataId\":\"2429\",\"id\":\"22\",\"name\":\"重庆市\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":23,\"values\":{\"codes\":\"[\\\"四川省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2469\",\"id\":\"23\",\"name\":\"四川省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":24,\"values\":{\"codes\":\"[\\\"贵州省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2669\",\"id\":\"24\",\"name\":\"贵州省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":25,\"values\":{\"codes\":\"[\\\"云南省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2766\",\"id\":\"25\",\"name\":\"云南省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":26,\"values\":{\"codes\":\"[\\\"西藏自治区\\\", \\\"西藏\\\"]\",\"countryId\":\"1\",\"dataId\":\"2912\",\"id\":\"26\",\"name\":\"西藏自治区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":27,\"values\":{\"codes\":\"[\\\"陕西省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2993\",\"id\":\"27\",\"name\":\"陕西省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":28,\"values\":{\"codes\":\"[\\\"甘肃省\\\"]\",\"countryId\":\"1\",\"dataId\":\"3109\",\"id\":\"28\",\"name\":\"甘肃省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":29,\"values\":{\"codes\":\"[\\\"青海省\\\"]\",\"countryId\":\"1\",\"dataId\":\"3209\",\"id\":\"29\",\"name\":\"青海省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":30,\"values\":{\"codes\":\"[\\\"宁夏回族自治区\\\", \\\"宁夏\\\"]\",\"countryId\":\"1\",\"dataId\":\"3260\",\"id\":\"30\",\"name\":\"宁夏回族自治区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":31,\"values\":{\"codes\":\"[\\\"新疆维吾尔自治区\\\", \\\"新疆\\\"]\",\"countryId\":\"1\",\"dataId\":\"3287\",\"id\":\"31\",\"name\":\"新疆维吾尔自治区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":32,\"values\":{\"codes\":\"[\\\"香港特别行政区\\\", \\\"香港\\\"]\",\"countryId\":\"1\",\"dataId\":\"3399\",\"id\":\"32\",\"name\":\"香港特别行政区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":33,\"values\":{\"codes\":\"[\\\"澳门特别行政区\\\", \\\"澳门\\\"]\",\"countryId\":\"1\",\"dataId\":\"3401\",\"id\":\"33\",\"name\":\"澳门特别行政区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":34,\"values\":{\"codes\":\"[\\\"台湾省\\\"]\",\"countryId\":\"1\",\"dataId\":\"3403\",\"id\":\"34\",\"name\":\"台湾省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]}]},{\"name\":\"edgeReverseProxies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeReverseProxies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `scheduling` json DEFAULT NULL COMMENT '调度算法',\\n  `primaryOrigins` json DEFAULT NULL COMMENT '主要源站',\\n  `backupOrigins` json DEFAULT NULL COMMENT '备用源站',\\n  `stripPrefix` varchar(255) DEFAULT NULL COMMENT '去除URL前缀',\\n  `requestHostType` tinyint(1) unsigned DEFAULT '0' COMMENT '请求Host类型',\\n  `requestHost` varchar(255) DEFAULT NULL COMMENT '请求Host',\\n  `requestURI` varchar(1024) DEFAULT NULL COMMENT '请求URI',\\n  `autoFlush` tinyint(1) unsigned DEFAULT '0' COMMENT '是否自动刷新缓冲区',\\n  `addHeaders` json DEFAULT NULL COMMENT '自动添加的Header列表',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `connTimeout` json DEFAULT NULL COMMENT '连接超时时间',\\n  `readTimeout` json DEFAULT NULL COMMENT '读取超时时间',\\n  `idleTimeout` json DEFAULT NULL COMMENT '空闲超时时间',\\n  `maxConns` int(11) unsigned DEFAULT '0' COMMENT '最大并发连接数',\\n  `maxIdleConns` int(11) unsigned DEFAULT '0' COMMENT '最大空闲连接数',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='反向代理配置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"scheduling\",\"definition\":\"json COMMENT '调度算法'\"},{\"name\":\"primaryOrigins\",\"definition\":\"json COMMENT '主要源站'\"},{\"name\":\"backupOrigins\",\"definition\":\"json COMMENT '备用源站'\"},{\"name\":\"stripPrefix\",\"definition\":\"varchar(255) COMMENT '去除URL前缀'\"},{\"name\":\"requestHostType\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '请求Host类型'\"},{\"name\":\"requestHost\",\"definition\":\"varchar(255) COMMENT '请求Host'\"},{\"name\":\"requestURI\",\"definition\":\"varchar(1024) COMMENT '请求URI'\"},{\"name\":\"autoFlush\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否自动刷新缓冲区'\"},{\"name\":\"addHeaders\",\"definition\":\"json COMMENT '自动添加的Header列表'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"connTimeout\",\"definition\":\"json COMMENT '连接超时时间'\"},{\"name\":\"readTimeout\",\"definition\":\"json COMMENT '读取超时时间'\"},{\"name\":\"idleTimeout\",\"definition\":\"json COMMENT '空闲超时时间'\"},{\"name\":\"maxConns\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最大并发连接数'\"},{\"name\":\"maxIdleConns\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最大空闲连接数'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSSLCertGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSSLCertGroups` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '分组名',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '分组排序',\\n  `state` tinyint(1) unsigned DEFAULT '0' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='证书分组'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '分组名'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '分组排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSSLCerts\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSSLCerts` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '证书名',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `certData` blob COMMENT '证书内容',\\n  `keyData` blob COMMENT '密钥内容',\\n  `serverName` varchar(255) DEFAULT NULL COMMENT '证书使用的主机名',\\n  `isCA` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为CA证书',\\n  `groupIds` json DEFAULT NULL COMMENT '证书分组',\\n  `timeBeginAt` bigint(11) unsigned DEFAULT '0' COMMENT '开始时间',\\n  `timeEndAt` bigint(11) unsigned DEFAULT '0' COMMENT '结束时间',\\n  `dnsNames` json DEFAULT NULL COMMENT 'DNS名称列表',\\n  `commonNames` json DEFAULT NULL COMMENT '发行单位列表',\\n  `isACME` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为ACME自动生成的',\\n  `acmeTaskId` bigint(11) unsigned DEFAULT '0' COMMENT 'ACME任务ID',\\n  `notifiedAt` bigint(11) unsigned DEFAULT '0' COMMENT '最后通知时间',\\n  PRIMARY KEY (`id`),\\n  KEY `adminId` (`adminId`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='SSL证书'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '证书名'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"certData\",\"definition\":\"blob COMMENT '证书内容'\"},{\"name\":\"keyData\",\"definition\":\"blob COMMENT '密钥内容'\"},{\"name\":\"serverName\",\"definition\":\"varchar(255) COMMENT '证书使用的主机名'\"},{\"name\":\"isCA\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为CA证书'\"},{\"name\":\"groupIds\",\"definition\":\"json COMMENT '证书分组'\"},{\"name\":\"timeBeginAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '开始时间'\"},{\"name\":\"timeEndAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '结束时间'\"},{\"name\":\"dnsNames\",\"definition\":\"json COMMENT 'DNS名称列表'\"},{\"name\":\"commonNames\",\"definition\":\"json COMMENT '发行单位列表'\"},{\"name\":\"isACME\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为ACME自动生成的'\"},{\"name\":\"acmeTaskId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT 'ACME任务ID'\"},{\"name\":\"notifiedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '最后通知时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSSLPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSSLPolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `certs` json DEFAULT NULL COMMENT '证书列表',\\n  `clientCACerts` json DEFAULT NULL COMMENT '客户端证书',\\n  `clientAuthType` int(11) unsigned DEFAULT '0' COMMENT '客户端认证类型',\\n  `minVersion` varchar(32) DEFAULT NULL COMMENT '支持的SSL最小版本',\\n  `cipherSuitesIsOn` tinyint(1) unsigned DEFAULT '0' COMMENT '是否自定义加密算法套件',\\n  `cipherSuites` json DEFAULT NULL COMMENT '加密算法套件',\\n  `hsts` json DEFAULT NULL COMMENT 'HSTS设置',\\n  `http2Enabled` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用HTTP/2',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='SSL配置策略'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"certs\",\"definition\":\"json COMMENT '证书列表'\"},{\"name\":\"clientCACerts\",\"definition\":\"json COMMENT '客户端证书'\"},{\"name\":\"clientAuthType\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '客户端认证类型'\"},{\"name\":\"minVersion\",\"definition\":\"varchar(32) COMMENT '支持的SSL最小版本'\"},{\"name\":\"cipherSuitesIsOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否自定义加密算法套件'\"},{\"name\":\"cipherSuites\",\"definition\":\"json COMMENT '加密算法套件'\"},{\"name\":\"hsts\",\"definition\":\"json COMMENT 'HSTS设置'\"},{\"name\":\"http2Enabled\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用HTTP/2'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerClientBrowserMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerClientBrowserMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `browserId` int(11) unsigned DEFAULT '0' COMMENT '浏览器ID',\\n  `month` varchar(6) DEFAULT NULL COMMENT 'YYYYMM',\\n  `version` varchar(255) DEFAULT NULL COMMENT '主版本号',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`serverId`,`browserId`,`version`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='浏览器统计（按月）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"browserId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '浏览器ID'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT 'YYYYMM'\"},{\"name\":\"version\",\"definition\":\"varchar(255) COMMENT '主版本号'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`serverId`,`browserId`,`version`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerClientSystemMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerClientSystemMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `systemId` int(11) unsigned DEFAULT '0' COMMENT '系统ID',\\n  `version` varchar(255) DEFAULT NULL COMMENT '主版本号',\\n  `month` varchar(6) DEFAULT NULL COMMENT 'YYYYMM',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`serverId`,`systemId`,`version`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='操作系统统计（按月）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"systemId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '系统ID'\"},{\"name\":\"version\",\"definition\":\"varchar(255) COMMENT '主版本号'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT 'YYYYMM'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`serverId`,`systemId`,`version`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerDailyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerDailyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `regionId` int(11) unsigned DEFAULT '0' COMMENT '区域ID',\\n  `bytes` bigint(20) unsigned DEFAULT '0' COMMENT '流量',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存的流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  `day` varchar(8) DEFAULT NULL COMMENT '日期YYYYMMDD',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `timeFrom` varchar(6) DEFAULT NULL COMMENT '开始时间HHMMSS',\\n  `timeTo` varchar(6) DEFAULT NULL COMMENT '结束时间',\\n  `isCharged` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已计算费用',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `sererId_regionId_day_timeFrom` (`serverId`,`regionId`,`day`,`timeFrom`),\\n  KEY `serverId_day` (`serverId`,`day`) USING BTREE,\\n  KEY `isCharged` (`isCharged`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='计费流量统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"regionId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '区域ID'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '流量'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存的流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT '日期YYYYMMDD'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"timeFrom\",\"definition\":\"varchar(6) COMMENT '开始时间HHMMSS'\"},{\"name\":\"timeTo\",\"definition\":\"varchar(6) COMMENT '结束时间'\"},{\"name\":\"isCharged\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已计算费用'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"sererId_regionId_day_timeFrom\",\"definition\":\"UNIQUE KEY `sererId_regionId_day_timeFrom` (`serverId`,`regionId`,`day`,`timeFrom`) USING BTREE\"},{\"name\":\"serverId_day\",\"definition\":\"KEY `serverId_day` (`serverId`,`day`) USING BTREE\"},{\"name\":\"isCharged\",\"definition\":\"KEY `isCharged` (`isCharged`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerDomainHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerDomainHourlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `domain` varchar(255) DEFAULT NULL COMMENT '域名',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `bytes` bigint(20) unsigned DEFAULT '0' COMMENT '流量',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `domain_hour` (`clusterId`,`serverId`,`domain`,`hour`) USING BTREE,\\n  KEY `clusterId` (`clusterId`),\\n  KEY `serverId` (`serverId`),\\n  KEY `nodeId` (`nodeId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务域名统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"domain\",\"definition\":\"varchar(255) COMMENT '域名'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '流量'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"domain_hour\",\"definition\":\"UNIQUE KEY `domain_hour` (`clusterId`,`serverId`,`domain`,`hour`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerGroups` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务分组'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerHTTPFirewallDailyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerHTTPFirewallDailyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `httpFirewallRuleGroupId` int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID',\\n  `action` varchar(64) DEFAULT NULL COMMENT '采取的动作',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`serverId`,`day`,`httpFirewallRuleGroupId`,`action`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='WAF统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"httpFirewallRuleGroupId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID'\"},{\"name\":\"action\",\"definition\":\"varchar(64) COMMENT '采取的动作'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`serverId`,`day`,`httpFirewallRuleGroupId`,`action`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerHTTPFirewallHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerHTTPFirewallHourlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `httpFirewallRuleGroupId` int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID',\\n  `action` varchar(64) DEFAULT NULL COMMENT '采取的动作',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`serverId`,`hour`,`httpFirewallRuleGroupId`,`action`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='WAF统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"httpFirewallRuleGroupId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID'\"},{\"name\":\"action\",\"definition\":\"varchar(64) COMMENT '采取的动作'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`serverId`,`hour`,`httpFirewallRuleGroupId`,`action`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerRegionCityMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerRegionCityMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `cityId` int(11) unsigned DEFAULT '0' COMMENT '城市ID',\\n  `month` varchar(6) DEFAULT NULL COMMENT '月份YYYYMM',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `unique_id` (`serverId`,`cityId`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务用户省份分布统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"cityId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '城市ID'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT '月份YYYYMM'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"unique_id\",\"definition\":\"UNIQUE KEY `unique_id` (`serverId`,`cityId`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerRegionCountryMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerRegionCountryMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `countryId` int(11) unsigned DEFAULT '0' COMMENT '国家/区域ID',\\n  `month` varchar(6) DEFAULT NULL COMMENT '月份YYYYMM',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `unique_id` (`serverId`,`countryId`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务用户区域分布统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"countryId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '国家/区域ID'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT '月份YYYYMM'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"unique_id\",\"definition\":\"UNIQUE KEY `unique_id` (`serverId`,`countryId`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerRegionProviderMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerRegionProviderMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `providerId` int(11) unsigned DEFAULT '0' COMMENT '运营商ID',\\n  `month` varchar(6) DEFAULT NULL COMMENT '月份YYYYMM',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `unique_id` (`serverId`,`providerId`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务用户省份分布统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"providerId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '运营商ID'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT '月份YYYYMM'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"unique_id\",\"definition\":\"UNIQUE KEY `unique_id` (`serverId`,`providerId`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerRegionProvinceMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerRegionProvinceMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `provinceId` int(11) unsigned DEFAULT '0' COMMENT '省份ID',\\n  `month` varchar(6) DEFAULT NULL COMMENT '月份YYYYMM',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `unique_id` (`serverId`,`provinceId`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务用户省份分布统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"provinceId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '省份ID'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT '月份YYYYMM'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"unique_id\",\"definition\":\"UNIQUE KEY `unique_id` (`serverId`,`provinceId`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerStatBoardCharts\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerStatBoardCharts` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `boardId` bigint(11) unsigned DEFAULT '0' COMMENT '看板ID',\\n  `code` varchar(255) DEFAULT NULL COMMENT '内置图表代码',\\n  `itemId` int(11) unsigned DEFAULT '0' COMMENT '指标ID',\\n  `chartId` int(11) unsigned DEFAULT '0' COMMENT '图表ID',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `boardId` (`boardId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务看板中的图表'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"boardId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '看板ID'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '内置图表代码'\"},{\"name\":\"itemId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '指标ID'\"},{\"name\":\"chartId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '图表ID'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"boardId\",\"definition\":\"KEY `boardId` (`boardId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerStatBoards\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerStatBoards` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务统计看板'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServers` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `type` varchar(64) DEFAULT NULL COMMENT '服务类型',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(512) DEFAULT NULL COMMENT '描述',\\n  `serverNames` json DEFAULT NULL COMMENT '域名列表',\\n  `auditingServerNames` json DEFAULT NULL COMMENT '审核中的域名',\\n  `isAuditing` tinyint(1) unsigned DEFAULT '0' COMMENT '是否正在审核',\\n  `auditingResult` json DEFAULT NULL COMMENT '审核结果',\\n  `http` json DEFAULT NULL COMMENT 'HTTP配置',\\n  `https` json DEFAULT NULL COMMENT 'HTTPS配置',\\n  `tcp` json DEFAULT NULL COMMENT 'TCP配置',\\n  `tls` json DEFAULT NULL COMMENT 'TLS配置',\\n  `unix` json DEFAULT NULL COMMENT 'Unix配置',\\n  `udp` json DEFAULT NULL COMMENT 'UDP配置',\\n  `webId` int(11) unsigned DEFAULT '0' COMMENT 'WEB配置',\\n  `reverseProxy` json DEFAULT NULL COMMENT '反向代理配置',\\n  `groupIds` json DEFAULT NULL COMMENT '分组ID列表',\\n  `config` json DEFAULT NULL COMMENT '服务配置，自动生成',\\n  `configMd5` varchar(32) DEFAULT NULL COMMENT 'Md5',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `includeNodes` json DEFAULT NULL COMMENT '部署条件',\\n  `excludeNodes` json DEFAULT NULL COMMENT '节点排除条件',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '版本号',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `dnsName` varchar(255) DEFAULT NULL COMMENT 'DNS名称',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `adminId` (`adminId`),\\n  KEY `isUpdating_state` (`state`) USING BTREE,\\n  KEY `dnsName` (`dnsName`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `isAuditing` (`isAuditing`),\\n  KEY `webId` (`webId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"type\",\"definition\":\"varchar(64) COMMENT '服务类型'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '描述'\"},{\"name\":\"serverNames\",\"definition\":\"json COMMENT '域名列表'\"},{\"name\":\"auditingServerNames\",\"definition\":\"json COMMENT '审核中的域名'\"},{\"name\":\"isAuditing\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否正在审核'\"},{\"name\":\"auditingResult\",\"definition\":\"json COMMENT '审核结果'\"},{\"name\":\"http\",\"definition\":\"json COMMENT 'HTTP配置'\"},{\"name\":\"https\",\"definition\":\"json COMMENT 'HTTPS配置'\"},{\"name\":\"tcp\",\"definition\":\"json COMMENT 'TCP配置'\"},{\"name\":\"tls\",\"definition\":\"json COMMENT 'TLS配置'\"},{\"name\":\"unix\",\"definition\":\"json COMMENT 'Unix配置'\"},{\"name\":\"udp\",\"definition\":\"json COMMENT 'UDP配置'\"},{\"name\":\"webId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WEB配置'\"},{\"name\":\"reverseProxy\",\"definition\":\"json COMMENT '反向代理配置'\"},{\"name\":\"groupIds\",\"definition\":\"json COMMENT '分组ID列表'\"},{\"name\":\"config\",\"definition\":\"json COMMENT '服务配置，自动生成'\"},{\"name\":\"configMd5\",\"definition\":\"varchar(32) COMMENT 'Md5'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"includeNodes\",\"definition\":\"json COMMENT '部署条件'\"},{\"name\":\"excludeNodes\",\"definition\":\"json COMMENT '节点排除条件'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"dnsName\",\"definition\":\"varchar(255) COMMENT 'DNS名称'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"},{\"name\":\"isUpdating_state\",\"definition\":\"KEY `isUpdating_state` (`state`) USING BTREE\"},{\"name\":\"dnsName\",\"definition\":\"KEY `dnsName` (`dnsName`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"isAuditing\",\"definition\":\"KEY `isAuditing` (`isAuditing`) USING BTREE\"},{\"name\":\"webId\",\"definition\":\"KEY `webId` (`webId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSubUsers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSubUsers` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '所属主用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `username` varchar(255) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(32) DEFAULT NULL COMMENT '密码',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='子用户'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '所属主用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"username\",\"definition\":\"varchar(255) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(32) COMMENT '密码'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSysEvents\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSysEvents` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='系统事件'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSysLockers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSysLockers` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `key` varchar(255) DEFAULT NULL COMMENT '键值',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本号',\\n  `timeoutAt` bigint(11) unsigned DEFAULT '0' COMMENT '超时时间',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `key` (`key`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='并发锁'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"key\",\"definition\":\"varchar(255) COMMENT '键值'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"timeoutAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '超时时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"key\",\"definition\":\"UNIQUE KEY `key` (`key`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSysSettings\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSysSettings` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `value` json DEFAULT NULL COMMENT '配置值',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `code` (`code`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='系统配置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"value\",\"definition\":\"json COMMENT '配置值'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"code\",\"definition\":\"UNIQUE KEY `code` (`code`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeTCPFirewallPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeTCPFirewallPolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) DEFAULT NULL COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='TCP防火墙'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeTrafficDailyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeTrafficDailyStats` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `bytes` bigint(20) unsigned DEFAULT NULL COMMENT '流量字节',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击量',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `day` (`day`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='总的流量统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned COMMENT '流量字节'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击量'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"UNIQUE KEY `day` (`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeTrafficHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeTrafficHourlyStats` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `bytes` bigint(20) unsigned DEFAULT NULL COMMENT '流量字节',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `hour` (`hour`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='总的流量统计（按小时）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned COMMENT '流量字节'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"hour\",\"definition\":\"UNIQUE KEY `hour` (`hour`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeUserAccessKeys\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeUserAccessKeys` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `subUserId` int(11) unsigned DEFAULT '0' COMMENT '子用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一的Key',\\n  `secret` varchar(64) DEFAULT NULL COMMENT '密钥',\\n  `description` varchar(255) DEFAULT NULL COMMENT '备注',\\n  `accessedAt` bigint(11) unsigned DEFAULT '0' COMMENT '最近一次访问时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `subUserId` (`subUserId`),\\n  KEY `uniqueId` (`uniqueId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='AccessKey'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"subUserId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '子用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一的Key'\"},{\"name\":\"secret\",\"definition\":\"varchar(64) COMMENT '密钥'\"},{\"name\":\"description\",\"definition\":\"varchar(255) COMMENT '备注'\"},{\"name\":\"accessedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '最近一次访问时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"subUserId\",\"definition\":\"KEY `subUserId` (`subUserId`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"KEY `uniqueId` (`uniqueId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeUserBills\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeUserBills` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '消费类型',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `amount` decimal(11,2) unsigned DEFAULT '0.00' COMMENT '消费数额',\\n  `month` varchar(6) DEFAULT NULL COMMENT '帐期YYYYMM',\\n  `isPaid` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已支付',\\n  `paidAt` bigint(11) unsigned DEFAULT '0' COMMENT '支付时间',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户账单'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '消费类型'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"amount\",\"definition\":\"decimal(11,2) unsigned DEFAULT '0.00' COMMENT '消费数额'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT '帐期YYYYMM'\"},{\"name\":\"isPaid\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已支付'\"},{\"name\":\"paidAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '支付时间'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeUserNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeUserNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `http` json DEFAULT NULL COMMENT '监听的HTTP配置',\\n  `https` json DEFAULT NULL COMMENT '监听的HTTPS配置',\\n  `accessAddrs` json DEFAULT NULL COMMENT '外部访问地址',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `status` json DEFAULT NULL COMMENT '运行状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='API节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"http\",\"definition\":\"json COMMENT '监听的HTTP配置'\"},{\"name\":\"https\",\"definition\":\"json COMMENT '监听的HTTPS配置'\"},{\"name\":\"accessAddrs\",\"definition\":\"json COMMENT '外部访问地址'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '运行状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeUsers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeUsers` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `username` varchar(64) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(32) DEFAULT NULL COMMENT '密码',\\n  `fullname` varchar(64) DEFAULT NULL COMMENT '真实姓名',\\n  `mobile` varchar(11) DEFAULT NULL COMMENT '手机号',\\n  `tel` varchar(255) DEFAULT NULL COMMENT '联系电话',\\n  `remark` varchar(1024) DEFAULT NULL COMMENT '备注',\\n  `email` varchar(255) DEFAULT NULL COMMENT '邮箱地址',\\n  `avatarFileId` bigint(11) unsigned DEFAULT '0' COMMENT '头像文件ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `source` varchar(255) DEFAULT NULL COMMENT '来源',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `features` json DEFAULT NULL COMMENT '允许操作的特征',\\n  PRIMARY KEY (`id`),\\n  KEY `username` (`username`),\\n  KEY `day` (`day`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"username\",\"definition\":\"varchar(64) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(32) COMMENT '密码'\"},{\"name\":\"fullname\",\"definition\":\"varchar(64) COMMENT '真实姓名'\"},{\"name\":\"mobile\",\"definition\":\"varchar(11) COMMENT '手机号'\"},{\"name\":\"tel\",\"definition\":\"varchar(255) COMMENT '联系电话'\"},{\"name\":\"remark\",\"definition\":\"varchar(1024) COMMENT '备注'\"},{\"name\":\"email\",\"definition\":\"varchar(255) COMMENT '邮箱地址'\"},{\"name\":\"avatarFileId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '头像文件ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"source\",\"definition\":\"varchar(255) COMMENT '来源'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"features\",\"definition\":\"json COMMENT '允许操作的特征'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"username\",\"definition\":\"KEY `username` (`username`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeVersions\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeVersions` (\\n  `id` bigint(16) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `version` varchar(64) DEFAULT NULL,\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='数据库结构版本'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(16) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"version\",\"definition\":\"varchar(64)\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]}]}"), LatestSQLResult)
+	err := json.Unmarshal([]byte("{\"tables\":[{\"name\":\"edgeACMEAuthentications\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeACMEAuthentications` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `taskId` bigint(11) unsigned DEFAULT '0' COMMENT '任务ID',\\n  `type` varchar(32) DEFAULT NULL COMMENT '认证类型',\\n  `domain` varchar(255) DEFAULT NULL COMMENT '域名',\\n  `token` varchar(255) DEFAULT NULL COMMENT '令牌',\\n  `key` varchar(1024) DEFAULT NULL COMMENT '密钥',\\n  `certData` text COMMENT 'TLS-ALPN认证证书',\\n  `keyData` text COMMENT 'TLS-ALPN认证证书私钥',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `token` (`token`),\\n  KEY `domain` (`domain`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME认证'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"taskId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '任务ID'\"},{\"name\":\"type\",\"definition\":\"varchar(32) COMMENT '认证类型'\"},{\"name\":\"domain\",\"definition\":\"varchar(255) COMMENT '域名'\"},{\"name\":\"token\",\"definition\":\"varchar(255) COMMENT '令牌'\"},{\"name\":\"key\",\"definition\":\"varchar(1024) COMMENT '密钥'\"},{\"name\":\"certData\",\"definition\":\"text COMMENT 'TLS-ALPN认证证书'\"},{\"name\":\"keyData\",\"definition\":\"text COMMENT 'TLS-ALPN认证证书私钥'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"token\",\"definition\":\"KEY `token` (`token`) USING BTREE\"},{\"name\":\"domain\",\"definition\":\"KEY `domain` (`domain`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeACMETaskLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeACMETaskLogs` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `taskId` bigint(11) unsigned DEFAULT '0' COMMENT '任务ID',\\n  `isOk` tinyint(1) unsigned DEFAULT '1' COMMENT '是否成功',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '错误信息',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '运行时间',\\n  PRIMARY KEY (`id`),\\n  KEY `taskId` (`taskId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME任务运行日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"taskId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '任务ID'\"},{\"name\":\"isOk\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否成功'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '错误信息'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '运行时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"taskId\",\"definition\":\"KEY `taskId` (`taskId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeACMETasks\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeACMETasks` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `acmeUserId` int(11) unsigned DEFAULT '0' COMMENT 'ACME用户ID',\\n  `dnsDomain` varchar(255) DEFAULT NULL COMMENT 'DNS主域名',\\n  `dnsProviderId` bigint(11) unsigned DEFAULT '0' COMMENT 'DNS服务商',\\n  `domains` json DEFAULT NULL COMMENT '证书域名',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `certId` bigint(11) unsigned DEFAULT '0' COMMENT '生成的证书ID',\\n  `autoRenew` tinyint(1) unsigned DEFAULT '0' COMMENT '是否自动更新',\\n  `authType` varchar(64) DEFAULT NULL COMMENT '认证类型',\\n  `authTypes` json DEFAULT NULL COMMENT '按顺序尝试的认证类型',\\n  `authURL` varchar(1024) DEFAULT NULL COMMENT '认证URL',\\n  `keyType` varchar(32) DEFAULT NULL COMMENT '证书私钥类型',\\n  `renewAttempts` int(11) unsigned DEFAULT '0' COMMENT '连续续期失败次数',\\n  `nextRenewAt` bigint(11) unsigned DEFAULT '0' COMMENT '下次可以尝试续期的时间',\\n  PRIMARY KEY (`id`),\\n  KEY `adminId` (`adminId`),\\n  KEY `userId` (`userId`),\\n  KEY `acmeUserId` (`acmeUserId`),\\n  KEY `certId` (`certId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME任务'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"acmeUserId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'ACME用户ID'\"},{\"name\":\"dnsDomain\",\"definition\":\"varchar(255) COMMENT 'DNS主域名'\"},{\"name\":\"dnsProviderId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT 'DNS服务商'\"},{\"name\":\"domains\",\"definition\":\"json COMMENT '证书域名'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"certId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '生成的证书ID'\"},{\"name\":\"autoRenew\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否自动更新'\"},{\"name\":\"authType\",\"definition\":\"varchar(64) COMMENT '认证类型'\"},{\"name\":\"authTypes\",\"definition\":\"json COMMENT '按顺序尝试的认证类型'\"},{\"name\":\"authURL\",\"definition\":\"varchar(1024) COMMENT '认证URL'\"},{\"name\":\"keyType\",\"definition\":\"varchar(32) COMMENT '证书私钥类型'\"},{\"name\":\"renewAttempts\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '连续续期失败次数'\"},{\"name\":\"nextRenewAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '下次可以尝试续期的时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"acmeUserId\",\"definition\":\"KEY `acmeUserId` (`acmeUserId`) USING BTREE\"},{\"name\":\"certId\",\"definition\":\"KEY `certId` (`certId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeACMEUsers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeACMEUsers` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `privateKey` text COMMENT '私钥',\\n  `email` varchar(255) DEFAULT NULL COMMENT 'E-mail',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `description` varchar(512) DEFAULT NULL COMMENT '备注介绍',\\n  `registration` json DEFAULT NULL COMMENT '注册信息',\\n  `directoryURL` varchar(255) DEFAULT NULL COMMENT 'ACME目录URL',\\n  `eab` json DEFAULT NULL COMMENT 'External Account Binding凭证',\\n  PRIMARY KEY (`id`),\\n  KEY `adminId` (`adminId`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='ACME用户'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"privateKey\",\"definition\":\"text COMMENT '私钥'\"},{\"name\":\"email\",\"definition\":\"varchar(255) COMMENT 'E-mail'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '备注介绍'\"},{\"name\":\"registration\",\"definition\":\"json COMMENT '注册信息'\"},{\"name\":\"directoryURL\",\"definition\":\"varchar(255) COMMENT 'ACME目录URL'\"},{\"name\":\"eab\",\"definition\":\"json COMMENT 'External Account Binding凭证'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAPIAccessTokens\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAPIAccessTokens` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `token` varchar(255) DEFAULT NULL COMMENT '令牌',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `expiredAt` bigint(11) unsigned DEFAULT '0' COMMENT '过期时间',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `token` (`token`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='API访问令牌'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"token\",\"definition\":\"varchar(255) COMMENT '令牌'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"expiredAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '过期时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"token\",\"definition\":\"KEY `token` (`token`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAPINodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAPINodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '专用集群ID',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `http` json DEFAULT NULL COMMENT '监听的HTTP配置',\\n  `https` json DEFAULT NULL COMMENT '监听的HTTPS配置',\\n  `restIsOn` tinyint(1) unsigned DEFAULT '0' COMMENT '是否开放REST',\\n  `restHTTP` json DEFAULT NULL COMMENT 'REST HTTP配置',\\n  `restHTTPS` json DEFAULT NULL COMMENT 'REST HTTPS配置',\\n  `accessAddrs` json DEFAULT NULL COMMENT '外部访问地址',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `status` json DEFAULT NULL COMMENT '运行状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='API节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '专用集群ID'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"http\",\"definition\":\"json COMMENT '监听的HTTP配置'\"},{\"name\":\"https\",\"definition\":\"json COMMENT '监听的HTTPS配置'\"},{\"name\":\"restIsOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否开放REST'\"},{\"name\":\"restHTTP\",\"definition\":\"json COMMENT 'REST HTTP配置'\"},{\"name\":\"restHTTPS\",\"definition\":\"json COMMENT 'REST HTTPS配置'\"},{\"name\":\"accessAddrs\",\"definition\":\"json COMMENT '外部访问地址'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '运行状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAPITokens\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAPITokens` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `nodeId` varchar(32) DEFAULT NULL COMMENT '节点ID',\\n  `secret` varchar(255) DEFAULT NULL COMMENT '节点密钥',\\n  `role` varchar(64) DEFAULT NULL COMMENT '节点角色',\\n  `requireEnvelope` tinyint(1) unsigned DEFAULT '0' COMMENT '是否只接受加密信封',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='API令牌管理'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"nodeId\",\"definition\":\"varchar(32) COMMENT '节点ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(255) COMMENT '节点密钥'\"},{\"name\":\"role\",\"definition\":\"varchar(64) COMMENT '节点角色'\"},{\"name\":\"requireEnvelope\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否只接受加密信封'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAdmins\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAdmins` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `username` varchar(64) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(32) DEFAULT NULL COMMENT '密码',\\n  `fullname` varchar(64) DEFAULT NULL COMMENT '全名',\\n  `isSuper` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为超级管理员',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `modules` json DEFAULT NULL COMMENT '允许的模块',\\n  `canLogin` tinyint(1) unsigned DEFAULT '1' COMMENT '是否可以登录',\\n  `theme` varchar(64) DEFAULT NULL COMMENT '模板设置',\\n  PRIMARY KEY (`id`),\\n  KEY `username` (`username`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='管理员'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"username\",\"definition\":\"varchar(64) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(32) COMMENT '密码'\"},{\"name\":\"fullname\",\"definition\":\"varchar(64) COMMENT '全名'\"},{\"name\":\"isSuper\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为超级管理员'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"modules\",\"definition\":\"json COMMENT '允许的模块'\"},{\"name\":\"canLogin\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否可以登录'\"},{\"name\":\"theme\",\"definition\":\"varchar(64) COMMENT '模板设置'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"username\",\"definition\":\"KEY `username` (`username`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAuthorityKeys\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAuthorityKeys` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `value` varchar(4096) DEFAULT NULL COMMENT 'Key值',\\n  `dayFrom` date DEFAULT NULL COMMENT '开始日期',\\n  `dayTo` date DEFAULT NULL COMMENT '结束日期',\\n  `hostname` varchar(255) DEFAULT NULL COMMENT 'Hostname',\\n  `macAddresses` json DEFAULT NULL COMMENT 'MAC地址',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建/修改时间',\\n  `company` varchar(512) DEFAULT NULL COMMENT '公司组织',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='企业版认证信息'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"value\",\"definition\":\"varchar(4096) COMMENT 'Key值'\"},{\"name\":\"dayFrom\",\"definition\":\"date COMMENT '开始日期'\"},{\"name\":\"dayTo\",\"definition\":\"date COMMENT '结束日期'\"},{\"name\":\"hostname\",\"definition\":\"varchar(255) COMMENT 'Hostname'\"},{\"name\":\"macAddresses\",\"definition\":\"json COMMENT 'MAC地址'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建/修改时间'\"},{\"name\":\"company\",\"definition\":\"varchar(512) COMMENT '公司组织'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeAuthorityNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeAuthorityNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `status` json DEFAULT NULL COMMENT '运行状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='监控节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '运行状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeClientBrowsers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeClientBrowsers` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '浏览器名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `name` (`name`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='终端浏览器信息'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '浏览器名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"name\",\"definition\":\"UNIQUE KEY `name` (`name`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeClientSystems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeClientSystems` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '系统名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `name` (`name`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='终端操作系统信息'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '系统名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"name\",\"definition\":\"UNIQUE KEY `name` (`name`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeDBNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeDBNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `role` varchar(255) DEFAULT NULL COMMENT '数据库角色',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `host` varchar(255) DEFAULT NULL COMMENT '主机',\\n  `port` int(11) unsigned DEFAULT '0' COMMENT '端口',\\n  `database` varchar(255) DEFAULT NULL COMMENT '数据库名称',\\n  `username` varchar(255) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(255) DEFAULT NULL COMMENT '密码',\\n  `charset` varchar(255) DEFAULT NULL COMMENT '通讯字符集',\\n  `connTimeout` int(11) unsigned DEFAULT '0' COMMENT '连接超时时间（秒）',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='数据库节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"role\",\"definition\":\"varchar(255) COMMENT '数据库角色'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"host\",\"definition\":\"varchar(255) COMMENT '主机'\"},{\"name\":\"port\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '端口'\"},{\"name\":\"database\",\"definition\":\"varchar(255) COMMENT '数据库名称'\"},{\"name\":\"username\",\"definition\":\"varchar(255) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(255) COMMENT '密码'\"},{\"name\":\"charset\",\"definition\":\"varchar(255) COMMENT '通讯字符集'\"},{\"name\":\"connTimeout\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '连接超时时间（秒）'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeDNSClusterBindings\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeDNSClusterBindings` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `isPrimary` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为集群的主域名',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `isOk` tinyint(1) unsigned DEFAULT '0' COMMENT '最后一次同步是否成功',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '最后一次同步错误',\\n  `syncedAt` bigint(11) unsigned DEFAULT '0' COMMENT '最后同步时间',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `domainId` (`domainId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='集群DNS域名绑定'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"isPrimary\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为集群的主域名'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"isOk\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '最后一次同步是否成功'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '最后一次同步错误'\"},{\"name\":\"syncedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '最后同步时间'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeDNSDomains\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeDNSDomains` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `providerId` int(11) unsigned DEFAULT '0' COMMENT '服务商ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否可用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '域名',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `dataUpdatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '数据更新时间',\\n  `dataError` varchar(512) DEFAULT NULL COMMENT '数据更新错误',\\n  `data` text COMMENT '原始数据信息',\\n  `records` json DEFAULT NULL COMMENT '所有解析记录',\\n  `routes` json DEFAULT NULL COMMENT '线路数据',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `providerId` (`providerId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='管理的域名'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"providerId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务商ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否可用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '域名'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"dataUpdatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '数据更新时间'\"},{\"name\":\"dataError\",\"definition\":\"varchar(512) COMMENT '数据更新错误'\"},{\"name\":\"data\",\"definition\":\"text COMMENT '原始数据信息'\"},{\"name\":\"records\",\"definition\":\"json COMMENT '所有解析记录'\"},{\"name\":\"routes\",\"definition\":\"json COMMENT '线路数据'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"providerId\",\"definition\":\"KEY `providerId` (`providerId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeDNSProviders\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeDNSProviders` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '供应商类型',\\n  `apiParams` json DEFAULT NULL COMMENT 'API参数',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `dataUpdatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '数据同步时间',\\n  PRIMARY KEY (`id`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS服务商'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '供应商类型'\"},{\"name\":\"apiParams\",\"definition\":\"json COMMENT 'API参数'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"dataUpdatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '数据同步时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeDNSTaskLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeDNSTaskLogs` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `taskId` bigint(20) unsigned DEFAULT '0' COMMENT '任务ID',\\n  `taskType` varchar(255) DEFAULT NULL COMMENT '任务类型',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `action` varchar(32) DEFAULT NULL COMMENT '操作：add, update, delete',\\n  `record` json DEFAULT NULL COMMENT '记录',\\n  `oldRecord` json DEFAULT NULL COMMENT '修改前的记录',\\n  `isOk` tinyint(1) unsigned DEFAULT '0' COMMENT '是否成功',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '错误信息',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `day` varchar(8) DEFAULT NULL COMMENT '日期YYYYMMDD',\\n  PRIMARY KEY (`id`),\\n  KEY `domainId` (`domainId`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `serverId` (`serverId`),\\n  KEY `day` (`day`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS同步日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"taskId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '任务ID'\"},{\"name\":\"taskType\",\"definition\":\"varchar(255) COMMENT '任务类型'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"action\",\"definition\":\"varchar(32) COMMENT '操作：add, update, delete'\"},{\"name\":\"record\",\"definition\":\"json COMMENT '记录'\"},{\"name\":\"oldRecord\",\"definition\":\"json COMMENT '修改前的记录'\"},{\"name\":\"isOk\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否成功'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '错误信息'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT '日期YYYYMMDD'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeDNSTasks\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeDNSTasks` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '任务类型',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '更新时间',\\n  `isDone` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成',\\n  `isOk` tinyint(1) unsigned DEFAULT '0' COMMENT '是否成功',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '错误信息',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`clusterId`,`serverId`,`nodeId`,`domainId`,`type`) USING BTREE,\\n  KEY `isDone` (`isDone`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS更新任务'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '任务类型'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '更新时间'\"},{\"name\":\"isDone\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成'\"},{\"name\":\"isOk\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否成功'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '错误信息'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`clusterId`,`serverId`,`nodeId`,`domainId`,`type`) USING BTREE\"},{\"name\":\"isDone\",\"definition\":\"KEY `isDone` (`isDone`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeFileChunks\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeFileChunks` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `fileId` int(11) unsigned DEFAULT '0' COMMENT '文件ID',\\n  `data` longblob COMMENT '分块内容',\\n  PRIMARY KEY (`id`),\\n  KEY `fileId` (`fileId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文件片段'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"fileId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '文件ID'\"},{\"name\":\"data\",\"definition\":\"longblob COMMENT '分块内容'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"fileId\",\"definition\":\"KEY `fileId` (`fileId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeFiles\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeFiles` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `description` varchar(255) DEFAULT NULL COMMENT '文件描述',\\n  `filename` varchar(255) DEFAULT NULL COMMENT '文件名',\\n  `size` int(11) unsigned DEFAULT '0' COMMENT '文件尺寸',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `type` varchar(64) DEFAULT '' COMMENT '类型',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `isFinished` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成上传',\\n  `isPublic` tinyint(1) unsigned DEFAULT '0' COMMENT '是否可以公开访问',\\n  PRIMARY KEY (`id`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文件管理'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"description\",\"definition\":\"varchar(255) COMMENT '文件描述'\"},{\"name\":\"filename\",\"definition\":\"varchar(255) COMMENT '文件名'\"},{\"name\":\"size\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '文件尺寸'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"type\",\"definition\":\"varchar(64) COMMENT '类型'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"isFinished\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成上传'\"},{\"name\":\"isPublic\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否可以公开访问'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPAccessLogPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPAccessLogPolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `type` varchar(255) DEFAULT NULL COMMENT '存储类型',\\n  `options` json DEFAULT NULL COMMENT '存储选项',\\n  `conds` json DEFAULT NULL COMMENT '请求条件',\\n  `isPublic` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为公用',\\n  `version` int(10) unsigned DEFAULT '0' COMMENT '版本号',\\n  PRIMARY KEY (`id`),\\n  KEY `isPublic` (`isPublic`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='访问日志策略'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '存储类型'\"},{\"name\":\"options\",\"definition\":\"json COMMENT '存储选项'\"},{\"name\":\"conds\",\"definition\":\"json COMMENT '请求条件'\"},{\"name\":\"isPublic\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为公用'\"},{\"name\":\"version\",\"definition\":\"int(10) unsigned DEFAULT '0' COMMENT '版本号'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"isPublic\",\"definition\":\"KEY `isPublic` (`isPublic`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPAccessLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPAccessLogs` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `status` int(3) unsigned DEFAULT '0' COMMENT '状态码',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `content` json DEFAULT NULL COMMENT '日志内容',\\n  `requestId` varchar(128) DEFAULT NULL COMMENT '请求ID',\\n  `firewallPolicyId` int(11) unsigned DEFAULT '0' COMMENT 'WAF策略ID',\\n  `firewallRuleGroupId` int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID',\\n  `firewallRuleSetId` int(11) unsigned DEFAULT '0' COMMENT 'WAF集ID',\\n  `firewallRuleId` int(11) unsigned DEFAULT '0' COMMENT 'WAF规则ID',\\n  `remoteAddr` varchar(64) DEFAULT NULL COMMENT 'IP地址',\\n  `domain` varchar(128) DEFAULT NULL COMMENT '域名',\\n  PRIMARY KEY (`id`),\\n  KEY `serverId` (`serverId`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `serverId_status` (`serverId`,`status`),\\n  KEY `requestId` (`requestId`),\\n  KEY `firewallPolicyId` (`firewallPolicyId`),\\n  KEY `firewallRuleGroupId` (`firewallRuleGroupId`),\\n  KEY `firewallRuleSetId` (`firewallRuleSetId`),\\n  KEY `firewallRuleId` (`firewallRuleId`),\\n  KEY `remoteAddr` (`remoteAddr`),\\n  KEY `domain` (`domain`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='访问日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"status\",\"definition\":\"int(3) unsigned DEFAULT '0' COMMENT '状态码'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"content\",\"definition\":\"json COMMENT '日志内容'\"},{\"name\":\"requestId\",\"definition\":\"varchar(128) COMMENT '请求ID'\"},{\"name\":\"firewallPolicyId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF策略ID'\"},{\"name\":\"firewallRuleGroupId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID'\"},{\"name\":\"firewallRuleSetId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF集ID'\"},{\"name\":\"firewallRuleId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF规则ID'\"},{\"name\":\"remoteAddr\",\"definition\":\"varchar(64) COMMENT 'IP地址'\"},{\"name\":\"domain\",\"definition\":\"varchar(128) COMMENT '域名'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"serverId_status\",\"definition\":\"KEY `serverId_status` (`serverId`,`status`) USING BTREE\"},{\"name\":\"requestId\",\"definition\":\"KEY `requestId` (`requestId`) USING BTREE\"},{\"name\":\"firewallPolicyId\",\"definition\":\"KEY `firewallPolicyId` (`firewallPolicyId`) USING BTREE\"},{\"name\":\"firewallRuleGroupId\",\"definition\":\"KEY `firewallRuleGroupId` (`firewallRuleGroupId`) USING BTREE\"},{\"name\":\"firewallRuleSetId\",\"definition\":\"KEY `firewallRuleSetId` (`firewallRuleSetId`) USING BTREE\"},{\"name\":\"firewallRuleId\",\"definition\":\"KEY `firewallRuleId` (`firewallRuleId`) USING BTREE\"},{\"name\":\"remoteAddr\",\"definition\":\"KEY `remoteAddr` (`remoteAddr`) USING BTREE\"},{\"name\":\"domain\",\"definition\":\"KEY `domain` (`domain`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPAuthPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPAuthPolicies` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(128) DEFAULT NULL COMMENT '名称',\\n  `type` varchar(32) DEFAULT NULL COMMENT '类型',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='HTTP认证策略'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(128) COMMENT '名称'\"},{\"name\":\"type\",\"definition\":\"varchar(32) COMMENT '类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPCachePolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPCachePolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `capacity` json DEFAULT NULL COMMENT '容量数据',\\n  `maxKeys` bigint(20) unsigned DEFAULT '0' COMMENT '最多Key值',\\n  `maxSize` json DEFAULT NULL COMMENT '最大缓存内容尺寸',\\n  `type` varchar(255) DEFAULT NULL COMMENT '存储类型',\\n  `options` json DEFAULT NULL COMMENT '存储选项',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `refs` json DEFAULT NULL COMMENT '默认的缓存设置',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='HTTP缓存策略'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"capacity\",\"definition\":\"json COMMENT '容量数据'\"},{\"name\":\"maxKeys\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '最多Key值'\"},{\"name\":\"maxSize\",\"definition\":\"json COMMENT '最大缓存内容尺寸'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '存储类型'\"},{\"name\":\"options\",\"definition\":\"json COMMENT '存储选项'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"refs\",\"definition\":\"json COMMENT '默认的缓存设置'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPFastcgis\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPFastcgis` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `address` varchar(255) DEFAULT NULL COMMENT '地址',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `readTimeout` json DEFAULT NULL COMMENT '读取超时',\\n  `connTimeout` json DEFAULT NULL COMMENT '连接超时',\\n  `poolSize` int(11) unsigned DEFAULT '0' COMMENT '连接池尺寸',\\n  `pathInfoPattern` varchar(512) DEFAULT NULL COMMENT 'PATH_INFO匹配',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Fastcgi设置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"address\",\"definition\":\"varchar(255) COMMENT '地址'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"readTimeout\",\"definition\":\"json COMMENT '读取超时'\"},{\"name\":\"connTimeout\",\"definition\":\"json COMMENT '连接超时'\"},{\"name\":\"poolSize\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '连接池尺寸'\"},{\"name\":\"pathInfoPattern\",\"definition\":\"varchar(512) COMMENT 'PATH_INFO匹配'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPFirewallPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPFirewallPolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `inbound` json DEFAULT NULL COMMENT '入站规则',\\n  `outbound` json DEFAULT NULL COMMENT '出站规则',\\n  `blockOptions` json DEFAULT NULL COMMENT 'BLOCK选项',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='HTTP防火墙'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"inbound\",\"definition\":\"json COMMENT '入站规则'\"},{\"name\":\"outbound\",\"definition\":\"json COMMENT '出站规则'\"},{\"name\":\"blockOptions\",\"definition\":\"json COMMENT 'BLOCK选项'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPFirewallRuleGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPFirewallRuleGroups` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `sets` json DEFAULT NULL COMMENT '规则集列表',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `code` (`code`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='防火墙规则分组'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"sets\",\"definition\":\"json COMMENT '规则集列表'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"code\",\"definition\":\"KEY `code` (`code`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPFirewallRuleSets\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPFirewallRuleSets` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `rules` json DEFAULT NULL COMMENT '规则列表',\\n  `connector` varchar(64) DEFAULT NULL COMMENT '规则之间的关系',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `action` varchar(255) DEFAULT NULL COMMENT '执行的动作（过期）',\\n  `actionOptions` json DEFAULT NULL COMMENT '动作的选项（过期）',\\n  `actions` json DEFAULT NULL COMMENT '一组动作',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='防火墙规则集'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"rules\",\"definition\":\"json COMMENT '规则列表'\"},{\"name\":\"connector\",\"definition\":\"varchar(64) COMMENT '规则之间的关系'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"action\",\"definition\":\"varchar(255) COMMENT '执行的动作（过期）'\"},{\"name\":\"actionOptions\",\"definition\":\"json COMMENT '动作的选项（过期）'\"},{\"name\":\"actions\",\"definition\":\"json COMMENT '一组动作'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPFirewallRules\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPFirewallRules` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '说明',\\n  `param` varchar(1024) DEFAULT NULL COMMENT '参数',\\n  `paramFilters` json DEFAULT NULL COMMENT '处理器',\\n  `operator` varchar(255) DEFAULT NULL COMMENT '操作符',\\n  `value` varchar(4096) DEFAULT NULL COMMENT '对比值',\\n  `isCaseInsensitive` tinyint(1) unsigned DEFAULT '1' COMMENT '是否大小写不敏感',\\n  `checkpointOptions` json DEFAULT NULL COMMENT '检查点参数',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='防火墙规则'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '说明'\"},{\"name\":\"param\",\"definition\":\"varchar(1024) COMMENT '参数'\"},{\"name\":\"paramFilters\",\"definition\":\"json COMMENT '处理器'\"},{\"name\":\"operator\",\"definition\":\"varchar(255) COMMENT '操作符'\"},{\"name\":\"value\",\"definition\":\"varchar(4096) COMMENT '对比值'\"},{\"name\":\"isCaseInsensitive\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否大小写不敏感'\"},{\"name\":\"checkpointOptions\",\"definition\":\"json COMMENT '检查点参数'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPGzips\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPGzips` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `level` int(11) unsigned DEFAULT '0' COMMENT '压缩级别',\\n  `minLength` json DEFAULT NULL COMMENT '可压缩最小值',\\n  `maxLength` json DEFAULT NULL COMMENT '可压缩最大值',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `conds` json DEFAULT NULL COMMENT '条件',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Gzip配置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"level\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '压缩级别'\"},{\"name\":\"minLength\",\"definition\":\"json COMMENT '可压缩最小值'\"},{\"name\":\"maxLength\",\"definition\":\"json COMMENT '可压缩最大值'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"conds\",\"definition\":\"json COMMENT '条件'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPHeaderPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPHeaderPolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned NOT NULL DEFAULT '1' COMMENT '是否启用',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `addHeaders` json DEFAULT NULL COMMENT '添加的Header',\\n  `addTrailers` json DEFAULT NULL COMMENT '添加的Trailers',\\n  `setHeaders` json DEFAULT NULL COMMENT '设置Header',\\n  `replaceHeaders` json DEFAULT NULL COMMENT '替换Header内容',\\n  `expires` json DEFAULT NULL COMMENT 'Expires单独设置',\\n  `deleteHeaders` json DEFAULT NULL COMMENT '删除的Headers',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Header定义'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"addHeaders\",\"definition\":\"json COMMENT '添加的Header'\"},{\"name\":\"addTrailers\",\"definition\":\"json COMMENT '添加的Trailers'\"},{\"name\":\"setHeaders\",\"definition\":\"json COMMENT '设置Header'\"},{\"name\":\"replaceHeaders\",\"definition\":\"json COMMENT '替换Header内容'\"},{\"name\":\"expires\",\"definition\":\"json COMMENT 'Expires单独设置'\"},{\"name\":\"deleteHeaders\",\"definition\":\"json COMMENT '删除的Headers'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPHeaders\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPHeaders` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `value` varchar(1024) DEFAULT NULL COMMENT '值',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `status` json DEFAULT NULL COMMENT '状态码设置',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='HTTP Header'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"value\",\"definition\":\"varchar(1024) COMMENT '值'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '状态码设置'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPLocations\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPLocations` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `parentId` int(11) unsigned DEFAULT '0' COMMENT '父级ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `pattern` varchar(1024) DEFAULT NULL COMMENT '匹配规则',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `webId` int(11) unsigned DEFAULT '0' COMMENT 'Web配置ID',\\n  `reverseProxy` json DEFAULT NULL COMMENT '反向代理',\\n  `urlPrefix` varchar(1024) DEFAULT NULL COMMENT 'URL前缀',\\n  `isBreak` tinyint(1) unsigned DEFAULT '0' COMMENT '是否终止匹配',\\n  `conds` json DEFAULT NULL COMMENT '匹配条件',\\n  PRIMARY KEY (`id`),\\n  KEY `webId` (`webId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='路由规则配置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"parentId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '父级ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"pattern\",\"definition\":\"varchar(1024) COMMENT '匹配规则'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"webId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'Web配置ID'\"},{\"name\":\"reverseProxy\",\"definition\":\"json COMMENT '反向代理'\"},{\"name\":\"urlPrefix\",\"definition\":\"varchar(1024) COMMENT 'URL前缀'\"},{\"name\":\"isBreak\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否终止匹配'\"},{\"name\":\"conds\",\"definition\":\"json COMMENT '匹配条件'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"webId\",\"definition\":\"KEY `webId` (`webId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPPages\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPPages` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '0' COMMENT '是否启用',\\n  `statusList` json DEFAULT NULL COMMENT '状态列表',\\n  `url` varchar(1024) DEFAULT NULL COMMENT '页面URL',\\n  `newStatus` int(3) DEFAULT NULL COMMENT '新状态码',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='特殊页面'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否启用'\"},{\"name\":\"statusList\",\"definition\":\"json COMMENT '状态列表'\"},{\"name\":\"url\",\"definition\":\"varchar(1024) COMMENT '页面URL'\"},{\"name\":\"newStatus\",\"definition\":\"int(3) COMMENT '新状态码'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPRewriteRules\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPRewriteRules` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `pattern` varchar(1024) DEFAULT NULL COMMENT '匹配规则',\\n  `replace` varchar(1024) DEFAULT NULL COMMENT '跳转后的地址',\\n  `mode` varchar(255) DEFAULT NULL COMMENT '替换模式',\\n  `redirectStatus` int(3) unsigned DEFAULT '0' COMMENT '跳转的状态码',\\n  `proxyHost` varchar(255) DEFAULT NULL COMMENT '代理的主机名',\\n  `isBreak` tinyint(1) unsigned DEFAULT '1' COMMENT '是否终止解析',\\n  `withQuery` tinyint(1) unsigned DEFAULT '1' COMMENT '是否保留URI参数',\\n  `conds` json DEFAULT NULL COMMENT '匹配条件',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='重写规则'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"pattern\",\"definition\":\"varchar(1024) COMMENT '匹配规则'\"},{\"name\":\"replace\",\"definition\":\"varchar(1024) COMMENT '跳转后的地址'\"},{\"name\":\"mode\",\"definition\":\"varchar(255) COMMENT '替换模式'\"},{\"name\":\"redirectStatus\",\"definition\":\"int(3) unsigned DEFAULT '0' COMMENT '跳转的状态码'\"},{\"name\":\"proxyHost\",\"definition\":\"varchar(255) COMMENT '代理的主机名'\"},{\"name\":\"isBreak\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否终止解析'\"},{\"name\":\"withQuery\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否保留URI参数'\"},{\"name\":\"conds\",\"definition\":\"json COMMENT '匹配条件'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPWebs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPWebs` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `root` json DEFAULT NULL COMMENT '根目录',\\n  `charset` json DEFAULT NULL COMMENT '字符集',\\n  `shutdown` json DEFAULT NULL COMMENT '临时关闭页面配置',\\n  `pages` json DEFAULT NULL COMMENT '特殊页面',\\n  `redirectToHttps` json DEFAULT NULL COMMENT '跳转到HTTPS设置',\\n  `indexes` json DEFAULT NULL COMMENT '首页文件列表',\\n  `maxRequestBodySize` json DEFAULT NULL COMMENT '最大允许的请求内容尺寸',\\n  `requestHeader` json DEFAULT NULL COMMENT '请求Header配置',\\n  `responseHeader` json DEFAULT NULL COMMENT '响应Header配置',\\n  `accessLog` json DEFAULT NULL COMMENT '访问日志配置',\\n  `stat` json DEFAULT NULL COMMENT '统计配置',\\n  `gzip` json DEFAULT NULL COMMENT 'Gzip配置',\\n  `cache` json DEFAULT NULL COMMENT '缓存配置',\\n  `firewall` json DEFAULT NULL COMMENT '防火墙设置',\\n  `locations` json DEFAULT NULL COMMENT '路由规则配置',\\n  `websocket` json DEFAULT NULL COMMENT 'Websocket设置',\\n  `rewriteRules` json DEFAULT NULL COMMENT '重写规则配置',\\n  `hostRedirects` json DEFAULT NULL COMMENT '域名跳转',\\n  `fastcgi` json DEFAULT NULL COMMENT 'Fastcgi配置',\\n  `auth` json DEFAULT NULL COMMENT '认证策略配置',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='HTTP Web'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"root\",\"definition\":\"json COMMENT '根目录'\"},{\"name\":\"charset\",\"definition\":\"json COMMENT '字符集'\"},{\"name\":\"shutdown\",\"definition\":\"json COMMENT '临时关闭页面配置'\"},{\"name\":\"pages\",\"definition\":\"json COMMENT '特殊页面'\"},{\"name\":\"redirectToHttps\",\"definition\":\"json COMMENT '跳转到HTTPS设置'\"},{\"name\":\"indexes\",\"definition\":\"json COMMENT '首页文件列表'\"},{\"name\":\"maxRequestBodySize\",\"definition\":\"json COMMENT '最大允许的请求内容尺寸'\"},{\"name\":\"requestHeader\",\"definition\":\"json COMMENT '请求Header配置'\"},{\"name\":\"responseHeader\",\"definition\":\"json COMMENT '响应Header配置'\"},{\"name\":\"accessLog\",\"definition\":\"json COMMENT '访问日志配置'\"},{\"name\":\"stat\",\"definition\":\"json COMMENT '统计配置'\"},{\"name\":\"gzip\",\"definition\":\"json COMMENT 'Gzip配置'\"},{\"name\":\"cache\",\"definition\":\"json COMMENT '缓存配置'\"},{\"name\":\"firewall\",\"definition\":\"json COMMENT '防火墙设置'\"},{\"name\":\"locations\",\"definition\":\"json COMMENT '路由规则配置'\"},{\"name\":\"websocket\",\"definition\":\"json COMMENT 'Websocket设置'\"},{\"name\":\"rewriteRules\",\"definition\":\"json COMMENT '重写规则配置'\"},{\"name\":\"hostRedirects\",\"definition\":\"json COMMENT '域名跳转'\"},{\"name\":\"fastcgi\",\"definition\":\"json COMMENT 'Fastcgi配置'\"},{\"name\":\"auth\",\"definition\":\"json COMMENT '认证策略配置'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeHTTPWebsockets\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeHTTPWebsockets` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `handshakeTimeout` json DEFAULT NULL COMMENT '握手超时时间',\\n  `allowAllOrigins` tinyint(1) unsigned DEFAULT '1' COMMENT '是否支持所有源',\\n  `allowedOrigins` json DEFAULT NULL COMMENT '支持的源域名列表',\\n  `requestSameOrigin` tinyint(1) unsigned DEFAULT '1' COMMENT '是否请求一样的Origin',\\n  `requestOrigin` varchar(255) DEFAULT NULL COMMENT '请求Origin',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Websocket设置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"handshakeTimeout\",\"definition\":\"json COMMENT '握手超时时间'\"},{\"name\":\"allowAllOrigins\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否支持所有源'\"},{\"name\":\"allowedOrigins\",\"definition\":\"json COMMENT '支持的源域名列表'\"},{\"name\":\"requestSameOrigin\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否请求一样的Origin'\"},{\"name\":\"requestOrigin\",\"definition\":\"varchar(255) COMMENT '请求Origin'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeIPItems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeIPItems` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `listId` int(11) unsigned DEFAULT '0' COMMENT '所属名单ID',\\n  `type` varchar(64) DEFAULT 'ipv4' COMMENT '类型',\\n  `ipFrom` varchar(64) DEFAULT NULL COMMENT '开始IP',\\n  `ipTo` varchar(64) DEFAULT NULL COMMENT '结束IP',\\n  `ipFromLong` bigint(20) unsigned DEFAULT '0' COMMENT '开始IP整型',\\n  `ipToLong` bigint(20) unsigned DEFAULT '0' COMMENT '结束IP整型',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `reason` varchar(255) DEFAULT NULL COMMENT '加入说明',\\n  `eventLevel` varchar(64) DEFAULT NULL COMMENT '事件级别',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `expiredAt` bigint(11) unsigned DEFAULT '0' COMMENT '过期时间',\\n  PRIMARY KEY (`id`),\\n  KEY `listId` (`listId`),\\n  KEY `version_expiredAt` (`version`,`expiredAt`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='IP'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"listId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '所属名单ID'\"},{\"name\":\"type\",\"definition\":\"varchar(64) DEFAULT 'ipv4' COMMENT '类型'\"},{\"name\":\"ipFrom\",\"definition\":\"varchar(64) COMMENT '开始IP'\"},{\"name\":\"ipTo\",\"definition\":\"varchar(64) COMMENT '结束IP'\"},{\"name\":\"ipFromLong\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '开始IP整型'\"},{\"name\":\"ipToLong\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '结束IP整型'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"reason\",\"definition\":\"varchar(255) COMMENT '加入说明'\"},{\"name\":\"eventLevel\",\"definition\":\"varchar(64) COMMENT '事件级别'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"expiredAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '过期时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"listId\",\"definition\":\"KEY `listId` (`listId`) USING BTREE\"},{\"name\":\"version_expiredAt\",\"definition\":\"KEY `version_expiredAt` (`version`,`expiredAt`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeIPLibraries\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeIPLibraries` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `fileId` int(11) unsigned DEFAULT '0' COMMENT '文件ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='IP库'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"fileId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '文件ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeIPLists\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeIPLists` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '列表名',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `timeout` json DEFAULT NULL COMMENT '默认超时时间',\\n  `actions` json DEFAULT NULL COMMENT 'IP触发的动作',\\n  `description` varchar(512) DEFAULT NULL COMMENT '描述',\\n  `isPublic` tinyint(1) unsigned DEFAULT '0' COMMENT '是否公用',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='IP名单'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '列表名'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"timeout\",\"definition\":\"json COMMENT '默认超时时间'\"},{\"name\":\"actions\",\"definition\":\"json COMMENT 'IP触发的动作'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '描述'\"},{\"name\":\"isPublic\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否公用'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeLatestItems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeLatestItems` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `itemType` varchar(255) DEFAULT NULL COMMENT 'Item类型',\\n  `itemId` bigint(20) unsigned DEFAULT '0' COMMENT 'itemID',\\n  `count` bigint(11) unsigned DEFAULT '0' COMMENT '数量',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '更新时间',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `item` (`itemType`,`itemId`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='最近的条目统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"itemType\",\"definition\":\"varchar(255) COMMENT 'Item类型'\"},{\"name\":\"itemId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT 'itemID'\"},{\"name\":\"count\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '数量'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '更新时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"item\",\"definition\":\"UNIQUE KEY `item` (`itemType`,`itemId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeLogins\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeLogins` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `type` varchar(255) DEFAULT NULL COMMENT '认证方式',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `adminId_type` (`adminId`,`type`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='第三方登录认证'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '认证方式'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"adminId_type\",\"definition\":\"KEY `adminId_type` (`adminId`,`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeLogs` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `level` varchar(32) DEFAULT NULL COMMENT '级别',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `action` varchar(255) DEFAULT NULL COMMENT '动作',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `providerId` int(11) unsigned DEFAULT '0' COMMENT '供应商ID',\\n  `ip` varchar(32) DEFAULT NULL COMMENT 'IP地址',\\n  `type` varchar(255) DEFAULT 'admin' COMMENT '类型：admin, user',\\n  `day` varchar(10) DEFAULT NULL COMMENT '日期',\\n  `billId` int(11) unsigned DEFAULT '0' COMMENT '账单ID',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `adminid` (`adminId`),\\n  KEY `day` (`day`),\\n  KEY `billId` (`billId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='操作日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"level\",\"definition\":\"varchar(32) COMMENT '级别'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"action\",\"definition\":\"varchar(255) COMMENT '动作'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"providerId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '供应商ID'\"},{\"name\":\"ip\",\"definition\":\"varchar(32) COMMENT 'IP地址'\"},{\"name\":\"type\",\"definition\":\"varchar(255) DEFAULT 'admin' COMMENT '类型：admin, user'\"},{\"name\":\"day\",\"definition\":\"varchar(10) COMMENT '日期'\"},{\"name\":\"billId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '账单ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"adminid\",\"definition\":\"KEY `adminid` (`adminId`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"},{\"name\":\"billId\",\"definition\":\"KEY `billId` (`billId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageEscalations\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageEscalations` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `messageId` bigint(20) unsigned DEFAULT '0' COMMENT '消息ID',\\n  `routeId` int(11) unsigned DEFAULT '0' COMMENT '路由规则ID',\\n  `escalateAt` bigint(11) unsigned DEFAULT '0' COMMENT '升级时间',\\n  `status` tinyint(1) unsigned DEFAULT '0' COMMENT '状态：0等待，1已升级，2已取消',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `messageId` (`messageId`),\\n  KEY `status_escalateAt` (`status`,`escalateAt`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息升级'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"messageId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '消息ID'\"},{\"name\":\"routeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '路由规则ID'\"},{\"name\":\"escalateAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '升级时间'\"},{\"name\":\"status\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '状态：0等待，1已升级，2已取消'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"messageId\",\"definition\":\"KEY `messageId` (`messageId`) USING BTREE\"},{\"name\":\"status_escalateAt\",\"definition\":\"KEY `status_escalateAt` (`status`,`escalateAt`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageMediaInstances\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageMediaInstances` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `mediaType` varchar(255) DEFAULT NULL COMMENT '媒介类型',\\n  `params` json DEFAULT NULL COMMENT '媒介参数',\\n  `description` varchar(512) DEFAULT NULL COMMENT '备注',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `mediaType` (`mediaType`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息媒介接收人'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"mediaType\",\"definition\":\"varchar(255) COMMENT '媒介类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '媒介参数'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '备注'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"mediaType\",\"definition\":\"KEY `mediaType` (`mediaType`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageMedias\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageMedias` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型',\\n  `description` varchar(512) DEFAULT NULL COMMENT '描述',\\n  `userDescription` varchar(512) DEFAULT NULL COMMENT '用户描述',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息媒介'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '描述'\"},{\"name\":\"userDescription\",\"definition\":\"varchar(512) COMMENT '用户描述'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"UNIQUE KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageReceivers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageReceivers` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `recipientId` int(11) unsigned DEFAULT '0' COMMENT '接收人ID',\\n  `recipientGroupId` int(11) unsigned DEFAULT '0' COMMENT '接收人分组ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `serverId` (`serverId`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息通知接收人'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"recipientId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '接收人ID'\"},{\"name\":\"recipientGroupId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '接收人分组ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageRecipientGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageRecipientGroups` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '分组名',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息接收人分组'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '分组名'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageRecipients\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageRecipients` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `instanceId` int(11) unsigned DEFAULT '0' COMMENT '实例ID',\\n  `user` varchar(1024) DEFAULT NULL COMMENT '接收人信息',\\n  `groupIds` json DEFAULT NULL COMMENT '分组ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `description` varchar(512) DEFAULT NULL COMMENT '备注',\\n  PRIMARY KEY (`id`),\\n  KEY `adminId` (`adminId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息媒介接收人'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"instanceId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '实例ID'\"},{\"name\":\"user\",\"definition\":\"varchar(1024) COMMENT '接收人信息'\"},{\"name\":\"groupIds\",\"definition\":\"json COMMENT '分组ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '备注'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageRouteGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageRouteGroups` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `routeId` int(11) unsigned DEFAULT '0' COMMENT '路由规则ID',\\n  `groupKey` varchar(128) DEFAULT NULL COMMENT '合并的依据',\\n  `messageIds` json DEFAULT NULL COMMENT '消息ID',\\n  `countMessages` int(11) unsigned DEFAULT '0' COMMENT '消息数量',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `sendAt` bigint(11) unsigned DEFAULT '0' COMMENT '发送时间',\\n  `isSent` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已发送',\\n  PRIMARY KEY (`id`),\\n  KEY `routeId_groupKey` (`routeId`,`groupKey`),\\n  KEY `isSent_sendAt` (`isSent`,`sendAt`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息路由合并发送'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"routeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '路由规则ID'\"},{\"name\":\"groupKey\",\"definition\":\"varchar(128) COMMENT '合并的依据'\"},{\"name\":\"messageIds\",\"definition\":\"json COMMENT '消息ID'\"},{\"name\":\"countMessages\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '消息数量'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"sendAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '发送时间'\"},{\"name\":\"isSent\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已发送'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"routeId_groupKey\",\"definition\":\"KEY `routeId_groupKey` (`routeId`,`groupKey`) USING BTREE\"},{\"name\":\"isSent_sendAt\",\"definition\":\"KEY `isSent_sendAt` (`isSent`,`sendAt`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageRoutes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageRoutes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `messageTypes` json DEFAULT NULL COMMENT '消息类型，为空表示所有类型',\\n  `levels` json DEFAULT NULL COMMENT '消息级别，为空表示所有级别',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID，0表示所有集群',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID，0表示所有节点',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID，0表示所有服务',\\n  `recipientGroupIds` json DEFAULT NULL COMMENT '接收人分组ID',\\n  `dedupSeconds` int(11) unsigned DEFAULT '0' COMMENT '去重时间窗口（秒）',\\n  `groupSeconds` int(11) unsigned DEFAULT '0' COMMENT '合并时间窗口（秒）',\\n  `escalateMinutes` int(11) unsigned DEFAULT '0' COMMENT '多少分钟未处理后升级',\\n  `escalateGroupIds` json DEFAULT NULL COMMENT '升级接收人分组ID',\\n  `isFinal` tinyint(1) unsigned DEFAULT '0' COMMENT '匹配后是否停止匹配其他规则',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息路由规则'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"messageTypes\",\"definition\":\"json COMMENT '消息类型，为空表示所有类型'\"},{\"name\":\"levels\",\"definition\":\"json COMMENT '消息级别，为空表示所有级别'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID，0表示所有集群'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID，0表示所有节点'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID，0表示所有服务'\"},{\"name\":\"recipientGroupIds\",\"definition\":\"json COMMENT '接收人分组ID'\"},{\"name\":\"dedupSeconds\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '去重时间窗口（秒）'\"},{\"name\":\"groupSeconds\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '合并时间窗口（秒）'\"},{\"name\":\"escalateMinutes\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '多少分钟未处理后升级'\"},{\"name\":\"escalateGroupIds\",\"definition\":\"json COMMENT '升级接收人分组ID'\"},{\"name\":\"isFinal\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '匹配后是否停止匹配其他规则'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageTaskLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageTaskLogs` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `taskId` bigint(20) unsigned DEFAULT '0' COMMENT '任务ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `isOk` tinyint(1) unsigned DEFAULT '0' COMMENT '是否成功',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '错误信息',\\n  `response` varchar(1024) DEFAULT NULL COMMENT '响应信息',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息发送日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"taskId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '任务ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"isOk\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否成功'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '错误信息'\"},{\"name\":\"response\",\"definition\":\"varchar(1024) COMMENT '响应信息'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessageTasks\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessageTasks` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `recipientId` int(11) unsigned DEFAULT '0' COMMENT '接收人ID',\\n  `instanceId` int(11) unsigned DEFAULT '0' COMMENT '媒介实例ID',\\n  `user` varchar(255) DEFAULT NULL COMMENT '接收用户标识',\\n  `subject` varchar(255) DEFAULT NULL COMMENT '标题',\\n  `body` varchar(1024) DEFAULT NULL COMMENT '内容',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `status` tinyint(1) unsigned DEFAULT '0' COMMENT '发送状态',\\n  `sentAt` bigint(11) unsigned DEFAULT '0' COMMENT '最后一次发送时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `result` json DEFAULT NULL COMMENT '结果',\\n  `isPrimary` tinyint(1) unsigned DEFAULT '0' COMMENT '是否优先',\\n  `countAttempts` int(11) unsigned DEFAULT '0' COMMENT '已经尝试发送的次数',\\n  `nextAttemptAt` bigint(11) unsigned DEFAULT '0' COMMENT '下次尝试发送的时间',\\n  PRIMARY KEY (`id`),\\n  KEY `status` (`status`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息发送相关任务'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"recipientId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '接收人ID'\"},{\"name\":\"instanceId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '媒介实例ID'\"},{\"name\":\"user\",\"definition\":\"varchar(255) COMMENT '接收用户标识'\"},{\"name\":\"subject\",\"definition\":\"varchar(255) COMMENT '标题'\"},{\"name\":\"body\",\"definition\":\"varchar(1024) COMMENT '内容'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"status\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '发送状态'\"},{\"name\":\"sentAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '最后一次发送时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"result\",\"definition\":\"json COMMENT '结果'\"},{\"name\":\"isPrimary\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否优先'\"},{\"name\":\"countAttempts\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '已经尝试发送的次数'\"},{\"name\":\"nextAttemptAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '下次尝试发送的时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"status\",\"definition\":\"KEY `status` (`status`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMessages\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMessages` (\\n  `id` bigint(16) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '角色',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `level` varchar(32) DEFAULT NULL COMMENT '级别',\\n  `subject` varchar(255) DEFAULT NULL COMMENT '标题',\\n  `body` varchar(2048) DEFAULT NULL COMMENT '内容',\\n  `type` varchar(128) DEFAULT NULL COMMENT '消息类型',\\n  `params` json DEFAULT NULL COMMENT '额外的参数',\\n  `isRead` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已读',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `day` varchar(8) DEFAULT NULL COMMENT '日期YYYYMMDD',\\n  `hash` varchar(32) DEFAULT NULL COMMENT '消息内容的Hash',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `serverId` (`serverId`),\\n  KEY `day` (`day`),\\n  KEY `hash` (`hash`),\\n  KEY `userId` (`userId`),\\n  KEY `adminId` (`adminId`),\\n  KEY `isRead` (`isRead`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='消息通知'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(16) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '角色'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"level\",\"definition\":\"varchar(32) COMMENT '级别'\"},{\"name\":\"subject\",\"definition\":\"varchar(255) COMMENT '标题'\"},{\"name\":\"body\",\"definition\":\"varchar(2048) COMMENT '内容'\"},{\"name\":\"type\",\"definition\":\"varchar(128) COMMENT '消息类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '额外的参数'\"},{\"name\":\"isRead\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已读'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT '日期YYYYMMDD'\"},{\"name\":\"hash\",\"definition\":\"varchar(32) COMMENT '消息内容的Hash'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"},{\"name\":\"hash\",\"definition\":\"KEY `hash` (`hash`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"},{\"name\":\"isRead\",\"definition\":\"KEY `isRead` (`isRead`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMetricCharts\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMetricCharts` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `itemId` int(11) unsigned DEFAULT '0' COMMENT '指标ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `code` varchar(128) DEFAULT NULL COMMENT '代号',\\n  `type` varchar(32) DEFAULT NULL COMMENT '图形类型',\\n  `widthDiv` int(11) DEFAULT NULL COMMENT '宽度划分',\\n  `params` json DEFAULT NULL COMMENT '图形参数',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `maxItems` int(11) unsigned DEFAULT '0' COMMENT '最多条目',\\n  `ignoreEmptyKeys` tinyint(1) unsigned DEFAULT '1' COMMENT '忽略空的键值',\\n  `ignoredKeys` json DEFAULT NULL COMMENT '忽略键值',\\n  PRIMARY KEY (`id`),\\n  KEY `itemId` (`itemId`),\\n  KEY `code` (`code`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='指标图表'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"itemId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '指标ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"code\",\"definition\":\"varchar(128) COMMENT '代号'\"},{\"name\":\"type\",\"definition\":\"varchar(32) COMMENT '图形类型'\"},{\"name\":\"widthDiv\",\"definition\":\"int(11) COMMENT '宽度划分'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '图形参数'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"maxItems\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最多条目'\"},{\"name\":\"ignoreEmptyKeys\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '忽略空的键值'\"},{\"name\":\"ignoredKeys\",\"definition\":\"json COMMENT '忽略键值'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"itemId\",\"definition\":\"KEY `itemId` (`itemId`) USING BTREE\"},{\"name\":\"code\",\"definition\":\"KEY `code` (`code`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMetricItems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMetricItems` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `code` varchar(128) DEFAULT NULL COMMENT '代号（用来区分是否内置）',\\n  `category` varchar(32) DEFAULT NULL COMMENT '类型，比如http, tcp等',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '指标名称',\\n  `keys` json DEFAULT NULL COMMENT '统计的Key',\\n  `period` int(11) unsigned DEFAULT '0' COMMENT '周期',\\n  `periodUnit` varchar(32) DEFAULT NULL COMMENT '周期单位',\\n  `value` varchar(512) DEFAULT NULL COMMENT '值运算',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '版本号',\\n  `isPublic` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为公用',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `category` (`category`),\\n  KEY `code` (`code`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='指标定义'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"code\",\"definition\":\"varchar(128) COMMENT '代号（用来区分是否内置）'\"},{\"name\":\"category\",\"definition\":\"varchar(32) COMMENT '类型，比如http, tcp等'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '指标名称'\"},{\"name\":\"keys\",\"definition\":\"json COMMENT '统计的Key'\"},{\"name\":\"period\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '周期'\"},{\"name\":\"periodUnit\",\"definition\":\"varchar(32) COMMENT '周期单位'\"},{\"name\":\"value\",\"definition\":\"varchar(512) COMMENT '值运算'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"isPublic\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为公用'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"category\",\"definition\":\"KEY `category` (`category`) USING BTREE\"},{\"name\":\"code\",\"definition\":\"KEY `code` (`code`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMetricStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMetricStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `hash` varchar(32) DEFAULT NULL COMMENT 'Hash值',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `itemId` bigint(20) unsigned DEFAULT '0' COMMENT '指标',\\n  `keys` json DEFAULT NULL COMMENT '键值',\\n  `value` decimal(20,2) DEFAULT '0.00' COMMENT '数值',\\n  `time` varchar(14) DEFAULT NULL COMMENT '分钟值YYYYMMDDHHII',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '版本号',\\n  `createdDay` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `hash` (`hash`),\\n  KEY `serverId` (`serverId`),\\n  KEY `createdDay` (`createdDay`),\\n  KEY `itemId` (`itemId`) USING BTREE,\\n  KEY `item_time` (`itemId`,`time`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='指标统计数据'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"hash\",\"definition\":\"varchar(32) COMMENT 'Hash值'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"itemId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '指标'\"},{\"name\":\"keys\",\"definition\":\"json COMMENT '键值'\"},{\"name\":\"value\",\"definition\":\"decimal(20,2) DEFAULT '0.00' COMMENT '数值'\"},{\"name\":\"time\",\"definition\":\"varchar(14) COMMENT '分钟值YYYYMMDDHHII'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"createdDay\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"hash\",\"definition\":\"UNIQUE KEY `hash` (`hash`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"createdDay\",\"definition\":\"KEY `createdDay` (`createdDay`) USING BTREE\"},{\"name\":\"itemId\",\"definition\":\"KEY `itemId` (`itemId`) USING BTREE\"},{\"name\":\"item_time\",\"definition\":\"KEY `item_time` (`itemId`,`time`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMetricSumStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMetricSumStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `itemId` bigint(20) unsigned DEFAULT '0' COMMENT '指标',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  `total` decimal(20,2) DEFAULT '0.00' COMMENT '总和',\\n  `time` varchar(14) DEFAULT NULL COMMENT '分钟值YYYYMMDDHHII',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '版本号',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `node_server_item_time_version` (`nodeId`,`serverId`,`itemId`,`time`,`version`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='指标统计总和数据'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"itemId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '指标'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"},{\"name\":\"total\",\"definition\":\"decimal(20,2) DEFAULT '0.00' COMMENT '总和'\"},{\"name\":\"time\",\"definition\":\"varchar(14) COMMENT '分钟值YYYYMMDDHHII'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '版本号'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"node_server_item_time_version\",\"definition\":\"UNIQUE KEY `node_server_item_time_version` (`nodeId`,`serverId`,`itemId`,`time`,`version`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeMonitorNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeMonitorNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `status` json DEFAULT NULL COMMENT '运行状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='监控节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '运行状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSAccessLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSAccessLogs` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `recordId` int(11) unsigned DEFAULT '0' COMMENT '记录ID',\\n  `content` json DEFAULT NULL COMMENT '访问数据',\\n  `requestId` varchar(128) DEFAULT NULL COMMENT '请求ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `remoteAddr` varchar(128) DEFAULT NULL COMMENT 'IP',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `domainId` (`domainId`),\\n  KEY `recordId` (`recordId`),\\n  KEY `requestId` (`requestId`),\\n  KEY `remoteAddr` (`remoteAddr`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='域名服务访问日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"recordId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '记录ID'\"},{\"name\":\"content\",\"definition\":\"json COMMENT '访问数据'\"},{\"name\":\"requestId\",\"definition\":\"varchar(128) COMMENT '请求ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"remoteAddr\",\"definition\":\"varchar(128) COMMENT 'IP'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"},{\"name\":\"recordId\",\"definition\":\"KEY `recordId` (`recordId`) USING BTREE\"},{\"name\":\"requestId\",\"definition\":\"KEY `requestId` (`requestId`) USING BTREE\"},{\"name\":\"remoteAddr\",\"definition\":\"KEY `remoteAddr` (`remoteAddr`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSClusters\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSClusters` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '集群名',\\n  `installDir` varchar(512) DEFAULT NULL COMMENT '安装目录',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `accessLog` json DEFAULT NULL COMMENT '访问日志配置',\\n  `grantId` int(11) unsigned DEFAULT '0' COMMENT '授权ID',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='域名服务器集群'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '集群名'\"},{\"name\":\"installDir\",\"definition\":\"varchar(512) COMMENT '安装目录'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"accessLog\",\"definition\":\"json COMMENT '访问日志配置'\"},{\"name\":\"grantId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '授权ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSDomains\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSDomains` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '域名',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `tsig` json DEFAULT NULL COMMENT 'TSIG配置',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `name` (`name`),\\n  KEY `version` (`version`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS域名'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '域名'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"tsig\",\"definition\":\"json COMMENT 'TSIG配置'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"name\",\"definition\":\"KEY `name` (`name`) USING BTREE\"},{\"name\":\"version\",\"definition\":\"KEY `version` (`version`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSKeys\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSKeys` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `domainId` bigint(20) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `zoneId` bigint(20) unsigned DEFAULT '0' COMMENT '子域ID',\\n  `algo` varchar(64) DEFAULT NULL COMMENT '算法',\\n  `secret` varchar(4096) DEFAULT NULL COMMENT '密码',\\n  `secretType` varchar(32) DEFAULT NULL COMMENT '密码类型',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `zoneId` (`zoneId`),\\n  KEY `domainId` (`domainId`),\\n  KEY `version` (`version`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='密钥管理'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"domainId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"zoneId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '子域ID'\"},{\"name\":\"algo\",\"definition\":\"varchar(64) COMMENT '算法'\"},{\"name\":\"secret\",\"definition\":\"varchar(4096) COMMENT '密码'\"},{\"name\":\"secretType\",\"definition\":\"varchar(32) COMMENT '密码类型'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"zoneId\",\"definition\":\"KEY `zoneId` (`zoneId`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"},{\"name\":\"version\",\"definition\":\"KEY `version` (`version`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '节点名称',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `status` json DEFAULT NULL COMMENT '运行状态',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '节点ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `isUp` tinyint(1) unsigned DEFAULT '1' COMMENT '是否运行',\\n  `isInstalled` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已安装',\\n  `installStatus` json DEFAULT NULL COMMENT '安装状态',\\n  `installDir` varchar(512) DEFAULT NULL COMMENT '安装目录',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `isActive` tinyint(1) unsigned DEFAULT '1' COMMENT '是否活跃',\\n  `statusIsNotified` tinyint(1) unsigned DEFAULT '1' COMMENT '活跃状态已经通知',\\n  `connectedAPINodes` json DEFAULT NULL COMMENT '当前连接的API节点',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='域名服务器节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '节点名称'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '运行状态'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '节点ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"isUp\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否运行'\"},{\"name\":\"isInstalled\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已安装'\"},{\"name\":\"installStatus\",\"definition\":\"json COMMENT '安装状态'\"},{\"name\":\"installDir\",\"definition\":\"varchar(512) COMMENT '安装目录'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"isActive\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否活跃'\"},{\"name\":\"statusIsNotified\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '活跃状态已经通知'\"},{\"name\":\"connectedAPINodes\",\"definition\":\"json COMMENT '当前连接的API节点'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSQuestionOptions\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSQuestionOptions` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(128) DEFAULT NULL COMMENT '选项名',\\n  `values` json DEFAULT NULL COMMENT '选项值',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS请求选项'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(128) COMMENT '选项名'\"},{\"name\":\"values\",\"definition\":\"json COMMENT '选项值'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSRecordHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSRecordHourlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(110) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `recordId` bigint(11) unsigned DEFAULT '0' COMMENT '记录ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `countRequests` int(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `bytes` bigint(20) unsigned DEFAULT '0' COMMENT '流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `node_record_hour` (`nodeId`,`recordId`,`hour`) USING BTREE,\\n  KEY `day` (`day`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='NS记录统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(110) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"recordId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '记录ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"countRequests\",\"definition\":\"int(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"node_record_hour\",\"definition\":\"UNIQUE KEY `node_record_hour` (`nodeId`,`recordId`,`hour`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSRecords\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSRecords` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `description` varchar(512) DEFAULT NULL COMMENT '备注',\\n  `name` varchar(255) DEFAULT NULL COMMENT '记录名',\\n  `type` varchar(32) DEFAULT NULL COMMENT '类型',\\n  `value` varchar(4096) DEFAULT NULL COMMENT '值',\\n  `ttl` int(11) unsigned DEFAULT '0' COMMENT 'TTL（秒）',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `routeIds` json DEFAULT NULL COMMENT '线路',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `domainId` (`domainId`),\\n  KEY `version` (`version`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS记录'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '备注'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '记录名'\"},{\"name\":\"type\",\"definition\":\"varchar(32) COMMENT '类型'\"},{\"name\":\"value\",\"definition\":\"varchar(4096) COMMENT '值'\"},{\"name\":\"ttl\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'TTL（秒）'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"routeIds\",\"definition\":\"json COMMENT '线路'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"},{\"name\":\"version\",\"definition\":\"KEY `version` (`version`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSRoutes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSRoutes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '0' COMMENT '是否启用',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `domainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `ranges` json DEFAULT NULL COMMENT '范围',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本号',\\n  `code` varchar(128) DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `domainId` (`domainId`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='DNS线路'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否启用'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"domainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"ranges\",\"definition\":\"json COMMENT '范围'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"code\",\"definition\":\"varchar(128) COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNSZones\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNSZones` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `domainId` bigint(20) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本',\\n  `tsig` json DEFAULT NULL COMMENT 'TSIG配置',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `domainId` (`domainId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='域名子域'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"domainId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本'\"},{\"name\":\"tsig\",\"definition\":\"json COMMENT 'TSIG配置'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"domainId\",\"definition\":\"KEY `domainId` (`domainId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeClusterFirewallActions\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeClusterFirewallActions` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `eventLevel` varchar(255) DEFAULT NULL COMMENT '级别',\\n  `type` varchar(64) DEFAULT NULL COMMENT '动作类型',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='防火墙动作'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"eventLevel\",\"definition\":\"varchar(255) COMMENT '级别'\"},{\"name\":\"type\",\"definition\":\"varchar(64) COMMENT '动作类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeClusterMetricItems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeClusterMetricItems` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `itemId` bigint(20) unsigned DEFAULT '0' COMMENT '指标ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `itemId` (`itemId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='集群使用的指标'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"itemId\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '指标ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"itemId\",\"definition\":\"KEY `itemId` (`itemId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeClusterTrafficDailyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeClusterTrafficDailyStats` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `bytes` bigint(20) unsigned DEFAULT NULL COMMENT '流量字节',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `cluster_day` (`clusterId`,`day`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='总的流量统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned COMMENT '流量字节'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"cluster_day\",\"definition\":\"UNIQUE KEY `cluster_day` (`clusterId`,`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeClusters\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeClusters` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `useAllAPINodes` tinyint(1) unsigned DEFAULT '1' COMMENT '是否使用所有API节点',\\n  `apiNodes` json DEFAULT NULL COMMENT '使用的API节点',\\n  `installDir` varchar(512) DEFAULT NULL COMMENT '安装目录',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `grantId` int(11) unsigned DEFAULT '0' COMMENT '默认认证方式',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `autoRegister` tinyint(1) unsigned DEFAULT '1' COMMENT '是否开启自动注册',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `healthCheck` json DEFAULT NULL COMMENT '健康检查',\\n  `dnsName` varchar(255) DEFAULT NULL COMMENT 'DNS名称',\\n  `dnsDomainId` int(11) unsigned DEFAULT '0' COMMENT '域名ID',\\n  `dns` json DEFAULT NULL COMMENT 'DNS配置',\\n  `toa` json DEFAULT NULL COMMENT 'TOA配置',\\n  `cachePolicyId` int(11) unsigned DEFAULT '0' COMMENT '缓存策略ID',\\n  `httpFirewallPolicyId` int(11) unsigned DEFAULT '0' COMMENT 'WAF策略ID',\\n  `accessLog` json DEFAULT NULL COMMENT '访问日志设置',\\n  `systemServices` json DEFAULT NULL COMMENT '系统服务设置',\\n  PRIMARY KEY (`id`),\\n  KEY `uniqueId` (`uniqueId`),\\n  KEY `grantId` (`grantId`),\\n  KEY `dnsDomainId` (`dnsDomainId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点集群'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"useAllAPINodes\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否使用所有API节点'\"},{\"name\":\"apiNodes\",\"definition\":\"json COMMENT '使用的API节点'\"},{\"name\":\"installDir\",\"definition\":\"varchar(512) COMMENT '安装目录'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"grantId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '默认认证方式'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"autoRegister\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否开启自动注册'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"healthCheck\",\"definition\":\"json COMMENT '健康检查'\"},{\"name\":\"dnsName\",\"definition\":\"varchar(255) COMMENT 'DNS名称'\"},{\"name\":\"dnsDomainId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '域名ID'\"},{\"name\":\"dns\",\"definition\":\"json COMMENT 'DNS配置'\"},{\"name\":\"toa\",\"definition\":\"json COMMENT 'TOA配置'\"},{\"name\":\"cachePolicyId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '缓存策略ID'\"},{\"name\":\"httpFirewallPolicyId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF策略ID'\"},{\"name\":\"accessLog\",\"definition\":\"json COMMENT '访问日志设置'\"},{\"name\":\"systemServices\",\"definition\":\"json COMMENT '系统服务设置'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"KEY `uniqueId` (`uniqueId`) USING BTREE\"},{\"name\":\"grantId\",\"definition\":\"KEY `grantId` (`grantId`) USING BTREE\"},{\"name\":\"dnsDomainId\",\"definition\":\"KEY `dnsDomainId` (`dnsDomainId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeGrants\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeGrants` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `method` varchar(64) DEFAULT NULL COMMENT '登录方式',\\n  `username` varchar(255) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(1024) DEFAULT NULL COMMENT '密码',\\n  `su` tinyint(1) unsigned DEFAULT '1' COMMENT '是否需要su',\\n  `privateKey` text COMMENT '密钥',\\n  `description` varchar(255) DEFAULT NULL COMMENT '备注',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '专有节点',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '角色',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点授权'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"method\",\"definition\":\"varchar(64) COMMENT '登录方式'\"},{\"name\":\"username\",\"definition\":\"varchar(255) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(1024) COMMENT '密码'\"},{\"name\":\"su\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否需要su'\"},{\"name\":\"privateKey\",\"definition\":\"text COMMENT '密钥'\"},{\"name\":\"description\",\"definition\":\"varchar(255) COMMENT '备注'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '专有节点'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '角色'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeGroups` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点分组'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeHealthCheckHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeHealthCheckHourlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `countChecks` int(11) unsigned DEFAULT '0' COMMENT '检查次数',\\n  `countFailed` int(11) unsigned DEFAULT '0' COMMENT '失败次数',\\n  `totalCostMs` double unsigned DEFAULT '0' COMMENT '总耗时（毫秒）',\\n  `maxCostMs` double unsigned DEFAULT '0' COMMENT '最大耗时（毫秒）',\\n  `costBuckets` json DEFAULT NULL COMMENT '耗时分布',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `cluster_node_hour` (`clusterId`,`nodeId`,`hour`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `hour` (`hour`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点健康检查小时统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"countChecks\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '检查次数'\"},{\"name\":\"countFailed\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '失败次数'\"},{\"name\":\"totalCostMs\",\"definition\":\"double unsigned DEFAULT '0' COMMENT '总耗时（毫秒）'\"},{\"name\":\"maxCostMs\",\"definition\":\"double unsigned DEFAULT '0' COMMENT '最大耗时（毫秒）'\"},{\"name\":\"costBuckets\",\"definition\":\"json COMMENT '耗时分布'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"cluster_node_hour\",\"definition\":\"UNIQUE KEY `cluster_node_hour` (`clusterId`,`nodeId`,`hour`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"hour\",\"definition\":\"KEY `hour` (`hour`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeHealthCheckIncidents\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeHealthCheckIncidents` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `startedAt` bigint(11) unsigned DEFAULT '0' COMMENT '开始时间',\\n  `endedAt` bigint(11) unsigned DEFAULT '0' COMMENT '结束时间，0表示尚未结束',\\n  `countFailed` int(11) unsigned DEFAULT '0' COMMENT '失败次数',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '第一次失败的错误信息',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `startedAt` (`startedAt`),\\n  KEY `endedAt` (`endedAt`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点健康检查故障'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"startedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '开始时间'\"},{\"name\":\"endedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '结束时间，0表示尚未结束'\"},{\"name\":\"countFailed\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '失败次数'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '第一次失败的错误信息'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"startedAt\",\"definition\":\"KEY `startedAt` (`startedAt`) USING BTREE\"},{\"name\":\"endedAt\",\"definition\":\"KEY `endedAt` (`endedAt`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeIPAddresses\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeIPAddresses` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '节点角色',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `ip` varchar(128) DEFAULT NULL COMMENT 'IP地址',\\n  `description` varchar(255) DEFAULT NULL COMMENT '描述',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `canAccess` tinyint(1) unsigned DEFAULT '1' COMMENT '是否可以访问',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点IP地址'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '节点角色'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"ip\",\"definition\":\"varchar(128) COMMENT 'IP地址'\"},{\"name\":\"description\",\"definition\":\"varchar(255) COMMENT '描述'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"canAccess\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否可以访问'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeLogins\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeLogins` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '角色',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型：ssh,agent',\\n  `params` json DEFAULT NULL COMMENT '配置参数',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点登录信息'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '角色'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型：ssh,agent'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '配置参数'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeLogs\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeLogs` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `role` varchar(64) DEFAULT NULL COMMENT '节点角色',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `tag` varchar(255) DEFAULT NULL COMMENT '标签',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `level` varchar(32) DEFAULT NULL COMMENT '级别',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT '日期',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `originId` int(11) unsigned DEFAULT '0' COMMENT '源站ID',\\n  `hash` varchar(32) DEFAULT NULL COMMENT '信息内容Hash',\\n  `count` int(11) unsigned DEFAULT '0' COMMENT '重复次数',\\n  `isFixed` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已处理',\\n  PRIMARY KEY (`id`),\\n  KEY `level` (`level`),\\n  KEY `day` (`day`),\\n  KEY `role_nodeId` (`role`,`nodeId`) USING BTREE,\\n  KEY `hash` (`hash`),\\n  KEY `serverId` (`serverId`),\\n  KEY `originId` (`originId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点日志'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"role\",\"definition\":\"varchar(64) COMMENT '节点角色'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"tag\",\"definition\":\"varchar(255) COMMENT '标签'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"level\",\"definition\":\"varchar(32) COMMENT '级别'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT '日期'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"originId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '源站ID'\"},{\"name\":\"hash\",\"definition\":\"varchar(32) COMMENT '信息内容Hash'\"},{\"name\":\"count\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '重复次数'\"},{\"name\":\"isFixed\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已处理'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"level\",\"definition\":\"KEY `level` (`level`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"},{\"name\":\"role_nodeId\",\"definition\":\"KEY `role_nodeId` (`role`,`nodeId`) USING BTREE\"},{\"name\":\"hash\",\"definition\":\"KEY `hash` (`hash`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"originId\",\"definition\":\"KEY `originId` (`originId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodePriceItems\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodePriceItems` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `type` varchar(64) DEFAULT NULL COMMENT '类型：峰值|流量',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `bitsFrom` bigint(20) unsigned DEFAULT '0' COMMENT '起始值',\\n  `bitsTo` bigint(20) unsigned DEFAULT '0' COMMENT '结束值',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `type` (`type`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='区域计费设置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"type\",\"definition\":\"varchar(64) COMMENT '类型：峰值|流量'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"bitsFrom\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '起始值'\"},{\"name\":\"bitsTo\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '结束值'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"type\",\"definition\":\"KEY `type` (`type`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeRegions\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeRegions` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `prices` json DEFAULT NULL COMMENT '价格',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点区域'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"prices\",\"definition\":\"json COMMENT '价格'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeTasks\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeTasks` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '节点角色',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '任务类型',\\n  `uniqueId` varchar(255) DEFAULT NULL COMMENT '唯一ID：nodeId@type',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `isDone` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成',\\n  `isOk` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成',\\n  `error` varchar(1024) DEFAULT NULL COMMENT '错误信息',\\n  `isNotified` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已通知更新',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE,\\n  KEY `nodeId` (`nodeId`),\\n  KEY `isDone` (`isDone`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `isNotified` (`isNotified`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点同步任务'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '节点角色'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '任务类型'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(255) COMMENT '唯一ID：nodeId@type'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"isDone\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成'\"},{\"name\":\"isOk\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已完成'\"},{\"name\":\"error\",\"definition\":\"varchar(1024) COMMENT '错误信息'\"},{\"name\":\"isNotified\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已通知更新'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"isDone\",\"definition\":\"KEY `isDone` (`isDone`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"isNotified\",\"definition\":\"KEY `isNotified` (`isNotified`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeThresholds\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeThresholds` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `role` varchar(32) DEFAULT NULL COMMENT '节点角色',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `item` varchar(255) DEFAULT NULL COMMENT '监控项',\\n  `param` varchar(255) DEFAULT NULL COMMENT '参数',\\n  `operator` varchar(255) DEFAULT NULL COMMENT '操作符',\\n  `value` json DEFAULT NULL COMMENT '对比值',\\n  `message` varchar(512) DEFAULT NULL COMMENT '消息内容',\\n  `notifyDuration` int(11) unsigned DEFAULT '0' COMMENT '通知间隔（单位分钟）',\\n  `notifiedAt` int(11) unsigned DEFAULT '0' COMMENT '上次通知时间',\\n  `duration` int(11) unsigned DEFAULT '0' COMMENT '时间段',\\n  `durationUnit` varchar(16) DEFAULT NULL COMMENT '时间段单位',\\n  `sumMethod` varchar(32) DEFAULT NULL COMMENT '聚合方法',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `nodeId` (`nodeId`),\\n  KEY `clusterId` (`clusterId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='集群阈值设置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) COMMENT '节点角色'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"item\",\"definition\":\"varchar(255) COMMENT '监控项'\"},{\"name\":\"param\",\"definition\":\"varchar(255) COMMENT '参数'\"},{\"name\":\"operator\",\"definition\":\"varchar(255) COMMENT '操作符'\"},{\"name\":\"value\",\"definition\":\"json COMMENT '对比值'\"},{\"name\":\"message\",\"definition\":\"varchar(512) COMMENT '消息内容'\"},{\"name\":\"notifyDuration\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '通知间隔（单位分钟）'\"},{\"name\":\"notifiedAt\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '上次通知时间'\"},{\"name\":\"duration\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '时间段'\"},{\"name\":\"durationUnit\",\"definition\":\"varchar(16) COMMENT '时间段单位'\"},{\"name\":\"sumMethod\",\"definition\":\"varchar(32) COMMENT '聚合方法'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeTrafficDailyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeTrafficDailyStats` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '节点角色',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `bytes` bigint(20) unsigned DEFAULT NULL COMMENT '流量字节',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `node_day` (`clusterId`,`nodeId`,`day`) USING BTREE,\\n  KEY `clusterId` (`clusterId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='总的流量统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '节点角色'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned COMMENT '流量字节'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"node_day\",\"definition\":\"UNIQUE KEY `node_day` (`clusterId`,`nodeId`,`day`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeTrafficHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeTrafficHourlyStats` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `role` varchar(32) DEFAULT 'node' COMMENT '节点角色',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `bytes` bigint(20) unsigned DEFAULT NULL COMMENT '流量字节',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `node_day` (`clusterId`,`nodeId`,`hour`) USING BTREE,\\n  KEY `clusterId` (`clusterId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='总的流量统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"role\",\"definition\":\"varchar(32) DEFAULT 'node' COMMENT '节点角色'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned COMMENT '流量字节'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"node_day\",\"definition\":\"UNIQUE KEY `node_day` (`clusterId`,`nodeId`,`hour`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodeValues\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodeValues` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `role` varchar(64) DEFAULT NULL COMMENT '节点角色',\\n  `item` varchar(255) DEFAULT NULL COMMENT '监控项',\\n  `value` json DEFAULT NULL COMMENT '数据',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `day` varchar(8) DEFAULT NULL COMMENT '日期',\\n  `hour` varchar(10) DEFAULT NULL COMMENT '小时',\\n  `minute` varchar(12) DEFAULT NULL COMMENT '分钟',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `nodeId_role_time` (`nodeId`,`role`,`item`,`day`,`hour`,`minute`) USING BTREE,\\n  KEY `nodeId` (`nodeId`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `role_item_minute` (`role`,`item`,`minute`),\\n  KEY `day` (`day`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点监控数据'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"role\",\"definition\":\"varchar(64) COMMENT '节点角色'\"},{\"name\":\"item\",\"definition\":\"varchar(255) COMMENT '监控项'\"},{\"name\":\"value\",\"definition\":\"json COMMENT '数据'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT '日期'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT '小时'\"},{\"name\":\"minute\",\"definition\":\"varchar(12) COMMENT '分钟'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"nodeId_role_time\",\"definition\":\"UNIQUE KEY `nodeId_role_time` (`nodeId`,`role`,`item`,`day`,`hour`,`minute`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"role_item_minute\",\"definition\":\"KEY `role_item_minute` (`role`,`item`,`minute`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `isUp` tinyint(1) unsigned DEFAULT '1' COMMENT '是否在线',\\n  `countUp` int(11) unsigned DEFAULT '0' COMMENT '连续在线次数',\\n  `countDown` int(11) unsigned DEFAULT '0' COMMENT '连续下线次数',\\n  `isActive` tinyint(1) unsigned DEFAULT '1' COMMENT '是否活跃',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '节点ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `name` varchar(255) DEFAULT NULL COMMENT '节点名',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '主集群ID',\\n  `secondaryClusterIds` json DEFAULT NULL COMMENT '从集群ID',\\n  `regionId` int(11) unsigned DEFAULT '0' COMMENT '区域ID',\\n  `groupId` int(11) unsigned DEFAULT '0' COMMENT '分组ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `status` json DEFAULT NULL COMMENT '最新的状态',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '当前版本号',\\n  `latestVersion` int(11) unsigned DEFAULT '0' COMMENT '最后版本号',\\n  `installDir` varchar(512) DEFAULT NULL COMMENT '安装目录',\\n  `isInstalled` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已安装',\\n  `installStatus` json DEFAULT NULL COMMENT '安装状态',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `connectedAPINodes` json DEFAULT NULL COMMENT '当前连接的API节点',\\n  `maxCPU` int(4) unsigned DEFAULT '0' COMMENT '可以使用的最多CPU',\\n  `dnsRoutes` json DEFAULT NULL COMMENT 'DNS线路设置',\\n  `maxCacheDiskCapacity` json DEFAULT NULL COMMENT '硬盘缓存容量',\\n  `maxCacheMemoryCapacity` json DEFAULT NULL COMMENT '内存缓存容量',\\n  PRIMARY KEY (`id`),\\n  KEY `uniqueId` (`uniqueId`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `groupId` (`groupId`),\\n  KEY `regionId` (`regionId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"isUp\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否在线'\"},{\"name\":\"countUp\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '连续在线次数'\"},{\"name\":\"countDown\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '连续下线次数'\"},{\"name\":\"isActive\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否活跃'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '节点ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '节点名'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '主集群ID'\"},{\"name\":\"secondaryClusterIds\",\"definition\":\"json COMMENT '从集群ID'\"},{\"name\":\"regionId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '区域ID'\"},{\"name\":\"groupId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '分组ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '最新的状态'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '当前版本号'\"},{\"name\":\"latestVersion\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最后版本号'\"},{\"name\":\"installDir\",\"definition\":\"varchar(512) COMMENT '安装目录'\"},{\"name\":\"isInstalled\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已安装'\"},{\"name\":\"installStatus\",\"definition\":\"json COMMENT '安装状态'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"connectedAPINodes\",\"definition\":\"json COMMENT '当前连接的API节点'\"},{\"name\":\"maxCPU\",\"definition\":\"int(4) unsigned DEFAULT '0' COMMENT '可以使用的最多CPU'\"},{\"name\":\"dnsRoutes\",\"definition\":\"json COMMENT 'DNS线路设置'\"},{\"name\":\"maxCacheDiskCapacity\",\"definition\":\"json COMMENT '硬盘缓存容量'\"},{\"name\":\"maxCacheMemoryCapacity\",\"definition\":\"json COMMENT '内存缓存容量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"KEY `uniqueId` (`uniqueId`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"groupId\",\"definition\":\"KEY `groupId` (`groupId`) USING BTREE\"},{\"name\":\"regionId\",\"definition\":\"KEY `regionId` (`regionId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeOrigins\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeOrigins` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '版本',\\n  `addr` json DEFAULT NULL COMMENT '地址',\\n  `description` varchar(512) DEFAULT NULL COMMENT '描述',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `connTimeout` json DEFAULT NULL COMMENT '连接超时',\\n  `readTimeout` json DEFAULT NULL COMMENT '读超时',\\n  `idleTimeout` json DEFAULT NULL COMMENT '空闲连接超时',\\n  `maxFails` int(11) unsigned DEFAULT '0' COMMENT '最多失败次数',\\n  `maxConns` int(11) unsigned DEFAULT '0' COMMENT '最大并发连接数',\\n  `maxIdleConns` int(11) unsigned DEFAULT '0' COMMENT '最多空闲连接数',\\n  `httpRequestURI` varchar(1024) DEFAULT NULL COMMENT '转发后的请求URI',\\n  `httpRequestHeader` json DEFAULT NULL COMMENT '请求Header配置',\\n  `httpResponseHeader` json DEFAULT NULL COMMENT '响应Header配置',\\n  `host` varchar(255) DEFAULT NULL COMMENT '自定义主机名',\\n  `healthCheck` json DEFAULT NULL COMMENT '健康检查设置',\\n  `cert` json DEFAULT NULL COMMENT '证书设置',\\n  `ftp` json DEFAULT NULL COMMENT 'FTP相关设置',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='源站'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '版本'\"},{\"name\":\"addr\",\"definition\":\"json COMMENT '地址'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '描述'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"connTimeout\",\"definition\":\"json COMMENT '连接超时'\"},{\"name\":\"readTimeout\",\"definition\":\"json COMMENT '读超时'\"},{\"name\":\"idleTimeout\",\"definition\":\"json COMMENT '空闲连接超时'\"},{\"name\":\"maxFails\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最多失败次数'\"},{\"name\":\"maxConns\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最大并发连接数'\"},{\"name\":\"maxIdleConns\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最多空闲连接数'\"},{\"name\":\"httpRequestURI\",\"definition\":\"varchar(1024) COMMENT '转发后的请求URI'\"},{\"name\":\"httpRequestHeader\",\"definition\":\"json COMMENT '请求Header配置'\"},{\"name\":\"httpResponseHeader\",\"definition\":\"json COMMENT '响应Header配置'\"},{\"name\":\"host\",\"definition\":\"varchar(255) COMMENT '自定义主机名'\"},{\"name\":\"healthCheck\",\"definition\":\"json COMMENT '健康检查设置'\"},{\"name\":\"cert\",\"definition\":\"json COMMENT '证书设置'\"},{\"name\":\"ftp\",\"definition\":\"json COMMENT 'FTP相关设置'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeProviders\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeProviders` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `username` varchar(64) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(32) DEFAULT NULL COMMENT '密码',\\n  `fullname` varchar(64) DEFAULT NULL COMMENT '真实姓名',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='供应商'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"username\",\"definition\":\"varchar(64) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(32) COMMENT '密码'\"},{\"name\":\"fullname\",\"definition\":\"varchar(64) COMMENT '真实姓名'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeRegionCities\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeRegionCities` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `provinceId` int(11) unsigned DEFAULT '0' COMMENT '省份ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `dataId` varchar(255) DEFAULT NULL COMMENT '原始数据ID',\\n  PRIMARY KEY (`id`),\\n  KEY `proviceId` (`provinceId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='区域-城市'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"provinceId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '省份ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"dataId\",\"definition\":\"varchar(255) COMMENT '原始数据ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"proviceId\",\"definition\":\"KEY `proviceId` (`provinceId`) USING BTREE\"}],\"records\":[{\"id\":1,\"values\":{\"codes\":\"[\\\"北京市\\\"]\",\"dataId\":\"215\",\"id\":\"1\",\"name\":\"北京市\",\"provinceId\":\"1\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":2,\"values\":{\"codes\":\"[\\\"天津市\\\"]\",\"dataId\":\"235\",\"id\":\"2\",\"name\":\"天津市\",\"provinceId\":\"2\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":3,\"values\":{\"codes\":\"[\\\"石家庄市\\\"]\",\"dataId\":\"255\",\"id\":\"3\",\"name\":\"石家庄市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":4,\"values\":{\"codes\":\"[\\\"唐山市\\\"]\",\"dataId\":\"279\",\"id\":\"4\",\"name\":\"唐山市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":5,\"values\":{\"codes\":\"[\\\"秦皇岛市\\\"]\",\"dataId\":\"294\",\"id\":\"5\",\"name\":\"秦皇岛市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":6,\"values\":{\"codes\":\"[\\\"邯郸市\\\"]\",\"dataId\":\"302\",\"id\":\"6\",\"name\":\"邯郸市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":7,\"values\":{\"codes\":\"[\\\"邢台市\\\"]\",\"dataId\":\"322\",\"id\":\"7\",\"name\":\"邢台市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":8,\"values\":{\"codes\":\"[\\\"保定市\\\"]\",\"dataId\":\"340\",\"id\":\"8\",\"name\":\"保定市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":9,\"values\":{\"codes\":\"[\\\"张家口市\\\"]\",\"dataId\":\"366\",\"id\":\"9\",\"name\":\"张家口市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":10,\"values\":{\"codes\":\"[\\\"承德市\\\"]\",\"dataId\":\"382\",\"id\":\"10\",\"name\":\"承德市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":11,\"values\":{\"codes\":\"[\\\"沧州市\\\"]\",\"dataId\":\"394\",\"id\":\"11\",\"name\":\"沧州市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":12,\"values\":{\"codes\":\"[\\\"廊坊市\\\"]\",\"dataId\":\"410\",\"id\":\"12\",\"name\":\"廊坊市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":13,\"values\":{\"codes\":\"[\\\"衡水市\\\"]\",\"dataId\":\"421\",\"id\":\"13\",\"name\":\"衡水市\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":14,\"values\":{\"codes\":\"[\\\"太原市\\\"]\",\"dataId\":\"434\",\"id\":\"14\",\"name\":\"太原市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":15,\"values\":{\"codes\":\"[\\\"大同市\\\"]\",\"dataId\":\"445\",\"id\":\"15\",\"name\":\"大同市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":16,\"values\":{\"codes\":\"[\\\"阳泉市\\\"]\",\"dataId\":\"457\",\"id\":\"16\",\"name\":\"阳泉市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":17,\"values\":{\"codes\":\"[\\\"长治市\\\"]\",\"dataId\":\"461\",\"id\":\"17\",\"name\":\"长治市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":18,\"values\":{\"codes\":\"[\\\"晋城市\\\"]\",\"dataId\":\"473\",\"id\":\"18\",\"name\":\"晋城市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":19,\"values\":{\"codes\":\"[\\\"朔州市\\\"]\",\"dataId\":\"479\",\"id\":\"19\",\"name\":\"朔州市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":20,\"values\":{\"codes\":\"[\\\"晋中市\\\"]\",\"dataId\":\"486\",\"id\":\"20\",\"name\":\"晋中市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":21,\"values\":{\"codes\":\"[\\\"运城市\\\"]\",\"dataId\":\"498\",\"id\":\"21\",\"name\":\"运城市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":22,\"values\":{\"codes\":\"[\\\"忻州市\\\"]\",\"dataId\":\"512\",\"id\":\"22\",\"name\":\"忻州市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":23,\"values\":{\"codes\":\"[\\\"临汾市\\\"]\",\"dataId\":\"527\",\"id\":\"23\",\"name\":\"临汾市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":24,\"values\":{\"codes\":\"[\\\"吕梁市\\\"]\",\"dataId\":\"545\",\"id\":\"24\",\"name\":\"吕梁市\",\"provinceId\":\"4\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":25,\"values\":{\"codes\":\"[\\\"呼和浩特市\\\"]\",\"dataId\":\"560\",\"id\":\"25\",\"name\":\"呼和浩特市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":26,\"values\":{\"codes\":\"[\\\"包头市\\\"]\",\"dataId\":\"570\",\"id\":\"26\",\"name\":\"包头市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":27,\"values\":{\"codes\":\"[\\\"乌海市\\\"]\",\"dataId\":\"580\",\"id\":\"27\",\"name\":\"乌海市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":28,\"values\":{\"codes\":\"[\\\"赤峰市\\\"]\",\"dataId\":\"584\",\"id\":\"28\",\"name\":\"赤峰市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":29,\"values\":{\"codes\":\"[\\\"通辽市\\\"]\",\"dataId\":\"597\",\"id\":\"29\",\"name\":\"通辽市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":30,\"values\":{\"codes\":\"[\\\"鄂尔多斯市\\\"]\",\"dataId\":\"606\",\"id\":\"30\",\"name\":\"鄂尔多斯市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":31,\"values\":{\"codes\":\"[\\\"呼伦贝尔市\\\"]\",\"dataId\":\"615\",\"id\":\"31\",\"name\":\"呼伦贝尔市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":32,\"values\":{\"codes\":\"[\\\"巴彦淖尔市\\\"]\",\"dataId\":\"629\",\"id\":\"32\",\"name\":\"巴彦淖尔市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":33,\"values\":{\"codes\":\"[\\\"乌兰察布市\\\"]\",\"dataId\":\"637\",\"id\":\"33\",\"name\":\"乌兰察布市\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":34,\"values\":{\"codes\":\"[\\\"兴安盟\\\"]\",\"dataId\":\"649\",\"id\":\"34\",\"name\":\"兴安盟\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":35,\"values\":{\"codes\":\"[\\\"锡林郭勒盟\\\"]\",\"dataId\":\"656\",\"id\":\"35\",\"name\":\"锡林郭勒盟\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":36,\"values\":{\"codes\":\"[\\\"阿拉善盟\\\"]\",\"dataId\":\"669\",\"id\":\"36\",\"name\":\"阿拉善盟\",\"provinceId\":\"5\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":37,\"values\":{\"codes\":\"[\\\"沈阳市\\\"]\",\"dataId\":\"674\",\"id\":\"37\",\"name\":\"沈阳市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":38,\"values\":{\"codes\":\"[\\\"大连市\\\"]\",\"dataId\":\"687\",\"id\":\"38\",\"name\":\"大连市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":39,\"values\":{\"codes\":\"[\\\"鞍山市\\\"]\",\"dataId\":\"698\",\"id\":\"39\",\"name\":\"鞍山市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":40,\"values\":{\"codes\":\"[\\\"抚顺市\\\"]\",\"dataId\":\"705\",\"id\":\"40\",\"name\":\"抚顺市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":41,\"values\":{\"codes\":\"[\\\"本溪市\\\"]\",\"dataId\":\"713\",\"id\":\"41\",\"name\":\"本溪市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":42,\"values\":{\"codes\":\"[\\\"丹东市\\\"]\",\"dataId\":\"720\",\"id\":\"42\",\"name\":\"丹东市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":43,\"values\":{\"codes\":\"[\\\"锦州市\\\"]\",\"dataId\":\"727\",\"id\":\"43\",\"name\":\"锦州市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":44,\"values\":{\"codes\":\"[\\\"营口市\\\"]\",\"dataId\":\"735\",\"id\":\"44\",\"name\":\"营口市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":45,\"values\":{\"codes\":\"[\\\"阜新市\\\"]\",\"dataId\":\"742\",\"id\":\"45\",\"name\":\"阜新市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":46,\"values\":{\"codes\":\"[\\\"辽阳市\\\"]\",\"dataId\":\"750\",\"id\":\"46\",\"name\":\"辽阳市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":47,\"values\":{\"codes\":\"[\\\"盘锦市\\\"]\",\"dataId\":\"758\",\"id\":\"47\",\"name\":\"盘锦市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":48,\"values\":{\"codes\":\"[\\\"铁岭市\\\"]\",\"dataId\":\"763\",\"id\":\"48\",\"name\":\"铁岭市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":49,\"values\":{\"codes\":\"[\\\"朝阳市\\\"]\",\"dataId\":\"771\",\"id\":\"49\",\"name\":\"朝阳市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":50,\"values\":{\"codes\":\"[\\\"葫芦岛市\\\"]\",\"dataId\":\"779\",\"id\":\"50\",\"name\":\"葫芦岛市\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":51,\"values\":{\"codes\":\"[\\\"长春市\\\"]\",\"dataId\":\"787\",\"id\":\"51\",\"name\":\"长春市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":52,\"values\":{\"codes\":\"[\\\"吉林市\\\"]\",\"dataId\":\"797\",\"id\":\"52\",\"name\":\"吉林市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":53,\"values\":{\"codes\":\"[\\\"四平市\\\"]\",\"dataId\":\"807\",\"id\":\"53\",\"name\":\"四平市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":54,\"values\":{\"codes\":\"[\\\"辽源市\\\"]\",\"dataId\":\"812\",\"id\":\"54\",\"name\":\"辽源市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":55,\"values\":{\"codes\":\"[\\\"通化市\\\"]\",\"dataId\":\"817\",\"id\":\"55\",\"name\":\"通化市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":56,\"values\":{\"codes\":\"[\\\"白山市\\\"]\",\"dataId\":\"825\",\"id\":\"56\",\"name\":\"白山市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":57,\"values\":{\"codes\":\"[\\\"松原市\\\"]\",\"dataId\":\"832\",\"id\":\"57\",\"name\":\"松原市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":58,\"values\":{\"codes\":\"[\\\"白城市\\\"]\",\"dataId\":\"838\",\"id\":\"58\",\"name\":\"白城市\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":59,\"values\":{\"codes\":\"[\\\"延边朝鲜族自治州\\\"]\",\"dataId\":\"844\",\"id\":\"59\",\"name\":\"延边朝鲜族自治州\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":60,\"values\":{\"codes\":\"[\\\"哈尔滨市\\\"]\",\"dataId\":\"854\",\"id\":\"60\",\"name\":\"哈尔滨市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":61,\"values\":{\"codes\":\"[\\\"齐齐哈尔市\\\"]\",\"dataId\":\"874\",\"id\":\"61\",\"name\":\"齐齐哈尔市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":62,\"values\":{\"codes\":\"[\\\"鸡西市\\\"]\",\"dataId\":\"891\",\"id\":\"62\",\"name\":\"鸡西市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":63,\"values\":{\"codes\":\"[\\\"鹤岗市\\\"]\",\"dataId\":\"901\",\"id\":\"63\",\"name\":\"鹤岗市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":64,\"values\":{\"codes\":\"[\\\"双鸭山市\\\"]\",\"dataId\":\"910\",\"id\":\"64\",\"name\":\"双鸭山市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":65,\"values\":{\"codes\":\"[\\\"大庆市\\\"]\",\"dataId\":\"919\",\"id\":\"65\",\"name\":\"大庆市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":66,\"values\":{\"codes\":\"[\\\"伊春市\\\"]\",\"dataId\":\"929\",\"id\":\"66\",\"name\":\"伊春市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":67,\"values\":{\"codes\":\"[\\\"佳木斯市\\\"]\",\"dataId\":\"947\",\"id\":\"67\",\"name\":\"佳木斯市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":68,\"values\":{\"codes\":\"[\\\"七台河市\\\"]\",\"dataId\":\"957\",\"id\":\"68\",\"name\":\"七台河市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":69,\"values\":{\"codes\":\"[\\\"牡丹江市\\\"]\",\"dataId\":\"962\",\"id\":\"69\",\"name\":\"牡丹江市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":70,\"values\":{\"codes\":\"[\\\"黑河市\\\"]\",\"dataId\":\"972\",\"id\":\"70\",\"name\":\"黑河市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":71,\"values\":{\"codes\":\"[\\\"绥化市\\\"]\",\"dataId\":\"979\",\"id\":\"71\",\"name\":\"绥化市\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":72,\"values\":{\"codes\":\"[\\\"大兴安岭地区\\\"]\",\"dataId\":\"990\",\"id\":\"72\",\"name\":\"大兴安岭地区\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":73,\"values\":{\"codes\":\"[\\\"上海市\\\"]\",\"dataId\":\"995\",\"id\":\"73\",\"name\":\"上海市\",\"provinceId\":\"9\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":74,\"values\":{\"codes\":\"[\\\"南京市\\\"]\",\"dataId\":\"1015\",\"id\":\"74\",\"name\":\"南京市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":75,\"values\":{\"codes\":\"[\\\"无锡市\\\"]\",\"dataId\":\"1029\",\"id\":\"75\",\"name\":\"无锡市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":76,\"values\":{\"codes\":\"[\\\"徐州市\\\"]\",\"dataId\":\"1038\",\"id\":\"76\",\"name\":\"徐州市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":77,\"values\":{\"codes\":\"[\\\"常州市\\\"]\",\"dataId\":\"1049\",\"id\":\"77\",\"name\":\"常州市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":78,\"values\":{\"codes\":\"[\\\"苏州市\\\"]\",\"dataId\":\"1057\",\"id\":\"78\",\"name\":\"苏州市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":79,\"values\":{\"codes\":\"[\\\"南通市\\\"]\",\"dataId\":\"1069\",\"id\":\"79\",\"name\":\"南通市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":80,\"values\":{\"codes\":\"[\\\"连云港市\\\"]\",\"dataId\":\"1078\",\"id\":\"80\",\"name\":\"连云港市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":81,\"values\":{\"codes\":\"[\\\"淮安市\\\"]\",\"dataId\":\"1085\",\"id\":\"81\",\"name\":\"淮安市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":82,\"values\":{\"codes\":\"[\\\"盐城市\\\"]\",\"dataId\":\"1093\",\"id\":\"82\",\"name\":\"盐城市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":83,\"values\":{\"codes\":\"[\\\"扬州市\\\"]\",\"dataId\":\"1103\",\"id\":\"83\",\"name\":\"扬州市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":84,\"values\":{\"codes\":\"[\\\"镇江市\\\"]\",\"dataId\":\"1111\",\"id\":\"84\",\"name\":\"镇江市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":85,\"values\":{\"codes\":\"[\\\"泰州市\\\"]\",\"dataId\":\"1118\",\"id\":\"85\",\"name\":\"泰州市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":86,\"values\":{\"codes\":\"[\\\"宿迁市\\\"]\",\"dataId\":\"1125\",\"id\":\"86\",\"name\":\"宿迁市\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":87,\"values\":{\"codes\":\"[\\\"杭州市\\\"]\",\"dataId\":\"1132\",\"id\":\"87\",\"name\":\"杭州市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":88,\"values\":{\"codes\":\"[\\\"宁波市\\\"]\",\"dataId\":\"1146\",\"id\":\"88\",\"name\":\"宁波市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":89,\"values\":{\"codes\":\"[\\\"温州市\\\"]\",\"dataId\":\"1158\",\"id\":\"89\",\"name\":\"温州市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":90,\"values\":{\"codes\":\"[\\\"嘉兴市\\\"]\",\"dataId\":\"1170\",\"id\":\"90\",\"name\":\"嘉兴市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":91,\"values\":{\"codes\":\"[\\\"湖州市\\\"]\",\"dataId\":\"1178\",\"id\":\"91\",\"name\":\"湖州市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":92,\"values\":{\"codes\":\"[\\\"绍兴市\\\"]\",\"dataId\":\"1184\",\"id\":\"92\",\"name\":\"绍兴市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":93,\"values\":{\"codes\":\"[\\\"金华市\\\"]\",\"dataId\":\"1191\",\"id\":\"93\",\"name\":\"金华市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":94,\"values\":{\"codes\":\"[\\\"衢州市\\\"]\",\"dataId\":\"1201\",\"id\":\"94\",\"name\":\"衢州市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":95,\"values\":{\"codes\":\"[\\\"舟山市\\\"]\",\"dataId\":\"1208\",\"id\":\"95\",\"name\":\"舟山市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":96,\"values\":{\"codes\":\"[\\\"台州市\\\"]\",\"dataId\":\"1212\",\"id\":\"96\",\"name\":\"台州市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":97,\"values\":{\"codes\":\"[\\\"丽水市\\\"]\",\"dataId\":\"1222\",\"id\":\"97\",\"name\":\"丽水市\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":98,\"values\":{\"codes\":\"[\\\"合肥市\\\"]\",\"dataId\":\"1233\",\"id\":\"98\",\"name\":\"合肥市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":99,\"values\":{\"codes\":\"[\\\"芜湖市\\\"]\",\"dataId\":\"1241\",\"id\":\"99\",\"name\":\"芜湖市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":100,\"values\":{\"codes\":\"[\\\"蚌埠市\\\"]\",\"dataId\":\"1249\",\"id\":\"100\",\"name\":\"蚌埠市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":101,\"values\":{\"codes\":\"[\\\"淮南市\\\"]\",\"dataId\":\"1257\",\"id\":\"101\",\"name\":\"淮南市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":102,\"values\":{\"codes\":\"[\\\"马鞍山市\\\"]\",\"dataId\":\"1264\",\"id\":\"102\",\"name\":\"马鞍山市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":103,\"values\":{\"codes\":\"[\\\"淮北市\\\"]\",\"dataId\":\"1269\",\"id\":\"103\",\"name\":\"淮北市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":104,\"values\":{\"codes\":\"[\\\"铜陵市\\\"]\",\"dataId\":\"1274\",\"id\":\"104\",\"name\":\"铜陵市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":105,\"values\":{\"codes\":\"[\\\"安庆市\\\"]\",\"dataId\":\"1278\",\"id\":\"105\",\"name\":\"安庆市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":106,\"values\":{\"codes\":\"[\\\"黄山市\\\"]\",\"dataId\":\"1289\",\"id\":\"106\",\"name\":\"黄山市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":107,\"values\":{\"codes\":\"[\\\"滁州市\\\"]\",\"dataId\":\"1297\",\"id\":\"107\",\"name\":\"滁州市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":108,\"values\":{\"codes\":\"[\\\"阜阳市\\\"]\",\"dataId\":\"1306\",\"id\":\"108\",\"name\":\"阜阳市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":109,\"values\":{\"codes\":\"[\\\"宿州市\\\"]\",\"dataId\":\"1315\",\"id\":\"109\",\"name\":\"宿州市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":110,\"values\":{\"codes\":\"[\\\"巢湖市\\\"]\",\"dataId\":\"1321\",\"id\":\"110\",\"name\":\"巢湖市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":111,\"values\":{\"codes\":\"[\\\"六安市\\\"]\",\"dataId\":\"1327\",\"id\":\"111\",\"name\":\"六安市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":112,\"values\":{\"codes\":\"[\\\"亳州市\\\"]\",\"dataId\":\"1335\",\"id\":\"112\",\"name\":\"亳州市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":113,\"values\":{\"codes\":\"[\\\"池州市\\\"]\",\"dataId\":\"1340\",\"id\":\"113\",\"name\":\"池州市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":114,\"values\":{\"codes\":\"[\\\"宣城市\\\"]\",\"dataId\":\"1345\",\"id\":\"114\",\"name\":\"宣城市\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":115,\"values\":{\"codes\":\"[\\\"福州市\\\"]\",\"dataId\":\"1354\",\"id\":\"115\",\"name\":\"福州市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":116,\"values\":{\"codes\":\"[\\\"厦门市\\\"]\",\"dataId\":\"1367\",\"id\":\"116\",\"name\":\"厦门市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":117,\"values\":{\"codes\":\"[\\\"莆田市\\\"]\",\"dataId\":\"1374\",\"id\":\"117\",\"name\":\"莆田市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":118,\"values\":{\"codes\":\"[\\\"三明市\\\"]\",\"dataId\":\"1380\",\"id\":\"118\",\"name\":\"三明市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":119,\"values\":{\"codes\":\"[\\\"泉州市\\\"]\",\"dataId\":\"1393\",\"id\":\"119\",\"name\":\"泉州市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":120,\"values\":{\"codes\":\"[\\\"漳州市\\\"]\",\"dataId\":\"1406\",\"id\":\"120\",\"name\":\"漳州市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":121,\"values\":{\"codes\":\"[\\\"南平市\\\"]\",\"dataId\":\"1418\",\"id\":\"121\",\"name\":\"南平市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":122,\"values\":{\"codes\":\"[\\\"龙岩市\\\"]\",\"dataId\":\"1429\",\"id\":\"122\",\"name\":\"龙岩市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":123,\"values\":{\"codes\":\"[\\\"宁德市\\\"]\",\"dataId\":\"1437\",\"id\":\"123\",\"name\":\"宁德市\",\"provinceId\":\"13\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":124,\"values\":{\"codes\":\"[\\\"南昌市\\\"]\",\"dataId\":\"1448\",\"id\":\"124\",\"name\":\"南昌市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":125,\"values\":{\"codes\":\"[\\\"景德镇市\\\"]\",\"dataId\":\"1457\",\"id\":\"125\",\"name\":\"景德镇市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":126,\"values\":{\"codes\":\"[\\\"萍乡市\\\"]\",\"dataId\":\"1462\",\"id\":\"126\",\"name\":\"萍乡市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":127,\"values\":{\"codes\":\"[\\\"九江市\\\"]\",\"dataId\":\"1468\",\"id\":\"127\",\"name\":\"九江市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":128,\"values\":{\"codes\":\"[\\\"新余市\\\"]\",\"dataId\":\"1481\",\"id\":\"128\",\"name\":\"新余市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":129,\"values\":{\"codes\":\"[\\\"鹰潭市\\\"]\",\"dataId\":\"1484\",\"id\":\"129\",\"name\":\"鹰潭市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":130,\"values\":{\"codes\":\"[\\\"赣州市\\\"]\",\"dataId\":\"1488\",\"id\":\"130\",\"name\":\"赣州市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":131,\"values\":{\"codes\":\"[\\\"吉安市\\\"]\",\"dataId\":\"1507\",\"id\":\"131\",\"name\":\"吉安市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":132,\"values\":{\"codes\":\"[\\\"宜春市\\\"]\",\"dataId\":\"1521\",\"id\":\"132\",\"name\":\"宜春市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":133,\"values\":{\"codes\":\"[\\\"抚州市\\\"]\",\"dataId\":\"1532\",\"id\":\"133\",\"name\":\"抚州市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":134,\"values\":{\"codes\":\"[\\\"上饶市\\\"]\",\"dataId\":\"1544\",\"id\":\"134\",\"name\":\"上饶市\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":135,\"values\":{\"codes\":\"[\\\"济南市\\\"]\",\"dataId\":\"1558\",\"id\":\"135\",\"name\":\"济南市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":136,\"values\":{\"codes\":\"[\\\"青岛市\\\"]\",\"dataId\":\"1569\",\"id\":\"136\",\"name\":\"青岛市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":137,\"values\":{\"codes\":\"[\\\"淄博市\\\"]\",\"dataId\":\"1582\",\"id\":\"137\",\"name\":\"淄博市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":138,\"values\":{\"codes\":\"[\\\"枣庄市\\\"]\",\"dataId\":\"1591\",\"id\":\"138\",\"name\":\"枣庄市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":139,\"values\":{\"codes\":\"[\\\"东营市\\\"]\",\"dataId\":\"1597\",\"id\":\"139\",\"name\":\"东营市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":140,\"values\":{\"codes\":\"[\\\"烟台市\\\"]\",\"dataId\":\"1603\",\"id\":\"140\",\"name\":\"烟台市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":141,\"values\":{\"codes\":\"[\\\"潍坊市\\\"]\",\"dataId\":\"1616\",\"id\":\"141\",\"name\":\"潍坊市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":142,\"values\":{\"codes\":\"[\\\"济宁市\\\"]\",\"dataId\":\"1629\",\"id\":\"142\",\"name\":\"济宁市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":143,\"values\":{\"codes\":\"[\\\"泰安市\\\"]\",\"dataId\":\"1641\",\"id\":\"143\",\"name\":\"泰安市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":144,\"values\":{\"codes\":\"[\\\"威海市\\\"]\",\"dataId\":\"1648\",\"id\":\"144\",\"name\":\"威海市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":145,\"values\":{\"codes\":\"[\\\"日照市\\\"]\",\"dataId\":\"1653\",\"id\":\"145\",\"name\":\"日照市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":146,\"values\":{\"codes\":\"[\\\"莱芜市\\\"]\",\"dataId\":\"1658\",\"id\":\"146\",\"name\":\"莱芜市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":147,\"values\":{\"codes\":\"[\\\"临沂市\\\"]\",\"dataId\":\"1661\",\"id\":\"147\",\"name\":\"临沂市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":148,\"values\":{\"codes\":\"[\\\"德州市\\\"]\",\"dataId\":\"1673\",\"id\":\"148\",\"name\":\"德州市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":149,\"values\":{\"codes\":\"[\\\"聊城市\\\"]\",\"dataId\":\"1685\",\"id\":\"149\",\"name\":\"聊城市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":150,\"values\":{\"codes\":\"[\\\"滨州市\\\"]\",\"dataId\":\"1694\",\"id\":\"150\",\"name\":\"滨州市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":151,\"values\":{\"codes\":\"[\\\"菏泽市\\\"]\",\"dataId\":\"1702\",\"id\":\"151\",\"name\":\"菏泽市\",\"provinceId\":\"15\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":152,\"values\":{\"codes\":\"[\\\"郑州市\\\"]\",\"dataId\":\"1713\",\"id\":\"152\",\"name\":\"郑州市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":153,\"values\":{\"codes\":\"[\\\"开封市\\\"]\",\"dataId\":\"1726\",\"id\":\"153\",\"name\":\"开封市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":154,\"values\":{\"codes\":\"[\\\"洛阳市\\\"]\",\"dataId\":\"1734\",\"id\":\"154\",\"name\":\"洛阳市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":155,\"values\":{\"codes\":\"[\\\"平顶山市\\\"]\",\"dataId\":\"1750\",\"id\":\"155\",\"name\":\"平顶山市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":156,\"values\":{\"codes\":\"[\\\"安阳市\\\"]\",\"dataId\":\"1760\",\"id\":\"156\",\"name\":\"安阳市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":157,\"values\":{\"codes\":\"[\\\"鹤壁市\\\"]\",\"dataId\":\"1770\",\"id\":\"157\",\"name\":\"鹤壁市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":158,\"values\":{\"codes\":\"[\\\"新乡市\\\"]\",\"dataId\":\"1776\",\"id\":\"158\",\"name\":\"新乡市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":159,\"values\":{\"codes\":\"[\\\"焦作市\\\"]\",\"dataId\":\"1789\",\"id\":\"159\",\"name\":\"焦作市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":160,\"values\":{\"codes\":\"[\\\"濮阳市\\\"]\",\"dataId\":\"1801\",\"id\":\"160\",\"name\":\"濮阳市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":161,\"values\":{\"codes\":\"[\\\"许昌市\\\"]\",\"dataId\":\"1808\",\"id\":\"161\",\"name\":\"许昌市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":162,\"values\":{\"codes\":\"[\\\"漯河市\\\"]\",\"dataId\":\"1815\",\"id\":\"162\",\"name\":\"漯河市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":163,\"values\":{\"codes\":\"[\\\"三门峡市\\\"]\",\"dataId\":\"1821\",\"id\":\"163\",\"name\":\"三门峡市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":164,\"values\":{\"codes\":\"[\\\"南阳市\\\"]\",\"dataId\":\"1829\",\"id\":\"164\",\"name\":\"南阳市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":165,\"values\":{\"codes\":\"[\\\"商丘市\\\"]\",\"dataId\":\"1843\",\"id\":\"165\",\"name\":\"商丘市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":166,\"values\":{\"codes\":\"[\\\"信阳市\\\"]\",\"dataId\":\"1853\",\"id\":\"166\",\"name\":\"信阳市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":167,\"values\":{\"codes\":\"[\\\"周口市\\\"]\",\"dataId\":\"1864\",\"id\":\"167\",\"name\":\"周口市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":168,\"values\":{\"codes\":\"[\\\"驻马店市\\\"]\",\"dataId\":\"1875\",\"id\":\"168\",\"name\":\"驻马店市\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":169,\"values\":{\"codes\":\"[\\\"武汉市\\\"]\",\"dataId\":\"1887\",\"id\":\"169\",\"name\":\"武汉市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":170,\"values\":{\"codes\":\"[\\\"黄石市\\\"]\",\"dataId\":\"1900\",\"id\":\"170\",\"name\":\"黄石市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":171,\"values\":{\"codes\":\"[\\\"十堰市\\\"]\",\"dataId\":\"1907\",\"id\":\"171\",\"name\":\"十堰市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":172,\"values\":{\"codes\":\"[\\\"宜昌市\\\"]\",\"dataId\":\"1916\",\"id\":\"172\",\"name\":\"宜昌市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":173,\"values\":{\"codes\":\"[\\\"襄樊市\\\"]\",\"dataId\":\"1930\",\"id\":\"173\",\"name\":\"襄樊市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":174,\"values\":{\"codes\":\"[\\\"鄂州市\\\"]\",\"dataId\":\"1940\",\"id\":\"174\",\"name\":\"鄂州市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":175,\"values\":{\"codes\":\"[\\\"荆门市\\\"]\",\"dataId\":\"1944\",\"id\":\"175\",\"name\":\"荆门市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":176,\"values\":{\"codes\":\"[\\\"孝感市\\\"]\",\"dataId\":\"1950\",\"id\":\"176\",\"name\":\"孝感市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":177,\"values\":{\"codes\":\"[\\\"荆州市\\\"]\",\"dataId\":\"1958\",\"id\":\"177\",\"name\":\"荆州市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":178,\"values\":{\"codes\":\"[\\\"黄冈市\\\"]\",\"dataId\":\"1967\",\"id\":\"178\",\"name\":\"黄冈市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":179,\"values\":{\"codes\":\"[\\\"咸宁市\\\"]\",\"dataId\":\"1978\",\"id\":\"179\",\"name\":\"咸宁市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":180,\"values\":{\"codes\":\"[\\\"随州市\\\"]\",\"dataId\":\"1985\",\"id\":\"180\",\"name\":\"随州市\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":181,\"values\":{\"codes\":\"[\\\"恩施土家族苗族自治州\\\"]\",\"dataId\":\"1988\",\"id\":\"181\",\"name\":\"恩施土家族苗族自治州\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":182,\"values\":{\"codes\":\"[\\\"神农架\\\"]\",\"dataId\":\"1997\",\"id\":\"182\",\"name\":\"神农架\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":183,\"values\":{\"codes\":\"[\\\"长沙市\\\"]\",\"dataId\":\"2003\",\"id\":\"183\",\"name\":\"长沙市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":184,\"values\":{\"codes\":\"[\\\"株洲市\\\"]\",\"dataId\":\"2013\",\"id\":\"184\",\"name\":\"株洲市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":185,\"values\":{\"codes\":\"[\\\"湘潭市\\\"]\",\"dataId\":\"2023\",\"id\":\"185\",\"name\":\"湘潭市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":186,\"values\":{\"codes\":\"[\\\"衡阳市\\\"]\",\"dataId\":\"2029\",\"id\":\"186\",\"name\":\"衡阳市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":187,\"values\":{\"codes\":\"[\\\"邵阳市\\\"]\",\"dataId\":\"2042\",\"id\":\"187\",\"name\":\"邵阳市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":188,\"values\":{\"codes\":\"[\\\"岳阳市\\\"]\",\"dataId\":\"2055\",\"id\":\"188\",\"name\":\"岳阳市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":189,\"values\":{\"codes\":\"[\\\"常德市\\\"]\",\"dataId\":\"2065\",\"id\":\"189\",\"name\":\"常德市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":190,\"values\":{\"codes\":\"[\\\"张家界市\\\"]\",\"dataId\":\"2075\",\"id\":\"190\",\"name\":\"张家界市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":191,\"values\":{\"codes\":\"[\\\"益阳市\\\"]\",\"dataId\":\"2080\",\"id\":\"191\",\"name\":\"益阳市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":192,\"values\":{\"codes\":\"[\\\"郴州市\\\"]\",\"dataId\":\"2087\",\"id\":\"192\",\"name\":\"郴州市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":193,\"values\":{\"codes\":\"[\\\"永州市\\\"]\",\"dataId\":\"2099\",\"id\":\"193\",\"name\":\"永州市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":194,\"values\":{\"codes\":\"[\\\"怀化市\\\"]\",\"dataId\":\"2111\",\"id\":\"194\",\"name\":\"怀化市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":195,\"values\":{\"codes\":\"[\\\"娄底市\\\"]\",\"dataId\":\"2124\",\"id\":\"195\",\"name\":\"娄底市\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":196,\"values\":{\"codes\":\"[\\\"湘西土家族苗族自治州\\\"]\",\"dataId\":\"2130\",\"id\":\"196\",\"name\":\"湘西土家族苗族自治州\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":197,\"values\":{\"codes\":\"[\\\"广州市\\\"]\",\"dataId\":\"2140\",\"id\":\"197\",\"name\":\"广州市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":198,\"values\":{\"codes\":\"[\\\"韶关市\\\"]\",\"dataId\":\"2152\",\"id\":\"198\",\"name\":\"韶关市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":199,\"values\":{\"codes\":\"[\\\"深圳市\\\"]\",\"dataId\":\"2163\",\"id\":\"199\",\"name\":\"深圳市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":200,\"values\":{\"codes\":\"[\\\"珠海市\\\"]\",\"dataId\":\"2169\",\"id\":\"200\",\"name\":\"珠海市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":201,\"values\":{\"codes\":\"[\\\"汕头市\\\"]\",\"dataId\":\"2173\",\"id\":\"201\",\"name\":\"汕头市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":202,\"values\":{\"codes\":\"[\\\"佛山市\\\"]\",\"dataId\":\"2181\",\"id\":\"202\",\"name\":\"佛山市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":203,\"values\":{\"codes\":\"[\\\"江门市\\\"]\",\"dataId\":\"2187\",\"id\":\"203\",\"name\":\"江门市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":204,\"values\":{\"codes\":\"[\\\"湛江市\\\"]\",\"dataId\":\"2195\",\"id\":\"204\",\"name\":\"湛江市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":205,\"values\":{\"codes\":\"[\\\"茂名市\\\"]\",\"dataId\":\"2205\",\"id\":\"205\",\"name\":\"茂名市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":206,\"values\":{\"codes\":\"[\\\"肇庆市\\\"]\",\"dataId\":\"2212\",\"id\":\"206\",\"name\":\"肇庆市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":207,\"values\":{\"codes\":\"[\\\"惠州市\\\"]\",\"dataId\":\"2221\",\"id\":\"207\",\"name\":\"惠州市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":208,\"values\":{\"codes\":\"[\\\"梅州市\\\"]\",\"dataId\":\"2227\",\"id\":\"208\",\"name\":\"梅州市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":209,\"values\":{\"codes\":\"[\\\"汕尾市\\\"]\",\"dataId\":\"2236\",\"id\":\"209\",\"name\":\"汕尾市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":210,\"values\":{\"codes\":\"[\\\"河源市\\\"]\",\"dataId\":\"2240\",\"id\":\"210\",\"name\":\"河源市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":211,\"values\":{\"codes\":\"[\\\"阳江市\\\"]\",\"dataId\":\"2247\",\"id\":\"211\",\"name\":\"阳江市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":212,\"values\":{\"codes\":\"[\\\"清远市\\\"]\",\"dataId\":\"2252\",\"id\":\"212\",\"name\":\"清远市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":213,\"values\":{\"codes\":\"[\\\"东莞市\\\"]\",\"dataId\":\"2261\",\"id\":\"213\",\"name\":\"东莞市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":214,\"values\":{\"codes\":\"[\\\"中山市\\\"]\",\"dataId\":\"2262\",\"id\":\"214\",\"name\":\"中山市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":215,\"values\":{\"codes\":\"[\\\"潮州市\\\"]\",\"dataId\":\"2263\",\"id\":\"215\",\"name\":\"潮州市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":216,\"values\":{\"codes\":\"[\\\"揭阳市\\\"]\",\"dataId\":\"2267\",\"id\":\"216\",\"name\":\"揭阳市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":217,\"values\":{\"codes\":\"[\\\"云浮市\\\"]\",\"dataId\":\"2273\",\"id\":\"217\",\"name\":\"云浮市\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":218,\"values\":{\"codes\":\"[\\\"南宁市\\\"]\",\"dataId\":\"2280\",\"id\":\"218\",\"name\":\"南宁市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":219,\"values\":{\"codes\":\"[\\\"柳州市\\\"]\",\"dataId\":\"2293\",\"id\":\"219\",\"name\":\"柳州市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":220,\"values\":{\"codes\":\"[\\\"桂林市\\\"]\",\"dataId\":\"2304\",\"id\":\"220\",\"name\":\"桂林市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":221,\"values\":{\"codes\":\"[\\\"梧州市\\\"]\",\"dataId\":\"2322\",\"id\":\"221\",\"name\":\"梧州市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":222,\"values\":{\"codes\":\"[\\\"北海市\\\"]\",\"dataId\":\"2330\",\"id\":\"222\",\"name\":\"北海市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":223,\"values\":{\"codes\":\"[\\\"防城港市\\\"]\",\"dataId\":\"2335\",\"id\":\"223\",\"name\":\"防城港市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":224,\"values\":{\"codes\":\"[\\\"钦州市\\\"]\",\"dataId\":\"2340\",\"id\":\"224\",\"name\":\"钦州市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":225,\"values\":{\"codes\":\"[\\\"贵港市\\\"]\",\"dataId\":\"2345\",\"id\":\"225\",\"name\":\"贵港市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":226,\"values\":{\"codes\":\"[\\\"玉林市\\\"]\",\"dataId\":\"2351\",\"id\":\"226\",\"name\":\"玉林市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":227,\"values\":{\"codes\":\"[\\\"百色市\\\"]\",\"dataId\":\"2358\",\"id\":\"227\",\"name\":\"百色市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":228,\"values\":{\"codes\":\"[\\\"贺州市\\\"]\",\"dataId\":\"2371\",\"id\":\"228\",\"name\":\"贺州市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":229,\"values\":{\"codes\":\"[\\\"河池市\\\"]\",\"dataId\":\"2376\",\"id\":\"229\",\"name\":\"河池市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":230,\"values\":{\"codes\":\"[\\\"来宾市\\\"]\",\"dataId\":\"2388\",\"id\":\"230\",\"name\":\"来宾市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":231,\"values\":{\"codes\":\"[\\\"崇左市\\\"]\",\"dataId\":\"2395\",\"id\":\"231\",\"name\":\"崇左市\",\"provinceId\":\"20\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":232,\"values\":{\"codes\":\"[\\\"海口市\\\"]\",\"dataId\":\"2404\",\"id\":\"232\",\"name\":\"海口市\",\"provinceId\":\"21\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":233,\"values\":{\"codes\":\"[\\\"三亚市\\\"]\",\"dataId\":\"2409\",\"id\":\"233\",\"name\":\"三亚市\",\"provinceId\":\"21\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":234,\"values\":{\"codes\":\"[\\\"重庆市\\\"]\",\"dataId\":\"2430\",\"id\":\"234\",\"name\":\"重庆市\",\"provinceId\":\"22\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":235,\"values\":{\"codes\":\"[\\\"成都市\\\"]\",\"dataId\":\"2470\",\"id\":\"235\",\"name\":\"成都市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":236,\"values\":{\"codes\":\"[\\\"自贡市\\\"]\",\"dataId\":\"2490\",\"id\":\"236\",\"name\":\"自贡市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":237,\"values\":{\"codes\":\"[\\\"攀枝花市\\\"]\",\"dataId\":\"2497\",\"id\":\"237\",\"name\":\"攀枝花市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":238,\"values\":{\"codes\":\"[\\\"泸州市\\\"]\",\"dataId\":\"2503\",\"id\":\"238\",\"name\":\"泸州市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":239,\"values\":{\"codes\":\"[\\\"德阳市\\\"]\",\"dataId\":\"2511\",\"id\":\"239\",\"name\":\"德阳市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":240,\"values\":{\"codes\":\"[\\\"绵阳市\\\"]\",\"dataId\":\"2518\",\"id\":\"240\",\"name\":\"绵阳市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":241,\"values\":{\"codes\":\"[\\\"广元市\\\"]\",\"dataId\":\"2528\",\"id\":\"241\",\"name\":\"广元市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":242,\"values\":{\"codes\":\"[\\\"遂宁市\\\"]\",\"dataId\":\"2535\",\"id\":\"242\",\"name\":\"遂宁市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":243,\"values\":{\"codes\":\"[\\\"内江市\\\"]\",\"dataId\":\"2541\",\"id\":\"243\",\"name\":\"内江市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":244,\"values\":{\"codes\":\"[\\\"乐山市\\\"]\",\"dataId\":\"2546\",\"id\":\"244\",\"name\":\"乐山市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":245,\"values\":{\"codes\":\"[\\\"南充市\\\"]\",\"dataId\":\"2557\",\"id\":\"245\",\"name\":\"南充市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":246,\"values\":{\"codes\":\"[\\\"眉山市\\\"]\",\"dataId\":\"2567\",\"id\":\"246\",\"name\":\"眉山市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":247,\"values\":{\"codes\":\"[\\\"宜宾市\\\"]\",\"dataId\":\"2574\",\"id\":\"247\",\"name\":\"宜宾市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":248,\"values\":{\"codes\":\"[\\\"广安市\\\"]\",\"dataId\":\"2585\",\"id\":\"248\",\"name\":\"广安市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":249,\"values\":{\"codes\":\"[\\\"达州市\\\"]\",\"dataId\":\"2591\",\"id\":\"249\",\"name\":\"达州市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":250,\"values\":{\"codes\":\"[\\\"雅安市\\\"]\",\"dataId\":\"2599\",\"id\":\"250\",\"name\":\"雅安市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":251,\"values\":{\"codes\":\"[\\\"巴中市\\\"]\",\"dataId\":\"2608\",\"id\":\"251\",\"name\":\"巴中市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":252,\"values\":{\"codes\":\"[\\\"资阳市\\\"]\",\"dataId\":\"2613\",\"id\":\"252\",\"name\":\"资阳市\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":253,\"values\":{\"codes\":\"[\\\"阿坝藏族羌族自治州\\\"]\",\"dataId\":\"2618\",\"id\":\"253\",\"name\":\"阿坝藏族羌族自治州\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":254,\"values\":{\"codes\":\"[\\\"甘孜藏族自治州\\\"]\",\"dataId\":\"2632\",\"id\":\"254\",\"name\":\"甘孜藏族自治州\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":255,\"values\":{\"codes\":\"[\\\"凉山彝族自治州\\\"]\",\"dataId\":\"2651\",\"id\":\"255\",\"name\":\"凉山彝族自治州\",\"provinceId\":\"23\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":256,\"values\":{\"codes\":\"[\\\"贵阳市\\\"]\",\"dataId\":\"2670\",\"id\":\"256\",\"name\":\"贵阳市\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":257,\"values\":{\"codes\":\"[\\\"六盘水市\\\"]\",\"dataId\":\"2680\",\"id\":\"257\",\"name\":\"六盘水市\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":258,\"values\":{\"codes\":\"[\\\"遵义市\\\"]\",\"dataId\":\"2685\",\"id\":\"258\",\"name\":\"遵义市\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":259,\"values\":{\"codes\":\"[\\\"安顺市\\\"]\",\"dataId\":\"2700\",\"id\":\"259\",\"name\":\"安顺市\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":260,\"values\":{\"codes\":\"[\\\"铜仁地区\\\"]\",\"dataId\":\"2707\",\"id\":\"260\",\"name\":\"铜仁地区\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":261,\"values\":{\"codes\":\"[\\\"黔西南布依族苗族自治州\\\"]\",\"dataId\":\"2718\",\"id\":\"261\",\"name\":\"黔西南布依族苗族自治州\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":262,\"values\":{\"codes\":\"[\\\"毕节地区\\\"]\",\"dataId\":\"2727\",\"id\":\"262\",\"name\":\"毕节地区\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":263,\"values\":{\"codes\":\"[\\\"黔东南苗族侗族自治州\\\"]\",\"dataId\":\"2736\",\"id\":\"263\",\"name\":\"黔东南苗族侗族自治州\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":264,\"values\":{\"codes\":\"[\\\"黔南布依族苗族自治州\\\"]\",\"dataId\":\"2753\",\"id\":\"264\",\"name\":\"黔南布依族苗族自治州\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":265,\"values\":{\"codes\":\"[\\\"昆明市\\\"]\",\"dataId\":\"2767\",\"id\":\"265\",\"name\":\"昆明市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":266,\"values\":{\"codes\":\"[\\\"曲靖市\\\"]\",\"dataId\":\"2782\",\"id\":\"266\",\"name\":\"曲靖市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":267,\"values\":{\"codes\":\"[\\\"玉溪市\\\"]\",\"dataId\":\"2792\",\"id\":\"267\",\"name\":\"玉溪市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":268,\"values\":{\"codes\":\"[\\\"保山市\\\"]\",\"dataId\":\"2802\",\"id\":\"268\",\"name\":\"保山市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":269,\"values\":{\"codes\":\"[\\\"昭通市\\\"]\",\"dataId\":\"2808\",\"id\":\"269\",\"name\":\"昭通市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":270,\"values\":{\"codes\":\"[\\\"丽江市\\\"]\",\"dataId\":\"2820\",\"id\":\"270\",\"name\":\"丽江市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":271,\"values\":{\"codes\":\"[\\\"思茅市\\\"]\",\"dataId\":\"2826\",\"id\":\"271\",\"name\":\"思茅市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":272,\"values\":{\"codes\":\"[\\\"临沧市\\\"]\",\"dataId\":\"2837\",\"id\":\"272\",\"name\":\"临沧市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":273,\"values\":{\"codes\":\"[\\\"楚雄彝族自治州\\\"]\",\"dataId\":\"2846\",\"id\":\"273\",\"name\":\"楚雄彝族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":274,\"values\":{\"codes\":\"[\\\"红河哈尼族彝族自治州\\\"]\",\"dataId\":\"2857\",\"id\":\"274\",\"name\":\"红河哈尼族彝族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":275,\"values\":{\"codes\":\"[\\\"文山壮族苗族自治州\\\"]\",\"dataId\":\"2871\",\"id\":\"275\",\"name\":\"文山壮族苗族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":276,\"values\":{\"codes\":\"[\\\"西双版纳傣族自治州\\\"]\",\"dataId\":\"2880\",\"id\":\"276\",\"name\":\"西双版纳傣族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":277,\"values\":{\"codes\":\"[\\\"大理白族自治州\\\"]\",\"dataId\":\"2884\",\"id\":\"277\",\"name\":\"大理白族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":278,\"values\":{\"codes\":\"[\\\"德宏傣族景颇族自治州\\\"]\",\"dataId\":\"2897\",\"id\":\"278\",\"name\":\"德宏傣族景颇族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":279,\"values\":{\"codes\":\"[\\\"怒江傈僳族自治州\\\"]\",\"dataId\":\"2903\",\"id\":\"279\",\"name\":\"怒江傈僳族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":280,\"values\":{\"codes\":\"[\\\"迪庆藏族自治州\\\"]\",\"dataId\":\"2908\",\"id\":\"280\",\"name\":\"迪庆藏族自治州\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":281,\"values\":{\"codes\":\"[\\\"拉萨市\\\"]\",\"dataId\":\"2913\",\"id\":\"281\",\"name\":\"拉萨市\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":282,\"values\":{\"codes\":\"[\\\"昌都地区\\\"]\",\"dataId\":\"2922\",\"id\":\"282\",\"name\":\"昌都地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":283,\"values\":{\"codes\":\"[\\\"山南地区\\\"]\",\"dataId\":\"2934\",\"id\":\"283\",\"name\":\"山南地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":284,\"values\":{\"codes\":\"[\\\"日喀则地区\\\"]\",\"dataId\":\"2947\",\"id\":\"284\",\"name\":\"日喀则地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":285,\"values\":{\"codes\":\"[\\\"那曲地区\\\"]\",\"dataId\":\"2966\",\"id\":\"285\",\"name\":\"那曲地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":286,\"values\":{\"codes\":\"[\\\"阿里地区\\\"]\",\"dataId\":\"2977\",\"id\":\"286\",\"name\":\"阿里地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":287,\"values\":{\"codes\":\"[\\\"林芝地区\\\"]\",\"dataId\":\"2985\",\"id\":\"287\",\"name\":\"林芝地区\",\"provinceId\":\"26\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":288,\"values\":{\"codes\":\"[\\\"西安市\\\"]\",\"dataId\":\"2994\",\"id\":\"288\",\"name\":\"西安市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":289,\"values\":{\"codes\":\"[\\\"铜川市\\\"]\",\"dataId\":\"3006\",\"id\":\"289\",\"name\":\"铜川市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":290,\"values\":{\"codes\":\"[\\\"宝鸡市\\\"]\",\"dataId\":\"3011\",\"id\":\"290\",\"name\":\"宝鸡市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":291,\"values\":{\"codes\":\"[\\\"咸阳市\\\"]\",\"dataId\":\"3024\",\"id\":\"291\",\"name\":\"咸阳市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":292,\"values\":{\"codes\":\"[\\\"渭南市\\\"]\",\"dataId\":\"3039\",\"id\":\"292\",\"name\":\"渭南市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":293,\"values\":{\"codes\":\"[\\\"延安市\\\"]\",\"dataId\":\"3051\",\"id\":\"293\",\"name\":\"延安市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":294,\"values\":{\"codes\":\"[\\\"汉中市\\\"]\",\"dataId\":\"3065\",\"id\":\"294\",\"name\":\"汉中市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":295,\"values\":{\"codes\":\"[\\\"榆林市\\\"]\",\"dataId\":\"3077\",\"id\":\"295\",\"name\":\"榆林市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":296,\"values\":{\"codes\":\"[\\\"安康市\\\"]\",\"dataId\":\"3090\",\"id\":\"296\",\"name\":\"安康市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":297,\"values\":{\"codes\":\"[\\\"商洛市\\\"]\",\"dataId\":\"3101\",\"id\":\"297\",\"name\":\"商洛市\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":298,\"values\":{\"codes\":\"[\\\"兰州市\\\"]\",\"dataId\":\"3110\",\"id\":\"298\",\"name\":\"兰州市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":299,\"values\":{\"codes\":\"[\\\"嘉峪关市\\\"]\",\"dataId\":\"3118\",\"id\":\"299\",\"name\":\"嘉峪关市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":300,\"values\":{\"codes\":\"[\\\"金昌市\\\"]\",\"dataId\":\"3119\",\"id\":\"300\",\"name\":\"金昌市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":301,\"values\":{\"codes\":\"[\\\"白银市\\\"]\",\"dataId\":\"3122\",\"id\":\"301\",\"name\":\"白银市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":302,\"values\":{\"codes\":\"[\\\"天水市\\\"]\",\"dataId\":\"3128\",\"id\":\"302\",\"name\":\"天水市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":303,\"values\":{\"codes\":\"[\\\"武威市\\\"]\",\"dataId\":\"3136\",\"id\":\"303\",\"name\":\"武威市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":304,\"values\":{\"codes\":\"[\\\"张掖市\\\"]\",\"dataId\":\"3141\",\"id\":\"304\",\"name\":\"张掖市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":305,\"values\":{\"codes\":\"[\\\"平凉市\\\"]\",\"dataId\":\"3148\",\"id\":\"305\",\"name\":\"平凉市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":306,\"values\":{\"codes\":\"[\\\"酒泉市\\\"]\",\"dataId\":\"3156\",\"id\":\"306\",\"name\":\"酒泉市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":307,\"values\":{\"codes\":\"[\\\"庆阳市\\\"]\",\"dataId\":\"3164\",\"id\":\"307\",\"name\":\"庆阳市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":308,\"values\":{\"codes\":\"[\\\"定西市\\\"]\",\"dataId\":\"3173\",\"id\":\"308\",\"name\":\"定西市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":309,\"values\":{\"codes\":\"[\\\"陇南市\\\"]\",\"dataId\":\"3181\",\"id\":\"309\",\"name\":\"陇南市\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":310,\"values\":{\"codes\":\"[\\\"临夏回族自治州\\\"]\",\"dataId\":\"3191\",\"id\":\"310\",\"name\":\"临夏回族自治州\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":311,\"values\":{\"codes\":\"[\\\"甘南藏族自治州\\\"]\",\"dataId\":\"3200\",\"id\":\"311\",\"name\":\"甘南藏族自治州\",\"provinceId\":\"28\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":312,\"values\":{\"codes\":\"[\\\"西宁市\\\"]\",\"dataId\":\"3210\",\"id\":\"312\",\"name\":\"西宁市\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":313,\"values\":{\"codes\":\"[\\\"海东地区\\\"]\",\"dataId\":\"3217\",\"id\":\"313\",\"name\":\"海东地区\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":314,\"values\":{\"codes\":\"[\\\"海北藏族自治州\\\"]\",\"dataId\":\"3224\",\"id\":\"314\",\"name\":\"海北藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":315,\"values\":{\"codes\":\"[\\\"黄南藏族自治州\\\"]\",\"dataId\":\"3229\",\"id\":\"315\",\"name\":\"黄南藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":316,\"values\":{\"codes\":\"[\\\"海南藏族自治州\\\"]\",\"dataId\":\"3234\",\"id\":\"316\",\"name\":\"海南藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":317,\"values\":{\"codes\":\"[\\\"果洛藏族自治州\\\"]\",\"dataId\":\"3240\",\"id\":\"317\",\"name\":\"果洛藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":318,\"values\":{\"codes\":\"[\\\"玉树藏族自治州\\\"]\",\"dataId\":\"3247\",\"id\":\"318\",\"name\":\"玉树藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":319,\"values\":{\"codes\":\"[\\\"海西蒙古族藏族自治州\\\"]\",\"dataId\":\"3254\",\"id\":\"319\",\"name\":\"海西蒙古族藏族自治州\",\"provinceId\":\"29\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":320,\"values\":{\"codes\":\"[\\\"银川市\\\"]\",\"dataId\":\"3261\",\"id\":\"320\",\"name\":\"银川市\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":321,\"values\":{\"codes\":\"[\\\"石嘴山市\\\"]\",\"dataId\":\"3268\",\"id\":\"321\",\"name\":\"石嘴山市\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":322,\"values\":{\"codes\":\"[\\\"吴忠市\\\"]\",\"dataId\":\"3272\",\"id\":\"322\",\"name\":\"吴忠市\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":323,\"values\":{\"codes\":\"[\\\"固原市\\\"]\",\"dataId\":\"3277\",\"id\":\"323\",\"name\":\"固原市\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":324,\"values\":{\"codes\":\"[\\\"中卫市\\\"]\",\"dataId\":\"3283\",\"id\":\"324\",\"name\":\"中卫市\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":325,\"values\":{\"codes\":\"[\\\"乌鲁木齐市\\\"]\",\"dataId\":\"3288\",\"id\":\"325\",\"name\":\"乌鲁木齐市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":326,\"values\":{\"codes\":\"[\\\"克拉玛依市\\\"]\",\"dataId\":\"3295\",\"id\":\"326\",\"name\":\"克拉玛依市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":327,\"values\":{\"codes\":\"[\\\"吐鲁番地区\\\"]\",\"dataId\":\"3300\",\"id\":\"327\",\"name\":\"吐鲁番地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":328,\"values\":{\"codes\":\"[\\\"哈密地区\\\"]\",\"dataId\":\"3304\",\"id\":\"328\",\"name\":\"哈密地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":329,\"values\":{\"codes\":\"[\\\"昌吉回族自治州\\\"]\",\"dataId\":\"3308\",\"id\":\"329\",\"name\":\"昌吉回族自治州\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":330,\"values\":{\"codes\":\"[\\\"博尔塔拉蒙古自治州\\\"]\",\"dataId\":\"3317\",\"id\":\"330\",\"name\":\"博尔塔拉蒙古自治州\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":331,\"values\":{\"codes\":\"[\\\"巴音郭楞蒙古自治州\\\"]\",\"dataId\":\"3321\",\"id\":\"331\",\"name\":\"巴音郭楞蒙古自治州\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":332,\"values\":{\"codes\":\"[\\\"阿克苏地区\\\"]\",\"dataId\":\"3331\",\"id\":\"332\",\"name\":\"阿克苏地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":333,\"values\":{\"codes\":\"[\\\"克孜勒苏柯尔克孜自治州\\\"]\",\"dataId\":\"3341\",\"id\":\"333\",\"name\":\"克孜勒苏柯尔克孜自治州\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":334,\"values\":{\"codes\":\"[\\\"喀什地区\\\"]\",\"dataId\":\"3346\",\"id\":\"334\",\"name\":\"喀什地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":335,\"values\":{\"codes\":\"[\\\"和田地区\\\"]\",\"dataId\":\"3359\",\"id\":\"335\",\"name\":\"和田地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":336,\"values\":{\"codes\":\"[\\\"伊犁哈萨克自治州\\\"]\",\"dataId\":\"3368\",\"id\":\"336\",\"name\":\"伊犁哈萨克自治州\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":337,\"values\":{\"codes\":\"[\\\"塔城地区\\\"]\",\"dataId\":\"3379\",\"id\":\"337\",\"name\":\"塔城地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":338,\"values\":{\"codes\":\"[\\\"阿勒泰地区\\\"]\",\"dataId\":\"3387\",\"id\":\"338\",\"name\":\"阿勒泰地区\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":339,\"values\":{\"codes\":\"[\\\"石河子市\\\"]\",\"dataId\":\"3395\",\"id\":\"339\",\"name\":\"石河子市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":340,\"values\":{\"codes\":\"[\\\"阿拉尔市\\\"]\",\"dataId\":\"3396\",\"id\":\"340\",\"name\":\"阿拉尔市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":341,\"values\":{\"codes\":\"[\\\"图木舒克市\\\"]\",\"dataId\":\"3397\",\"id\":\"341\",\"name\":\"图木舒克市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":342,\"values\":{\"codes\":\"[\\\"五家渠市\\\"]\",\"dataId\":\"3398\",\"id\":\"342\",\"name\":\"五家渠市\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":343,\"values\":{\"codes\":\"[\\\"香港特别行政区\\\"]\",\"dataId\":\"3400\",\"id\":\"343\",\"name\":\"香港特别行政区\",\"provinceId\":\"32\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":344,\"values\":{\"codes\":\"[\\\"澳门特别行政区\\\"]\",\"dataId\":\"3402\",\"id\":\"344\",\"name\":\"澳门特别行政区\",\"provinceId\":\"33\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":345,\"values\":{\"codes\":\"[\\\"台湾省\\\"]\",\"dataId\":\"3404\",\"id\":\"345\",\"name\":\"台湾省\",\"provinceId\":\"34\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":346,\"values\":{\"codes\":\"[\\\"自治区直辖县级行政区划\\\"]\",\"dataId\":\"3426\",\"id\":\"346\",\"name\":\"自治区直辖县级行政区划\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":347,\"values\":{\"codes\":\"[\\\"省直辖县级行政区划\\\"]\",\"dataId\":\"3427\",\"id\":\"347\",\"name\":\"省直辖县级行政区划\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":348,\"values\":{\"codes\":\"[\\\"省直辖县级行政区划\\\"]\",\"dataId\":\"3428\",\"id\":\"348\",\"name\":\"省直辖县级行政区划\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":349,\"values\":{\"codes\":\"[\\\"普洱市\\\"]\",\"dataId\":\"3429\",\"id\":\"349\",\"name\":\"普洱市\",\"provinceId\":\"25\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":350,\"values\":{\"codes\":\"[\\\"省直辖县级行政区划\\\"]\",\"dataId\":\"3430\",\"id\":\"350\",\"name\":\"省直辖县级行政区划\",\"provinceId\":\"21\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":351,\"values\":{\"codes\":\"[\\\"湖北省省直辖县级行政区划\\\"]\",\"dataId\":\"3446\",\"id\":\"351\",\"name\":\"湖北省省直辖县级行政区划\",\"provinceId\":\"17\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":352,\"values\":{\"codes\":\"[\\\"河南省省直辖县级行政区划\\\"]\",\"dataId\":\"3447\",\"id\":\"352\",\"name\":\"河南省省直辖县级行政区划\",\"provinceId\":\"16\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":353,\"values\":{\"codes\":\"[\\\"海南省省直辖县级行政区划\\\"]\",\"dataId\":\"3448\",\"id\":\"353\",\"name\":\"海南省省直辖县级行政区划\",\"provinceId\":\"21\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":354,\"values\":{\"codes\":\"[\\\"新疆维吾尔自治区省直辖县级行政区划\\\"]\",\"dataId\":\"3449\",\"id\":\"354\",\"name\":\"新疆维吾尔自治区省直辖县级行政区划\",\"provinceId\":\"31\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":355,\"values\":{\"codes\":\"[\\\"宁夏回族自治区省直辖县级行政区划\\\"]\",\"dataId\":\"3450\",\"id\":\"355\",\"name\":\"宁夏回族自治区省直辖县级行政区划\",\"provinceId\":\"30\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":356,\"values\":{\"codes\":\"[\\\"辽宁省省直辖县级行政区划\\\"]\",\"dataId\":\"3451\",\"id\":\"356\",\"name\":\"辽宁省省直辖县级行政区划\",\"provinceId\":\"6\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":357,\"values\":{\"codes\":\"[\\\"广东省省直辖县级行政区划\\\"]\",\"dataId\":\"3452\",\"id\":\"357\",\"name\":\"广东省省直辖县级行政区划\",\"provinceId\":\"19\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":358,\"values\":{\"codes\":\"[\\\"浙江省省直辖县级行政区划\\\"]\",\"dataId\":\"3453\",\"id\":\"358\",\"name\":\"浙江省省直辖县级行政区划\",\"provinceId\":\"11\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":359,\"values\":{\"codes\":\"[\\\"吉林省省直辖县级行政区划\\\"]\",\"dataId\":\"3454\",\"id\":\"359\",\"name\":\"吉林省省直辖县级行政区划\",\"provinceId\":\"7\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":360,\"values\":{\"codes\":\"[\\\"安徽省省直辖县级行政区划\\\"]\",\"dataId\":\"3455\",\"id\":\"360\",\"name\":\"安徽省省直辖县级行政区划\",\"provinceId\":\"12\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":361,\"values\":{\"codes\":\"[\\\"陕西省省直辖县级行政区划\\\"]\",\"dataId\":\"3456\",\"id\":\"361\",\"name\":\"陕西省省直辖县级行政区划\",\"provinceId\":\"27\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":362,\"values\":{\"codes\":\"[\\\"河北省省直辖县级行政区划\\\"]\",\"dataId\":\"3457\",\"id\":\"362\",\"name\":\"河北省省直辖县级行政区划\",\"provinceId\":\"3\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":363,\"values\":{\"codes\":\"[\\\"江苏省省直辖县级行政区划\\\"]\",\"dataId\":\"3458\",\"id\":\"363\",\"name\":\"江苏省省直辖县级行政区划\",\"provinceId\":\"10\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":364,\"values\":{\"codes\":\"[\\\"黑龙江省省直辖县级行政区划\\\"]\",\"dataId\":\"3459\",\"id\":\"364\",\"name\":\"黑龙江省省直辖县级行政区划\",\"provinceId\":\"8\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":365,\"values\":{\"codes\":\"[\\\"江西省省直辖县级行政区划\\\"]\",\"dataId\":\"3460\",\"id\":\"365\",\"name\":\"江西省省直辖县级行政区划\",\"provinceId\":\"14\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":366,\"values\":{\"codes\":\"[\\\"贵州省省直辖县级行政区划\\\"]\",\"dataId\":\"3461\",\"id\":\"366\",\"name\":\"贵州省省直辖县级行政区划\",\"provinceId\":\"24\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]},{\"id\":367,\"values\":{\"codes\":\"[\\\"湖南省省直辖县级行政区划\\\"]\",\"dataId\":\"3462\",\"id\":\"367\",\"name\":\"湖南省省直辖县级行政区划\",\"provinceId\":\"18\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"provinceId\"]}]},{\"name\":\"edgeRegionCountries\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeRegionCountries` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `dataId` varchar(255) DEFAULT NULL COMMENT '原始数据ID',\\n  `pinyin` json DEFAULT NULL COMMENT '拼音',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='区域-国家/地区'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"dataId\",\"definition\":\"varchar(255) COMMENT '原始数据ID'\"},{\"name\":\"pinyin\",\"definition\":\"json COMMENT '拼音'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[{\"id\":1,\"values\":{\"codes\":\"[\\\"中国\\\"]\",\"dataId\":\"1\",\"id\":\"1\",\"name\":\"中国\",\"pinyin\":\"[\\\"zhong guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":2,\"values\":{\"codes\":\"[\\\"蒙古\\\"]\",\"dataId\":\"2\",\"id\":\"2\",\"name\":\"蒙古\",\"pinyin\":\"[\\\"meng gu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":3,\"values\":{\"codes\":\"[\\\"朝鲜\\\"]\",\"dataId\":\"3\",\"id\":\"3\",\"name\":\"朝鲜\",\"pinyin\":\"[\\\"chao xian\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":4,\"values\":{\"codes\":\"[\\\"韩国\\\"]\",\"dataId\":\"4\",\"id\":\"4\",\"name\":\"韩国\",\"pinyin\":\"[\\\"han guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":5,\"values\":{\"codes\":\"[\\\"日本\\\"]\",\"dataId\":\"5\",\"id\":\"5\",\"name\":\"日本\",\"pinyin\":\"[\\\"ri ben\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":6,\"values\":{\"codes\":\"[\\\"菲律宾\\\"]\",\"dataId\":\"6\",\"id\":\"6\",\"name\":\"菲律宾\",\"pinyin\":\"[\\\"fei lv bin\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":7,\"values\":{\"codes\":\"[\\\"越南\\\"]\",\"dataId\":\"7\",\"id\":\"7\",\"name\":\"越南\",\"pinyin\":\"[\\\"yue nan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":8,\"values\":{\"codes\":\"[\\\"老挝\\\"]\",\"dataId\":\"8\",\"id\":\"8\",\"name\":\"老挝\",\"pinyin\":\"[\\\"lao wo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":9,\"values\":{\"codes\":\"[\\\"柬埔寨\\\"]\",\"dataId\":\"9\",\"id\":\"9\",\"name\":\"柬埔寨\",\"pinyin\":\"[\\\"jian pu zhai\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":10,\"values\":{\"codes\":\"[\\\"缅甸\\\"]\",\"dataId\":\"10\",\"id\":\"10\",\"name\":\"缅甸\",\"pinyin\":\"[\\\"mian dian\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":11,\"values\":{\"codes\":\"[\\\"泰国\\\"]\",\"dataId\":\"11\",\"id\":\"11\",\"name\":\"泰国\",\"pinyin\":\"[\\\"tai guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":12,\"values\":{\"codes\":\"[\\\"马来西亚\\\"]\",\"dataId\":\"12\",\"id\":\"12\",\"name\":\"马来西亚\",\"pinyin\":\"[\\\"ma lai xi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":13,\"values\":{\"codes\":\"[\\\"文莱\\\"]\",\"dataId\":\"13\",\"id\":\"13\",\"name\":\"文莱\",\"pinyin\":\"[\\\"wen lai\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":14,\"values\":{\"codes\":\"[\\\"新加坡\\\"]\",\"dataId\":\"14\",\"id\":\"14\",\"name\":\"新加坡\",\"pinyin\":\"[\\\"xin jia po\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":15,\"values\":{\"codes\":\"[\\\"印度尼西亚\\\"]\",\"dataId\":\"15\",\"id\":\"15\",\"name\":\"印度尼西亚\",\"pinyin\":\"[\\\"yin du ni xi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":16,\"values\":{\"codes\":\"[\\\"东帝汶\\\"]\",\"dataId\":\"16\",\"id\":\"16\",\"name\":\"东帝汶\",\"pinyin\":\"[\\\"dong di wen\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":17,\"values\":{\"codes\":\"[\\\"尼泊尔\\\"]\",\"dataId\":\"17\",\"id\":\"17\",\"name\":\"尼泊尔\",\"pinyin\":\"[\\\"ni po er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":18,\"values\":{\"codes\":\"[\\\"不丹\\\"]\",\"dataId\":\"18\",\"id\":\"18\",\"name\":\"不丹\",\"pinyin\":\"[\\\"bu dan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":19,\"values\":{\"codes\":\"[\\\"孟加拉国\\\", \\\"孟加拉\\\"]\",\"dataId\":\"19\",\"id\":\"19\",\"name\":\"孟加拉国\",\"pinyin\":\"[\\\"meng jia la guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":20,\"values\":{\"codes\":\"[\\\"印度\\\"]\",\"dataId\":\"20\",\"id\":\"20\",\"name\":\"印度\",\"pinyin\":\"[\\\"yin du\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":21,\"values\":{\"codes\":\"[\\\"巴基斯坦\\\"]\",\"dataId\":\"21\",\"id\":\"21\",\"name\":\"巴基斯坦\",\"pinyin\":\"[\\\"ba ji si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":22,\"values\":{\"codes\":\"[\\\"斯里兰卡\\\"]\",\"dataId\":\"22\",\"id\":\"22\",\"name\":\"斯里兰卡\",\"pinyin\":\"[\\\"si li lan ka\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":23,\"values\":{\"codes\":\"[\\\"马尔代夫\\\"]\",\"dataId\":\"23\",\"id\":\"23\",\"name\":\"马尔代夫\",\"pinyin\":\"[\\\"ma er dai fu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":24,\"values\":{\"codes\":\"[\\\"哈萨克斯坦\\\"]\",\"dataId\":\"24\",\"id\":\"24\",\"name\":\"哈萨克斯坦\",\"pinyin\":\"[\\\"ha sa ke si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":25,\"values\":{\"codes\":\"[\\\"吉尔吉斯斯坦\\\"]\",\"dataId\":\"25\",\"id\":\"25\",\"name\":\"吉尔吉斯斯坦\",\"pinyin\":\"[\\\"ji er ji si si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":26,\"values\":{\"codes\":\"[\\\"塔吉克斯坦\\\"]\",\"dataId\":\"26\",\"id\":\"26\",\"name\":\"塔吉克斯坦\",\"pinyin\":\"[\\\"ta ji ke si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":27,\"values\":{\"codes\":\"[\\\"乌兹别克斯坦\\\"]\",\"dataId\":\"27\",\"id\":\"27\",\"name\":\"乌兹别克斯坦\",\"pinyin\":\"[\\\"wu zi bie ke si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":28,\"values\":{\"codes\":\"[\\\"土库曼斯坦\\\"]\",\"dataId\":\"28\",\"id\":\"28\",\"name\":\"土库曼斯坦\",\"pinyin\":\"[\\\"tu ku man si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":29,\"values\":{\"codes\":\"[\\\"阿富汗\\\"]\",\"dataId\":\"29\",\"id\":\"29\",\"name\":\"阿富汗\",\"pinyin\":\"[\\\"a fu han\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":30,\"values\":{\"codes\":\"[\\\"伊拉克\\\"]\",\"dataId\":\"30\",\"id\":\"30\",\"name\":\"伊拉克\",\"pinyin\":\"[\\\"yi la ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":31,\"values\":{\"codes\":\"[\\\"伊朗\\\"]\",\"dataId\":\"31\",\"id\":\"31\",\"name\":\"伊朗\",\"pinyin\":\"[\\\"yi lang\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":32,\"values\":{\"codes\":\"[\\\"叙利亚\\\"]\",\"dataId\":\"32\",\"id\":\"32\",\"name\":\"叙利亚\",\"pinyin\":\"[\\\"xu li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":33,\"values\":{\"codes\":\"[\\\"约旦\\\"]\",\"dataId\":\"33\",\"id\":\"33\",\"name\":\"约旦\",\"pinyin\":\"[\\\"yue dan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":34,\"values\":{\"codes\":\"[\\\"黎巴嫩\\\"]\",\"dataId\":\"34\",\"id\":\"34\",\"name\":\"黎巴嫩\",\"pinyin\":\"[\\\"li ba nen\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":35,\"values\":{\"codes\":\"[\\\"以色列\\\"]\",\"dataId\":\"35\",\"id\":\"35\",\"name\":\"以色列\",\"pinyin\":\"[\\\"yi se lie\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":36,\"values\":{\"codes\":\"[\\\"巴勒斯坦\\\"]\",\"dataId\":\"36\",\"id\":\"36\",\"name\":\"巴勒斯坦\",\"pinyin\":\"[\\\"ba lei si tan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":37,\"values\":{\"codes\":\"[\\\"沙特阿拉伯\\\"]\",\"dataId\":\"37\",\"id\":\"37\",\"name\":\"沙特阿拉伯\",\"pinyin\":\"[\\\"sha te a la bo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":38,\"values\":{\"codes\":\"[\\\"巴林\\\"]\",\"dataId\":\"38\",\"id\":\"38\",\"name\":\"巴林\",\"pinyin\":\"[\\\"ba lin\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":39,\"values\":{\"codes\":\"[\\\"卡塔尔\\\"]\",\"dataId\":\"39\",\"id\":\"39\",\"name\":\"卡塔尔\",\"pinyin\":\"[\\\"ka ta er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":40,\"values\":{\"codes\":\"[\\\"科威特\\\"]\",\"dataId\":\"40\",\"id\":\"40\",\"name\":\"科威特\",\"pinyin\":\"[\\\"ke wei te\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":41,\"values\":{\"codes\":\"[\\\"阿拉伯联合酋长国\\\", \\\"阿联酋\\\"]\",\"dataId\":\"41\",\"id\":\"41\",\"name\":\"阿拉伯联合酋长国\",\"pinyin\":\"[\\\"a la bo lian he qiu zhang guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":42,\"values\":{\"codes\":\"[\\\"阿曼\\\"]\",\"dataId\":\"42\",\"id\":\"42\",\"name\":\"阿曼\",\"pinyin\":\"[\\\"a man\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":43,\"values\":{\"codes\":\"[\\\"也门\\\"]\",\"dataId\":\"43\",\"id\":\"43\",\"name\":\"也门\",\"pinyin\":\"[\\\"ye men\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":44,\"values\":{\"codes\":\"[\\\"格鲁吉亚\\\"]\",\"dataId\":\"44\",\"id\":\"44\",\"name\":\"格鲁吉亚\",\"pinyin\":\"[\\\"ge lu ji ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":45,\"values\":{\"codes\":\"[\\\"亚美尼亚\\\"]\",\"dataId\":\"45\",\"id\":\"45\",\"name\":\"亚美尼亚\",\"pinyin\":\"[\\\"ya mei ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":46,\"values\":{\"codes\":\"[\\\"阿塞拜疆\\\"]\",\"dataId\":\"46\",\"id\":\"46\",\"name\":\"阿塞拜疆\",\"pinyin\":\"[\\\"a sai bai jiang\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":47,\"values\":{\"codes\":\"[\\\"土耳其\\\"]\",\"dataId\":\"47\",\"id\":\"47\",\"name\":\"土耳其\",\"pinyin\":\"[\\\"tu er qi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":48,\"values\":{\"codes\":\"[\\\"塞浦路斯\\\"]\",\"dataId\":\"48\",\"id\":\"48\",\"name\":\"塞浦路斯\",\"pinyin\":\"[\\\"sai pu lu si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":49,\"values\":{\"codes\":\"[\\\"芬兰\\\"]\",\"dataId\":\"49\",\"id\":\"49\",\"name\":\"芬兰\",\"pinyin\":\"[\\\"fen lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":50,\"values\":{\"codes\":\"[\\\"瑞典\\\"]\",\"dataId\":\"50\",\"id\":\"50\",\"name\":\"瑞典\",\"pinyin\":\"[\\\"rui dian\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":51,\"values\":{\"codes\":\"[\\\"挪威\\\"]\",\"dataId\":\"51\",\"id\":\"51\",\"name\":\"挪威\",\"pinyin\":\"[\\\"nuo wei\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":52,\"values\":{\"codes\":\"[\\\"冰岛\\\"]\",\"dataId\":\"52\",\"id\":\"52\",\"name\":\"冰岛\",\"pinyin\":\"[\\\"bing dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":53,\"values\":{\"codes\":\"[\\\"丹麦\\\"]\",\"dataId\":\"53\",\"id\":\"53\",\"name\":\"丹麦\",\"pinyin\":\"[\\\"dan mai\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":54,\"values\":{\"codes\":\"[\\\"爱沙尼亚\\\"]\",\"dataId\":\"54\",\"id\":\"54\",\"name\":\"爱沙尼亚\",\"pinyin\":\"[\\\"ai sha ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":55,\"values\":{\"codes\":\"[\\\"拉脱维亚\\\"]\",\"dataId\":\"55\",\"id\":\"55\",\"name\":\"拉脱维亚\",\"pinyin\":\"[\\\"la tuo wei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":56,\"values\":{\"codes\":\"[\\\"立陶宛\\\"]\",\"dataId\":\"56\",\"id\":\"56\",\"name\":\"立陶宛\",\"pinyin\":\"[\\\"li tao wan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":57,\"values\":{\"codes\":\"[\\\"白俄罗斯\\\"]\",\"dataId\":\"57\",\"id\":\"57\",\"name\":\"白俄罗斯\",\"pinyin\":\"[\\\"bai e luo si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":58,\"values\":{\"codes\":\"[\\\"俄罗斯\\\"]\",\"dataId\":\"58\",\"id\":\"58\",\"name\":\"俄罗斯\",\"pinyin\":\"[\\\"e luo si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":59,\"values\":{\"codes\":\"[\\\"乌克兰\\\"]\",\"dataId\":\"59\",\"id\":\"59\",\"name\":\"乌克兰\",\"pinyin\":\"[\\\"wu ke lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":60,\"values\":{\"codes\":\"[\\\"摩尔多瓦\\\"]\",\"dataId\":\"60\",\"id\":\"60\",\"name\":\"摩尔多瓦\",\"pinyin\":\"[\\\"mo er duo wa\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":61,\"values\":{\"codes\":\"[\\\"波兰\\\"]\",\"dataId\":\"61\",\"id\":\"61\",\"name\":\"波兰\",\"pinyin\":\"[\\\"bo lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":62,\"values\":{\"codes\":\"[\\\"捷克\\\"]\",\"dataId\":\"62\",\"id\":\"62\",\"name\":\"捷克\",\"pinyin\":\"[\\\"jie ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":63,\"values\":{\"codes\":\"[\\\"斯洛伐克\\\"]\",\"dataId\":\"63\",\"id\":\"63\",\"name\":\"斯洛伐克\",\"pinyin\":\"[\\\"si luo fa ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":64,\"values\":{\"codes\":\"[\\\"匈牙利\\\"]\",\"dataId\":\"64\",\"id\":\"64\",\"name\":\"匈牙利\",\"pinyin\":\"[\\\"xiong ya li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":65,\"values\":{\"codes\":\"[\\\"德国\\\"]\",\"dataId\":\"65\",\"id\":\"65\",\"name\":\"德国\",\"pinyin\":\"[\\\"de guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":66,\"values\":{\"codes\":\"[\\\"奥地利\\\"]\",\"dataId\":\"66\",\"id\":\"66\",\"name\":\"奥地利\",\"pinyin\":\"[\\\"ao de li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":67,\"values\":{\"codes\":\"[\\\"瑞士\\\"]\",\"dataId\":\"67\",\"id\":\"67\",\"name\":\"瑞士\",\"pinyin\":\"[\\\"rui shi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":68,\"values\":{\"codes\":\"[\\\"列支敦士登\\\"]\",\"dataId\":\"68\",\"id\":\"68\",\"name\":\"列支敦士登\",\"pinyin\":\"[\\\"lie zhi dun shi deng\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":69,\"values\":{\"codes\":\"[\\\"英国\\\"]\",\"dataId\":\"69\",\"id\":\"69\",\"name\":\"英国\",\"pinyin\":\"[\\\"ying guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":70,\"values\":{\"codes\":\"[\\\"爱尔兰\\\"]\",\"dataId\":\"70\",\"id\":\"70\",\"name\":\"爱尔兰\",\"pinyin\":\"[\\\"ai er lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":71,\"values\":{\"codes\":\"[\\\"荷兰\\\"]\",\"dataId\":\"71\",\"id\":\"71\",\"name\":\"荷兰\",\"pinyin\":\"[\\\"he lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":72,\"values\":{\"codes\":\"[\\\"比利时\\\"]\",\"dataId\":\"72\",\"id\":\"72\",\"name\":\"比利时\",\"pinyin\":\"[\\\"bi li shi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":73,\"values\":{\"codes\":\"[\\\"卢森堡\\\"]\",\"dataId\":\"73\",\"id\":\"73\",\"name\":\"卢森堡\",\"pinyin\":\"[\\\"lu sen bao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":74,\"values\":{\"codes\":\"[\\\"法国\\\"]\",\"dataId\":\"74\",\"id\":\"74\",\"name\":\"法国\",\"pinyin\":\"[\\\"fa guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":75,\"values\":{\"codes\":\"[\\\"摩纳哥\\\"]\",\"dataId\":\"75\",\"id\":\"75\",\"name\":\"摩纳哥\",\"pinyin\":\"[\\\"mo na ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":76,\"values\":{\"codes\":\"[\\\"罗马尼亚\\\"]\",\"dataId\":\"76\",\"id\":\"76\",\"name\":\"罗马尼亚\",\"pinyin\":\"[\\\"luo ma ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":77,\"values\":{\"codes\":\"[\\\"保加利亚\\\"]\",\"dataId\":\"77\",\"id\":\"77\",\"name\":\"保加利亚\",\"pinyin\":\"[\\\"bao jia li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":78,\"values\":{\"codes\":\"[\\\"塞尔维亚\\\"]\",\"dataId\":\"78\",\"id\":\"78\",\"name\":\"塞尔维亚\",\"pinyin\":\"[\\\"sai er wei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":79,\"values\":{\"codes\":\"[\\\"马其顿\\\"]\",\"dataId\":\"79\",\"id\":\"79\",\"name\":\"马其顿\",\"pinyin\":\"[\\\"ma qi dun\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":80,\"values\":{\"codes\":\"[\\\"阿尔巴尼亚\\\"]\",\"dataId\":\"80\",\"id\":\"80\",\"name\":\"阿尔巴尼亚\",\"pinyin\":\"[\\\"a er ba ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":81,\"values\":{\"codes\":\"[\\\"希腊\\\"]\",\"dataId\":\"81\",\"id\":\"81\",\"name\":\"希腊\",\"pinyin\":\"[\\\"xi la\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":82,\"values\":{\"codes\":\"[\\\"斯洛文尼亚\\\"]\",\"dataId\":\"82\",\"id\":\"82\",\"name\":\"斯洛文尼亚\",\"pinyin\":\"[\\\"si luo wen ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":83,\"values\":{\"codes\":\"[\\\"克罗地亚\\\"]\",\"dataId\":\"83\",\"id\":\"83\",\"name\":\"克罗地亚\",\"pinyin\":\"[\\\"ke luo de ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":84,\"values\":{\"codes\":\"[\\\"波斯尼亚和墨塞哥维那\\\"]\",\"dataId\":\"84\",\"id\":\"84\",\"name\":\"波斯尼亚和墨塞哥维那\",\"pinyin\":\"[\\\"bo si ni ya he mo sai ge wei na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":85,\"values\":{\"codes\":\"[\\\"意大利\\\"]\",\"dataId\":\"85\",\"id\":\"85\",\"name\":\"意大利\",\"pinyin\":\"[\\\"yi da li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":86,\"values\":{\"codes\":\"[\\\"梵蒂冈\\\"]\",\"dataId\":\"86\",\"id\":\"86\",\"name\":\"梵蒂冈\",\"pinyin\":\"[\\\"fan di gang\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":87,\"values\":{\"codes\":\"[\\\"圣马力诺\\\"]\",\"dataId\":\"87\",\"id\":\"87\",\"name\":\"圣马力诺\",\"pinyin\":\"[\\\"sheng ma li nuo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":88,\"values\":{\"codes\":\"[\\\"马耳他\\\"]\",\"dataId\":\"88\",\"id\":\"88\",\"name\":\"马耳他\",\"pinyin\":\"[\\\"ma er ta\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":89,\"values\":{\"codes\":\"[\\\"西班牙\\\"]\",\"dataId\":\"89\",\"id\":\"89\",\"name\":\"西班牙\",\"pinyin\":\"[\\\"xi ban ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":90,\"values\":{\"codes\":\"[\\\"葡萄牙\\\"]\",\"dataId\":\"90\",\"id\":\"90\",\"name\":\"葡萄牙\",\"pinyin\":\"[\\\"pu tao ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":91,\"values\":{\"codes\":\"[\\\"安道尔共和国\\\", \\\"安道尔\\\"]\",\"dataId\":\"91\",\"id\":\"91\",\"name\":\"安道尔共和国\",\"pinyin\":\"[\\\"an dao er gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":92,\"values\":{\"codes\":\"[\\\"埃及\\\"]\",\"dataId\":\"92\",\"id\":\"92\",\"name\":\"埃及\",\"pinyin\":\"[\\\"ai ji\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":93,\"values\":{\"codes\":\"[\\\"利比亚\\\"]\",\"dataId\":\"93\",\"id\":\"93\",\"name\":\"利比亚\",\"pinyin\":\"[\\\"li bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":94,\"values\":{\"codes\":\"[\\\"苏丹\\\"]\",\"dataId\":\"94\",\"id\":\"94\",\"name\":\"苏丹\",\"pinyin\":\"[\\\"su dan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":95,\"values\":{\"codes\":\"[\\\"突尼斯\\\"]\",\"dataId\":\"95\",\"id\":\"95\",\"name\":\"突尼斯\",\"pinyin\":\"[\\\"tu ni si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":96,\"values\":{\"codes\":\"[\\\"阿尔及利亚\\\"]\",\"dataId\":\"96\",\"id\":\"96\",\"name\":\"阿尔及利亚\",\"pinyin\":\"[\\\"a er ji li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":97,\"values\":{\"codes\":\"[\\\"摩洛哥\\\"]\",\"dataId\":\"97\",\"id\":\"97\",\"name\":\"摩洛哥\",\"pinyin\":\"[\\\"mo luo ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":98,\"values\":{\"codes\":\"[\\\"亚速尔群岛\\\"]\",\"dataId\":\"98\",\"id\":\"98\",\"name\":\"亚速尔群岛\",\"pinyin\":\"[\\\"ya su er qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":99,\"values\":{\"codes\":\"[\\\"马德拉群岛\\\"]\",\"dataId\":\"99\",\"id\":\"99\",\"name\":\"马德拉群岛\",\"pinyin\":\"[\\\"ma de la qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":100,\"values\":{\"codes\":\"[\\\"埃塞俄比亚\\\"]\",\"dataId\":\"100\",\"id\":\"100\",\"name\":\"埃塞俄比亚\",\"pinyin\":\"[\\\"ai sai e bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":101,\"values\":{\"codes\":\"[\\\"厄立特里亚\\\"]\",\"dataId\":\"101\",\"id\":\"101\",\"name\":\"厄立特里亚\",\"pinyin\":\"[\\\"e li te li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":102,\"values\":{\"codes\":\"[\\\"索马里\\\"]\",\"dataId\":\"102\",\"id\":\"102\",\"name\":\"索马里\",\"pinyin\":\"[\\\"suo ma li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":103,\"values\":{\"codes\":\"[\\\"吉布提\\\"]\",\"dataId\":\"103\",\"id\":\"103\",\"name\":\"吉布提\",\"pinyin\":\"[\\\"ji bu ti\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":104,\"values\":{\"codes\":\"[\\\"肯尼亚\\\"]\",\"dataId\":\"104\",\"id\":\"104\",\"name\":\"肯尼亚\",\"pinyin\":\"[\\\"ken ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":105,\"values\":{\"codes\":\"[\\\"坦桑尼亚\\\"]\",\"dataId\":\"105\",\"id\":\"105\",\"name\":\"坦桑尼亚\",\"pinyin\":\"[\\\"tan sang ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":106,\"values\":{\"codes\":\"[\\\"乌干达\\\"]\",\"dataId\":\"106\",\"id\":\"106\",\"name\":\"乌干达\",\"pinyin\":\"[\\\"wu gan da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":107,\"values\":{\"codes\":\"[\\\"卢旺达\\\"]\",\"dataId\":\"107\",\"id\":\"107\",\"name\":\"卢旺达\",\"pinyin\":\"[\\\"lu wang da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":108,\"values\":{\"codes\":\"[\\\"布隆迪\\\"]\",\"dataId\":\"108\",\"id\":\"108\",\"name\":\"布隆迪\",\"pinyin\":\"[\\\"bu long di\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":109,\"values\":{\"codes\":\"[\\\"塞舌尔\\\"]\",\"dataId\":\"109\",\"id\":\"109\",\"name\":\"塞舌尔\",\"pinyin\":\"[\\\"sai she er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":110,\"values\":{\"codes\":\"[\\\"圣多美及普林西比\\\", \\\"圣多美和普林西比\\\"]\",\"dataId\":\"110\",\"id\":\"110\",\"name\":\"圣多美及普林西比\",\"pinyin\":\"[\\\"sheng duo mei ji pu lin xi bi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":111,\"values\":{\"codes\":\"[\\\"塞内加尔\\\"]\",\"dataId\":\"111\",\"id\":\"111\",\"name\":\"塞内加尔\",\"pinyin\":\"[\\\"sai nei jia er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":112,\"values\":{\"codes\":\"[\\\"冈比亚\\\"]\",\"dataId\":\"112\",\"id\":\"112\",\"name\":\"冈比亚\",\"pinyin\":\"[\\\"gang bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":113,\"values\":{\"codes\":\"[\\\"马里\\\"]\",\"dataId\":\"113\",\"id\":\"113\",\"name\":\"马里\",\"pinyin\":\"[\\\"ma li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":114,\"values\":{\"codes\":\"[\\\"布基纳法索\\\"]\",\"dataId\":\"114\",\"id\":\"114\",\"name\":\"布基纳法索\",\"pinyin\":\"[\\\"bu ji na fa suo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":115,\"values\":{\"codes\":\"[\\\"几内亚\\\"]\",\"dataId\":\"115\",\"id\":\"115\",\"name\":\"几内亚\",\"pinyin\":\"[\\\"ji nei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":116,\"values\":{\"codes\":\"[\\\"几内亚比绍\\\"]\",\"dataId\":\"116\",\"id\":\"116\",\"name\":\"几内亚比绍\",\"pinyin\":\"[\\\"ji nei ya bi shao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":117,\"values\":{\"codes\":\"[\\\"佛得角\\\"]\",\"dataId\":\"117\",\"id\":\"117\",\"name\":\"佛得角\",\"pinyin\":\"[\\\"fu de jiao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":118,\"values\":{\"codes\":\"[\\\"塞拉利昂\\\"]\",\"dataId\":\"118\",\"id\":\"118\",\"name\":\"塞拉利昂\",\"pinyin\":\"[\\\"sai la li ang\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":119,\"values\":{\"codes\":\"[\\\"利比里亚\\\"]\",\"dataId\":\"119\",\"id\":\"119\",\"name\":\"利比里亚\",\"pinyin\":\"[\\\"li bi li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":120,\"values\":{\"codes\":\"[\\\"科特迪瓦\\\"]\",\"dataId\":\"120\",\"id\":\"120\",\"name\":\"科特迪瓦\",\"pinyin\":\"[\\\"ke te di wa\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":121,\"values\":{\"codes\":\"[\\\"加纳\\\"]\",\"dataId\":\"121\",\"id\":\"121\",\"name\":\"加纳\",\"pinyin\":\"[\\\"jia na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":122,\"values\":{\"codes\":\"[\\\"多哥\\\"]\",\"dataId\":\"122\",\"id\":\"122\",\"name\":\"多哥\",\"pinyin\":\"[\\\"duo ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":123,\"values\":{\"codes\":\"[\\\"贝宁\\\"]\",\"dataId\":\"123\",\"id\":\"123\",\"name\":\"贝宁\",\"pinyin\":\"[\\\"bei ning\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":124,\"values\":{\"codes\":\"[\\\"尼日尔\\\"]\",\"dataId\":\"124\",\"id\":\"124\",\"name\":\"尼日尔\",\"pinyin\":\"[\\\"ni ri er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":125,\"values\":{\"codes\":\"[\\\"加那利群岛\\\"]\",\"dataId\":\"125\",\"id\":\"125\",\"name\":\"加那利群岛\",\"pinyin\":\"[\\\"jia na li qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":126,\"values\":{\"codes\":\"[\\\"赞比亚\\\"]\",\"dataId\":\"126\",\"id\":\"126\",\"name\":\"赞比亚\",\"pinyin\":\"[\\\"zan bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":127,\"values\":{\"codes\":\"[\\\"安哥拉\\\"]\",\"dataId\":\"127\",\"id\":\"127\",\"name\":\"安哥拉\",\"pinyin\":\"[\\\"an ge la\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":128,\"values\":{\"codes\":\"[\\\"津巴布韦\\\"]\",\"dataId\":\"128\",\"id\":\"128\",\"name\":\"津巴布韦\",\"pinyin\":\"[\\\"jin ba bu wei\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":129,\"values\":{\"codes\":\"[\\\"马拉维\\\"]\",\"dataId\":\"129\",\"id\":\"129\",\"name\":\"马拉维\",\"pinyin\":\"[\\\"ma la wei\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":130,\"values\":{\"codes\":\"[\\\"莫桑比克\\\"]\",\"dataId\":\"130\",\"id\":\"130\",\"name\":\"莫桑比克\",\"pinyin\":\"[\\\"mo sang bi ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":131,\"values\":{\"codes\":\"[\\\"博茨瓦纳\\\"]\",\"dataId\":\"131\",\"id\":\"131\",\"name\":\"博茨瓦纳\",\"pinyin\":\"[\\\"bo ci wa na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":132,\"values\":{\"codes\":\"[\\\"纳米比亚\\\"]\",\"dataId\":\"132\",\"id\":\"132\",\"name\":\"纳米比亚\",\"pinyin\":\"[\\\"na mi bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":133,\"values\":{\"codes\":\"[\\\"南非\\\"]\",\"dataId\":\"133\",\"id\":\"133\",\"name\":\"南非\",\"pinyin\":\"[\\\"nan fei\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":134,\"values\":{\"codes\":\"[\\\"斯威士兰\\\"]\",\"dataId\":\"134\",\"id\":\"134\",\"name\":\"斯威士兰\",\"pinyin\":\"[\\\"si wei shi lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":135,\"values\":{\"codes\":\"[\\\"莱索托\\\"]\",\"dataId\":\"135\",\"id\":\"135\",\"name\":\"莱索托\",\"pinyin\":\"[\\\"lai suo tuo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":136,\"values\":{\"codes\":\"[\\\"马达加斯加\\\"]\",\"dataId\":\"136\",\"id\":\"136\",\"name\":\"马达加斯加\",\"pinyin\":\"[\\\"ma da jia si jia\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":137,\"values\":{\"codes\":\"[\\\"科摩罗\\\"]\",\"dataId\":\"137\",\"id\":\"137\",\"name\":\"科摩罗\",\"pinyin\":\"[\\\"ke mo luo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":138,\"values\":{\"codes\":\"[\\\"毛里求斯\\\"]\",\"dataId\":\"138\",\"id\":\"138\",\"name\":\"毛里求斯\",\"pinyin\":\"[\\\"mao li qiu si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":139,\"values\":{\"codes\":\"[\\\"留尼旺\\\", \\\"留尼汪岛\\\", \\\"留尼汪\\\"]\",\"dataId\":\"139\",\"id\":\"139\",\"name\":\"留尼旺\",\"pinyin\":\"[\\\"liu ni wang\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":140,\"values\":{\"codes\":\"[\\\"圣赫勒拿\\\"]\",\"dataId\":\"140\",\"id\":\"140\",\"name\":\"圣赫勒拿\",\"pinyin\":\"[\\\"sheng he lei na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":141,\"values\":{\"codes\":\"[\\\"澳大利亚\\\"]\",\"dataId\":\"141\",\"id\":\"141\",\"name\":\"澳大利亚\",\"pinyin\":\"[\\\"ao da li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":142,\"values\":{\"codes\":\"[\\\"新西兰\\\"]\",\"dataId\":\"142\",\"id\":\"142\",\"name\":\"新西兰\",\"pinyin\":\"[\\\"xin xi lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":143,\"values\":{\"codes\":\"[\\\"巴布亚新几内亚\\\"]\",\"dataId\":\"143\",\"id\":\"143\",\"name\":\"巴布亚新几内亚\",\"pinyin\":\"[\\\"ba bu ya xin ji nei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":144,\"values\":{\"codes\":\"[\\\"所罗门群岛\\\"]\",\"dataId\":\"144\",\"id\":\"144\",\"name\":\"所罗门群岛\",\"pinyin\":\"[\\\"suo luo men qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":145,\"values\":{\"codes\":\"[\\\"瓦努阿图共和国\\\", \\\"瓦努阿图\\\"]\",\"dataId\":\"145\",\"id\":\"145\",\"name\":\"瓦努阿图共和国\",\"pinyin\":\"[\\\"wa nu a tu gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":146,\"values\":{\"codes\":\"[\\\"密克罗尼西亚\\\"]\",\"dataId\":\"146\",\"id\":\"146\",\"name\":\"密克罗尼西亚\",\"pinyin\":\"[\\\"mi ke luo ni xi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":147,\"values\":{\"codes\":\"[\\\"马绍尔群岛\\\"]\",\"dataId\":\"147\",\"id\":\"147\",\"name\":\"马绍尔群岛\",\"pinyin\":\"[\\\"ma shao er qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":148,\"values\":{\"codes\":\"[\\\"帕劳\\\"]\",\"dataId\":\"148\",\"id\":\"148\",\"name\":\"帕劳\",\"pinyin\":\"[\\\"pa lao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":149,\"values\":{\"codes\":\"[\\\"瑙鲁\\\"]\",\"dataId\":\"149\",\"id\":\"149\",\"name\":\"瑙鲁\",\"pinyin\":\"[\\\"nao lu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":150,\"values\":{\"codes\":\"[\\\"基里巴斯\\\"]\",\"dataId\":\"150\",\"id\":\"150\",\"name\":\"基里巴斯\",\"pinyin\":\"[\\\"ji li ba si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":151,\"values\":{\"codes\":\"[\\\"图瓦卢\\\"]\",\"dataId\":\"151\",\"id\":\"151\",\"name\":\"图瓦卢\",\"pinyin\":\"[\\\"tu wa lu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":152,\"values\":{\"codes\":\"[\\\"萨摩亚\\\"]\",\"dataId\":\"152\",\"id\":\"152\",\"name\":\"萨摩亚\",\"pinyin\":\"[\\\"sa mo ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":153,\"values\":{\"codes\":\"[\\\"斐济\\\"]\",\"dataId\":\"153\",\"id\":\"153\",\"name\":\"斐济\",\"pinyin\":\"[\\\"fei ji\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":154,\"values\":{\"codes\":\"[\\\"汤加\\\"]\",\"dataId\":\"154\",\"id\":\"154\",\"name\":\"汤加\",\"pinyin\":\"[\\\"tang jia\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":155,\"values\":{\"codes\":\"[\\\"库克群岛\\\"]\",\"dataId\":\"155\",\"id\":\"155\",\"name\":\"库克群岛\",\"pinyin\":\"[\\\"ku ke qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":156,\"values\":{\"codes\":\"[\\\"关岛\\\"]\",\"dataId\":\"156\",\"id\":\"156\",\"name\":\"关岛\",\"pinyin\":\"[\\\"guan dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":157,\"values\":{\"codes\":\"[\\\"新喀里多尼亚\\\", \\\"新喀里多尼亚群岛\\\"]\",\"dataId\":\"157\",\"id\":\"157\",\"name\":\"新喀里多尼亚\",\"pinyin\":\"[\\\"xin ka li duo ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":158,\"values\":{\"codes\":\"[\\\"法属波利尼西亚\\\"]\",\"dataId\":\"158\",\"id\":\"158\",\"name\":\"法属波利尼西亚\",\"pinyin\":\"[\\\"fa shu bo li ni xi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":159,\"values\":{\"codes\":\"[\\\"皮特凯恩岛\\\"]\",\"dataId\":\"159\",\"id\":\"159\",\"name\":\"皮特凯恩岛\",\"pinyin\":\"[\\\"pi te kai en dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":160,\"values\":{\"codes\":\"[\\\"瓦利斯与富图纳\\\", \\\"瓦利斯和富图纳群岛\\\"]\",\"dataId\":\"160\",\"id\":\"160\",\"name\":\"瓦利斯与富图纳\",\"pinyin\":\"[\\\"wa li si yu fu tu na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":161,\"values\":{\"codes\":\"[\\\"纽埃\\\"]\",\"dataId\":\"161\",\"id\":\"161\",\"name\":\"纽埃\",\"pinyin\":\"[\\\"niu ai\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":162,\"values\":{\"codes\":\"[\\\"托克劳\\\", \\\"托克劳群岛\\\"]\",\"dataId\":\"162\",\"id\":\"162\",\"name\":\"托克劳\",\"pinyin\":\"[\\\"tuo ke lao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":163,\"values\":{\"codes\":\"[\\\"美属萨摩亚\\\"]\",\"dataId\":\"163\",\"id\":\"163\",\"name\":\"美属萨摩亚\",\"pinyin\":\"[\\\"mei shu sa mo ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":164,\"values\":{\"codes\":\"[\\\"北马里亚纳\\\", \\\"北马里亚纳群岛\\\"]\",\"dataId\":\"164\",\"id\":\"164\",\"name\":\"北马里亚纳\",\"pinyin\":\"[\\\"bei ma li ya na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":165,\"values\":{\"codes\":\"[\\\"加拿大\\\"]\",\"dataId\":\"165\",\"id\":\"165\",\"name\":\"加拿大\",\"pinyin\":\"[\\\"jia na da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":166,\"values\":{\"codes\":\"[\\\"美国\\\"]\",\"dataId\":\"166\",\"id\":\"166\",\"name\":\"美国\",\"pinyin\":\"[\\\"mei guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":167,\"values\":{\"codes\":\"[\\\"墨西哥\\\"]\",\"dataId\":\"167\",\"id\":\"167\",\"name\":\"墨西哥\",\"pinyin\":\"[\\\"mo xi ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":168,\"values\":{\"codes\":\"[\\\"格陵兰\\\", \\\"格陵兰岛\\\"]\",\"dataId\":\"168\",\"id\":\"168\",\"name\":\"格陵兰\",\"pinyin\":\"[\\\"ge ling lan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":169,\"values\":{\"codes\":\"[\\\"危地马拉\\\"]\",\"dataId\":\"169\",\"id\":\"169\",\"name\":\"危地马拉\",\"pinyin\":\"[\\\"wei de ma la\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":170,\"values\":{\"codes\":\"[\\\"伯利兹\\\"]\",\"dataId\":\"170\",\"id\":\"170\",\"name\":\"伯利兹\",\"pinyin\":\"[\\\"bo li zi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":171,\"values\":{\"codes\":\"[\\\"萨尔瓦多\\\"]\",\"dataId\":\"171\",\"id\":\"171\",\"name\":\"萨尔瓦多\",\"pinyin\":\"[\\\"sa er wa duo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":172,\"values\":{\"codes\":\"[\\\"洪都拉斯\\\"]\",\"dataId\":\"172\",\"id\":\"172\",\"name\":\"洪都拉斯\",\"pinyin\":\"[\\\"hong dou la si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":173,\"values\":{\"codes\":\"[\\\"尼加拉瓜\\\"]\",\"dataId\":\"173\",\"id\":\"173\",\"name\":\"尼加拉瓜\",\"pinyin\":\"[\\\"ni jia la gua\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":174,\"values\":{\"codes\":\"[\\\"哥斯达黎加\\\"]\",\"dataId\":\"174\",\"id\":\"174\",\"name\":\"哥斯达黎加\",\"pinyin\":\"[\\\"ge si da li jia\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":175,\"values\":{\"codes\":\"[\\\"巴拿马\\\"]\",\"dataId\":\"175\",\"id\":\"175\",\"name\":\"巴拿马\",\"pinyin\":\"[\\\"ba na ma\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":176,\"values\":{\"codes\":\"[\\\"巴哈马\\\"]\",\"dataId\":\"176\",\"id\":\"176\",\"name\":\"巴哈马\",\"pinyin\":\"[\\\"ba ha ma\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":177,\"values\":{\"codes\":\"[\\\"古巴\\\"]\",\"dataId\":\"177\",\"id\":\"177\",\"name\":\"古巴\",\"pinyin\":\"[\\\"gu ba\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":178,\"values\":{\"codes\":\"[\\\"牙买加\\\"]\",\"dataId\":\"178\",\"id\":\"178\",\"name\":\"牙买加\",\"pinyin\":\"[\\\"ya mai jia\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":179,\"values\":{\"codes\":\"[\\\"海地\\\"]\",\"dataId\":\"179\",\"id\":\"179\",\"name\":\"海地\",\"pinyin\":\"[\\\"hai de\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":180,\"values\":{\"codes\":\"[\\\"多米尼加共和国\\\", \\\"多米尼加\\\"]\",\"dataId\":\"180\",\"id\":\"180\",\"name\":\"多米尼加共和国\",\"pinyin\":\"[\\\"duo mi ni jia gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":181,\"values\":{\"codes\":\"[\\\"安提瓜和巴布达\\\"]\",\"dataId\":\"181\",\"id\":\"181\",\"name\":\"安提瓜和巴布达\",\"pinyin\":\"[\\\"an ti gua he ba bu da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":182,\"values\":{\"codes\":\"[\\\"圣基茨和尼维斯\\\"]\",\"dataId\":\"182\",\"id\":\"182\",\"name\":\"圣基茨和尼维斯\",\"pinyin\":\"[\\\"sheng ji ci he ni wei si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":183,\"values\":{\"codes\":\"[\\\"多米尼克\\\"]\",\"dataId\":\"183\",\"id\":\"183\",\"name\":\"多米尼克\",\"pinyin\":\"[\\\"duo mi ni ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":184,\"values\":{\"codes\":\"[\\\"圣卢西亚\\\"]\",\"dataId\":\"184\",\"id\":\"184\",\"name\":\"圣卢西亚\",\"pinyin\":\"[\\\"sheng lu xi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":185,\"values\":{\"codes\":\"[\\\"圣文森特和格林纳丁斯\\\"]\",\"dataId\":\"185\",\"id\":\"185\",\"name\":\"圣文森特和格林纳丁斯\",\"pinyin\":\"[\\\"sheng wen sen te he ge lin na ding si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":186,\"values\":{\"codes\":\"[\\\"格林纳达\\\"]\",\"dataId\":\"186\",\"id\":\"186\",\"name\":\"格林纳达\",\"pinyin\":\"[\\\"ge lin na da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":187,\"values\":{\"codes\":\"[\\\"巴巴多斯\\\"]\",\"dataId\":\"187\",\"id\":\"187\",\"name\":\"巴巴多斯\",\"pinyin\":\"[\\\"ba ba duo si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":188,\"values\":{\"codes\":\"[\\\"特立尼达和多巴哥\\\"]\",\"dataId\":\"188\",\"id\":\"188\",\"name\":\"特立尼达和多巴哥\",\"pinyin\":\"[\\\"te li ni da he duo ba ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":189,\"values\":{\"codes\":\"[\\\"波多黎各\\\"]\",\"dataId\":\"189\",\"id\":\"189\",\"name\":\"波多黎各\",\"pinyin\":\"[\\\"bo duo li ge\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":190,\"values\":{\"codes\":\"[\\\"英属维尔京群岛\\\"]\",\"dataId\":\"190\",\"id\":\"190\",\"name\":\"英属维尔京群岛\",\"pinyin\":\"[\\\"ying shu wei er jing qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":191,\"values\":{\"codes\":\"[\\\"美属维尔京群岛\\\"]\",\"dataId\":\"191\",\"id\":\"191\",\"name\":\"美属维尔京群岛\",\"pinyin\":\"[\\\"mei shu wei er jing qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":192,\"values\":{\"codes\":\"[\\\"安圭拉\\\"]\",\"dataId\":\"192\",\"id\":\"192\",\"name\":\"安圭拉\",\"pinyin\":\"[\\\"an gui la\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":193,\"values\":{\"codes\":\"[\\\"蒙特塞拉特岛\\\", \\\"蒙塞拉特岛\\\"]\",\"dataId\":\"193\",\"id\":\"193\",\"name\":\"蒙特塞拉特岛\",\"pinyin\":\"[\\\"meng te sai la te dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":194,\"values\":{\"codes\":\"[\\\"瓜德罗普\\\"]\",\"dataId\":\"194\",\"id\":\"194\",\"name\":\"瓜德罗普\",\"pinyin\":\"[\\\"gua de luo pu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":195,\"values\":{\"codes\":\"[\\\"马提尼克\\\"]\",\"dataId\":\"195\",\"id\":\"195\",\"name\":\"马提尼克\",\"pinyin\":\"[\\\"ma ti ni ke\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":196,\"values\":{\"codes\":\"[\\\"荷属安的列斯\\\", \\\"安的列斯\\\"]\",\"dataId\":\"196\",\"id\":\"196\",\"name\":\"荷属安的列斯\",\"pinyin\":\"[\\\"he shu an de lie si\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":197,\"values\":{\"codes\":\"[\\\"阿鲁巴\\\"]\",\"dataId\":\"197\",\"id\":\"197\",\"name\":\"阿鲁巴\",\"pinyin\":\"[\\\"a lu ba\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":198,\"values\":{\"codes\":\"[\\\"特克斯和凯科斯群岛\\\"]\",\"dataId\":\"198\",\"id\":\"198\",\"name\":\"特克斯和凯科斯群岛\",\"pinyin\":\"[\\\"te ke si he kai ke si qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":199,\"values\":{\"codes\":\"[\\\"开曼群岛\\\"]\",\"dataId\":\"199\",\"id\":\"199\",\"name\":\"开曼群岛\",\"pinyin\":\"[\\\"kai man qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":200,\"values\":{\"codes\":\"[\\\"百慕大\\\", \\\"百慕大群岛\\\"]\",\"dataId\":\"200\",\"id\":\"200\",\"name\":\"百慕大\",\"pinyin\":\"[\\\"bai mu da\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":201,\"values\":{\"codes\":\"[\\\"哥伦比亚\\\"]\",\"dataId\":\"201\",\"id\":\"201\",\"name\":\"哥伦比亚\",\"pinyin\":\"[\\\"ge lun bi ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":202,\"values\":{\"codes\":\"[\\\"委内瑞拉\\\"]\",\"dataId\":\"202\",\"id\":\"202\",\"name\":\"委内瑞拉\",\"pinyin\":\"[\\\"wei nei rui la\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":203,\"values\":{\"codes\":\"[\\\"圭亚那\\\"]\",\"dataId\":\"203\",\"id\":\"203\",\"name\":\"圭亚那\",\"pinyin\":\"[\\\"gui ya na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":204,\"values\":{\"codes\":\"[\\\"法属圭亚那\\\"]\",\"dataId\":\"204\",\"id\":\"204\",\"name\":\"法属圭亚那\",\"pinyin\":\"[\\\"fa shu gui ya na\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":205,\"values\":{\"codes\":\"[\\\"苏里南\\\"]\",\"dataId\":\"205\",\"id\":\"205\",\"name\":\"苏里南\",\"pinyin\":\"[\\\"su li nan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":206,\"values\":{\"codes\":\"[\\\"厄瓜多尔\\\"]\",\"dataId\":\"206\",\"id\":\"206\",\"name\":\"厄瓜多尔\",\"pinyin\":\"[\\\"e gua duo er\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":207,\"values\":{\"codes\":\"[\\\"秘鲁\\\"]\",\"dataId\":\"207\",\"id\":\"207\",\"name\":\"秘鲁\",\"pinyin\":\"[\\\"mi lu\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":208,\"values\":{\"codes\":\"[\\\"玻利维亚\\\"]\",\"dataId\":\"208\",\"id\":\"208\",\"name\":\"玻利维亚\",\"pinyin\":\"[\\\"bo li wei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":209,\"values\":{\"codes\":\"[\\\"巴西\\\"]\",\"dataId\":\"209\",\"id\":\"209\",\"name\":\"巴西\",\"pinyin\":\"[\\\"ba xi\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":210,\"values\":{\"codes\":\"[\\\"智利\\\"]\",\"dataId\":\"210\",\"id\":\"210\",\"name\":\"智利\",\"pinyin\":\"[\\\"zhi li\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":211,\"values\":{\"codes\":\"[\\\"阿根廷\\\"]\",\"dataId\":\"211\",\"id\":\"211\",\"name\":\"阿根廷\",\"pinyin\":\"[\\\"a gen ting\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":212,\"values\":{\"codes\":\"[\\\"乌拉圭\\\"]\",\"dataId\":\"212\",\"id\":\"212\",\"name\":\"乌拉圭\",\"pinyin\":\"[\\\"wu la gui\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":213,\"values\":{\"codes\":\"[\\\"巴拉圭\\\"]\",\"dataId\":\"213\",\"id\":\"213\",\"name\":\"巴拉圭\",\"pinyin\":\"[\\\"ba la gui\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":214,\"values\":{\"codes\":\"[\\\"波黑\\\"]\",\"dataId\":\"3405\",\"id\":\"214\",\"name\":\"波黑\",\"pinyin\":\"[\\\"bo hei\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":215,\"values\":{\"codes\":\"[\\\"直布罗陀\\\"]\",\"dataId\":\"3406\",\"id\":\"215\",\"name\":\"直布罗陀\",\"pinyin\":\"[\\\"zhi bu luo tuo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":216,\"values\":{\"codes\":\"[\\\"新喀里多尼亚群岛\\\"]\",\"dataId\":\"3407\",\"id\":\"216\",\"name\":\"新喀里多尼亚群岛\",\"pinyin\":\"[\\\"xin ka li duo ni ya qun dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":217,\"values\":{\"codes\":\"[\\\"瓦利斯和富图纳群岛\\\"]\",\"dataId\":\"3408\",\"id\":\"217\",\"name\":\"瓦利斯和富图纳群岛\",\"pinyin\":\"[\\\"wa li si he fu tu na qun dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":218,\"values\":{\"codes\":\"[\\\"泽西岛\\\"]\",\"dataId\":\"3409\",\"id\":\"218\",\"name\":\"泽西岛\",\"pinyin\":\"[\\\"ze xi dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":219,\"values\":{\"codes\":\"[\\\"黑山\\\"]\",\"dataId\":\"3410\",\"id\":\"219\",\"name\":\"黑山\",\"pinyin\":\"[\\\"hei shan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":220,\"values\":{\"codes\":\"[\\\"英属马恩岛\\\", \\\"马恩岛\\\"]\",\"dataId\":\"3411\",\"id\":\"220\",\"name\":\"英属马恩岛\",\"pinyin\":\"[\\\"ying shu ma en dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":221,\"values\":{\"codes\":\"[\\\"尼日利亚\\\"]\",\"dataId\":\"3412\",\"id\":\"221\",\"name\":\"尼日利亚\",\"pinyin\":\"[\\\"ni ri li ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":222,\"values\":{\"codes\":\"[\\\"喀麦隆\\\"]\",\"dataId\":\"3413\",\"id\":\"222\",\"name\":\"喀麦隆\",\"pinyin\":\"[\\\"ka mai long\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":223,\"values\":{\"codes\":\"[\\\"加蓬\\\"]\",\"dataId\":\"3414\",\"id\":\"223\",\"name\":\"加蓬\",\"pinyin\":\"[\\\"jia peng\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":224,\"values\":{\"codes\":\"[\\\"乍得\\\"]\",\"dataId\":\"3415\",\"id\":\"224\",\"name\":\"乍得\",\"pinyin\":\"[\\\"zha de\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":225,\"values\":{\"codes\":\"[\\\"刚果共和国\\\", \\\"刚果布\\\"]\",\"dataId\":\"3416\",\"id\":\"225\",\"name\":\"刚果共和国\",\"pinyin\":\"[\\\"gang guo gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":226,\"values\":{\"codes\":\"[\\\"中非共和国\\\", \\\"中非\\\"]\",\"dataId\":\"3417\",\"id\":\"226\",\"name\":\"中非共和国\",\"pinyin\":\"[\\\"zhong fei gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":227,\"values\":{\"codes\":\"[\\\"南苏丹\\\"]\",\"dataId\":\"3418\",\"id\":\"227\",\"name\":\"南苏丹\",\"pinyin\":\"[\\\"nan su dan\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":228,\"values\":{\"codes\":\"[\\\"赤道几内亚\\\"]\",\"dataId\":\"3419\",\"id\":\"228\",\"name\":\"赤道几内亚\",\"pinyin\":\"[\\\"chi dao ji nei ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":229,\"values\":{\"codes\":\"[\\\"毛里塔尼亚\\\"]\",\"dataId\":\"3420\",\"id\":\"229\",\"name\":\"毛里塔尼亚\",\"pinyin\":\"[\\\"mao li ta ni ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":230,\"values\":{\"codes\":\"[\\\"刚果民主共和国\\\", \\\"刚果金\\\"]\",\"dataId\":\"3421\",\"id\":\"230\",\"name\":\"刚果民主共和国\",\"pinyin\":\"[\\\"gang guo min zhu gong he guo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":231,\"values\":{\"codes\":\"[\\\"留尼汪岛\\\"]\",\"dataId\":\"3422\",\"id\":\"231\",\"name\":\"留尼汪岛\",\"pinyin\":\"[\\\"liu ni wang dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":232,\"values\":{\"codes\":\"[\\\"格陵兰岛\\\"]\",\"dataId\":\"3423\",\"id\":\"232\",\"name\":\"格陵兰岛\",\"pinyin\":\"[\\\"ge ling lan dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":233,\"values\":{\"codes\":\"[\\\"法罗群岛\\\"]\",\"dataId\":\"3424\",\"id\":\"233\",\"name\":\"法罗群岛\",\"pinyin\":\"[\\\"fa luo qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":234,\"values\":{\"codes\":\"[\\\"根西岛\\\"]\",\"dataId\":\"3425\",\"id\":\"234\",\"name\":\"根西岛\",\"pinyin\":\"[\\\"gen xi dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":235,\"values\":{\"codes\":\"[\\\"百慕大群岛\\\"]\",\"dataId\":\"3432\",\"id\":\"235\",\"name\":\"百慕大群岛\",\"pinyin\":\"[\\\"bai mu da qun dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":236,\"values\":{\"codes\":\"[\\\"圣皮埃尔和密克隆群岛\\\"]\",\"dataId\":\"3433\",\"id\":\"236\",\"name\":\"圣皮埃尔和密克隆群岛\",\"pinyin\":\"[\\\"sheng pi ai er he mi ke long qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":237,\"values\":{\"codes\":\"[\\\"法属圣马丁\\\"]\",\"dataId\":\"3434\",\"id\":\"237\",\"name\":\"法属圣马丁\",\"pinyin\":\"[\\\"fa shu sheng ma ding\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":238,\"values\":{\"codes\":\"[\\\"奥兰群岛\\\"]\",\"dataId\":\"3435\",\"id\":\"238\",\"name\":\"奥兰群岛\",\"pinyin\":\"[\\\"ao lan qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":239,\"values\":{\"codes\":\"[\\\"北马里亚纳群岛\\\"]\",\"dataId\":\"3436\",\"id\":\"239\",\"name\":\"北马里亚纳群岛\",\"pinyin\":\"[\\\"bei ma li ya na qun dao\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":240,\"values\":{\"codes\":\"[\\\"库拉索\\\"]\",\"dataId\":\"3437\",\"id\":\"240\",\"name\":\"库拉索\",\"pinyin\":\"[\\\"ku la suo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":241,\"values\":{\"codes\":\"[\\\"博内尔岛\\\"]\",\"dataId\":\"3438\",\"id\":\"241\",\"name\":\"博内尔岛\",\"pinyin\":\"[\\\"bo nei er dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":242,\"values\":{\"codes\":\"[\\\"圣马丁岛\\\", \\\"圣马丁\\\"]\",\"dataId\":\"3439\",\"id\":\"242\",\"name\":\"圣马丁岛\",\"pinyin\":\"[\\\"sheng ma ding dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":243,\"values\":{\"codes\":\"[\\\"圣巴泰勒米岛\\\", \\\"圣巴泰勒米\\\"]\",\"dataId\":\"3440\",\"id\":\"243\",\"name\":\"圣巴泰勒米岛\",\"pinyin\":\"[\\\"sheng ba tai lei mi dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":244,\"values\":{\"codes\":\"[\\\"福克兰群岛\\\"]\",\"dataId\":\"3441\",\"id\":\"244\",\"name\":\"福克兰群岛\",\"pinyin\":\"[\\\"fu ke lan qun dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":245,\"values\":{\"codes\":\"[\\\"圣多美和普林西比\\\"]\",\"dataId\":\"3442\",\"id\":\"245\",\"name\":\"圣多美和普林西比\",\"pinyin\":\"[\\\"sheng duo mei he pu lin xi bi\\\"]\",\"state\":\"0\"},\"uniqueFields\":[\"name\"]},{\"id\":246,\"values\":{\"codes\":\"[\\\"英属印度洋领地\\\"]\",\"dataId\":\"3443\",\"id\":\"246\",\"name\":\"英属印度洋领地\",\"pinyin\":\"[\\\"ying shu yin du yang ling de\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":247,\"values\":{\"codes\":\"[\\\"东萨摩亚\\\"]\",\"dataId\":\"3444\",\"id\":\"247\",\"name\":\"东萨摩亚\",\"pinyin\":\"[\\\"dong sa mo ya\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":248,\"values\":{\"codes\":\"[\\\"诺福克岛\\\"]\",\"dataId\":\"3445\",\"id\":\"248\",\"name\":\"诺福克岛\",\"pinyin\":\"[\\\"nuo fu ke dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":249,\"values\":{\"codes\":\"[\\\"法属马约特岛\\\", \\\"马约特岛\\\", \\\"马约特\\\"]\",\"dataId\":\"\",\"id\":\"249\",\"name\":\"马约特岛\",\"pinyin\":\"[\\\"ma yue te dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":250,\"values\":{\"codes\":\"[\\\"科索沃\\\"]\",\"dataId\":\"\",\"id\":\"250\",\"name\":\"科索沃\",\"pinyin\":\"[\\\"ke suo wo\\\", \\\"Kosovo\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":251,\"values\":{\"codes\":\"[\\\"荷属圣马丁\\\"]\",\"dataId\":\"\",\"id\":\"251\",\"name\":\"荷属圣马丁\",\"pinyin\":\"[\\\"he shu sheng ma ding\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":252,\"values\":{\"codes\":\"[\\\"南乔治亚岛和南桑威奇群岛\\\"]\",\"dataId\":\"\",\"id\":\"252\",\"name\":\"南乔治亚岛和南桑威奇群岛\",\"pinyin\":\"[\\\"nan qiao zhi ya dao he nan sang wei qi qun dao\\\", \\\"South Georgia and The South Sandwich Islands\\\", \\\"SGSSI\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":253,\"values\":{\"codes\":\"[\\\"南极洲\\\"]\",\"dataId\":\"\",\"id\":\"253\",\"name\":\"南极洲\",\"pinyin\":\"[\\\"nan ji zhou\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":254,\"values\":{\"codes\":\"[\\\"阿森松岛\\\"]\",\"dataId\":\"\",\"id\":\"254\",\"name\":\"阿森松岛\",\"pinyin\":\"[\\\"a sen song dao\\\", \\\"Ascension Island\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":255,\"values\":{\"codes\":\"[\\\"圣诞岛\\\"]\",\"dataId\":\"\",\"id\":\"255\",\"name\":\"圣诞岛\",\"pinyin\":\"[\\\"sheng dan dao\\\"]\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]}]},{\"name\":\"edgeRegionProviders\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeRegionProviders` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='区域-运营商'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[{\"id\":1,\"values\":{\"codes\":\"[\\\"电信\\\"]\",\"id\":\"1\",\"name\":\"电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":2,\"values\":{\"codes\":\"[\\\"TOT\\\"]\",\"id\":\"2\",\"name\":\"TOT\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":3,\"values\":{\"codes\":\"[\\\"联通\\\"]\",\"id\":\"3\",\"name\":\"联通\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":4,\"values\":{\"codes\":\"[\\\"谷歌\\\"]\",\"id\":\"4\",\"name\":\"谷歌\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":5,\"values\":{\"codes\":\"[\\\"软银\\\"]\",\"id\":\"5\",\"name\":\"软银\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":6,\"values\":{\"codes\":\"[\\\"Sify\\\"]\",\"id\":\"6\",\"name\":\"Sify\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":7,\"values\":{\"codes\":\"[\\\"马来西亚电信\\\"]\",\"id\":\"7\",\"name\":\"马来西亚电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":8,\"values\":{\"codes\":\"[\\\"方正宽带\\\"]\",\"id\":\"8\",\"name\":\"方正宽带\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":9,\"values\":{\"codes\":\"[\\\"Tikona\\\"]\",\"id\":\"9\",\"name\":\"Tikona\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":10,\"values\":{\"codes\":\"[\\\"新加坡电信\\\"]\",\"id\":\"10\",\"name\":\"新加坡电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":11,\"values\":{\"codes\":\"[\\\"日本电信电话\\\"]\",\"id\":\"11\",\"name\":\"日本电信电话\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":12,\"values\":{\"codes\":\"[\\\"中华电信\\\"]\",\"id\":\"12\",\"name\":\"中华电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":13,\"values\":{\"codes\":\"[\\\"电讯盈科\\\"]\",\"id\":\"13\",\"name\":\"电讯盈科\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":14,\"values\":{\"codes\":\"[\\\"Globe-Telecom\\\"]\",\"id\":\"14\",\"name\":\"Globe-Telecom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":15,\"values\":{\"codes\":\"[\\\"沃达丰印度\\\"]\",\"id\":\"15\",\"name\":\"沃达丰印度\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":16,\"values\":{\"codes\":\"[\\\"Optus\\\"]\",\"id\":\"16\",\"name\":\"Optus\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":17,\"values\":{\"codes\":\"[\\\"DTAC\\\"]\",\"id\":\"17\",\"name\":\"DTAC\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":18,\"values\":{\"codes\":\"[\\\"教育网\\\"]\",\"id\":\"18\",\"name\":\"教育网\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":19,\"values\":{\"codes\":\"[\\\"Finance-and-Promoting-Technology\\\"]\",\"id\":\"19\",\"name\":\"Finance-and-Promoting-Technology\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":20,\"values\":{\"codes\":\"[\\\"多科莫\\\"]\",\"id\":\"20\",\"name\":\"多科莫\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":21,\"values\":{\"codes\":\"[\\\"歌华\\\"]\",\"id\":\"21\",\"name\":\"歌华\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":22,\"values\":{\"codes\":\"[\\\"鹏博士\\\"]\",\"id\":\"22\",\"name\":\"鹏博士\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":23,\"values\":{\"codes\":\"[\\\"韩国电信\\\"]\",\"id\":\"23\",\"name\":\"韩国电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":24,\"values\":{\"codes\":\"[\\\"腾讯\\\"]\",\"id\":\"24\",\"name\":\"腾讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":25,\"values\":{\"codes\":\"[\\\"皓宽网络\\\"]\",\"id\":\"25\",\"name\":\"皓宽网络\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":26,\"values\":{\"codes\":\"[\\\"澳大利亚电信\\\"]\",\"id\":\"26\",\"name\":\"澳大利亚电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":27,\"values\":{\"codes\":\"[\\\"阿里云\\\"]\",\"id\":\"27\",\"name\":\"阿里云\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":28,\"values\":{\"codes\":\"[\\\"Dvois\\\"]\",\"id\":\"28\",\"name\":\"Dvois\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":29,\"values\":{\"codes\":\"[\\\"台湾之星\\\"]\",\"id\":\"29\",\"name\":\"台湾之星\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":30,\"values\":{\"codes\":\"[\\\"KINX\\\"]\",\"id\":\"30\",\"name\":\"KINX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":31,\"values\":{\"codes\":\"[\\\"SK宽带\\\"]\",\"id\":\"31\",\"name\":\"SK宽带\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":32,\"values\":{\"codes\":\"[\\\"橘子电信\\\"]\",\"id\":\"32\",\"name\":\"橘子电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":33,\"values\":{\"codes\":\"[\\\"阿卡迈\\\"]\",\"id\":\"33\",\"name\":\"阿卡迈\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":34,\"values\":{\"codes\":\"[\\\"沃达丰\\\"]\",\"id\":\"34\",\"name\":\"沃达丰\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":35,\"values\":{\"codes\":\"[\\\"科进\\\"]\",\"id\":\"35\",\"name\":\"科进\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":36,\"values\":{\"codes\":\"[\\\"GTT\\\"]\",\"id\":\"36\",\"name\":\"GTT\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":37,\"values\":{\"codes\":\"[\\\"康卡斯特\\\"]\",\"id\":\"37\",\"name\":\"康卡斯特\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":38,\"values\":{\"codes\":\"[\\\"俄罗斯电信\\\"]\",\"id\":\"38\",\"name\":\"俄罗斯电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":39,\"values\":{\"codes\":\"[\\\"VimpelCom\\\"]\",\"id\":\"39\",\"name\":\"VimpelCom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":40,\"values\":{\"codes\":\"[\\\"意大利电信\\\"]\",\"id\":\"40\",\"name\":\"意大利电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":41,\"values\":{\"codes\":\"[\\\"Telenor\\\"]\",\"id\":\"41\",\"name\":\"Telenor\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":42,\"values\":{\"codes\":\"[\\\"哈萨克斯坦电信\\\"]\",\"id\":\"42\",\"name\":\"哈萨克斯坦电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":43,\"values\":{\"codes\":\"[\\\"西班牙电信\\\"]\",\"id\":\"43\",\"name\":\"西班牙电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":44,\"values\":{\"codes\":\"[\\\"FastWeb\\\"]\",\"id\":\"44\",\"name\":\"FastWeb\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":45,\"values\":{\"codes\":\"[\\\"Telia-Company\\\"]\",\"id\":\"45\",\"name\":\"Telia-Company\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":46,\"values\":{\"codes\":\"[\\\"亚马逊\\\"]\",\"id\":\"46\",\"name\":\"亚马逊\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":47,\"values\":{\"codes\":\"[\\\"Level3\\\"]\",\"id\":\"47\",\"name\":\"Level3\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":48,\"values\":{\"codes\":\"[\\\"微软\\\"]\",\"id\":\"48\",\"name\":\"微软\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":49,\"values\":{\"codes\":\"[\\\"Trans-Telecom\\\"]\",\"id\":\"49\",\"name\":\"Trans-Telecom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":50,\"values\":{\"codes\":\"[\\\"Mobile-TeleSystems\\\"]\",\"id\":\"50\",\"name\":\"Mobile-TeleSystems\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":51,\"values\":{\"codes\":\"[\\\"Liberty\\\"]\",\"id\":\"51\",\"name\":\"Liberty\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":52,\"values\":{\"codes\":\"[\\\"SoftLayer\\\"]\",\"id\":\"52\",\"name\":\"SoftLayer\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":53,\"values\":{\"codes\":\"[\\\"卡塔尔电信\\\"]\",\"id\":\"53\",\"name\":\"卡塔尔电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":54,\"values\":{\"codes\":\"[\\\"Tata\\\"]\",\"id\":\"54\",\"name\":\"Tata\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":55,\"values\":{\"codes\":\"[\\\"阿曼电信\\\"]\",\"id\":\"55\",\"name\":\"阿曼电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":56,\"values\":{\"codes\":\"[\\\"T-Mobile\\\"]\",\"id\":\"56\",\"name\":\"T-Mobile\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":57,\"values\":{\"codes\":\"[\\\"Altice\\\"]\",\"id\":\"57\",\"name\":\"Altice\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":58,\"values\":{\"codes\":\"[\\\"威瑞森\\\"]\",\"id\":\"58\",\"name\":\"威瑞森\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":59,\"values\":{\"codes\":\"[\\\"美国电话电报\\\"]\",\"id\":\"59\",\"name\":\"美国电话电报\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":60,\"values\":{\"codes\":\"[\\\"Hurricane-Electric\\\"]\",\"id\":\"60\",\"name\":\"Hurricane-Electric\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":61,\"values\":{\"codes\":\"[\\\"Equinix\\\"]\",\"id\":\"61\",\"name\":\"Equinix\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":62,\"values\":{\"codes\":\"[\\\"移动\\\"]\",\"id\":\"62\",\"name\":\"移动\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":63,\"values\":{\"codes\":\"[\\\"CAT\\\"]\",\"id\":\"63\",\"name\":\"CAT\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":64,\"values\":{\"codes\":\"[\\\"Kvant-Telecom\\\"]\",\"id\":\"64\",\"name\":\"Kvant-Telecom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":65,\"values\":{\"codes\":\"[\\\"俄罗斯Tele2\\\"]\",\"id\":\"65\",\"name\":\"俄罗斯Tele2\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":66,\"values\":{\"codes\":\"[\\\"Smart\\\"]\",\"id\":\"66\",\"name\":\"Smart\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":67,\"values\":{\"codes\":\"[\\\"RETN\\\"]\",\"id\":\"67\",\"name\":\"RETN\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":68,\"values\":{\"codes\":\"[\\\"Net1\\\"]\",\"id\":\"68\",\"name\":\"Net1\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":69,\"values\":{\"codes\":\"[\\\"阿里巴巴\\\"]\",\"id\":\"69\",\"name\":\"阿里巴巴\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":70,\"values\":{\"codes\":\"[\\\"KDDI\\\"]\",\"id\":\"70\",\"name\":\"KDDI\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":71,\"values\":{\"codes\":\"[\\\"广州驰联\\\"]\",\"id\":\"71\",\"name\":\"广州驰联\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":72,\"values\":{\"codes\":\"[\\\"香港宽频\\\"]\",\"id\":\"72\",\"name\":\"香港宽频\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":73,\"values\":{\"codes\":\"[\\\"雅虎\\\"]\",\"id\":\"73\",\"name\":\"雅虎\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":74,\"values\":{\"codes\":\"[\\\"层峰网络\\\"]\",\"id\":\"74\",\"name\":\"层峰网络\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":75,\"values\":{\"codes\":\"[\\\"越南邮电\\\"]\",\"id\":\"75\",\"name\":\"越南邮电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":76,\"values\":{\"codes\":\"[\\\"Ctrls\\\"]\",\"id\":\"76\",\"name\":\"Ctrls\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":77,\"values\":{\"codes\":\"[\\\"Indusind\\\"]\",\"id\":\"77\",\"name\":\"Indusind\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":78,\"values\":{\"codes\":\"[\\\"GTPL\\\"]\",\"id\":\"78\",\"name\":\"GTPL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":79,\"values\":{\"codes\":\"[\\\"MAXIS\\\"]\",\"id\":\"79\",\"name\":\"MAXIS\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":80,\"values\":{\"codes\":\"[\\\"TPG电信\\\"]\",\"id\":\"80\",\"name\":\"TPG电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":81,\"values\":{\"codes\":\"[\\\"Jastel\\\"]\",\"id\":\"81\",\"name\":\"Jastel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":82,\"values\":{\"codes\":\"[\\\"苹果\\\"]\",\"id\":\"82\",\"name\":\"苹果\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":83,\"values\":{\"codes\":\"[\\\"印度电信\\\"]\",\"id\":\"83\",\"name\":\"印度电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":84,\"values\":{\"codes\":\"[\\\"ACT\\\"]\",\"id\":\"84\",\"name\":\"ACT\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":85,\"values\":{\"codes\":\"[\\\"Telus\\\"]\",\"id\":\"85\",\"name\":\"Telus\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":86,\"values\":{\"codes\":\"[\\\"JIO\\\"]\",\"id\":\"86\",\"name\":\"JIO\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":87,\"values\":{\"codes\":\"[\\\"Telin\\\"]\",\"id\":\"87\",\"name\":\"Telin\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":88,\"values\":{\"codes\":\"[\\\"Claro\\\"]\",\"id\":\"88\",\"name\":\"Claro\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":89,\"values\":{\"codes\":\"[\\\"Fastly\\\"]\",\"id\":\"89\",\"name\":\"Fastly\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":90,\"values\":{\"codes\":\"[\\\"CenturyLink\\\"]\",\"id\":\"90\",\"name\":\"CenturyLink\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":91,\"values\":{\"codes\":\"[\\\"罗杰斯通信\\\"]\",\"id\":\"91\",\"name\":\"罗杰斯通信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":92,\"values\":{\"codes\":\"[\\\"EarthLink\\\"]\",\"id\":\"92\",\"name\":\"EarthLink\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":93,\"values\":{\"codes\":\"[\\\"Sprint\\\"]\",\"id\":\"93\",\"name\":\"Sprint\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":94,\"values\":{\"codes\":\"[\\\"BSNL\\\"]\",\"id\":\"94\",\"name\":\"BSNL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":95,\"values\":{\"codes\":\"[\\\"HathWay\\\"]\",\"id\":\"95\",\"name\":\"HathWay\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":96,\"values\":{\"codes\":\"[\\\"远传电信\\\"]\",\"id\":\"96\",\"name\":\"远传电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":97,\"values\":{\"codes\":\"[\\\"星和移动\\\"]\",\"id\":\"97\",\"name\":\"星和移动\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":98,\"values\":{\"codes\":\"[\\\"信实通信\\\"]\",\"id\":\"98\",\"name\":\"信实通信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":99,\"values\":{\"codes\":\"[\\\"True\\\"]\",\"id\":\"99\",\"name\":\"True\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":100,\"values\":{\"codes\":\"[\\\"Mahataa\\\"]\",\"id\":\"100\",\"name\":\"Mahataa\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":101,\"values\":{\"codes\":\"[\\\"越南军用通讯\\\"]\",\"id\":\"101\",\"name\":\"越南军用通讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":102,\"values\":{\"codes\":\"[\\\"Ezecom\\\"]\",\"id\":\"102\",\"name\":\"Ezecom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":103,\"values\":{\"codes\":\"[\\\"AIS\\\"]\",\"id\":\"103\",\"name\":\"AIS\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":104,\"values\":{\"codes\":\"[\\\"新加坡第一通\\\"]\",\"id\":\"104\",\"name\":\"新加坡第一通\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":105,\"values\":{\"codes\":\"[\\\"So-Net\\\"]\",\"id\":\"105\",\"name\":\"So-Net\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":106,\"values\":{\"codes\":\"[\\\"Syscon\\\"]\",\"id\":\"106\",\"name\":\"Syscon\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":107,\"values\":{\"codes\":\"[\\\"DiGi-Telecommunication\\\"]\",\"id\":\"107\",\"name\":\"DiGi-Telecommunication\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":108,\"values\":{\"codes\":\"[\\\"澳门电讯\\\"]\",\"id\":\"108\",\"name\":\"澳门电讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":109,\"values\":{\"codes\":\"[\\\"菲律宾长途电话公司\\\"]\",\"id\":\"109\",\"name\":\"菲律宾长途电话公司\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":110,\"values\":{\"codes\":\"[\\\"互联网泰国\\\"]\",\"id\":\"110\",\"name\":\"互联网泰国\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":111,\"values\":{\"codes\":\"[\\\"Ani-Network\\\"]\",\"id\":\"111\",\"name\":\"Ani-Network\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":112,\"values\":{\"codes\":\"[\\\"UMobile\\\"]\",\"id\":\"112\",\"name\":\"UMobile\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":113,\"values\":{\"codes\":\"[\\\"Biglobe\\\"]\",\"id\":\"113\",\"name\":\"Biglobe\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":114,\"values\":{\"codes\":\"[\\\"重庆广电\\\"]\",\"id\":\"114\",\"name\":\"重庆广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":115,\"values\":{\"codes\":\"[\\\"SK电讯\\\"]\",\"id\":\"115\",\"name\":\"SK电讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":116,\"values\":{\"codes\":\"[\\\"Aircel\\\"]\",\"id\":\"116\",\"name\":\"Aircel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":117,\"values\":{\"codes\":\"[\\\"Quadrant\\\"]\",\"id\":\"117\",\"name\":\"Quadrant\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":118,\"values\":{\"codes\":\"[\\\"Elisa\\\"]\",\"id\":\"118\",\"name\":\"Elisa\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":119,\"values\":{\"codes\":\"[\\\"脸书\\\"]\",\"id\":\"119\",\"name\":\"脸书\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":120,\"values\":{\"codes\":\"[\\\"MegaFon\\\"]\",\"id\":\"120\",\"name\":\"MegaFon\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":121,\"values\":{\"codes\":\"[\\\"扎因\\\"]\",\"id\":\"121\",\"name\":\"扎因\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":122,\"values\":{\"codes\":\"[\\\"ASK4\\\"]\",\"id\":\"122\",\"name\":\"ASK4\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":123,\"values\":{\"codes\":\"[\\\"XTRA-Telecom\\\"]\",\"id\":\"123\",\"name\":\"XTRA-Telecom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":124,\"values\":{\"codes\":\"[\\\"天威\\\"]\",\"id\":\"124\",\"name\":\"天威\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":125,\"values\":{\"codes\":\"[\\\"和记电讯\\\"]\",\"id\":\"125\",\"name\":\"和记电讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":126,\"values\":{\"codes\":\"[\\\"铁通\\\"]\",\"id\":\"126\",\"name\":\"铁通\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":127,\"values\":{\"codes\":\"[\\\"科技网\\\"]\",\"id\":\"127\",\"name\":\"科技网\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":128,\"values\":{\"codes\":\"[\\\"德国电信\\\"]\",\"id\":\"128\",\"name\":\"德国电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":129,\"values\":{\"codes\":\"[\\\"GrameenPhone\\\"]\",\"id\":\"129\",\"name\":\"GrameenPhone\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":130,\"values\":{\"codes\":\"[\\\"巴基斯坦电信\\\"]\",\"id\":\"130\",\"name\":\"巴基斯坦电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":131,\"values\":{\"codes\":\"[\\\"Workonline\\\"]\",\"id\":\"131\",\"name\":\"Workonline\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":132,\"values\":{\"codes\":\"[\\\"Safaricom\\\"]\",\"id\":\"132\",\"name\":\"Safaricom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":133,\"values\":{\"codes\":\"[\\\"MTNL\\\"]\",\"id\":\"133\",\"name\":\"MTNL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":134,\"values\":{\"codes\":\"[\\\"宁夏广电\\\"]\",\"id\":\"134\",\"name\":\"宁夏广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":135,\"values\":{\"codes\":\"[\\\"Webe-Digital\\\"]\",\"id\":\"135\",\"name\":\"Webe-Digital\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":136,\"values\":{\"codes\":\"[\\\"广电\\\"]\",\"id\":\"136\",\"name\":\"广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":137,\"values\":{\"codes\":\"[\\\"西安交通大学\\\"]\",\"id\":\"137\",\"name\":\"西安交通大学\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":138,\"values\":{\"codes\":\"[\\\"老挝电信\\\"]\",\"id\":\"138\",\"name\":\"老挝电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":139,\"values\":{\"codes\":\"[\\\"湖南广电\\\"]\",\"id\":\"139\",\"name\":\"湖南广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":140,\"values\":{\"codes\":\"[\\\"黑龙江广电\\\"]\",\"id\":\"140\",\"name\":\"黑龙江广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":141,\"values\":{\"codes\":\"[\\\"湖北广电\\\"]\",\"id\":\"141\",\"name\":\"湖北广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":142,\"values\":{\"codes\":\"[\\\"Unitel\\\"]\",\"id\":\"142\",\"name\":\"Unitel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":143,\"values\":{\"codes\":\"[\\\"You-Broadband\\\"]\",\"id\":\"143\",\"name\":\"You-Broadband\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":144,\"values\":{\"codes\":\"[\\\"Siti\\\"]\",\"id\":\"144\",\"name\":\"Siti\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":145,\"values\":{\"codes\":\"[\\\"Cogetel\\\"]\",\"id\":\"145\",\"name\":\"Cogetel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":146,\"values\":{\"codes\":\"[\\\"SMTeleMedia\\\"]\",\"id\":\"146\",\"name\":\"SMTeleMedia\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":147,\"values\":{\"codes\":\"[\\\"VNPL\\\"]\",\"id\":\"147\",\"name\":\"VNPL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":148,\"values\":{\"codes\":\"[\\\"台湾固网\\\"]\",\"id\":\"148\",\"name\":\"台湾固网\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":149,\"values\":{\"codes\":\"[\\\"亚太电信\\\"]\",\"id\":\"149\",\"name\":\"亚太电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":150,\"values\":{\"codes\":\"[\\\"台湾大哥大\\\"]\",\"id\":\"150\",\"name\":\"台湾大哥大\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":151,\"values\":{\"codes\":\"[\\\"Intech\\\"]\",\"id\":\"151\",\"name\":\"Intech\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":152,\"values\":{\"codes\":\"[\\\"MegaPort\\\"]\",\"id\":\"152\",\"name\":\"MegaPort\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":153,\"values\":{\"codes\":\"[\\\"SpeedCast\\\"]\",\"id\":\"153\",\"name\":\"SpeedCast\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":154,\"values\":{\"codes\":\"[\\\"广东广电\\\"]\",\"id\":\"154\",\"name\":\"广东广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":155,\"values\":{\"codes\":\"[\\\"Banglalink\\\"]\",\"id\":\"155\",\"name\":\"Banglalink\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":156,\"values\":{\"codes\":\"[\\\"SINET\\\"]\",\"id\":\"156\",\"name\":\"SINET\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":157,\"values\":{\"codes\":\"[\\\"WiCAM\\\"]\",\"id\":\"157\",\"name\":\"WiCAM\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":158,\"values\":{\"codes\":\"[\\\"Mtel\\\"]\",\"id\":\"158\",\"name\":\"Mtel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":159,\"values\":{\"codes\":\"[\\\"华数\\\"]\",\"id\":\"159\",\"name\":\"华数\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":160,\"values\":{\"codes\":\"[\\\"上海驰联\\\"]\",\"id\":\"160\",\"name\":\"上海驰联\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":161,\"values\":{\"codes\":\"[\\\"OpenNet\\\"]\",\"id\":\"161\",\"name\":\"OpenNet\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":162,\"values\":{\"codes\":\"[\\\"Asianet\\\"]\",\"id\":\"162\",\"name\":\"Asianet\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":163,\"values\":{\"codes\":\"[\\\"老挝电信企业\\\"]\",\"id\":\"163\",\"name\":\"老挝电信企业\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":164,\"values\":{\"codes\":\"[\\\"Alliance\\\"]\",\"id\":\"164\",\"name\":\"Alliance\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":165,\"values\":{\"codes\":\"[\\\"广西广电\\\"]\",\"id\":\"165\",\"name\":\"广西广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":166,\"values\":{\"codes\":\"[\\\"江苏广电\\\"]\",\"id\":\"166\",\"name\":\"江苏广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":167,\"values\":{\"codes\":\"[\\\"世纪互联\\\"]\",\"id\":\"167\",\"name\":\"世纪互联\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":168,\"values\":{\"codes\":\"[\\\"SkyCable\\\"]\",\"id\":\"168\",\"name\":\"SkyCable\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":169,\"values\":{\"codes\":\"[\\\"SmileServ\\\"]\",\"id\":\"169\",\"name\":\"SmileServ\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":170,\"values\":{\"codes\":\"[\\\"SmarTone\\\"]\",\"id\":\"170\",\"name\":\"SmarTone\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":171,\"values\":{\"codes\":\"[\\\"BBIX\\\"]\",\"id\":\"171\",\"name\":\"BBIX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":172,\"values\":{\"codes\":\"[\\\"FastNet\\\"]\",\"id\":\"172\",\"name\":\"FastNet\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":173,\"values\":{\"codes\":\"[\\\"百度\\\"]\",\"id\":\"173\",\"name\":\"百度\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":174,\"values\":{\"codes\":\"[\\\"Railtel\\\"]\",\"id\":\"174\",\"name\":\"Railtel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":175,\"values\":{\"codes\":\"[\\\"内蒙古广电\\\"]\",\"id\":\"175\",\"name\":\"内蒙古广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":176,\"values\":{\"codes\":\"[\\\"吉视传媒\\\"]\",\"id\":\"176\",\"name\":\"吉视传媒\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":177,\"values\":{\"codes\":\"[\\\"Excitel\\\"]\",\"id\":\"177\",\"name\":\"Excitel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":178,\"values\":{\"codes\":\"[\\\"MyRepublic\\\"]\",\"id\":\"178\",\"name\":\"MyRepublic\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":179,\"values\":{\"codes\":\"[\\\"MobiFone\\\"]\",\"id\":\"179\",\"name\":\"MobiFone\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":180,\"values\":{\"codes\":\"[\\\"Den-Digital\\\"]\",\"id\":\"180\",\"name\":\"Den-Digital\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":181,\"values\":{\"codes\":\"[\\\"网上网\\\"]\",\"id\":\"181\",\"name\":\"网上网\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":182,\"values\":{\"codes\":\"[\\\"Biznet\\\"]\",\"id\":\"182\",\"name\":\"Biznet\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":183,\"values\":{\"codes\":\"[\\\"Netplus\\\"]\",\"id\":\"183\",\"name\":\"Netplus\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":184,\"values\":{\"codes\":\"[\\\"网宿\\\"]\",\"id\":\"184\",\"name\":\"网宿\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":185,\"values\":{\"codes\":\"[\\\"Telecom-Cambodia\\\"]\",\"id\":\"185\",\"name\":\"Telecom-Cambodia\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":186,\"values\":{\"codes\":\"[\\\"CIX\\\"]\",\"id\":\"186\",\"name\":\"CIX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":187,\"values\":{\"codes\":\"[\\\"加拿大贝尔\\\"]\",\"id\":\"187\",\"name\":\"加拿大贝尔\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":188,\"values\":{\"codes\":\"[\\\"AIMS\\\"]\",\"id\":\"188\",\"name\":\"AIMS\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":189,\"values\":{\"codes\":\"[\\\"SKBB\\\"]\",\"id\":\"189\",\"name\":\"SKBB\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":190,\"values\":{\"codes\":\"[\\\"Nift\\\"]\",\"id\":\"190\",\"name\":\"Nift\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":191,\"values\":{\"codes\":\"[\\\"有线通\\\"]\",\"id\":\"191\",\"name\":\"有线通\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":192,\"values\":{\"codes\":\"[\\\"香港新世界\\\"]\",\"id\":\"192\",\"name\":\"香港新世界\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":193,\"values\":{\"codes\":\"[\\\"华数传媒\\\"]\",\"id\":\"193\",\"name\":\"华数传媒\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":194,\"values\":{\"codes\":\"[\\\"3BB\\\"]\",\"id\":\"194\",\"name\":\"3BB\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":195,\"values\":{\"codes\":\"[\\\"辽宁广电\\\"]\",\"id\":\"195\",\"name\":\"辽宁广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":196,\"values\":{\"codes\":\"[\\\"河南广电\\\"]\",\"id\":\"196\",\"name\":\"河南广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":197,\"values\":{\"codes\":\"[\\\"陕西广电\\\"]\",\"id\":\"197\",\"name\":\"陕西广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":198,\"values\":{\"codes\":\"[\\\"新疆广电\\\"]\",\"id\":\"198\",\"name\":\"新疆广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":199,\"values\":{\"codes\":\"[\\\"山东广电\\\"]\",\"id\":\"199\",\"name\":\"山东广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":200,\"values\":{\"codes\":\"[\\\"四川广电\\\"]\",\"id\":\"200\",\"name\":\"四川广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":201,\"values\":{\"codes\":\"[\\\"文莱电信\\\"]\",\"id\":\"201\",\"name\":\"文莱电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":202,\"values\":{\"codes\":\"[\\\"香港有线\\\"]\",\"id\":\"202\",\"name\":\"香港有线\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":203,\"values\":{\"codes\":\"[\\\"天驰网络\\\"]\",\"id\":\"203\",\"name\":\"天驰网络\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":204,\"values\":{\"codes\":\"[\\\"湖南巨亚\\\"]\",\"id\":\"204\",\"name\":\"湖南巨亚\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":205,\"values\":{\"codes\":\"[\\\"MSK-IX\\\"]\",\"id\":\"205\",\"name\":\"MSK-IX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":206,\"values\":{\"codes\":\"[\\\"法国电信\\\"]\",\"id\":\"206\",\"name\":\"法国电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":207,\"values\":{\"codes\":\"[\\\"缅甸电信\\\"]\",\"id\":\"207\",\"name\":\"缅甸电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":208,\"values\":{\"codes\":\"[\\\"星通讯\\\"]\",\"id\":\"208\",\"name\":\"星通讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":209,\"values\":{\"codes\":\"[\\\"Netnod\\\"]\",\"id\":\"209\",\"name\":\"Netnod\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":210,\"values\":{\"codes\":\"[\\\"Masmovil\\\"]\",\"id\":\"210\",\"name\":\"Masmovil\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":211,\"values\":{\"codes\":\"[\\\"AMS-IX\\\"]\",\"id\":\"211\",\"name\":\"AMS-IX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":212,\"values\":{\"codes\":\"[\\\"Telefonica-O2\\\"]\",\"id\":\"212\",\"name\":\"Telefonica-O2\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":213,\"values\":{\"codes\":\"[\\\"Asiacell\\\"]\",\"id\":\"213\",\"name\":\"Asiacell\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":214,\"values\":{\"codes\":\"[\\\"布伊格电信\\\"]\",\"id\":\"214\",\"name\":\"布伊格电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":215,\"values\":{\"codes\":\"[\\\"Vivo\\\"]\",\"id\":\"215\",\"name\":\"Vivo\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":216,\"values\":{\"codes\":\"[\\\"XL\\\"]\",\"id\":\"216\",\"name\":\"XL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":217,\"values\":{\"codes\":\"[\\\"EE-Mobile\\\"]\",\"id\":\"217\",\"name\":\"EE-Mobile\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":218,\"values\":{\"codes\":\"[\\\"Tesco\\\"]\",\"id\":\"218\",\"name\":\"Tesco\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":219,\"values\":{\"codes\":\"[\\\"Netnam\\\"]\",\"id\":\"219\",\"name\":\"Netnam\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":220,\"values\":{\"codes\":\"[\\\"河北广电\\\"]\",\"id\":\"220\",\"name\":\"河北广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":221,\"values\":{\"codes\":\"[\\\"JPIX\\\"]\",\"id\":\"221\",\"name\":\"JPIX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":222,\"values\":{\"codes\":\"[\\\"Celcom\\\"]\",\"id\":\"222\",\"name\":\"Celcom\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":223,\"values\":{\"codes\":\"[\\\"印尼金光\\\"]\",\"id\":\"223\",\"name\":\"印尼金光\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":224,\"values\":{\"codes\":\"[\\\"和记电讯印尼\\\"]\",\"id\":\"224\",\"name\":\"和记电讯印尼\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":225,\"values\":{\"codes\":\"[\\\"Indosat\\\"]\",\"id\":\"225\",\"name\":\"Indosat\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":226,\"values\":{\"codes\":\"[\\\"Excell\\\"]\",\"id\":\"226\",\"name\":\"Excell\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":227,\"values\":{\"codes\":\"[\\\"StackPath\\\"]\",\"id\":\"227\",\"name\":\"StackPath\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":228,\"values\":{\"codes\":\"[\\\"贵州广电\\\"]\",\"id\":\"228\",\"name\":\"贵州广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":229,\"values\":{\"codes\":\"[\\\"Camintel\\\"]\",\"id\":\"229\",\"name\":\"Camintel\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":230,\"values\":{\"codes\":\"[\\\"Vnetwork\\\"]\",\"id\":\"230\",\"name\":\"Vnetwork\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":231,\"values\":{\"codes\":\"[\\\"湖南有线\\\"]\",\"id\":\"231\",\"name\":\"湖南有线\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":232,\"values\":{\"codes\":\"[\\\"山东乐享广电\\\"]\",\"id\":\"232\",\"name\":\"山东乐享广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":233,\"values\":{\"codes\":\"[\\\"BroadWay\\\"]\",\"id\":\"233\",\"name\":\"BroadWay\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":234,\"values\":{\"codes\":\"[\\\"First-Media\\\"]\",\"id\":\"234\",\"name\":\"First-Media\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":235,\"values\":{\"codes\":\"[\\\"西贡电信\\\"]\",\"id\":\"235\",\"name\":\"西贡电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":236,\"values\":{\"codes\":\"[\\\"APSFL\\\"]\",\"id\":\"236\",\"name\":\"APSFL\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":237,\"values\":{\"codes\":\"[\\\"Teletalk\\\"]\",\"id\":\"237\",\"name\":\"Teletalk\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":238,\"values\":{\"codes\":\"[\\\"越南电信\\\"]\",\"id\":\"238\",\"name\":\"越南电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":239,\"values\":{\"codes\":\"[\\\"2Degrees\\\"]\",\"id\":\"239\",\"name\":\"2Degrees\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":240,\"values\":{\"codes\":\"[\\\"湄公网\\\"]\",\"id\":\"240\",\"name\":\"湄公网\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":241,\"values\":{\"codes\":\"[\\\"大连理工大学\\\"]\",\"id\":\"241\",\"name\":\"大连理工大学\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":242,\"values\":{\"codes\":\"[\\\"山东青岛广电\\\"]\",\"id\":\"242\",\"name\":\"山东青岛广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":243,\"values\":{\"codes\":\"[\\\"浙江华数\\\"]\",\"id\":\"243\",\"name\":\"浙江华数\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":244,\"values\":{\"codes\":\"[\\\"Spark-New-Zealand\\\"]\",\"id\":\"244\",\"name\":\"Spark-New-Zealand\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":245,\"values\":{\"codes\":\"[\\\"珠江宽频\\\"]\",\"id\":\"245\",\"name\":\"珠江宽频\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":246,\"values\":{\"codes\":\"[\\\"海南广电\\\"]\",\"id\":\"246\",\"name\":\"海南广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":247,\"values\":{\"codes\":\"[\\\"香港流动通讯\\\"]\",\"id\":\"247\",\"name\":\"香港流动通讯\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":248,\"values\":{\"codes\":\"[\\\"墨西哥电信\\\"]\",\"id\":\"248\",\"name\":\"墨西哥电信\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":249,\"values\":{\"codes\":\"[\\\"柬埔寨信威\\\"]\",\"id\":\"249\",\"name\":\"柬埔寨信威\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":250,\"values\":{\"codes\":\"[\\\"MIX\\\"]\",\"id\":\"250\",\"name\":\"MIX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":251,\"values\":{\"codes\":\"[\\\"Linx\\\"]\",\"id\":\"251\",\"name\":\"Linx\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":252,\"values\":{\"codes\":\"[\\\"TPIX\\\"]\",\"id\":\"252\",\"name\":\"TPIX\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":253,\"values\":{\"codes\":\"[\\\"Movistar\\\"]\",\"id\":\"253\",\"name\":\"Movistar\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":254,\"values\":{\"codes\":\"[\\\"墨西哥美洲移动\\\"]\",\"id\":\"254\",\"name\":\"墨西哥美洲移动\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":255,\"values\":{\"codes\":\"[\\\"互联优势\\\"]\",\"id\":\"255\",\"name\":\"互联优势\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":256,\"values\":{\"codes\":\"[\\\"上海教委\\\"]\",\"id\":\"256\",\"name\":\"上海教委\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":257,\"values\":{\"codes\":\"[\\\"福建广电\\\"]\",\"id\":\"257\",\"name\":\"福建广电\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]},{\"id\":258,\"values\":{\"codes\":\"[\\\"内网IP\\\"]\",\"id\":\"258\",\"name\":\"内网IP\",\"state\":\"1\"},\"uniqueFields\":[\"name\"]}]},{\"name\":\"edgeRegionProvinces\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeRegionProvinces` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `countryId` int(11) unsigned DEFAULT '0' COMMENT '国家ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `codes` json DEFAULT NULL COMMENT '代号',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `dataId` varchar(255) DEFAULT NULL COMMENT '原始数据ID',\\n  PRIMARY KEY (`id`),\\n  KEY `countryId` (`countryId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='区域-省份'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"countryId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '国家ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"codes\",\"definition\":\"json COMMENT '代号'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"dataId\",\"definition\":\"varchar(255) COMMENT '原始数据ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"countryId\",\"definition\":\"KEY `countryId` (`countryId`) USING BTREE\"}],\"records\":[{\"id\":1,\"values\":{\"codes\":\"[\\\"北京市\\\", \\\"北京\\\"]\",\"countryId\":\"1\",\"dataId\":\"214\",\"id\":\"1\",\"name\":\"北京市\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":2,\"values\":{\"codes\":\"[\\\"天津市\\\", \\\"天津\\\"]\",\"countryId\":\"1\",\"dataId\":\"234\",\"id\":\"2\",\"name\":\"天津市\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":3,\"values\":{\"codes\":\"[\\\"河北省\\\"]\",\"countryId\":\"1\",\"dataId\":\"254\",\"id\":\"3\",\"name\":\"河北省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":4,\"values\":{\"codes\":\"[\\\"山西省\\\"]\",\"countryId\":\"1\",\"dataId\":\"433\",\"id\":\"4\",\"name\":\"山西省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":5,\"values\":{\"codes\":\"[\\\"内蒙古自治区\\\", \\\"内蒙古\\\"]\",\"countryId\":\"1\",\"dataId\":\"559\",\"id\":\"5\",\"name\":\"内蒙古自治区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":6,\"values\":{\"codes\":\"[\\\"辽宁省\\\"]\",\"countryId\":\"1\",\"dataId\":\"673\",\"id\":\"6\",\"name\":\"辽宁省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":7,\"values\":{\"codes\":\"[\\\"吉林省\\\"]\",\"countryId\":\"1\",\"dataId\":\"786\",\"id\":\"7\",\"name\":\"吉林省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":8,\"values\":{\"codes\":\"[\\\"黑龙江省\\\"]\",\"countryId\":\"1\",\"dataId\":\"853\",\"id\":\"8\",\"name\":\"黑龙江省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":9,\"values\":{\"codes\":\"[\\\"上海市\\\", \\\"上海\\\"]\",\"countryId\":\"1\",\"dataId\":\"994\",\"id\":\"9\",\"name\":\"上海市\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":10,\"values\":{\"codes\":\"[\\\"江苏省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1014\",\"id\":\"10\",\"name\":\"江苏省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":11,\"values\":{\"codes\":\"[\\\"浙江省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1131\",\"id\":\"11\",\"name\":\"浙江省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":12,\"values\":{\"codes\":\"[\\\"安徽省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1232\",\"id\":\"12\",\"name\":\"安徽省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":13,\"values\":{\"codes\":\"[\\\"福建省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1353\",\"id\":\"13\",\"name\":\"福建省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":14,\"values\":{\"codes\":\"[\\\"江西省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1447\",\"id\":\"14\",\"name\":\"江西省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":15,\"values\":{\"codes\":\"[\\\"山东省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1557\",\"id\":\"15\",\"name\":\"山东省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":16,\"values\":{\"codes\":\"[\\\"河南省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1712\",\"id\":\"16\",\"name\":\"河南省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":17,\"values\":{\"codes\":\"[\\\"湖北省\\\"]\",\"countryId\":\"1\",\"dataId\":\"1886\",\"id\":\"17\",\"name\":\"湖北省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":18,\"values\":{\"codes\":\"[\\\"湖南省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2002\",\"id\":\"18\",\"name\":\"湖南省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":19,\"values\":{\"codes\":\"[\\\"广东省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2139\",\"id\":\"19\",\"name\":\"广东省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":20,\"values\":{\"codes\":\"[\\\"广西壮族自治区\\\", \\\"广西\\\"]\",\"countryId\":\"1\",\"dataId\":\"2279\",\"id\":\"20\",\"name\":\"广西壮族自治区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":21,\"values\":{\"codes\":\"[\\\"海南省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2403\",\"id\":\"21\",\"name\":\"海南省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":22,\"values\":{\"codes\":\"[\\\"重庆市\\\", \\\"重庆\\\"]\",\"countryId\":\"1\",\"dataId\":\"2429\",\"id\":\"22\",\"name\":\"重庆市\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":23,\"values\":{\"codes\":\"[\\\"四川省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2469\",\"id\":\"23\",\"name\":\"四川省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":24,\"values\":{\"codes\":\"[\\\"贵州省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2669\",\"id\":\"24\",\"name\":\"贵州省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":25,\"values\":{\"codes\":\"[\\\"云南省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2766\",\"id\":\"25\",\"name\":\"云南省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":26,\"values\":{\"codes\":\"[\\\"西藏自治区\\\", \\\"西藏\\\"]\",\"countryId\":\"1\",\"dataId\":\"2912\",\"id\":\"26\",\"name\":\"西藏自治区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":27,\"values\":{\"codes\":\"[\\\"陕西省\\\"]\",\"countryId\":\"1\",\"dataId\":\"2993\",\"id\":\"27\",\"name\":\"陕西省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":28,\"values\":{\"codes\":\"[\\\"甘肃省\\\"]\",\"countryId\":\"1\",\"dataId\":\"3109\",\"id\":\"28\",\"name\":\"甘肃省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":29,\"values\":{\"codes\":\"[\\\"青海省\\\"]\",\"countryId\":\"1\",\"dataId\":\"3209\",\"id\":\"29\",\"name\":\"青海省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":30,\"values\":{\"codes\":\"[\\\"宁夏回族自治区\\\", \\\"宁夏\\\"]\",\"countryId\":\"1\",\"dataId\":\"3260\",\"id\":\"30\",\"name\":\"宁夏回族自治区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":31,\"values\":{\"codes\":\"[\\\"新疆维吾尔自治区\\\", \\\"新疆\\\"]\",\"countryId\":\"1\",\"dataId\":\"3287\",\"id\":\"31\",\"name\":\"新疆维吾尔自治区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":32,\"values\":{\"codes\":\"[\\\"香港特别行政区\\\", \\\"香港\\\"]\",\"countryId\":\"1\",\"dataId\":\"3399\",\"id\":\"32\",\"name\":\"香港特别行政区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":33,\"values\":{\"codes\":\"[\\\"澳门特别行政区\\\", \\\"澳门\\\"]\",\"countryId\":\"1\",\"dataId\":\"3401\",\"id\":\"33\",\"name\":\"澳门特别行政区\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]},{\"id\":34,\"values\":{\"codes\":\"[\\\"台湾省\\\"]\",\"countryId\":\"1\",\"dataId\":\"3403\",\"id\":\"34\",\"name\":\"台湾省\",\"state\":\"1\"},\"uniqueFields\":[\"name\",\"countryId\"]}]},{\"name\":\"edgeReverseProxies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeReverseProxies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `scheduling` json DEFAULT NULL COMMENT '调度算法',\\n  `primaryOrigins` json DEFAULT NULL COMMENT '主要源站',\\n  `backupOrigins` json DEFAULT NULL COMMENT '备用源站',\\n  `stripPrefix` varchar(255) DEFAULT NULL COMMENT '去除URL前缀',\\n  `requestHostType` tinyint(1) unsigned DEFAULT '0' COMMENT '请求Host类型',\\n  `requestHost` varchar(255) DEFAULT NULL COMMENT '请求Host',\\n  `requestURI` varchar(1024) DEFAULT NULL COMMENT '请求URI',\\n  `autoFlush` tinyint(1) unsigned DEFAULT '0' COMMENT '是否自动刷新缓冲区',\\n  `addHeaders` json DEFAULT NULL COMMENT '自动添加的Header列表',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `connTimeout` json DEFAULT NULL COMMENT '连接超时时间',\\n  `readTimeout` json DEFAULT NULL COMMENT '读取超时时间',\\n  `idleTimeout` json DEFAULT NULL COMMENT '空闲超时时间',\\n  `maxConns` int(11) unsigned DEFAULT '0' COMMENT '最大并发连接数',\\n  `maxIdleConns` int(11) unsigned DEFAULT '0' COMMENT '最大空闲连接数',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='反向代理配置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"scheduling\",\"definition\":\"json COMMENT '调度算法'\"},{\"name\":\"primaryOrigins\",\"definition\":\"json COMMENT '主要源站'\"},{\"name\":\"backupOrigins\",\"definition\":\"json COMMENT '备用源站'\"},{\"name\":\"stripPrefix\",\"definition\":\"varchar(255) COMMENT '去除URL前缀'\"},{\"name\":\"requestHostType\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '请求Host类型'\"},{\"name\":\"requestHost\",\"definition\":\"varchar(255) COMMENT '请求Host'\"},{\"name\":\"requestURI\",\"definition\":\"varchar(1024) COMMENT '请求URI'\"},{\"name\":\"autoFlush\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否自动刷新缓冲区'\"},{\"name\":\"addHeaders\",\"definition\":\"json COMMENT '自动添加的Header列表'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"connTimeout\",\"definition\":\"json COMMENT '连接超时时间'\"},{\"name\":\"readTimeout\",\"definition\":\"json COMMENT '读取超时时间'\"},{\"name\":\"idleTimeout\",\"definition\":\"json COMMENT '空闲超时时间'\"},{\"name\":\"maxConns\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最大并发连接数'\"},{\"name\":\"maxIdleConns\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '最大空闲连接数'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSSLCertGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSSLCertGroups` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '分组名',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '分组排序',\\n  `state` tinyint(1) unsigned DEFAULT '0' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='证书分组'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '分组名'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '分组排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSSLCerts\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSSLCerts` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '证书名',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `certData` blob COMMENT '证书内容',\\n  `keyData` blob COMMENT '密钥内容',\\n  `serverName` varchar(255) DEFAULT NULL COMMENT '证书使用的主机名',\\n  `isCA` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为CA证书',\\n  `groupIds` json DEFAULT NULL COMMENT '证书分组',\\n  `timeBeginAt` bigint(11) unsigned DEFAULT '0' COMMENT '开始时间',\\n  `timeEndAt` bigint(11) unsigned DEFAULT '0' COMMENT '结束时间',\\n  `dnsNames` json DEFAULT NULL COMMENT 'DNS名称列表',\\n  `commonNames` json DEFAULT NULL COMMENT '发行单位列表',\\n  `isACME` tinyint(1) unsigned DEFAULT '0' COMMENT '是否为ACME自动生成的',\\n  `acmeTaskId` bigint(11) unsigned DEFAULT '0' COMMENT 'ACME任务ID',\\n  `notifiedAt` bigint(11) unsigned DEFAULT '0' COMMENT '最后通知时间',\\n  PRIMARY KEY (`id`),\\n  KEY `adminId` (`adminId`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='SSL证书'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '证书名'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"certData\",\"definition\":\"blob COMMENT '证书内容'\"},{\"name\":\"keyData\",\"definition\":\"blob COMMENT '密钥内容'\"},{\"name\":\"serverName\",\"definition\":\"varchar(255) COMMENT '证书使用的主机名'\"},{\"name\":\"isCA\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为CA证书'\"},{\"name\":\"groupIds\",\"definition\":\"json COMMENT '证书分组'\"},{\"name\":\"timeBeginAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '开始时间'\"},{\"name\":\"timeEndAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '结束时间'\"},{\"name\":\"dnsNames\",\"definition\":\"json COMMENT 'DNS名称列表'\"},{\"name\":\"commonNames\",\"definition\":\"json COMMENT '发行单位列表'\"},{\"name\":\"isACME\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否为ACME自动生成的'\"},{\"name\":\"acmeTaskId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT 'ACME任务ID'\"},{\"name\":\"notifiedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '最后通知时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSSLPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSSLPolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `certs` json DEFAULT NULL COMMENT '证书列表',\\n  `clientCACerts` json DEFAULT NULL COMMENT '客户端证书',\\n  `clientAuthType` int(11) unsigned DEFAULT '0' COMMENT '客户端认证类型',\\n  `minVersion` varchar(32) DEFAULT NULL COMMENT '支持的SSL最小版本',\\n  `cipherSuitesIsOn` tinyint(1) unsigned DEFAULT '0' COMMENT '是否自定义加密算法套件',\\n  `cipherSuites` json DEFAULT NULL COMMENT '加密算法套件',\\n  `hsts` json DEFAULT NULL COMMENT 'HSTS设置',\\n  `http2Enabled` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用HTTP/2',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='SSL配置策略'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"certs\",\"definition\":\"json COMMENT '证书列表'\"},{\"name\":\"clientCACerts\",\"definition\":\"json COMMENT '客户端证书'\"},{\"name\":\"clientAuthType\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '客户端认证类型'\"},{\"name\":\"minVersion\",\"definition\":\"varchar(32) COMMENT '支持的SSL最小版本'\"},{\"name\":\"cipherSuitesIsOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否自定义加密算法套件'\"},{\"name\":\"cipherSuites\",\"definition\":\"json COMMENT '加密算法套件'\"},{\"name\":\"hsts\",\"definition\":\"json COMMENT 'HSTS设置'\"},{\"name\":\"http2Enabled\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用HTTP/2'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerClientBrowserMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerClientBrowserMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `browserId` int(11) unsigned DEFAULT '0' COMMENT '浏览器ID',\\n  `month` varchar(6) DEFAULT NULL COMMENT 'YYYYMM',\\n  `version` varchar(255) DEFAULT NULL COMMENT '主版本号',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`serverId`,`browserId`,`version`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='浏览器统计（按月）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"browserId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '浏览器ID'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT 'YYYYMM'\"},{\"name\":\"version\",\"definition\":\"varchar(255) COMMENT '主版本号'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`serverId`,`browserId`,`version`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerClientSystemMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerClientSystemMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `systemId` int(11) unsigned DEFAULT '0' COMMENT '系统ID',\\n  `version` varchar(255) DEFAULT NULL COMMENT '主版本号',\\n  `month` varchar(6) DEFAULT NULL COMMENT 'YYYYMM',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`serverId`,`systemId`,`version`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='操作系统统计（按月）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"systemId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '系统ID'\"},{\"name\":\"version\",\"definition\":\"varchar(255) COMMENT '主版本号'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT 'YYYYMM'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`serverId`,`systemId`,`version`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerDailyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerDailyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `regionId` int(11) unsigned DEFAULT '0' COMMENT '区域ID',\\n  `bytes` bigint(20) unsigned DEFAULT '0' COMMENT '流量',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存的流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  `day` varchar(8) DEFAULT NULL COMMENT '日期YYYYMMDD',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `timeFrom` varchar(6) DEFAULT NULL COMMENT '开始时间HHMMSS',\\n  `timeTo` varchar(6) DEFAULT NULL COMMENT '结束时间',\\n  `isCharged` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已计算费用',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `sererId_regionId_day_timeFrom` (`serverId`,`regionId`,`day`,`timeFrom`),\\n  KEY `serverId_day` (`serverId`,`day`) USING BTREE,\\n  KEY `isCharged` (`isCharged`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='计费流量统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"regionId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '区域ID'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '流量'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存的流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存的请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT '日期YYYYMMDD'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"timeFrom\",\"definition\":\"varchar(6) COMMENT '开始时间HHMMSS'\"},{\"name\":\"timeTo\",\"definition\":\"varchar(6) COMMENT '结束时间'\"},{\"name\":\"isCharged\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已计算费用'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"sererId_regionId_day_timeFrom\",\"definition\":\"UNIQUE KEY `sererId_regionId_day_timeFrom` (`serverId`,`regionId`,`day`,`timeFrom`) USING BTREE\"},{\"name\":\"serverId_day\",\"definition\":\"KEY `serverId_day` (`serverId`,`day`) USING BTREE\"},{\"name\":\"isCharged\",\"definition\":\"KEY `isCharged` (`isCharged`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerDomainHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerDomainHourlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `nodeId` int(11) unsigned DEFAULT '0' COMMENT '节点ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `domain` varchar(255) DEFAULT NULL COMMENT '域名',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `bytes` bigint(20) unsigned DEFAULT '0' COMMENT '流量',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `domain_hour` (`clusterId`,`serverId`,`domain`,`hour`) USING BTREE,\\n  KEY `clusterId` (`clusterId`),\\n  KEY `serverId` (`serverId`),\\n  KEY `nodeId` (`nodeId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务域名统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"nodeId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '节点ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"domain\",\"definition\":\"varchar(255) COMMENT '域名'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '流量'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击请求数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"domain_hour\",\"definition\":\"UNIQUE KEY `domain_hour` (`clusterId`,`serverId`,`domain`,`hour`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"serverId\",\"definition\":\"KEY `serverId` (`serverId`) USING BTREE\"},{\"name\":\"nodeId\",\"definition\":\"KEY `nodeId` (`nodeId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerGroups\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerGroups` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务分组'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerHTTPFirewallDailyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerHTTPFirewallDailyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `httpFirewallRuleGroupId` int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID',\\n  `action` varchar(64) DEFAULT NULL COMMENT '采取的动作',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`serverId`,`day`,`httpFirewallRuleGroupId`,`action`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='WAF统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"httpFirewallRuleGroupId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID'\"},{\"name\":\"action\",\"definition\":\"varchar(64) COMMENT '采取的动作'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`serverId`,`day`,`httpFirewallRuleGroupId`,`action`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerHTTPFirewallHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerHTTPFirewallHourlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `httpFirewallRuleGroupId` int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID',\\n  `action` varchar(64) DEFAULT NULL COMMENT '采取的动作',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`serverId`,`hour`,`httpFirewallRuleGroupId`,`action`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='WAF统计'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"httpFirewallRuleGroupId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WAF分组ID'\"},{\"name\":\"action\",\"definition\":\"varchar(64) COMMENT '采取的动作'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`serverId`,`hour`,`httpFirewallRuleGroupId`,`action`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerRegionCityMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerRegionCityMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `cityId` int(11) unsigned DEFAULT '0' COMMENT '城市ID',\\n  `month` varchar(6) DEFAULT NULL COMMENT '月份YYYYMM',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `unique_id` (`serverId`,`cityId`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务用户省份分布统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"cityId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '城市ID'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT '月份YYYYMM'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"unique_id\",\"definition\":\"UNIQUE KEY `unique_id` (`serverId`,`cityId`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerRegionCountryMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerRegionCountryMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `countryId` int(11) unsigned DEFAULT '0' COMMENT '国家/区域ID',\\n  `month` varchar(6) DEFAULT NULL COMMENT '月份YYYYMM',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `unique_id` (`serverId`,`countryId`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务用户区域分布统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"countryId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '国家/区域ID'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT '月份YYYYMM'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"unique_id\",\"definition\":\"UNIQUE KEY `unique_id` (`serverId`,`countryId`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerRegionProviderMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerRegionProviderMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `providerId` int(11) unsigned DEFAULT '0' COMMENT '运营商ID',\\n  `month` varchar(6) DEFAULT NULL COMMENT '月份YYYYMM',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `unique_id` (`serverId`,`providerId`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务用户省份分布统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"providerId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '运营商ID'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT '月份YYYYMM'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"unique_id\",\"definition\":\"UNIQUE KEY `unique_id` (`serverId`,`providerId`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerRegionProvinceMonthlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerRegionProvinceMonthlyStats` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `serverId` int(11) unsigned DEFAULT '0' COMMENT '服务ID',\\n  `provinceId` int(11) unsigned DEFAULT '0' COMMENT '省份ID',\\n  `month` varchar(6) DEFAULT NULL COMMENT '月份YYYYMM',\\n  `count` bigint(20) unsigned DEFAULT '0' COMMENT '数量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `unique_id` (`serverId`,`provinceId`,`month`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务用户省份分布统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"serverId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '服务ID'\"},{\"name\":\"provinceId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '省份ID'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT '月份YYYYMM'\"},{\"name\":\"count\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '数量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"unique_id\",\"definition\":\"UNIQUE KEY `unique_id` (`serverId`,`provinceId`,`month`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerStatBoardCharts\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerStatBoardCharts` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `boardId` bigint(11) unsigned DEFAULT '0' COMMENT '看板ID',\\n  `code` varchar(255) DEFAULT NULL COMMENT '内置图表代码',\\n  `itemId` int(11) unsigned DEFAULT '0' COMMENT '指标ID',\\n  `chartId` int(11) unsigned DEFAULT '0' COMMENT '图表ID',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `boardId` (`boardId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务看板中的图表'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"boardId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '看板ID'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '内置图表代码'\"},{\"name\":\"itemId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '指标ID'\"},{\"name\":\"chartId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '图表ID'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"boardId\",\"definition\":\"KEY `boardId` (`boardId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServerStatBoards\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServerStatBoards` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `clusterId` (`clusterId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务统计看板'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeServers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeServers` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `type` varchar(64) DEFAULT NULL COMMENT '服务类型',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(512) DEFAULT NULL COMMENT '描述',\\n  `serverNames` json DEFAULT NULL COMMENT '域名列表',\\n  `auditingServerNames` json DEFAULT NULL COMMENT '审核中的域名',\\n  `isAuditing` tinyint(1) unsigned DEFAULT '0' COMMENT '是否正在审核',\\n  `auditingResult` json DEFAULT NULL COMMENT '审核结果',\\n  `http` json DEFAULT NULL COMMENT 'HTTP配置',\\n  `https` json DEFAULT NULL COMMENT 'HTTPS配置',\\n  `tcp` json DEFAULT NULL COMMENT 'TCP配置',\\n  `tls` json DEFAULT NULL COMMENT 'TLS配置',\\n  `unix` json DEFAULT NULL COMMENT 'Unix配置',\\n  `udp` json DEFAULT NULL COMMENT 'UDP配置',\\n  `webId` int(11) unsigned DEFAULT '0' COMMENT 'WEB配置',\\n  `reverseProxy` json DEFAULT NULL COMMENT '反向代理配置',\\n  `groupIds` json DEFAULT NULL COMMENT '分组ID列表',\\n  `config` json DEFAULT NULL COMMENT '服务配置，自动生成',\\n  `configMd5` varchar(32) DEFAULT NULL COMMENT 'Md5',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `includeNodes` json DEFAULT NULL COMMENT '部署条件',\\n  `excludeNodes` json DEFAULT NULL COMMENT '节点排除条件',\\n  `version` int(11) unsigned DEFAULT '0' COMMENT '版本号',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `dnsName` varchar(255) DEFAULT NULL COMMENT 'DNS名称',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `adminId` (`adminId`),\\n  KEY `isUpdating_state` (`state`) USING BTREE,\\n  KEY `dnsName` (`dnsName`),\\n  KEY `clusterId` (`clusterId`),\\n  KEY `isAuditing` (`isAuditing`),\\n  KEY `webId` (`webId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='服务'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"type\",\"definition\":\"varchar(64) COMMENT '服务类型'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(512) COMMENT '描述'\"},{\"name\":\"serverNames\",\"definition\":\"json COMMENT '域名列表'\"},{\"name\":\"auditingServerNames\",\"definition\":\"json COMMENT '审核中的域名'\"},{\"name\":\"isAuditing\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否正在审核'\"},{\"name\":\"auditingResult\",\"definition\":\"json COMMENT '审核结果'\"},{\"name\":\"http\",\"definition\":\"json COMMENT 'HTTP配置'\"},{\"name\":\"https\",\"definition\":\"json COMMENT 'HTTPS配置'\"},{\"name\":\"tcp\",\"definition\":\"json COMMENT 'TCP配置'\"},{\"name\":\"tls\",\"definition\":\"json COMMENT 'TLS配置'\"},{\"name\":\"unix\",\"definition\":\"json COMMENT 'Unix配置'\"},{\"name\":\"udp\",\"definition\":\"json COMMENT 'UDP配置'\"},{\"name\":\"webId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT 'WEB配置'\"},{\"name\":\"reverseProxy\",\"definition\":\"json COMMENT '反向代理配置'\"},{\"name\":\"groupIds\",\"definition\":\"json COMMENT '分组ID列表'\"},{\"name\":\"config\",\"definition\":\"json COMMENT '服务配置，自动生成'\"},{\"name\":\"configMd5\",\"definition\":\"varchar(32) COMMENT 'Md5'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"includeNodes\",\"definition\":\"json COMMENT '部署条件'\"},{\"name\":\"excludeNodes\",\"definition\":\"json COMMENT '节点排除条件'\"},{\"name\":\"version\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"dnsName\",\"definition\":\"varchar(255) COMMENT 'DNS名称'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"adminId\",\"definition\":\"KEY `adminId` (`adminId`) USING BTREE\"},{\"name\":\"isUpdating_state\",\"definition\":\"KEY `isUpdating_state` (`state`) USING BTREE\"},{\"name\":\"dnsName\",\"definition\":\"KEY `dnsName` (`dnsName`) USING BTREE\"},{\"name\":\"clusterId\",\"definition\":\"KEY `clusterId` (`clusterId`) USING BTREE\"},{\"name\":\"isAuditing\",\"definition\":\"KEY `isAuditing` (`isAuditing`) USING BTREE\"},{\"name\":\"webId\",\"definition\":\"KEY `webId` (`webId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSubUsers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSubUsers` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '所属主用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `username` varchar(255) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(32) DEFAULT NULL COMMENT '密码',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='子用户'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '所属主用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"username\",\"definition\":\"varchar(255) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(32) COMMENT '密码'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSysEvents\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSysEvents` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '类型',\\n  `params` json DEFAULT NULL COMMENT '参数',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='系统事件'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '类型'\"},{\"name\":\"params\",\"definition\":\"json COMMENT '参数'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSysLockers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSysLockers` (\\n  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `key` varchar(255) DEFAULT NULL COMMENT '键值',\\n  `version` bigint(20) unsigned DEFAULT '0' COMMENT '版本号',\\n  `timeoutAt` bigint(11) unsigned DEFAULT '0' COMMENT '超时时间',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `key` (`key`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='并发锁'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(20) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"key\",\"definition\":\"varchar(255) COMMENT '键值'\"},{\"name\":\"version\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '版本号'\"},{\"name\":\"timeoutAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '超时时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"key\",\"definition\":\"UNIQUE KEY `key` (`key`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeSysSettings\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeSysSettings` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `code` varchar(255) DEFAULT NULL COMMENT '代号',\\n  `value` json DEFAULT NULL COMMENT '配置值',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `code` (`code`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='系统配置'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"code\",\"definition\":\"varchar(255) COMMENT '代号'\"},{\"name\":\"value\",\"definition\":\"json COMMENT '配置值'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"code\",\"definition\":\"UNIQUE KEY `code` (`code`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeTCPFirewallPolicies\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeTCPFirewallPolicies` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) DEFAULT NULL COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `templateId` int(11) unsigned DEFAULT '0' COMMENT '模版ID',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='TCP防火墙'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"templateId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '模版ID'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeTrafficDailyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeTrafficDailyStats` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `bytes` bigint(20) unsigned DEFAULT NULL COMMENT '流量字节',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击量',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `day` (`day`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='总的流量统计（按天）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned COMMENT '流量字节'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击量'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"UNIQUE KEY `day` (`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeTrafficHourlyStats\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeTrafficHourlyStats` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `hour` varchar(10) DEFAULT NULL COMMENT 'YYYYMMDDHH',\\n  `bytes` bigint(20) unsigned DEFAULT NULL COMMENT '流量字节',\\n  `cachedBytes` bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量',\\n  `countRequests` bigint(20) unsigned DEFAULT '0' COMMENT '请求数',\\n  `countCachedRequests` bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求数',\\n  `countAttackRequests` bigint(20) unsigned DEFAULT '0' COMMENT '攻击数',\\n  `attackBytes` bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `hour` (`hour`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='总的流量统计（按小时）'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"hour\",\"definition\":\"varchar(10) COMMENT 'YYYYMMDDHH'\"},{\"name\":\"bytes\",\"definition\":\"bigint(20) unsigned COMMENT '流量字节'\"},{\"name\":\"cachedBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存流量'\"},{\"name\":\"countRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '请求数'\"},{\"name\":\"countCachedRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '缓存请求数'\"},{\"name\":\"countAttackRequests\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击数'\"},{\"name\":\"attackBytes\",\"definition\":\"bigint(20) unsigned DEFAULT '0' COMMENT '攻击流量'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"hour\",\"definition\":\"UNIQUE KEY `hour` (`hour`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeUserAccessKeys\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeUserAccessKeys` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `subUserId` int(11) unsigned DEFAULT '0' COMMENT '子用户ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一的Key',\\n  `secret` varchar(64) DEFAULT NULL COMMENT '密钥',\\n  `description` varchar(255) DEFAULT NULL COMMENT '备注',\\n  `accessedAt` bigint(11) unsigned DEFAULT '0' COMMENT '最近一次访问时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  PRIMARY KEY (`id`),\\n  KEY `userId` (`userId`),\\n  KEY `subUserId` (`subUserId`),\\n  KEY `uniqueId` (`uniqueId`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='AccessKey'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"subUserId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '子用户ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一的Key'\"},{\"name\":\"secret\",\"definition\":\"varchar(64) COMMENT '密钥'\"},{\"name\":\"description\",\"definition\":\"varchar(255) COMMENT '备注'\"},{\"name\":\"accessedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '最近一次访问时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"userId\",\"definition\":\"KEY `userId` (`userId`) USING BTREE\"},{\"name\":\"subUserId\",\"definition\":\"KEY `subUserId` (`subUserId`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"KEY `uniqueId` (`uniqueId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeUserBills\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeUserBills` (\\n  `id` bigint(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `userId` int(11) unsigned DEFAULT '0' COMMENT '用户ID',\\n  `type` varchar(255) DEFAULT NULL COMMENT '消费类型',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `amount` decimal(11,2) unsigned DEFAULT '0.00' COMMENT '消费数额',\\n  `month` varchar(6) DEFAULT NULL COMMENT '帐期YYYYMM',\\n  `isPaid` tinyint(1) unsigned DEFAULT '0' COMMENT '是否已支付',\\n  `paidAt` bigint(11) unsigned DEFAULT '0' COMMENT '支付时间',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户账单'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"userId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '用户ID'\"},{\"name\":\"type\",\"definition\":\"varchar(255) COMMENT '消费类型'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"amount\",\"definition\":\"decimal(11,2) unsigned DEFAULT '0.00' COMMENT '消费数额'\"},{\"name\":\"month\",\"definition\":\"varchar(6) COMMENT '帐期YYYYMM'\"},{\"name\":\"isPaid\",\"definition\":\"tinyint(1) unsigned DEFAULT '0' COMMENT '是否已支付'\"},{\"name\":\"paidAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '支付时间'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeUserNodes\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeUserNodes` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `uniqueId` varchar(32) DEFAULT NULL COMMENT '唯一ID',\\n  `secret` varchar(32) DEFAULT NULL COMMENT '密钥',\\n  `name` varchar(255) DEFAULT NULL COMMENT '名称',\\n  `description` varchar(1024) DEFAULT NULL COMMENT '描述',\\n  `http` json DEFAULT NULL COMMENT '监听的HTTP配置',\\n  `https` json DEFAULT NULL COMMENT '监听的HTTPS配置',\\n  `accessAddrs` json DEFAULT NULL COMMENT '外部访问地址',\\n  `order` int(11) unsigned DEFAULT '0' COMMENT '排序',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `adminId` int(11) unsigned DEFAULT '0' COMMENT '管理员ID',\\n  `weight` int(11) unsigned DEFAULT '0' COMMENT '权重',\\n  `status` json DEFAULT NULL COMMENT '运行状态',\\n  PRIMARY KEY (`id`),\\n  UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='API节点'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"uniqueId\",\"definition\":\"varchar(32) COMMENT '唯一ID'\"},{\"name\":\"secret\",\"definition\":\"varchar(32) COMMENT '密钥'\"},{\"name\":\"name\",\"definition\":\"varchar(255) COMMENT '名称'\"},{\"name\":\"description\",\"definition\":\"varchar(1024) COMMENT '描述'\"},{\"name\":\"http\",\"definition\":\"json COMMENT '监听的HTTP配置'\"},{\"name\":\"https\",\"definition\":\"json COMMENT '监听的HTTPS配置'\"},{\"name\":\"accessAddrs\",\"definition\":\"json COMMENT '外部访问地址'\"},{\"name\":\"order\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '排序'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"adminId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '管理员ID'\"},{\"name\":\"weight\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '权重'\"},{\"name\":\"status\",\"definition\":\"json COMMENT '运行状态'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"uniqueId\",\"definition\":\"UNIQUE KEY `uniqueId` (`uniqueId`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeUsers\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeUsers` (\\n  `id` int(11) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `isOn` tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用',\\n  `username` varchar(64) DEFAULT NULL COMMENT '用户名',\\n  `password` varchar(32) DEFAULT NULL COMMENT '密码',\\n  `fullname` varchar(64) DEFAULT NULL COMMENT '真实姓名',\\n  `mobile` varchar(11) DEFAULT NULL COMMENT '手机号',\\n  `tel` varchar(255) DEFAULT NULL COMMENT '联系电话',\\n  `remark` varchar(1024) DEFAULT NULL COMMENT '备注',\\n  `email` varchar(255) DEFAULT NULL COMMENT '邮箱地址',\\n  `avatarFileId` bigint(11) unsigned DEFAULT '0' COMMENT '头像文件ID',\\n  `createdAt` bigint(11) unsigned DEFAULT '0' COMMENT '创建时间',\\n  `day` varchar(8) DEFAULT NULL COMMENT 'YYYYMMDD',\\n  `updatedAt` bigint(11) unsigned DEFAULT '0' COMMENT '修改时间',\\n  `state` tinyint(1) unsigned DEFAULT '1' COMMENT '状态',\\n  `source` varchar(255) DEFAULT NULL COMMENT '来源',\\n  `clusterId` int(11) unsigned DEFAULT '0' COMMENT '集群ID',\\n  `features` json DEFAULT NULL COMMENT '允许操作的特征',\\n  PRIMARY KEY (`id`),\\n  KEY `username` (`username`),\\n  KEY `day` (`day`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户'\",\"fields\":[{\"name\":\"id\",\"definition\":\"int(11) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"isOn\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '是否启用'\"},{\"name\":\"username\",\"definition\":\"varchar(64) COMMENT '用户名'\"},{\"name\":\"password\",\"definition\":\"varchar(32) COMMENT '密码'\"},{\"name\":\"fullname\",\"definition\":\"varchar(64) COMMENT '真实姓名'\"},{\"name\":\"mobile\",\"definition\":\"varchar(11) COMMENT '手机号'\"},{\"name\":\"tel\",\"definition\":\"varchar(255) COMMENT '联系电话'\"},{\"name\":\"remark\",\"definition\":\"varchar(1024) COMMENT '备注'\"},{\"name\":\"email\",\"definition\":\"varchar(255) COMMENT '邮箱地址'\"},{\"name\":\"avatarFileId\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '头像文件ID'\"},{\"name\":\"createdAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '创建时间'\"},{\"name\":\"day\",\"definition\":\"varchar(8) COMMENT 'YYYYMMDD'\"},{\"name\":\"updatedAt\",\"definition\":\"bigint(11) unsigned DEFAULT '0' COMMENT '修改时间'\"},{\"name\":\"state\",\"definition\":\"tinyint(1) unsigned DEFAULT '1' COMMENT '状态'\"},{\"name\":\"source\",\"definition\":\"varchar(255) COMMENT '来源'\"},{\"name\":\"clusterId\",\"definition\":\"int(11) unsigned DEFAULT '0' COMMENT '集群ID'\"},{\"name\":\"features\",\"definition\":\"json COMMENT '允许操作的特征'\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"},{\"name\":\"username\",\"definition\":\"KEY `username` (`username`) USING BTREE\"},{\"name\":\"day\",\"definition\":\"KEY `day` (`day`) USING BTREE\"}],\"records\":[]},{\"name\":\"edgeVersions\",\"engine\":\"InnoDB\",\"charset\":\"utf8mb4_general_ci\",\"definition\":\"CREATE TABLE `edgeVersions` (\\n  `id` bigint(16) unsigned NOT NULL AUTO_INCREMENT COMMENT 'ID',\\n  `version` varchar(64) DEFAULT NULL,\\n  PRIMARY KEY (`id`)\\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='数据库结构版本'\",\"fields\":[{\"name\":\"id\",\"definition\":\"bigint(16) unsigned auto_increment COMMENT 'ID'\"},{\"name\":\"version\",\"definition\":\"varchar(64)\"}],\"indexes\":[{\"name\":\"PRIMARY\",\"definition\":\"UNIQUE KEY `PRIMARY` (`id`) USING BTREE\"}],\"records\":[]}]}"), LatestSQLResult)
 	if err != nil {
 		logs.Println("[ERROR]load sql failed: " + err.Error())
 	}
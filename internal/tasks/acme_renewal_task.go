@@ -0,0 +1,175 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models/acme"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/maps"
+	"github.com/iwind/TeaGo/types"
+	timeutil "github.com/iwind/TeaGo/utils/time"
+	"sync"
+	"time"
+)
+
+const (
+	acmeRenewalBatchSize   = 100  // 每次最多处理的续期任务数
+	acmeRenewalTaskTimeout = 3600 // 单个任务的最长执行时间（秒），超过这个时间没有结果的任务会被重新续期
+	acmeRenewalLockTimeout = 600  // 锁的有效期（秒），执行期间会不断延长
+)
+
+func init() {
+	dbs.OnReady(func() {
+		go NewACMERenewalTask().Run()
+	})
+}
+
+// ACMERenewalTask 证书自动续期任务
+// 在证书剩余有效期不足时开始续期，失败后按照退避时间重试，和证书到期提醒互相独立
+type ACMERenewalTask struct {
+}
+
+// NewACMERenewalTask 获取新对象
+func NewACMERenewalTask() *ACMERenewalTask {
+	return &ACMERenewalTask{}
+}
+
+// Run 运行
+func (this *ACMERenewalTask) Run() {
+	ticker := utils.NewTicker(10 * time.Minute)
+	for ticker.Wait() {
+		err := this.Loop()
+		if err != nil {
+			logs.Println("[TASK][ACME_RENEWAL]" + err.Error())
+		}
+	}
+}
+
+// Loop 单次运行
+func (this *ACMERenewalTask) Loop() error {
+	var tx *dbs.Tx
+	config, err := models.SharedSysSettingDAO.ReadACMERenewalConfig(tx)
+	if err != nil {
+		return err
+	}
+	if !config.IsOn {
+		return nil
+	}
+
+	// 同一时间只有一个API节点执行续期
+	ok, err := models.SharedSysLockerDAO.Lock(tx, "acme_renewal_task", acmeRenewalLockTimeout)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	// 执行期间不断延长锁的有效期，防止锁过期后被其他API节点获得
+	var doneChan = make(chan bool)
+	go func() {
+		var ticker = time.NewTicker(acmeRenewalLockTimeout / 3 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-doneChan:
+				return
+			case <-ticker.C:
+				err := models.SharedSysLockerDAO.Renew(tx, "acme_renewal_task", acmeRenewalLockTimeout)
+				if err != nil {
+					logs.Println("[TASK][ACME_RENEWAL]renew locker failed: " + err.Error())
+				}
+			}
+		}
+	}()
+	defer func() {
+		close(doneChan)
+		_ = models.SharedSysLockerDAO.Unlock(tx, "acme_renewal_task")
+	}()
+
+	acmeTasks, err := acme.SharedACMETaskDAO.FindAllRenewableTasks(tx, config.RenewRatio, time.Now().Unix(), acmeRenewalBatchSize)
+	if err != nil {
+		return err
+	}
+	if len(acmeTasks) == 0 {
+		return nil
+	}
+
+	// 限制同时执行的任务数
+	var wg = sync.WaitGroup{}
+	var concurrentChan = make(chan bool, config.MaxConcurrent)
+	for _, acmeTask := range acmeTasks {
+		wg.Add(1)
+		concurrentChan <- true
+		go func(acmeTask *acme.ACMETask) {
+			defer func() {
+				<-concurrentChan
+				wg.Done()
+			}()
+
+			err := this.renew(tx, config, acmeTask)
+			if err != nil {
+				logs.Println("[TASK][ACME_RENEWAL]renew task '" + types.String(acmeTask.Id) + "' failed: " + err.Error())
+			}
+		}(acmeTask)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// 续期单个任务
+func (this *ACMERenewalTask) renew(tx *dbs.Tx, config *models.ACMERenewalConfig, acmeTask *acme.ACMETask) error {
+	var taskId = int64(acmeTask.Id)
+
+	// 占用任务，如果已经被其他API节点占用，则跳过
+	ok, err := acme.SharedACMETaskDAO.ClaimTaskRenewal(tx, taskId, int64(acmeTask.NextRenewAt), time.Now().Unix()+acmeRenewalTaskTimeout)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	cert, err := models.SharedSSLCertDAO.FindEnabledSSLCert(tx, int64(acmeTask.CertId))
+	if err != nil {
+		return err
+	}
+	if cert == nil {
+		return nil
+	}
+
+	// 执行任务，RunTask会将结果写入任务日志
+	isOk, errMsg, _ := acme.SharedACMETaskDAO.RunTask(tx, taskId)
+	if isOk {
+		subject := "系统已成功为你自动更新了证书\"" + cert.Name + "\""
+		msg := "系统已成功为你自动更新了证书\"" + cert.Name + "\"（" + cert.DnsNames + "）。"
+		return models.SharedMessageDAO.CreateMessage(tx, int64(cert.AdminId), int64(cert.UserId), models.MessageTypeSSLCertACMETaskSuccess, models.MessageLevelSuccess, subject, msg, maps.Map{
+			"certId":     cert.Id,
+			"acmeTaskId": acmeTask.Id,
+		}.AsJSON())
+	}
+
+	// 失败后等待一段时间再重试
+	var renewAttempts = int(acmeTask.RenewAttempts) + 1
+	var nextRenewAt = ACMERenewalNextRenewAt(config, renewAttempts, time.Now().Unix())
+	err = acme.SharedACMETaskDAO.UpdateTaskRenewalFailed(tx, taskId, renewAttempts, nextRenewAt)
+	if err != nil {
+		return err
+	}
+
+	subject := "系统在尝试自动更新证书\"" + cert.Name + "\"时发生错误"
+	msg := "系统在尝试自动更新证书\"" + cert.Name + "\"（" + cert.DnsNames + "）时发生错误：" + errMsg + "。系统将在" + timeutil.FormatTime("Y-m-d H:i:s", nextRenewAt) + "再次尝试，请检查系统设置并修复错误。"
+	return models.SharedMessageDAO.CreateMessage(tx, int64(cert.AdminId), int64(cert.UserId), models.MessageTypeSSLCertACMETaskFailed, models.MessageLevelError, subject, msg, maps.Map{
+		"certId":     cert.Id,
+		"acmeTaskId": acmeTask.Id,
+	}.AsJSON())
+}
+
+// ACMERenewalNextRenewAt 计算第N次续期失败后下次尝试的时间
+func ACMERenewalNextRenewAt(config *models.ACMERenewalConfig, renewAttempts int, now int64) int64 {
+	return now + config.BackoffSecondsWithAttempts(renewAttempts)
+}
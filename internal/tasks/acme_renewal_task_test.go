@@ -0,0 +1,48 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/iwind/TeaGo/dbs"
+	"testing"
+)
+
+func TestACMERenewalConfig_RenewAt(t *testing.T) {
+	var config = models.DefaultACMERenewalConfig()
+
+	// 90天的证书在剩余30天时开始续期
+	var renewAt = config.RenewAt(0, 90*86400)
+	if renewAt != 60*86400 {
+		t.Fatal("expected to renew at day 60, but got:", renewAt/86400)
+	}
+}
+
+func TestACMERenewalNextRenewAt(t *testing.T) {
+	var config = &models.ACMERenewalConfig{
+		BackoffSeconds:    3600,
+		MaxBackoffSeconds: 86400,
+	}
+	for attempts, expected := range map[int]int64{
+		1:  1000 + 3600,
+		2:  1000 + 7200,
+		5:  1000 + 57600,
+		6:  1000 + 86400,
+		10: 1000 + 86400,
+	} {
+		var nextRenewAt = ACMERenewalNextRenewAt(config, attempts, 1000)
+		if nextRenewAt != expected {
+			t.Fatal("attempts:", attempts, "expected:", expected, "but got:", nextRenewAt)
+		}
+	}
+}
+
+func TestACMERenewalTask_Loop(t *testing.T) {
+	dbs.NotifyReady()
+
+	err := NewACMERenewalTask().Loop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("ok")
+}
@@ -0,0 +1,425 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	dnsmodels "github.com/TeaOSLab/EdgeAPI/internal/db/models/dns"
+	"github.com/TeaOSLab/EdgeAPI/internal/remotelogs"
+	"github.com/TeaOSLab/EdgeCommon/pkg/nodeconfigs"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/maps"
+	"net"
+	"sort"
+	"time"
+)
+
+// 故障切换状态在系统设置中的代号
+const dnsFailoverStateSettingCode = "clusterDNSFailoverState%d"
+
+// 默认线路的占位符，用于统计没有设置线路的节点
+const dnsFailoverDefaultRoute = "$default"
+
+type DNSFailoverAction = string
+
+const (
+	DNSFailoverActionWithdraw DNSFailoverAction = "withdraw" // 摘除记录
+	DNSFailoverActionRestore  DNSFailoverAction = "restore"  // 恢复记录
+	DNSFailoverActionDamp     DNSFailoverAction = "damp"     // 因为频繁切换暂缓恢复记录
+	DNSFailoverActionKeep     DNSFailoverAction = "keep"     // 因为可用记录不足保留记录
+)
+
+// DNSFailoverState 集群的故障切换状态
+type DNSFailoverState struct {
+	Nodes map[int64]*DNSFailoverNodeState `json:"nodes"` // nodeId => state
+}
+
+// DNSFailoverNodeState 单个节点的故障切换状态
+type DNSFailoverNodeState struct {
+	IsWithdrawn bool    `json:"isWithdrawn"` // 记录是否已被摘除
+	IsDamped    bool    `json:"isDamped"`    // 是否正在暂缓恢复
+	IsKept      bool    `json:"isKept"`      // 是否因为可用记录不足而被保留
+	ChangedAt   int64   `json:"changedAt"`   // 最后切换时间
+	Flaps       []int64 `json:"flaps"`       // 时间窗口内的切换时间
+}
+
+// DNSFailoverNode 参与故障切换计算的节点
+type DNSFailoverNode struct {
+	Id           int64
+	Name         string
+	IsOffline    bool     // 节点是否已禁用或者下线，这样的节点不会出现在DNS记录中
+	IsOk         bool     // 本次健康检查是否成功
+	Routes       []string // 节点所在线路
+	CountRecords int      // 节点在每个线路上的A/AAAA记录数量
+}
+
+// DNSFailoverEvent 故障切换事件
+type DNSFailoverEvent struct {
+	Action DNSFailoverAction
+	Node   *DNSFailoverNode
+}
+
+// NewDNSFailoverState 获取新的状态对象
+func NewDNSFailoverState() *DNSFailoverState {
+	return &DNSFailoverState{
+		Nodes: map[int64]*DNSFailoverNodeState{},
+	}
+}
+
+// IsWithdrawn 判断节点记录是否已被摘除
+func (this *DNSFailoverState) IsWithdrawn(nodeId int64) bool {
+	state, ok := this.Nodes[nodeId]
+	return ok && state.IsWithdrawn
+}
+
+// WithdrawnNodeIds 所有已被摘除记录的节点
+func (this *DNSFailoverState) WithdrawnNodeIds() []int64 {
+	var result = []int64{}
+	for nodeId, state := range this.Nodes {
+		if state.IsWithdrawn {
+			result = append(result, nodeId)
+		}
+	}
+	return result
+}
+
+// Decide 根据本次健康检查结果计算需要摘除和恢复的节点，并更新状态
+// 先处理恢复，再处理摘除，以便在计算可用记录数量时包含刚恢复的节点
+func (this *DNSFailoverState) Decide(config *models.NodeClusterDNSFailoverConfig, nodes []*DNSFailoverNode, now int64) []*DNSFailoverEvent {
+	if this.Nodes == nil {
+		this.Nodes = map[int64]*DNSFailoverNodeState{}
+	}
+
+	var events = []*DNSFailoverEvent{}
+
+	// 按节点ID排序，保证结果稳定
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Id < nodes[j].Id
+	})
+
+	// 清除已经从集群中删除的节点，已下线的节点仍然保留状态，直到切换记录过期
+	var nodeIds = map[int64]bool{}
+	for _, node := range nodes {
+		nodeIds[node.Id] = true
+	}
+	for nodeId := range this.Nodes {
+		if !nodeIds[nodeId] {
+			delete(this.Nodes, nodeId)
+		}
+	}
+
+	// 清理时间窗口外的切换记录
+	var minTime = now - int64(config.FlapWindow)
+	for _, state := range this.Nodes {
+		var flaps = []int64{}
+		for _, flap := range state.Flaps {
+			if flap > minTime {
+				flaps = append(flaps, flap)
+			}
+		}
+		state.Flaps = flaps
+	}
+
+	// 已下线的节点不参与计算，也不再需要保留记录
+	for _, node := range nodes {
+		if !node.IsOffline {
+			continue
+		}
+		state, ok := this.Nodes[node.Id]
+		if ok {
+			state.IsKept = false
+		}
+	}
+
+	// 恢复
+	for _, node := range nodes {
+		if node.IsOffline || !node.IsOk {
+			continue
+		}
+		state, ok := this.Nodes[node.Id]
+		if !ok {
+			continue
+		}
+		state.IsKept = false
+		if !state.IsWithdrawn {
+			continue
+		}
+
+		if config.MaxFlaps > 0 && len(state.Flaps) >= config.MaxFlaps {
+			if !state.IsDamped {
+				state.IsDamped = true
+				events = append(events, &DNSFailoverEvent{Action: DNSFailoverActionDamp, Node: node})
+			}
+			continue
+		}
+
+		state.IsWithdrawn = false
+		state.IsDamped = false
+		state.ChangedAt = now
+		state.Flaps = append(state.Flaps, now)
+		events = append(events, &DNSFailoverEvent{Action: DNSFailoverActionRestore, Node: node})
+	}
+
+	// 计算每个线路上当前的可用记录数量
+	var countRouteRecords = map[string]int{} // route => count
+	for _, node := range nodes {
+		if node.IsOffline || this.IsWithdrawn(node.Id) {
+			continue
+		}
+		for _, route := range node.Routes {
+			countRouteRecords[route] += node.CountRecords
+		}
+	}
+
+	// 摘除
+	for _, node := range nodes {
+		if node.IsOffline || node.IsOk || node.CountRecords == 0 {
+			continue
+		}
+		state, ok := this.Nodes[node.Id]
+		if !ok {
+			state = &DNSFailoverNodeState{}
+			this.Nodes[node.Id] = state
+		}
+		if state.IsWithdrawn {
+			// 失败期间不再暂缓
+			state.IsDamped = false
+			continue
+		}
+
+		var canWithdraw = true
+		for _, route := range node.Routes {
+			if countRouteRecords[route]-node.CountRecords < config.MinHealthyRecords {
+				canWithdraw = false
+				break
+			}
+		}
+		if !canWithdraw {
+			if !state.IsKept {
+				state.IsKept = true
+				events = append(events, &DNSFailoverEvent{Action: DNSFailoverActionKeep, Node: node})
+			}
+			continue
+		}
+
+		for _, route := range node.Routes {
+			countRouteRecords[route] -= node.CountRecords
+		}
+		state.IsWithdrawn = true
+		state.IsKept = false
+		state.ChangedAt = now
+		state.Flaps = append(state.Flaps, now)
+		events = append(events, &DNSFailoverEvent{Action: DNSFailoverActionWithdraw, Node: node})
+	}
+
+	// 清除没有任何状态、并且切换记录已过期的节点
+	for nodeId, state := range this.Nodes {
+		if !state.IsWithdrawn && !state.IsKept && !state.IsDamped && len(state.Flaps) == 0 {
+			delete(this.Nodes, nodeId)
+		}
+	}
+
+	return events
+}
+
+// FindDNSFailoverState 读取集群的故障切换状态
+func FindDNSFailoverState(tx *dbs.Tx, clusterId int64) (*DNSFailoverState, error) {
+	var state = NewDNSFailoverState()
+	stateJSON, err := models.SharedSysSettingDAO.ReadSetting(tx, dnsFailoverStateSettingCode, clusterId)
+	if err != nil {
+		return nil, err
+	}
+	if len(stateJSON) == 0 {
+		return state, nil
+	}
+	err = json.Unmarshal(stateJSON, state)
+	if err != nil {
+		// 状态损坏时重新开始计算
+		return NewDNSFailoverState(), nil
+	}
+	if state.Nodes == nil {
+		state.Nodes = map[int64]*DNSFailoverNodeState{}
+	}
+	return state, nil
+}
+
+// DNSFailoverExecutor 根据健康检查结果摘除和恢复集群节点的DNS记录
+type DNSFailoverExecutor struct {
+	clusterId int64
+}
+
+// NewDNSFailoverExecutor 获取新对象
+func NewDNSFailoverExecutor(clusterId int64) *DNSFailoverExecutor {
+	return &DNSFailoverExecutor{clusterId: clusterId}
+}
+
+// Run 执行故障切换
+func (this *DNSFailoverExecutor) Run(results []*HealthCheckResult) error {
+	var tx *dbs.Tx
+
+	cluster, err := models.SharedNodeClusterDAO.FindClusterDNSInfo(tx, this.clusterId)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return nil
+	}
+
+	var config = cluster.DecodeDNSFailoverConfig()
+	if !config.IsOn {
+		// 关闭后清除以前的状态
+		state, err := FindDNSFailoverState(tx, this.clusterId)
+		if err != nil {
+			return err
+		}
+		if len(state.Nodes) > 0 {
+			return this.saveState(tx, NewDNSFailoverState())
+		}
+		return nil
+	}
+
+	var domainId = int64(cluster.DnsDomainId)
+	if domainId <= 0 || len(cluster.DnsName) == 0 {
+		return nil
+	}
+
+	// 参与计算的节点，包括已下线的节点，以便保留它们的切换记录
+	var resultMap = map[int64]*HealthCheckResult{} // nodeId => result
+	for _, result := range results {
+		if result.Node == nil {
+			continue
+		}
+		resultMap[int64(result.Node.Id)] = result
+	}
+	clusterNodes, err := models.SharedNodeDAO.FindAllEnabledNodesWithClusterId(tx, this.clusterId)
+	if err != nil {
+		return err
+	}
+	var nodes = []*DNSFailoverNode{}
+	for _, clusterNode := range clusterNodes {
+		node, err := this.convertNode(tx, domainId, clusterNode, resultMap[int64(clusterNode.Id)])
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+	}
+
+	state, err := FindDNSFailoverState(tx, this.clusterId)
+	if err != nil {
+		return err
+	}
+	var events = state.Decide(config, nodes, time.Now().Unix())
+	err = this.saveState(tx, state)
+	if err != nil {
+		return err
+	}
+
+	var isChanged = false
+	for _, event := range events {
+		if event.Action == DNSFailoverActionWithdraw || event.Action == DNSFailoverActionRestore {
+			isChanged = true
+		}
+		err = this.notify(tx, event)
+		if err != nil {
+			return err
+		}
+	}
+	if !isChanged {
+		return nil
+	}
+
+	// 立即同步集群记录，不等待DNS任务队列
+	var executor = NewDNSTaskExecutor()
+	plans, err := executor.PlanCluster(tx, this.clusterId)
+	if err != nil {
+		return err
+	}
+	return executor.applyPlans(tx, 0, dnsmodels.DNSTaskTypeClusterChange, plans)
+}
+
+// 转换健康检查结果
+func (this *DNSFailoverExecutor) convertNode(tx *dbs.Tx, domainId int64, node *models.Node, result *HealthCheckResult) (*DNSFailoverNode, error) {
+	var nodeId = int64(node.Id)
+	if node.IsOn != 1 || node.IsUp != 1 || result == nil {
+		// 已下线的节点不会出现在DNS记录中
+		return &DNSFailoverNode{
+			Id:        nodeId,
+			Name:      node.Name,
+			IsOffline: true,
+		}, nil
+	}
+
+	routes, err := node.DNSRouteCodesForDomainId(domainId)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		routes = []string{dnsFailoverDefaultRoute}
+	}
+
+	ipAddresses, err := models.SharedNodeIPAddressDAO.FindAllEnabledAddressesWithNode(tx, nodeId, nodeconfigs.NodeRoleNode)
+	if err != nil {
+		return nil, err
+	}
+	var countRecords = 0
+	for _, ipAddress := range ipAddresses {
+		if ipAddress.CanAccess == 0 || net.ParseIP(ipAddress.Ip) == nil {
+			continue
+		}
+		countRecords++
+	}
+
+	return &DNSFailoverNode{
+		Id:           nodeId,
+		Name:         node.Name,
+		IsOk:         result.IsOk,
+		Routes:       routes,
+		CountRecords: countRecords,
+	}, nil
+}
+
+// 保存状态
+func (this *DNSFailoverExecutor) saveState(tx *dbs.Tx, state *DNSFailoverState) error {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return models.SharedSysSettingDAO.UpdateSetting(tx, dnsFailoverStateSettingCode, stateJSON, this.clusterId)
+}
+
+// 发送故障切换消息
+func (this *DNSFailoverExecutor) notify(tx *dbs.Tx, event *DNSFailoverEvent) error {
+	var message string
+	var level string
+	switch event.Action {
+	case DNSFailoverActionWithdraw:
+		message = "健康检查失败，已摘除节点\"" + event.Node.Name + "\"的DNS记录"
+		level = models.MessageLevelError
+	case DNSFailoverActionRestore:
+		message = "健康检查成功，已恢复节点\"" + event.Node.Name + "\"的DNS记录"
+		level = models.MessageLevelSuccess
+	case DNSFailoverActionDamp:
+		message = "节点\"" + event.Node.Name + "\"切换过于频繁，暂缓恢复DNS记录"
+		level = models.MessageLevelWarning
+	case DNSFailoverActionKeep:
+		message = "健康检查失败，但可用DNS记录数量不足，暂不摘除节点\"" + event.Node.Name + "\"的DNS记录"
+		level = models.MessageLevelWarning
+	default:
+		return nil
+	}
+
+	paramsJSON, err := json.Marshal(maps.Map{
+		"action": event.Action,
+		"routes": event.Node.Routes,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = models.NewMessageDAO().CreateNodeMessage(tx, nodeconfigs.NodeRoleNode, this.clusterId, event.Node.Id, models.MessageTypeClusterDNSFailover, level, message, message, paramsJSON)
+	if err != nil {
+		remotelogs.Error("DNS_FAILOVER", "create message failed: "+err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/iwind/TeaGo/dbs"
+	"testing"
+)
+
+func TestDNSFailoverState_Decide(t *testing.T) {
+	var config = &models.NodeClusterDNSFailoverConfig{
+		IsOn:              true,
+		MinHealthyRecords: 1,
+		FlapWindow:        600,
+		MaxFlaps:          3,
+	}
+	var newNodes = func(ok1 bool, ok2 bool) []*DNSFailoverNode {
+		return []*DNSFailoverNode{
+			{Id: 1, Name: "node1", IsOk: ok1, Routes: []string{"default"}, CountRecords: 1},
+			{Id: 2, Name: "node2", IsOk: ok2, Routes: []string{"default"}, CountRecords: 1},
+		}
+	}
+
+	var state = NewDNSFailoverState()
+
+	// 一个节点失败
+	events := state.Decide(config, newNodes(false, true), 100)
+	if len(events) != 1 || events[0].Action != DNSFailoverActionWithdraw || events[0].Node.Id != 1 {
+		t.Fatal("node1 should be withdrawn")
+	}
+	if !state.IsWithdrawn(1) {
+		t.Fatal("node1 should be in withdrawn state")
+	}
+
+	// 两个节点都失败，需要保留最少可用记录
+	events = state.Decide(config, newNodes(false, false), 110)
+	if len(events) != 1 || events[0].Action != DNSFailoverActionKeep || events[0].Node.Id != 2 {
+		t.Fatal("node2 should be kept")
+	}
+
+	// 再次失败时不重复通知
+	events = state.Decide(config, newNodes(false, false), 120)
+	if len(events) != 0 {
+		t.Fatal("should not notify again")
+	}
+
+	// 恢复
+	events = state.Decide(config, newNodes(true, true), 130)
+	if len(events) != 1 || events[0].Action != DNSFailoverActionRestore || events[0].Node.Id != 1 {
+		t.Fatal("node1 should be restored")
+	}
+	if state.IsWithdrawn(1) {
+		t.Fatal("node1 should not be in withdrawn state")
+	}
+}
+
+func TestDNSFailoverState_Decide_Flap(t *testing.T) {
+	var config = &models.NodeClusterDNSFailoverConfig{
+		IsOn:              true,
+		MinHealthyRecords: 1,
+		FlapWindow:        600,
+		MaxFlaps:          3,
+	}
+	var newNodes = func(ok bool) []*DNSFailoverNode {
+		return []*DNSFailoverNode{
+			{Id: 1, Name: "node1", IsOk: ok, Routes: []string{"default"}, CountRecords: 2},
+			{Id: 2, Name: "node2", IsOk: true, Routes: []string{"default"}, CountRecords: 1},
+		}
+	}
+
+	var state = NewDNSFailoverState()
+	state.Decide(config, newNodes(false), 100) // withdraw
+	state.Decide(config, newNodes(true), 110)  // restore
+	state.Decide(config, newNodes(false), 120) // withdraw
+
+	// 切换次数已达到上限，暂缓恢复
+	events := state.Decide(config, newNodes(true), 130)
+	if len(events) != 1 || events[0].Action != DNSFailoverActionDamp {
+		t.Fatal("node1 should be damped")
+	}
+	if !state.IsWithdrawn(1) {
+		t.Fatal("node1 should still be withdrawn")
+	}
+
+	// 时间窗口过后恢复
+	events = state.Decide(config, newNodes(true), 800)
+	if len(events) != 1 || events[0].Action != DNSFailoverActionRestore {
+		t.Fatal("node1 should be restored after flap window")
+	}
+}
+
+func TestDNSFailoverState_Decide_Offline(t *testing.T) {
+	var config = &models.NodeClusterDNSFailoverConfig{
+		IsOn:              true,
+		MinHealthyRecords: 1,
+		FlapWindow:        600,
+		MaxFlaps:          3,
+	}
+	var newNodes = func(ok bool, isOffline bool) []*DNSFailoverNode {
+		return []*DNSFailoverNode{
+			{Id: 1, Name: "node1", IsOk: ok, IsOffline: isOffline, Routes: []string{"default"}, CountRecords: 1},
+			{Id: 2, Name: "node2", IsOk: true, Routes: []string{"default"}, CountRecords: 1},
+		}
+	}
+
+	var state = NewDNSFailoverState()
+
+	// down
+	events := state.Decide(config, newNodes(false, false), 100)
+	if len(events) != 1 || events[0].Action != DNSFailoverActionWithdraw {
+		t.Fatal("node1 should be withdrawn")
+	}
+
+	// 节点下线后仍然保留状态
+	events = state.Decide(config, newNodes(false, true), 110)
+	if len(events) != 0 || !state.IsWithdrawn(1) || len(state.Nodes[1].Flaps) != 1 {
+		t.Fatal("offline node should keep its state")
+	}
+
+	// up
+	events = state.Decide(config, newNodes(true, false), 120)
+	if len(events) != 1 || events[0].Action != DNSFailoverActionRestore {
+		t.Fatal("node1 should be restored")
+	}
+
+	// down
+	state.Decide(config, newNodes(false, false), 130)
+	state.Decide(config, newNodes(false, true), 140)
+
+	// up，切换次数已达到上限
+	events = state.Decide(config, newNodes(true, false), 150)
+	if len(events) != 1 || events[0].Action != DNSFailoverActionDamp {
+		t.Fatal("node1 should be damped")
+	}
+
+	// 从集群中删除后清除状态
+	state.Decide(config, newNodes(true, false)[1:], 160)
+	if _, ok := state.Nodes[1]; ok {
+		t.Fatal("state of removed node should be deleted")
+	}
+}
+
+func TestDNSFailoverState_Decide_Routes(t *testing.T) {
+	var config = &models.NodeClusterDNSFailoverConfig{
+		IsOn:              true,
+		MinHealthyRecords: 1,
+		FlapWindow:        600,
+		MaxFlaps:          3,
+	}
+	var state = NewDNSFailoverState()
+
+	// node1是telecom线路上唯一的节点
+	events := state.Decide(config, []*DNSFailoverNode{
+		{Id: 1, Name: "node1", IsOk: false, Routes: []string{"telecom"}, CountRecords: 1},
+		{Id: 2, Name: "node2", IsOk: false, Routes: []string{"unicom"}, CountRecords: 1},
+		{Id: 3, Name: "node3", IsOk: true, Routes: []string{"unicom"}, CountRecords: 1},
+	}, 100)
+	if len(events) != 2 {
+		t.Fatal("expect 2 events, but got", len(events))
+	}
+	if events[0].Action != DNSFailoverActionKeep || events[0].Node.Id != 1 {
+		t.Fatal("node1 should be kept")
+	}
+	if events[1].Action != DNSFailoverActionWithdraw || events[1].Node.Id != 2 {
+		t.Fatal("node2 should be withdrawn")
+	}
+}
+
+func TestDNSFailoverExecutor_Run(t *testing.T) {
+	dbs.NotifyReady()
+
+	results, err := NewHealthCheckExecutor(1).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewDNSFailoverExecutor(1).Run(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("ok")
+}
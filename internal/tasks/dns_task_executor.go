@@ -6,11 +6,13 @@ import (
 	dnsmodels "github.com/TeaOSLab/EdgeAPI/internal/db/models/dns"
 	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients"
 	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
 	"github.com/TeaOSLab/EdgeAPI/internal/remotelogs"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/nodeconfigs"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/lists"
+	"github.com/iwind/TeaGo/types"
 	"net"
 	"strings"
 	"time"
@@ -111,104 +113,16 @@ func (this *DNSTaskExecutor) doServer(taskId int64, serverId int64) error {
 		}
 	}()
 
-	// 检查是否已通过审核
-	serverDNS, err := models.SharedServerDAO.FindStatelessServerDNS(tx, serverId)
+	plans, err := this.PlanServer(tx, serverId)
 	if err != nil {
 		return err
 	}
-	if serverDNS == nil {
-		isOk = true
-		return nil
-	}
-	if len(serverDNS.DnsName) == 0 {
-		isOk = true
-		return nil
-	}
-
-	manager, domainId, domain, clusterDNSName, err := this.findDNSManager(tx, int64(serverDNS.ClusterId))
+	err = this.applyPlans(tx, taskId, dnsmodels.DNSTaskTypeServerChange, plans)
 	if err != nil {
 		return err
 	}
-	if manager == nil {
-		isOk = true
-		return nil
-	}
-
-	recordName := serverDNS.DnsName
-	recordValue := clusterDNSName + "." + domain + "."
-	recordRoute := manager.DefaultRoute()
-	recordType := dnstypes.RecordTypeCNAME
-	if serverDNS.State == models.ServerStateDisabled || serverDNS.IsOn == 0 {
-		// 检查记录是否已经存在
-		record, err := manager.QueryRecord(domain, recordName, recordType)
-		if err != nil {
-			return err
-		}
-		if record != nil {
-			// 删除
-			err = manager.DeleteRecord(domain, record)
-			if err != nil {
-				return err
-			}
-			err = dnsmodels.SharedDNSTaskDAO.CreateDomainTask(tx, domainId, dnsmodels.DNSTaskTypeDomainChange)
-			if err != nil {
-				return err
-			}
-		}
-
-		isOk = true
-	} else {
-		// 是否已存在
-		exist, err := dnsmodels.SharedDNSDomainDAO.ExistDomainRecord(tx, domainId, recordName, recordType, recordRoute, recordValue)
-		if err != nil {
-			return err
-		}
-		if exist {
-			isOk = true
-			return nil
-		}
-
-		// 检查记录是否已经存在
-		record, err := manager.QueryRecord(domain, recordName, recordType)
-		if err != nil {
-			return err
-		}
-		if record != nil {
-			if record.Value == recordValue || record.Value == strings.TrimRight(recordValue, ".") {
-				isOk = true
-				return nil
-			}
-
-			// 删除
-			err = manager.DeleteRecord(domain, record)
-			if err != nil {
-				return err
-			}
-			err = dnsmodels.SharedDNSTaskDAO.CreateDomainTask(tx, domainId, dnsmodels.DNSTaskTypeDomainChange)
-			if err != nil {
-				return err
-			}
-		}
-
-		err = manager.AddRecord(domain, &dnstypes.Record{
-			Id:    "",
-			Name:  recordName,
-			Type:  recordType,
-			Value: recordValue,
-			Route: recordRoute,
-		})
-		if err != nil {
-			return err
-		}
-
-		err = dnsmodels.SharedDNSTaskDAO.CreateDomainTask(tx, domainId, dnsmodels.DNSTaskTypeDomainChange)
-		if err != nil {
-			return err
-		}
-
-		isOk = true
-	}
 
+	isOk = true
 	return nil
 }
 
@@ -264,219 +178,617 @@ func (this *DNSTaskExecutor) doCluster(taskId int64, clusterId int64) error {
 	}()
 
 	var tx *dbs.Tx
-	manager, domainId, domain, clusterDNSName, err := this.findDNSManager(tx, clusterId)
+	plans, err := this.PlanCluster(tx, clusterId)
 	if err != nil {
 		return err
 	}
-	if manager == nil {
+	err = this.applyPlans(tx, taskId, dnsmodels.DNSTaskTypeClusterChange, plans)
+	if err != nil {
+		return err
+	}
+
+	isOk = true
+
+	return nil
+}
+
+func (this *DNSTaskExecutor) doDomain(taskId int64, domainId int64) error {
+	var tx *dbs.Tx
+
+	isOk := false
+	defer func() {
+		if isOk {
+			err := dnsmodels.SharedDNSTaskDAO.UpdateDNSTaskDone(tx, taskId)
+			if err != nil {
+				remotelogs.Error("DNSTaskExecutor", err.Error())
+			}
+		}
+	}()
+
+	plan, err := this.newDomainPlan(tx, domainId)
+	if err != nil {
+		return err
+	}
+	if plan == nil {
 		isOk = true
 		return nil
 	}
-
-	// 以前的节点记录
-	records, err := manager.GetRecords(domain)
+	if plan.HasError() {
+		return errors.New(plan.Error)
+	}
+	records, err := plan.manager.GetRecords(plan.Domain)
 	if err != nil {
 		return err
 	}
-	oldRecordsMap := map[string]*dnstypes.Record{} // route@value => record
-	for _, record := range records {
-		if (record.Type == dnstypes.RecordTypeA || record.Type == dnstypes.RecordTypeAAAA) && record.Name == clusterDNSName {
-			key := record.Route + "@" + record.Value
-			oldRecordsMap[key] = record
+	recordsJSON, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	err = dnsmodels.SharedDNSDomainDAO.UpdateDomainRecords(tx, domainId, recordsJSON)
+	if err != nil {
+		return err
+	}
+	isOk = true
+	return nil
+}
+
+// PlanServer 计算服务相关记录的变更，但不执行
+// 集群绑定的每个域名各有一个计划，主域名排在最前；如果集群没有设置DNS，则返回空
+func (this *DNSTaskExecutor) PlanServer(tx *dbs.Tx, serverId int64) ([]*DNSPlan, error) {
+	serverDNS, err := models.SharedServerDAO.FindStatelessServerDNS(tx, serverId)
+	if err != nil {
+		return nil, err
+	}
+	if serverDNS == nil || len(serverDNS.DnsName) == 0 {
+		return nil, nil
+	}
+
+	var clusterId = int64(serverDNS.ClusterId)
+	plans, clusterDNSName, ttl, err := this.newClusterPlans(tx, clusterId)
+	if err != nil {
+		return nil, err
+	}
+
+	var isOn = serverDNS.State != models.ServerStateDisabled && serverDNS.IsOn == 1
+	for _, plan := range plans {
+		if plan.HasError() {
+			continue
+		}
+		err = this.planServer(tx, plan, clusterId, clusterDNSName, ttl, serverDNS, isOn)
+		if err != nil {
+			plan.Error = err.Error()
 		}
 	}
+	return plans, nil
+}
 
-	// 当前的节点记录
-	newRecordKeys := []string{}
-	nodes, err := models.SharedNodeDAO.FindAllEnabledNodesDNSWithClusterId(tx, clusterId, true)
+// PlanCluster 计算集群相关记录的变更，但不执行
+// 集群绑定的每个域名各有一个计划，主域名排在最前；如果集群没有设置DNS，则返回空
+func (this *DNSTaskExecutor) PlanCluster(tx *dbs.Tx, clusterId int64) ([]*DNSPlan, error) {
+	plans, clusterDNSName, ttl, err := this.newClusterPlans(tx, clusterId)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	isChanged := false
-	for _, node := range nodes {
-		routes, err := node.DNSRouteCodesForDomainId(domainId)
+
+	for _, plan := range plans {
+		if plan.HasError() {
+			continue
+		}
+		records, err := plan.manager.GetRecords(plan.Domain)
 		if err != nil {
-			return err
+			plan.Error = err.Error()
+			continue
 		}
-		if len(routes) == 0 {
-			routes = []string{manager.DefaultRoute()}
+		err = this.planCluster(tx, plan, clusterId, clusterDNSName, ttl, records)
+		if err != nil {
+			plan.Error = err.Error()
 		}
+	}
+	return plans, nil
+}
 
-		// 所有的IP记录
-		ipAddresses, err := models.SharedNodeIPAddressDAO.FindAllEnabledAddressesWithNode(tx, int64(node.Id), nodeconfigs.NodeRoleNode)
+// PlanDomain 计算域名下所有集群和服务记录的变更，但不执行
+// 如果域名没有设置DNS服务商，则返回nil
+func (this *DNSTaskExecutor) PlanDomain(tx *dbs.Tx, domainId int64) (*DNSPlan, error) {
+	plan, err := this.newDomainPlan(tx, domainId)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, nil
+	}
+	if plan.HasError() {
+		return nil, errors.New(plan.Error)
+	}
+
+	// 以此域名作为主域名或额外域名的集群
+	var clusterIds = []int64{}
+	clusters, err := models.SharedNodeClusterDAO.FindAllEnabledClustersWithDNSDomainId(tx, domainId)
+	if err != nil {
+		return nil, err
+	}
+	for _, cluster := range clusters {
+		clusterIds = append(clusterIds, int64(cluster.Id))
+	}
+	secondaryClusterIds, err := dnsmodels.SharedDNSClusterBindingDAO.FindAllEnabledSecondaryClusterIds(tx, domainId)
+	if err != nil {
+		return nil, err
+	}
+	for _, clusterId := range secondaryClusterIds {
+		if !lists.ContainsInt64(clusterIds, clusterId) {
+			clusterIds = append(clusterIds, clusterId)
+		}
+	}
+	if len(clusterIds) == 0 {
+		return plan, nil
+	}
+
+	// 所有集群共用一份记录列表
+	records, err := plan.manager.GetRecords(plan.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, clusterId := range clusterIds {
+		cluster, err := models.SharedNodeClusterDAO.FindClusterDNSInfo(tx, clusterId)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		if len(ipAddresses) == 0 {
+		if cluster == nil || cluster.State != models.NodeClusterStateEnabled || len(cluster.DnsName) == 0 {
 			continue
 		}
-		for _, ipAddress := range ipAddresses {
-			ip := ipAddress.Ip
-			if len(ip) == 0 || ipAddress.CanAccess == 0 {
+		var ttl = cluster.DecodeDNSTTL()
+
+		err = this.planCluster(tx, plan, clusterId, cluster.DnsName, ttl, records)
+		if err != nil {
+			return nil, err
+		}
+
+		servers, err := models.SharedServerDAO.FindAllServersDNSWithClusterId(tx, clusterId)
+		if err != nil {
+			return nil, err
+		}
+		for _, server := range servers {
+			if len(server.DnsName) == 0 {
 				continue
 			}
-			if net.ParseIP(ip) == nil {
-				continue
+			err = this.planServer(tx, plan, clusterId, cluster.DnsName, ttl, server, true)
+			if err != nil {
+				return nil, err
 			}
-			for _, route := range routes {
-				key := route + "@" + ip
-				_, ok := oldRecordsMap[key]
-				if ok {
-					newRecordKeys = append(newRecordKeys, key)
-					continue
-				}
+		}
+	}
 
-				recordType := dnstypes.RecordTypeA
-				if utils.IsIPv6(ip) {
-					recordType = dnstypes.RecordTypeAAAA
-				}
-				err = manager.AddRecord(domain, &dnstypes.Record{
-					Id:    "",
-					Name:  clusterDNSName,
-					Type:  recordType,
-					Value: ip,
-					Route: route,
-				})
-				if err != nil {
-					return err
-				}
-				isChanged = true
-				newRecordKeys = append(newRecordKeys, key)
+	return plan, nil
+}
+
+// PlanClusterDomainRemoval 计算从某个域名中删除集群所有记录的变更，但不执行
+// 包括集群节点的A/AAAA记录和指向集群的服务CNAME记录；如果域名没有设置DNS服务商，则返回nil
+func (this *DNSTaskExecutor) PlanClusterDomainRemoval(tx *dbs.Tx, clusterId int64, domainId int64) (*DNSPlan, error) {
+	cluster, err := models.SharedNodeClusterDAO.FindClusterDNSInfo(tx, clusterId)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil || len(cluster.DnsName) == 0 {
+		return nil, nil
+	}
+
+	plan, err := this.newDomainPlan(tx, domainId)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, nil
+	}
+	if plan.HasError() {
+		return nil, errors.New(plan.Error)
+	}
+	plan.ClusterId = clusterId
+
+	records, err := plan.manager.GetRecords(plan.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// 服务记录
+	var serverIdsMap = map[string]int64{} // dnsName => serverId
+	servers, err := models.SharedServerDAO.FindAllServersDNSWithClusterId(tx, clusterId)
+	if err != nil {
+		return nil, err
+	}
+	for _, server := range servers {
+		if len(server.DnsName) > 0 {
+			serverIdsMap[server.DnsName] = int64(server.Id)
+		}
+	}
+
+	var clusterValue = cluster.DnsName + "." + plan.Domain
+	for _, record := range records {
+		switch record.Type {
+		case dnstypes.RecordTypeA, dnstypes.RecordTypeAAAA:
+			if record.Name == cluster.DnsName {
+				plan.Delete(clusterId, 0, record)
+			}
+		case dnstypes.RecordTypeCNAME:
+			serverId, ok := serverIdsMap[record.Name]
+			if ok && strings.TrimRight(record.Value, ".") == clusterValue {
+				plan.Delete(clusterId, serverId, record)
 			}
 		}
 	}
 
-	// 删除多余的节点解析记录
-	for key, record := range oldRecordsMap {
-		if !lists.ContainsString(newRecordKeys, key) {
-			isChanged = true
-			err = manager.DeleteRecord(domain, record)
+	return plan, nil
+}
+
+// RemoveClusterDomain 从某个域名中删除集群所有记录，用于停用或删除集群的额外域名
+func (this *DNSTaskExecutor) RemoveClusterDomain(tx *dbs.Tx, clusterId int64, domainId int64) error {
+	plan, err := this.PlanClusterDomainRemoval(tx, clusterId, domainId)
+	if err != nil {
+		return err
+	}
+	return this.ApplyPlan(tx, 0, dnsmodels.DNSTaskTypeClusterChange, plan)
+}
+
+// ApplyPlan 执行计划，并将每条记录的变更写入DNS同步日志
+func (this *DNSTaskExecutor) ApplyPlan(tx *dbs.Tx, taskId int64, taskType dnsmodels.DNSTaskType, plan *DNSPlan) error {
+	if plan == nil {
+		return nil
+	}
+	if plan.HasError() {
+		return errors.New(plan.Error)
+	}
+	if plan.IsEmpty() {
+		return nil
+	}
+	if plan.manager == nil {
+		return errors.New("invalid plan: no dns provider")
+	}
+
+	var isChanged = false
+	defer func() {
+		// 通知更新域名
+		if isChanged {
+			err := dnsmodels.SharedDNSTaskDAO.CreateDomainTask(tx, plan.DomainId, dnsmodels.DNSTaskTypeDomainChange)
 			if err != nil {
-				return err
+				remotelogs.Error("DNSTaskExecutor", err.Error())
 			}
 		}
-	}
+	}()
+
+	for _, change := range plan.Changes {
+		var err error
+		switch change.Action {
+		case DNSChangeActionAdd:
+			err = plan.manager.AddRecord(plan.Domain, change.Record)
+		case DNSChangeActionUpdate:
+			err = plan.manager.UpdateRecord(plan.Domain, change.OldRecord, change.Record)
+		case DNSChangeActionDelete:
+			err = plan.manager.DeleteRecord(plan.Domain, change.Record)
+		default:
+			continue
+		}
+
+		var errString = ""
+		if err != nil {
+			errString = err.Error()
+		} else {
+			isChanged = true
+		}
+		logErr := dnsmodels.SharedDNSTaskLogDAO.CreateLog(tx, taskId, taskType, change.ClusterId, change.ServerId, plan.DomainId, change.Action, change.Record, change.OldRecord, errString)
+		if logErr != nil {
+			remotelogs.Error("DNSTaskExecutor", "create task log failed: "+logErr.Error())
+		}
 
-	// 通知更新域名
-	if isChanged {
-		err = dnsmodels.SharedDNSTaskDAO.CreateDomainTask(tx, domainId, dnsmodels.DNSTaskTypeDomainChange)
 		if err != nil {
 			return err
 		}
 	}
 
-	isOk = true
-
 	return nil
 }
 
-func (this *DNSTaskExecutor) doDomain(taskId int64, domainId int64) error {
-	var tx *dbs.Tx
+// 依次执行多个计划，某个DNS服务商出错时不影响其他服务商
+// 每个计划执行后都会更新对应绑定的同步状态，有失败时返回包含每个失败服务商的错误
+func (this *DNSTaskExecutor) applyPlans(tx *dbs.Tx, taskId int64, taskType dnsmodels.DNSTaskType, plans []*DNSPlan) error {
+	var failures = []string{}
+	for _, plan := range plans {
+		var errString = ""
+		err := this.ApplyPlan(tx, taskId, taskType, plan)
+		if err != nil {
+			errString = err.Error()
+			failures = append(failures, plan.Summary()+": "+errString)
+		}
 
-	isOk := false
-	defer func() {
-		if isOk {
-			err := dnsmodels.SharedDNSTaskDAO.UpdateDNSTaskDone(tx, taskId)
+		if plan.ClusterId > 0 {
+			err = dnsmodels.SharedDNSClusterBindingDAO.UpdateBindingStatus(tx, plan.ClusterId, plan.DomainId, plan.IsPrimary, errString)
 			if err != nil {
-				remotelogs.Error("DNSTaskExecutor", err.Error())
+				remotelogs.Error("DNSTaskExecutor", "update binding status failed: "+err.Error())
 			}
 		}
-	}()
-
-	dnsDomain, err := dnsmodels.SharedDNSDomainDAO.FindEnabledDNSDomain(tx, domainId)
-	if err != nil {
-		return err
 	}
-	if dnsDomain == nil {
-		isOk = true
+
+	if len(failures) == 0 {
 		return nil
 	}
-	providerId := int64(dnsDomain.ProviderId)
-	if providerId <= 0 {
-		isOk = true
+	if len(failures) < len(plans) {
+		return errors.New("partial failure (" + types.String(len(failures)) + "/" + types.String(len(plans)) + " providers): " + strings.Join(failures, "; "))
+	}
+	return errors.New(strings.Join(failures, "; "))
+}
+
+// 计算服务记录的变更
+func (this *DNSTaskExecutor) planServer(tx *dbs.Tx, plan *DNSPlan, clusterId int64, clusterDNSName string, ttl int32, serverDNS *models.Server, isOn bool) error {
+	var serverId = int64(serverDNS.Id)
+	var recordName = serverDNS.DnsName
+	var recordValue = clusterDNSName + "." + plan.Domain + "."
+	var recordRoute = plan.manager.DefaultRoute()
+	var recordType = dnstypes.RecordTypeCNAME
+
+	if !isOn {
+		// 检查记录是否已经存在
+		record, err := plan.manager.QueryRecord(plan.Domain, recordName, recordType)
+		if err != nil {
+			return err
+		}
+		if record != nil {
+			plan.Delete(clusterId, serverId, record)
+		}
 		return nil
 	}
 
-	provider, err := dnsmodels.SharedDNSProviderDAO.FindEnabledDNSProvider(tx, providerId)
+	// 是否已存在
+	exist, err := dnsmodels.SharedDNSDomainDAO.ExistDomainRecord(tx, plan.DomainId, recordName, recordType, recordRoute, recordValue)
 	if err != nil {
 		return err
 	}
-	if provider == nil {
-		isOk = true
+	if exist {
 		return nil
 	}
 
-	manager := dnsclients.FindProvider(provider.Type)
-	if manager == nil {
-		remotelogs.Error("DNSTaskExecutor", "unsupported dns provider type '"+provider.Type+"'")
-		isOk = true
-		return nil
+	var newRecord = &dnstypes.Record{
+		Id:    "",
+		Name:  recordName,
+		Type:  recordType,
+		Value: recordValue,
+		Route: recordRoute,
+		TTL:   ttl,
 	}
-	params, err := provider.DecodeAPIParams()
+
+	// 检查记录是否已经存在
+	record, err := plan.manager.QueryRecord(plan.Domain, recordName, recordType)
 	if err != nil {
 		return err
 	}
-	err = manager.Auth(params)
-	if err != nil {
-		return err
+	if record != nil {
+		if record.Value == recordValue || record.Value == strings.TrimRight(recordValue, ".") {
+			return nil
+		}
+		plan.Update(clusterId, serverId, record, newRecord)
+		return nil
 	}
-	records, err := manager.GetRecords(dnsDomain.Name)
+
+	plan.Add(clusterId, serverId, newRecord)
+	return nil
+}
+
+// 计算集群节点记录的变更
+func (this *DNSTaskExecutor) planCluster(tx *dbs.Tx, plan *DNSPlan, clusterId int64, clusterDNSName string, ttl int32, records []*dnstypes.Record) error {
+	cluster, err := models.SharedNodeClusterDAO.FindClusterDNSInfo(tx, clusterId)
 	if err != nil {
 		return err
 	}
-	recordsJSON, err := json.Marshal(records)
+	if cluster == nil {
+		return nil
+	}
+	var weightConfig = cluster.DecodeDNSWeightConfig()
+
+	// 故障切换中被摘除记录的节点
+	withdrawnNodeIds, err := this.findWithdrawnNodeIds(tx, cluster)
 	if err != nil {
 		return err
 	}
-	err = dnsmodels.SharedDNSDomainDAO.UpdateDomainRecords(tx, domainId, recordsJSON)
+
+	// 当前的节点记录
+	var newRecords = []*dnstypes.Record{}
+	nodes, err := models.SharedNodeDAO.FindAllEnabledNodesDNSWithClusterId(tx, clusterId, true)
 	if err != nil {
 		return err
 	}
-	isOk = true
+	for _, node := range nodes {
+		var nodeId = int64(node.Id)
+		if lists.ContainsInt64(withdrawnNodeIds, nodeId) {
+			continue
+		}
+		routes, err := node.DNSRouteCodesForDomainId(plan.DomainId)
+		if err != nil {
+			return err
+		}
+		if len(routes) == 0 {
+			routes = []string{plan.manager.DefaultRoute()}
+		}
+
+		// 所有的IP记录
+		ipAddresses, err := models.SharedNodeIPAddressDAO.FindAllEnabledAddressesWithNode(tx, nodeId, nodeconfigs.NodeRoleNode)
+		if err != nil {
+			return err
+		}
+		for _, ipAddress := range ipAddresses {
+			ip := ipAddress.Ip
+			if len(ip) == 0 || ipAddress.CanAccess == 0 {
+				continue
+			}
+			if net.ParseIP(ip) == nil {
+				continue
+			}
+			recordType := dnstypes.RecordTypeA
+			if utils.IsIPv6(ip) {
+				recordType = dnstypes.RecordTypeAAAA
+			}
+			for _, route := range routes {
+				newRecords = append(newRecords, &dnstypes.Record{
+					Id:     "",
+					Name:   clusterDNSName,
+					Type:   recordType,
+					Value:  ip,
+					Route:  route,
+					TTL:    ttl,
+					Weight: weightConfig.Weight(nodeId, route),
+				})
+			}
+		}
+	}
+
+	planClusterRecords(plan, clusterId, clusterDNSName, records, newRecords)
 	return nil
 }
 
-func (this *DNSTaskExecutor) findDNSManager(tx *dbs.Tx, clusterId int64) (manager dnsclients.ProviderInterface, domainId int64, domain string, clusterDNSName string, err error) {
+// 对比集群节点的新旧记录，计算需要增加、修改和删除的记录
+// 记录以线路和IP区分，TTL或者权重有变化时修改记录；新记录中TTL和权重为0表示不设置
+func planClusterRecords(plan *DNSPlan, clusterId int64, clusterDNSName string, oldRecords []*dnstypes.Record, newRecords []*dnstypes.Record) {
+	// 以前的节点记录
+	oldRecordsMap := map[string]*dnstypes.Record{} // route@value => record
+	for _, record := range oldRecords {
+		if (record.Type == dnstypes.RecordTypeA || record.Type == dnstypes.RecordTypeAAAA) && record.Name == clusterDNSName {
+			key := record.Route + "@" + record.Value
+			oldRecordsMap[key] = record
+		}
+	}
+
+	newRecordKeys := []string{}
+	for _, newRecord := range newRecords {
+		key := newRecord.Route + "@" + newRecord.Value
+		if lists.ContainsString(newRecordKeys, key) {
+			continue
+		}
+		newRecordKeys = append(newRecordKeys, key)
+
+		oldRecord, ok := oldRecordsMap[key]
+		if !ok {
+			plan.Add(clusterId, 0, newRecord)
+			continue
+		}
+
+		var ttl = oldRecord.TTL
+		if newRecord.TTL > 0 && oldRecord.TTL > 0 {
+			ttl = newRecord.TTL
+		}
+		var weight = oldRecord.Weight
+		if newRecord.Weight > 0 {
+			weight = newRecord.Weight
+		}
+		if ttl != oldRecord.TTL || weight != oldRecord.Weight {
+			plan.Update(clusterId, 0, oldRecord, &dnstypes.Record{
+				Id:       oldRecord.Id,
+				Name:     oldRecord.Name,
+				Type:     oldRecord.Type,
+				Value:    oldRecord.Value,
+				Route:    oldRecord.Route,
+				TTL:      ttl,
+				Weight:   weight,
+				Priority: oldRecord.Priority,
+			})
+		}
+	}
+
+	// 删除多余的节点解析记录
+	for key, record := range oldRecordsMap {
+		if !lists.ContainsString(newRecordKeys, key) {
+			plan.Delete(clusterId, 0, record)
+		}
+	}
+}
+
+// 查找集群中因为故障切换而被摘除记录的节点
+func (this *DNSTaskExecutor) findWithdrawnNodeIds(tx *dbs.Tx, cluster *models.NodeCluster) ([]int64, error) {
+	if !cluster.DecodeDNSFailoverConfig().IsOn {
+		return nil, nil
+	}
+	state, err := FindDNSFailoverState(tx, int64(cluster.Id))
+	if err != nil {
+		return nil, err
+	}
+	return state.WithdrawnNodeIds(), nil
+}
+
+// 为集群绑定的每个域名创建空白计划，主域名排在最前
+func (this *DNSTaskExecutor) newClusterPlans(tx *dbs.Tx, clusterId int64) (plans []*DNSPlan, clusterDNSName string, ttl int32, err error) {
 	clusterDNS, err := models.SharedNodeClusterDAO.FindClusterDNSInfo(tx, clusterId)
 	if err != nil {
-		return nil, 0, "", "", err
+		return nil, "", 0, err
 	}
 	if clusterDNS == nil || len(clusterDNS.DnsName) == 0 || clusterDNS.DnsDomainId <= 0 {
-		return nil, 0, "", "", nil
+		return nil, "", 0, nil
+	}
+
+	var domainIds = []int64{int64(clusterDNS.DnsDomainId)}
+	secondaryDomainIds, err := dnsmodels.SharedDNSClusterBindingDAO.FindAllEnabledSecondaryDomainIds(tx, clusterId)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	for _, domainId := range secondaryDomainIds {
+		if !lists.ContainsInt64(domainIds, domainId) {
+			domainIds = append(domainIds, domainId)
+		}
 	}
 
-	dnsDomain, err := dnsmodels.SharedDNSDomainDAO.FindEnabledDNSDomain(tx, int64(clusterDNS.DnsDomainId))
+	for index, domainId := range domainIds {
+		plan, err := this.newDomainPlan(tx, domainId)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		if plan == nil {
+			continue
+		}
+		plan.ClusterId = clusterId
+		plan.IsPrimary = index == 0
+		plans = append(plans, plan)
+	}
+	return plans, clusterDNS.DnsName, clusterDNS.DecodeDNSTTL(), nil
+}
+
+// 为域名创建空白计划
+// 如果域名没有设置服务商，则返回nil；如果服务商认证失败，则返回带有错误信息的计划
+func (this *DNSTaskExecutor) newDomainPlan(tx *dbs.Tx, domainId int64) (*DNSPlan, error) {
+	dnsDomain, err := dnsmodels.SharedDNSDomainDAO.FindEnabledDNSDomain(tx, domainId)
 	if err != nil {
-		return nil, 0, "", "", err
+		return nil, err
 	}
 	if dnsDomain == nil {
-		return nil, 0, "", "", nil
+		return nil, nil
 	}
 	providerId := int64(dnsDomain.ProviderId)
 	if providerId <= 0 {
-		return nil, 0, "", "", nil
+		return nil, nil
 	}
 
 	provider, err := dnsmodels.SharedDNSProviderDAO.FindEnabledDNSProvider(tx, providerId)
 	if err != nil {
-		return nil, 0, "", "", err
+		return nil, err
 	}
 	if provider == nil {
-		return nil, 0, "", "", nil
+		return nil, nil
 	}
 
-	manager = dnsclients.FindProvider(provider.Type)
+	manager := dnsclients.FindProvider(provider.Type)
 	if manager == nil {
 		remotelogs.Error("DNSTaskExecutor", "unsupported dns provider type '"+provider.Type+"'")
-		return nil, 0, "", "", nil
+		return nil, nil
 	}
+
+	var plan = NewDNSPlan(nil, domainId, dnsDomain.Name)
+	plan.ProviderId = providerId
+	plan.ProviderName = provider.Name
+
 	params, err := provider.DecodeAPIParams()
 	if err != nil {
-		return nil, 0, "", "", err
+		plan.Error = err.Error()
+		return plan, nil
 	}
 	err = manager.Auth(params)
 	if err != nil {
-		return nil, 0, "", "", err
+		plan.Error = err.Error()
+		return plan, nil
 	}
-	return manager, int64(dnsDomain.Id), dnsDomain.Name, clusterDNS.DnsName, nil
+	plan.manager = manager
+	return plan, nil
 }
@@ -1,7 +1,10 @@
 package tasks
 
 import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
 	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
 	"testing"
 )
 
@@ -15,3 +18,116 @@ func TestDNSTaskExecutor_Loop(t *testing.T) {
 	}
 	t.Log("ok")
 }
+
+func TestDNSTaskExecutor_PlanCluster(t *testing.T) {
+	dbs.NotifyReady()
+
+	executor := NewDNSTaskExecutor()
+	plans, err := executor.PlanCluster(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) == 0 {
+		t.Log("no dns settings")
+		return
+	}
+	logs.PrintAsJSON(plans, t)
+}
+
+func TestDNSTaskExecutor_PlanDomain(t *testing.T) {
+	dbs.NotifyReady()
+
+	executor := NewDNSTaskExecutor()
+	plan, err := executor.PlanDomain(nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan == nil {
+		t.Log("no dns provider")
+		return
+	}
+	logs.PrintAsJSON(plan, t)
+}
+
+func TestDNSTaskExecutor_PlanClusterDomainRemoval(t *testing.T) {
+	dbs.NotifyReady()
+
+	executor := NewDNSTaskExecutor()
+	plan, err := executor.PlanClusterDomainRemoval(nil, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan == nil {
+		t.Log("no dns settings")
+		return
+	}
+	for _, change := range plan.Changes {
+		if change.Action != DNSChangeActionDelete {
+			t.Fatal("only delete actions are expected")
+		}
+	}
+	logs.PrintAsJSON(plan, t)
+}
+
+func TestDNSTaskExecutor_applyPlans(t *testing.T) {
+	var executor = NewDNSTaskExecutor()
+
+	// 没有认证成功的服务商
+	var okPlan = NewDNSPlan(nil, 1, "example.com")
+	var failedPlan = NewDNSPlan(nil, 2, "example.org")
+	failedPlan.ProviderName = "CloudFlare"
+	failedPlan.Error = "auth failed"
+
+	err := executor.applyPlans(nil, 0, "", []*DNSPlan{okPlan, failedPlan})
+	if err == nil {
+		t.Fatal("should return error")
+	}
+	t.Log(err)
+}
+
+func TestPlanClusterRecords_Weight(t *testing.T) {
+	var weightConfig = &models.NodeClusterDNSWeightConfig{
+		Default: 10,
+		Nodes:   map[int64]int32{1: 80},
+		Routes:  map[string]int32{"telecom": 20},
+	}
+	var newRecord = func(nodeId int64, ip string, route string) *dnstypes.Record {
+		return &dnstypes.Record{
+			Name:   "edge",
+			Type:   dnstypes.RecordTypeA,
+			Value:  ip,
+			Route:  route,
+			TTL:    600,
+			Weight: weightConfig.Weight(nodeId, route),
+		}
+	}
+
+	var plan = NewDNSPlan(nil, 1, "example.com")
+	planClusterRecords(plan, 1, "edge", []*dnstypes.Record{
+		{Id: "a", Name: "edge", Type: dnstypes.RecordTypeA, Value: "192.168.1.1", Route: "default", TTL: 600, Weight: 80},
+		{Id: "b", Name: "edge", Type: dnstypes.RecordTypeA, Value: "192.168.1.2", Route: "default", TTL: 600, Weight: 50},
+		{Id: "c", Name: "edge", Type: dnstypes.RecordTypeA, Value: "192.168.1.9", Route: "default", TTL: 600},
+	}, []*dnstypes.Record{
+		newRecord(1, "192.168.1.1", "default"),
+		newRecord(2, "192.168.1.2", "default"),
+		newRecord(3, "192.168.1.3", "telecom"),
+	})
+
+	var changes = map[string]*DNSChange{}
+	for _, change := range plan.Changes {
+		changes[change.Action+"@"+change.Record.Value] = change
+	}
+	if len(plan.Changes) != 3 {
+		logs.PrintAsJSON(plan.Changes, t)
+		t.Fatal("expect 3 changes, but got", len(plan.Changes))
+	}
+	if change, ok := changes["update@192.168.1.2"]; !ok || change.Record.Weight != 10 || change.Record.Id != "b" {
+		t.Fatal("weight of 192.168.1.2 should be updated to default weight")
+	}
+	if change, ok := changes["add@192.168.1.3"]; !ok || change.Record.Weight != 20 {
+		t.Fatal("192.168.1.3 should be added with route weight")
+	}
+	if _, ok := changes["delete@192.168.1.9"]; !ok {
+		t.Fatal("192.168.1.9 should be deleted")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients/dnstypes"
+)
+
+type DNSChangeAction = string
+
+const (
+	DNSChangeActionAdd    DNSChangeAction = "add"
+	DNSChangeActionUpdate DNSChangeAction = "update"
+	DNSChangeActionDelete DNSChangeAction = "delete"
+)
+
+// DNSChange 单条记录的变更
+type DNSChange struct {
+	Action    DNSChangeAction  `json:"action"`
+	ClusterId int64            `json:"clusterId"` // 记录所属集群
+	ServerId  int64            `json:"serverId"`  // 记录所属服务，集群记录为0
+	Record    *dnstypes.Record `json:"record"`    // 新的记录，删除时为要删除的记录
+	OldRecord *dnstypes.Record `json:"oldRecord"` // 修改前的记录，只有修改时才有
+}
+
+// DNSPlan DNS同步计划
+// 先通过 DNSTaskExecutor.PlanXXX() 计算需要增加、修改和删除的记录，然后再通过 DNSTaskExecutor.ApplyPlan() 执行
+type DNSPlan struct {
+	DomainId     int64        `json:"domainId"`
+	Domain       string       `json:"domain"`
+	ProviderId   int64        `json:"providerId"`
+	ProviderName string       `json:"providerName"`
+	ClusterId    int64        `json:"clusterId"` // 计划所属集群，域名计划为0
+	IsPrimary    bool         `json:"isPrimary"` // 是否为集群的主域名
+	Changes      []*DNSChange `json:"changes"`
+	Error        string       `json:"error"` // 无法计算变更时的错误，比如服务商认证失败
+
+	manager dnsclients.ProviderInterface
+}
+
+// NewDNSPlan 获取新计划对象
+func NewDNSPlan(manager dnsclients.ProviderInterface, domainId int64, domain string) *DNSPlan {
+	return &DNSPlan{
+		DomainId: domainId,
+		Domain:   domain,
+		manager:  manager,
+	}
+}
+
+// Add 增加记录
+func (this *DNSPlan) Add(clusterId int64, serverId int64, record *dnstypes.Record) {
+	this.Changes = append(this.Changes, &DNSChange{
+		Action:    DNSChangeActionAdd,
+		ClusterId: clusterId,
+		ServerId:  serverId,
+		Record:    record,
+	})
+}
+
+// Update 修改记录
+func (this *DNSPlan) Update(clusterId int64, serverId int64, oldRecord *dnstypes.Record, record *dnstypes.Record) {
+	this.Changes = append(this.Changes, &DNSChange{
+		Action:    DNSChangeActionUpdate,
+		ClusterId: clusterId,
+		ServerId:  serverId,
+		Record:    record,
+		OldRecord: oldRecord,
+	})
+}
+
+// Delete 删除记录
+func (this *DNSPlan) Delete(clusterId int64, serverId int64, record *dnstypes.Record) {
+	this.Changes = append(this.Changes, &DNSChange{
+		Action:    DNSChangeActionDelete,
+		ClusterId: clusterId,
+		ServerId:  serverId,
+		Record:    record,
+	})
+}
+
+// Merge 合并同一个域名下的其他计划
+func (this *DNSPlan) Merge(plan *DNSPlan) {
+	if plan == nil || plan.DomainId != this.DomainId {
+		return
+	}
+	this.Changes = append(this.Changes, plan.Changes...)
+}
+
+// HasError 判断计划是否有错误
+func (this *DNSPlan) HasError() bool {
+	return len(this.Error) > 0
+}
+
+// Summary 用于错误信息中的服务商和域名描述
+func (this *DNSPlan) Summary() string {
+	if len(this.ProviderName) == 0 {
+		return this.Domain
+	}
+	return this.ProviderName + "(" + this.Domain + ")"
+}
+
+// IsEmpty 判断是否没有任何变更
+func (this *DNSPlan) IsEmpty() bool {
+	return len(this.Changes) == 0
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/TeaOSLab/EdgeCommon/pkg/systemconfigs"
 	"github.com/iwind/TeaGo/logs"
 	"github.com/iwind/TeaGo/maps"
+	timeutil "github.com/iwind/TeaGo/utils/time"
 	"time"
 )
 
@@ -112,6 +113,15 @@ func (this *HealthCheckClusterTask) loop(seconds int64) error {
 		return err
 	}
 
+	// 记录检查历史
+	this.saveResults(results, timestamp)
+
+	// DNS故障切换
+	err = NewDNSFailoverExecutor(this.clusterId).Run(results)
+	if err != nil {
+		logs.Println("[TASK][HEALTH_CHECK]dns failover: " + err.Error())
+	}
+
 	failedResults := []maps.Map{}
 	for _, result := range results {
 		if !result.IsOk {
@@ -146,3 +156,22 @@ func (this *HealthCheckClusterTask) loop(seconds int64) error {
 func (this *HealthCheckClusterTask) Config() *serverconfigs.HealthCheckConfig {
 	return this.config
 }
+
+// 记录检查历史，用于可用性统计
+func (this *HealthCheckClusterTask) saveResults(results []*HealthCheckResult, timestamp int64) {
+	var hour = timeutil.FormatTime("YmdH", timestamp)
+	for _, result := range results {
+		if result.Node == nil {
+			continue
+		}
+		var nodeId = int64(result.Node.Id)
+		err := models.SharedNodeHealthCheckHourlyStatDAO.IncreaseStat(nil, this.clusterId, nodeId, hour, result.IsOk, result.CostMs)
+		if err != nil {
+			logs.Println("[TASK][HEALTH_CHECK]save stat: " + err.Error())
+		}
+		err = models.SharedNodeHealthCheckIncidentDAO.UpdateIncident(nil, this.clusterId, nodeId, result.IsOk, result.Error, timestamp)
+		if err != nil {
+			logs.Println("[TASK][HEALTH_CHECK]save incident: " + err.Error())
+		}
+	}
+}
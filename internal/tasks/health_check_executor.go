@@ -1,21 +1,15 @@
 package tasks
 
 import (
-	"context"
-	"crypto/tls"
 	"encoding/json"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
-	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/TeaOSLab/EdgeAPI/internal/healthchecks"
 	"github.com/TeaOSLab/EdgeCommon/pkg/nodeconfigs"
 	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs"
-	"github.com/iwind/TeaGo/lists"
 	"github.com/iwind/TeaGo/logs"
 	"github.com/iwind/TeaGo/types"
-	"net"
-	"net/http"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
@@ -46,6 +40,12 @@ func (this *HealthCheckExecutor) Run() ([]*HealthCheckResult, error) {
 		return nil, err
 	}
 
+	// 探测方式
+	probe, probeConfig, err := this.initProbe([]byte(cluster.HealthCheck), healthCheckConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	results := []*HealthCheckResult{}
 	nodes, err := models.NewNodeDAO().FindAllEnabledNodesWithClusterId(nil, this.clusterId)
 	if err != nil {
@@ -117,10 +117,15 @@ func (this *HealthCheckExecutor) Run() ([]*HealthCheckResult, error) {
 				case result := <-queue:
 					for i := 1; i <= countTries; i++ {
 						before := time.Now()
-						err := this.checkNode(healthCheckConfig, result)
+						err := this.checkNode(healthCheckConfig, probe, probeConfig, result)
 						result.CostMs = time.Since(before).Seconds() * 1000
 						if err != nil {
 							result.Error = err.Error()
+
+							// 状态码不符合要求等明确的失败不需要重试
+							if healthchecks.IsNonRetryableError(err) {
+								break
+							}
 						}
 						if result.IsOk {
 							break
@@ -159,62 +164,50 @@ func (this *HealthCheckExecutor) Run() ([]*HealthCheckResult, error) {
 	return results, nil
 }
 
-// 检查单个节点
-func (this *HealthCheckExecutor) checkNode(healthCheckConfig *serverconfigs.HealthCheckConfig, result *HealthCheckResult) error {
-	// 支持IPv6
-	if utils.IsIPv6(result.NodeAddr) {
-		result.NodeAddr = "[" + result.NodeAddr + "]"
+// 初始化探测方式
+func (this *HealthCheckExecutor) initProbe(healthCheckJSON []byte, healthCheckConfig *serverconfigs.HealthCheckConfig) (healthchecks.ProbeInterface, *healthchecks.ProbeConfig, error) {
+	probeConfig, err := healthchecks.DecodeProbeConfig(healthCheckJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	probeConfig.URL = healthCheckConfig.URL
+	probeConfig.StatusCodes = healthCheckConfig.StatusCodes
+	err = probeConfig.Init()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	url := strings.ReplaceAll(healthCheckConfig.URL, "${host}", result.NodeAddr)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	probe := healthchecks.FindProbe(probeConfig.Type)
+	if probe == nil {
+		return nil, nil, errors.New("unsupported probe type '" + probeConfig.Type + "'")
+	}
+	err = probe.Init(probeConfig)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	return probe, probeConfig, nil
+}
 
+// 检查单个节点
+func (this *HealthCheckExecutor) checkNode(healthCheckConfig *serverconfigs.HealthCheckConfig, probe healthchecks.ProbeInterface, probeConfig *healthchecks.ProbeConfig, result *HealthCheckResult) error {
 	timeout := 5 * time.Second
 	if healthCheckConfig.Timeout != nil {
 		timeout = healthCheckConfig.Timeout.Duration()
 	}
 
-	client := &http.Client{
-		Timeout: timeout,
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				_, port, err := net.SplitHostPort(addr)
-				if err != nil {
-					return nil, err
-				}
-				conn, err := net.Dial(network, result.NodeAddr+":"+port)
-				if err == nil {
-					return conn, nil
-				}
-				return net.DialTimeout(network, result.NodeAddr+":"+port, timeout)
-			},
-			MaxIdleConns:          1,
-			MaxIdleConnsPerHost:   1,
-			MaxConnsPerHost:       1,
-			IdleConnTimeout:       2 * time.Minute,
-			ExpectContinueTimeout: 1 * time.Second,
-			TLSHandshakeTimeout:   0,
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
-	}
-	defer func() {
-		client.CloseIdleConnections()
-	}()
-
-	resp, err := client.Do(req)
+	before := time.Now()
+	err := probe.Check(result.NodeAddr, timeout)
 	if err != nil {
 		return err
 	}
-	_ = resp.Body.Close()
 
-	if len(healthCheckConfig.StatusCodes) > 0 && !lists.ContainsInt(healthCheckConfig.StatusCodes, resp.StatusCode) {
-		result.Error = "invalid response status code '" + strconv.Itoa(resp.StatusCode) + "'"
-		return nil
+	// 响应时间
+	if probeConfig.MaxLatencyMs > 0 {
+		costMs := time.Since(before).Milliseconds()
+		if costMs > probeConfig.MaxLatencyMs {
+			result.Error = "response is too slow: " + strconv.FormatInt(costMs, 10) + "ms > " + strconv.FormatInt(probeConfig.MaxLatencyMs, 10) + "ms"
+			return nil
+		}
 	}
 
 	result.IsOk = true
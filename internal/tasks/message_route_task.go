@@ -0,0 +1,192 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/types"
+	timeutil "github.com/iwind/TeaGo/utils/time"
+	"strings"
+	"time"
+)
+
+// 合并发送时内容中最多列出的消息数量
+const messageRouteGroupMaxLines = 20
+
+func init() {
+	dbs.OnReady(func() {
+		go NewMessageRouteTask().Run()
+	})
+}
+
+// MessageRouteTask 消息路由中的合并发送和升级任务
+type MessageRouteTask struct {
+}
+
+// NewMessageRouteTask 获取新对象
+func NewMessageRouteTask() *MessageRouteTask {
+	return &MessageRouteTask{}
+}
+
+// Run 运行
+func (this *MessageRouteTask) Run() {
+	ticker := utils.NewTicker(10 * time.Second)
+	for ticker.Wait() {
+		err := this.loop()
+		if err != nil {
+			logs.Println("[TASK][MESSAGE_ROUTE]" + err.Error())
+		}
+	}
+}
+
+// 单次运行
+func (this *MessageRouteTask) loop() error {
+	var now = time.Now().Unix()
+	err := this.sendGroups(now)
+	if err != nil {
+		return err
+	}
+	return this.escalate(now)
+}
+
+// 发送已经到期的合并
+func (this *MessageRouteTask) sendGroups(now int64) error {
+	var tx *dbs.Tx
+	groups, err := models.SharedMessageRouteGroupDAO.FindAllSendingGroups(tx, now, 100)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		// 防止多个API节点重复发送
+		ok, err := models.SharedMessageRouteGroupDAO.UpdateGroupSent(tx, int64(group.Id))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		route, err := models.SharedMessageRouteDAO.FindEnabledMessageRoute(tx, int64(group.RouteId))
+		if err != nil {
+			return err
+		}
+		if route == nil || route.IsOn != 1 {
+			continue
+		}
+
+		var messages = []*models.Message{}
+		for _, messageId := range group.DecodeMessageIds() {
+			message, err := models.SharedMessageDAO.FindEnabledMessage(tx, messageId)
+			if err != nil {
+				return err
+			}
+			if message != nil {
+				messages = append(messages, message)
+			}
+		}
+		if len(messages) == 0 {
+			continue
+		}
+
+		subject, body := composeMessageGroup(messages, int64(group.SendAt)-int64(group.CreatedAt))
+		err = models.SharedMessageTaskDAO.CreateMessageTasksWithGroupIds(tx, route.DecodeRecipientGroupIds(), subject, body)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 升级已经到期但仍未处理的消息
+func (this *MessageRouteTask) escalate(now int64) error {
+	var tx *dbs.Tx
+	escalations, err := models.SharedMessageEscalationDAO.FindAllWaitingEscalations(tx, now, 100)
+	if err != nil {
+		return err
+	}
+	for _, escalation := range escalations {
+		var escalationId = int64(escalation.Id)
+
+		message, err := models.SharedMessageDAO.FindEnabledMessage(tx, int64(escalation.MessageId))
+		if err != nil {
+			return err
+		}
+		route, err := models.SharedMessageRouteDAO.FindEnabledMessageRoute(tx, int64(escalation.RouteId))
+		if err != nil {
+			return err
+		}
+
+		var isResolved = message == nil || route == nil || route.IsOn != 1
+		if !isResolved {
+			isResolved, err = models.SharedMessageDAO.CheckMessageResolved(tx, message)
+			if err != nil {
+				return err
+			}
+		}
+		if isResolved {
+			_, err = models.SharedMessageEscalationDAO.UpdateEscalationStatus(tx, escalationId, models.MessageEscalationStatusCancelled)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 防止多个API节点重复发送
+		ok, err := models.SharedMessageEscalationDAO.UpdateEscalationStatus(tx, escalationId, models.MessageEscalationStatusEscalated)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		var subject = "[升级]" + message.Subject
+		var body = message.Body + "\n\n此消息已超过" + types.String(route.EscalateMinutes) + "分钟未处理，消息时间：" + timeutil.FormatTime("Y-m-d H:i:s", int64(message.CreatedAt))
+		err = models.SharedMessageTaskDAO.CreateMessageTasksWithGroupIds(tx, route.DecodeEscalateGroupIds(), subject, body)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 组合合并发送的标题和内容
+func composeMessageGroup(messages []*models.Message, seconds int64) (subject string, body string) {
+	if len(messages) == 1 {
+		return messages[0].Subject, messages[0].Body
+	}
+
+	var nodeIds = map[uint32]bool{}
+	for _, message := range messages {
+		if message.NodeId > 0 {
+			nodeIds[message.NodeId] = true
+		}
+	}
+
+	var duration string
+	if seconds >= 60 && seconds%60 == 0 {
+		duration = types.String(seconds/60) + "分钟"
+	} else {
+		duration = types.String(seconds) + "秒"
+	}
+
+	if len(nodeIds) > 1 {
+		subject = types.String(len(nodeIds)) + "个节点在" + duration + "内产生了" + types.String(len(messages)) + "条消息：" + messages[0].Subject + "等"
+	} else {
+		subject = duration + "内产生了" + types.String(len(messages)) + "条消息：" + messages[0].Subject + "等"
+	}
+
+	var lines = []string{}
+	for index, message := range messages {
+		if index >= messageRouteGroupMaxLines {
+			lines = append(lines, "... 其余"+types.String(len(messages)-messageRouteGroupMaxLines)+"条消息请在系统中查看")
+			break
+		}
+		lines = append(lines, timeutil.FormatTime("H:i:s", int64(message.CreatedAt))+" "+message.Subject)
+	}
+	body = strings.Join(lines, "\n")
+	return
+}
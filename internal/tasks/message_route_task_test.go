@@ -0,0 +1,54 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/types"
+	"strings"
+	"testing"
+)
+
+func TestComposeMessageGroup(t *testing.T) {
+	// 单条消息
+	{
+		subject, body := composeMessageGroup([]*models.Message{
+			{Subject: "节点\"node1\"已处于离线状态", Body: "body1", NodeId: 1},
+		}, 120)
+		if subject != "节点\"node1\"已处于离线状态" || body != "body1" {
+			t.Fatal("single message should be sent as it is")
+		}
+	}
+
+	// 多个节点
+	{
+		var messages = []*models.Message{}
+		for i := 1; i <= 25; i++ {
+			messages = append(messages, &models.Message{
+				Subject:   "节点\"node" + types.String(i) + "\"已处于离线状态",
+				NodeId:    uint32(i),
+				CreatedAt: 1600000000,
+			})
+		}
+		subject, body := composeMessageGroup(messages, 120)
+		t.Log(subject)
+		if !strings.HasPrefix(subject, "25个节点在2分钟内产生了25条消息") {
+			t.Fatal("unexpected subject: " + subject)
+		}
+		var lines = strings.Split(body, "\n")
+		if len(lines) != messageRouteGroupMaxLines+1 {
+			t.Fatal("expect", messageRouteGroupMaxLines+1, "lines, but got", len(lines))
+		}
+	}
+}
+
+func TestMessageRouteTask_loop(t *testing.T) {
+	dbs.NotifyReady()
+
+	err := NewMessageRouteTask().loop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("ok")
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/messagemedias"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/maps"
+	"time"
+)
+
+// 发送中的任务超过这个时间（秒）仍然没有结果，则认为发送的API节点已经退出，需要重新发送
+const messageSenderTaskTimeout = 600
+
+func init() {
+	dbs.OnReady(func() {
+		go NewMessageSenderTask().Run()
+	})
+}
+
+// MessageSenderTask 在API节点中直接发送消息
+// 只发送邮件、WebHook和脚本等媒介，其他媒介仍然由监控节点发送
+type MessageSenderTask struct {
+}
+
+// NewMessageSenderTask 获取新对象
+func NewMessageSenderTask() *MessageSenderTask {
+	return &MessageSenderTask{}
+}
+
+// Run 运行
+func (this *MessageSenderTask) Run() {
+	ticker := utils.NewTicker(10 * time.Second)
+	for ticker.Wait() {
+		err := this.Loop()
+		if err != nil {
+			logs.Println("[TASK][MESSAGE_SENDER]" + err.Error())
+		}
+	}
+}
+
+// Loop 单次运行
+func (this *MessageSenderTask) Loop() error {
+	var tx *dbs.Tx
+	config, err := models.SharedSysSettingDAO.ReadMessageSenderConfig(tx)
+	if err != nil {
+		return err
+	}
+	if !config.IsOn {
+		return nil
+	}
+
+	// 同一时间只有一个API节点发送
+	ok, err := models.SharedSysLockerDAO.Lock(tx, "message_sender_task", 300)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	defer func() {
+		_ = models.SharedSysLockerDAO.Unlock(tx, "message_sender_task")
+	}()
+
+	var mediaTypes = messagemedias.FindAllMediaTypeCodes()
+	err = models.SharedMessageTaskDAO.ResetTimeoutMessageTasks(tx, mediaTypes, messageSenderTaskTimeout)
+	if err != nil {
+		return err
+	}
+
+	messageTasks, err := models.SharedMessageTaskDAO.FindSendingMessageTasksWithMediaTypes(tx, mediaTypes, true, config.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, messageTask := range messageTasks {
+		err = this.send(tx, config, messageTask)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 发送单个任务
+func (this *MessageSenderTask) send(tx *dbs.Tx, config *models.MessageSenderConfig, messageTask *models.MessageTask) error {
+	var taskId = int64(messageTask.Id)
+
+	// 查找媒介
+	var instanceId = int64(messageTask.InstanceId)
+	var user = messageTask.User
+	if messageTask.RecipientId > 0 {
+		recipient, err := models.SharedMessageRecipientDAO.FindEnabledMessageRecipient(tx, int64(messageTask.RecipientId))
+		if err != nil {
+			return err
+		}
+		if recipient == nil || recipient.IsOn == 0 {
+			// 如果接收人已经删除或者禁用，则删除此消息
+			return models.SharedMessageTaskDAO.DisableMessageTask(tx, taskId)
+		}
+		instanceId = int64(recipient.InstanceId)
+		if len(user) == 0 {
+			user = recipient.User
+		}
+	}
+	instance, err := models.SharedMessageMediaInstanceDAO.FindEnabledMessageMediaInstance(tx, instanceId)
+	if err != nil {
+		return err
+	}
+	if instance == nil || instance.IsOn == 0 {
+		// 如果媒介实例已经删除或者禁用，则删除此消息
+		return models.SharedMessageTaskDAO.DisableMessageTask(tx, taskId)
+	}
+
+	// 防止被重复发送
+	ok, err := models.SharedMessageTaskDAO.ClaimMessageTask(tx, taskId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	// 发送
+	var response []byte
+	media, sendErr := messagemedias.NewMedia(instance.MediaType, []byte(instance.Params))
+	if sendErr == nil {
+		if media.RequireUser() && len(user) == 0 {
+			sendErr = errors.New("recipient user should not be empty")
+		} else {
+			response, sendErr = media.Send(user, messageTask.Subject, messageTask.Body)
+		}
+	}
+
+	var isOk = sendErr == nil
+	var errString = ""
+	if sendErr != nil {
+		errString = sendErr.Error()
+	}
+
+	// 记录发送结果
+	err = models.SharedMessageTaskLogDAO.CreateLog(tx, taskId, isOk, errString, string(response))
+	if err != nil {
+		return err
+	}
+	resultJSON, err := json.Marshal(maps.Map{
+		"isOk":     isOk,
+		"error":    errString,
+		"response": string(response),
+	})
+	if err != nil {
+		return err
+	}
+	status, nextAttemptAt := messageTaskNextAttempt(config, int(messageTask.CountAttempts)+1, isOk, time.Now().Unix())
+	return models.SharedMessageTaskDAO.UpdateMessageTaskAttempt(tx, taskId, status, nextAttemptAt, resultJSON)
+}
+
+// 根据本次发送结果计算任务的状态和下次发送时间
+// attempts 为包括本次在内已经尝试的次数
+func messageTaskNextAttempt(config *models.MessageSenderConfig, attempts int, isOk bool, now int64) (status models.MessageTaskStatus, nextAttemptAt int64) {
+	if isOk {
+		return models.MessageTaskStatusSuccess, 0
+	}
+	if attempts >= config.MaxAttempts {
+		return models.MessageTaskStatusFailed, 0
+	}
+	return models.MessageTaskStatusNone, now + config.BackoffSecondsWithAttempts(attempts)
+}
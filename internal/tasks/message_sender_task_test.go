@@ -0,0 +1,55 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/iwind/TeaGo/dbs"
+	"testing"
+)
+
+func TestMessageTaskNextAttempt(t *testing.T) {
+	var config = &models.MessageSenderConfig{
+		MaxAttempts:       3,
+		BackoffSeconds:    60,
+		MaxBackoffSeconds: 3600,
+	}
+
+	{
+		status, nextAttemptAt := messageTaskNextAttempt(config, 1, true, 1000)
+		if status != models.MessageTaskStatusSuccess || nextAttemptAt != 0 {
+			t.Fatal("should be success")
+		}
+	}
+
+	{
+		status, nextAttemptAt := messageTaskNextAttempt(config, 1, false, 1000)
+		if status != models.MessageTaskStatusNone || nextAttemptAt != 1060 {
+			t.Fatal("should retry after 60 seconds, but got:", status, nextAttemptAt)
+		}
+	}
+
+	{
+		status, nextAttemptAt := messageTaskNextAttempt(config, 2, false, 1000)
+		if status != models.MessageTaskStatusNone || nextAttemptAt != 1120 {
+			t.Fatal("should retry after 120 seconds, but got:", status, nextAttemptAt)
+		}
+	}
+
+	{
+		status, _ := messageTaskNextAttempt(config, 3, false, 1000)
+		if status != models.MessageTaskStatusFailed {
+			t.Fatal("should be failed")
+		}
+	}
+}
+
+func TestMessageSenderTask_Loop(t *testing.T) {
+	dbs.NotifyReady()
+
+	err := NewMessageSenderTask().Loop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("ok")
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/lists"
+)
+
+// 节点资源提醒状态在系统设置中的代号
+const nodeMonitorStateSettingCode = "nodeMonitorState%d"
+
+type NodeMonitorAction = string
+
+const (
+	NodeMonitorActionNone     NodeMonitorAction = ""         // 没有变化
+	NodeMonitorActionOverload NodeMonitorAction = "overload" // 持续超出限制
+	NodeMonitorActionRecover  NodeMonitorAction = "recover"  // 恢复正常
+)
+
+// NodeMonitorState 集群中节点资源的提醒状态
+type NodeMonitorState struct {
+	Nodes map[int64]map[string]int64 `json:"nodes"` // nodeId => item.param => 开始超出限制的时间
+}
+
+// NewNodeMonitorState 获取新的状态对象
+func NewNodeMonitorState() *NodeMonitorState {
+	return &NodeMonitorState{
+		Nodes: map[int64]map[string]int64{},
+	}
+}
+
+// IsOverloaded 检查某个监控项是否处于超出限制状态
+func (this *NodeMonitorState) IsOverloaded(nodeId int64, key string) bool {
+	items, ok := this.Nodes[nodeId]
+	if !ok {
+		return false
+	}
+	_, ok = items[key]
+	return ok
+}
+
+// Decide 根据最近一段时间的数据判断是否需要提醒
+// 只有在整个时间段内都超出限制时才提醒，也只有在整个时间段内都低于限制时才认为恢复，没有足够数据时保持原有状态
+func (this *NodeMonitorState) Decide(nodeId int64, key string, summary *models.NodeValueSummary, maxValue float64, minutes int, now int64) NodeMonitorAction {
+	if summary == nil || summary.CountMinutes < minutes {
+		return NodeMonitorActionNone
+	}
+
+	if !this.IsOverloaded(nodeId, key) {
+		if summary.MinValue < maxValue {
+			return NodeMonitorActionNone
+		}
+		items, ok := this.Nodes[nodeId]
+		if !ok {
+			items = map[string]int64{}
+			this.Nodes[nodeId] = items
+		}
+		items[key] = now
+		return NodeMonitorActionOverload
+	}
+
+	if summary.MaxValue >= maxValue {
+		return NodeMonitorActionNone
+	}
+	delete(this.Nodes[nodeId], key)
+	if len(this.Nodes[nodeId]) == 0 {
+		delete(this.Nodes, nodeId)
+	}
+	return NodeMonitorActionRecover
+}
+
+// Retain 只保留某些节点的状态，返回是否有变化
+func (this *NodeMonitorState) Retain(nodeIds []int64) bool {
+	var isChanged = false
+	for nodeId := range this.Nodes {
+		if !lists.ContainsInt64(nodeIds, nodeId) {
+			delete(this.Nodes, nodeId)
+			isChanged = true
+		}
+	}
+	return isChanged
+}
+
+// FindNodeMonitorState 读取集群中节点资源的提醒状态
+func FindNodeMonitorState(tx *dbs.Tx, clusterId int64) (*NodeMonitorState, error) {
+	var state = NewNodeMonitorState()
+	stateJSON, err := models.SharedSysSettingDAO.ReadSetting(tx, nodeMonitorStateSettingCode, clusterId)
+	if err != nil {
+		return nil, err
+	}
+	if len(stateJSON) == 0 {
+		return state, nil
+	}
+	err = json.Unmarshal(stateJSON, state)
+	if err != nil {
+		// 状态损坏时重新开始计算
+		return NewNodeMonitorState(), nil
+	}
+	if state.Nodes == nil {
+		state.Nodes = map[int64]map[string]int64{}
+	}
+	return state, nil
+}
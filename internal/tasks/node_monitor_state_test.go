@@ -0,0 +1,68 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"testing"
+)
+
+func TestNodeMonitorState_Decide(t *testing.T) {
+	var state = NewNodeMonitorState()
+
+	// 数据不足
+	if state.Decide(1, "cpu.usage", &models.NodeValueSummary{MinValue: 0.95, MaxValue: 0.99, CountMinutes: 3}, 0.9, 5, 100) != NodeMonitorActionNone {
+		t.Fatal("should wait for enough data")
+	}
+
+	// 期间有低于限制的值
+	if state.Decide(1, "cpu.usage", &models.NodeValueSummary{MinValue: 0.5, MaxValue: 0.99, CountMinutes: 5}, 0.9, 5, 100) != NodeMonitorActionNone {
+		t.Fatal("should not overload")
+	}
+
+	// 持续超出限制
+	if state.Decide(1, "cpu.usage", &models.NodeValueSummary{MinValue: 0.92, MaxValue: 0.99, CountMinutes: 5}, 0.9, 5, 100) != NodeMonitorActionOverload {
+		t.Fatal("should overload")
+	}
+	if !state.IsOverloaded(1, "cpu.usage") {
+		t.Fatal("should be overloaded")
+	}
+
+	// 不重复提醒
+	if state.Decide(1, "cpu.usage", &models.NodeValueSummary{MinValue: 0.92, MaxValue: 0.99, CountMinutes: 5}, 0.9, 5, 160) != NodeMonitorActionNone {
+		t.Fatal("should not notify again")
+	}
+
+	// 没有数据时保持状态
+	if state.Decide(1, "cpu.usage", nil, 0.9, 5, 220) != NodeMonitorActionNone || !state.IsOverloaded(1, "cpu.usage") {
+		t.Fatal("should keep state without data")
+	}
+
+	// 期间仍有超出限制的值
+	if state.Decide(1, "cpu.usage", &models.NodeValueSummary{MinValue: 0.2, MaxValue: 0.95, CountMinutes: 5}, 0.9, 5, 280) != NodeMonitorActionNone {
+		t.Fatal("should not recover")
+	}
+
+	// 恢复
+	if state.Decide(1, "cpu.usage", &models.NodeValueSummary{MinValue: 0.2, MaxValue: 0.5, CountMinutes: 5}, 0.9, 5, 340) != NodeMonitorActionRecover {
+		t.Fatal("should recover")
+	}
+	if len(state.Nodes) != 0 {
+		t.Fatal("state should be empty")
+	}
+}
+
+func TestNodeMonitorState_Retain(t *testing.T) {
+	var state = NewNodeMonitorState()
+	state.Decide(1, "cpu.usage", &models.NodeValueSummary{MinValue: 1, MaxValue: 1, CountMinutes: 5}, 0.9, 5, 100)
+	state.Decide(2, "cpu.usage", &models.NodeValueSummary{MinValue: 1, MaxValue: 1, CountMinutes: 5}, 0.9, 5, 100)
+	if !state.Retain([]int64{1}) {
+		t.Fatal("should be changed")
+	}
+	if state.IsOverloaded(2, "cpu.usage") || !state.IsOverloaded(1, "cpu.usage") {
+		t.Fatal("node2 should be removed")
+	}
+	if state.Retain([]int64{1}) {
+		t.Fatal("should not be changed")
+	}
+}
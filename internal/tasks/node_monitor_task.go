@@ -1,12 +1,18 @@
 package tasks
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils/numberutils"
+	"github.com/TeaOSLab/EdgeCommon/pkg/configutils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/nodeconfigs"
 	"github.com/TeaOSLab/EdgeCommon/pkg/systemconfigs"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/maps"
+	"github.com/iwind/TeaGo/types"
+	"strings"
 	"time"
 )
 
@@ -58,12 +64,17 @@ func (this *NodeMonitorTask) loop() error {
 		return err
 	}
 
+	config, err := models.SharedSysSettingDAO.ReadNodeMonitorConfig(nil)
+	if err != nil {
+		return err
+	}
+
 	clusters, err := models.SharedNodeClusterDAO.FindAllEnableClusters(nil)
 	if err != nil {
 		return err
 	}
 	for _, cluster := range clusters {
-		err := this.monitorCluster(cluster)
+		err := this.monitorCluster(cluster, config)
 		if err != nil {
 			return err
 		}
@@ -72,7 +83,7 @@ func (this *NodeMonitorTask) loop() error {
 	return nil
 }
 
-func (this *NodeMonitorTask) monitorCluster(cluster *models.NodeCluster) error {
+func (this *NodeMonitorTask) monitorCluster(cluster *models.NodeCluster, config *models.NodeMonitorConfig) error {
 	clusterId := int64(cluster.Id)
 
 	// 检查离线节点
@@ -95,10 +106,123 @@ func (this *NodeMonitorTask) monitorCluster(cluster *models.NodeCluster) error {
 		}
 	}
 
-	// TODO 检查恢复连接
+	// 检查恢复连接
+	recoveredNodes, err := models.SharedNodeDAO.FindAllRecoveredNodesWithClusterId(nil, clusterId)
+	if err != nil {
+		return err
+	}
+	for _, node := range recoveredNodes {
+		err = models.SharedNodeDAO.UpdateNodeActive(nil, int64(node.Id), true)
+		if err != nil {
+			return err
+		}
+
+		subject := "节点\"" + node.Name + "\"已经恢复在线"
+		msg := "节点\"" + node.Name + "\"已经恢复在线"
+		err = models.SharedMessageDAO.CreateNodeMessage(nil, nodeconfigs.NodeRoleNode, clusterId, int64(node.Id), models.MessageTypeNodeActive, models.MessageLevelSuccess, subject, msg, nil)
+		if err != nil {
+			return err
+		}
+	}
 
 	// 检查CPU、内存、磁盘不足节点，而且离线的节点不再重复提示
-	// TODO 需要实现
+	if config != nil && config.IsOn {
+		err = this.monitorClusterResources(clusterId, config)
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
+
+// 检查集群中节点的资源使用情况
+func (this *NodeMonitorTask) monitorClusterResources(clusterId int64, config *models.NodeMonitorConfig) error {
+	var tx *dbs.Tx
+
+	// 只检查在线的节点
+	activeNodes, err := models.SharedNodeDAO.FindAllActiveNodesWithClusterId(tx, clusterId)
+	if err != nil {
+		return err
+	}
+
+	state, err := FindNodeMonitorState(tx, clusterId)
+	if err != nil {
+		return err
+	}
+	var isChanged = false
+	var now = time.Now().Unix()
+
+	for _, item := range config.Items {
+		if !item.IsOn {
+			continue
+		}
+
+		summaries, err := models.SharedNodeValueDAO.SumNodeValuesWithClusterId(tx, nodeconfigs.NodeRoleNode, clusterId, item.Item, item.Param, config.Minutes)
+		if err != nil {
+			return err
+		}
+
+		for _, node := range activeNodes {
+			var nodeId = int64(node.Id)
+			var summary = summaries[nodeId]
+			var action = state.Decide(nodeId, item.Item+"."+item.Param, summary, item.MaxValue, config.Minutes, now)
+			switch action {
+			case NodeMonitorActionOverload:
+				subject := "节点\"" + node.Name + "\"" + item.ItemName() + "持续超出限制"
+				msg := "节点\"" + node.Name + "\"" + item.ItemName() + "持续" + types.String(config.Minutes) + "分钟超出限制\n限制：" + this.formatValue(item.Param, item.MaxValue) + "\n期间最小值：" + this.formatValue(item.Param, summary.MinValue)
+				err = models.SharedMessageDAO.CreateNodeMessage(tx, nodeconfigs.NodeRoleNode, clusterId, nodeId, models.MessageTypeNodeResourceOverload, models.MessageLevelWarning, subject, msg, this.paramsJSON(item, summary.MinValue))
+				if err != nil {
+					return err
+				}
+				isChanged = true
+			case NodeMonitorActionRecover:
+				subject := "节点\"" + node.Name + "\"" + item.ItemName() + "已恢复正常"
+				msg := "节点\"" + node.Name + "\"" + item.ItemName() + "已恢复正常\n限制：" + this.formatValue(item.Param, item.MaxValue) + "\n期间最大值：" + this.formatValue(item.Param, summary.MaxValue)
+				err = models.SharedMessageDAO.CreateNodeMessage(tx, nodeconfigs.NodeRoleNode, clusterId, nodeId, models.MessageTypeNodeResourceRecovered, models.MessageLevelSuccess, subject, msg, this.paramsJSON(item, summary.MaxValue))
+				if err != nil {
+					return err
+				}
+				isChanged = true
+			}
+		}
+	}
+
+	// 清除已经删除的节点的状态
+	if len(state.Nodes) > 0 {
+		nodeIds, err := models.SharedNodeDAO.FindAllNodeIdsMatch(tx, clusterId, false, configutils.BoolStateAll)
+		if err != nil {
+			return err
+		}
+		if state.Retain(nodeIds) {
+			isChanged = true
+		}
+	}
+
+	if !isChanged {
+		return nil
+	}
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return models.SharedSysSettingDAO.UpdateSetting(tx, nodeMonitorStateSettingCode, stateJSON, clusterId)
+}
+
+// 格式化数值，使用率显示为百分比
+func (this *NodeMonitorTask) formatValue(param string, value float64) string {
+	if strings.HasSuffix(strings.ToLower(param), "usage") {
+		return fmt.Sprintf("%.2f%%", value*100)
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// 消息参数
+func (this *NodeMonitorTask) paramsJSON(item *models.NodeMonitorItemConfig, value float64) []byte {
+	return maps.Map{
+		"item":     item.Item,
+		"param":    item.Param,
+		"maxValue": item.MaxValue,
+		"value":    value,
+	}.AsJSON()
+}
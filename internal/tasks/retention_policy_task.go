@@ -0,0 +1,207 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models/acme"
+	dnsmodels "github.com/TeaOSLab/EdgeAPI/internal/db/models/dns"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models/nameservers"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models/stats"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/types"
+	timeutil "github.com/iwind/TeaGo/utils/time"
+	"time"
+)
+
+// 数据表中时间字段的格式
+const (
+	RetentionColumnFormatDay   = "Ymd"  // 日期，比如20211010
+	RetentionColumnFormatHour  = "YmdH" // 小时，比如2021101008
+	RetentionColumnFormatMonth = "Ym"   // 月份，比如202110
+	RetentionColumnFormatUnix  = "unix" // 时间戳
+)
+
+func init() {
+	dbs.OnReady(func() {
+		go NewRetentionPolicyTask().Run()
+	})
+}
+
+// RetentionTable 需要按保留策略清理的数据表
+type RetentionTable struct {
+	Family models.RetentionFamily // 所属分类
+	DAO    dbs.DAOWrapper         // 数据表对应的DAO
+	Column string                 // 时间字段
+	Format string                 // 时间字段格式
+	Where  string                 // 额外的条件
+}
+
+// FindAllRetentionTables 所有需要按保留策略清理的数据表
+func FindAllRetentionTables() []*RetentionTable {
+	return []*RetentionTable{
+		// 消息
+		{Family: models.RetentionFamilyMessages, DAO: models.SharedMessageDAO, Column: "day", Format: RetentionColumnFormatDay},
+		{Family: models.RetentionFamilyMessageTaskLogs, DAO: models.SharedMessageTaskLogDAO, Column: "createdAt", Format: RetentionColumnFormatUnix},
+
+		// 消息路由，未发送的合并和未处理的升级不能删除
+		{Family: models.RetentionFamilyMessageRoutes, DAO: models.SharedMessageRouteGroupDAO, Column: "createdAt", Format: RetentionColumnFormatUnix, Where: "isSent=1"},
+		{Family: models.RetentionFamilyMessageRoutes, DAO: models.SharedMessageEscalationDAO, Column: "createdAt", Format: RetentionColumnFormatUnix, Where: "status!=" + types.String(models.MessageEscalationStatusWaiting)},
+
+		// 节点日志
+		{Family: models.RetentionFamilyNodeLogs, DAO: models.SharedNodeLogDAO, Column: "day", Format: RetentionColumnFormatDay},
+
+		// 指标
+		{Family: models.RetentionFamilyMetricStats, DAO: models.SharedMetricStatDAO, Column: "createdDay", Format: RetentionColumnFormatDay},
+
+		// 按小时统计
+		{Family: models.RetentionFamilyHourlyStats, DAO: stats.SharedTrafficHourlyStatDAO, Column: "hour", Format: RetentionColumnFormatHour},
+		{Family: models.RetentionFamilyHourlyStats, DAO: stats.SharedNodeTrafficHourlyStatDAO, Column: "hour", Format: RetentionColumnFormatHour},
+		{Family: models.RetentionFamilyHourlyStats, DAO: stats.SharedServerDomainHourlyStatDAO, Column: "hour", Format: RetentionColumnFormatHour},
+		{Family: models.RetentionFamilyHourlyStats, DAO: stats.SharedServerHTTPFirewallHourlyStatDAO, Column: "hour", Format: RetentionColumnFormatHour},
+		{Family: models.RetentionFamilyHourlyStats, DAO: nameservers.SharedNSRecordHourlyStatDAO, Column: "hour", Format: RetentionColumnFormatHour},
+
+		// 按天统计
+		{Family: models.RetentionFamilyDailyStats, DAO: models.SharedServerDailyStatDAO, Column: "day", Format: RetentionColumnFormatDay},
+		{Family: models.RetentionFamilyDailyStats, DAO: stats.SharedTrafficDailyStatDAO, Column: "day", Format: RetentionColumnFormatDay},
+		{Family: models.RetentionFamilyDailyStats, DAO: stats.SharedNodeTrafficDailyStatDAO, Column: "day", Format: RetentionColumnFormatDay},
+		{Family: models.RetentionFamilyDailyStats, DAO: stats.SharedNodeClusterTrafficDailyStatDAO, Column: "day", Format: RetentionColumnFormatDay},
+		{Family: models.RetentionFamilyDailyStats, DAO: stats.SharedServerHTTPFirewallDailyStatDAO, Column: "day", Format: RetentionColumnFormatDay},
+
+		// 按月统计
+		{Family: models.RetentionFamilyMonthlyStats, DAO: stats.SharedServerRegionCountryMonthlyStatDAO, Column: "month", Format: RetentionColumnFormatMonth},
+		{Family: models.RetentionFamilyMonthlyStats, DAO: stats.SharedServerRegionProvinceMonthlyStatDAO, Column: "month", Format: RetentionColumnFormatMonth},
+		{Family: models.RetentionFamilyMonthlyStats, DAO: stats.SharedServerRegionCityMonthlyStatDAO, Column: "month", Format: RetentionColumnFormatMonth},
+		{Family: models.RetentionFamilyMonthlyStats, DAO: stats.SharedServerRegionProviderMonthlyStatDAO, Column: "month", Format: RetentionColumnFormatMonth},
+		{Family: models.RetentionFamilyMonthlyStats, DAO: stats.SharedServerClientBrowserMonthlyStatDAO, Column: "month", Format: RetentionColumnFormatMonth},
+		{Family: models.RetentionFamilyMonthlyStats, DAO: stats.SharedServerClientSystemMonthlyStatDAO, Column: "month", Format: RetentionColumnFormatMonth},
+
+		// 健康检查，未结束的故障不能删除
+		{Family: models.RetentionFamilyHealthChecks, DAO: models.SharedNodeHealthCheckHourlyStatDAO, Column: "hour", Format: RetentionColumnFormatHour},
+		{Family: models.RetentionFamilyHealthChecks, DAO: models.SharedNodeHealthCheckIncidentDAO, Column: "endedAt", Format: RetentionColumnFormatUnix, Where: "endedAt>0"},
+
+		// ACME认证信息
+		{Family: models.RetentionFamilyACMEAuths, DAO: acme.SharedACMEAuthenticationDAO, Column: "createdAt", Format: RetentionColumnFormatUnix},
+
+		// 任务日志
+		{Family: models.RetentionFamilyACMETaskLogs, DAO: acme.SharedACMETaskLogDAO, Column: "createdAt", Format: RetentionColumnFormatUnix},
+		{Family: models.RetentionFamilyDNSTaskLogs, DAO: dnsmodels.SharedDNSTaskLogDAO, Column: "day", Format: RetentionColumnFormatDay},
+	}
+}
+
+// RetentionPolicyTask 按照数据保留策略清理过期数据
+type RetentionPolicyTask struct {
+}
+
+// NewRetentionPolicyTask 获取新对象
+func NewRetentionPolicyTask() *RetentionPolicyTask {
+	return &RetentionPolicyTask{}
+}
+
+// Run 运行
+func (this *RetentionPolicyTask) Run() {
+	ticker := utils.NewTicker(6 * time.Hour)
+	for ticker.Wait() {
+		err := this.Loop()
+		if err != nil {
+			logs.Println("[TASK][RETENTION_POLICY]" + err.Error())
+		}
+	}
+}
+
+// Loop 单次运行
+func (this *RetentionPolicyTask) Loop() error {
+	var tx *dbs.Tx
+
+	// 同一时间只有一个API节点清理
+	ok, err := models.SharedSysLockerDAO.Lock(tx, "retention_policy_task", 3600)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	defer func() {
+		_ = models.SharedSysLockerDAO.Unlock(tx, "retention_policy_task")
+	}()
+
+	config, err := models.SharedSysSettingDAO.ReadRetentionPolicyConfig(tx)
+	if err != nil {
+		return err
+	}
+
+	var report = &models.RetentionPolicyReport{
+		StartedAt: time.Now().Unix(),
+	}
+	var now = time.Now()
+	for _, table := range FindAllRetentionTables() {
+		var days = config.FindDays(table.Family)
+		if days <= 0 {
+			continue
+		}
+
+		var item = &models.RetentionPolicyReportItem{
+			Family: table.Family,
+			Table:  table.DAO.Object().Table,
+			Days:   days,
+		}
+		item.CountDeleted, item.IsFinished, err = this.cleanTable(tx, table, RetentionExpireValue(table.Format, days, now), config.BatchSize, config.MaxBatches)
+		if err != nil {
+			// 单张表出错不影响其他表的清理
+			item.Error = err.Error()
+			logs.Println("[TASK][RETENTION_POLICY]clean table '" + item.Table + "' failed: " + err.Error())
+		}
+		report.Items = append(report.Items, item)
+	}
+	report.FinishedAt = time.Now().Unix()
+
+	if report.CountDeleted() > 0 {
+		logs.Println("[TASK][RETENTION_POLICY]deleted " + types.String(report.CountDeleted()) + " expired records")
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return models.SharedSysSettingDAO.UpdateSetting(tx, models.SettingCodeRetentionPolicyReport, reportJSON)
+}
+
+// 分批删除单张表中的过期数据
+func (this *RetentionPolicyTask) cleanTable(tx *dbs.Tx, table *RetentionTable, expireValue interface{}, batchSize int64, maxBatches int) (countDeleted int64, isFinished bool, err error) {
+	for i := 0; i < maxBatches; i++ {
+		query := table.DAO.Object().Query(tx).
+			Lt(table.Column, expireValue)
+		if len(table.Where) > 0 {
+			query.Where(table.Where)
+		}
+		rows, err := query.
+			Limit(batchSize).
+			Delete()
+		if err != nil {
+			return countDeleted, false, err
+		}
+		countDeleted += rows
+		if rows < batchSize {
+			return countDeleted, true, nil
+		}
+	}
+	return countDeleted, false, nil
+}
+
+// RetentionExpireValue 计算过期数据时间字段的临界值，小于此值的数据会被删除
+func RetentionExpireValue(format string, days int, now time.Time) interface{} {
+	var expireTime = now.AddDate(0, 0, -days)
+	switch format {
+	case RetentionColumnFormatDay:
+		return timeutil.Format("Ymd", expireTime)
+	case RetentionColumnFormatHour:
+		return timeutil.Format("Ymd", expireTime) + "00"
+	case RetentionColumnFormatMonth:
+		return timeutil.Format("Ym", expireTime)
+	default:
+		return expireTime.Unix()
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 Liuxiangchao iwind.liu@gmail.com. All rights reserved.
+
+package tasks
+
+import (
+	"github.com/iwind/TeaGo/dbs"
+	"testing"
+	"time"
+)
+
+func TestRetentionExpireValue(t *testing.T) {
+	var now = time.Date(2021, 10, 15, 8, 30, 0, 0, time.Local)
+	for format, expected := range map[string]interface{}{
+		RetentionColumnFormatDay:   "20210915",
+		RetentionColumnFormatHour:  "2021091500",
+		RetentionColumnFormatMonth: "202109",
+		RetentionColumnFormatUnix:  time.Date(2021, 9, 15, 8, 30, 0, 0, time.Local).Unix(),
+	} {
+		var value = RetentionExpireValue(format, 30, now)
+		if value != expected {
+			t.Fatal("format:", format, "expected:", expected, "but got:", value)
+		}
+	}
+}
+
+func TestRetentionPolicyTask_Loop(t *testing.T) {
+	dbs.NotifyReady()
+
+	err := NewRetentionPolicyTask().Loop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("ok")
+}